@@ -0,0 +1,1780 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/internal/version"
+	"mantle-claim-crossing/notify"
+	"mantle-claim-crossing/server"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/spf13/cobra"
+)
+
+// defaultAPIListenAddr is where `serve` listens without --addr or API_LISTEN_ADDR
+const defaultAPIListenAddr = ":8080"
+
+// txOptions are the flags of the commands that send prove and finalize transactions
+type txOptions struct {
+	from      string
+	gasLimit  uint64
+	legacyGas bool
+	yes       bool
+	maxCost   float64
+	force     bool
+}
+
+// addFlags registers the transaction flags on cmd; costGuard adds the finalize cost
+// guard's --max-cost and --force
+func (o *txOptions) addFlags(cmd *cobra.Command, costGuard bool) {
+	flags := cmd.Flags()
+	flags.StringVar(&o.from, "from", "", "sign with this configured wallet; with --offline, estimate gas from this address (default SIMULATION_FROM, else the wallet, else the withdrawal's L2 sender)")
+	flags.Uint64Var(&o.gasLimit, "gas-limit", 0, "use a fixed gas limit instead of estimating")
+	flags.BoolVar(&o.legacyGas, "legacy-gas", false, "send legacy transactions priced with eth_gasPrice (same as LEGACY_GAS=true)")
+	flags.BoolVarP(&o.yes, "yes", "y", false, "send without asking for confirmation (also skipped when CI=true)")
+	if costGuard {
+		flags.Float64Var(&o.maxCost, "max-cost", 0, "skip finalizing if the L1 cost exceeds this many USD (overrides MAX_FINALIZE_COST_USD)")
+		flags.BoolVar(&o.force, "force", false, "ignore MAX_FINALIZE_COST_USD, MIN_VALUE_RATIO and --max-cost")
+	}
+}
+
+// apply sets the gas and cost flags on messenger and returns the --from wallet, if any
+func (o *txOptions) apply(messenger *crosschain.CrossChainMessenger) (common.Address, error) {
+	if o.gasLimit > 0 {
+		messenger.Gas.GasLimit = o.gasLimit
+	}
+	// --max-cost replaces MAX_FINALIZE_COST_USD; --force turns the finalize cost guard off
+	if o.maxCost < 0 {
+		return common.Address{}, fmt.Errorf("invalid --max-cost %v: must be a positive USD amount", o.maxCost)
+	}
+	if o.maxCost > 0 {
+		messenger.Cost.MaxFinalizeCostUSD = o.maxCost
+	}
+	if o.force {
+		messenger.Cost.MaxFinalizeCostUSD = 0
+		messenger.Cost.MinValueRatio = 0
+	}
+	if o.legacyGas {
+		messenger.Gas.Legacy = true
+	}
+
+	if o.from == "" {
+		return common.Address{}, nil
+	}
+	return crosschain.ParseSimulationFrom("--from", o.from)
+}
+
+// submitOptions are the SubmitOptions for a transaction signed by from; prove and
+// finalize ask before signing unless --yes, -y or CI=true
+func (o *txOptions) submitOptions(from common.Address) crosschain.SubmitOptions {
+	return crosschain.SubmitOptions{From: from, Confirm: confirmPrompt(o.yes)}
+}
+
+// txHashArg accepts a single <tx_hash>
+func txHashArg(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	return crosschain.ValidateTxHash(args[0])
+}
+
+// messageArgs accepts <tx_hash> [message_index]
+func messageArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.RangeArgs(1, 2)(cmd, args); err != nil {
+		return err
+	}
+	_, _, err := parseMessageArgs(args)
+	return err
+}
+
+// parseMessageArgs returns the transaction hash and the message index (default 0) in
+// arguments accepted by messageArgs
+func parseMessageArgs(args []string) (string, int, error) {
+	if err := crosschain.ValidateTxHash(args[0]); err != nil {
+		return "", 0, err
+	}
+	messageIndex := 0
+	if len(args) > 1 {
+		var err error
+		if messageIndex, err = crosschain.ParseMessageIndex(args[1]); err != nil {
+			return "", 0, err
+		}
+	}
+	return args[0], messageIndex, nil
+}
+
+// batchArgs accepts transaction hashes, each argument holding one or more separated
+// by commas
+func batchArgs(cmd *cobra.Command, args []string) error {
+	if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	txHashes := splitHashList(args)
+	if len(txHashes) == 0 {
+		return fmt.Errorf("no transaction hashes given")
+	}
+	return crosschain.ValidateTxHashes(txHashes)
+}
+
+// splitHashList returns the non-empty comma-separated hashes in args
+func splitHashList(args []string) []string {
+	var txHashes []string
+	for _, h := range strings.Split(strings.Join(args, ","), ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			txHashes = append(txHashes, h)
+		}
+	}
+	return txHashes
+}
+
+// durationValue is a time.Duration flag that rejects negative values and, unlike the
+// stock one, says what a valid duration looks like
+type durationValue time.Duration
+
+func (d *durationValue) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil || v < 0 {
+		return fmt.Errorf("expected a duration like 30s, 10m or 24h")
+	}
+	*d = durationValue(v)
+	return nil
+}
+
+// String prints zero as "0", which the help output treats as no default
+func (d *durationValue) String() string {
+	if *d == 0 {
+		return "0"
+	}
+	return time.Duration(*d).String()
+}
+
+func (d *durationValue) Type() string {
+	return "duration"
+}
+
+// messengerRun is the body of a command that works on a messenger
+type messengerRun func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error
+
+// withMessenger adapts run into a RunE that first creates the messenger from the
+// environment and afterwards reports the outcome. skipStartupChecks is for commands
+// that only read L2 and must work while L1 is down.
+func (o *rootOptions) withMessenger(skipStartupChecks bool, run messengerRun) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cfg, err := crosschain.MessengerConfigFromEnv(os.Getenv("L1_RPC"), os.Getenv("L2_RPC"))
+		if err != nil {
+			return fmt.Errorf("failed to create messenger: %w", err)
+		}
+		if skipStartupChecks {
+			cfg.SkipStartupChecks = true
+		}
+		if o.progressJSON {
+			cfg.Progress = printProgress
+		}
+		messenger, err := crosschain.NewCrossChainMessenger(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create messenger: %w", err)
+		}
+		messenger.DebugProof = o.debugProof
+		return report(run(cmd, messenger, args), o.output != "text")
+	}
+}
+
+// printProgress writes a progress event to stderr as a JSON line (--progress-json); the
+// human-readable rendering is the log output the messenger writes anyway
+func printProgress(stage string, detail map[string]any) {
+	_ = json.NewEncoder(os.Stderr).Encode(crosschain.ProgressEvent{Stage: stage, Detail: detail, Time: time.Now()})
+}
+
+// report prints the outcome of a command and returns the error that sets the exit code.
+// Work someone else already did counts as success; quiet leaves out the success line so
+// JSON and CSV output stay parseable.
+func report(err error, quiet bool) error {
+	if crosschain.IsExternallyCompleted(err) {
+		fmt.Printf("\n🤝 %v — nothing left to do\n", err)
+		err = nil
+	}
+	if errors.Is(err, errNotConfirmed) {
+		fmt.Println("\n🚫 Aborted; nothing was sent")
+		return err
+	}
+	if err != nil {
+		if reason, ok := crosschain.RevertReason(err); ok {
+			fmt.Printf("\n⛔ %s\n", reason)
+		}
+		return fmt.Errorf("operation failed: %w", err)
+	}
+	if !quiet {
+		fmt.Println("\n✅ Operation completed successfully")
+	}
+	return nil
+}
+
+// newWithdrawalCmds returns the commands that check, prove and finalize withdrawals
+func newWithdrawalCmds(opts *rootOptions) []*cobra.Command {
+	return []*cobra.Command{
+		newCheckCmd(opts),
+		newCheckHashCmd(opts),
+		newFindTxCmd(opts),
+		newProveCmd(opts),
+		newFinalizeCmd(opts),
+		newReplayCmd(opts),
+		newCancelPendingCmd(opts),
+		newFullCmd(opts),
+		newWaitCmd(opts),
+		newRecommendCmd(opts),
+		newBatchCmd(opts, "prove"),
+		newBatchCmd(opts, "finalize"),
+		newCheckBatchCmd(opts),
+		newVerifyProofCmd(opts),
+		newHashCmd(opts),
+		newTimelineCmd(opts),
+		newETACmd(opts),
+		newProofCmd(opts),
+		newExportBundleCmd(opts),
+		newOutputsCmd(opts),
+		newOracleCmd(opts),
+		newDoctorCmd(opts),
+		newServeCmd(opts),
+	}
+}
+
+func newCheckCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:     "check <tx_hash> [message_index]",
+		Aliases: []string{"status"},
+		Short:   "Check message status",
+		Args:    messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			return messenger.CheckMessageStatus(cmd.Context(), txHash, messageIndex)
+		}),
+	}
+}
+
+func newCheckHashCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check-hash <withdrawal_hash>",
+		Short: "Check a withdrawal by its withdrawal hash alone, without the L2 transaction; see --output json",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return err
+			}
+			return crosschain.ValidateWithdrawalHash(args[0])
+		},
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			return runCheckHash(cmd.Context(), messenger, common.HexToHash(args[0]), format == "json")
+		}),
+	}
+}
+
+// findTxOptions are the flags of the find-tx command
+type findTxOptions struct {
+	fromBlock uint64
+	toBlock   uint64
+	last      time.Duration
+	senders   []string
+	check     bool
+}
+
+func newFindTxCmd(opts *rootOptions) *cobra.Command {
+	var o findTxOptions
+	cmd := &cobra.Command{
+		Use:   "find-tx <withdrawal_hash>",
+		Short: "Find the L2 transaction that sent a withdrawal from its withdrawal hash; see --last and --output json",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return err
+			}
+			return crosschain.ValidateWithdrawalHash(args[0])
+		},
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			if flags.Changed("from-block") && flags.Changed("last") {
+				return fmt.Errorf("--from-block and --last can't be used together")
+			}
+			return runFindTx(cmd.Context(), messenger, common.HexToHash(args[0]), o, flags.Changed("from-block"), flags.Changed("to-block"), format == "json")
+		}),
+	}
+	flags := cmd.Flags()
+	flags.Uint64Var(&o.fromBlock, "from-block", 0, "first L2 block to scan")
+	flags.Uint64Var(&o.toBlock, "to-block", 0, "last L2 block to scan (default: latest)")
+	o.last = crosschain.DefaultFindTxLookback
+	flags.Var((*durationValue)(&o.last), "last", "scan the L2 blocks of this last period, at the oracle's L2 block time")
+	flags.StringSliceVar(&o.senders, "sender", nil, "only scan withdrawals from these L2 senders (faster; the sender is an indexed topic)")
+	flags.BoolVar(&o.check, "check", false, "run the full status check on the transaction once found")
+	return cmd
+}
+
+func newProveCmd(opts *rootOptions) *cobra.Command {
+	var tx txOptions
+	var offline, compactProof bool
+	var proofFile, bundle, l2BlockHash string
+	cmd := &cobra.Command{
+		Use:   "prove <tx_hash> [message_index]",
+		Short: "Prove message",
+		Args:  messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			ctx := cmd.Context()
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			from, err := tx.apply(messenger)
+			if err != nil {
+				return err
+			}
+			messenger.CompactProofs = compactProof
+			if offline {
+				return runOffline(messenger, from, func() (*crosschain.UnsignedTx, error) {
+					return messenger.BuildProveCalldata(ctx, txHash, messageIndex)
+				})
+			}
+			if proofFile != "" && bundle != "" {
+				return errors.New("--proof-file and --bundle are mutually exclusive")
+			}
+			if proofFile != "" {
+				_, err = messenger.ProveMessageWithProof(ctx, txHash, messageIndex, proofFile, tx.submitOptions(from))
+				return err
+			}
+			if bundle != "" {
+				_, err = messenger.ProveMessageWithBundle(ctx, txHash, messageIndex, bundle, tx.submitOptions(from))
+				return err
+			}
+
+			// --l2-block-hash replaces the L2 RPC's block hash in the output root proof
+			submit := tx.submitOptions(from)
+			if l2BlockHash != "" {
+				b, err := hexutil.Decode(l2BlockHash)
+				if err != nil || len(b) != common.HashLength {
+					return fmt.Errorf("invalid --l2-block-hash %q: must be a 32-byte hex hash", l2BlockHash)
+				}
+				submit.L2BlockHash = common.BytesToHash(b)
+			}
+			_, err = messenger.ProveMessage(ctx, txHash, messageIndex, submit)
+			return err
+		}),
+	}
+	tx.addFlags(cmd, false)
+	flags := cmd.Flags()
+	flags.BoolVar(&offline, "offline", false, "print the unsigned calldata instead of sending (no signer needed)")
+	flags.StringVar(&proofFile, "proof-file", "", "submit the proof in this file (written by proof --out) instead of generating one")
+	flags.StringVar(&bundle, "bundle", "", "submit the proof in this claim bundle (written by export-bundle) instead of generating one")
+	flags.StringVar(&l2BlockHash, "l2-block-hash", "", "use this as the output's L2 block hash instead of the RPC's (manual recovery)")
+	addCompactProofFlag(cmd, &compactProof)
+	return cmd
+}
+
+func newFinalizeCmd(opts *rootOptions) *cobra.Command {
+	var tx txOptions
+	var offline bool
+	cmd := &cobra.Command{
+		Use:     "finalize <tx_hash> [message_index]",
+		Aliases: []string{"claim"},
+		Short:   "Finalize message",
+		Args:    messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			ctx := cmd.Context()
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			from, err := tx.apply(messenger)
+			if err != nil {
+				return err
+			}
+			if offline {
+				return runOffline(messenger, from, func() (*crosschain.UnsignedTx, error) {
+					return messenger.BuildFinalizeCalldata(ctx, txHash, messageIndex)
+				})
+			}
+			_, err = messenger.FinalizeMessage(ctx, txHash, messageIndex, tx.submitOptions(from))
+			return err
+		}),
+	}
+	tx.addFlags(cmd, true)
+	cmd.Flags().BoolVar(&offline, "offline", false, "print the unsigned calldata instead of sending (no signer needed)")
+	return cmd
+}
+
+func newReplayCmd(opts *rootOptions) *cobra.Command {
+	var tx txOptions
+	var offline bool
+	cmd := &cobra.Command{
+		Use:   "replay <tx_hash> [message_index]",
+		Short: "Replay the message of a finalized withdrawal whose relay failed on L1",
+		Args:  messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			ctx := cmd.Context()
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			from, err := tx.apply(messenger)
+			if err != nil {
+				return err
+			}
+			if offline {
+				return runOffline(messenger, from, func() (*crosschain.UnsignedTx, error) {
+					return messenger.BuildReplayCalldata(ctx, txHash, messageIndex)
+				})
+			}
+			_, err = messenger.ReplayMessage(ctx, txHash, messageIndex, tx.submitOptions(from))
+			return err
+		}),
+	}
+	tx.addFlags(cmd, false)
+	cmd.Flags().BoolVar(&offline, "offline", false, "print the unsigned calldata instead of sending (no signer needed)")
+	return cmd
+}
+
+func newCancelPendingCmd(opts *rootOptions) *cobra.Command {
+	var tx txOptions
+	cmd := &cobra.Command{
+		Use:   "cancel-pending",
+		Short: "Unblock a wallet by replacing its oldest pending L1 transaction with a 0-value self-transfer",
+		Args:  cobra.NoArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			from, err := tx.apply(messenger)
+			if err != nil {
+				return err
+			}
+			_, err = messenger.CancelPending(cmd.Context(), tx.submitOptions(from))
+			return err
+		}),
+	}
+	// A cancellation is always a 21000-gas transfer, so there is no --gas-limit
+	flags := cmd.Flags()
+	flags.StringVar(&tx.from, "from", "", "cancel for this configured wallet (default: the default signer)")
+	flags.BoolVar(&tx.legacyGas, "legacy-gas", false, "send a legacy transaction priced with eth_gasPrice (same as LEGACY_GAS=true)")
+	flags.BoolVarP(&tx.yes, "yes", "y", false, "send without asking for confirmation (also skipped when CI=true)")
+	return cmd
+}
+
+func newFullCmd(opts *rootOptions) *cobra.Command {
+	var tx txOptions
+	var wait waitOptions
+	cmd := &cobra.Command{
+		Use:   "full <tx_hash> [message_index]",
+		Short: "Full claim process: prove if needed, wait out the challenge period, finalize",
+		Args:  messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			from, err := tx.apply(messenger)
+			if err != nil {
+				return err
+			}
+			return runFull(cmd.Context(), messenger, txHash, messageIndex, tx.submitOptions(from), wait)
+		}),
+	}
+	tx.addFlags(cmd, true)
+	wait.addFlags(cmd, false)
+	return cmd
+}
+
+func newWaitCmd(opts *rootOptions) *cobra.Command {
+	var wait waitOptions
+	cmd := &cobra.Command{
+		Use:   "wait <tx_hash> [message_index]",
+		Short: "Block until the withdrawal reaches --until status",
+		Args:  messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			return runWait(cmd.Context(), messenger, txHash, messageIndex, wait)
+		}),
+	}
+	wait.addFlags(cmd, true)
+	return cmd
+}
+
+func newRecommendCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:     "recommend <tx_hash>",
+		Aliases: []string{"next", "can-finalize", "ready"},
+		Short:   "Print the next recommended action and the command to run",
+		Args:    txHashArg,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			return printRecommendation(cmd.Context(), messenger, args[0])
+		}),
+	}
+}
+
+// newBatchCmd returns prove-batch or finalize-batch
+func newBatchCmd(opts *rootOptions, operation string) *cobra.Command {
+	var tx txOptions
+	var compactProof bool
+	cmd := &cobra.Command{
+		Use:   operation + "-batch <tx_hash>[,<tx_hash>...] [tx_hash...]",
+		Short: strings.ToUpper(operation[:1]) + operation[1:] + " several withdrawals in one go",
+		Args:  batchArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			from, err := tx.apply(messenger)
+			if err != nil {
+				return err
+			}
+			messenger.CompactProofs = compactProof
+			batch := messenger.BatchProve
+			if operation == "finalize" {
+				batch = messenger.BatchFinalize
+			}
+			return runBatch(cmd.Context(), operation, splitHashList(args), from, batch)
+		}),
+	}
+	tx.addFlags(cmd, operation == "finalize")
+	if operation == "prove" {
+		addCompactProofFlag(cmd, &compactProof)
+	}
+	return cmd
+}
+
+func newCheckBatchCmd(opts *rootOptions) *cobra.Command {
+	var concurrency int
+	cmd := &cobra.Command{
+		Use:   "check-batch <file>",
+		Short: "Check the status of the withdrawals listed in a file, one hash per line (- reads stdin); see --output json|csv",
+		Args:  cobra.ExactArgs(1),
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json", "csv")
+			if err != nil {
+				return err
+			}
+			if concurrency <= 0 {
+				return fmt.Errorf("invalid --concurrency %d: must be a positive integer", concurrency)
+			}
+			txHashes, err := readHashFile(args[0])
+			if err != nil {
+				return err
+			}
+			return runCheckBatch(cmd.Context(), messenger, txHashes, concurrency, format)
+		}),
+	}
+	cmd.Flags().IntVar(&concurrency, "concurrency", crosschain.DefaultStatusConcurrency, "withdrawals checked at once")
+	return cmd
+}
+
+// readHashFile reads the transaction hashes in path, or stdin for "-", one per line.
+// Blank lines and lines starting with # are skipped; a malformed hash fails with its
+// line number.
+func readHashFile(path string) ([]string, error) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open hash file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var txHashes []string
+	scanner := bufio.NewScanner(in)
+	for line := 1; scanner.Scan(); line++ {
+		txHash := strings.TrimSpace(scanner.Text())
+		if txHash == "" || strings.HasPrefix(txHash, "#") {
+			continue
+		}
+		if err := crosschain.ValidateTxHash(txHash); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		txHashes = append(txHashes, txHash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hash file: %w", err)
+	}
+	if len(txHashes) == 0 {
+		return nil, fmt.Errorf("no transaction hashes in %s", path)
+	}
+	return txHashes, nil
+}
+
+func newVerifyProofCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify-proof <tx_hash>",
+		Short: "Check the output a proven withdrawal used still exists on L1",
+		Args:  txHashArg,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			return runVerifyProof(cmd.Context(), messenger, args[0])
+		}),
+	}
+}
+
+func newHashCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "hash <tx_hash>",
+		Short: "Print the withdrawal hash, its fields and the relayed message from the L2 receipt only (no L1 calls); see --output json",
+		Args:  txHashArg,
+		// hash only reads L2, so it must not fail on L1 startup checks when L1 is down
+		RunE: opts.withMessenger(true, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			return runHash(cmd.Context(), messenger, args[0], format == "json")
+		}),
+	}
+}
+
+func newTimelineCmd(opts *rootOptions) *cobra.Command {
+	var storeSpec string
+	cmd := &cobra.Command{
+		Use:   "timeline <tx_hash>",
+		Short: "Print when the withdrawal reached each stage, from chain data and the scheduler's status store; see --output json",
+		Args:  txHashArg,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			return runTimeline(cmd.Context(), messenger, args[0], storeSpec, format == "json")
+		}),
+	}
+	cmd.Flags().StringVar(&storeSpec, "store", "", "scheduler status store to read recorded stages from: json or sqlite:PATH (default STATUS_STORE, else json)")
+	return cmd
+}
+
+func newETACmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "eta <tx_hash>",
+		Short: "Estimate when the withdrawal can be proven and finalized; see --output json",
+		Args:  txHashArg,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			return runETA(cmd.Context(), messenger, args[0], format == "json")
+		}),
+	}
+}
+
+func newProofCmd(opts *rootOptions) *cobra.Command {
+	var out string
+	var l2Block uint64
+	var compactProof bool
+	cmd := &cobra.Command{
+		Use:   "proof <tx_hash> [message_index]",
+		Short: "Generate and verify the withdrawal proof without submitting it",
+		Args:  messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			messenger.CompactProofs = compactProof
+			return runProof(cmd.Context(), messenger, txHash, messageIndex, out, l2Block)
+		}),
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&out, "out", "", "write the proof JSON to this file instead of stdout")
+	flags.Uint64Var(&l2Block, "l2-block", 0, "prove against the output for this L2 block (default: first output covering the withdrawal)")
+	addCompactProofFlag(cmd, &compactProof)
+	return cmd
+}
+
+func newExportBundleCmd(opts *rootOptions) *cobra.Command {
+	var out string
+	cmd := &cobra.Command{
+		Use:   "export-bundle <tx_hash> [message_index]",
+		Short: "Write the receipt, message, output, proof and calldata of a withdrawal to one file for support",
+		Args:  messageArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			txHash, messageIndex, _ := parseMessageArgs(args)
+			return runExportBundle(cmd.Context(), messenger, txHash, messageIndex, out)
+		}),
+	}
+	cmd.Flags().StringVar(&out, "out", "bundle.json", "write the bundle to this file; - for stdout")
+	return cmd
+}
+
+// addCompactProofFlag registers the experimental --compact-proof on a command that
+// generates withdrawal proofs
+func addCompactProofFlag(cmd *cobra.Command, compact *bool) {
+	cmd.Flags().BoolVar(compact, "compact-proof", false, "experimental: drop proof nodes off the path to the withdrawal's slot, if the trimmed proof still verifies")
+}
+
+// outputsOptions are the flags of the outputs command
+type outputsOptions struct {
+	fromBlock uint64
+	toBlock   uint64
+	last      time.Duration
+}
+
+func newOutputsCmd(opts *rootOptions) *cobra.Command {
+	var o outputsOptions
+	cmd := &cobra.Command{
+		Use:   "outputs",
+		Short: "List L2 output proposals; see --last and --output json|csv",
+		Args:  cobra.NoArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json", "csv")
+			if err != nil {
+				return err
+			}
+			flags := cmd.Flags()
+			if flags.Changed("from-l1-block") && flags.Changed("last") {
+				return fmt.Errorf("--from-l1-block and --last can't be used together")
+			}
+			return runOutputs(cmd.Context(), messenger, o, flags.Changed("from-l1-block"), flags.Changed("to-l1-block"), format)
+		}),
+	}
+	flags := cmd.Flags()
+	flags.Uint64Var(&o.fromBlock, "from-l1-block", 0, "first L1 block to scan")
+	flags.Uint64Var(&o.toBlock, "to-l1-block", 0, "last L1 block to scan (default: latest)")
+	o.last = 24 * time.Hour
+	flags.Var((*durationValue)(&o.last), "last", "scan the L1 blocks of this last period")
+	return cmd
+}
+
+func newOracleCmd(opts *rootOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "oracle",
+		Short: "Inspect the L2OutputOracle: latest and next output, L1 block hash checkpoints",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "latest",
+			Short: "Print the latest output's index, L2 block, root and timestamp; see --output json",
+			Args:  cobra.NoArgs,
+			RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+				format, err := opts.outputFormat("json")
+				if err != nil {
+					return err
+				}
+				return runOracleLatest(cmd.Context(), messenger, format == "json")
+			}),
+		},
+		&cobra.Command{
+			Use:   "next",
+			Short: "Print the next expected output and when it should be proposed; see --output json",
+			Args:  cobra.NoArgs,
+			RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+				format, err := opts.outputFormat("json")
+				if err != nil {
+					return err
+				}
+				return runOracleNext(cmd.Context(), messenger, format == "json")
+			}),
+		},
+		&cobra.Command{
+			Use:   "checkpoints <l1_block>",
+			Short: "Print the block hash checkpointed for an L1 block and whether proposals can use it; see --output json",
+			Args:  l1BlockArg,
+			RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+				format, err := opts.outputFormat("json")
+				if err != nil {
+					return err
+				}
+				l1Block, _ := parseL1Block(args[0])
+				return runOracleCheckpoint(cmd.Context(), messenger, l1Block, format == "json")
+			}),
+		},
+	)
+	return cmd
+}
+
+func newDoctorCmd(opts *rootOptions) *cobra.Command {
+	var noTelegram bool
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check RPCs, contracts, eth_getProof, signers, balances and Telegram; see --output json",
+		Long: `Runs every configuration check and prints a pass/fail table with a hint for each
+problem: RPC reachability and chain IDs, contract code at the OptimismPortal,
+L2OutputOracle and L1CrossDomainMessenger, the oracle's version(), eth_getProof at
+the latest output's L2 block, signers and KMS keys, wallet balances, and a test
+message to TELEGRAM_CHAT_ID. Exits non-zero when a check fails.`,
+		Args: cobra.NoArgs,
+		// Startup checks would stop at the first problem; doctor reports them all
+		RunE: opts.withMessenger(true, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			checks := messenger.DoctorChecks()
+			if !noTelegram {
+				checks = append(checks, telegramCheck())
+			}
+			return runDoctor(cmd.Context(), messenger, checks, format == "json")
+		}),
+	}
+	cmd.Flags().BoolVar(&noTelegram, "no-telegram", false, "don't send a Telegram test message")
+	return cmd
+}
+
+// l1BlockArg accepts a single <l1_block>
+func l1BlockArg(cmd *cobra.Command, args []string) error {
+	if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+		return err
+	}
+	_, err := parseL1Block(args[0])
+	return err
+}
+
+// parseL1Block parses an L1 block number argument
+func parseL1Block(s string) (uint64, error) {
+	block, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, &crosschain.InputError{Name: "L1 block", Value: s, Expected: "a decimal block number"}
+	}
+	return block, nil
+}
+
+func newServeCmd(opts *rootOptions) *cobra.Command {
+	var addr string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP API (needs API_TOKEN)",
+		Args:  cobra.NoArgs,
+		RunE: opts.withMessenger(false, func(cmd *cobra.Command, messenger *crosschain.CrossChainMessenger, args []string) error {
+			return runServer(messenger, addr)
+		}),
+	}
+	cmd.Flags().StringVar(&addr, "addr", "", "listen address (default API_LISTEN_ADDR or "+defaultAPIListenAddr+")")
+	return cmd
+}
+
+// errNotConfirmed is returned by the confirmation prompt when the answer isn't yes
+var errNotConfirmed = errors.New("transaction not confirmed")
+
+// confirmPrompt returns a SubmitOptions.Confirm hook that prints the transaction about to
+// be signed and asks y/N on stdin. It returns nil, sending without asking, with --yes, -y
+// or CI=true. Without a terminal the answer reads as no, so automation has to opt in.
+func confirmPrompt(yes bool) func(crosschain.TxPreview) error {
+	if yes {
+		return nil
+	}
+	if ci, _ := strconv.ParseBool(os.Getenv("CI")); ci {
+		return nil
+	}
+	return func(p crosschain.TxPreview) error {
+		fmt.Println("\n=== CONFIRM TRANSACTION ===")
+		fmt.Printf("  Call:            %s\n", p.Method)
+		if p.Method == "cancel" {
+			fmt.Printf("  Nonce:           %d (replaced by a 0-value transfer to the wallet itself)\n", p.Nonce)
+		} else {
+			fmt.Printf("  Transaction:     %s\n", p.TxHash)
+			fmt.Printf("  Withdrawal hash: %s\n", p.WithdrawalHash)
+			fmt.Printf("  Sender (L2):     %s\n", p.Sender.Hex())
+			fmt.Printf("  Target (L1):     %s\n", p.Target.Hex())
+			fmt.Printf("  ETH value:       %s ETH\n", crosschain.FormatEther(p.EthValue))
+			fmt.Printf("  MNT value:       %s MNT\n", crosschain.FormatEther(p.MntValue))
+			if t := p.TokenWithdrawal; t != nil {
+				fmt.Printf("  Bridged:         %s\n", t)
+			}
+		}
+		fmt.Printf("  Wallet:          %s\n", p.From.Hex())
+		fmt.Printf("  Gas limit:       %d\n", p.GasLimit)
+		fmt.Printf("  Gas cost:        up to %s ETH\n", crosschain.FormatEther(p.MaxCostWei))
+		if p.ProofSize != nil {
+			fmt.Printf("  Proof:           %s\n", p.ProofSize)
+		}
+		fmt.Print("\nSend this transaction? [y/N] ")
+
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return nil
+		}
+		return errNotConfirmed
+	}
+}
+
+// printRecommendation prints the next action for a withdrawal from the shared decision table
+func printRecommendation(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string) error {
+	rec, state, err := messenger.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n=== RECOMMENDATION ===")
+	fmt.Printf("  Transaction:     %s\n", txHash)
+	if link := messenger.Explorer.L2Tx(txHash); link != "" {
+		fmt.Printf("  Explorer:        %s\n", link)
+	}
+	fmt.Printf("  Status:          %d\n", state.Status)
+	fmt.Printf("  Output proposed: %t (latest proposed L2 block: %d)\n", state.OutputProposed, state.LatestProposedBlock)
+	if !state.FinalizeAt.IsZero() {
+		fmt.Printf("  Proven at:       %s\n", state.ProvenAt.Format(time.RFC3339))
+		fmt.Printf("  Finalize after:  %s\n", state.FinalizeAt.Format(time.RFC3339))
+	}
+	fmt.Printf("  Finalization:    %s\n", state.Finalization)
+	if state.ConfirmationsNeeded > 0 {
+		fmt.Printf("  Confirmations:   %d more L2 blocks needed\n", state.ConfirmationsNeeded)
+	}
+	if state.PortalPaused {
+		fmt.Println("  Portal paused:   true")
+	}
+	fmt.Printf("\n👉 Next action: %s\n", rec.Action)
+	fmt.Printf("   Why: %s\n", rec.Reason)
+	if rec.Command != "" {
+		fmt.Printf("   Run: %s\n", rec.Command)
+	}
+	if rec.Automatic {
+		fmt.Println("   (the scheduler handles this automatically)")
+	}
+	return nil
+}
+
+// runVerifyProof prints the output a withdrawal was proven against and fails with
+// ErrProvingOutputDeleted if the oracle no longer holds it
+// runTimeline prints txHash's timeline: what the scheduler recorded, completed with
+// what can be reconstructed from chain data
+func runTimeline(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash, storeSpec string, asJSON bool) error {
+	recorded, err := storedTimeline(ctx, storeSpec, txHash)
+	if err != nil {
+		log.Printf("⚠️  Failed to read the recorded timeline: %v", err)
+	}
+	reconstructed, err := messenger.WithdrawalTimeline(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	timeline := recorded.Merge(reconstructed)
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(timeline)
+	}
+	fmt.Println("\n=== WITHDRAWAL TIMELINE ===")
+	fmt.Printf("  Transaction: %s\n", txHash)
+	if message, err := messenger.GetMessageLocal(ctx, txHash); err == nil {
+		values := messenger.WithdrawalValues(ctx, message)
+		fmt.Printf("  Value:       %s\n", values)
+		if values.Token != nil {
+			fmt.Printf("  Amount:      %s\n", values.Token)
+		}
+	}
+	fmt.Println()
+	for _, ev := range timeline {
+		line := fmt.Sprintf("  %-19s %s", ev.Stage, ev.At.Format(time.RFC3339))
+		if ev.Block != 0 {
+			line += fmt.Sprintf("  %s block %d", ev.Chain, ev.Block)
+		}
+		if ev.TxHash != "" {
+			line += "  " + ev.TxHash
+		}
+		if ev.Inferred {
+			line += "  (from chain)"
+		}
+		fmt.Println(line)
+	}
+	if len(recorded) == 0 {
+		fmt.Println("\nℹ️  Not in the scheduler's status store; submission times are unknown")
+	}
+	return nil
+}
+
+// runETA prints when txHash can be proven and finalized
+func runETA(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string, asJSON bool) error {
+	schedule, err := messenger.EstimateClaimSchedule(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(schedule)
+	}
+	fmt.Println("\n=== CLAIM SCHEDULE ===")
+	fmt.Printf("  Transaction: %s\n", txHash)
+	for _, line := range strings.Split(strings.TrimSuffix(schedule.String(), "\n"), "\n") {
+		fmt.Println("  " + line)
+	}
+	if schedule.FinalizeEstimated {
+		fmt.Println("\nℹ️  Estimated from the oracle's proposal cadence and the current challenge period")
+	}
+	return nil
+}
+
+func runVerifyProof(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string) error {
+	check, err := messenger.VerifyProof(ctx, txHash)
+	if err != nil {
+		return err
+	}
+
+	p := check.Provenance
+	fmt.Println("\n=== PROOF PROVENANCE ===")
+	fmt.Printf("  Transaction:     %s\n", check.TxHash)
+	fmt.Printf("  Withdrawal hash: 0x%s\n", strings.TrimPrefix(check.WithdrawalHash, "0x"))
+	fmt.Printf("  Proven at:       %s\n", p.ProvenAt.Format(time.RFC3339))
+	if p.ProveTxHash != (common.Hash{}) {
+		fmt.Printf("  Prove tx:        %s\n", p.ProveTxHash.Hex())
+		if link := messenger.Explorer.L1Tx(p.ProveTxHash.Hex()); link != "" {
+			fmt.Printf("                   %s\n", link)
+		}
+	} else {
+		fmt.Println("  Prove tx:        not found")
+	}
+	fmt.Printf("  Output index:    %d\n", p.OutputIndex)
+	fmt.Printf("  Output root:     %s\n", p.OutputRoot.Hex())
+	if !check.OutputDeleted {
+		fmt.Println("\n✅ The proving output is still on the L2OutputOracle")
+		return nil
+	}
+	if check.CurrentRoot != (common.Hash{}) {
+		fmt.Printf("  Current root:    %s\n", check.CurrentRoot.Hex())
+	} else {
+		fmt.Println("  Current root:    (output deleted)")
+	}
+	fmt.Printf("\n👉 Run: bridge-status prove %s\n", txHash)
+	return crosschain.ErrProvingOutputDeleted
+}
+
+// hashOutput is what `hash --json` prints; amounts are decimal wei
+type hashOutput struct {
+	TxHash           string `json:"txHash"`
+	WithdrawalHash   string `json:"withdrawalHash"`
+	Nonce            string `json:"nonce"`
+	NonceRaw         string `json:"nonceRaw"`
+	MessageVersion   uint16 `json:"messageVersion"`
+	Sender           string `json:"sender"`
+	Target           string `json:"target"`
+	MntValue         string `json:"mntValue"`
+	EthValue         string `json:"ethValue"`
+	GasLimit         string `json:"gasLimit"`
+	SentMessagesSlot string `json:"sentMessagesSlot"`
+	ExplorerURL      string `json:"explorerUrl,omitempty"` // L2 transaction on the Mantle explorer
+
+	RelayedMessage *relayedMessageOutput `json:"relayedMessage,omitempty"` // Nil for withdrawals not sent through L2CrossDomainMessenger
+}
+
+// relayedMessageOutput is the message a withdrawal relays through L1CrossDomainMessenger:
+// the arguments relayMessage takes to replay it, and the hash successfulMessages and
+// failedMessages are keyed by
+type relayedMessageOutput struct {
+	MessageHash string `json:"messageHash"`
+	Nonce       string `json:"nonce"` // Versioned
+	Sender      string `json:"sender"`
+	Target      string `json:"target"`
+	MntValue    string `json:"mntValue"`
+	EthValue    string `json:"ethValue"`
+	MinGasLimit string `json:"minGasLimit"`
+	Data        string `json:"data"`
+}
+
+// runHash prints the withdrawal hash and the fields it commits to, reading only the L2
+// receipt so it works while L1 is unreachable
+func runHash(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string, asJSON bool) error {
+	message, err := messenger.GetMessageLocal(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	passed := message.MessagePassedEvent
+	out := hashOutput{
+		TxHash:           message.TxHash,
+		WithdrawalHash:   "0x" + strings.TrimPrefix(message.WithdrawalHash, "0x"),
+		Nonce:            message.MsgNonceDecoded.String(),
+		NonceRaw:         message.MsgNonceRaw.String(),
+		MessageVersion:   message.MessageVersion,
+		Sender:           passed.Sender.Hex(),
+		Target:           passed.Target.Hex(),
+		MntValue:         message.MntValue.String(),
+		EthValue:         message.EthValue.String(),
+		GasLimit:         passed.GasLimit.String(),
+		SentMessagesSlot: crosschain.SentMessagesSlot(message.WithdrawalHash).Hex(),
+		ExplorerURL:      messenger.Explorer.L2Tx(message.TxHash),
+	}
+	values := crosschain.NativeValues(message.MntValue, message.EthValue)
+	var relayedValues crosschain.WithdrawalValues
+	if msg, ok := crosschain.RelayedMessage(message); ok {
+		relayedValues = crosschain.NativeValues(msg.MntValue, msg.EthValue)
+		messageHash, err := crosschain.ComputeCrossDomainMessageHash(msg)
+		if err != nil {
+			return err
+		}
+		out.RelayedMessage = &relayedMessageOutput{
+			MessageHash: messageHash.Hex(),
+			Nonce:       msg.Nonce.String(),
+			Sender:      msg.Sender.Hex(),
+			Target:      msg.Target.Hex(),
+			MntValue:    msg.MntValue.String(),
+			EthValue:    msg.EthValue.String(),
+			MinGasLimit: msg.GasLimit.String(),
+			Data:        hexutil.Encode(msg.Data),
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	fmt.Println("\n=== WITHDRAWAL HASH ===")
+	fmt.Printf("  Transaction:        %s\n", out.TxHash)
+	fmt.Printf("  Withdrawal hash:    %s\n", out.WithdrawalHash)
+	fmt.Printf("  Nonce:              %s (version %d, raw %s)\n", out.Nonce, out.MessageVersion, out.NonceRaw)
+	fmt.Printf("  Sender:             %s\n", out.Sender)
+	fmt.Printf("  Target:             %s\n", out.Target)
+	fmt.Printf("  MNT value:          %s wei (%s)\n", out.MntValue, values.MNT)
+	fmt.Printf("  ETH value:          %s wei (%s)\n", out.EthValue, values.ETH)
+	fmt.Printf("  Gas limit:          %s\n", out.GasLimit)
+	fmt.Printf("  sentMessages slot:  %s\n", out.SentMessagesSlot)
+	if out.ExplorerURL != "" {
+		fmt.Printf("  Explorer:           %s\n", out.ExplorerURL)
+	}
+	if r := out.RelayedMessage; r != nil {
+		fmt.Println("\n=== RELAYED MESSAGE (L1CrossDomainMessenger.relayMessage) ===")
+		fmt.Printf("  Message hash:       %s\n", r.MessageHash)
+		fmt.Printf("  Nonce:              %s\n", r.Nonce)
+		fmt.Printf("  Sender:             %s\n", r.Sender)
+		fmt.Printf("  Target:             %s\n", r.Target)
+		fmt.Printf("  MNT value:          %s wei (%s)\n", r.MntValue, relayedValues.MNT)
+		fmt.Printf("  ETH value:          %s wei (%s)\n", r.EthValue, relayedValues.ETH)
+		fmt.Printf("  Min gas limit:      %s\n", r.MinGasLimit)
+		fmt.Printf("  Data:               %s\n", r.Data)
+	}
+	return nil
+}
+
+// runProof generates a withdrawal proof without submitting it and writes it to out, or
+// prints it as JSON without one. l2Block 0 picks the first output covering the withdrawal.
+// runCheckHash prints what OptimismPortal records for a withdrawal hash: whether it is
+// proven or finalized, when it was proven and how much of the challenge period is left
+func runCheckHash(ctx context.Context, messenger *crosschain.CrossChainMessenger, hash common.Hash, asJSON bool) error {
+	report, err := messenger.GetStatusByWithdrawalHash(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	fmt.Println("\n=== WITHDRAWAL STATUS (by withdrawal hash) ===")
+	fmt.Printf("  Withdrawal hash:    %s\n", report.WithdrawalHash)
+	fmt.Printf("  Status:             %s %s\n", statusIcon(report.Status), report.StatusName)
+	if p := report.Provenance; p != nil {
+		fmt.Printf("  Proven at:          %s (output #%d)\n", p.ProvenAt.Format(time.RFC3339), p.OutputIndex)
+	}
+	if report.FinalizeAt != nil && report.Status == crosschain.StatusProven {
+		if remaining := time.Until(*report.FinalizeAt); remaining > 0 {
+			fmt.Printf("  Challenge ends:     %s (in %s)\n", report.FinalizeAt.Format(time.RFC3339), remaining.Round(time.Second))
+		} else {
+			fmt.Printf("  Challenge ended:    %s (ready to finalize)\n", report.FinalizeAt.Format(time.RFC3339))
+		}
+	}
+	if report.Status == crosschain.StatusUnknown {
+		fmt.Println("  The portal has no record of this hash: the withdrawal is not proven yet, or the hash is not a withdrawal hash")
+	}
+	fmt.Printf("  Not available:      %s (only the withdrawal hash is known)\n", strings.Join(report.Missing, ", "))
+	return nil
+}
+
+// runFindTx searches the L2 blocks given by --from-block, --to-block or --last for the
+// transaction that sent hash and prints it; with --check it then checks its status.
+// hasFrom and hasTo say whether the block flags were given.
+func runFindTx(ctx context.Context, messenger *crosschain.CrossChainMessenger, hash common.Hash, o findTxOptions, hasFrom, hasTo, asJSON bool) error {
+	var senders []common.Address
+	for _, sender := range o.senders {
+		if !common.IsHexAddress(sender) {
+			return fmt.Errorf("invalid --sender %q: must be an address", sender)
+		}
+		senders = append(senders, common.HexToAddress(sender))
+	}
+
+	head, err := messenger.GetLatestL2Block(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest L2 block: %w", err)
+	}
+	toBlock := head
+	if hasTo {
+		toBlock = o.toBlock
+	}
+	fromBlock := o.fromBlock
+	if !hasFrom {
+		if o.last <= 0 {
+			return fmt.Errorf("invalid --last %s: must be a positive duration such as 168h", o.last)
+		}
+		if fromBlock, err = messenger.L2BlocksSince(ctx, toBlock, o.last); err != nil {
+			return err
+		}
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("--from-block %d is after --to-block %d", fromBlock, toBlock)
+	}
+
+	found, err := messenger.FindWithdrawalTx(ctx, hash, senders, fromBlock, toBlock)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(found); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("\n=== WITHDRAWAL TRANSACTION ===")
+		fmt.Printf("  Withdrawal hash:    %s\n", found.WithdrawalHash)
+		fmt.Printf("  Transaction:        %s\n", found.TxHash)
+		fmt.Printf("  L2 block:           %d\n", found.BlockNumber)
+		fmt.Printf("  Sender:             %s\n", found.Sender.Hex())
+		fmt.Printf("  Target:             %s\n", found.Target.Hex())
+		fmt.Printf("  Nonce:              %s\n", found.Nonce)
+		if link := messenger.Explorer.L2Tx(found.TxHash); link != "" {
+			fmt.Printf("  Explorer:           %s\n", link)
+		}
+	}
+	if o.check {
+		return messenger.CheckMessageStatus(ctx, found.TxHash, 0)
+	}
+	return nil
+}
+
+func runProof(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string, messageIndex int, out string, l2Block uint64) error {
+	proof, err := messenger.ExportWithdrawalProof(ctx, txHash, messageIndex, l2Block)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(proof)
+	}
+	if err := crosschain.SaveWithdrawalProof(out, proof); err != nil {
+		return err
+	}
+	fmt.Printf("\n📝 Proof against output #%d (L2 block %d, root %s) written to %s\n",
+		proof.OutputIndex, proof.L2BlockNumber, proof.OutputRoot.Hex(), out)
+	fmt.Printf("📏 Proof size: %s\n", proof.Size)
+	return nil
+}
+
+// runExportBundle writes the claim bundle of txHash to out and summarizes what it holds
+func runExportBundle(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string, messageIndex int, out string) error {
+	bundle, err := messenger.ExportBundle(ctx, txHash, messageIndex)
+	if err != nil {
+		return err
+	}
+	if err := crosschain.SaveBundle(out, bundle); err != nil {
+		return err
+	}
+	if out == "-" {
+		return nil
+	}
+	fmt.Printf("\n📦 Bundle for %s written to %s\n", txHash, out)
+	if m := bundle.Message; m != nil {
+		fmt.Printf("  Withdrawal: %s (%s)\n", m.WithdrawalHash.Hex(), m.StatusName)
+	}
+	if o := bundle.Output; o != nil {
+		fmt.Printf("  Output: #%d (L2 block %d)\n", o.OutputIndex, o.L2BlockNumber)
+	}
+	if p := bundle.Proof; p != nil {
+		fmt.Printf("  Proof: against output #%d, %s\n", p.OutputIndex, p.Size)
+	}
+	for _, step := range []string{crosschain.BundleStepMessage, crosschain.BundleStepOutput, crosschain.BundleStepProof} {
+		if reason, ok := bundle.Errors[step]; ok {
+			fmt.Printf("  ⚠️  %s failed: %s\n", step, reason)
+		}
+	}
+	return nil
+}
+
+// runServer serves the HTTP API on addr until interrupted
+func runServer(messenger *crosschain.CrossChainMessenger, addr string) error {
+	if addr == "" {
+		addr = os.Getenv("API_LISTEN_ADDR")
+	}
+	if addr == "" {
+		addr = defaultAPIListenAddr
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	api, err := server.New(ctx, messenger, os.Getenv("API_TOKEN"), messenger.Logger)
+	if err != nil {
+		return err
+	}
+	// Status responses include what a scheduler sharing STATUS_STORE/STATE_FILE recorded
+	api.StoredTimeline = func(ctx context.Context, txHash string) (crosschain.Timeline, error) {
+		return storedTimeline(ctx, "", txHash)
+	}
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		fmt.Printf("🌐 API listening on %s (version %s)\n", addr, version.BuildInfo().Short())
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	fmt.Println("\n🛑 Shutting down API server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// waitTargets maps --until values to WaitForStatus targets
+var waitTargets = map[string]int{
+	"proven":    crosschain.StatusProven,
+	"ready":     crosschain.WaitReadyToFinalize,
+	"finalized": crosschain.StatusFinalized,
+}
+
+// runOffline builds a prove/finalize call with build and prints it for an offline
+// signer; --from overrides SIMULATION_FROM as the sender gas is estimated from
+func runOffline(messenger *crosschain.CrossChainMessenger, from common.Address, build func() (*crosschain.UnsignedTx, error)) error {
+	if from != (common.Address{}) {
+		messenger.SimulationFrom = from
+	}
+
+	tx, err := build()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\n=== UNSIGNED TRANSACTION ===")
+	fmt.Printf("  Method:    %s\n", tx.Method)
+	fmt.Printf("  Chain ID:  %s\n", tx.ChainID)
+	fmt.Printf("  To:        %s\n", tx.To.Hex())
+	fmt.Printf("  Value:     %s\n", tx.Value)
+	fmt.Printf("  Gas limit: %d (suggested, estimated from %s: %s)\n", tx.GasLimit, tx.EstimatedFromSource, tx.EstimatedFrom.Hex())
+	if tx.ProofSize != nil {
+		fmt.Printf("  Proof:     %s\n", tx.ProofSize)
+	}
+	fmt.Printf("  Calldata:  %s\n", tx.Data)
+	fmt.Println("\nSign and broadcast this with your offline signer; nonce and fees are up to the signer.")
+	return nil
+}
+
+// waitOptions are the flags of the commands that wait for a withdrawal status
+type waitOptions struct {
+	until   string
+	timeout time.Duration
+	poll    time.Duration
+}
+
+// addFlags registers --timeout and --poll on cmd, and --until with until
+func (o *waitOptions) addFlags(cmd *cobra.Command, until bool) {
+	flags := cmd.Flags()
+	if until {
+		flags.StringVar(&o.until, "until", "finalized", "status to wait for: proven, ready (to finalize) or finalized")
+	}
+	o.poll = crosschain.DefaultWaitPollInterval
+	flags.Var((*durationValue)(&o.timeout), "timeout", "give up after this long, e.g. 24h (default: no limit)")
+	flags.Var((*durationValue)(&o.poll), "poll", "check interval")
+}
+
+// deadline bounds ctx by --timeout, if set, after checking the durations
+func (o waitOptions) deadline(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if o.poll <= 0 {
+		return nil, nil, fmt.Errorf("invalid --poll %s: must be a positive duration such as 1m", o.poll)
+	}
+	if o.timeout == 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	return ctx, cancel, nil
+}
+
+// runWait blocks until the withdrawal reaches the --until status or --timeout passes
+func runWait(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string, messageIndex int, wait waitOptions) error {
+	target, ok := waitTargets[strings.ToLower(wait.until)]
+	if !ok {
+		return fmt.Errorf("invalid --until %q: must be proven, ready or finalized", wait.until)
+	}
+	ctx, cancel, err := wait.deadline(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return messenger.WaitForStatus(ctx, txHash, messageIndex, target, wait.poll)
+}
+
+// runFull takes a withdrawal the rest of the way: it proves it unless it already is,
+// waits out the challenge period and finalizes it. --timeout bounds the whole run.
+func runFull(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHash string, messageIndex int, submit crosschain.SubmitOptions, wait waitOptions) error {
+	ctx, cancel, err := wait.deadline(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	status, err := messenger.GetMessageStatus(ctx, txHash)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case crosschain.StatusFinalized, crosschain.StatusRelayFailed:
+		return crosschain.ErrAlreadyFinalized
+	case crosschain.StatusReadyToProve:
+		if _, err := messenger.ProveMessage(ctx, txHash, messageIndex, submit); err != nil && !crosschain.IsExternallyCompleted(err) {
+			return err
+		}
+	}
+
+	fmt.Println("\n⏳ Waiting for the challenge period to pass...")
+	if err := messenger.WaitForStatus(ctx, txHash, messageIndex, crosschain.WaitReadyToFinalize, wait.poll); err != nil {
+		return err
+	}
+	_, err = messenger.FinalizeMessage(ctx, txHash, messageIndex, submit)
+	return err
+}
+
+// runBatch runs a batch prove/finalize over txHashes and prints one line per withdrawal
+func runBatch(ctx context.Context, operation string, txHashes []string, from common.Address, batch func(context.Context, []string, crosschain.BatchOptions) ([]crosschain.BatchResult, error)) error {
+	results, err := batch(ctx, txHashes, crosschain.BatchOptions{From: from})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	fmt.Printf("\n=== %s BATCH RESULTS ===\n", strings.ToUpper(operation))
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			if reason, ok := crosschain.RevertReason(r.Err); ok {
+				fmt.Printf("  ❌ %s: %s\n", r.TxHash, reason)
+			} else {
+				fmt.Printf("  ❌ %s: %v\n", r.TxHash, r.Err)
+			}
+		case r.Skipped:
+			fmt.Printf("  ⏭️  %s: nothing to do\n", r.TxHash)
+		case r.External:
+			fmt.Printf("  🤝 %s: already done by someone else\n", r.TxHash)
+		case r.Fee != nil:
+			fmt.Printf("  ✅ %s: %s (fee %s ETH)\n", r.TxHash, r.L1TxHash, r.Fee.ETH())
+		default:
+			fmt.Printf("  ✅ %s: %s\n", r.TxHash, r.L1TxHash)
+		}
+		if r.L1TxURL != "" && r.Err == nil && !r.Skipped && !r.External {
+			fmt.Printf("     🔗 %s\n", r.L1TxURL)
+		}
+		if r.ProofSize != nil && r.Err == nil && !r.External {
+			fmt.Printf("     📏 proof: %s\n", r.ProofSize)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %s transaction(s) failed", failed, len(results), operation)
+	}
+	return nil
+}
+
+// checkBatchEntry is one line of `check-batch -o json`: the status, or why it couldn't
+// be read
+type checkBatchEntry struct {
+	crosschain.MessageStatusReport
+	Error string `json:"error,omitempty"`
+}
+
+// runCheckBatch reads the status of txHashes in parallel and prints them in input order
+// as a table, or in format json or csv. It fails when any status couldn't be read.
+func runCheckBatch(ctx context.Context, messenger *crosschain.CrossChainMessenger, txHashes []string, concurrency int, format string) error {
+	reports, errs := messenger.GetMessageStatusBatch(ctx, txHashes, concurrency)
+
+	entries := make([]checkBatchEntry, 0, len(txHashes))
+	seen := make(map[string]bool, len(txHashes))
+	for _, txHash := range txHashes {
+		if seen[txHash] {
+			continue
+		}
+		seen[txHash] = true
+		entry := checkBatchEntry{MessageStatusReport: reports[txHash]}
+		if err := errs[txHash]; err != nil {
+			entry.TxHash = txHash
+			entry.Error = err.Error()
+		}
+		entries = append(entries, entry)
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(entries); err != nil {
+			return err
+		}
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"tx_hash", "withdrawal_hash", "l2_block_number", "status", "error"})
+		for _, e := range entries {
+			status := e.StatusName
+			if e.Error != "" {
+				status = ""
+			}
+			_ = w.Write([]string{e.TxHash, e.WithdrawalHash, strconv.FormatUint(e.L2BlockNumber, 10), status, e.Error})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	default:
+		fmt.Printf("\n=== WITHDRAWAL STATUSES (%d) ===\n", len(entries))
+		for _, e := range entries {
+			if e.Error != "" {
+				fmt.Printf("  ❌ %s: %s\n", e.TxHash, e.Error)
+				continue
+			}
+			fmt.Printf("  %s %s: %s (L2 block %d)\n", statusIcon(e.Status), e.TxHash, e.StatusName, e.L2BlockNumber)
+			if e.OutputWait != nil {
+				fmt.Printf("     ⏳ %s\n", e.OutputWait.Describe())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d withdrawal status check(s) failed", len(errs), len(entries))
+	}
+	return nil
+}
+
+// statusIcon marks a withdrawal status in tables
+func statusIcon(status int) string {
+	switch status {
+	case crosschain.StatusFinalized:
+		return "✅"
+	case crosschain.StatusRelayFailed:
+		return "❌"
+	case crosschain.StatusProven:
+		return "⏳"
+	default:
+		return "📤"
+	}
+}
+
+// runOutputs lists the OutputProposed events in the block range given by --from-l1-block,
+// --to-l1-block or --last (default: the last 24h) as a table, or in format json or csv.
+// hasFrom and hasTo say whether the block flags were given.
+func runOutputs(ctx context.Context, messenger *crosschain.CrossChainMessenger, o outputsOptions, hasFrom, hasTo bool, format string) error {
+	head, err := messenger.GetLatestL1Block(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+
+	toBlock := head
+	if hasTo {
+		toBlock = o.toBlock
+	}
+	fromBlock := o.fromBlock
+	if !hasFrom {
+		if o.last <= 0 {
+			return fmt.Errorf("invalid --last %s: must be a positive duration such as 24h", o.last)
+		}
+		if blocks := uint64(o.last / crosschain.L1BlockTime); blocks < toBlock {
+			fromBlock = toBlock - blocks
+		}
+	}
+	if fromBlock > toBlock {
+		return fmt.Errorf("--from-l1-block %d is after --to-l1-block %d", fromBlock, toBlock)
+	}
+
+	proposals, err := messenger.ListOutputProposals(ctx, fromBlock, toBlock)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if proposals == nil {
+			proposals = []crosschain.OutputProposalInfo{}
+		}
+		return enc.Encode(proposals)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"output_index", "l2_block_number", "output_root", "l1_timestamp", "l1_block_number", "l1_tx_hash"})
+		for _, p := range proposals {
+			_ = w.Write([]string{
+				strconv.FormatUint(p.OutputIndex, 10),
+				strconv.FormatUint(p.L2BlockNumber, 10),
+				p.OutputRoot.Hex(),
+				p.L1Timestamp.Format(time.RFC3339),
+				strconv.FormatUint(p.L1BlockNumber, 10),
+				p.L1TxHash.Hex(),
+			})
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	fmt.Printf("\n=== OUTPUT PROPOSALS (L1 blocks %d-%d) ===\n", fromBlock, toBlock)
+	for _, p := range proposals {
+		fmt.Printf("  #%d  L2 block %d  %s  %s\n", p.OutputIndex, p.L2BlockNumber, p.OutputRoot.Hex(), p.L1Timestamp.Format(time.RFC3339))
+	}
+	fmt.Printf("  %d proposal(s)\n", len(proposals))
+	return nil
+}
+
+// runOracleLatest prints the oracle's latest output
+func runOracleLatest(ctx context.Context, messenger *crosschain.CrossChainMessenger, asJSON bool) error {
+	latest, err := messenger.LatestOutputProposal(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(latest)
+	}
+	fmt.Println("\n=== LATEST OUTPUT ===")
+	fmt.Printf("  Output index:  %d\n", latest.OutputIndex)
+	fmt.Printf("  L2 block:      %d\n", latest.L2BlockNumber)
+	fmt.Printf("  Output root:   %s\n", latest.OutputRoot.Hex())
+	fmt.Printf("  Proposed at:   %s (%s ago)\n", latest.L1Timestamp.Format(time.RFC3339), time.Since(latest.L1Timestamp).Round(time.Second))
+	if latest.L1TxHash != (common.Hash{}) {
+		fmt.Printf("  L1 tx:         %s\n", latest.L1TxHash.Hex())
+	}
+	return nil
+}
+
+// runOracleNext prints the output the oracle expects next and when it should arrive
+func runOracleNext(ctx context.Context, messenger *crosschain.CrossChainMessenger, asJSON bool) error {
+	next, err := messenger.NextOutput(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(next)
+	}
+	fmt.Println("\n=== NEXT OUTPUT ===")
+	fmt.Printf("  Output index:        %d\n", next.OutputIndex)
+	fmt.Printf("  L2 block:            %d (latest output: %d)\n", next.L2BlockNumber, next.LatestBlockNumber)
+	fmt.Printf("  Submission interval: %d L2 blocks of %ds\n", next.SubmissionInterval, next.L2BlockTime)
+	if !next.LatestProposedAt.IsZero() {
+		fmt.Printf("  Latest proposed at:  %s\n", next.LatestProposedAt.Format(time.RFC3339))
+	}
+	fmt.Printf("  Proposable from:     %s\n", next.ProposableAt.Format(time.RFC3339))
+	fmt.Printf("  Expected at:         %s\n", next.ExpectedAt.Format(time.RFC3339))
+	if next.Optimistic {
+		fmt.Println("  Mode:                optimistic, cadence may differ")
+	}
+	if next.Overdue {
+		fmt.Printf("\n⚠️  Overdue by %s; the proposer may be stalled\n", time.Since(next.ExpectedAt).Round(time.Second))
+	} else {
+		fmt.Printf("\n⏳ Expected in ~%s (approximate)\n", next.Wait().Round(time.Minute))
+	}
+	return nil
+}
+
+// runOracleCheckpoint prints what historicBlockHashes holds for l1Block and whether a
+// proposal anchored to it would pass the oracle's block hash check
+func runOracleCheckpoint(ctx context.Context, messenger *crosschain.CrossChainMessenger, l1Block uint64, asJSON bool) error {
+	checkpoint, err := messenger.BlockHashCheckpoint(ctx, l1Block)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(checkpoint)
+	}
+	fmt.Println("\n=== L1 BLOCK HASH CHECKPOINT ===")
+	fmt.Printf("  L1 block:     %d\n", checkpoint.L1BlockNumber)
+	if checkpoint.Checkpointed {
+		fmt.Printf("  Checkpoint:   %s\n", checkpoint.Hash.Hex())
+	} else {
+		fmt.Println("  Checkpoint:   none")
+	}
+	if checkpoint.ChainHash != (common.Hash{}) {
+		fmt.Printf("  L1 hash:      %s\n", checkpoint.ChainHash.Hex())
+	} else {
+		fmt.Println("  L1 hash:      (block not produced yet)")
+	}
+	switch {
+	case checkpoint.InBlockhashWindow:
+		fmt.Println("\n✅ Within the last 256 L1 blocks; proposals can use it without a checkpoint")
+	case checkpoint.Usable():
+		fmt.Println("\n✅ Checkpointed; proposals can use it")
+	case checkpoint.Checkpointed:
+		fmt.Println("\n❌ The checkpoint doesn't match the L1 block hash")
+	default:
+		fmt.Println("\n❌ Not checkpointed; proposals anchored to it revert with L1BlockHashNotCheckpointed")
+	}
+	return nil
+}
+
+// telegramCheck posts a test message with TELEGRAM_BOT_TOKEN to TELEGRAM_CHAT_ID and
+// TELEGRAM_TOPIC_ID, skipping when no bot token is set
+func telegramCheck() crosschain.Check {
+	return crosschain.Check{Name: "Telegram", Run: func(ctx context.Context) crosschain.CheckResult {
+		token := os.Getenv("TELEGRAM_BOT_TOKEN")
+		if token == "" {
+			return crosschain.CheckResult{Status: crosschain.CheckSkip, Detail: "TELEGRAM_BOT_TOKEN is not set"}
+		}
+		var ids [2]int64
+		for i, key := range []string{"TELEGRAM_CHAT_ID", "TELEGRAM_TOPIC_ID"} {
+			if v := os.Getenv(key); v != "" {
+				id, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return crosschain.CheckResult{Status: crosschain.CheckFail, Detail: fmt.Sprintf("invalid %s %q", key, v),
+						Hint: key + " must be an integer; supergroup IDs start with -100"}
+				}
+				ids[i] = id
+			}
+		}
+		telegram, err := notify.NewTelegram(token, ids[0], ids[1])
+		if err != nil {
+			return crosschain.CheckResult{Status: crosschain.CheckFail, Detail: err.Error(),
+				Hint: "Check TELEGRAM_BOT_TOKEN with @BotFather and set TELEGRAM_CHAT_ID"}
+		}
+		if err := telegram.SelfTest(fmt.Sprintf("🩺 *bridge-status doctor* test message (`%s`)", version.BuildInfo().Short())); err != nil {
+			return crosschain.CheckResult{Status: crosschain.CheckFail, Detail: err.Error(),
+				Hint: "Add the bot to the chat and check TELEGRAM_CHAT_ID and TELEGRAM_TOPIC_ID"}
+		}
+		return crosschain.CheckResult{Status: crosschain.CheckPass, Detail: fmt.Sprintf("test message sent by @%s", telegram.UserName())}
+	}}
+}
+
+// doctorIcons marks each check outcome in the text table
+var doctorIcons = map[string]string{
+	crosschain.CheckPass: "✅",
+	crosschain.CheckWarn: "⚠️ ",
+	crosschain.CheckFail: "❌",
+	crosschain.CheckSkip: "➖",
+}
+
+// runDoctor runs checks and prints their results, returning an error when one failed
+func runDoctor(ctx context.Context, messenger *crosschain.CrossChainMessenger, checks []crosschain.Check, asJSON bool) error {
+	results := messenger.RunChecks(ctx, checks)
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("\n=== DOCTOR ===")
+		for _, r := range results {
+			fmt.Printf("%s %-28s %s\n", doctorIcons[r.Status], r.Name, r.Detail)
+			if r.Hint != "" {
+				fmt.Printf("   %-28s → %s\n", "", r.Hint)
+			}
+		}
+	}
+
+	var failed []string
+	for _, r := range results {
+		if r.Failed() {
+			failed = append(failed, r.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d check(s) failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// handleCommands answers Telegram bot commands until the scheduler stops
+func (s *WithdrawalScheduler) handleCommands() {
+	s.logger.Infof("🤖 Listening for Telegram commands (%d user(s) allowed to prove/finalize/replay)", len(s.commandUsers))
+	for cmd := range s.telegram.Commands(s.ctx) {
+		s.logger.Infof("🤖 /%s %s from @%s (%d)", cmd.Name, strings.Join(cmd.Args, " "), cmd.UserName, cmd.UserID)
+		// Prove/finalize can take minutes; don't hold up other commands
+		go func() {
+			if err := s.telegram.Reply(cmd, s.commandReply(cmd)); err != nil {
+				s.logger.Warnf("⚠️  %v", err)
+			}
+		}()
+	}
+}
+
+// commandReply runs a bot command and returns the Markdown answer
+func (s *WithdrawalScheduler) commandReply(cmd notify.TelegramCommand) string {
+	switch cmd.Name {
+	case "status":
+		if len(cmd.Args) != 1 {
+			return "Usage: `/status <tx_hash>`"
+		}
+		return s.statusReply(cmd.Args[0])
+	case "list":
+		return s.listReply()
+	case "costs":
+		return s.costsReply()
+	case "summary":
+		text, err := s.summaryText()
+		if err != nil {
+			return "❌ " + notify.EscapeMarkdown(err.Error())
+		}
+		return text
+	case "prove", "finalize", "replay":
+		if len(cmd.Args) != 1 {
+			return fmt.Sprintf("Usage: `/%s <tx_hash>`", cmd.Name)
+		}
+		if !s.commandUsers[cmd.UserID] {
+			return fmt.Sprintf("⛔ You are not allowed to /%s (user ID %d is not in TELEGRAM\\_ALLOWED\\_USERS)", cmd.Name, cmd.UserID)
+		}
+		if s.monitorOnly {
+			return "👀 The scheduler runs in monitor-only mode and can't send transactions"
+		}
+		return s.submitReply(cmd.Name, cmd.Args[0])
+	case "help", "start":
+		return "*Commands*\n\n" +
+			"`/status <tx_hash>` - status and next action\n" +
+			"`/list` - all monitored withdrawals\n" +
+			"`/costs` - L1 fees paid per wallet\n" +
+			"`/summary` - the daily summary, now\n" +
+			"`/prove <tx_hash>` - prove now (allowlisted users)\n" +
+			"`/finalize <tx_hash>` - finalize now (allowlisted users)\n" +
+			"`/replay <tx_hash>` - replay a failed relayed message (allowlisted users)"
+	default:
+		return fmt.Sprintf("Unknown command /%s, try /help", notify.EscapeMarkdown(cmd.Name))
+	}
+}
+
+// statusReply describes one withdrawal for /status
+func (s *WithdrawalScheduler) statusReply(txHash string) string {
+	if hash, ok := withdrawalHashEntry(txHash); ok {
+		return s.withdrawalHashReply(txHash, hash)
+	}
+	message, _, state, err := s.loadWithdrawal(txHash)
+	if err != nil {
+		return "❌ " + failureDetail(err)
+	}
+	rec := crosschain.Recommend(state, txHash)
+
+	reply := "📋 *Withdrawal Status*\n\n"
+	if label := s.labelOf(txHash); label != "" {
+		reply += "Label: " + notify.EscapeMarkdown(label) + "\n"
+	}
+	reply += fmt.Sprintf(
+		"Transaction: `%s`\n"+
+			"Status: %s\n"+
+			"Next action: %s\n"+
+			"%s",
+		txHash, crosschain.StatusDescription(message.Status), rec.Action, notify.EscapeMarkdown(rec.Reason))
+	if remaining := time.Until(state.FinalizeAt); !state.FinalizeAt.IsZero() && remaining > 0 {
+		reply += fmt.Sprintf("\nCan finalize at: %s (in %s)", state.FinalizeAt.Format(time.RFC3339), formatCountdown(remaining))
+	}
+	return reply
+}
+
+// withdrawalHashReply describes a withdrawalhash: entry for /status from the portal alone
+func (s *WithdrawalScheduler) withdrawalHashReply(txHash string, hash common.Hash) string {
+	report, err := s.messenger.GetStatusByWithdrawalHash(s.ctx, hash)
+	if err != nil {
+		return "❌ " + failureDetail(err)
+	}
+	reply := "📋 *Withdrawal Status*\n\n"
+	if label := s.labelOf(txHash); label != "" {
+		reply += "Label: " + notify.EscapeMarkdown(label) + "\n"
+	}
+	reply += fmt.Sprintf(
+		"Withdrawal: `%s`\n"+
+			"Status: %s\n"+
+			"Only the withdrawal hash is known, so it is monitored but never proven or finalized",
+		txHash, report.StatusName)
+	if p := report.Provenance; p != nil {
+		reply += fmt.Sprintf("\nProven at: %s (output #%d)", p.ProvenAt.Format(time.RFC3339), p.OutputIndex)
+	}
+	if report.Status == crosschain.StatusProven && report.FinalizeAt != nil {
+		if remaining := time.Until(*report.FinalizeAt); remaining > 0 {
+			reply += fmt.Sprintf("\nCan finalize at: %s (in %s)", report.FinalizeAt.Format(time.RFC3339), formatCountdown(remaining))
+		}
+	}
+	return reply
+}
+
+// listReply summarizes every monitored withdrawal for /list, grouped by label with
+// unlabeled withdrawals last
+func (s *WithdrawalScheduler) listReply() string {
+	s.mu.Lock()
+	txHashes := append([]string(nil), s.withdrawalHashes...)
+	s.mu.Unlock()
+	if len(txHashes) == 0 {
+		return "No withdrawals are being monitored"
+	}
+
+	groups := make(map[string][]string)
+	for _, txHash := range txHashes {
+		label := s.labelOf(txHash)
+		groups[label] = append(groups[label], s.listLine(txHash))
+	}
+	labels := make([]string, 0, len(groups))
+	for label := range groups {
+		if label != "" {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	lines := []string{fmt.Sprintf("📋 *Monitored Withdrawals* (%d)", len(txHashes))}
+	for _, label := range labels {
+		lines = append(lines, fmt.Sprintf("\n🏷️ *%s* (%d)", notify.EscapeMarkdown(label), len(groups[label])))
+		lines = append(lines, groups[label]...)
+	}
+	if unlabeled := groups[""]; len(unlabeled) > 0 {
+		if len(labels) > 0 {
+			lines = append(lines, fmt.Sprintf("\n*Unlabeled* (%d)", len(unlabeled)))
+		} else {
+			lines = append(lines, "")
+		}
+		lines = append(lines, unlabeled...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listLine is one withdrawal's /list entry: its status and what it is waiting for
+func (s *WithdrawalScheduler) listLine(txHash string) string {
+	if hash, ok := withdrawalHashEntry(txHash); ok {
+		report, err := s.messenger.GetStatusByWithdrawalHash(s.ctx, hash)
+		if err != nil {
+			return fmt.Sprintf("❌ `%s`: %s", txHash, briefError(err))
+		}
+		line := fmt.Sprintf("• `%s`: %s", txHash, report.StatusName)
+		if report.Status == crosschain.StatusProven && report.FinalizeAt != nil {
+			if remaining := time.Until(*report.FinalizeAt); remaining > 0 {
+				line += ", finalize in " + formatCountdown(remaining)
+			} else {
+				line += ", ready to finalize by tx hash"
+			}
+		}
+		return line
+	}
+	message, _, state, err := s.loadWithdrawal(txHash)
+	if err != nil {
+		return fmt.Sprintf("❌ `%s`: %s", txHash, briefError(err))
+	}
+	line := fmt.Sprintf("• `%s`: %s", txHash, crosschain.StatusDescription(message.Status))
+	switch remaining := time.Until(state.FinalizeAt); {
+	case message.Status == crosschain.StatusProven && !state.FinalizeAt.IsZero() && remaining > 0:
+		line += ", finalize in " + formatCountdown(remaining)
+	case message.Status == crosschain.StatusProven && state.ChallengePassed:
+		line += ", ready to finalize"
+	case message.Status == crosschain.StatusReadyToProve && !state.OutputProposed:
+		line += ", waiting for output"
+	}
+	return line
+}
+
+// submitReply runs /prove, /finalize or /replay. Submissions go through proveWithdrawal,
+// finalizeWithdrawal and replayWithdrawal, whose per-withdrawal lock keeps the cron loop from submitting the
+// same withdrawal at the same time.
+func (s *WithdrawalScheduler) submitReply(operation, txHash string) string {
+	if !s.beginWork() {
+		return "🛑 The scheduler is shutting down; try again once it is back"
+	}
+	defer s.inFlight.Done()
+	if _, hashOnly := withdrawalHashEntry(txHash); hashOnly {
+		return fmt.Sprintf("⏸️ `%s` is only a withdrawal hash; %s it by its L2 transaction hash instead", txHash, operation)
+	}
+	message, latestProposedBlock, state, err := s.loadWithdrawal(txHash)
+	if err != nil {
+		return "❌ " + failureDetail(err)
+	}
+	rec := crosschain.Recommend(state, txHash)
+	status := s.statusFor(txHash)
+
+	switch {
+	case operation == "prove" && (rec.Action == crosschain.ActionProve || rec.Action == crosschain.ActionReprove):
+		err = s.proveWithdrawal(txHash, status, message, latestProposedBlock)
+	case operation == "finalize" && rec.Action == crosschain.ActionFinalize:
+		err = s.finalizeWithdrawal(txHash, status, state)
+	case operation == "replay" && rec.Action == crosschain.ActionReplay:
+		err = s.replayWithdrawal(txHash, status)
+	default:
+		return fmt.Sprintf("⏸️ Not ready to %s `%s`\nNext action: %s\n%s",
+			operation, txHash, rec.Action, notify.EscapeMarkdown(rec.Reason))
+	}
+
+	switch {
+	case errors.Is(err, errSubmissionInProgress):
+		return fmt.Sprintf("⏳ A prove, finalize or replay for `%s` is already in progress", txHash)
+	case err != nil:
+		return fmt.Sprintf("❌ %s failed for `%s`\n%s", operation, txHash, failureDetail(err))
+	default:
+		return fmt.Sprintf("✅ %s done for `%s`", operation, txHash)
+	}
+}
+
+// feeLine renders the fee for a success notification, linking the L1 transaction when
+// there is an explorer; empty when the fee isn't known
+func feeLine(fee *crosschain.TxFee) string {
+	if fee == nil {
+		return ""
+	}
+	if fee.URL != "" {
+		return fmt.Sprintf("Fee: %s ETH ([%s](%s))\n", fee.ETH(), fee.L1TxHash.Hex(), fee.URL)
+	}
+	return fmt.Sprintf("Fee: %s ETH (`%s`)\n", fee.ETH(), fee.L1TxHash.Hex())
+}
+
+// formatCountdown renders a duration as "3h 5m"
+func formatCountdown(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// withdrawalValueLines renders the values of message and its decoded bridge withdrawal,
+// if any, for notifications, one field per line
+func withdrawalValueLines(message crosschain.Message, values crosschain.WithdrawalValues) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Value: %s\n", values)
+	t := message.TokenWithdrawal
+	if t == nil {
+		return b.String()
+	}
+	if t.Kind == crosschain.TokenKindERC20 {
+		fmt.Fprintf(&b, "Token: `%s` (L2 `%s`)\n", t.L1Token.Hex(), t.L2Token.Hex())
+	}
+	fmt.Fprintf(&b, "Amount: %s\n", values.Token)
+	fmt.Fprintf(&b, "From: `%s`\n", t.From.Hex())
+	fmt.Fprintf(&b, "To: `%s`\n", t.To.Hex())
+	return b.String()
+}
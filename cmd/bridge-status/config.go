@@ -0,0 +1,870 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v2"
+)
+
+// SchedulerConfig holds the scheduler settings: the --config file, if any, with
+// environment variables overriding it
+type SchedulerConfig struct {
+	Network             crosschain.Network // Chain IDs and contracts; L1_CHAINID, L2_CHAINID and the contract variables still override them
+	L1RPC               string             // Comma-separated; entries after the first are fallbacks
+	L2RPC               string
+	CheckInterval       time.Duration
+	PerTxDelay          time.Duration
+	Concurrency         int
+	Mode                string // SchedulerModePoll or SchedulerModeSubscribe
+	StateFile           string
+	Store               string // STATUS_STORE / --store: "json" (in the state file) or "sqlite:PATH"
+	LogLevel            crosschain.LogLevel
+	Withdrawals         []WithdrawalConfig
+	WatchAddresses      []common.Address
+	DiscoveryLookback   uint64
+	DiscoveryStartBlock uint64 // First L2 block of the first discovery scan; 0 scans DiscoveryLookback blocks back
+	Notifications       NotificationsConfig
+	Path                string        // Config file it was read from; empty when there is none
+	ReloadInterval      time.Duration // Time between re-reads of the file's withdrawal list; 0 reloads on SIGHUP only
+	SummarySchedule     string        // Cron spec of the daily summary, in UTC unless it sets CRON_TZ; empty disables it
+	FailureCooldown     time.Duration // How long repeats of the same failure notification are held back
+	HealthAddr          string        // HEALTH_LISTEN_ADDR: where /healthz and /readyz are served; empty disables them
+	HealthRPCMaxAge     time.Duration // HEALTH_RPC_MAX_AGE: /healthz fails when an RPC endpoint hasn't answered for this long
+	ShutdownGrace       time.Duration // SHUTDOWN_GRACE: how long SIGTERM waits for in-flight checks and submissions
+	StuckTxAlert        time.Duration // STUCK_TX_ALERT: alert when a wallet's oldest pending transaction stays unmined this long; 0 disables
+	Relay               RelayConfig
+	Targets             []TargetConfig           // Networks run side by side in one process; empty runs the settings above alone
+	Target              string                   // Set in the config of one target; see targetConfigs
+	Signer              *crosschain.SignerConfig // The target's own signer; nil uses the environment's
+}
+
+// RelayConfig enables the relay API, which takes third parties' withdrawals to prove and
+// finalize and reports each one's L1 fees to a callback URL
+type RelayConfig struct {
+	Enabled        bool   `yaml:"enabled" json:"enabled"`
+	Concurrency    int    `yaml:"concurrency" json:"concurrency"`         // Relay requests worked on at once
+	Token          string `yaml:"token" json:"token"`                     // Bearer token of the relay API (API_TOKEN)
+	CallbackSecret string `yaml:"callback_secret" json:"callback_secret"` // Signs callbacks like WEBHOOK_SECRET
+}
+
+// WithdrawalConfig is one monitored withdrawal
+type WithdrawalConfig struct {
+	Hash  string
+	Label string         // Shown next to the hash in logs and bot replies
+	From  common.Address // Wallet that signs for it; zero means the default signer
+}
+
+// NotificationsConfig selects where notifications go; empty destinations are skipped
+type NotificationsConfig struct {
+	ExplorerURL string         `yaml:"explorer_url" json:"explorer_url"`
+	Telegram    TelegramConfig `yaml:"telegram" json:"telegram"`
+	Slack       SlackConfig    `yaml:"slack" json:"slack"`
+	Webhook     WebhookConfig  `yaml:"webhook" json:"webhook"`
+}
+
+// TelegramConfig configures Telegram notifications and bot commands
+type TelegramConfig struct {
+	BotToken     string  `yaml:"bot_token" json:"bot_token"`
+	ChatID       int64   `yaml:"chat_id" json:"chat_id"`
+	TopicID      int64   `yaml:"topic_id" json:"topic_id"`
+	Commands     bool    `yaml:"commands" json:"commands"`           // Answer bot commands
+	AllowedUsers []int64 `yaml:"allowed_users" json:"allowed_users"` // User IDs allowed to /prove, /finalize and /replay
+}
+
+// SlackConfig posts through an incoming webhook, or as a bot when WebhookURL is empty
+type SlackConfig struct {
+	WebhookURL string `yaml:"webhook_url" json:"webhook_url"`
+	BotToken   string `yaml:"bot_token" json:"bot_token"`
+	Channel    string `yaml:"channel" json:"channel"`
+}
+
+// WebhookConfig posts JSON events to URL, signed with Secret when set
+type WebhookConfig struct {
+	URL    string `yaml:"url" json:"url"`
+	Secret string `yaml:"secret" json:"secret"`
+}
+
+// schedulerFile is the layout of scheduler.yaml / scheduler.json. Values that need
+// validating are kept as strings so errors can point at their line.
+type schedulerFile struct {
+	Network string `yaml:"network" json:"network"`
+	RPC     struct {
+		L1 []string `yaml:"l1" json:"l1"`
+		L2 []string `yaml:"l2" json:"l2"`
+	} `yaml:"rpc" json:"rpc"`
+	CheckInterval  string                    `yaml:"check_interval" json:"check_interval"`
+	PerTxDelay     string                    `yaml:"per_tx_delay" json:"per_tx_delay"`
+	Concurrency    int                       `yaml:"concurrency" json:"concurrency"`
+	Mode           string                    `yaml:"mode" json:"mode"`
+	ReloadInterval string                    `yaml:"reload_interval" json:"reload_interval"`
+	Summary        string                    `yaml:"summary_schedule" json:"summary_schedule"`
+	Cooldown       string                    `yaml:"failure_cooldown" json:"failure_cooldown"`
+	Store          string                    `yaml:"store" json:"store"`
+	HealthAddr     string                    `yaml:"health_addr" json:"health_addr"`
+	HealthRPCAge   string                    `yaml:"health_rpc_max_age" json:"health_rpc_max_age"`
+	ShutdownGrace  string                    `yaml:"shutdown_grace" json:"shutdown_grace"`
+	StuckTxAlert   string                    `yaml:"stuck_tx_alert" json:"stuck_tx_alert"`
+	Relay          RelayConfig               `yaml:"relay" json:"relay"`
+	Withdrawals    []schedulerFileWithdrawal `yaml:"withdrawals" json:"withdrawals"`
+	Targets        []schedulerFileTarget     `yaml:"targets" json:"targets"`
+	Notifications  NotificationsConfig       `yaml:"notifications" json:"notifications"`
+}
+
+// schedulerFileWithdrawal is one entry of a withdrawals: list in the config file
+type schedulerFileWithdrawal struct {
+	Hash  string `yaml:"hash" json:"hash"`
+	Label string `yaml:"label" json:"label"`
+	From  string `yaml:"from" json:"from"`
+}
+
+// defaultSchedulerConfig returns the settings used when neither the file nor the
+// environment sets them
+func defaultSchedulerConfig() (SchedulerConfig, error) {
+	network, err := crosschain.LookupNetwork(crosschain.DefaultNetwork)
+	if err != nil {
+		return SchedulerConfig{}, err
+	}
+	return SchedulerConfig{
+		Network:           network,
+		CheckInterval:     DefaultCheckInterval,
+		PerTxDelay:        DefaultPerTxDelay,
+		Concurrency:       DefaultCheckConcurrency,
+		Mode:              SchedulerModePoll,
+		StateFile:         DefaultStateFile,
+		Store:             DefaultStatusStore,
+		LogLevel:          crosschain.LogLevelInfo,
+		DiscoveryLookback: DefaultDiscoveryLookback,
+		ReloadInterval:    DefaultReloadInterval,
+		SummarySchedule:   "CRON_TZ=UTC " + DefaultSummarySchedule,
+		FailureCooldown:   notify.DefaultFailureCooldown,
+		HealthRPCMaxAge:   DefaultHealthRPCMaxAge,
+		ShutdownGrace:     DefaultShutdownGrace,
+		StuckTxAlert:      DefaultStuckTxAlert,
+		Relay:             RelayConfig{Concurrency: DefaultRelayConcurrency},
+	}, nil
+}
+
+// LoadSchedulerConfig reads the config file at path (YAML, or JSON for a .json file;
+// empty for none) and applies the environment variables on top
+func LoadSchedulerConfig(path string) (SchedulerConfig, error) {
+	cfg, err := defaultSchedulerConfig()
+	if err != nil {
+		return cfg, err
+	}
+	if path != "" {
+		if err := readSchedulerFile(path, &cfg); err != nil {
+			return cfg, err
+		}
+		cfg.Path = path
+	}
+	if err := applySchedulerEnv(&cfg); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.checkTargets(); err != nil {
+		return cfg, err
+	}
+	if cfg.L1RPC == "" && len(cfg.Targets) == 0 {
+		return cfg, fmt.Errorf("L1 RPC is not set (rpc.l1 in the config file or L1_RPC)")
+	}
+	if cfg.L2RPC == "" && len(cfg.Targets) == 0 {
+		return cfg, fmt.Errorf("L2 RPC is not set (rpc.l2 in the config file or L2_RPC)")
+	}
+	if cfg.PerTxDelay >= cfg.CheckInterval {
+		return cfg, fmt.Errorf("per-tx delay %s must be shorter than check interval %s", cfg.PerTxDelay, cfg.CheckInterval)
+	}
+	if cfg.Relay.Enabled && cfg.HealthAddr == "" {
+		return cfg, fmt.Errorf("the relay API is served on the health listener: set HEALTH_LISTEN_ADDR (or health_addr)")
+	}
+	if cfg.Relay.Enabled && cfg.Relay.Token == "" {
+		return cfg, fmt.Errorf("the relay API needs a bearer token: set API_TOKEN (or relay.token)")
+	}
+	return cfg, nil
+}
+
+// readSchedulerFile parses the config file into cfg. Every invalid value is reported,
+// each as path:line.
+func readSchedulerFile(path string, cfg *SchedulerConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file schedulerFile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file); err != nil {
+			return jsonFileError(path, data, err)
+		}
+	} else if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		// yaml errors already name the line
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	v := configValidator{path: path, data: data}
+	if file.Network != "" {
+		if network, err := crosschain.LookupNetwork(file.Network); err != nil {
+			v.errorf("network:", 0, "%v", err)
+		} else {
+			cfg.Network = network
+		}
+	}
+	if len(file.RPC.L1) > 0 {
+		cfg.L1RPC = strings.Join(file.RPC.L1, ",")
+	}
+	if len(file.RPC.L2) > 0 {
+		cfg.L2RPC = strings.Join(file.RPC.L2, ",")
+	}
+	if file.CheckInterval != "" {
+		if d, err := parseCheckInterval(file.CheckInterval); err != nil {
+			v.errorf("check_interval", 0, "invalid check_interval: %v", err)
+		} else {
+			cfg.CheckInterval = d
+		}
+	}
+	if file.PerTxDelay != "" {
+		if d, err := parseDuration(file.PerTxDelay); err != nil {
+			v.errorf("per_tx_delay", 0, "invalid per_tx_delay: %v", err)
+		} else {
+			cfg.PerTxDelay = d
+		}
+	}
+	if file.Concurrency != 0 {
+		if file.Concurrency < 0 {
+			v.errorf("concurrency", 0, "invalid concurrency %d: must be a positive integer", file.Concurrency)
+		} else {
+			cfg.Concurrency = file.Concurrency
+		}
+	}
+	if file.Mode != "" {
+		if mode, err := parseSchedulerMode(file.Mode); err != nil {
+			v.errorf("mode", 0, "invalid mode: %v", err)
+		} else {
+			cfg.Mode = mode
+		}
+	}
+	if file.ReloadInterval != "" {
+		if d, err := parseDuration(file.ReloadInterval); err != nil {
+			v.errorf("reload_interval", 0, "invalid reload_interval: %v", err)
+		} else {
+			cfg.ReloadInterval = d
+		}
+	}
+	if file.Store != "" {
+		cfg.Store = file.Store
+	}
+	if file.HealthAddr != "" {
+		cfg.HealthAddr = file.HealthAddr
+	}
+	if file.HealthRPCAge != "" {
+		if d, err := parseDuration(file.HealthRPCAge); err != nil || d == 0 {
+			v.errorf("health_rpc_max_age", 0, "invalid health_rpc_max_age: %q must be a positive duration like 5m", file.HealthRPCAge)
+		} else {
+			cfg.HealthRPCMaxAge = d
+		}
+	}
+	if file.Relay.Concurrency < 0 {
+		v.errorf("relay:", 0, "invalid relay concurrency %d: must be a positive integer", file.Relay.Concurrency)
+	} else {
+		concurrency := cfg.Relay.Concurrency
+		cfg.Relay = file.Relay
+		if cfg.Relay.Concurrency == 0 {
+			cfg.Relay.Concurrency = concurrency
+		}
+	}
+	if file.ShutdownGrace != "" {
+		if d, err := parseDuration(file.ShutdownGrace); err != nil {
+			v.errorf("shutdown_grace", 0, "invalid shutdown_grace: %v", err)
+		} else {
+			cfg.ShutdownGrace = d
+		}
+	}
+	if file.StuckTxAlert != "" {
+		if d, err := parseDuration(file.StuckTxAlert); err != nil {
+			v.errorf("stuck_tx_alert", 0, "invalid stuck_tx_alert: %v", err)
+		} else {
+			cfg.StuckTxAlert = d
+		}
+	}
+	if file.Cooldown != "" {
+		if d, err := parseDuration(file.Cooldown); err != nil {
+			v.errorf("failure_cooldown", 0, "invalid failure_cooldown: %v", err)
+		} else {
+			cfg.FailureCooldown = d
+		}
+	}
+	if file.Summary != "" {
+		if spec, err := parseSummarySchedule(file.Summary); err != nil {
+			v.errorf("summary_schedule", 0, "invalid summary_schedule: %v", err)
+		} else {
+			cfg.SummarySchedule = spec
+		}
+	}
+
+	seen := make(map[string]int)
+	cfg.Withdrawals = append(cfg.Withdrawals, v.withdrawals(file.Withdrawals, seen)...)
+	cfg.Targets = v.targets(file, cfg.Network, seen)
+
+	cfg.Notifications = file.Notifications
+	return v.err()
+}
+
+// withdrawals validates a withdrawals: list. seen counts the occurrences of each hash in
+// the file so far, to locate its errors.
+func (v *configValidator) withdrawals(entries []schedulerFileWithdrawal, seen map[string]int) []WithdrawalConfig {
+	var withdrawals []WithdrawalConfig
+	for i, w := range entries {
+		if w.Hash == "" {
+			v.errorf("withdrawals", 0, "withdrawal #%d has no hash", i+1)
+			continue
+		}
+		occurrence := seen[w.Hash]
+		seen[w.Hash]++
+		hash, hashOnly := strings.CutPrefix(w.Hash, withdrawalHashPrefix)
+		if !isTxHash(hash) {
+			v.errorf(w.Hash, occurrence, "invalid withdrawal hash %q: expected 0x followed by 64 hex digits", w.Hash)
+			continue
+		}
+		if occurrence > 0 {
+			v.errorf(w.Hash, occurrence, "withdrawal %s is listed more than once", w.Hash)
+			continue
+		}
+		withdrawal := WithdrawalConfig{Hash: w.Hash, Label: w.Label}
+		if w.From != "" {
+			if hashOnly {
+				v.errorf(w.From, 0, "from wallet %q for %s: %s entries are only monitored, never signed for", w.From, w.Hash, withdrawalHashPrefix)
+				continue
+			}
+			if !common.IsHexAddress(w.From) {
+				v.errorf(w.From, 0, "invalid from wallet %q for %s: must be an address", w.From, w.Hash)
+				continue
+			}
+			withdrawal.From = common.HexToAddress(w.From)
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	return withdrawals
+}
+
+// configValidator collects config file errors, each located by the line of the text it
+// is about
+type configValidator struct {
+	path string
+	data []byte
+	errs []error
+}
+
+// errorf records an error at the line holding the (skip+1)th occurrence of needle, or
+// without a line when needle isn't found
+func (v *configValidator) errorf(needle string, skip int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if line := lineOf(v.data, needle, skip); line > 0 {
+		v.errs = append(v.errs, fmt.Errorf("%s:%d: %s", v.path, line, msg))
+	} else {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", v.path, msg))
+	}
+}
+
+// err returns the recorded errors, one per line, or nil
+func (v *configValidator) err() error {
+	return errors.Join(v.errs...)
+}
+
+// lineOf returns the 1-based line of the (skip+1)th occurrence of needle in data, or 0
+func lineOf(data []byte, needle string, skip int) int {
+	offset := 0
+	for {
+		i := bytes.Index(data[offset:], []byte(needle))
+		if i < 0 {
+			return 0
+		}
+		if skip == 0 {
+			return bytes.Count(data[:offset+i], []byte("\n")) + 1
+		}
+		skip--
+		offset += i + len(needle)
+	}
+}
+
+// jsonFileError adds the line to a JSON decoding error
+func jsonFileError(path string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("%s:%d: %w", path, bytes.Count(data[:syntaxErr.Offset], []byte("\n"))+1, err)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("%s:%d: %w", path, bytes.Count(data[:typeErr.Offset], []byte("\n"))+1, err)
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if line := lineOf(data, field, 0); line > 0 {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// applySchedulerEnv overrides cfg with the environment variables that are set.
+// WITHDRAWAL_TX_HASH replaces the file's withdrawals rather than adding to them.
+func applySchedulerEnv(cfg *SchedulerConfig) error {
+	var err error
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if cfg.LogLevel, err = crosschain.ParseLogLevel(v); err != nil {
+			return err
+		}
+	}
+	if v := os.Getenv("NETWORK"); v != "" {
+		if cfg.Network, err = crosschain.LookupNetwork(v); err != nil {
+			return err
+		}
+	}
+	if v := os.Getenv("L1_RPC"); v != "" {
+		cfg.L1RPC = v
+	}
+	if v := os.Getenv("L2_RPC"); v != "" {
+		cfg.L2RPC = v
+	}
+
+	if v := os.Getenv("CHECK_INTERVAL"); v != "" {
+		if cfg.CheckInterval, err = parseCheckInterval(v); err != nil {
+			return fmt.Errorf("invalid CHECK_INTERVAL: %w", err)
+		}
+	}
+	if cfg.PerTxDelay, err = durationEnv("PER_TX_DELAY", cfg.PerTxDelay); err != nil {
+		return err
+	}
+	if v := os.Getenv("CHECK_CONCURRENCY"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &cfg.Concurrency); err != nil || cfg.Concurrency < 1 {
+			return fmt.Errorf("invalid CHECK_CONCURRENCY %q: must be a positive integer", v)
+		}
+	}
+	if v := os.Getenv("SCHEDULER_MODE"); v != "" {
+		if cfg.Mode, err = parseSchedulerMode(v); err != nil {
+			return fmt.Errorf("invalid SCHEDULER_MODE: %w", err)
+		}
+	}
+	if v := os.Getenv("STATE_FILE"); v != "" {
+		cfg.StateFile = v
+	}
+	if v := os.Getenv("STATUS_STORE"); v != "" {
+		cfg.Store = v
+	}
+	if cfg.ReloadInterval, err = durationEnv("CONFIG_RELOAD_INTERVAL", cfg.ReloadInterval); err != nil {
+		return err
+	}
+	if cfg.FailureCooldown, err = durationEnv("FAILURE_NOTIFY_COOLDOWN", cfg.FailureCooldown); err != nil {
+		return err
+	}
+	if v := os.Getenv("HEALTH_LISTEN_ADDR"); v != "" {
+		cfg.HealthAddr = v
+	}
+	if cfg.HealthRPCMaxAge, err = durationEnv("HEALTH_RPC_MAX_AGE", cfg.HealthRPCMaxAge); err != nil {
+		return err
+	}
+	if cfg.HealthRPCMaxAge <= 0 {
+		return fmt.Errorf("invalid HEALTH_RPC_MAX_AGE: must be a positive duration like 5m")
+	}
+	if cfg.ShutdownGrace, err = durationEnv("SHUTDOWN_GRACE", cfg.ShutdownGrace); err != nil {
+		return err
+	}
+	if cfg.StuckTxAlert, err = durationEnv("STUCK_TX_ALERT", cfg.StuckTxAlert); err != nil {
+		return err
+	}
+	if v := os.Getenv("RELAY_ENABLED"); v != "" {
+		if cfg.Relay.Enabled, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("invalid RELAY_ENABLED %q: must be true or false", v)
+		}
+	}
+	if v := os.Getenv("RELAY_CONCURRENCY"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &cfg.Relay.Concurrency); err != nil || cfg.Relay.Concurrency < 1 {
+			return fmt.Errorf("invalid RELAY_CONCURRENCY %q: must be a positive integer", v)
+		}
+	}
+	if v := os.Getenv("API_TOKEN"); v != "" {
+		cfg.Relay.Token = v
+	}
+	if v := os.Getenv("RELAY_CALLBACK_SECRET"); v != "" {
+		cfg.Relay.CallbackSecret = v
+	}
+	if v := os.Getenv("SUMMARY_SCHEDULE"); v != "" {
+		if cfg.SummarySchedule, err = parseSummarySchedule(v); err != nil {
+			return fmt.Errorf("invalid SUMMARY_SCHEDULE: %w", err)
+		}
+	}
+
+	// Comma-separated hashes; each may name the wallet that signs for it as hash@address
+	// and a label as hash:label, in that order (hash@address:label). A withdrawal hash
+	// prefixed with withdrawalhash: is only monitored (withdrawalhash:hash:label).
+	if v := os.Getenv("WITHDRAWAL_TX_HASH"); v != "" {
+		cfg.Withdrawals = nil
+		var hashes []string
+		for i, entry := range splitAndTrim(v, ",") {
+			if entry, hashOnly := strings.CutPrefix(entry, withdrawalHashPrefix); hashOnly {
+				hash, label, _ := strings.Cut(entry, ":")
+				if err := crosschain.ValidateWithdrawalHash(hash); err != nil {
+					return fmt.Errorf("invalid WITHDRAWAL_TX_HASH: entry %d: %w", i+1, err)
+				}
+				cfg.Withdrawals = append(cfg.Withdrawals, WithdrawalConfig{Hash: withdrawalHashPrefix + hash, Label: strings.TrimSpace(label)})
+				continue
+			}
+			entry, label, _ := strings.Cut(entry, ":")
+			hash, wallet, hasWallet := strings.Cut(entry, "@")
+			withdrawal := WithdrawalConfig{Hash: hash, Label: strings.TrimSpace(label)}
+			if hasWallet {
+				if !common.IsHexAddress(wallet) {
+					return fmt.Errorf("invalid WITHDRAWAL_TX_HASH: entry %d: invalid wallet %q for %s: expected a 0x address", i+1, wallet, hash)
+				}
+				withdrawal.From = common.HexToAddress(wallet)
+			}
+			hashes = append(hashes, hash)
+			cfg.Withdrawals = append(cfg.Withdrawals, withdrawal)
+		}
+		if err := crosschain.ValidateTxHashes(hashes); err != nil {
+			return fmt.Errorf("invalid WITHDRAWAL_TX_HASH: %w", err)
+		}
+	}
+
+	// Optional senders whose withdrawals are discovered automatically
+	for _, addr := range splitAndTrim(os.Getenv("WATCH_ADDRESSES"), ",") {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid address %q in WATCH_ADDRESSES", addr)
+		}
+		cfg.WatchAddresses = append(cfg.WatchAddresses, common.HexToAddress(addr))
+	}
+	if v := os.Getenv("DISCOVERY_LOOKBACK_BLOCKS"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &cfg.DiscoveryLookback); err != nil {
+			return fmt.Errorf("invalid DISCOVERY_LOOKBACK_BLOCKS %q: must be a block count", v)
+		}
+	}
+	if v := os.Getenv("DISCOVERY_START_BLOCK"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &cfg.DiscoveryStartBlock); err != nil || cfg.DiscoveryStartBlock == 0 {
+			return fmt.Errorf("invalid DISCOVERY_START_BLOCK %q: must be a positive block number", v)
+		}
+	}
+
+	n := &cfg.Notifications
+	for key, field := range map[string]*string{
+		"TELEGRAM_BOT_TOKEN":  &n.Telegram.BotToken,
+		"SLACK_WEBHOOK_URL":   &n.Slack.WebhookURL,
+		"SLACK_BOT_TOKEN":     &n.Slack.BotToken,
+		"SLACK_CHANNEL":       &n.Slack.Channel,
+		"WEBHOOK_URL":         &n.Webhook.URL,
+		"WEBHOOK_SECRET":      &n.Webhook.Secret,
+		"MANTLE_EXPLORER_URL": &n.ExplorerURL,
+	} {
+		if v := os.Getenv(key); v != "" {
+			*field = v
+		}
+	}
+	for key, field := range map[string]*int64{
+		"TELEGRAM_CHAT_ID":  &n.Telegram.ChatID,
+		"TELEGRAM_TOPIC_ID": &n.Telegram.TopicID,
+	} {
+		if v := os.Getenv(key); v != "" {
+			if *field, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return fmt.Errorf("invalid %s %q: must be an integer", key, v)
+			}
+		}
+	}
+	if v := os.Getenv("TELEGRAM_COMMANDS"); v != "" {
+		if n.Telegram.Commands, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("invalid TELEGRAM_COMMANDS %q: must be true or false", v)
+		}
+	}
+	if v := os.Getenv("TELEGRAM_ALLOWED_USERS"); v != "" {
+		n.Telegram.AllowedUsers = nil
+		for _, id := range splitAndTrim(v, ",") {
+			userID, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid user ID %q in TELEGRAM_ALLOWED_USERS", id)
+			}
+			n.Telegram.AllowedUsers = append(n.Telegram.AllowedUsers, userID)
+		}
+	}
+	return nil
+}
+
+// parseDuration parses a non-negative duration like 30s or 2m
+func parseDuration(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%q is not a duration like 30s or 2m", v)
+	}
+	return d, nil
+}
+
+// parseCheckInterval parses a check interval and enforces MinCheckInterval
+func parseCheckInterval(v string) (time.Duration, error) {
+	d, err := parseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if d < MinCheckInterval {
+		return 0, fmt.Errorf("%s is too short: minimum is %s", d, MinCheckInterval)
+	}
+	return d, nil
+}
+
+// parseSchedulerMode validates a SCHEDULER_MODE value
+func parseSchedulerMode(v string) (string, error) {
+	switch mode := strings.ToLower(v); mode {
+	case SchedulerModePoll, SchedulerModeSubscribe:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%q must be %s or %s", v, SchedulerModePoll, SchedulerModeSubscribe)
+}
+
+// parseSummarySchedule checks a standard 5-field cron spec, or "off", and pins it to UTC
+// unless it names a time zone itself
+func parseSummarySchedule(v string) (string, error) {
+	if strings.EqualFold(v, "off") {
+		return "", nil
+	}
+	spec := v
+	if !strings.HasPrefix(v, "CRON_TZ=") && !strings.HasPrefix(v, "TZ=") {
+		spec = "CRON_TZ=UTC " + v
+	}
+	if _, err := cron.ParseStandard(spec); err != nil {
+		return "", fmt.Errorf("%q: %w", v, err)
+	}
+	return spec, nil
+}
+
+// withdrawalHashEntry returns the withdrawal hash of a monitored entry that has only
+// that, written withdrawalhash:0x...; ok is false for transaction hashes
+func withdrawalHashEntry(txHash string) (hash common.Hash, ok bool) {
+	hex, ok := strings.CutPrefix(txHash, withdrawalHashPrefix)
+	if !ok {
+		return common.Hash{}, false
+	}
+	return common.HexToHash(hex), true
+}
+
+// isTxHash reports whether s is a 0x-prefixed 32-byte hash
+func isTxHash(s string) bool {
+	_, err := hexutil.Decode(s)
+	return err == nil && len(s) == 66
+}
+
+// ValidateConfig checks what the file can't show on its own: both RPC endpoints answer
+// on the expected networks with the contracts deployed, the Telegram bot token works and
+// every from wallet is a configured signer. Nothing is sent and no check loop is started.
+func ValidateConfig(cfg SchedulerConfig) error {
+	logger := schedulerLogger(cfg)
+	ctx := context.Background()
+
+	logger.Infof("🌐 Network: %s (L1 chain %d, L2 chain %d)", cfg.Network.Name, cfg.Network.L1ChainID, cfg.Network.L2ChainID)
+	messenger, err := newSchedulerMessenger(cfg, logger)
+	if err != nil {
+		return err
+	}
+	l1Head, err := messenger.GetLatestL1Block(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get L1 head: %w", err)
+	}
+	l2Head, err := messenger.GetLatestL2Block(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get L2 head: %w", err)
+	}
+	logger.Infof("✅ RPC endpoints reachable (L1 head %d, L2 head %d)", l1Head, l2Head)
+
+	if tg := cfg.Notifications.Telegram; tg.BotToken != "" {
+		telegram, err := notify.NewTelegram(tg.BotToken, tg.ChatID, tg.TopicID)
+		if err != nil {
+			return err
+		}
+		logger.Infof("✅ Telegram bot token works: @%s", telegram.UserName())
+	}
+
+	if messenger.HasSigner() {
+		if err := checkWallets(ctx, messenger, cfg.Withdrawals, logger); err != nil {
+			return err
+		}
+	} else {
+		logger.Infof("👀 No signing credentials: the scheduler would run in monitor-only mode")
+	}
+
+	logger.Infof("✅ Config OK: %d withdrawal(s), %d watched address(es), check interval %s, mode %s",
+		len(cfg.Withdrawals), len(cfg.WatchAddresses), cfg.CheckInterval, cfg.Mode)
+	return nil
+}
+
+// durationEnv parses a duration string (e.g. "2m") from the environment
+func durationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration like 30s or 2m", key, v)
+	}
+	return d, nil
+}
+
+// splitAndTrim splits a string by delimiter and trims whitespace
+func splitAndTrim(s, delimiter string) []string {
+	parts := strings.Split(s, delimiter)
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// watchConfig reloads the withdrawal list from the config file on SIGHUP and every
+// reloadInterval until the scheduler stops
+func (s *WithdrawalScheduler) watchConfig() {
+	if os.Getenv("WITHDRAWAL_TX_HASH") != "" {
+		s.logger.Warnf("⚠️  WITHDRAWAL_TX_HASH overrides the withdrawals in %s, so reloading won't change them", s.configPath)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	var tick <-chan time.Time
+	if s.reloadInterval > 0 {
+		ticker := time.NewTicker(s.reloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// A broken file is reported once rather than on every tick
+	lastErr := ""
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-hup:
+			s.logger.Infof("🔄 SIGHUP received, reloading %s", s.configPath)
+			lastErr = ""
+		case <-tick:
+		}
+		err := s.reloadWithdrawals()
+		switch {
+		case err != nil && err.Error() != lastErr:
+			s.logger.Errorf("❌ Failed to reload %s, keeping the current withdrawals: %v", s.configPath, err)
+			lastErr = err.Error()
+		case err == nil:
+			lastErr = ""
+		}
+	}
+}
+
+// reloadWithdrawals re-reads the config file and applies changes to its withdrawal list;
+// other settings take effect on restart. New withdrawals are checked from the next
+// cycle. Removed ones are retired: no longer checked, but a prove or finalize already in
+// flight finishes, and what was notified is kept in the state file so re-adding them
+// doesn't notify again. Discovered withdrawals are left alone.
+func (s *WithdrawalScheduler) reloadWithdrawals() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	cfg, err := LoadSchedulerConfig(s.configPath)
+	if err != nil {
+		return err
+	}
+	if s.target != "" {
+		if cfg, err = cfg.targetConfig(s.target); err != nil {
+			return err
+		}
+	}
+
+	// Catch a new wallet mapping that no signer serves before it's applied
+	s.mu.Lock()
+	var newMappings []WithdrawalConfig
+	for _, w := range cfg.Withdrawals {
+		if status := s.withdrawalStatus[w.Hash]; status == nil && w.From != (common.Address{}) {
+			newMappings = append(newMappings, w)
+		}
+	}
+	s.mu.Unlock()
+	if len(newMappings) > 0 && !s.monitorOnly {
+		if err := checkWallets(s.ctx, s.messenger, newMappings, s.logger); err != nil {
+			return err
+		}
+	}
+
+	wanted := make(map[string]bool)
+	var added, removed []string
+	s.mu.Lock()
+	monitored := make(map[string]bool)
+	for _, hash := range s.withdrawalHashes {
+		monitored[hash] = true
+	}
+	for _, w := range cfg.Withdrawals {
+		wanted[w.Hash] = true
+		status := s.withdrawalStatus[w.Hash]
+		if status == nil {
+			status = &WithdrawalStatus{from: w.From}
+			s.withdrawalStatus[w.Hash] = status
+		} else if status.from != w.From {
+			s.logger.Warnf("⚠️  Wallet change for %s takes effect after a restart", w.Hash)
+		}
+		status.label = w.Label
+		if !monitored[w.Hash] {
+			status.retiredAt = time.Time{}
+			added = append(added, w.Hash)
+		}
+	}
+	kept := make([]string, 0, len(s.withdrawalHashes)+len(added))
+	for _, hash := range s.withdrawalHashes {
+		if status := s.withdrawalStatus[hash]; !wanted[hash] && !status.discovered {
+			status.retiredAt = time.Now()
+			removed = append(removed, hash)
+			continue
+		}
+		kept = append(kept, hash)
+	}
+	s.withdrawalHashes = append(kept, added...)
+	s.mu.Unlock()
+
+	if len(added) == 0 && len(removed) == 0 {
+		s.logger.Debugf("🔄 Reloaded %s: withdrawal list unchanged", s.configPath)
+		return nil
+	}
+	s.saveState()
+
+	s.logger.Infof("🔄 Reloaded %s: %d withdrawal(s) added, %d removed", s.configPath, len(added), len(removed))
+	text := "🔄 *Withdrawal List Reloaded*\n"
+	for _, group := range []struct {
+		title  string
+		hashes []string
+	}{{"Added", added}, {"Removed", removed}} {
+		if len(group.hashes) == 0 {
+			continue
+		}
+		text += fmt.Sprintf("\n%s (%d):\n", group.title, len(group.hashes))
+		for _, hash := range group.hashes {
+			s.logger.Infof("   %s: %s", strings.ToLower(group.title), s.displayName(hash))
+			if label := s.labelOf(hash); label != "" {
+				text += fmt.Sprintf("• %s `%s`\n", notify.EscapeMarkdown(label), hash)
+			} else {
+				text += fmt.Sprintf("• `%s`\n", hash)
+			}
+		}
+	}
+	s.notify(notify.EventWithdrawalsReloaded, "", text)
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+)
+
+// scanGuardianEvents scans L1 for guardian events since the last scan: OptimismPortal
+// pauses, L2OutputOracle finalization period changes and output deletions. It runs
+// before every check cycle, so they are seen even when L1_RPC can't subscribe or the
+// subscription missed them.
+func (s *WithdrawalScheduler) scanGuardianEvents() error {
+	head, err := s.messenger.GetLatestL1Block(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get L1 head: %w", err)
+	}
+	backfill := s.lastGuardianScan == 0
+	from := s.lastGuardianScan + 1
+	if backfill && head > guardianLookback {
+		from = head - guardianLookback
+	}
+	if from > head {
+		return nil
+	}
+
+	events, err := s.messenger.ListGuardianEvents(s.ctx, from, head)
+	if err != nil {
+		return err
+	}
+	s.lastGuardianScan = head
+	for _, ev := range events {
+		s.onGuardianEvent(ev, backfill)
+	}
+	return nil
+}
+
+// watchGuardianEvents handles guardian events as soon as they are mined. The scan before
+// each check cycle catches up on whatever the subscription misses.
+func (s *WithdrawalScheduler) watchGuardianEvents() {
+	err := s.messenger.WatchGuardianEvents(s.ctx, func(ev crosschain.GuardianEvent) {
+		s.onGuardianEvent(ev, false)
+	})
+	if err != nil && s.ctx.Err() == nil {
+		s.logger.Warnf("⚠️  Not watching guardian events (%v); they are scanned every check", err)
+	}
+}
+
+// onGuardianEvent tells the operator what ev means for the monitored withdrawals. Pauses
+// and period changes re-read the contract, so an event seen twice, or one from before
+// the current state, only alerts when that state changed; a deletion is handled once.
+// backfill is set for events the first scan found, which predate the scheduler.
+func (s *WithdrawalScheduler) onGuardianEvent(ev crosschain.GuardianEvent, backfill bool) {
+	l1TxHash := ev.L1TxHash.Hex()
+	if backfill {
+		l1TxHash = ""
+	}
+	switch ev.Kind {
+	case crosschain.GuardianPortalPaused, crosschain.GuardianPortalUnpaused:
+		paused, err := s.messenger.PortalPaused(s.ctx)
+		if err != nil {
+			s.logger.Warnf("⚠️  Failed to read whether OptimismPortal is paused: %v", err)
+			return
+		}
+		s.setPortalPaused(paused, l1TxHash)
+
+	case crosschain.GuardianFinalizationPeriodUpdated:
+		s.logger.Infof("🔀 finalizationPeriodSeconds updated from %s to %s in L1 tx %s",
+			ev.OldFinalizationPeriod, ev.NewFinalizationPeriod, ev.L1TxHash.Hex())
+		params, err := s.messenger.RefreshFinalizationParams(s.ctx)
+		if err != nil {
+			s.logger.Warnf("⚠️  Failed to read the finalization period: %v", err)
+			return
+		}
+		s.onFinalizationParams(params, l1TxHash)
+
+	case crosschain.GuardianOutputsDeleted:
+		s.mu.Lock()
+		seen := s.seenDeletions[ev.L1TxHash]
+		s.seenDeletions[ev.L1TxHash] = true
+		s.mu.Unlock()
+		if !seen {
+			s.onOutputsDeleted(*ev.Deletion, backfill)
+		}
+	}
+}
+
+// onOutputsDeleted resets the withdrawals proven against one of the deleted outputs: they
+// lose their finalize countdown, and the check that follows sees the missing output and
+// re-proves once a new one covers them. One alert lists the affected withdrawals;
+// deletions from before the scheduler started are only alerted if they affect any.
+func (s *WithdrawalScheduler) onOutputsDeleted(d crosschain.OutputDeletion, backfill bool) {
+	s.logger.Warnf("🗑️  Outputs #%d-#%d were deleted in L1 tx %s", d.NewNextOutputIndex, d.PrevNextOutputIndex-1, d.L1TxHash.Hex())
+
+	var affected, hashOnly []string
+	s.mu.Lock()
+	for _, txHash := range s.withdrawalHashes {
+		status := s.withdrawalStatus[txHash]
+		if status == nil || status.finalized || status.provenance == nil || !d.Covers(status.provenance.OutputIndex) {
+			continue
+		}
+		if _, ok := withdrawalHashEntry(txHash); ok {
+			hashOnly = append(hashOnly, txHash)
+			continue
+		}
+		// Back to ready-to-prove as far as the countdown and its notifications go
+		status.provenance = nil
+		status.finalizeAt = time.Time{}
+		status.sentWaitingMessage = false
+		status.sent5MinuteReminder = false
+		affected = append(affected, txHash)
+	}
+	s.mu.Unlock()
+
+	for _, txHash := range affected {
+		s.logger.Warnf("♻️  %s was proven against a deleted output and will be proven again", s.displayName(txHash))
+	}
+	for _, txHash := range hashOnly {
+		s.logger.Warnf("🗑️  %s was proven against a deleted output; it must be proven again with its L2 transaction", s.displayName(txHash))
+	}
+	if backfill && len(affected) == 0 && len(hashOnly) == 0 {
+		return
+	}
+
+	impact := "None of the monitored withdrawals was proven against them."
+	if len(affected) > 0 {
+		impact = fmt.Sprintf("%d monitored withdrawal(s) were proven against them and must be re-proven. "+
+			"This happens on its own once a new output covers them:\n`%s`",
+			len(affected), strings.Join(affected, "`\n`"))
+	}
+	if len(hashOnly) > 0 {
+		impact += fmt.Sprintf("\n%d withdrawal(s) monitored by withdrawal hash only must be proven again with their L2 transaction:\n`%s`",
+			len(hashOnly), strings.Join(hashOnly, "`\n`"))
+	}
+	s.notify(notify.EventOutputsDeleted, "", fmt.Sprintf(
+		"🗑️ *Outputs Deleted*\n\n"+
+			"Outputs: #%d-#%d\n"+
+			"L1 tx: `%s`\n"+
+			"%s",
+		d.NewNextOutputIndex, d.PrevNextOutputIndex-1, d.L1TxHash.Hex(), impact))
+}
+
+// setPortalPaused records OptimismPortal's paused flag and alerts once when it changes.
+// While the portal is paused every prove and finalize is skipped, since it would revert;
+// the first check after the guardian unpauses it submits them again. l1TxHash is the
+// pause or unpause transaction, if known.
+func (s *WithdrawalScheduler) setPortalPaused(paused bool, l1TxHash string) {
+	s.mu.Lock()
+	was := s.portalPaused
+	s.portalPaused = &paused
+	waiting := 0
+	for _, txHash := range s.withdrawalHashes {
+		if status := s.withdrawalStatus[txHash]; status == nil || (!status.finalized && !status.notAWithdrawal) {
+			waiting++
+		}
+	}
+	s.mu.Unlock()
+
+	var text string
+	switch {
+	case paused && (was == nil || !*was):
+		s.logger.Warnf("⏸️  OptimismPortal %s is paused by the guardian; proves and finalizes of %d withdrawal(s) are skipped until it is unpaused",
+			s.messenger.Contracts.L1.OptimismPortal, waiting)
+		text = fmt.Sprintf(
+			"⏸️ *OptimismPortal Paused*\n\n"+
+				"Portal: `%s`\n"+
+				"The guardian paused the portal, so proves and finalizes would revert. "+
+				"%d monitored withdrawal(s) wait while it stays paused and resume on their own once it is unpaused.",
+			s.messenger.Contracts.L1.OptimismPortal, waiting)
+		if l1TxHash != "" {
+			text += fmt.Sprintf("\nL1 tx: `%s`", l1TxHash)
+		}
+		s.notify(notify.EventPortalPaused, "", text)
+	case !paused && was != nil && *was:
+		s.logger.Infof("▶️  OptimismPortal was unpaused; resuming proves and finalizes of %d withdrawal(s)", waiting)
+		text = fmt.Sprintf(
+			"▶️ *OptimismPortal Unpaused*\n\n"+
+				"Portal: `%s`\n"+
+				"Proves and finalizes of %d monitored withdrawal(s) resume.",
+			s.messenger.Contracts.L1.OptimismPortal, waiting)
+		if l1TxHash != "" {
+			text += fmt.Sprintf("\nL1 tx: `%s`", l1TxHash)
+		}
+		s.notify(notify.EventPortalUnpaused, "", text)
+	}
+}
+
+// pausedFlag returns OptimismPortal's paused flag last seen, or nil before the first read
+func (s *WithdrawalScheduler) pausedFlag() *bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.portalPaused
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/server"
+)
+
+// serveHealth serves /healthz and /readyz, and the relay API when it is enabled, on
+// s.healthAddr in the background
+func (s *WithdrawalScheduler) serveHealth() *http.Server {
+	mux := server.HealthMux(s.liveness, s.readiness, s.pausedFlag)
+	mux.HandleFunc("GET /metrics", server.MetricsHandler(s.messenger.RateLimitStats))
+	if s.relay.Enabled {
+		server.RegisterRelay(mux, s.relay.Token, s)
+	}
+	healthServer := &http.Server{
+		Addr:              s.healthAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		s.logger.Infof("🩺 Health probes listening on %s (/healthz, /readyz, /metrics)", s.healthAddr)
+		if s.relay.Enabled {
+			s.logger.Infof("🤝 Relay API listening on %s (/relay/withdrawals, %d worker(s))", s.healthAddr, s.relay.Concurrency)
+		}
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf("❌ Health listener failed: %v", err)
+		}
+	}()
+	return healthServer
+}
+
+// stopHealth shuts the health listener down, letting in-flight probes finish
+func (s *WithdrawalScheduler) stopHealth(healthServer *http.Server) {
+	if healthServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := healthServer.Shutdown(ctx); err != nil {
+		s.logger.Warnf("⚠️  Failed to stop the health listener: %v", err)
+	}
+}
+
+// pingRPC asks both RPC endpoints for their chain ID several times per healthRPCMaxAge,
+// recording when each last answered, until the scheduler stops
+func (s *WithdrawalScheduler) pingRPC() {
+	ticker := time.NewTicker(max(s.healthRPCMaxAge/4, time.Second))
+	defer ticker.Stop()
+	for {
+		for _, endpoint := range []struct {
+			client crosschain.EthClient
+			last   *time.Time
+		}{
+			{s.messenger.ClientL1, &s.lastL1Answer},
+			{s.messenger.ClientL2, &s.lastL2Answer},
+		} {
+			ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+			_, err := endpoint.client.ChainID(ctx)
+			cancel()
+			if err == nil {
+				s.mu.Lock()
+				*endpoint.last = time.Now()
+				s.mu.Unlock()
+			}
+		}
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// cycleCompleted records the end of a check cycle that wasn't cut short by shutdown
+func (s *WithdrawalScheduler) cycleCompleted() {
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.mu.Lock()
+	s.lastCycleAt = time.Now()
+	s.mu.Unlock()
+}
+
+// liveness fails when an RPC endpoint hasn't answered a ChainID call within
+// healthRPCMaxAge or no check cycle has completed within 2× the check interval
+func (s *WithdrawalScheduler) liveness(context.Context) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, endpoint := range []struct {
+		name string
+		last time.Time
+	}{
+		{"L1", s.lastL1Answer},
+		{"L2", s.lastL2Answer},
+	} {
+		if now.Sub(endpoint.last) > s.healthRPCMaxAge {
+			if endpoint.last.IsZero() {
+				return fmt.Errorf("%s RPC has not answered yet", endpoint.name)
+			}
+			return fmt.Errorf("%s RPC last answered %s ago", endpoint.name, now.Sub(endpoint.last).Round(time.Second))
+		}
+	}
+
+	lastCycle := s.lastCycleAt
+	if lastCycle.IsZero() {
+		lastCycle = s.startedAt
+	}
+	if since := now.Sub(lastCycle); since > 2*s.checkInterval {
+		if s.lastCycleAt.IsZero() {
+			return fmt.Errorf("no check cycle has completed in the %s since start", since.Round(time.Second))
+		}
+		return fmt.Errorf("last check cycle completed %s ago (check interval %s)", since.Round(time.Second), s.checkInterval)
+	}
+	return nil
+}
+
+// readiness fails until Start has finished setting up, and while one of the critical
+// doctor checks fails: the RPC endpoints and their chain IDs, the L1 contracts and, when
+// not monitor-only, the signers. The config was validated before the scheduler existed.
+func (s *WithdrawalScheduler) readiness(ctx context.Context) error {
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	if !running {
+		return errors.New("scheduler is still starting")
+	}
+	return crosschain.FirstFailure(s.messenger.RunChecks(ctx, s.messenger.ReadinessChecks(!s.monitorOnly)))
+}
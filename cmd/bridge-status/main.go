@@ -0,0 +1,288 @@
+// Command bridge-status checks, proves and finalizes Mantle withdrawals, serves them over
+// HTTP and runs the withdrawal scheduler. The cross_chain package does the work; the
+// commands parse flags and print results.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/internal/tracing"
+	"mantle-claim-crossing/internal/version"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for scripting; anything not listed exits with exitFailure
+const (
+	exitOK                    = 0
+	exitFailure               = 1
+	exitNotProven             = 2
+	exitChallengePeriodActive = 3
+	exitAlreadyFinalized      = 4
+	exitOutputNotProposed     = 5
+	exitRPC                   = 6
+	exitReverted              = 7
+	exitInsufficientFunds     = 8
+	exitCostTooHigh           = 9
+	exitNotAWithdrawal        = 10
+	exitProvingOutputDeleted  = 11
+	exitTimeout               = 12
+	exitStaleProof            = 13
+	exitUnconfirmed           = 14
+	exitInvalidInput          = 15
+	exitNotFinalized          = 16
+	exitNothingToReplay       = 17
+	exitReplayFailed          = 18
+	exitNothingPending        = 19
+	exitWithdrawalTxNotFound  = 20
+	exitWithdrawalLocked      = 21
+	exitPortalPaused          = 22
+)
+
+// exitCode maps an operation error to the process exit code
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, crosschain.ErrInvalidInput), errors.Is(err, crosschain.ErrDuplicateTxHash):
+		return exitInvalidInput
+	case errors.Is(err, crosschain.ErrNotProven):
+		return exitNotProven
+	case errors.Is(err, crosschain.ErrChallengePeriodActive):
+		return exitChallengePeriodActive
+	case errors.Is(err, crosschain.ErrAlreadyFinalized):
+		return exitAlreadyFinalized
+	case errors.Is(err, crosschain.ErrNotFinalized):
+		return exitNotFinalized
+	case errors.Is(err, crosschain.ErrNothingToReplay):
+		return exitNothingToReplay
+	case errors.Is(err, crosschain.ErrReplayFailed):
+		return exitReplayFailed
+	case errors.Is(err, crosschain.ErrNothingPending):
+		return exitNothingPending
+	case errors.Is(err, crosschain.ErrWithdrawalTxNotFound):
+		return exitWithdrawalTxNotFound
+	case errors.Is(err, crosschain.ErrWithdrawalLocked):
+		return exitWithdrawalLocked
+	case errors.Is(err, crosschain.ErrPortalPaused):
+		return exitPortalPaused
+	case errors.Is(err, crosschain.ErrOutputNotProposed):
+		return exitOutputNotProposed
+	case errors.Is(err, crosschain.ErrReverted):
+		return exitReverted
+	case errors.Is(err, crosschain.ErrInsufficientFunds):
+		return exitInsufficientFunds
+	case errors.Is(err, crosschain.ErrCostTooHigh):
+		return exitCostTooHigh
+	case errors.Is(err, crosschain.ErrNoWithdrawalFound):
+		return exitNotAWithdrawal
+	case errors.Is(err, crosschain.ErrProvingOutputDeleted):
+		return exitProvingOutputDeleted
+	case errors.Is(err, crosschain.ErrTimeout):
+		return exitTimeout
+	case errors.Is(err, crosschain.ErrStaleProof):
+		return exitStaleProof
+	case errors.Is(err, crosschain.ErrInsufficientConfirmations):
+		return exitUnconfirmed
+	case errors.Is(err, crosschain.ErrRPC):
+		return exitRPC
+	default:
+		return exitFailure
+	}
+}
+
+// outputFormats are the --output values; commands without structured output only print text
+var outputFormats = []string{"text", "json", "csv"}
+
+// rootOptions are the persistent flags every command shares
+type rootOptions struct {
+	l1RPC   string // --l1-rpc, exported as L1_RPC
+	l2RPC   string // --l2-rpc, exported as L2_RPC
+	network string // --network, exported as NETWORK
+	output  string // --output: text, json or csv
+
+	progressJSON bool // --progress-json: progress events as JSON lines on stderr
+	debugProof   bool // --debug-proof: log every withdrawal proof node and add them to bundles
+}
+
+// applyEnv exports the RPC and network flags as the environment variables they stand
+// for, so they win over the environment and the scheduler config file alike and survive
+// a config reload
+func (o *rootOptions) applyEnv() error {
+	for _, v := range []struct{ name, value string }{
+		{"L1_RPC", o.l1RPC},
+		{"L2_RPC", o.l2RPC},
+		{"NETWORK", o.network},
+	} {
+		if v.value == "" {
+			continue
+		}
+		if err := os.Setenv(v.name, v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputFormat returns --output after checking the command supports it; every command
+// supports text
+func (o *rootOptions) outputFormat(supported ...string) (string, error) {
+	if o.output == "text" || slices.Contains(supported, o.output) {
+		return o.output, nil
+	}
+	return "", fmt.Errorf("--output %s is not supported by this command (use text or %s)", o.output, strings.Join(supported, ", "))
+}
+
+// rootHelp is the long help of the root command
+const rootHelp = `Mantle Cross-Chain Message Status Checker with AWS KMS Support
+
+Exit codes:
+  0                - Success (also when someone else already proved/finalized)
+  1                - Other failure
+  2                - Withdrawal not proven yet
+  3                - Challenge period still active
+  4                - Withdrawal already finalized
+  5                - No L2 output covering the withdrawal yet
+  6                - RPC request failed
+  7                - Contract call or transaction reverted
+  8                - Wallet balance too low; nothing was sent
+  9                - Finalize cost over the configured limit; nothing was sent
+  10               - The transaction is not a withdrawal
+  11               - The proving output was deleted; prove again
+  12               - An RPC read, proof generation or mining wait timed out
+  13               - The proof file is stale; generate a new one
+  14               - The withdrawal doesn't have L2_CONFIRMATIONS L2 blocks on top yet
+  15               - A transaction hash or message index is malformed; nothing was sent
+  16               - Replay was run before the withdrawal was finalized
+  17               - The withdrawal has no failed relayed message to replay
+  18               - The replayed message failed again
+  19               - cancel-pending found no pending transactions
+  20               - find-tx found no transaction with the withdrawal hash in the scanned blocks
+  21               - Another process is proving or finalizing the withdrawal; nothing was sent
+  22               - OptimismPortal is paused by the guardian; nothing was sent
+
+Environment Variables:
+  L1_RPC / L2_RPC  - RPC URLs, comma-separated for fallbacks (or --l1-rpc / --l2-rpc)
+  NETWORK          - Network preset (or --network; default: mainnet)
+  KMS_KEY_ID       - AWS KMS Key ID(s) for signing, comma-separated (recommended)
+  PRIV_KEY         - Private key(s) for signing, comma-separated (alternative)
+  SIGNER_RPC_URL   - Remote signer with eth_signTransaction (SIGNER_ADDRESS picks the account)
+  AWS_REGION       - AWS region (default: ap-northeast-1)
+  KMS_REGION       - KMS region; overrides AWS_REGION and the key ARN's region
+  KMS_ASSUME_ROLE_ARN - IAM role assumed via STS for KMS calls
+  LOG_LEVEL        - debug, info, warn, error or silent (default: info)
+  OTEL_EXPORTER_OTLP_ENDPOINT - Export OpenTelemetry traces over OTLP/HTTP (default: off)
+  GAS_LIMIT_MULTIPLIER - Multiplier applied to estimated gas (default: 1.2)
+  MAX_FEE_GWEI     - Cap on the max fee per gas (default: 2 * baseFee + tip, uncapped)
+  MAX_PRIORITY_FEE_GWEI - Cap on the node's suggested priority fee per gas (default: uncapped)
+  LEGACY_GAS       - true: send legacy transactions priced with eth_gasPrice
+  BALANCE_MARGIN   - Required balance as a multiple of the max tx cost (default: 1.0)
+  MAX_FINALIZE_COST_USD - Don't finalize when the L1 cost exceeds this many USD
+  MIN_VALUE_RATIO  - Don't finalize ETH withdrawals worth less than this multiple of the cost
+  ETH_PRICE_USD    - Static ETH price; otherwise the Chainlink ETH_USD_PRICE_FEED is read
+  L2_CONFIRMATIONS - L2 blocks a withdrawal needs on top before it is trusted (default: 50; 0 disables)
+  L1_CONFIRMATIONS - L1 blocks a prove or finalize needs on top before it counts as done (default: 3; 0 disables)
+  SIMULATION_FROM  - Sender for --offline gas estimates (default: the wallet, else the withdrawal's L2 sender)
+  L1_EXPLORER_URL  - L1 block explorer for links (default: Etherscan for L1_CHAINID)
+  MANTLE_EXPLORER_URL - Mantle block explorer for links (default: Mantlescan for L2_CHAINID)
+
+Setup:
+  1. Copy .env.example to .env
+  2. Set either KMS_KEY_ID or PRIV_KEY in .env (not needed for check/recommend)
+  3. Ensure AWS credentials are configured (for KMS)`
+
+func newRootCmd() *cobra.Command {
+	opts := &rootOptions{}
+	info := version.BuildInfo()
+	cmd := &cobra.Command{
+		Use:   "bridge-status",
+		Short: "Check, prove and finalize Mantle withdrawals",
+		Long:  rootHelp,
+		Example: `  bridge-status check 0xe0c400563d9a70f84966622f13a5560bfacfe9621ea554ee7939fd06d2e10417
+  bridge-status recommend 0xe0c400563d9a70f84966622f13a5560bfacfe9621ea554ee7939fd06d2e10417
+  bridge-status scheduler --config scheduler.yaml start`,
+		Version:       info.Short(),
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The arguments parsed; a failed operation shouldn't print the usage text
+			cmd.SilenceUsage = true
+			if !slices.Contains(outputFormats, opts.output) {
+				return fmt.Errorf("invalid --output %q: must be one of %s", opts.output, strings.Join(outputFormats, ", "))
+			}
+			return opts.applyEnv()
+		},
+	}
+	cmd.SetVersionTemplate(info.String())
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.l1RPC, "l1-rpc", "", "L1 RPC URL(s), comma-separated (default L1_RPC)")
+	flags.StringVar(&opts.l2RPC, "l2-rpc", "", "Mantle RPC URL(s), comma-separated (default L2_RPC)")
+	flags.StringVar(&opts.network, "network", "", "network preset (default NETWORK, else "+crosschain.DefaultNetwork+")")
+	flags.StringVarP(&opts.output, "output", "o", "text", "output format: text, or json/csv where the command supports it")
+	flags.BoolVar(&opts.progressJSON, "progress-json", false, "also write prove, finalize and wait progress to stderr as JSON lines")
+	flags.BoolVar(&opts.debugProof, "debug-proof", false, "log every withdrawal proof node (also at LOG_LEVEL=debug) and add them to export-bundle output")
+
+	cmd.AddCommand(newWithdrawalCmds(opts)...)
+	cmd.AddCommand(newSchedulerCmd(), newVersionCmd(opts))
+	return cmd
+}
+
+func newVersionCmd(opts *rootOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit, build date and dependency versions; see --output json",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := opts.outputFormat("json")
+			if err != nil {
+				return err
+			}
+			info := version.BuildInfo()
+			if format == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(info)
+			}
+			fmt.Print(info.String())
+			return nil
+		},
+	}
+}
+
+func main() {
+	// Run the root's and the scheduler's persistent pre-runs both, not just the nearest
+	cobra.EnableTraverseRunHooks = true
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "bridge-status")
+	if err != nil {
+		log.Printf("⚠️  Tracing disabled: %v", err)
+	}
+
+	err = newRootCmd().Execute()
+	if err != nil && !errors.Is(err, errNotConfirmed) {
+		log.Printf("❌ %v", err)
+	}
+	if shutdownTracing != nil {
+		flushTraces(shutdownTracing)
+	}
+	os.Exit(exitCode(err))
+}
+
+// flushTraces exports the spans still buffered, giving a slow or unreachable
+// collector a few seconds before the process exits without them
+func flushTraces(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("⚠️  Failed to flush traces: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+)
+
+// notify sends an event to every configured notifier. txHash is empty for events that
+// aren't about a single withdrawal.
+func (s *WithdrawalScheduler) notify(event notify.EventType, txHash, message string) {
+	s.notifyFee(event, txHash, message, nil)
+}
+
+// notifyFee is notify for success events, attaching the fee our transaction paid when known
+func (s *WithdrawalScheduler) notifyFee(event notify.EventType, txHash, message string, fee *crosschain.TxFee) {
+	var eventFee *notify.Fee
+	if fee != nil {
+		eventFee = &notify.Fee{
+			L1TxHash: fee.L1TxHash.Hex(),
+			L1TxURL:  fee.URL,
+			Wallet:   fee.From.Hex(),
+			GasUsed:  fee.GasUsed,
+			Wei:      fee.Wei.String(),
+			ETH:      fee.ETH(),
+		}
+	}
+	s.send(notify.Event{Type: event, TxHash: txHash, Text: message, Fee: eventFee})
+}
+
+// notifyFailure sends a failure notification classified by err, so repeats of the same
+// failure are held back for the cool-down (FAILURE_NOTIFY_COOLDOWN)
+func (s *WithdrawalScheduler) notifyFailure(event notify.EventType, txHash, message string, err error) {
+	s.send(notify.Event{Type: event, TxHash: txHash, Text: message, ErrorClass: errorClass(err)})
+}
+
+// send labels and timestamps event and hands it to the notifier
+func (s *WithdrawalScheduler) send(event notify.Event) {
+	if s.notifier == nil {
+		return
+	}
+	s.logger.Debugf("Sending %s notification: %s", event.Type, event.Text)
+	if event.TxHash != "" {
+		event.Label = s.labelOf(event.TxHash)
+		event.Text = withLabel(event.Text, event.Label)
+		if _, hashOnly := withdrawalHashEntry(event.TxHash); !hashOnly {
+			event.TxURL = s.messenger.Explorer.L2Tx(event.TxHash)
+		}
+		s.mu.Lock()
+		if relay := s.relays[event.TxHash]; relay != nil {
+			event.RequestID = relay.RequestID
+			event.Metadata = relay.Metadata
+		}
+		s.mu.Unlock()
+	}
+	event.Time = time.Now()
+	if err := s.notifier.Notify(s.ctx, event); err != nil {
+		s.logger.Warnf("⚠️  Failed to deliver notification: %v", err)
+	}
+}
+
+// recovered announces that txHash works again if its failures were being reported
+func (s *WithdrawalScheduler) recovered(txHash string) {
+	if s.dedup == nil {
+		return
+	}
+	if err := s.dedup.Recovered(s.ctx, txHash, s.labelOf(txHash)); err != nil {
+		s.logger.Warnf("⚠️  Failed to deliver notification: %v", err)
+	}
+}
+
+// errorClass groups errors that are the same failure for notification purposes, so a
+// repeated timeout is held back but a new revert reason still gets through
+func errorClass(err error) string {
+	var fundsErr *crosschain.InsufficientFundsError
+	switch {
+	case errors.As(err, &fundsErr):
+		return "insufficient-funds"
+	case errors.Is(err, crosschain.ErrTimeout):
+		return "timeout"
+	case errors.Is(err, crosschain.ErrNoArchiveState):
+		return "no-archive-state"
+	}
+	if reason, ok := crosschain.RevertReason(err); ok {
+		return "revert: " + reason
+	}
+	switch {
+	case errors.Is(err, crosschain.ErrReverted):
+		return "revert"
+	case errors.Is(err, crosschain.ErrRPC):
+		return "rpc"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+	"mantle-claim-crossing/server"
+	"mantle-claim-crossing/store"
+)
+
+// Relay request states
+const (
+	relayQueued    = "queued"
+	relayFinalized = "finalized"
+	relayFailed    = "failed"
+)
+
+// relayRequest is a withdrawal accepted through the relay API, persisted in the state
+// file so a restart keeps working on it and still delivers its callback
+type relayRequest struct {
+	server.RelayRequest
+	State             string    `json:"state"` // relayQueued, relayFinalized or relayFailed
+	LastError         string    `json:"lastError,omitempty"`
+	AcceptedAt        time.Time `json:"acceptedAt"`
+	CompletedAt       time.Time `json:"completedAt,omitempty"`
+	CallbackDelivered bool      `json:"callbackDelivered,omitempty"`
+}
+
+// SubmitWithdrawal implements server.Relayer: it records req in the state file and queues
+// it for the relay workers. Hashes the scheduler already monitors are refused.
+func (s *WithdrawalScheduler) SubmitWithdrawal(req server.RelayRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if req.RequestID == "" {
+		req.RequestID = req.TxHash
+	}
+
+	s.mu.Lock()
+	if _, ok := s.relays[req.TxHash]; ok || slices.Contains(s.withdrawalHashes, req.TxHash) {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", server.ErrAlreadyTracked, req.TxHash)
+	}
+	s.relays[req.TxHash] = &relayRequest{RelayRequest: req, State: relayQueued, AcceptedAt: time.Now()}
+	status := s.withdrawalStatus[req.TxHash]
+	if status == nil {
+		status = &WithdrawalStatus{}
+		s.withdrawalStatus[req.TxHash] = status
+	}
+	status.label = req.Label
+	status.retiredAt = time.Time{}
+	s.mu.Unlock()
+	s.saveState()
+
+	s.logger.Infof("🤝 Accepted relay request %s for %s", req.RequestID, s.displayName(req.TxHash))
+	s.enqueueRelay(req.TxHash)
+	return nil
+}
+
+// RelayStatus implements server.Relayer
+func (s *WithdrawalScheduler) RelayStatus(ctx context.Context, txHash string) (server.RelayStatus, bool, error) {
+	var status server.RelayStatus
+	s.mu.Lock()
+	relay, ok := s.relays[txHash]
+	if ok {
+		status = server.RelayStatus{
+			RelayRequest: relay.RelayRequest,
+			State:        relay.State,
+			LastError:    relay.LastError,
+			AcceptedAt:   relay.AcceptedAt,
+		}
+		if !relay.CompletedAt.IsZero() {
+			completedAt := relay.CompletedAt
+			status.CompletedAt = &completedAt
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return status, false, nil
+	}
+
+	rec, _, err := s.statusStore.Get(ctx, txHash)
+	if err != nil {
+		return status, true, fmt.Errorf("failed to read the status of %s: %w", txHash, err)
+	}
+	status.Status = rec.Status
+	status.FeesWei = relayFees(rec).String()
+	if status.LastError == "" {
+		status.LastError = rec.LastError
+	}
+	return status, true, nil
+}
+
+// relayFees is what our proves and finalizes for rec's withdrawal paid in total
+func relayFees(rec store.Record) *big.Int {
+	if rec.FeesWei == nil {
+		return new(big.Int)
+	}
+	return rec.FeesWei
+}
+
+// runRelays starts relay.Concurrency workers and, on every check, queues the relay
+// requests still waiting for a prove, a finalize or their callback, until the
+// scheduler stops
+func (s *WithdrawalScheduler) runRelays() {
+	for w := 0; w < s.relay.Concurrency; w++ {
+		go func() {
+			for {
+				select {
+				case <-s.ctx.Done():
+					return
+				case txHash := <-s.relayQueue:
+					s.processRelay(txHash)
+				}
+			}
+		}()
+	}
+
+	for {
+		s.mu.Lock()
+		var pending []string
+		for hash, relay := range s.relays {
+			if relay.State == relayQueued || !relay.CallbackDelivered {
+				pending = append(pending, hash)
+			}
+		}
+		s.mu.Unlock()
+		for _, hash := range pending {
+			s.enqueueRelay(hash)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(s.nextCheckDelay(time.Now())):
+		}
+	}
+}
+
+// enqueueRelay hands txHash to a relay worker unless it is already queued or being
+// worked on. When the queue is full it waits for the next pass of runRelays.
+func (s *WithdrawalScheduler) enqueueRelay(txHash string) {
+	s.mu.Lock()
+	if s.relayInFlight[txHash] {
+		s.mu.Unlock()
+		return
+	}
+	s.relayInFlight[txHash] = true
+	s.mu.Unlock()
+
+	select {
+	case s.relayQueue <- txHash:
+	default:
+		s.mu.Lock()
+		delete(s.relayInFlight, txHash)
+		s.mu.Unlock()
+	}
+}
+
+// processRelay checks a relay request's withdrawal, proving or finalizing it when due,
+// and completes the request once it is finalized or turns out not to be a withdrawal
+func (s *WithdrawalScheduler) processRelay(txHash string) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.relayInFlight, txHash)
+		s.mu.Unlock()
+	}()
+
+	s.mu.Lock()
+	state := s.relays[txHash].State
+	s.mu.Unlock()
+
+	if state == relayQueued {
+		status := s.statusFor(txHash)
+		err := s.checkWithdrawal(txHash, nil)
+		switch {
+		case errors.Is(err, crosschain.ErrNoWithdrawalFound):
+			s.completeRelay(txHash, relayFailed, err)
+		case errors.Is(err, errShuttingDown):
+			return
+		case err != nil && !errors.Is(err, errSubmissionInProgress):
+			s.logger.Errorf("❌ Relay check failed for %s: %v", s.displayName(txHash), err)
+			s.recordError(txHash, err)
+			return
+		case err == nil:
+			s.recovered(txHash)
+		}
+
+		s.mu.Lock()
+		finalized := status.finalized
+		s.mu.Unlock()
+		if finalized {
+			s.completeRelay(txHash, relayFinalized, nil)
+		}
+	}
+	s.deliverRelayCallback(txHash)
+}
+
+// completeRelay records the outcome of a relay request; its callback goes out next
+func (s *WithdrawalScheduler) completeRelay(txHash, outcome string, err error) {
+	s.mu.Lock()
+	relay := s.relays[txHash]
+	relay.State = outcome
+	relay.CompletedAt = time.Now()
+	if err != nil {
+		relay.LastError = err.Error()
+	}
+	s.mu.Unlock()
+	s.saveState()
+}
+
+// deliverRelayCallback posts a completed relay request's outcome, metadata and L1 fees
+// to its callback URL, then tells the operator. A failed delivery is retried on the
+// next pass of runRelays.
+func (s *WithdrawalScheduler) deliverRelayCallback(txHash string) {
+	s.mu.Lock()
+	relay := *s.relays[txHash]
+	s.mu.Unlock()
+	if relay.State == relayQueued || relay.CallbackDelivered {
+		return
+	}
+
+	fees := new(big.Int)
+	if rec, _, err := s.statusStore.Get(s.ctx, txHash); err != nil {
+		s.logger.Warnf("⚠️  Failed to read the fees paid for %s: %v", txHash, err)
+	} else {
+		fees = relayFees(rec)
+	}
+
+	event := notify.Event{
+		Type:      notify.EventRelayCompleted,
+		TxHash:    txHash,
+		RequestID: relay.RequestID,
+		Metadata:  relay.Metadata,
+		FeesWei:   fees.String(),
+	}
+	if relay.State == relayFailed {
+		event.Type = notify.EventRelayFailed
+		event.Text = fmt.Sprintf(
+			"🚫 *Relay Request Failed*\n\n"+
+				"Request: `%s`\n"+
+				"Transaction: `%s`\n"+
+				"%s",
+			relay.RequestID, txHash, notify.EscapeMarkdown(relay.LastError))
+	} else {
+		event.Text = fmt.Sprintf(
+			"🤝 *Relay Request Completed*\n\n"+
+				"Request: `%s`\n"+
+				"Transaction: `%s`\n"+
+				"L1 fees paid: %s ETH",
+			relay.RequestID, txHash, crosschain.FormatEther(fees))
+	}
+
+	// The caller gets the same event the operator does
+	callbackEvent := event
+	callbackEvent.Label = relay.Label
+	callbackEvent.Text = withLabel(event.Text, relay.Label)
+	callbackEvent.Time = time.Now()
+	callback := notify.WithRetry("relay callback", notify.NewWebhook(relay.CallbackURL, s.relay.CallbackSecret),
+		notify.DefaultAttempts, notify.DefaultInitialBackoff)
+	if err := callback.Notify(s.ctx, callbackEvent); err != nil {
+		s.logger.Warnf("⚠️  Failed to deliver the relay callback for %s, retrying on the next check: %v", s.displayName(txHash), err)
+		return
+	}
+
+	s.mu.Lock()
+	s.relays[txHash].CallbackDelivered = true
+	s.mu.Unlock()
+	s.saveState()
+	s.logger.Infof("📨 Relay request %s %s, callback delivered (fees %s ETH)", relay.RequestID, relay.State, crosschain.FormatEther(fees))
+	s.send(event)
+}
@@ -0,0 +1,783 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/internal/version"
+	"mantle-claim-crossing/notify"
+	"mantle-claim-crossing/store"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// Defaults for CHECK_INTERVAL, PER_TX_DELAY and CHECK_CONCURRENCY
+	DefaultCheckInterval    = 10 * time.Minute
+	DefaultPerTxDelay       = 1 * time.Second
+	DefaultCheckConcurrency = 4
+
+	// DefaultDiscoveryLookback is how far back the first WATCH_ADDRESSES scan reaches
+	// (~7 days of 2s Mantle blocks, a bit more than prove + challenge period)
+	DefaultDiscoveryLookback = 302400
+
+	// MinCheckInterval keeps a tightened schedule from hammering the RPC endpoints
+	MinCheckInterval = 15 * time.Second
+
+	// finalizeBuffer is added after a finalize time so the L1 block timestamp has passed it
+	finalizeBuffer = 30 * time.Second
+
+	// withdrawalHashPrefix marks a monitored entry that is a withdrawal hash rather than
+	// an L2 transaction hash; without the transaction it can only be followed, not
+	// proven or finalized
+	withdrawalHashPrefix = "withdrawalhash:"
+
+	// DefaultStateFile is where sent-but-unconfirmed transactions are recorded (STATE_FILE)
+	DefaultStateFile = "scheduler-state.json"
+
+	// DefaultStatusStore keeps withdrawal statuses in the state file (STATUS_STORE)
+	DefaultStatusStore = "json"
+
+	// guardianLookback is how far back the first guardian event scan reaches: one challenge
+	// period of L1 blocks, so deletions hitting proofs that can't be finalized yet are caught
+	guardianLookback = uint64(crosschain.ChallengePeriod / crosschain.L1BlockTime)
+
+	// DefaultReloadInterval is how often the config file's withdrawal list is re-read
+	DefaultReloadInterval = time.Minute
+
+	// DefaultSummarySchedule sends the daily summary at 09:00 UTC (SUMMARY_SCHEDULE)
+	DefaultSummarySchedule = "0 9 * * *"
+
+	// summaryWindow is how far back the summary lists finalized withdrawals
+	summaryWindow = 24 * time.Hour
+
+	// DefaultHealthRPCMaxAge is how recently both RPC endpoints must have answered for
+	// /healthz to pass (HEALTH_RPC_MAX_AGE)
+	DefaultHealthRPCMaxAge = 5 * time.Minute
+
+	// DefaultShutdownGrace is how long a shutdown waits for in-flight checks and
+	// submissions before cancelling them (SHUTDOWN_GRACE)
+	DefaultShutdownGrace = 5 * time.Minute
+
+	// DefaultStuckTxAlert is how long a wallet's oldest pending L1 transaction may stay
+	// unmined before it is alerted as stuck (STUCK_TX_ALERT)
+	DefaultStuckTxAlert = 30 * time.Minute
+
+	// DefaultRelayConcurrency is how many relay requests are worked on at once (RELAY_CONCURRENCY)
+	DefaultRelayConcurrency = 2
+
+	// relayQueueSize bounds the relay requests waiting for a worker; the rest wait for the next pass
+	relayQueueSize = 256
+
+	// SCHEDULER_MODE values: poll only, or also react to OutputProposed events as they happen
+	SchedulerModePoll      = "poll"
+	SchedulerModeSubscribe = "subscribe"
+)
+
+// errSubmissionInProgress is returned when another prove/finalize for the same withdrawal
+// (from the cron loop or a bot command) hasn't finished yet
+var errSubmissionInProgress = errors.New("a prove or finalize for this withdrawal is already in progress")
+
+// errShuttingDown is returned instead of starting a check once shutdown has begun
+var errShuttingDown = errors.New("the scheduler is shutting down")
+
+// WithdrawalStatus tracks status for each withdrawal transaction
+type WithdrawalStatus struct {
+	sentWaitingMessage  bool                        // Track if we've sent the initial waiting message
+	sent5MinuteReminder bool                        // Track if we've sent the 5-minute reminder
+	finalized           bool                        // Track if this withdrawal has been finalized
+	blockedAction       crosschain.Action           // Last blocking action we alerted about
+	notifiedReady       crosschain.Action           // Last ready action reported in monitor-only mode
+	finalizeAt          time.Time                   // When the challenge period ends; zero unless proven
+	provenAt            time.Time                   // Proven timestamp last read from OptimismPortal; persisted to notice a reorg dropping the proof
+	submitMu            sync.Mutex                  // Held while a prove/finalize is in flight, from cron or a bot command
+	submitted           submittedTx                 // Last prove/finalize sent and not yet confirmed; written holding submitMu and mu
+	from                common.Address              // Wallet that signs for this withdrawal; zero means the default signer
+	awaitingOutput      uint64                      // L2 block still waiting for an output to cover it; 0 otherwise
+	label               string                      // Name from the config file, shown next to the hash; guarded by the scheduler's mu
+	discovered          bool                        // Found through WATCH_ADDRESSES rather than listed in the config
+	retiredAt           time.Time                   // When a reload removed it from the config; zero while monitored
+	notAWithdrawal      bool                        // The transaction has no withdrawal events; it is skipped from then on
+	provenance          *crosschain.ProofProvenance // Output the withdrawal was proven against; nil until known
+	fee                 *crosschain.TxFee           // Fee of the prove/finalize we last mined; reset before each submission
+	timelineBackfill    crosschain.TimelineStage    // Latest stage the timeline was last reconstructed from chain data for
+}
+
+// WithdrawalScheduler manages periodic checks for withdrawals
+type WithdrawalScheduler struct {
+	messenger         *crosschain.CrossChainMessenger
+	target            string // Name of the target this scheduler runs for; empty without targets
+	ctx               context.Context
+	cancel            context.CancelFunc
+	notifier          notify.Notifier              // Telegram, Slack and/or webhook; nil when none is configured
+	dedup             *notify.Dedup                // Wraps notifier to hold back repeated failures; nil with notifier
+	telegram          *notify.Telegram             // Set when Telegram is configured, for bot commands
+	commandsEnabled   bool                         // Answer bot commands (TELEGRAM_COMMANDS)
+	commandUsers      map[int64]bool               // Telegram user IDs allowed to /prove, /finalize and /replay
+	withdrawalHashes  []string                     // List of withdrawal transaction hashes to monitor
+	withdrawalStatus  map[string]*WithdrawalStatus // Status for each withdrawal
+	logger            crosschain.Logger
+	monitorOnly       bool                            // No signing credentials: report readiness but never submit
+	checkInterval     time.Duration                   // Regular time between scheduled checks (CHECK_INTERVAL)
+	perTxDelay        time.Duration                   // Minimum spacing between starting two withdrawal checks (PER_TX_DELAY)
+	concurrency       int                             // Withdrawals checked in parallel (CHECK_CONCURRENCY)
+	watchAddresses    []common.Address                // Senders whose withdrawals are discovered on L2 (WATCH_ADDRESSES)
+	discoveryLookback uint64                          // Blocks scanned back from head on the first discovery pass
+	lastScannedBlock  uint64                          // Last L2 block covered by discovery; 0 before the first scan
+	lastGuardianScan  uint64                          // Last L1 block scanned for guardian events; 0 before the first scan
+	seenDeletions     map[common.Hash]bool            // OutputsDeleted events already handled, by L1 tx; guarded by mu
+	walletQueues      map[common.Address]*walletQueue // Pending nonce gaps of the signing wallets; guarded by mu
+	stuckTxAlert      time.Duration                   // How long a nonce gap may last before it is alerted (STUCK_TX_ALERT); 0 only logs it
+	finalization      crosschain.FinalizationParams   // Oracle finalization period and mode last seen; guarded by mu
+	portalPaused      *bool                           // OptimismPortal's paused flag last seen; nil before the first read; guarded by mu
+	stateFile         string                          // Unconfirmed submissions are persisted here (STATE_FILE)
+	stateMu           sync.Mutex                      // Serializes state file writes
+	costs             map[common.Address]walletCosts  // L1 fees paid per wallet, persisted in the state file; guarded by mu
+	statusStore       store.StatusStore               // Last known status of each withdrawal (STATUS_STORE)
+	mode              string                          // SCHEDULER_MODE: SchedulerModePoll or SchedulerModeSubscribe
+	configPath        string                          // Config file the withdrawal list is reloaded from; empty without --config
+	reloadInterval    time.Duration                   // Time between config reloads; 0 reloads on SIGHUP only
+	reloadMu          sync.Mutex                      // Serializes config reloads
+	summarySchedule   string                          // Cron spec of the daily summary; empty disables it
+	healthAddr        string                          // Where /healthz and /readyz are served (HEALTH_LISTEN_ADDR); empty disables them
+	healthRPCMaxAge   time.Duration                   // How recently both RPC endpoints must have answered a ChainID call
+	startedAt         time.Time                       // When Start was called; liveness baseline before the first cycle completes
+	lastCycleAt       time.Time                       // When CheckAllWithdrawals last ran to completion; guarded by mu
+	lastL1Answer      time.Time                       // When L1 last answered a ChainID call; guarded by mu
+	lastL2Answer      time.Time                       // When L2 last answered a ChainID call; guarded by mu
+	running           bool                            // Start finished setting up; guarded by mu
+	relay             RelayConfig                     // Relay API settings; relay.Enabled mounts it on the health listener
+	relays            map[string]*relayRequest        // Relay requests by withdrawal hash; guarded by mu
+	relayQueue        chan string                     // Relay requests waiting for a worker
+	relayInFlight     map[string]bool                 // Relay requests queued or being worked on; guarded by mu
+	shutdownGrace     time.Duration                   // How long shutdown waits for inFlight
+	inFlight          sync.WaitGroup                  // Checks and submissions running; see beginWork
+	draining          bool                            // Shutdown has begun and no new work starts; guarded by mu
+	mu                sync.Mutex                      // Guards withdrawalStatus and the finalized/finalizeAt fields shared across workers
+}
+
+// newSchedulerMessenger connects to the configured RPC endpoints, checking their chain
+// IDs and the contract code on the way
+func newSchedulerMessenger(cfg SchedulerConfig, logger crosschain.Logger) (*crosschain.CrossChainMessenger, error) {
+	messengerConfig, err := crosschain.NetworkMessengerConfigFromEnv(cfg.Network, cfg.L1RPC, cfg.L2RPC)
+	if err != nil {
+		return nil, err
+	}
+	messengerConfig.Logger = logger
+	if cfg.Target != "" {
+		// A target's chain IDs and contracts come from its entry in the file; the
+		// environment's are meant for a single network
+		messengerConfig.L1ChainID = cfg.Network.L1ChainID
+		messengerConfig.L2ChainID = cfg.Network.L2ChainID
+		messengerConfig.Contracts = cfg.Network.Contracts
+		messengerConfig.Explorer = cfg.Network.Explorer
+	} else if cfg.Notifications.ExplorerURL != "" {
+		messengerConfig.Explorer.L2 = cfg.Notifications.ExplorerURL
+	}
+	if cfg.Signer != nil {
+		messengerConfig.Signer = *cfg.Signer
+		messengerConfig.Signers = nil
+	}
+	messenger, err := crosschain.NewCrossChainMessenger(messengerConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create messenger: %w", err)
+	}
+	return messenger, nil
+}
+
+// NewWithdrawalScheduler creates a new scheduler from cfg
+func NewWithdrawalScheduler(cfg SchedulerConfig) (*WithdrawalScheduler, error) {
+	// startTargets neither answers bot commands nor mounts the relay API
+	if err := cfg.checkTargets(); err != nil {
+		return nil, err
+	}
+
+	// Leveled logger shared with the messenger so both can be silenced together
+	logger := schedulerLogger(cfg)
+
+	commandUsers := make(map[int64]bool)
+	for _, userID := range cfg.Notifications.Telegram.AllowedUsers {
+		commandUsers[userID] = true
+	}
+
+	var lastScannedBlock uint64
+	if cfg.DiscoveryStartBlock > 0 {
+		lastScannedBlock = cfg.DiscoveryStartBlock - 1
+	}
+
+	// Transactions a previous run sent but didn't see mined
+	state, err := loadSchedulerState(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	statusStore, err := openStatusStore(cfg, state)
+	if err != nil {
+		return nil, err
+	}
+
+	messenger, err := newSchedulerMessenger(cfg, logger)
+	if err != nil {
+		statusStore.Close()
+		return nil, err
+	}
+
+	monitorOnly := !messenger.HasSigner()
+	if monitorOnly {
+		logger.Infof("👀 No KMS_KEY_ID, PRIV_KEY or SIGNER_RPC_URL set — running in monitor-only mode (no transactions will be sent)")
+	}
+	if monitorOnly && cfg.Relay.Enabled {
+		statusStore.Close()
+		return nil, fmt.Errorf("the relay API finalizes withdrawals, so it needs KMS_KEY_ID, PRIV_KEY or SIGNER_RPC_URL")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Notification destinations (optional); each retries failed deliveries on its own
+	var notifiers notify.Multi
+	var telegram *notify.Telegram
+	tg := cfg.Notifications.Telegram
+
+	if tg.BotToken != "" && tg.ChatID != 0 {
+		var err error
+		telegram, err = notify.NewTelegram(tg.BotToken, tg.ChatID, tg.TopicID)
+		if err != nil {
+			logger.Warnf("⚠️  Warning: %v", err)
+			logger.Warnf("Continuing without Telegram notifications...")
+		} else {
+			if tg.TopicID != 0 {
+				logger.Infof("✅ Telegram bot initialized: @%s (Topic ID: %d)", telegram.UserName(), tg.TopicID)
+			} else {
+				logger.Infof("✅ Telegram bot initialized: @%s", telegram.UserName())
+			}
+			// Catch a wrong chat or topic now rather than at the first real notification
+			started := fmt.Sprintf("🟢 *Withdrawal scheduler started* (`%s`)", version.BuildInfo().Short())
+			if cfg.Target != "" {
+				started += "\n\n🌐 Network: " + notify.EscapeMarkdown(cfg.Target)
+			}
+			if err := telegram.SelfTest(started); err != nil {
+				logger.Errorf("❌ Telegram self-test failed: %v", err)
+				logger.Warnf("Continuing; messages will go to the chat itself if the topic is rejected")
+			}
+			notifiers = append(notifiers, notify.WithRetry("telegram", telegram, notify.DefaultAttempts, notify.DefaultInitialBackoff))
+		}
+	} else {
+		logger.Infof("ℹ️  Telegram notifications disabled (bot token or chat ID not set)")
+	}
+
+	if tg.Commands && telegram == nil {
+		logger.Warnf("⚠️  Telegram commands are enabled but Telegram is not configured; bot commands are disabled")
+	}
+
+	if hook := cfg.Notifications.Webhook; hook.URL != "" {
+		webhook := notify.NewWebhook(hook.URL, hook.Secret)
+		notifiers = append(notifiers, notify.WithRetry("webhook", webhook, notify.DefaultAttempts, notify.DefaultInitialBackoff))
+		logger.Infof("✅ Webhook notifications enabled")
+	}
+
+	explorer := messenger.Explorer.L2
+	if slack := cfg.Notifications.Slack; slack.WebhookURL != "" {
+		notifiers = append(notifiers, notify.WithRetry("slack", notify.NewSlackWebhook(slack.WebhookURL, explorer), notify.DefaultAttempts, notify.DefaultInitialBackoff))
+		logger.Infof("✅ Slack notifications enabled (incoming webhook)")
+	} else if slack.BotToken != "" && slack.Channel != "" {
+		notifiers = append(notifiers, notify.WithRetry("slack", notify.NewSlackBot(slack.BotToken, slack.Channel, explorer), notify.DefaultAttempts, notify.DefaultInitialBackoff))
+		logger.Infof("✅ Slack notifications enabled (channel %s, threaded per withdrawal)", slack.Channel)
+	}
+
+	// Repeated failures, e.g. while an RPC endpoint is down, reach every destination once per cool-down
+	var notifier notify.Notifier
+	var dedup *notify.Dedup
+	if len(notifiers) > 0 {
+		var next notify.Notifier = notifiers
+		if cfg.Target != "" {
+			next = notify.WithNetwork(cfg.Target, notifiers)
+		}
+		dedup = notify.NewDedup(next, cfg.FailureCooldown)
+		notifier = dedup
+	}
+
+	var withdrawalHashes []string
+	withdrawalStatus := make(map[string]*WithdrawalStatus)
+	for _, w := range cfg.Withdrawals {
+		withdrawalHashes = append(withdrawalHashes, w.Hash)
+		withdrawalStatus[w.Hash] = &WithdrawalStatus{from: w.From, label: w.Label}
+	}
+
+	if !monitorOnly {
+		if err := checkWallets(ctx, messenger, cfg.Withdrawals, logger); err != nil {
+			cancel()
+			statusStore.Close()
+			return nil, err
+		}
+	}
+
+	// Withdrawals an earlier reload removed keep their notification state, whether or not
+	// the config lists them again
+	for hash, retired := range state.Retired {
+		status := withdrawalStatus[hash]
+		if status == nil {
+			status = &WithdrawalStatus{retiredAt: retired.RetiredAt, label: retired.Label}
+			withdrawalStatus[hash] = status
+		}
+		status.restoreNotified(retired)
+	}
+
+	// Pick up transactions a previous run sent but didn't see mined
+	for hash, sub := range state.Submitted {
+		status := withdrawalStatus[hash]
+		if status == nil {
+			status = &WithdrawalStatus{}
+			withdrawalStatus[hash] = status
+		}
+		status.submitted = sub
+		logger.Infof("📂 Resuming %s %s for %s from %s", sub.Operation, sub.L1TxHash.Hex(), hash, cfg.StateFile)
+	}
+	for hash, provenAt := range state.Proven {
+		status := withdrawalStatus[hash]
+		if status == nil {
+			status = &WithdrawalStatus{}
+			withdrawalStatus[hash] = status
+		}
+		status.provenAt = provenAt
+	}
+
+	// Relay requests a previous run accepted; completed ones are kept for the status endpoint
+	relays := make(map[string]*relayRequest)
+	for hash, relay := range state.Relays {
+		relays[hash] = &relay
+		status := withdrawalStatus[hash]
+		if status == nil {
+			status = &WithdrawalStatus{finalized: relay.State == relayFinalized, notAWithdrawal: relay.State == relayFailed}
+			withdrawalStatus[hash] = status
+		}
+		status.label = relay.Label
+	}
+
+	// Fee totals keep adding up across restarts
+	costs := state.Costs
+	if costs == nil {
+		costs = make(map[common.Address]walletCosts)
+	}
+
+	return &WithdrawalScheduler{
+		messenger:         messenger,
+		target:            cfg.Target,
+		ctx:               ctx,
+		cancel:            cancel,
+		notifier:          notifier,
+		dedup:             dedup,
+		telegram:          telegram,
+		commandsEnabled:   tg.Commands,
+		commandUsers:      commandUsers,
+		withdrawalHashes:  withdrawalHashes,
+		withdrawalStatus:  withdrawalStatus,
+		logger:            logger,
+		monitorOnly:       monitorOnly,
+		checkInterval:     cfg.CheckInterval,
+		perTxDelay:        cfg.PerTxDelay,
+		concurrency:       cfg.Concurrency,
+		watchAddresses:    cfg.WatchAddresses,
+		discoveryLookback: cfg.DiscoveryLookback,
+		lastScannedBlock:  lastScannedBlock,
+		stateFile:         cfg.StateFile,
+		costs:             costs,
+		statusStore:       statusStore,
+		mode:              cfg.Mode,
+		configPath:        cfg.Path,
+		reloadInterval:    cfg.ReloadInterval,
+		summarySchedule:   cfg.SummarySchedule,
+		healthAddr:        cfg.HealthAddr,
+		healthRPCMaxAge:   cfg.HealthRPCMaxAge,
+		relay:             cfg.Relay,
+		relays:            relays,
+		relayQueue:        make(chan string, relayQueueSize),
+		relayInFlight:     make(map[string]bool),
+		shutdownGrace:     cfg.ShutdownGrace,
+		walletQueues:      make(map[common.Address]*walletQueue),
+		seenDeletions:     make(map[common.Hash]bool),
+		stuckTxAlert:      cfg.StuckTxAlert,
+	}, nil
+}
+
+// GetLatestProposedL2Block gets the latest L2 block covered by an output from the
+// oracle's latestBlockNumber(). If that call fails it falls back to the latest output,
+// which itself falls back to scanning OutputProposed events.
+func (s *WithdrawalScheduler) GetLatestProposedL2Block() (uint64, error) {
+	latest, err := s.messenger.GetLatestProposedL2Block(s.ctx)
+	if err == nil {
+		s.logger.Infof("📊 Latest proposed L2 block: %d", latest)
+		return latest, nil
+	}
+	s.logger.Warnf("⚠️  latestBlockNumber() failed, looking up the latest output instead: %v", err)
+
+	proposal, err := s.messenger.LatestOutputProposal(s.ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the latest proposed L2 block: %w", err)
+	}
+	s.logger.Infof("📊 Latest proposed L2 block: %d (output #%d, proposed %s)", proposal.L2BlockNumber, proposal.OutputIndex,
+		proposal.L1Timestamp.Format(time.RFC3339))
+	return proposal.L2BlockNumber, nil
+}
+
+// CheckWithdrawal checks the withdrawal transaction and proves it if ready
+func (s *WithdrawalScheduler) CheckWithdrawal(txHash string) error {
+	return s.checkWithdrawal(txHash, nil)
+}
+
+// statusFor returns the tracked status for txHash, creating it for withdrawals we haven't seen
+func (s *WithdrawalScheduler) statusFor(txHash string) *WithdrawalStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.withdrawalStatus[txHash]
+	if status == nil {
+		status = &WithdrawalStatus{}
+		s.withdrawalStatus[txHash] = status
+	}
+	return status
+}
+
+// labelOf returns the config label of txHash, or "" without one
+func (s *WithdrawalScheduler) labelOf(txHash string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status := s.withdrawalStatus[txHash]; status != nil {
+		return status.label
+	}
+	return ""
+}
+
+// displayName returns txHash followed by its label in parentheses, for log lines
+func (s *WithdrawalScheduler) displayName(txHash string) string {
+	if label := s.labelOf(txHash); label != "" {
+		return fmt.Sprintf("%s (%s)", txHash, label)
+	}
+	return txHash
+}
+
+// withLabel adds a label line below the title of a notification message
+func withLabel(message, label string) string {
+	if label == "" {
+		return message
+	}
+	line := "🏷️ Label: " + notify.EscapeMarkdown(label)
+	title, body, ok := strings.Cut(message, "\n\n")
+	if !ok {
+		return message + "\n" + line
+	}
+	return title + "\n\n" + line + "\n" + body
+}
+
+// adaptiveSchedule runs checks every checkInterval, tightening the interval while a
+// withdrawal's finalize time is near so finalization isn't delayed by a whole interval
+type adaptiveSchedule struct {
+	s *WithdrawalScheduler
+}
+
+// Next implements cron.Schedule
+func (a adaptiveSchedule) Next(t time.Time) time.Time {
+	return t.Add(a.s.nextCheckDelay(t))
+}
+
+// nextCheckDelay returns the time until the next check. When a pending withdrawal
+// becomes finalizable within 2× the check interval, the next check lands just after it.
+func (s *WithdrawalScheduler) nextCheckDelay(now time.Time) time.Duration {
+	delay := s.checkInterval
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for txHash, status := range s.withdrawalStatus {
+		if status.finalized || status.finalizeAt.IsZero() {
+			continue
+		}
+		until := status.finalizeAt.Sub(now)
+		if until > 2*s.checkInterval {
+			continue
+		}
+		tightened := until + finalizeBuffer
+		if tightened < MinCheckInterval {
+			tightened = MinCheckInterval
+		}
+		if tightened < delay {
+			delay = tightened
+			s.logger.Debugf("⏱️  %s finalizable at %s; next check in %s", txHash, status.finalizeAt.Format(time.RFC3339), delay.Round(time.Second))
+		}
+	}
+	return delay
+}
+
+// Start begins the periodic checking
+func (s *WithdrawalScheduler) Start() {
+	s.logger.Infof("🚀 Starting withdrawal scheduler (mode: %s, check interval: %s, per-tx delay: %s)", s.mode, s.checkInterval, s.perTxDelay)
+	s.startedAt = time.Now()
+
+	// Probes answer during the initial check, so a slow first cycle shows up too
+	var healthServer *http.Server
+	if s.healthAddr != "" {
+		healthServer = s.serveHealth()
+		go s.pingRPC()
+	}
+
+	// Create a new cron scheduler; a check that runs long is skipped rather than overlapped
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+
+	// Add the check job on an interval that tightens near finalize times
+	c.Schedule(adaptiveSchedule{s: s}, cron.FuncJob(func() {
+		s.logger.Infof("\n⏰ Running scheduled check at %s...", time.Now().Format(time.RFC3339))
+		s.CheckAllWithdrawals()
+	}))
+
+	if s.summarySchedule != "" {
+		if _, err := c.AddFunc(s.summarySchedule, s.SendSummary); err != nil {
+			s.logger.Warnf("⚠️  Failed to schedule the daily summary: %v", err)
+		} else {
+			s.logger.Infof("📰 Daily summary scheduled (%s)", s.summarySchedule)
+		}
+	}
+
+	if s.commandsEnabled && s.telegram != nil {
+		go s.handleCommands()
+	}
+
+	// Setup signal handling for graceful shutdown before the initial check, the longest
+	// cycle, so a signal during it drains as well
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	// Perform initial check
+	s.logger.Infof("\n⏰ Performing initial check...")
+	initial := make(chan struct{})
+	go func() {
+		defer close(initial)
+		s.CheckAllWithdrawals()
+	}()
+	select {
+	case <-initial:
+	case <-sigChan:
+		s.shutdown(c, sigChan, healthServer)
+		return
+	}
+	s.startWatchers()
+
+	// Start the cron scheduler
+	c.Start()
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+	s.logger.Infof("✅ Cron scheduler started")
+
+	// Wait for shutdown signal
+	select {
+	case <-sigChan:
+		s.shutdown(c, sigChan, healthServer)
+		return
+
+	case <-s.ctx.Done():
+		s.logger.Infof("🛑 Context cancelled, stopping scheduler...")
+		c.Stop()
+		s.stopHealth(healthServer)
+		return
+	}
+}
+
+// shutdown stops the scheduler after a shutdown signal: in-flight work is drained, then
+// cancelled, and the state file is saved
+func (s *WithdrawalScheduler) shutdown(c *cron.Cron, sigChan <-chan os.Signal, healthServer *http.Server) {
+	s.logger.Infof("\n🛑 Received shutdown signal, stopping scheduler...")
+	s.drain(c, sigChan)
+	s.cancel()
+	s.saveState()
+	s.stopHealth(healthServer)
+}
+
+// beginWork registers a check or submission with inFlight so shutdown waits for it. It
+// returns false once shutdown has begun; the caller must then not start. Callers that
+// get true call s.inFlight.Done when finished.
+func (s *WithdrawalScheduler) beginWork() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return false
+	}
+	s.inFlight.Add(1)
+	return true
+}
+
+// isDraining reports whether shutdown has begun
+func (s *WithdrawalScheduler) isDraining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+// drain stops new cycles, checks and submissions from starting and waits up to
+// shutdownGrace for the running ones, so a just-sent prove or finalize is seen mined and
+// recorded instead of being cut off mid-wait. Another signal on sigChan stops waiting.
+func (s *WithdrawalScheduler) drain(c *cron.Cron, sigChan <-chan os.Signal) {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+	c.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	s.logger.Infof("⏳ Waiting up to %s for in-flight checks and transactions; signal again to exit now", s.shutdownGrace)
+	s.send(notify.Event{Type: notify.EventShuttingDown, Text: fmt.Sprintf(
+		"🛑 *Withdrawal scheduler shutting down*\n\n"+
+			"Waiting up to %s for in-flight prove/finalize transactions before exiting", s.shutdownGrace)})
+
+	timer := time.NewTimer(s.shutdownGrace)
+	defer timer.Stop()
+	select {
+	case <-done:
+		s.logger.Infof("✅ In-flight work finished")
+	case <-timer.C:
+		s.logger.Warnf("⚠️  Shutdown grace period of %s elapsed; cancelling in-flight work", s.shutdownGrace)
+	case <-sigChan:
+		s.logger.Warnf("⚠️  Second shutdown signal; cancelling in-flight work")
+	}
+}
+
+// CheckAllWithdrawals checks all withdrawal transactions on a bounded worker pool and
+// returns the error for each withdrawal whose check failed
+func (s *WithdrawalScheduler) CheckAllWithdrawals() map[string]error {
+	if !s.beginWork() {
+		return nil
+	}
+	defer s.inFlight.Done()
+	defer s.cycleCompleted()
+	if len(s.watchAddresses) > 0 {
+		if err := s.discoverWithdrawals(); err != nil {
+			s.logger.Errorf("❌ Withdrawal discovery failed: %v", err)
+		}
+	}
+	if err := s.scanGuardianEvents(); err != nil {
+		s.logger.Errorf("❌ Guardian event scan failed: %v", err)
+	}
+	if !s.monitorOnly {
+		s.checkWalletQueues()
+	}
+	if params, err := s.messenger.RefreshFinalizationParams(s.ctx); err != nil {
+		s.logger.Warnf("⚠️  Failed to read the finalization period: %v", err)
+	} else {
+		s.onFinalizationParams(params, "")
+	}
+	if paused, err := s.messenger.PortalPaused(s.ctx); err != nil {
+		s.logger.Warnf("⚠️  Failed to read whether OptimismPortal is paused: %v", err)
+	} else {
+		s.setPortalPaused(paused, "")
+	}
+
+	// A reload may change the list while this cycle runs; hashes that turned out not to
+	// be withdrawals are skipped
+	s.mu.Lock()
+	var txHashes []string
+	for _, txHash := range s.withdrawalHashes {
+		if status := s.withdrawalStatus[txHash]; status == nil || !status.notAWithdrawal {
+			txHashes = append(txHashes, txHash)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(txHashes) == 0 {
+		s.logger.Infof("ℹ️  No withdrawal transactions to check (set WITHDRAWAL_TX_HASH or WATCH_ADDRESSES)")
+		return nil
+	}
+
+	s.logger.Infof("📋 Checking %d withdrawal(s) (concurrency %d)...", len(txHashes), s.concurrency)
+
+	// Space out check starts so a long list doesn't burst the RPC endpoints
+	limiter := time.NewTicker(max(s.perTxDelay, time.Millisecond))
+	defer limiter.Stop()
+
+	jobs := make(chan int)
+	queue := &submissionQueue{}
+	failures := make(map[string]error)
+	var failuresMu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.concurrency, len(txHashes)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				txHash := txHashes[i]
+				s.logger.Infof("\n[%d/%d] Checking withdrawal: %s", i+1, len(txHashes), s.displayName(txHash))
+				if err := s.checkWithdrawal(txHash, queue); err != nil && !errors.Is(err, errSubmissionInProgress) && !errors.Is(err, errShuttingDown) {
+					s.logger.Errorf("❌ Check failed for %s: %v", s.displayName(txHash), err)
+					failuresMu.Lock()
+					failures[txHash] = err
+					failuresMu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range txHashes {
+		if i > 0 {
+			select {
+			case <-s.ctx.Done():
+				break dispatch
+			case <-limiter.C:
+			}
+		}
+		select {
+		case <-s.ctx.Done():
+			break dispatch
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Submit everything that became actionable this cycle, batching where possible. Once
+	// shutdown has begun nothing new is sent; the next run picks these up.
+	if s.isDraining() {
+		s.logger.Infof("🛑 Shutting down; leaving this cycle's submissions for the next run")
+	} else {
+		for txHash, err := range s.submitQueued(queue) {
+			failures[txHash] = err
+		}
+	}
+
+	for _, txHash := range txHashes {
+		if _, failed := failures[txHash]; !failed && s.ctx.Err() == nil {
+			s.recovered(txHash)
+		}
+	}
+
+	if len(failures) > 0 {
+		s.logger.Warnf("⚠️  %d of %d withdrawal check(s) failed:", len(failures), len(txHashes))
+		for _, txHash := range txHashes {
+			if err, failed := failures[txHash]; failed {
+				s.logger.Warnf("   %s: %v", s.displayName(txHash), err)
+				s.recordError(txHash, err)
+			}
+		}
+	}
+	return failures
+}
+
+// Stop stops the scheduler
+func (s *WithdrawalScheduler) Stop() {
+	s.logger.Infof("🛑 Stopping scheduler...")
+	s.cancel()
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"mantle-claim-crossing/internal/version"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+// schedulerOptions are the flags shared by the scheduler commands
+type schedulerOptions struct {
+	configPath string // --config
+	storeSpec  string // --store, overrides STATUS_STORE
+	summaryNow bool   // --summary-now
+}
+
+// load reads the config file and the environment, with --store applied
+func (o *schedulerOptions) load() (SchedulerConfig, error) {
+	cfg, err := LoadSchedulerConfig(o.configPath)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	if o.storeSpec != "" {
+		cfg.Store = o.storeSpec
+	}
+	return cfg, nil
+}
+
+// run creates the scheduler, or one per target, sends the summary first with
+// --summary-now, and hands the schedulers to fn, if any. A target that can't be created
+// is logged and left out so the others still run.
+func (o *schedulerOptions) run(fn func([]*WithdrawalScheduler)) error {
+	cfg, err := o.load()
+	if err != nil {
+		return err
+	}
+	var schedulers []*WithdrawalScheduler
+	for _, targetCfg := range cfg.targetConfigs() {
+		scheduler, err := NewWithdrawalScheduler(targetCfg)
+		if err != nil {
+			if targetCfg.Target == "" {
+				return fmt.Errorf("failed to create scheduler: %w", err)
+			}
+			log.Printf("❌ Failed to create the scheduler of target %s, running without it: %v", targetCfg.Target, err)
+			continue
+		}
+		schedulers = append(schedulers, scheduler)
+	}
+	if len(schedulers) == 0 {
+		return fmt.Errorf("failed to create the scheduler of any target")
+	}
+
+	for _, scheduler := range schedulers {
+		if o.summaryNow {
+			scheduler.SendSummary()
+		}
+	}
+	if fn != nil {
+		fn(schedulers)
+	}
+	for _, scheduler := range schedulers {
+		if err := scheduler.statusStore.Close(); err != nil {
+			log.Printf("⚠️  Failed to close the status store: %v", err)
+		}
+	}
+	return nil
+}
+
+// schedulerEnvHelp lists the environment variables the scheduler reads, for --help
+const schedulerEnvHelp = `Status store:
+  --store json|sqlite:PATH - Where withdrawal statuses are kept (STATUS_STORE); json (default) uses STATE_FILE
+
+Config file:
+  --config PATH - YAML (or .json) file with withdrawals, notifications, check interval, RPC endpoints and network;
+                  see scheduler.example.yaml. Environment variables and --l1-rpc, --l2-rpc and --network override its values
+                  The withdrawal list is reloaded on SIGHUP and every CONFIG_RELOAD_INTERVAL (default 1m)
+                  targets: runs several networks side by side, each with its own RPC endpoints, signer and withdrawals
+
+Environment Variables:
+  WITHDRAWAL_TX_HASH - Withdrawal transaction hash(es) to monitor (comma-separated for multiple);
+                       append @0xWallet to a hash to sign it with that wallet, and :label to name it;
+                       withdrawalhash:0x... follows a withdrawal hash without proving or finalizing it
+  WATCH_ADDRESSES - Sender address(es) whose withdrawals are discovered and monitored automatically
+  DISCOVERY_LOOKBACK_BLOCKS / DISCOVERY_START_BLOCK - Where the first discovery scan starts
+  KMS_KEY_ID / PRIV_KEY - Signing credentials, comma-separated for several wallets; without them the scheduler only monitors
+  SIGNER_RPC_URL / SIGNER_ADDRESS - Remote signer exposing eth_signTransaction
+  CHECK_INTERVAL - Time between scheduled checks (default 10m, min 15s)
+  MAX_FINALIZE_COST_USD / MIN_VALUE_RATIO - Don't auto-finalize when the L1 cost is too high (notifies instead)
+  SCHEDULER_MODE - poll (default) or subscribe: also check as soon as an output is proposed (websocket L1_RPC)
+  PER_TX_DELAY - Minimum spacing between starting two withdrawal checks (default 1s)
+  CHECK_CONCURRENCY - Withdrawals checked in parallel (default 4)
+  SUMMARY_SCHEDULE - Cron spec of the daily summary, UTC (default "0 9 * * *"; off disables it)
+  FAILURE_NOTIFY_COOLDOWN - How often a repeated failure is notified again (default 1h)
+  HEALTH_LISTEN_ADDR / HEALTH_RPC_MAX_AGE - Serve /healthz and /readyz there (start only); RPC answer max age (default 5m)
+  SHUTDOWN_GRACE - How long SIGTERM waits for in-flight checks and transactions (default 5m; a second signal exits now)
+  STUCK_TX_ALERT - Alert when a signing wallet's oldest pending L1 transaction stays unmined this long (default 30m; 0 disables)
+  RELAY_ENABLED / RELAY_CONCURRENCY - Accept third parties' withdrawals on POST /relay/withdrawals (needs API_TOKEN); workers (default 2)
+  RELAY_CALLBACK_SECRET - Signs relay callbacks with X-Signature-256`
+
+// newSchedulerCmd returns the scheduler command group
+func newSchedulerCmd() *cobra.Command {
+	opts := &schedulerOptions{}
+	cmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "Monitor withdrawals and prove and finalize them automatically",
+		Long:  "Monitor withdrawals and prove and finalize them automatically.\n\n" + schedulerEnvHelp,
+		Example: `  # Single withdrawal
+  export WITHDRAWAL_TX_HASH=0x2ddc5affc8b98cf6c9e5157347d726d0b11c79e9697a3d27ec55aa9693f9baf2
+  # Multiple withdrawals
+  export WITHDRAWAL_TX_HASH=0x2ddc5affc8b98cf6c9e5157347d726d0b11c79e9697a3d27ec55aa9693f9baf2,0xabc123...
+  bridge-status scheduler check
+  # Withdrawals and settings from a file
+  bridge-status scheduler --config scheduler.yaml start`,
+		Args: cobra.NoArgs,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			log.SetFlags(log.LstdFlags | log.Lshortfile)
+			log.Printf("=== Mantle Withdrawal Scheduler %s ===", version.BuildInfo().Short())
+			log.Println()
+		},
+		// --summary-now works without a subcommand
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !opts.summaryNow {
+				return cmd.Help()
+			}
+			return opts.run(nil)
+		},
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.configPath, "config", "", "YAML (or .json) config file; see scheduler.example.yaml")
+	flags.StringVar(&opts.storeSpec, "store", "", "where withdrawal statuses are kept: json or sqlite:PATH (default STATUS_STORE, else json)")
+	flags.BoolVar(&opts.summaryNow, "summary-now", false, "send the withdrawal summary now, then run the command, if any")
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "check",
+			Short: "Run a single check of every withdrawal",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return opts.run(func(schedulers []*WithdrawalScheduler) {
+					log.Println("🔍 Running single check...")
+					checkAll(schedulers)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "start",
+			Short: "Start the scheduler in continuous mode",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return opts.run(func(schedulers []*WithdrawalScheduler) {
+					log.Println("🚀 Starting scheduler in continuous mode...")
+					startAll(schedulers)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "validate-config",
+			Short: "Check the config and RPC connectivity, then exit",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := opts.load()
+				if err != nil {
+					return err
+				}
+				var errs []error
+				for _, targetCfg := range cfg.targetConfigs() {
+					if targetCfg.Target != "" {
+						log.Printf("🎯 Target %s", targetCfg.Target)
+					}
+					if err := ValidateConfig(targetCfg); err != nil {
+						if targetCfg.Target == "" {
+							return err
+						}
+						errs = append(errs, fmt.Errorf("target %s: %w", targetCfg.Target, err))
+					}
+				}
+				return errors.Join(errs...)
+			},
+		},
+		&cobra.Command{
+			Use:   "costs",
+			Short: "Print the L1 fees paid per wallet from STATE_FILE",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := opts.load()
+				if err != nil {
+					return err
+				}
+				for _, targetCfg := range cfg.targetConfigs() {
+					state, err := loadSchedulerState(targetCfg.StateFile)
+					if err != nil {
+						return err
+					}
+					lines := costReport(state.Costs, common.Address.Hex)
+					if lines == nil {
+						log.Printf("No prove or finalize fees recorded in %s yet", targetCfg.StateFile)
+						continue
+					}
+					log.Printf("💰 L1 fees paid (from %s):", targetCfg.StateFile)
+					for _, line := range lines {
+						log.Printf("  %s", line)
+					}
+				}
+				return nil
+			},
+		},
+		&cobra.Command{
+			Use:   "list [STATUS...]",
+			Short: "Print the tracked withdrawals from the status store",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				cfg, err := opts.load()
+				if err != nil {
+					return err
+				}
+				for _, targetCfg := range cfg.targetConfigs() {
+					if targetCfg.Target != "" {
+						log.Printf("🎯 Target %s", targetCfg.Target)
+					}
+					if err := listWithdrawals(targetCfg, args); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+// listWithdrawals prints the withdrawals in the status store, optionally only those with
+// one of statuses, without contacting any RPC endpoint
+func listWithdrawals(cfg SchedulerConfig, statuses []string) error {
+	state, err := loadSchedulerState(cfg.StateFile)
+	if err != nil {
+		return err
+	}
+	statusStore, err := openStatusStore(cfg, state)
+	if err != nil {
+		return err
+	}
+	defer statusStore.Close()
+
+	records, err := statusStore.ListByStatus(context.Background(), statuses...)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		log.Printf("No withdrawals recorded in the %s status store yet", cfg.Store)
+		return nil
+	}
+	log.Printf("📋 Tracked withdrawals (%d):", len(records))
+	for _, rec := range records {
+		line := rec.TxHash
+		if rec.Label != "" {
+			line += " (" + rec.Label + ")"
+		}
+		line += ": " + rec.Status + ", next " + rec.NextAction
+		if !rec.NextActionAt.IsZero() {
+			line += " at " + rec.NextActionAt.Format(time.RFC3339)
+		}
+		log.Printf("  %s (checked %s)", line, rec.UpdatedAt.Format(time.RFC3339))
+	}
+	return nil
+}
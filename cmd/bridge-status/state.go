@@ -0,0 +1,381 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/store"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// submittedTx is an L1 transaction sent for a withdrawal, persisted in the state file so
+// a restart resumes waiting for it instead of sending a duplicate
+type submittedTx struct {
+	Operation string      `json:"operation"` // "prove" or "finalize"
+	L1TxHash  common.Hash `json:"l1TxHash"`
+	SentAt    time.Time   `json:"sentAt"`
+}
+
+// retiredWithdrawal is what was already notified for a withdrawal removed from the
+// config, persisted so re-adding it doesn't repeat those notifications
+type retiredWithdrawal struct {
+	SentWaitingMessage  bool              `json:"sentWaitingMessage,omitempty"`
+	Sent5MinuteReminder bool              `json:"sent5MinuteReminder,omitempty"`
+	Finalized           bool              `json:"finalized,omitempty"`
+	BlockedAction       crosschain.Action `json:"blockedAction,omitempty"`
+	NotifiedReady       crosschain.Action `json:"notifiedReady,omitempty"`
+	Label               string            `json:"label,omitempty"`
+	RetiredAt           time.Time         `json:"retiredAt"`
+}
+
+// schedulerState is the content of the state file
+type schedulerState struct {
+	Submitted map[string]submittedTx         `json:"submitted"`         // L2 withdrawal tx hash -> unconfirmed L1 tx
+	Retired   map[string]retiredWithdrawal   `json:"retired,omitempty"` // L2 withdrawal tx hash -> notification state
+	Costs     map[common.Address]walletCosts `json:"costs,omitempty"`   // Signing wallet -> fees paid so far
+	Relays    map[string]relayRequest        `json:"relays,omitempty"`  // L2 withdrawal tx hash -> relay request
+	Proven    map[string]time.Time           `json:"proven,omitempty"`  // L2 withdrawal tx hash -> on-chain proven timestamp
+
+	// L2 withdrawal tx hash -> last known status; only written with the default json status store
+	Withdrawals map[string]store.Record `json:"withdrawals,omitempty"`
+}
+
+// loadSchedulerState reads the state file; a missing file is an empty state
+func loadSchedulerState(path string) (schedulerState, error) {
+	var state schedulerState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read STATE_FILE %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse STATE_FILE %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// openStatusStore opens cfg.Store; the default json store starts from the statuses saved
+// in state
+func openStatusStore(cfg SchedulerConfig, state schedulerState) (store.StatusStore, error) {
+	if cfg.Store == "" || cfg.Store == DefaultStatusStore {
+		return store.NewMemory(state.Withdrawals), nil
+	}
+	statusStore, err := store.Open(cfg.Store)
+	if err != nil {
+		return nil, fmt.Errorf("invalid STATUS_STORE: %w", err)
+	}
+	return statusStore, nil
+}
+
+// storedTimeline returns the timeline the scheduler recorded for txHash in the status store
+// named by spec (default STATUS_STORE, else the json store in STATE_FILE); nil if none
+func storedTimeline(ctx context.Context, spec, txHash string) (crosschain.Timeline, error) {
+	cfg := SchedulerConfig{
+		StateFile: cmp.Or(os.Getenv("STATE_FILE"), DefaultStateFile),
+		Store:     cmp.Or(spec, os.Getenv("STATUS_STORE"), DefaultStatusStore),
+	}
+	state, err := loadSchedulerState(cfg.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	statusStore, err := openStatusStore(cfg, state)
+	if err != nil {
+		return nil, err
+	}
+	defer statusStore.Close()
+	rec, _, err := statusStore.Get(ctx, txHash)
+	return rec.Timeline, err
+}
+
+// saveState writes every unconfirmed submission to the state file. The file is replaced
+// atomically so a crash mid-write never leaves it truncated.
+func (s *WithdrawalScheduler) saveState() {
+	state := schedulerState{
+		Submitted: make(map[string]submittedTx),
+		Retired:   make(map[string]retiredWithdrawal),
+		Costs:     make(map[common.Address]walletCosts),
+		Relays:    make(map[string]relayRequest),
+		Proven:    make(map[string]time.Time),
+	}
+	s.mu.Lock()
+	for wallet, costs := range s.costs {
+		state.Costs[wallet] = costs
+	}
+	for hash, relay := range s.relays {
+		state.Relays[hash] = *relay
+	}
+	for hash, status := range s.withdrawalStatus {
+		if status.submitted.L1TxHash != (common.Hash{}) {
+			state.Submitted[hash] = status.submitted
+		}
+		if !status.provenAt.IsZero() && !status.finalized {
+			state.Proven[hash] = status.provenAt
+		}
+		if !status.retiredAt.IsZero() {
+			state.Retired[hash] = status.notified()
+		}
+	}
+	s.mu.Unlock()
+	if memory, ok := s.statusStore.(*store.Memory); ok {
+		state.Withdrawals = memory.Records()
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		s.logger.Errorf("❌ Failed to encode scheduler state: %v", err)
+		return
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	tmp := s.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		s.logger.Errorf("❌ Failed to write %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, s.stateFile); err != nil {
+		s.logger.Errorf("❌ Failed to replace %s: %v", s.stateFile, err)
+	}
+}
+
+// notified returns what was already notified for the withdrawal. Callers hold the scheduler's mu.
+func (status *WithdrawalStatus) notified() retiredWithdrawal {
+	return retiredWithdrawal{
+		SentWaitingMessage:  status.sentWaitingMessage,
+		Sent5MinuteReminder: status.sent5MinuteReminder,
+		Finalized:           status.finalized,
+		BlockedAction:       status.blockedAction,
+		NotifiedReady:       status.notifiedReady,
+		Label:               status.label,
+		RetiredAt:           status.retiredAt,
+	}
+}
+
+// restoreNotified marks the notifications in r as already sent
+func (status *WithdrawalStatus) restoreNotified(r retiredWithdrawal) {
+	status.sentWaitingMessage = r.SentWaitingMessage
+	status.sent5MinuteReminder = r.Sent5MinuteReminder
+	status.finalized = r.Finalized
+	status.blockedAction = r.BlockedAction
+	status.notifiedReady = r.NotifiedReady
+}
+
+// submitOptions returns options that resume status's last operation transaction, if
+// any, and persist each newly sent one. Callers hold status.submitMu.
+func (s *WithdrawalScheduler) submitOptions(txHash, operation string, status *WithdrawalStatus) crosschain.SubmitOptions {
+	s.mu.Lock()
+	status.fee = nil
+	s.mu.Unlock()
+
+	opts := crosschain.SubmitOptions{From: status.from}
+	if status.submitted.Operation == operation {
+		opts.PreviousTx = status.submitted.L1TxHash
+	}
+	opts.OnSubmitted = func(l1TxHash common.Hash) {
+		s.recordSubmitted(txHash, operation, status, l1TxHash)
+	}
+	opts.OnMined = func(fee crosschain.TxFee) {
+		s.mu.Lock()
+		status.fee = &fee
+		s.mu.Unlock()
+		s.recordFee(txHash, operation, fee)
+	}
+	if operation == "prove" {
+		opts.OnProven = func(p crosschain.ProofProvenance) {
+			s.mu.Lock()
+			status.provenance = &p
+			s.mu.Unlock()
+		}
+	}
+	return opts
+}
+
+// recordSubmitted persists status's newly sent operation transaction, so a restart
+// resumes it, and adds it to the withdrawal's timeline
+func (s *WithdrawalScheduler) recordSubmitted(txHash, operation string, status *WithdrawalStatus, l1TxHash common.Hash) {
+	s.mu.Lock()
+	status.submitted = submittedTx{Operation: operation, L1TxHash: l1TxHash, SentAt: time.Now()}
+	s.mu.Unlock()
+	s.saveState()
+	stage, _ := operationStages(operation)
+	s.recordTimeline(txHash, crosschain.TimelineEvent{Stage: stage, At: time.Now(), Chain: "L1", TxHash: l1TxHash.Hex()})
+}
+
+// recordFee adds a mined transaction's fee to its wallet's running total and to the
+// withdrawal's record, and persists both
+func (s *WithdrawalScheduler) recordFee(txHash, operation string, fee crosschain.TxFee) {
+	s.mu.Lock()
+	costs := s.costs[fee.From]
+	if costs.TotalWei == nil {
+		costs.TotalWei = new(big.Int)
+	}
+	costs.TotalWei = new(big.Int).Add(costs.TotalWei, fee.Wei)
+	if operation == "prove" {
+		costs.Proves++
+	} else {
+		costs.Finalizes++
+	}
+	s.costs[fee.From] = costs
+	s.mu.Unlock()
+	s.logger.Infof("💰 %s %s paid %s ETH; %s has now paid %s ETH",
+		operation, fee.L1TxHash.Hex(), fee.ETH(), fee.From.Hex(), crosschain.FormatEther(costs.TotalWei))
+	s.saveState()
+	_, stage := operationStages(operation)
+	s.updateRecord(txHash, func(rec *store.Record) {
+		if rec.FeesWei == nil {
+			rec.FeesWei = new(big.Int)
+		}
+		rec.FeesWei = new(big.Int).Add(rec.FeesWei, fee.Wei)
+		rec.Timeline = rec.Timeline.With(crosschain.TimelineEvent{Stage: stage, At: time.Now(), Chain: "L1",
+			TxHash: fee.L1TxHash.Hex(), Block: fee.L1Block})
+	})
+}
+
+// clearSubmitted forgets status's unconfirmed transaction once it no longer matters.
+// Callers hold status.submitMu.
+func (s *WithdrawalScheduler) clearSubmitted(status *WithdrawalStatus) {
+	if status.submitted.L1TxHash == (common.Hash{}) {
+		return
+	}
+	s.mu.Lock()
+	status.submitted = submittedTx{}
+	s.mu.Unlock()
+	s.saveState()
+}
+
+// recordStatus saves a withdrawal's status and next action to the status store, and when
+// it was first seen. Only a challenge period wait has a known next action time.
+func (s *WithdrawalScheduler) recordStatus(txHash string, message crosschain.Message, action crosschain.Action, state crosschain.WithdrawalState) {
+	s.updateRecord(txHash, func(rec *store.Record) {
+		if _, ok := rec.Timeline.Event(crosschain.StageFirstSeen); !ok {
+			rec.Timeline = rec.Timeline.With(crosschain.TimelineEvent{Stage: crosschain.StageFirstSeen, At: time.Now(),
+				Chain: "L2", TxHash: txHash, Block: message.BlockNumber})
+		}
+		rec.Status = crosschain.StatusDescription(message.Status)
+		rec.NextAction = string(action)
+		rec.NextActionAt = time.Time{}
+		if action == crosschain.ActionWaitChallenge {
+			rec.NextActionAt = state.FinalizeAt
+		}
+		rec.LastError = ""
+	})
+}
+
+// recordTimeline adds ev to txHash's timeline in the status store; a stage already
+// recorded keeps its first sighting
+func (s *WithdrawalScheduler) recordTimeline(txHash string, ev crosschain.TimelineEvent) {
+	s.updateRecord(txHash, func(rec *store.Record) {
+		rec.Timeline = rec.Timeline.With(ev)
+	})
+}
+
+// backfillTimeline reconstructs txHash's timeline from chain data when state shows it
+// reached a stage the timeline lacks, e.g. proven by someone else or before it was
+// tracked. It tries once per stage so a lookup that finds nothing isn't repeated every check.
+func (s *WithdrawalScheduler) backfillTimeline(txHash string, status *WithdrawalStatus, state crosschain.WithdrawalState) {
+	goal := timelineGoal(state)
+	s.mu.Lock()
+	tried := status.timelineBackfill == goal
+	s.mu.Unlock()
+	if tried {
+		return
+	}
+	rec, _, err := s.statusStore.Get(s.ctx, txHash)
+	if err != nil {
+		s.logger.Warnf("⚠️  Failed to read the status of %s: %v", txHash, err)
+		return
+	}
+	if _, ok := rec.Timeline.Event(goal); ok {
+		return
+	}
+
+	s.mu.Lock()
+	status.timelineBackfill = goal
+	s.mu.Unlock()
+	timeline, err := s.messenger.WithdrawalTimeline(s.ctx, txHash)
+	if err != nil {
+		s.logger.Warnf("⚠️  Failed to reconstruct the timeline of %s: %v", s.displayName(txHash), err)
+		return
+	}
+	s.updateRecord(txHash, func(rec *store.Record) {
+		rec.Timeline = rec.Timeline.Merge(timeline)
+	})
+}
+
+// timelineGoal is the latest timeline stage state shows the withdrawal has reached
+func timelineGoal(state crosschain.WithdrawalState) crosschain.TimelineStage {
+	switch {
+	case crosschain.FinalizedAtPortal(state.Status):
+		return crosschain.StageFinalizeMined
+	case state.ChallengePassed:
+		return crosschain.StageFinalizeEligible
+	case state.Status == crosschain.StatusProven:
+		return crosschain.StageProveMined
+	case state.OutputProposed:
+		return crosschain.StageProvable
+	default:
+		return crosschain.StageFirstSeen
+	}
+}
+
+// operationStages are the timeline stages of sending and mining a prove or finalize
+func operationStages(operation string) (submitted, mined crosschain.TimelineStage) {
+	if operation == "prove" {
+		return crosschain.StageProveSubmitted, crosschain.StageProveMined
+	}
+	return crosschain.StageFinalizeSubmitted, crosschain.StageFinalizeMined
+}
+
+// recordError saves why a withdrawal's last check or submission failed
+func (s *WithdrawalScheduler) recordError(txHash string, err error) {
+	reason := err.Error()
+	if revert, ok := crosschain.RevertReason(err); ok {
+		reason = revert
+	}
+	s.updateRecord(txHash, func(rec *store.Record) {
+		rec.LastError = reason
+	})
+}
+
+// updateRecord applies update to txHash's record in the status store, creating it if
+// needed, and refreshes its label and update time
+func (s *WithdrawalScheduler) updateRecord(txHash string, update func(rec *store.Record)) {
+	rec, _, err := s.statusStore.Get(s.ctx, txHash)
+	if err != nil {
+		s.logger.Warnf("⚠️  Failed to read the status of %s: %v", txHash, err)
+		return
+	}
+	rec.TxHash = txHash
+	rec.Label = s.labelOf(txHash)
+	update(&rec)
+	rec.UpdatedAt = time.Now()
+	if err := s.statusStore.Upsert(s.ctx, rec); err != nil {
+		s.logger.Warnf("⚠️  Failed to record the status of %s: %v", txHash, err)
+		return
+	}
+	if _, ok := s.statusStore.(*store.Memory); ok {
+		s.saveState()
+	}
+}
+
+// recordProven stores the on-chain proven timestamp of a prove we mined and returns when
+// the withdrawal can be finalized, persisting both so later cycles can spot a reorg
+func (s *WithdrawalScheduler) recordProven(status *WithdrawalStatus, provenAt time.Time) time.Time {
+	finalizeAt := provenAt.Add(s.messenger.FinalizationParams(s.ctx).Period)
+	s.mu.Lock()
+	status.provenAt = provenAt
+	status.finalizeAt = finalizeAt
+	s.mu.Unlock()
+	s.saveState()
+	return finalizeAt
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// queuedSubmission is a prove or finalize deferred to the end of a check cycle so that
+// several ready withdrawals can be submitted as one batch
+type queuedSubmission struct {
+	txHash              string
+	status              *WithdrawalStatus
+	state               crosschain.WithdrawalState
+	message             crosschain.Message
+	latestProposedBlock uint64
+}
+
+// submissionQueue collects the submissions found by concurrent workers in one cycle
+type submissionQueue struct {
+	mu       sync.Mutex
+	prove    []queuedSubmission
+	finalize []queuedSubmission
+}
+
+func (q *submissionQueue) add(action crosschain.Action, sub queuedSubmission) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if action == crosschain.ActionFinalize {
+		q.finalize = append(q.finalize, sub)
+	} else {
+		q.prove = append(q.prove, sub)
+	}
+}
+
+// submitQueued sends the prove and finalize transactions queued during a cycle. A lone
+// withdrawal goes through the regular path; several are submitted as one batch.
+func (s *WithdrawalScheduler) submitQueued(queue *submissionQueue) map[string]error {
+	failures := make(map[string]error)
+
+	// Withdrawals with a transaction from before a restart go through the regular path,
+	// which resumes that transaction instead of batching a duplicate
+	if len(queue.prove) > 1 || len(queue.finalize) > 1 {
+		queue.prove = s.submitResumable(queue.prove, failures, func(sub queuedSubmission) error {
+			return s.proveWithdrawal(sub.txHash, sub.status, sub.message, sub.latestProposedBlock)
+		})
+		queue.finalize = s.submitResumable(queue.finalize, failures, func(sub queuedSubmission) error {
+			return s.finalizeWithdrawal(sub.txHash, sub.status, sub.state)
+		})
+	}
+
+	// A batch is signed by one wallet, so withdrawals are batched per signing wallet
+	for _, subs := range groupByWallet(queue.prove) {
+		switch {
+		case len(subs) == 1:
+			sub := subs[0]
+			if err := s.proveWithdrawal(sub.txHash, sub.status, sub.message, sub.latestProposedBlock); err != nil && !errors.Is(err, errSubmissionInProgress) {
+				failures[sub.txHash] = err
+			}
+		case len(subs) > 1:
+			s.submitBatch("prove", subs, s.messenger.BatchProve, failures)
+		}
+	}
+
+	for _, subs := range groupByWallet(queue.finalize) {
+		switch {
+		case len(subs) == 1:
+			sub := subs[0]
+			if err := s.finalizeWithdrawal(sub.txHash, sub.status, sub.state); err != nil && !errors.Is(err, errSubmissionInProgress) {
+				failures[sub.txHash] = err
+			}
+		case len(subs) > 1:
+			s.submitBatch("finalize", subs, s.messenger.BatchFinalize, failures)
+		}
+	}
+	return failures
+}
+
+// groupByWallet splits subs by signing wallet, keeping the queue order within and
+// across groups
+func groupByWallet(subs []queuedSubmission) [][]queuedSubmission {
+	var groups [][]queuedSubmission
+	index := make(map[common.Address]int)
+	for _, sub := range subs {
+		i, ok := index[sub.status.from]
+		if !ok {
+			i = len(groups)
+			index[sub.status.from] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], sub)
+	}
+	return groups
+}
+
+// submitResumable submits the subs that have an unconfirmed transaction with submit and
+// returns the rest
+func (s *WithdrawalScheduler) submitResumable(subs []queuedSubmission, failures map[string]error, submit func(sub queuedSubmission) error) []queuedSubmission {
+	rest := subs[:0:0]
+	for _, sub := range subs {
+		if !sub.status.submitMu.TryLock() {
+			rest = append(rest, sub) // submitBatch skips it too
+			continue
+		}
+		pending := sub.status.submitted.L1TxHash != (common.Hash{})
+		sub.status.submitMu.Unlock()
+		if !pending {
+			rest = append(rest, sub)
+			continue
+		}
+		if err := submit(sub); err != nil && !errors.Is(err, errSubmissionInProgress) {
+			failures[sub.txHash] = err
+		}
+	}
+	return rest
+}
+
+// submitBatch runs a batch prove/finalize for subs and reports each result
+func (s *WithdrawalScheduler) submitBatch(operation string, subs []queuedSubmission,
+	batch func(context.Context, []string, crosschain.BatchOptions) ([]crosschain.BatchResult, error), failures map[string]error) {
+	// Leave out withdrawals a bot command is already submitting
+	locked := subs[:0:0]
+	for _, sub := range subs {
+		if !sub.status.submitMu.TryLock() {
+			s.logger.Infof("⏭️  A prove/finalize for %s is already in progress, leaving it out of the batch", s.displayName(sub.txHash))
+			continue
+		}
+		defer sub.status.submitMu.Unlock()
+		locked = append(locked, sub)
+	}
+	subs = locked
+	if len(subs) == 0 {
+		return
+	}
+
+	txHashes := make([]string, len(subs))
+	for i, sub := range subs {
+		txHashes[i] = sub.txHash
+	}
+
+	title := strings.ToUpper(operation[:1]) + operation[1:]
+	s.logger.Infof("🚀 %d withdrawals ready to %s — submitting as a batch", len(subs), operation)
+	s.notify(notify.EventBatchSubmitted, "", fmt.Sprintf(
+		"🚀 *Starting Batch %s*\n\n"+
+			"Withdrawals: %d\n"+
+			"`%s`",
+		title, len(subs), strings.Join(txHashes, "`\n`")))
+
+	// Each transaction is recorded as it is sent, like a lone submission, so a restart
+	// resumes it instead of sending a duplicate
+	byHash := make(map[string]*WithdrawalStatus, len(subs))
+	for _, sub := range subs {
+		byHash[sub.txHash] = sub.status
+	}
+	results, err := batch(s.ctx, txHashes, crosschain.BatchOptions{
+		From: subs[0].status.from,
+		OnSubmitted: func(txHash string, l1TxHash common.Hash) {
+			s.recordSubmitted(txHash, operation, byHash[txHash], l1TxHash)
+		},
+	})
+	if errors.Is(err, crosschain.ErrPortalPaused) {
+		// Nothing was sent; the withdrawals are submitted after the unpause
+		s.setPortalPaused(true, "")
+		return
+	}
+	if err != nil {
+		s.logger.Errorf("❌ Batch %s failed: %v", operation, err)
+		s.notifyFailure(notify.EventBatchFailed, "", fmt.Sprintf("❌ *Batch %s Failed*\n\nError: %s", title, notify.EscapeMarkdown(err.Error())), err)
+		for _, txHash := range txHashes {
+			failures[txHash] = err
+		}
+		return
+	}
+
+	var lines []string
+	for i, r := range results {
+		// A failed transaction stays recorded for the next check to look up, as after a
+		// lone submission
+		if r.Err == nil {
+			s.clearSubmitted(subs[i].status)
+		}
+		switch {
+		case r.Err != nil:
+			failures[r.TxHash] = r.Err
+			lines = append(lines, fmt.Sprintf("❌ `%s`: %s", r.TxHash, briefError(r.Err)))
+		case r.Skipped:
+			lines = append(lines, fmt.Sprintf("⏭️ `%s`: already done", r.TxHash))
+		case r.External:
+			lines = append(lines, fmt.Sprintf("🤝 `%s`: %s externally", r.TxHash, operation))
+		case r.Fee != nil:
+			s.recordFee(r.TxHash, operation, *r.Fee)
+			lines = append(lines, fmt.Sprintf("✅ `%s`: `%s` (fee %s ETH)", r.TxHash, r.L1TxHash, r.Fee.ETH()))
+		default:
+			lines = append(lines, fmt.Sprintf("✅ `%s`: `%s`", r.TxHash, r.L1TxHash))
+		}
+		if r.Err == nil && operation == "finalize" {
+			s.markFinalized(subs[i].txHash, subs[i].status)
+		}
+		if r.Err == nil && r.Provenance != nil {
+			s.mu.Lock()
+			subs[i].status.provenance = r.Provenance
+			s.mu.Unlock()
+			s.recordProven(subs[i].status, r.Provenance.ProvenAt)
+		}
+	}
+	s.notify(notify.EventBatchResults, "", fmt.Sprintf("📦 *Batch %s Results*\n\n%s", title, strings.Join(lines, "\n")))
+}
+
+// failureDetail formats err for a failure notification, putting the decoded contract
+// revert reason (if any) on its own line ahead of the full error
+func failureDetail(err error) string {
+	if reason, ok := crosschain.RevertReason(err); ok {
+		return fmt.Sprintf("Reason: %s\nError: %s", notify.EscapeMarkdown(reason), notify.EscapeMarkdown(err.Error()))
+	}
+	return "Error: " + notify.EscapeMarkdown(err.Error())
+}
+
+// briefError returns the decoded revert reason if there is one, otherwise err itself,
+// escaped for a Markdown notification
+func briefError(err error) string {
+	if reason, ok := crosschain.RevertReason(err); ok {
+		return notify.EscapeMarkdown(reason)
+	}
+	return notify.EscapeMarkdown(err.Error())
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+	"mantle-claim-crossing/store"
+)
+
+// SendSummary notifies the daily summary: counts per status, what was finalized in the
+// last day and its fees, challenge period countdowns and failing withdrawals
+func (s *WithdrawalScheduler) SendSummary() {
+	text, err := s.summaryText()
+	if err != nil {
+		s.logger.Errorf("❌ Failed to build the summary: %v", err)
+		return
+	}
+	if s.notifier == nil {
+		s.logger.Infof("📰 No notification destination configured; summary:\n%s", text)
+		return
+	}
+	s.logger.Infof("📰 Sending the withdrawal summary")
+	s.notify(notify.EventDailySummary, "", text)
+}
+
+// summaryText builds the summary from the status store, without querying any chain
+func (s *WithdrawalScheduler) summaryText() (string, error) {
+	records, err := s.statusStore.ListByStatus(s.ctx)
+	if err != nil {
+		return "", err
+	}
+	return formatSummary(records, time.Now()), nil
+}
+
+// formatSummary formats records as of now for SendSummary and /summary
+func formatSummary(records []store.Record, now time.Time) string {
+	if len(records) == 0 {
+		return "📰 *Withdrawal Summary*\n\nNo withdrawals have been checked yet"
+	}
+
+	counts := make(map[string]int)
+	var finalized, waiting, failing []store.Record
+	fees := new(big.Int)
+	for _, rec := range records {
+		counts[rec.Status]++
+		switch {
+		case !rec.FinalizedAt.IsZero() && now.Sub(rec.FinalizedAt) <= summaryWindow:
+			finalized = append(finalized, rec)
+			if rec.FeesWei != nil {
+				fees.Add(fees, rec.FeesWei)
+			}
+		case rec.NextAction == string(crosschain.ActionWaitChallenge) && !rec.NextActionAt.IsZero():
+			waiting = append(waiting, rec)
+		}
+		if rec.LastError != "" {
+			failing = append(failing, rec)
+		}
+	}
+
+	lines := []string{"📰 *Withdrawal Summary*", "", fmt.Sprintf("Tracked withdrawals: %d", len(records))}
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		lines = append(lines, fmt.Sprintf("• %s: %d", notify.EscapeMarkdown(status), counts[status]))
+	}
+
+	if len(finalized) > 0 {
+		lines = append(lines, "", fmt.Sprintf("✅ *Finalized in the last 24h* (%d)", len(finalized)))
+		for _, rec := range finalized {
+			line := "• " + summaryName(rec)
+			if rec.FeesWei != nil {
+				line += fmt.Sprintf(": %s ETH in fees", crosschain.FormatEther(rec.FeesWei))
+			}
+			lines = append(lines, line)
+		}
+		lines = append(lines, fmt.Sprintf("Fees paid: %s ETH", crosschain.FormatEther(fees)))
+	}
+
+	if len(waiting) > 0 {
+		sort.Slice(waiting, func(i, j int) bool { return waiting[i].NextActionAt.Before(waiting[j].NextActionAt) })
+		lines = append(lines, "", fmt.Sprintf("⏳ *In challenge period* (%d)", len(waiting)))
+		for _, rec := range waiting {
+			line := fmt.Sprintf("• %s: finalize at %s", summaryName(rec), rec.NextActionAt.UTC().Format(time.RFC3339))
+			if remaining := rec.NextActionAt.Sub(now); remaining > 0 {
+				line += " (in " + formatCountdown(remaining) + ")"
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if len(failing) > 0 {
+		lines = append(lines, "", fmt.Sprintf("❌ *Failing* (%d)", len(failing)))
+		for _, rec := range failing {
+			lines = append(lines, fmt.Sprintf("• %s: %s", summaryName(rec), notify.EscapeMarkdown(rec.LastError)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// summaryName shows a record as its label, if any, and hash
+func summaryName(rec store.Record) string {
+	if rec.Label != "" {
+		return fmt.Sprintf("%s `%s`", notify.EscapeMarkdown(rec.Label), rec.TxHash)
+	}
+	return "`" + rec.TxHash + "`"
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/robfig/cron/v3"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/server"
+)
+
+// TargetConfig is one network a scheduler process runs next to others: its own RPC
+// endpoints, chain IDs and contracts, signer and withdrawals. The other settings are
+// shared.
+type TargetConfig struct {
+	Name        string             // Names the target in logs, notifications, metrics and its state file
+	Network     crosschain.Network // Preset with the target's chain ID and contract overrides applied
+	L1RPC       string             // Comma-separated; entries after the first are fallbacks
+	L2RPC       string
+	Signer      *crosschain.SignerConfig // nil signs with KMS_KEY_ID, PRIV_KEY or SIGNER_RPC_URL
+	Withdrawals []WithdrawalConfig
+}
+
+// schedulerFileTarget is one entry of targets: in the config file
+type schedulerFileTarget struct {
+	Name    string `yaml:"name" json:"name"`
+	Network string `yaml:"network" json:"network"`
+	RPC     struct {
+		L1 []string `yaml:"l1" json:"l1"`
+		L2 []string `yaml:"l2" json:"l2"`
+	} `yaml:"rpc" json:"rpc"`
+	ChainIDs struct {
+		L1 uint64 `yaml:"l1" json:"l1"`
+		L2 uint64 `yaml:"l2" json:"l2"`
+	} `yaml:"chain_ids" json:"chain_ids"`
+	Contracts struct {
+		OptimismPortal         string `yaml:"optimism_portal" json:"optimism_portal"`
+		L2OutputOracle         string `yaml:"l2_output_oracle" json:"l2_output_oracle"`
+		L1CrossDomainMessenger string `yaml:"l1_cross_domain_messenger" json:"l1_cross_domain_messenger"`
+		L1StandardBridge       string `yaml:"l1_standard_bridge" json:"l1_standard_bridge"`
+	} `yaml:"contracts" json:"contracts"`
+	Signer *struct {
+		KMSKeyID      string `yaml:"kms_key_id" json:"kms_key_id"`
+		PrivKeyEnv    string `yaml:"priv_key_env" json:"priv_key_env"` // Variable holding the private key, so it stays out of the file
+		RemoteURL     string `yaml:"signer_rpc_url" json:"signer_rpc_url"`
+		RemoteAddress string `yaml:"signer_address" json:"signer_address"`
+	} `yaml:"signer" json:"signer"`
+	Withdrawals []schedulerFileWithdrawal `yaml:"withdrawals" json:"withdrawals"`
+}
+
+// targetNamePattern is what a target name may look like; it becomes part of file names
+var targetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// targets validates the file's targets. A target without a network uses the file's, and
+// a target without a name is named after its network.
+func (v *configValidator) targets(file schedulerFile, network crosschain.Network, seen map[string]int) []TargetConfig {
+	if len(file.Targets) == 0 {
+		return nil
+	}
+	if len(file.Withdrawals) > 0 {
+		v.errorf("withdrawals:", 0, "withdrawals are listed under each target when targets are set")
+	}
+	if len(file.RPC.L1) > 0 || len(file.RPC.L2) > 0 {
+		v.errorf("rpc:", 0, "rpc is set under each target when targets are set")
+	}
+
+	names := make(map[string]bool)
+	var targets []TargetConfig
+	for i, t := range file.Targets {
+		target := TargetConfig{Name: t.Name, Network: network}
+		if t.Network != "" {
+			preset, err := crosschain.LookupNetwork(t.Network)
+			if err != nil {
+				v.errorf("network: "+t.Network, 0, "target #%d: %v", i+1, err)
+				continue
+			}
+			target.Network = preset
+		}
+		if target.Name == "" {
+			target.Name = target.Network.Name
+		}
+		needle := cmp.Or(t.Name, t.Network)
+		if !targetNamePattern.MatchString(target.Name) {
+			v.errorf(needle, 0, "invalid target name %q: use lowercase letters, digits, - and _", target.Name)
+			continue
+		}
+		if names[target.Name] {
+			v.errorf(needle, 1, "target %s is listed more than once; name one of them", target.Name)
+			continue
+		}
+		names[target.Name] = true
+
+		if len(t.RPC.L1) == 0 || len(t.RPC.L2) == 0 {
+			v.errorf(needle, 0, "target %s needs both rpc.l1 and rpc.l2", target.Name)
+			continue
+		}
+		target.L1RPC = strings.Join(t.RPC.L1, ",")
+		target.L2RPC = strings.Join(t.RPC.L2, ",")
+
+		if t.ChainIDs.L1 != 0 || t.ChainIDs.L2 != 0 {
+			target.Network.L1ChainID = cmp.Or(t.ChainIDs.L1, target.Network.L1ChainID)
+			target.Network.L2ChainID = cmp.Or(t.ChainIDs.L2, target.Network.L2ChainID)
+			target.Network.Explorer = crosschain.ExplorerForChains(target.Network.L1ChainID, target.Network.L2ChainID)
+		}
+		contracts := &target.Network.Contracts
+		for _, c := range []struct {
+			name, value string
+			fields      []*string
+		}{
+			{"optimism_portal", t.Contracts.OptimismPortal, []*string{&contracts.L1.OptimismPortal}},
+			{"l2_output_oracle", t.Contracts.L2OutputOracle, []*string{&contracts.L1.L2OutputOracle}},
+			{"l1_cross_domain_messenger", t.Contracts.L1CrossDomainMessenger, []*string{&contracts.L1.L1CrossDomainMessenger}},
+			{"l1_standard_bridge", t.Contracts.L1StandardBridge, []*string{&contracts.L1.L1StandardBridge, &contracts.Bridges.L1Bridge}},
+		} {
+			if c.value == "" {
+				continue
+			}
+			if !common.IsHexAddress(c.value) {
+				v.errorf(c.value, 0, "invalid %s %q for target %s: must be an address", c.name, c.value, target.Name)
+				continue
+			}
+			for _, field := range c.fields {
+				*field = c.value
+			}
+		}
+
+		if s := t.Signer; s != nil {
+			signer := crosschain.SignerConfig{KMSKeyID: s.KMSKeyID, RemoteURL: s.RemoteURL, RemoteAddress: s.RemoteAddress}
+			if s.PrivKeyEnv != "" {
+				if signer.PrivateKey = os.Getenv(s.PrivKeyEnv); signer.PrivateKey == "" {
+					v.errorf(s.PrivKeyEnv, 0, "priv_key_env of target %s names %s, which is not set", target.Name, s.PrivKeyEnv)
+					continue
+				}
+			}
+			if signer == (crosschain.SignerConfig{}) {
+				v.errorf(needle, 0, "target %s has an empty signer: set kms_key_id, priv_key_env or signer_rpc_url", target.Name)
+				continue
+			}
+			target.Signer = &signer
+		}
+
+		target.Withdrawals = v.withdrawals(t.Withdrawals, seen)
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// checkTargets rejects the settings that can't be told apart between targets once the
+// environment is applied. It checks the config of a single target as well, so a target
+// config built without LoadSchedulerConfig can't turn them on either.
+func (cfg SchedulerConfig) checkTargets() error {
+	if len(cfg.Targets) == 0 && cfg.Target == "" {
+		return nil
+	}
+	if os.Getenv("WITHDRAWAL_TX_HASH") != "" {
+		return fmt.Errorf("WITHDRAWAL_TX_HASH can't be used with targets: list the withdrawals under each target")
+	}
+	if cfg.Relay.Enabled {
+		return fmt.Errorf("the relay API can't be used with targets: a relay request doesn't say which network it is on")
+	}
+	if cfg.Notifications.Telegram.Commands {
+		return fmt.Errorf("Telegram bot commands can't be used with targets: turn off telegram.commands (TELEGRAM_COMMANDS)")
+	}
+	return nil
+}
+
+// targetConfigs returns the config of each target: the shared settings with the target's
+// network, RPC endpoints, signer and withdrawals, and a state file and status store of
+// its own. Without targets it returns cfg.
+func (cfg SchedulerConfig) targetConfigs() []SchedulerConfig {
+	if len(cfg.Targets) == 0 {
+		return []SchedulerConfig{cfg}
+	}
+	configs := make([]SchedulerConfig, 0, len(cfg.Targets))
+	for _, t := range cfg.Targets {
+		c := cfg
+		c.Targets = nil
+		c.Target = t.Name
+		c.Network = t.Network
+		c.L1RPC = t.L1RPC
+		c.L2RPC = t.L2RPC
+		c.Signer = t.Signer
+		c.Withdrawals = t.Withdrawals
+		c.StateFile = namespacedPath(cfg.StateFile, t.Name)
+		if path, ok := strings.CutPrefix(cfg.Store, "sqlite:"); ok {
+			c.Store = "sqlite:" + namespacedPath(path, t.Name)
+		}
+		configs = append(configs, c)
+	}
+	return configs
+}
+
+// targetConfig returns the config of the target called name
+func (cfg SchedulerConfig) targetConfig(name string) (SchedulerConfig, error) {
+	for _, c := range cfg.targetConfigs() {
+		if c.Target == name {
+			return c, nil
+		}
+	}
+	return cfg, fmt.Errorf("target %s is no longer in the config; restart to remove it", name)
+}
+
+// namespacedPath inserts name before the extension of path, so scheduler-state.json
+// becomes scheduler-state.mainnet.json
+func namespacedPath(path, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + name + ext
+}
+
+// schedulerLogger returns the leveled logger of a scheduler built from cfg; a target's
+// lines are prefixed with its name
+func schedulerLogger(cfg SchedulerConfig) crosschain.Logger {
+	if cfg.Target == "" {
+		return crosschain.NewStdLogger(log.Default(), cfg.LogLevel)
+	}
+	return crosschain.NewStdLogger(log.New(log.Writer(), "["+cfg.Target+"] ", log.Flags()), cfg.LogLevel)
+}
+
+// isTargets reports whether schedulers are the targets of one config rather than a
+// single scheduler
+func isTargets(schedulers []*WithdrawalScheduler) bool {
+	return len(schedulers) > 1 || schedulers[0].target != ""
+}
+
+// checkAll runs one check cycle of every scheduler
+func checkAll(schedulers []*WithdrawalScheduler) {
+	if !isTargets(schedulers) {
+		schedulers[0].CheckAllWithdrawals()
+		return
+	}
+	runTargets(schedulers, func(s *WithdrawalScheduler) { s.CheckAllWithdrawals() })
+}
+
+// runTargets calls fn for every target at once and waits for all of them, so a slow or
+// failing network doesn't hold up the others
+func runTargets(schedulers []*WithdrawalScheduler, fn func(*WithdrawalScheduler)) {
+	var wg sync.WaitGroup
+	for _, s := range schedulers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.recoverTarget()
+			fn(s)
+		}()
+	}
+	wg.Wait()
+}
+
+// recoverTarget logs a panic in one target's work instead of letting it take the other
+// targets down. It must be deferred directly.
+func (s *WithdrawalScheduler) recoverTarget() {
+	if r := recover(); r != nil {
+		s.logger.Errorf("❌ Target %s panicked: %v\n%s", s.target, r, debug.Stack())
+	}
+}
+
+// startAll starts the schedulers in continuous mode
+func startAll(schedulers []*WithdrawalScheduler) {
+	if !isTargets(schedulers) {
+		schedulers[0].Start()
+		return
+	}
+	startTargets(schedulers)
+}
+
+// startTargets is Start for several targets in one process: one cron runs each target's
+// check schedule and summary as separate jobs, one listener serves the health probes and
+// metrics of all of them, and a shutdown signal drains them all at once
+func startTargets(schedulers []*WithdrawalScheduler) {
+	names := make([]string, len(schedulers))
+	startedAt := time.Now()
+	for i, s := range schedulers {
+		names[i] = s.target
+		s.startedAt = startedAt
+	}
+	log.Printf("🚀 Starting withdrawal scheduler for %d target(s): %s", len(schedulers), strings.Join(names, ", "))
+
+	var healthServer *http.Server
+	if schedulers[0].healthAddr != "" {
+		healthServer = serveTargetsHealth(schedulers)
+		for _, s := range schedulers {
+			go s.pingRPC()
+		}
+	}
+
+	// A check that runs long is skipped rather than overlapped, per target
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	for _, s := range schedulers {
+		s.logger.Infof("🌐 L1 chain %d, L2 chain %d (mode: %s, check interval: %s)",
+			s.messenger.L1ChainID, s.messenger.L2ChainID, s.mode, s.checkInterval)
+		c.Schedule(adaptiveSchedule{s: s}, cron.FuncJob(func() {
+			defer s.recoverTarget()
+			s.logger.Infof("\n⏰ Running scheduled check at %s...", time.Now().Format(time.RFC3339))
+			s.CheckAllWithdrawals()
+		}))
+		if s.summarySchedule == "" {
+			continue
+		}
+		if _, err := c.AddFunc(s.summarySchedule, func() {
+			defer s.recoverTarget()
+			s.SendSummary()
+		}); err != nil {
+			s.logger.Warnf("⚠️  Failed to schedule the daily summary: %v", err)
+		}
+	}
+
+	// Installed before the initial check, the longest cycle, so a signal during it drains too
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	log.Printf("\n⏰ Performing initial check of every target...")
+	initial := make(chan struct{})
+	go func() {
+		defer close(initial)
+		runTargets(schedulers, func(s *WithdrawalScheduler) { s.CheckAllWithdrawals() })
+	}()
+	select {
+	case <-initial:
+		for _, s := range schedulers {
+			s.startWatchers()
+		}
+
+		c.Start()
+		for _, s := range schedulers {
+			s.mu.Lock()
+			s.running = true
+			s.mu.Unlock()
+		}
+		log.Printf("✅ Cron scheduler started")
+		<-sigChan
+	case <-sigChan:
+	}
+	log.Printf("\n🛑 Received shutdown signal, stopping %d target(s)...", len(schedulers))
+
+	// Every target drains at once; a second signal stops all of them waiting
+	again := make(chan os.Signal)
+	drained := make(chan struct{})
+	go func() {
+		select {
+		case <-sigChan:
+			close(again)
+		case <-drained:
+		}
+	}()
+	runTargets(schedulers, func(s *WithdrawalScheduler) {
+		s.drain(c, again)
+		s.cancel()
+		s.saveState()
+	})
+	close(drained)
+	schedulers[0].stopHealth(healthServer)
+}
+
+// serveTargetsHealth is serveHealth for several targets. A probe fails only when it fails
+// for every target: restarting the process wouldn't fix one network's outage and would
+// interrupt the others, which keep reporting their own failures.
+func serveTargetsHealth(schedulers []*WithdrawalScheduler) *http.Server {
+	live := func(ctx context.Context) error {
+		return allTargetsFail(schedulers, func(s *WithdrawalScheduler) error { return s.liveness(ctx) })
+	}
+	ready := func(ctx context.Context) error {
+		return allTargetsFail(schedulers, func(s *WithdrawalScheduler) error { return s.readiness(ctx) })
+	}
+	stats := make(map[string]func() []crosschain.RateLimitStats)
+	for _, s := range schedulers {
+		stats[s.target] = s.messenger.RateLimitStats
+	}
+	mux := server.HealthMux(live, ready, func() *bool { return anyTargetPaused(schedulers) })
+	mux.HandleFunc("GET /metrics", server.NetworkMetricsHandler(stats))
+
+	addr := schedulers[0].healthAddr
+	healthServer := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Printf("🩺 Health probes listening on %s (/healthz, /readyz, /metrics)", addr)
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("❌ Health listener failed: %v", err)
+		}
+	}()
+	return healthServer
+}
+
+// allTargetsFail returns nil when probe passes for any target, else each target's failure
+func allTargetsFail(schedulers []*WithdrawalScheduler, probe func(*WithdrawalScheduler) error) error {
+	var errs []error
+	for _, s := range schedulers {
+		err := probe(s)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", s.target, err))
+	}
+	return errors.Join(errs...)
+}
+
+// anyTargetPaused reports whether the OptimismPortal of any target is paused; nil while
+// no target has read the flag yet
+func anyTargetPaused(schedulers []*WithdrawalScheduler) *bool {
+	var paused *bool
+	for _, s := range schedulers {
+		if p := s.pausedFlag(); p != nil && (paused == nil || *p) {
+			paused = p
+		}
+	}
+	return paused
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestCheckTargetsRejectsRelayAndCommands(t *testing.T) {
+	targets := SchedulerConfig{Targets: []TargetConfig{{Name: "mainnet"}, {Name: "sepolia"}}}
+
+	tests := []struct {
+		name  string
+		cfg   func(SchedulerConfig) SchedulerConfig
+		fails bool
+	}{
+		{"targets", func(c SchedulerConfig) SchedulerConfig { return c }, false},
+		{"relay", func(c SchedulerConfig) SchedulerConfig { c.Relay.Enabled = true; return c }, true},
+		{"commands", func(c SchedulerConfig) SchedulerConfig { c.Notifications.Telegram.Commands = true; return c }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg(targets)
+			if err := cfg.checkTargets(); (err != nil) != tt.fails {
+				t.Fatalf("checkTargets() = %v, want error %v", err, tt.fails)
+			}
+			// The same setting in the config of one target, as NewWithdrawalScheduler sees it
+			for _, target := range cfg.targetConfigs() {
+				if err := target.checkTargets(); (err != nil) != tt.fails {
+					t.Fatalf("checkTargets() of target %s = %v, want error %v", target.Target, err, tt.fails)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTargetsSingleNetwork(t *testing.T) {
+	cfg := SchedulerConfig{}
+	cfg.Relay.Enabled = true
+	cfg.Notifications.Telegram.Commands = true
+	if err := cfg.checkTargets(); err != nil {
+		t.Fatalf("checkTargets() without targets = %v, want nil", err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// walletQueue is a signing wallet's pending nonce gap, from when it was first seen at
+// its current oldest nonce
+type walletQueue struct {
+	nonce   uint64    // Latest nonce: the pending transaction everything else waits on
+	since   time.Time // When the gap was first seen at nonce
+	alerted bool      // EventWalletQueueStuck was sent for nonce
+}
+
+// walletCosts is the running total of L1 fees a wallet paid for our proves and
+// finalizes, persisted in the state file
+type walletCosts struct {
+	Proves    int      `json:"proves"`
+	Finalizes int      `json:"finalizes"`
+	TotalWei  *big.Int `json:"totalWei"`
+}
+
+// checkWallets resolves every signer and checks that each withdrawal's from wallet is
+// one of them, so a typo in a wallet mapping fails at startup
+func checkWallets(ctx context.Context, messenger *crosschain.CrossChainMessenger, withdrawals []WithdrawalConfig, logger crosschain.Logger) error {
+	wallets, err := messenger.WalletAddresses(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve signer wallets: %w", err)
+	}
+	walletList := make([]string, len(wallets))
+	known := make(map[common.Address]bool)
+	for i, wallet := range wallets {
+		walletList[i] = wallet.Hex()
+		known[wallet] = true
+	}
+	logger.Infof("💼 Wallets: %s (default %s)", strings.Join(walletList, ", "), walletList[0])
+	for _, w := range withdrawals {
+		if w.From != (common.Address{}) && !known[w.From] {
+			return fmt.Errorf("%s is mapped to %s, which is not a configured signer", w.Hash, w.From.Hex())
+		}
+	}
+	return nil
+}
+
+// checkWalletQueues compares the pending and latest L1 nonces of every signing wallet. A
+// gap means transactions are waiting on the one at the latest nonce, and the proves and
+// finalizes sent next would queue behind it unseen. When the gap stays at the same
+// nonce for stuckTxAlert it is alerted once, suggesting a fee bump or cancel-pending.
+func (s *WithdrawalScheduler) checkWalletQueues() {
+	wallets, err := s.messenger.WalletAddresses(s.ctx)
+	if err != nil {
+		s.logger.Warnf("⚠️  Failed to list wallets for the pending transaction check: %v", err)
+		return
+	}
+	for _, wallet := range wallets {
+		queue, err := s.messenger.CheckPendingQueue(s.ctx, wallet)
+		if err != nil {
+			s.logger.Warnf("⚠️  Pending transaction check failed for %s: %v", wallet.Hex(), err)
+			continue
+		}
+
+		s.mu.Lock()
+		tracked := s.walletQueues[wallet]
+		if queue.Pending() == 0 {
+			delete(s.walletQueues, wallet)
+			s.mu.Unlock()
+			if tracked != nil {
+				s.logger.Infof("✅ Pending transactions of %s were mined", wallet.Hex())
+				if tracked.alerted {
+					s.notify(notify.EventWalletQueueCleared, "", fmt.Sprintf(
+						"✅ *Wallet Queue Cleared*\n\n"+
+							"Wallet: `%s`\n"+
+							"No transactions are pending anymore (nonce %d).",
+						wallet.Hex(), queue.LatestNonce))
+				}
+			}
+			continue
+		}
+		// A different oldest nonce means the stuck one got mined; the new gap starts over
+		if tracked == nil || tracked.nonce != queue.LatestNonce {
+			tracked = &walletQueue{nonce: queue.LatestNonce, since: time.Now()}
+			s.walletQueues[wallet] = tracked
+		}
+		age := time.Since(tracked.since)
+		alert := s.stuckTxAlert > 0 && !tracked.alerted && age >= s.stuckTxAlert
+		if alert {
+			tracked.alerted = true
+		}
+		s.mu.Unlock()
+
+		s.logger.Infof("⏳ %s has %d pending transaction(s) from nonce %d, pending for at least %s",
+			wallet.Hex(), queue.Pending(), queue.LatestNonce, age.Round(time.Second))
+		if !alert {
+			continue
+		}
+		s.logger.Warnf("⚠️  Nonce %d of %s has been pending for %s; %d transaction(s) wait behind it",
+			queue.LatestNonce, wallet.Hex(), age.Round(time.Second), queue.Pending()-1)
+		s.notify(notify.EventWalletQueueStuck, "", fmt.Sprintf(
+			"⛽ *Wallet Transactions Stuck*\n\n"+
+				"Wallet: `%s`\n"+
+				"Pending: %d transaction(s), nonces %d-%d\n"+
+				"The transaction at nonce %d has been pending for over %s; proves and finalizes from this wallet queue behind it.\n"+
+				"Our own transactions are re-sent with higher fees every REPLACEMENT\\_TIMEOUT up to REPLACEMENT\\_MAX\\_FEE\\_GWEI; raise that ceiling, "+
+				"or replace the stuck transaction with a 0-value self-transfer: `bridge-status cancel-pending --from %s`",
+			wallet.Hex(), queue.Pending(), queue.LatestNonce, queue.PendingNonce-1,
+			queue.LatestNonce, s.stuckTxAlert, wallet.Hex()))
+	}
+}
+
+// costsReply reports the L1 fees paid per wallet for /costs
+func (s *WithdrawalScheduler) costsReply() string {
+	s.mu.Lock()
+	lines := costReport(s.costs, func(wallet common.Address) string { return "`" + wallet.Hex() + "`" })
+	s.mu.Unlock()
+	if lines == nil {
+		return "No prove or finalize fees recorded yet"
+	}
+	return "💰 *L1 Fees Paid*\n\n" + strings.Join(lines, "\n")
+}
+
+// costReport formats one line per wallet, by address, plus a total line when there are
+// several wallets; nil when nothing was recorded
+func costReport(costs map[common.Address]walletCosts, formatWallet func(common.Address) string) []string {
+	if len(costs) == 0 {
+		return nil
+	}
+	wallets := make([]common.Address, 0, len(costs))
+	for wallet := range costs {
+		wallets = append(wallets, wallet)
+	}
+	sort.Slice(wallets, func(i, j int) bool { return bytes.Compare(wallets[i][:], wallets[j][:]) < 0 })
+
+	var lines []string
+	total := new(big.Int)
+	for _, wallet := range wallets {
+		c := costs[wallet]
+		lines = append(lines, fmt.Sprintf("%s: %s ETH (%d prove, %d finalize)",
+			formatWallet(wallet), crosschain.FormatEther(c.TotalWei), c.Proves, c.Finalizes))
+		if c.TotalWei != nil {
+			total.Add(total, c.TotalWei)
+		}
+	}
+	if len(wallets) > 1 {
+		lines = append(lines, fmt.Sprintf("Total: %s ETH", crosschain.FormatEther(total)))
+	}
+	return lines
+}
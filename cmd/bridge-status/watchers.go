@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+)
+
+// startWatchers starts the background loops the configuration asks for: output,
+// optimistic mode and guardian event subscriptions, config reloads and relay workers
+func (s *WithdrawalScheduler) startWatchers() {
+	if s.mode == SchedulerModeSubscribe {
+		go s.watchOutputs()
+		go s.watchOptimisticMode()
+		go s.watchGuardianEvents()
+	}
+
+	if s.configPath != "" {
+		go s.watchConfig()
+	}
+
+	if s.relay.Enabled {
+		go s.runRelays()
+	}
+}
+
+// watchOutputs checks withdrawals as soon as an output covering them is proposed. The
+// cron poll keeps running, so checks continue while the subscription is down, and when
+// the L1 endpoint can't subscribe at all the scheduler just polls.
+func (s *WithdrawalScheduler) watchOutputs() {
+	s.logger.Infof("📡 Subscribing to OutputProposed events")
+	err := s.messenger.WatchOutputProposals(s.ctx, s.onOutputProposed)
+	if err != nil && s.ctx.Err() == nil {
+		s.logger.Warnf("⚠️  SCHEDULER_MODE=subscribe needs a websocket L1_RPC (%v); polling every %s instead", err, s.checkInterval)
+	}
+}
+
+// onOutputProposed checks the withdrawals that were waiting for an output the new one covers
+func (s *WithdrawalScheduler) onOutputProposed(p crosschain.OutputProposalInfo) {
+	var covered []string
+	s.mu.Lock()
+	for _, txHash := range s.withdrawalHashes {
+		if status := s.withdrawalStatus[txHash]; status != nil && status.awaitingOutput != 0 && status.awaitingOutput <= p.L2BlockNumber {
+			covered = append(covered, txHash)
+		}
+	}
+	s.mu.Unlock()
+
+	s.logger.Debugf("📡 Output #%d proposed for L2 block %d", p.OutputIndex, p.L2BlockNumber)
+	if len(covered) == 0 {
+		return
+	}
+	s.logger.Infof("\n📡 Output #%d covers L2 block %d; checking %d waiting withdrawal(s)", p.OutputIndex, p.L2BlockNumber, len(covered))
+	for i, txHash := range covered {
+		if i > 0 {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(s.perTxDelay):
+			}
+		}
+		if err := s.checkWithdrawal(txHash, nil); err != nil && !errors.Is(err, errSubmissionInProgress) && !errors.Is(err, errShuttingDown) {
+			s.logger.Errorf("❌ Check failed for %s: %v", s.displayName(txHash), err)
+			s.recordError(txHash, err)
+		} else if err == nil {
+			s.recovered(txHash)
+		}
+	}
+}
+
+// watchOptimisticMode recalculates countdowns as soon as the oracle toggles optimistic
+// mode. The poll before each check cycle notices toggles as well, so losing the
+// subscription only delays the update.
+func (s *WithdrawalScheduler) watchOptimisticMode() {
+	err := s.messenger.WatchOptimisticModeToggled(s.ctx, func(change crosschain.OptimisticModeChange) {
+		s.onFinalizationParams(change.FinalizationParams, change.L1TxHash.Hex())
+	})
+	if err != nil && s.ctx.Err() == nil {
+		s.logger.Warnf("⚠️  Not watching OptimisticModeToggled (%v); the mode is re-read every check", err)
+	}
+}
+
+// onFinalizationParams records the oracle's finalization params. When they differ from
+// the last ones seen, proven withdrawals' finalize times are moved by the change in
+// period, their countdowns are announced again and an alert is sent. l1TxHash is the
+// toggle transaction, if known.
+func (s *WithdrawalScheduler) onFinalizationParams(params crosschain.FinalizationParams, l1TxHash string) {
+	s.mu.Lock()
+	old := s.finalization
+	s.finalization = params
+	if old == params || old == (crosschain.FinalizationParams{}) {
+		s.mu.Unlock()
+		return
+	}
+	shift := params.Period - old.Period
+	pending := 0
+	for _, status := range s.withdrawalStatus {
+		if status.finalized || status.finalizeAt.IsZero() {
+			continue
+		}
+		status.finalizeAt = status.finalizeAt.Add(shift)
+		status.sentWaitingMessage = false
+		status.sent5MinuteReminder = false
+		pending++
+	}
+	s.mu.Unlock()
+
+	title := "Finalization Period Changed"
+	switch {
+	case params.Optimistic && !old.Optimistic:
+		title = "Optimistic Mode Enabled"
+	case !params.Optimistic && old.Optimistic:
+		title = "Optimistic Mode Disabled"
+	}
+	s.logger.Warnf("🔀 Oracle finalization changed from %s to %s; %d countdown(s) recalculated", old, params, pending)
+	text := fmt.Sprintf(
+		"🔀 *%s*\n\n"+
+			"Finalization period: %s (was %s)\n"+
+			"%d proven withdrawal(s) have a new finalize time; their countdowns will be sent again.",
+		title, formatCountdown(params.Period), formatCountdown(old.Period), pending)
+	if l1TxHash != "" {
+		text += fmt.Sprintf("\nL1 tx: `%s`", l1TxHash)
+	}
+	s.notify(notify.EventOptimisticModeToggled, "", text)
+}
@@ -0,0 +1,739 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/notify"
+	"mantle-claim-crossing/store"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// loadWithdrawal reads a withdrawal's message and decision-table state from chain
+func (s *WithdrawalScheduler) loadWithdrawal(txHash string) (crosschain.Message, uint64, crosschain.WithdrawalState, error) {
+	// Get the L2 block number for this transaction
+	message, err := s.messenger.GetMessages(s.ctx, txHash)
+	var confErr *crosschain.ConfirmationsError
+	if errors.As(err, &confErr) {
+		// Not an error: the decision table answers wait-for-confirmations
+		s.logger.Infof("  L2 Block: %d (%d/%d confirmations)", message.BlockNumber, confErr.Confirmations, confErr.Required)
+		return message, 0, crosschain.UnconfirmedState(confErr), nil
+	}
+	if err != nil {
+		return message, 0, crosschain.WithdrawalState{}, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	s.logger.Infof("  L2 Block: %d", message.BlockNumber)
+
+	// Get latest proposed L2 block
+	latestProposedBlock, err := s.GetLatestProposedL2Block()
+	if err != nil {
+		return message, 0, crosschain.WithdrawalState{}, fmt.Errorf("failed to get latest proposed block: %w", err)
+	}
+
+	s.logger.Infof("  Latest Proposed: %d", latestProposedBlock)
+
+	// Run the withdrawal through the decision table shared with the `recommend` command
+	state, err := s.messenger.BuildWithdrawalState(s.ctx, message, latestProposedBlock)
+	if err != nil {
+		return message, latestProposedBlock, state, fmt.Errorf("failed to build withdrawal state: %w", err)
+	}
+	return message, latestProposedBlock, state, nil
+}
+
+// checkWithdrawal checks one withdrawal; with a non-nil queue, prove and finalize are
+// queued for submitQueued instead of being sent immediately
+func (s *WithdrawalScheduler) checkWithdrawal(txHash string, queue *submissionQueue) error {
+	if txHash == "" {
+		return nil
+	}
+	if !s.beginWork() {
+		return errShuttingDown
+	}
+	defer s.inFlight.Done()
+
+	// Get status for this withdrawal
+	status := s.statusFor(txHash)
+
+	s.logger.Infof("🔍 Checking withdrawal: %s", s.displayName(txHash))
+
+	if hash, ok := withdrawalHashEntry(txHash); ok {
+		return s.checkWithdrawalHash(txHash, hash, status)
+	}
+
+	message, latestProposedBlock, state, err := s.loadWithdrawal(txHash)
+	if errors.Is(err, crosschain.ErrNoWithdrawalFound) {
+		s.markNotAWithdrawal(txHash, status, err)
+		return err
+	}
+	if err != nil {
+		s.notifyFailure(notify.EventCheckFailed, txHash, fmt.Sprintf(
+			"⚠️ *Check Failed*\n\n"+
+				"Transaction: `%s`\n"+
+				"%s\n\n"+
+				"It will be checked again on the next cycle.",
+			txHash, failureDetail(err)), err)
+		return err
+	}
+
+	// Leave finalizing to the operator when it costs more than allowed (MAX_FINALIZE_COST_USD, MIN_VALUE_RATIO)
+	var costErr *crosschain.CostTooHighError
+	if state.Status == crosschain.StatusProven && state.ChallengePassed && s.messenger.Cost.Enabled() {
+		if _, err := s.messenger.CheckFinalizeCost(s.ctx, message, status.from); errors.As(err, &costErr) {
+			state.OverBudget = true
+		} else if err != nil {
+			s.logger.Warnf("⚠️  %v", err)
+		}
+	}
+	rec := crosschain.Recommend(state, txHash)
+	if costErr != nil {
+		rec.Reason = fmt.Sprintf("%v; finalize manually if it is worth it", costErr)
+	}
+
+	s.mu.Lock()
+	// A proof we saw on chain that is gone again was dropped by an L1 reorg. READY_TO_PROVE
+	// only comes from a successful provenWithdrawals read; a failed one fails loadWithdrawal.
+	proofLost := state.Status == crosschain.StatusReadyToProve && !status.provenAt.IsZero()
+	if proofLost {
+		status.provenAt = time.Time{}
+		status.provenance = nil
+		status.sentWaitingMessage = false
+		status.sent5MinuteReminder = false
+	}
+	provenChanged := !state.ProvenAt.IsZero() && !state.ProvenAt.Equal(status.provenAt)
+	if provenChanged {
+		status.provenAt = state.ProvenAt
+	}
+	status.finalizeAt = state.FinalizeAt
+	if state.Provenance != nil {
+		status.provenance = state.Provenance
+	}
+	status.awaitingOutput = 0
+	if rec.Action == crosschain.ActionWaitForOutput {
+		status.awaitingOutput = message.BlockNumber
+	}
+	s.mu.Unlock()
+	if proofLost {
+		s.logger.Warnf("⚠️  The proof of %s is no longer on L1, likely dropped by a reorg; it will be proven again", s.displayName(txHash))
+		s.notify(notify.EventProofReorged, txHash, fmt.Sprintf(
+			"⚠️ *Proof Disappeared*\n\n"+
+				"Transaction: `%s`\n"+
+				"OptimismPortal no longer records a proof for this withdrawal, likely after an L1 reorg. "+
+				"The challenge period countdown was reset and the withdrawal will be proven again.",
+			txHash))
+	}
+	if proofLost || provenChanged {
+		s.saveState()
+	}
+
+	s.logger.Infof("  Current status: %d (%s)", message.Status, crosschain.StatusDescription(message.Status))
+	s.logger.Infof("  Next action: %s (%s)", rec.Action, rec.Reason)
+	s.recordStatus(txHash, message, rec.Action, state)
+	s.backfillTimeline(txHash, status, state)
+
+	// Without credentials, report readiness once instead of submitting
+	if s.monitorOnly && (rec.Action == crosschain.ActionProve || rec.Action == crosschain.ActionReprove || rec.Action == crosschain.ActionFinalize) {
+		s.logger.Infof("👀 Monitor-only mode: %s", rec.Reason)
+		if status.notifiedReady != rec.Action {
+			s.notify(notify.EventWithdrawalReady, txHash, fmt.Sprintf(
+				"🎯 *Withdrawal Ready* (monitor-only)\n\n"+
+					"Transaction: `%s`\n"+
+					"Status: %s\n"+
+					"%s\n\n"+
+					"Run: `%s`",
+				txHash, crosschain.StatusDescription(message.Status), notify.EscapeMarkdown(rec.Reason), rec.Command))
+			status.notifiedReady = rec.Action
+		}
+		return nil
+	}
+
+	switch rec.Action {
+	case crosschain.ActionNone:
+		s.logger.Infof("  Already finalized, no action needed")
+
+		// Mark as finalized if not already marked
+		s.mu.Lock()
+		status.finalized = true
+		s.mu.Unlock()
+
+		s.notify(notify.EventAlreadyFinalized, txHash, fmt.Sprintf(
+			"✅ *Already Finalized*\n\n"+
+				"Transaction: `%s`\n"+
+				"Status: %s",
+			txHash, crosschain.StatusDescription(message.Status)))
+		return nil
+
+	case crosschain.ActionWaitForConfirmations:
+		s.logger.Infof("⏳ Waiting for %d more L2 blocks before trusting the withdrawal's receipt", state.ConfirmationsNeeded)
+		return nil
+
+	case crosschain.ActionWaitForOutput:
+		remainingBlocks := message.BlockNumber - latestProposedBlock
+		eta := "unknown"
+		waitingFor := fmt.Sprintf("waiting for output covering block %d", message.BlockNumber)
+		schedule := "Provable at: unknown\nEarliest finalize: unknown"
+		if claim, err := s.messenger.EstimateClaimSchedule(s.ctx, txHash); err != nil {
+			s.logger.Warnf("⚠️  Failed to estimate the claim schedule: %v", err)
+		} else {
+			if claim.OutputWait != nil {
+				eta = claim.OutputWait.String()
+				waitingFor = claim.OutputWait.Describe()
+			}
+			schedule = fmt.Sprintf("Provable at: ≈ %s\nEarliest finalize: ≈ %s",
+				crosschain.FormatETA(claim.ProvableAt), crosschain.FormatETA(claim.FinalizeAt))
+		}
+		s.logger.Infof("⏳ Still waiting: need %d more L2 blocks to be proposed, expected in %s", remainingBlocks, eta)
+		s.logger.Infof("⏳ %s", waitingFor)
+		s.notify(notify.EventProvePending, txHash, fmt.Sprintf(
+			"⏳ *Prove Pending!*\n\n"+
+				"Transaction: `%s`\n"+
+				"Still waiting: need `%d` more L2 blocks to be proposed\n"+
+				"Last Proposed Block: %d\n"+
+				"Status: %s\n"+
+				"Expected in: %s\n"+
+				"%s\n\n",
+			txHash, remainingBlocks, latestProposedBlock, waitingFor, eta, schedule))
+		return nil
+
+	case crosschain.ActionWaitChallenge:
+		s.waitForChallengePeriod(txHash, status, state)
+		return nil
+
+	case crosschain.ActionWaitForUnpause:
+		// Alerted once for all withdrawals; the next check after the unpause submits
+		s.setPortalPaused(true, "")
+		s.logger.Infof("⏸️  OptimismPortal is paused; checking again next cycle")
+		return nil
+
+	case crosschain.ActionFinalize, crosschain.ActionProve:
+		if queue != nil {
+			queue.add(rec.Action, queuedSubmission{txHash, status, state, message, latestProposedBlock})
+			return nil
+		}
+		if rec.Action == crosschain.ActionFinalize {
+			return s.finalizeWithdrawal(txHash, status, state)
+		}
+		return s.proveWithdrawal(txHash, status, message, latestProposedBlock)
+
+	case crosschain.ActionReprove:
+		// Batches only prove unproven withdrawals, so re-proving always goes on its own
+		if p := state.Provenance; p != nil {
+			s.logger.Warnf("♻️  Output #%d used to prove %s is gone, proving again", p.OutputIndex, s.displayName(txHash))
+		}
+		// The new proof starts a new challenge period, so its countdown is announced again
+		status.sentWaitingMessage = false
+		status.sent5MinuteReminder = false
+		return s.proveWithdrawal(txHash, status, message, latestProposedBlock)
+
+	case crosschain.ActionReplay:
+		// The portal is done with it, but the funds sit in L1CrossDomainMessenger until someone replays the message
+		s.logger.Warnf("❌ Withdrawal %s is finalized but its relayed message failed on L1", s.displayName(txHash))
+		if status.blockedAction != rec.Action {
+			s.notify(notify.EventRelayedMessageFailed, txHash, fmt.Sprintf(
+				"❌ *Relayed Message Failed*\n\n"+
+					"Transaction: `%s`\n"+
+					"Status: %s\n"+
+					"The withdrawal was finalized, but the message it relayed reverted on L1, so the funds were not delivered. "+
+					"Anyone can replay it by calling `relayMessage` on L1CrossDomainMessenger `%s` with the original message "+
+					"once the target can accept it.\n\n"+
+					"Run: `%s` or send `/replay %s`",
+				txHash, crosschain.StatusDescription(message.Status), s.messenger.Contracts.L1.L1CrossDomainMessenger, rec.Command, txHash))
+			status.blockedAction = rec.Action
+		}
+		return nil
+
+	default:
+		// Blocked on something automation can't resolve; tell the operator once per blocking reason
+		s.logger.Warnf("⚠️  Withdrawal blocked: %s", rec.Reason)
+		if status.blockedAction != rec.Action {
+			s.notify(notify.EventActionRequired, txHash, fmt.Sprintf(
+				"⚠️ *Action Required*\n\n"+
+					"Transaction: `%s`\n"+
+					"Status: %s\n"+
+					"Reason: %s\n"+
+					"Next step: `%s`",
+				txHash, crosschain.StatusDescription(message.Status), notify.EscapeMarkdown(rec.Reason), rec.Command))
+			status.blockedAction = rec.Action
+		}
+		return nil
+	}
+}
+
+// checkWithdrawalHash follows a withdrawalhash: entry through OptimismPortal alone.
+// Without the L2 transaction there is nothing to prove or finalize with, so it only
+// notifies: the challenge period countdown once proven, when it ends, and finalization.
+func (s *WithdrawalScheduler) checkWithdrawalHash(txHash string, hash common.Hash, status *WithdrawalStatus) error {
+	report, err := s.messenger.GetStatusByWithdrawalHash(s.ctx, hash)
+	if err != nil {
+		s.notifyFailure(notify.EventCheckFailed, txHash, fmt.Sprintf(
+			"⚠️ *Check Failed*\n\n"+
+				"Withdrawal: `%s`\n"+
+				"%s\n\n"+
+				"It will be checked again on the next cycle.",
+			txHash, failureDetail(err)), err)
+		return err
+	}
+	s.logger.Infof("  Current status: %s (withdrawal hash only)", report.StatusName)
+
+	var finalizeAt time.Time
+	if report.FinalizeAt != nil {
+		finalizeAt = *report.FinalizeAt
+	}
+	action := crosschain.ActionProve
+	switch {
+	case report.Status == crosschain.StatusFinalized:
+		action = crosschain.ActionNone
+	case report.Status == crosschain.StatusProven && time.Now().Before(finalizeAt):
+		action = crosschain.ActionWaitChallenge
+	case report.Status == crosschain.StatusProven:
+		action = crosschain.ActionFinalize
+	}
+	s.updateRecord(txHash, func(rec *store.Record) {
+		if _, ok := rec.Timeline.Event(crosschain.StageFirstSeen); !ok {
+			rec.Timeline = rec.Timeline.With(crosschain.TimelineEvent{Stage: crosschain.StageFirstSeen, At: time.Now()})
+		}
+		rec.Status = report.StatusName
+		rec.NextAction = string(action)
+		rec.NextActionAt = time.Time{}
+		if action == crosschain.ActionWaitChallenge {
+			rec.NextActionAt = finalizeAt
+		}
+		rec.LastError = ""
+	})
+
+	s.mu.Lock()
+	alreadyFinalized := status.finalized
+	if report.Provenance != nil {
+		status.provenance = report.Provenance
+		status.provenAt = report.Provenance.ProvenAt
+	}
+	status.finalizeAt = finalizeAt
+	s.mu.Unlock()
+
+	switch action {
+	case crosschain.ActionNone:
+		if alreadyFinalized {
+			return nil
+		}
+		s.logger.Infof("✅ Withdrawal %s is finalized", s.displayName(txHash))
+		s.notify(notify.EventAlreadyFinalized, txHash, fmt.Sprintf(
+			"✅ *Withdrawal Finalized*\n\n"+
+				"Withdrawal: `%s`\n"+
+				"Status: %s\n"+
+				"Whether the relayed message succeeded can't be told from the withdrawal hash alone.",
+			txHash, report.StatusName))
+		s.markFinalized(txHash, status)
+	case crosschain.ActionWaitChallenge:
+		s.waitForChallengePeriod(txHash, status, crosschain.WithdrawalState{FinalizeAt: finalizeAt})
+	case crosschain.ActionFinalize:
+		s.logger.Infof("🎯 Challenge period ended; finalizing needs the L2 transaction hash")
+		if status.notifiedReady != action {
+			s.notify(notify.EventWithdrawalReady, txHash, fmt.Sprintf(
+				"🎯 *Challenge Period Ended*\n\n"+
+					"Withdrawal: `%s`\n"+
+					"Status: PROVEN\n"+
+					"It can be finalized now, but only with its L2 transaction hash: "+
+					"`bridge-status finalize <tx_hash>`",
+				txHash))
+			status.notifiedReady = action
+		}
+	default:
+		s.logger.Infof("⏳ Not proven yet, or not a withdrawal the portal knows")
+	}
+	return nil
+}
+
+// markNotAWithdrawal stops checking a hash that isn't a withdrawal and alerts about it once
+func (s *WithdrawalScheduler) markNotAWithdrawal(txHash string, status *WithdrawalStatus, err error) {
+	s.mu.Lock()
+	alerted := status.notAWithdrawal
+	status.notAWithdrawal = true
+	s.mu.Unlock()
+	if alerted {
+		return
+	}
+
+	s.logger.Errorf("🚫 %v; it will no longer be checked", err)
+	s.notify(notify.EventNotAWithdrawal, txHash, fmt.Sprintf(
+		"🚫 *Not a Withdrawal*\n\n"+
+			"Transaction: `%s`\n"+
+			"%s\n\n"+
+			"It will no longer be checked; remove it from the monitored withdrawals.",
+		txHash, notify.EscapeMarkdown(err.Error())))
+}
+
+// waitForChallengePeriod reports the finalize countdown for a proven withdrawal
+func (s *WithdrawalScheduler) waitForChallengePeriod(txHash string, status *WithdrawalStatus, state crosschain.WithdrawalState) {
+	if state.FinalizeAt.IsZero() {
+		s.logger.Warnf("  Warning: status is PROVEN but checkProvenStatus returned false")
+		return
+	}
+
+	remainingTime := int64(time.Until(state.FinalizeAt).Seconds())
+	finalizeTimeStr := state.FinalizeAt.Format(time.RFC3339)
+	hours := remainingTime / 3600
+	minutes := (remainingTime % 3600) / 60
+
+	s.logger.Infof("⏳ Challenge period not yet passed")
+	s.logger.Infof("   Can finalize at: %s (in %dh %dm)", finalizeTimeStr, hours, minutes)
+
+	// Notify only:
+	// 1. First time (initial waiting message)
+	// 2. When there's 5 minutes remaining (reminder)
+	const fiveMinutes = 5 * 60
+
+	if !status.sentWaitingMessage {
+		// Send initial waiting message, with the proven time the schedule reads from L1
+		finalizeETA := crosschain.FormatETA(state.FinalizeAt)
+		if claim, err := s.messenger.EstimateClaimSchedule(s.ctx, txHash); err != nil {
+			s.logger.Warnf("⚠️  Failed to estimate the claim schedule: %v", err)
+		} else if !claim.FinalizeAt.IsZero() {
+			finalizeETA = crosschain.FormatETA(claim.FinalizeAt)
+			if claim.ProvenAt != nil {
+				finalizeETA += fmt.Sprintf(" (proven %s)", crosschain.FormatETA(*claim.ProvenAt))
+			}
+		}
+		s.notify(notify.EventChallengeWaiting, txHash, fmt.Sprintf(
+			"⏳ *Waiting for Challenge Period*\n\n"+
+				"Transaction: `%s`\n"+
+				"Status: PROVEN\n"+
+				"Can finalize at: %s\n"+
+				"Time remaining: %dh %dm",
+			txHash, finalizeETA, hours, minutes))
+		status.sentWaitingMessage = true
+	} else if remainingTime <= fiveMinutes && !status.sent5MinuteReminder {
+		// Send 5-minute reminder
+		s.notify(notify.EventFinalizeSoon, txHash, fmt.Sprintf(
+			"⏰ *Finalize Coming Soon*\n\n"+
+				"Transaction: `%s`\n"+
+				"Can finalize at: %s\n"+
+				"Time remaining: %d minutes",
+			txHash, finalizeTimeStr, minutes))
+		status.sent5MinuteReminder = true
+	}
+}
+
+// finalizeWithdrawal submits the finalize transaction once the challenge period has passed
+func (s *WithdrawalScheduler) finalizeWithdrawal(txHash string, status *WithdrawalStatus, state crosschain.WithdrawalState) error {
+	if !status.submitMu.TryLock() {
+		s.logger.Infof("⏭️  A prove/finalize for %s is already in progress, skipping", s.displayName(txHash))
+		return errSubmissionInProgress
+	}
+	defer status.submitMu.Unlock()
+
+	s.logger.Infof("✅ Challenge period has passed, ready to finalize!")
+
+	// Reset flags for this withdrawal
+	status.sentWaitingMessage = false
+	status.sent5MinuteReminder = false
+	status.blockedAction = ""
+
+	// Notify that withdrawal is ready to finalize
+	s.notify(notify.EventReadyToFinalize, txHash, fmt.Sprintf(
+		"🎯 *Withdrawal Ready to Finalize*\n\n"+
+			"Transaction: `%s`\n"+
+			"Proven at: %s\n"+
+			"Challenge period has passed!",
+		txHash, state.ProvenAt.Format(time.RFC3339)))
+
+	// Attempt to finalize
+	s.logger.Infof("🚀 Attempting to finalize withdrawal...")
+	s.notify(notify.EventFinalizeSubmitted, txHash, fmt.Sprintf(
+		"🚀 *Starting Finalize Operation*\n\n"+
+			"Transaction: `%s`\n"+
+			"Submitting finalization to L1...",
+		txHash))
+
+	_, err := s.messenger.FinalizeMessage(s.ctx, txHash, 0, s.submitOptions(txHash, "finalize", status))
+	if errors.Is(err, crosschain.ErrFinalizedExternally) || errors.Is(err, crosschain.ErrAlreadyFinalized) {
+		s.clearSubmitted(status)
+		s.logger.Infof("🤝 Withdrawal was finalized by someone else")
+		s.notify(notify.EventFinalizedExternally, txHash, fmt.Sprintf(
+			"🤝 *Finalized Externally*\n\n"+
+				"Transaction: `%s`\n"+
+				"Another relayer finalized this withdrawal before us. Nothing left to do.",
+			txHash))
+		s.markFinalized(txHash, status)
+		return nil
+	}
+	if errors.Is(err, crosschain.ErrWithdrawalLocked) {
+		// Another process is finalizing it; the next check sees the outcome
+		s.logger.Warnf("🔒 %v; checking again next cycle", err)
+		return nil
+	}
+	if errors.Is(err, crosschain.ErrPortalPaused) {
+		// Paused since this cycle started; nothing was sent
+		s.setPortalPaused(true, "")
+		return nil
+	}
+	if s.alertInsufficientFunds("finalize", txHash, err) {
+		return fmt.Errorf("failed to finalize: %w", err)
+	}
+	if err != nil {
+		s.logger.Errorf("❌ Failed to finalize: %v", err)
+		s.notifyFailure(notify.EventFinalizeFailed, txHash, fmt.Sprintf(
+			"❌ *Finalize Failed*\n\n"+
+				"Transaction: `%s`\n"+
+				"%s",
+			txHash, failureDetail(err)), err)
+		return fmt.Errorf("failed to finalize: %w", err)
+	}
+
+	s.clearSubmitted(status)
+	s.logger.Infof("✅ Successfully finalized withdrawal!")
+	s.mu.Lock()
+	fee := status.fee
+	s.mu.Unlock()
+	s.notifyFee(notify.EventFinalizeSucceeded, txHash, fmt.Sprintf(
+		"✅ *Finalize Successful!*\n\n"+
+			"Transaction: `%s`\n%s"+
+			"The withdrawal has been successfully finalized on L1!\n"+
+			"Funds are now available.",
+		txHash, feeLine(fee)), fee)
+
+	s.markFinalized(txHash, status)
+	return nil
+}
+
+// replayWithdrawal replays the failed relayed message of a finalized withdrawal. The
+// target may keep rejecting it, so this only runs on /replay, never on its own.
+func (s *WithdrawalScheduler) replayWithdrawal(txHash string, status *WithdrawalStatus) error {
+	if !status.submitMu.TryLock() {
+		s.logger.Infof("⏭️  A submission for %s is already in progress, skipping", s.displayName(txHash))
+		return errSubmissionInProgress
+	}
+	defer status.submitMu.Unlock()
+
+	s.logger.Infof("🔄 Replaying the failed message of %s...", s.displayName(txHash))
+	var fee *crosschain.TxFee
+	opts := crosschain.SubmitOptions{From: status.from, OnMined: func(f crosschain.TxFee) { fee = &f }}
+	_, err := s.messenger.ReplayMessage(s.ctx, txHash, 0, opts)
+	if s.alertInsufficientFunds("replay", txHash, err) {
+		return fmt.Errorf("failed to replay: %w", err)
+	}
+	if err != nil {
+		s.logger.Errorf("❌ Failed to replay: %v", err)
+		s.notifyFailure(notify.EventReplayFailed, txHash, fmt.Sprintf(
+			"❌ *Replay Failed*\n\n"+
+				"Transaction: `%s`\n"+
+				"%s",
+			txHash, failureDetail(err)), err)
+		return fmt.Errorf("failed to replay: %w", err)
+	}
+
+	s.logger.Infof("✅ Successfully replayed the relayed message!")
+	status.blockedAction = ""
+	s.notifyFee(notify.EventReplaySucceeded, txHash, fmt.Sprintf(
+		"✅ *Replay Successful!*\n\n"+
+			"Transaction: `%s`\n%s"+
+			"The relayed message went through on L1.\n"+
+			"Funds are now available.",
+		txHash, feeLine(fee)), fee)
+
+	s.markFinalized(txHash, status)
+	return nil
+}
+
+// alertInsufficientFunds sends a funding alert when err means the wallet can't pay for
+// the transaction. The messenger checks the balance before signing, so nothing was sent
+// and the next check retries once the wallet is topped up.
+func (s *WithdrawalScheduler) alertInsufficientFunds(operation, txHash string, err error) bool {
+	var fundsErr *crosschain.InsufficientFundsError
+	if !errors.As(err, &fundsErr) {
+		return false
+	}
+	s.logger.Warnf("💸 Skipping %s: %v", operation, fundsErr)
+	s.notifyFailure(notify.EventInsufficientFunds, txHash, fmt.Sprintf(
+		"💸 *Wallet Needs Funds*\n\n"+
+			"Transaction: `%s`\n"+
+			"Wallet `%s` needs ~%s more ETH to %s (balance %s ETH, required %s ETH).\n"+
+			"Nothing was submitted; it will be retried on the next check.",
+		txHash, fundsErr.Wallet.Hex(), crosschain.FormatEther(fundsErr.Shortfall()), operation,
+		crosschain.FormatEther(fundsErr.Balance), crosschain.FormatEther(fundsErr.Required)), err)
+	return true
+}
+
+// markFinalized records a finalized withdrawal and stops the scheduler once nothing is left
+func (s *WithdrawalScheduler) markFinalized(txHash string, status *WithdrawalStatus) {
+	s.updateRecord(txHash, func(rec *store.Record) {
+		rec.Status = crosschain.StatusDescription(crosschain.StatusFinalized)
+		rec.NextAction = string(crosschain.ActionNone)
+		rec.NextActionAt = time.Time{}
+		if rec.FinalizedAt.IsZero() {
+			rec.FinalizedAt = time.Now()
+		}
+	})
+
+	// Mark this withdrawal as finalized and check if all withdrawals are
+	s.mu.Lock()
+	status.finalized = true
+	allFinalized := true
+	for _, ws := range s.withdrawalStatus {
+		if !ws.finalized && !ws.notAWithdrawal && ws.retiredAt.IsZero() {
+			allFinalized = false
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if allFinalized && len(s.watchAddresses) > 0 {
+		s.logger.Infof("✅ All known withdrawals finalized, still watching %d address(es) for new ones", len(s.watchAddresses))
+	} else if allFinalized && s.configPath != "" {
+		s.logger.Infof("✅ All withdrawals finalized, still watching %s for new ones", s.configPath)
+	} else if allFinalized && s.relay.Enabled {
+		s.logger.Infof("✅ All withdrawals finalized, still accepting relay requests")
+	} else if allFinalized {
+		// All withdrawals are finalized, stop the scheduler
+		s.logger.Infof("🛑 All withdrawals finalized — stopping scheduler and exiting cron")
+		s.notify(notify.EventAllCompleted, "", "🎉 *All Withdrawals Completed!*\n\nAll configured withdrawals have been successfully finalized.")
+		s.Stop()
+	} else {
+		s.logger.Infof("✅ Withdrawal finalized, continuing to monitor remaining withdrawals...")
+	}
+}
+
+// proveWithdrawal submits the prove transaction once an output covers the withdrawal
+func (s *WithdrawalScheduler) proveWithdrawal(txHash string, status *WithdrawalStatus, message crosschain.Message, latestProposedBlock uint64) error {
+	if !status.submitMu.TryLock() {
+		s.logger.Infof("⏭️  A prove/finalize for %s is already in progress, skipping", s.displayName(txHash))
+		return errSubmissionInProgress
+	}
+	defer status.submitMu.Unlock()
+
+	s.logger.Infof("✅ Withdrawal is ready to prove!")
+
+	// Notify that withdrawal is ready
+	s.notify(notify.EventReadyToProve, txHash, fmt.Sprintf(
+		"🎯 *Withdrawal Ready to Prove*\n\n"+
+			"Transaction: `%s`\n"+
+			"L2 Block: %d\n"+
+			"Latest Proposed: %d\n%s\n"+
+			"The withdrawal is now ready to be proven!",
+		txHash, message.BlockNumber, latestProposedBlock, withdrawalValueLines(message, s.messenger.WithdrawalValues(s.ctx, message))))
+
+	// Attempt to prove
+	s.logger.Infof("🚀 Attempting to prove withdrawal...")
+	s.notify(notify.EventProveSubmitted, txHash, fmt.Sprintf(
+		"🚀 *Starting Prove Operation*\n\n"+
+			"Transaction: `%s`\n"+
+			"Submitting proof to L1...",
+		txHash))
+
+	_, err := s.messenger.ProveMessage(s.ctx, txHash, 0, s.submitOptions(txHash, "prove", status))
+	if errors.Is(err, crosschain.ErrAlreadyFinalized) {
+		s.clearSubmitted(status)
+		s.logger.Infof("🤝 Withdrawal was finalized before it could be proven")
+		s.notify(notify.EventFinalizedExternally, txHash, fmt.Sprintf(
+			"🤝 *Finalized Externally*\n\n"+
+				"Transaction: `%s`\n"+
+				"This withdrawal is already finalized. Nothing left to do.",
+			txHash))
+		s.markFinalized(txHash, status)
+		return nil
+	}
+	if crosschain.IsExternallyCompleted(err) {
+		s.clearSubmitted(status)
+		s.logger.Infof("🤝 %v", err)
+		s.notify(notify.EventProvenExternally, txHash, fmt.Sprintf(
+			"🤝 *Proven Externally*\n\n"+
+				"Transaction: `%s`\n"+
+				"%s. The scheduler will pick up from the new state on the next check.",
+			txHash, notify.EscapeMarkdown(err.Error())))
+		return nil
+	}
+	if errors.Is(err, crosschain.ErrWithdrawalLocked) {
+		// Another process is proving it; the next check sees the outcome
+		s.logger.Warnf("🔒 %v; checking again next cycle", err)
+		return nil
+	}
+	if errors.Is(err, crosschain.ErrPortalPaused) {
+		// Paused since this cycle started; nothing was sent
+		s.setPortalPaused(true, "")
+		return nil
+	}
+	if s.alertInsufficientFunds("prove", txHash, err) {
+		return fmt.Errorf("failed to prove: %w", err)
+	}
+	if err != nil {
+		s.logger.Errorf("❌ Failed to prove: %v", err)
+		s.notifyFailure(notify.EventProveFailed, txHash, fmt.Sprintf(
+			"❌ *Prove Failed*\n\n"+
+				"Transaction: `%s`\n"+
+				"%s",
+			txHash, failureDetail(err)), err)
+		return fmt.Errorf("failed to prove: %w", err)
+	}
+
+	s.clearSubmitted(status)
+	s.logger.Infof("✅ Successfully proved withdrawal!")
+
+	s.mu.Lock()
+	provenance := status.provenance
+	fee := status.fee
+	s.mu.Unlock()
+	// Count the challenge period from the timestamp OptimismPortal recorded
+	provenAt := time.Now()
+	if provenance != nil {
+		provenAt = provenance.ProvenAt
+	}
+	finalizeAt := s.recordProven(status, provenAt)
+	finalizeTimeStr := finalizeAt.Format(time.RFC3339)
+	outputLine := ""
+	if provenance != nil {
+		outputLine = fmt.Sprintf("Output: #%d (`%s`)\n", provenance.OutputIndex, provenance.OutputRoot.Hex())
+	}
+
+	s.notifyFee(notify.EventProveSucceeded, txHash, fmt.Sprintf(
+		"✅ *Prove Successful!*\n\n"+
+			"Transaction: `%s`\n"+
+			"L2 Block: %d\n%s%s\n"+
+			"The withdrawal has been successfully proven on L1.\n"+
+			"Can finalize at: %s (~%s)",
+		txHash, message.BlockNumber, outputLine, feeLine(fee), finalizeTimeStr, formatCountdown(time.Until(finalizeAt))), fee)
+	return nil
+}
+
+// discoverWithdrawals scans L2 for MessagePassed events from the watched addresses since
+// the last scan and adds unfinalized withdrawals to the monitored set
+func (s *WithdrawalScheduler) discoverWithdrawals() error {
+	head, err := s.messenger.GetLatestL2Block(s.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get L2 head: %w", err)
+	}
+	from, to := crosschain.DiscoveryWindow(head, s.lastScannedBlock, s.discoveryLookback)
+	if from > to {
+		return nil
+	}
+
+	s.logger.Infof("🔎 Scanning L2 blocks %d-%d for withdrawals from %d watched address(es)...", from, to, len(s.watchAddresses))
+	found, err := s.messenger.FindWithdrawals(s.ctx, s.watchAddresses, from, to)
+	if err != nil {
+		return err
+	}
+	s.lastScannedBlock = to
+
+	for _, w := range found {
+		s.mu.Lock()
+		_, known := s.withdrawalStatus[w.TxHash]
+		if !known && !w.Finalized {
+			s.withdrawalStatus[w.TxHash] = &WithdrawalStatus{discovered: true}
+			s.withdrawalHashes = append(s.withdrawalHashes, w.TxHash)
+		}
+		s.mu.Unlock()
+		if known || w.Finalized {
+			continue
+		}
+
+		s.logger.Infof("🆕 Discovered withdrawal %s (L2 block %d, sender %s)", w.TxHash, w.BlockNumber, w.Sender.Hex())
+		s.notify(notify.EventWithdrawalDiscovered, w.TxHash, fmt.Sprintf(
+			"🆕 *New Withdrawal Discovered*\n\n"+
+				"Transaction: `%s`\n"+
+				"Sender: `%s`\n"+
+				"L2 Block: %d",
+			w.TxHash, w.Sender.Hex(), w.BlockNumber))
+	}
+	return nil
+}
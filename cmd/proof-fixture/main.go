@@ -0,0 +1,97 @@
+// Command proof-fixture records the L2 eth_getProof response of a withdrawal as a fixture
+// for the proof size tests and benchmarks in cross_chain:
+//
+//	go run ./cmd/proof-fixture -rpc https://rpc.mantle.xyz -name eth -block 70000000 0x<withdrawal hash>
+//	go test ./cross_chain -run TestProofFixtures
+//	go test ./cross_chain -run '^$' -bench BenchmarkWithdrawalProof
+//
+// -block must be at or after the withdrawal's L2 block, e.g. the block of the output it
+// is proven against, and the node must still serve state at it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func main() {
+	rpcURL := flag.String("rpc", "", "L2 RPC URL to fetch the proof from")
+	network := flag.String("network", crosschain.DefaultNetwork, "network preset whose L2ToL1MessagePasser holds the withdrawal")
+	name := flag.String("name", "", "fixture name, e.g. eth or erc20")
+	block := flag.Uint64("block", 0, "L2 block to prove the withdrawal at")
+	dir := flag.String("dir", "cross_chain/testdata/proofs", "directory to write <name>.json to")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: proof-fixture -rpc <url> -name <name> -block <number> [-network <name>] <withdrawal_hash>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*rpcURL, *network, *name, *block, *dir, flag.Args()); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+func run(rpcURL, network, name string, block uint64, dir string, args []string) error {
+	if rpcURL == "" || name == "" || block == 0 || len(args) != 1 {
+		flag.Usage()
+		return fmt.Errorf("-rpc, -name, -block and one withdrawal hash are required")
+	}
+	withdrawalHash := args[0]
+	if err := crosschain.ValidateWithdrawalHash(withdrawalHash); err != nil {
+		return err
+	}
+	preset, err := crosschain.LookupNetwork(network)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := rpc.DialContext(ctx, rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", rpcURL, err)
+	}
+	defer client.Close()
+
+	// Kept as the node returned it; the account proof is recorded but not used
+	passer := common.HexToAddress(preset.Contracts.Bridges.L2ToL1MessagePasser)
+	slot := crosschain.SentMessagesSlot(withdrawalHash)
+	var proof json.RawMessage
+	if err := client.CallContext(ctx, &proof, "eth_getProof", passer, []string{slot.Hex()}, hexutil.EncodeUint64(block)); err != nil {
+		return fmt.Errorf("failed to get the proof of %s at block %d: %w", withdrawalHash, block, err)
+	}
+	if len(proof) == 0 || string(proof) == "null" {
+		return fmt.Errorf("no proof of %s at block %d", withdrawalHash, block)
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Network        string          `json:"network"`
+		Block          uint64          `json:"block"`
+		WithdrawalHash string          `json:"withdrawalHash"`
+		Proof          json.RawMessage `json:"proof"`
+	}{network, block, withdrawalHash, proof}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	log.Printf("✅ Wrote %s; now run go test ./cross_chain -run TestProofFixtures", path)
+	return nil
+}
@@ -0,0 +1,89 @@
+// Command receipt-fixture captures an L2 withdrawal receipt as a fixture for the receipt
+// golden-file tests in cross_chain, then the test writes its golden file and fails until
+// it is rerun without -update:
+//
+//	go run ./cmd/receipt-fixture -rpc https://rpc.mantle.xyz -name eth 0x...
+//	go test ./cross_chain -run TestReceiptGolden -update
+//
+// Review the new .golden.json before committing it; from then on the test fails when
+// parsing the receipt gives anything else.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func main() {
+	rpcURL := flag.String("rpc", "", "L2 RPC URL to fetch the receipt from")
+	network := flag.String("network", crosschain.DefaultNetwork, "network preset whose contracts the receipt is parsed with")
+	name := flag.String("name", "", "fixture name, e.g. eth, mnt, erc20 or multi-message")
+	dir := flag.String("dir", "cross_chain/testdata/receipts", "directory to write <name>.json to")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: receipt-fixture -rpc <url> -name <name> [-network <name>] <tx_hash>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*rpcURL, *network, *name, *dir, flag.Args()); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+func run(rpcURL, network, name, dir string, args []string) error {
+	if rpcURL == "" || name == "" || len(args) != 1 {
+		flag.Usage()
+		return fmt.Errorf("-rpc, -name and one transaction hash are required")
+	}
+	txHash := args[0]
+	if err := crosschain.ValidateTxHash(txHash); err != nil {
+		return err
+	}
+	if _, err := crosschain.LookupNetwork(network); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := rpc.DialContext(ctx, rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", rpcURL, err)
+	}
+	defer client.Close()
+
+	// Kept as the node returned it, Mantle's extra fields included
+	var receipt json.RawMessage
+	if err := client.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+		return fmt.Errorf("failed to get the receipt of %s: %w", txHash, err)
+	}
+	if len(receipt) == 0 || string(receipt) == "null" {
+		return fmt.Errorf("no receipt for %s", txHash)
+	}
+
+	data, err := json.MarshalIndent(struct {
+		Network string          `json:"network"`
+		Receipt json.RawMessage `json:"receipt"`
+	}{network, receipt}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	log.Printf("✅ Wrote %s; now run go test ./cross_chain -run TestReceiptGolden -update and review %s.golden.json", path, name)
+	return nil
+}
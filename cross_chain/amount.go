@@ -0,0 +1,208 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Symbols of the two native values a withdrawal carries: MNT is Mantle's native token,
+// ETH is bridged from L1
+const (
+	SymbolMNT = "MNT"
+	SymbolETH = "ETH"
+)
+
+// nativeDecimals are the decimals of MNT and ETH
+const nativeDecimals = 18
+
+// erc20MetadataABI holds the ERC20 metadata getters. Some older tokens return symbol
+// as bytes32 instead of a string; erc20Bytes32SymbolABI reads those.
+const erc20MetadataABI = `[
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"}
+]`
+
+const erc20Bytes32SymbolABI = `[
+	{"inputs":[],"name":"symbol","outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view","type":"function"}
+]`
+
+// FormatUnits renders amount, in a token's smallest unit, as an exact decimal number of
+// whole units without trailing zeros: 1500000000000000000000 with 18 decimals is "1500",
+// 1 is "0.000000000000000001". Unlike FormatEther it never rounds.
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+	digits := new(big.Int).Abs(amount).String()
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if decimals == 0 {
+		return sign + digits
+	}
+	if pad := int(decimals) + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	whole, frac := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// Amount is a value in both its raw and its display form, for JSON output
+type Amount struct {
+	Raw       string `json:"raw"`              // In the smallest unit (wei for MNT and ETH), as a decimal string
+	Formatted string `json:"formatted"`        // Whole units and symbol, e.g. "1500 MNT"; raw units when decimals aren't known
+	Symbol    string `json:"symbol,omitempty"` // Empty for a token whose symbol couldn't be read
+	Decimals  uint8  `json:"decimals"`
+}
+
+// NewAmount formats raw, in the smallest unit of a token with decimals and symbol
+func NewAmount(raw *big.Int, decimals uint8, symbol string) Amount {
+	a := Amount{Raw: orZero(raw).String(), Symbol: symbol, Decimals: decimals}
+	a.Formatted = FormatUnits(raw, decimals)
+	if symbol != "" {
+		a.Formatted += " " + symbol
+	}
+	return a
+}
+
+// String returns the formatted amount
+func (a Amount) String() string {
+	return a.Formatted
+}
+
+// WithdrawalValues are the values a withdrawal moves, raw and formatted
+type WithdrawalValues struct {
+	MNT   Amount  `json:"mnt"`             // MntValue of the withdrawal transaction
+	ETH   Amount  `json:"eth"`             // EthValue of the withdrawal transaction
+	Token *Amount `json:"token,omitempty"` // Amount of a decoded bridge withdrawal, in the bridged token
+}
+
+// String renders the non-zero native values, e.g. "1500 MNT" or "1500 MNT, 0.25 ETH"
+func (v WithdrawalValues) String() string {
+	var parts []string
+	for _, a := range []Amount{v.MNT, v.ETH} {
+		if a.Raw != "0" {
+			parts = append(parts, a.Formatted)
+		}
+	}
+	if len(parts) == 0 {
+		return "0 " + SymbolMNT
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NativeValues formats the MNT and ETH values of a withdrawal, in wei; it makes no RPC
+// calls
+func NativeValues(mntValue, ethValue *big.Int) WithdrawalValues {
+	return WithdrawalValues{
+		MNT: NewAmount(mntValue, nativeDecimals, SymbolMNT),
+		ETH: NewAmount(ethValue, nativeDecimals, SymbolETH),
+	}
+}
+
+// WithdrawalValues formats the values of message. The amount of an ERC20 bridge
+// withdrawal is formatted with the L1 token's decimals and symbol, read once per token;
+// when they can't be read it stays in raw units.
+func (m *CrossChainMessenger) WithdrawalValues(ctx context.Context, message Message) WithdrawalValues {
+	v := NativeValues(message.MntValue, message.EthValue)
+	t := message.TokenWithdrawal
+	if t == nil {
+		return v
+	}
+	if t.Kind != TokenKindERC20 {
+		token := NewAmount(t.Amount, nativeDecimals, t.Kind)
+		v.Token = &token
+		return v
+	}
+	meta, err := m.tokenMetadata(ctx, t.L1Token)
+	if err != nil {
+		m.logger().Debugf("⚠️  Failed to read the metadata of token %s: %v", t.L1Token.Hex(), err)
+		token := NewAmount(t.Amount, 0, "")
+		v.Token = &token
+		return v
+	}
+	token := NewAmount(t.Amount, meta.Decimals, meta.Symbol)
+	v.Token = &token
+	return v
+}
+
+// TokenMetadata is what an ERC20 token reports about its units
+type TokenMetadata struct {
+	Symbol   string
+	Decimals uint8
+}
+
+// tokenMetadataCache holds the metadata of every token read so far; it never changes
+type tokenMetadataCache struct {
+	mu      sync.Mutex
+	byToken map[common.Address]TokenMetadata
+}
+
+// tokenMetadata reads the decimals and symbol of the L1 token at addr, cached for the
+// messenger's lifetime. A token without a symbol gets an empty one.
+func (m *CrossChainMessenger) tokenMetadata(ctx context.Context, addr common.Address) (TokenMetadata, error) {
+	m.tokens.mu.Lock()
+	meta, ok := m.tokens.byToken[addr]
+	m.tokens.mu.Unlock()
+	if ok {
+		return meta, nil
+	}
+	if m.ClientL1 == nil {
+		return TokenMetadata{}, errors.New("no L1 client")
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(erc20MetadataABI))
+	if err != nil {
+		return TokenMetadata{}, fmt.Errorf("failed to parse ERC20 ABI: %w", err)
+	}
+	token := bind.NewBoundContract(addr, parsed, m.ClientL1, nil, nil)
+	decimals, err := withRetry(ctx, m, "token decimals", func(ctx context.Context) ([]interface{}, error) {
+		var out []interface{}
+		err := token.Call(&bind.CallOpts{Context: ctx}, &out, "decimals")
+		return out, err
+	})
+	if err != nil {
+		return TokenMetadata{}, fmt.Errorf("failed to read decimals: %w", err)
+	}
+	meta.Decimals = decimals[0].(uint8)
+	meta.Symbol = m.tokenSymbol(ctx, addr, token)
+
+	m.tokens.mu.Lock()
+	if m.tokens.byToken == nil {
+		m.tokens.byToken = make(map[common.Address]TokenMetadata)
+	}
+	m.tokens.byToken[addr] = meta
+	m.tokens.mu.Unlock()
+	return meta, nil
+}
+
+// tokenSymbol reads the symbol of token as a string, or else as bytes32; empty when
+// neither works
+func (m *CrossChainMessenger) tokenSymbol(ctx context.Context, addr common.Address, token *bind.BoundContract) string {
+	var out []interface{}
+	if err := token.Call(&bind.CallOpts{Context: ctx}, &out, "symbol"); err == nil {
+		return out[0].(string)
+	}
+	parsed, err := abi.JSON(strings.NewReader(erc20Bytes32SymbolABI))
+	if err != nil {
+		return ""
+	}
+	out = nil
+	if err := bind.NewBoundContract(addr, parsed, m.ClientL1, nil, nil).Call(&bind.CallOpts{Context: ctx}, &out, "symbol"); err != nil {
+		return ""
+	}
+	symbol := out[0].([32]byte)
+	return strings.TrimRight(string(symbol[:]), "\x00")
+}
@@ -0,0 +1,135 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFormatUnits(t *testing.T) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	wei := func(s string) *big.Int {
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			t.Fatalf("bad amount %q", s)
+		}
+		return v
+	}
+	tests := []struct {
+		amount   *big.Int
+		decimals uint8
+		want     string
+	}{
+		{nil, 18, "0"},
+		{new(big.Int), 18, "0"},
+		{wei("1500000000000000000000"), 18, "1500"},
+		{wei("1000000000000000000"), 18, "1"},
+		{wei("1050000000000000000"), 18, "1.05"},
+		{wei("1"), 18, "0.000000000000000001"},
+		{wei("999999999999999999"), 18, "0.999999999999999999"},
+		{wei("123456789012345678901234567890"), 18, "123456789012.34567890123456789"},
+		// Beyond what a float64 holds exactly
+		{wei("9007199254740993000000000000000000"), 18, "9007199254740993"},
+		{maxUint256, 18, "115792089237316195423570985008687907853269984665640564039457.584007913129639935"},
+		{wei("1234567"), 6, "1.234567"},
+		{wei("1000000"), 6, "1"},
+		{wei("42"), 0, "42"},
+		{wei("-1500000000000000000"), 18, "-1.5"},
+		{wei("-1"), 18, "-0.000000000000000001"},
+	}
+	for _, tt := range tests {
+		if got := FormatUnits(tt.amount, tt.decimals); got != tt.want {
+			t.Fatalf("FormatUnits(%v, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestNativeValues(t *testing.T) {
+	v := NativeValues(big.NewInt(1500), nil)
+	if v.MNT != (Amount{Raw: "1500", Formatted: "0.0000000000000015 MNT", Symbol: SymbolMNT, Decimals: 18}) {
+		t.Fatalf("MNT = %+v", v.MNT)
+	}
+	if v.ETH.Raw != "0" || v.ETH.Formatted != "0 ETH" {
+		t.Fatalf("nil ETH = %+v, want zero", v.ETH)
+	}
+	if s := v.String(); s != "0.0000000000000015 MNT" {
+		t.Fatalf("String() = %s, want only the non-zero MNT", s)
+	}
+
+	both := NativeValues(new(big.Int).Mul(big.NewInt(1500), big.NewInt(1e18)), big.NewInt(25e16))
+	if s := both.String(); s != "1500 MNT, 0.25 ETH" {
+		t.Fatalf("String() = %s", s)
+	}
+	if s := NativeValues(nil, nil).String(); s != "0 MNT" {
+		t.Fatalf("String() of no value = %s", s)
+	}
+}
+
+// tokenClient is an L1 node with one ERC20 token at its address, counting the calls
+// made to it
+type tokenClient struct {
+	EthClient
+	token    common.Address
+	decimals uint8
+	symbol   string
+	calls    int
+}
+
+func (c *tokenClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	c.calls++
+	if msg.To == nil || *msg.To != c.token {
+		return nil, errors.New("execution reverted")
+	}
+	parsed, err := abi.JSON(strings.NewReader(erc20MetadataABI))
+	if err != nil {
+		return nil, err
+	}
+	method, err := parsed.MethodById(msg.Data)
+	if err != nil {
+		return nil, err
+	}
+	if method.Name == "decimals" {
+		return method.Outputs.Pack(c.decimals)
+	}
+	return method.Outputs.Pack(c.symbol)
+}
+
+func TestWithdrawalValuesToken(t *testing.T) {
+	client := &tokenClient{token: common.HexToAddress("0x6666666666666666666666666666666666666666"), decimals: 6, symbol: "USDC"}
+	m, err := NewCrossChainMessenger(MessengerConfig{
+		L1Client:          client,
+		L2Client:          client,
+		SkipStartupChecks: true,
+		Contracts:         DefaultContracts(),
+		Logger:            NopLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := Message{TokenWithdrawal: &TokenWithdrawalInfo{Kind: TokenKindERC20, L1Token: client.token, Amount: big.NewInt(2500000)}}
+	for range 2 {
+		v := m.WithdrawalValues(context.Background(), message)
+		if v.Token == nil || v.Token.Formatted != "2.5 USDC" || v.Token.Raw != "2500000" {
+			t.Fatalf("token amount = %+v, want 2.5 USDC", v.Token)
+		}
+	}
+	if client.calls != 2 {
+		t.Fatalf("made %d calls, want decimals and symbol read once", client.calls)
+	}
+
+	unknown := Message{TokenWithdrawal: &TokenWithdrawalInfo{Kind: TokenKindERC20, L1Token: common.HexToAddress("0x77"), Amount: big.NewInt(2500000)}}
+	if v := m.WithdrawalValues(context.Background(), unknown); v.Token == nil || v.Token.Formatted != "2500000" {
+		t.Fatalf("amount of a token without metadata = %+v, want it in raw units", v.Token)
+	}
+	eth := Message{TokenWithdrawal: &TokenWithdrawalInfo{Kind: TokenKindETH, Amount: big.NewInt(5e17)}}
+	if v := m.WithdrawalValues(context.Background(), eth); v.Token == nil || v.Token.Formatted != "0.5 ETH" {
+		t.Fatalf("ETH bridge amount = %+v, want 0.5 ETH", v.Token)
+	}
+}
@@ -0,0 +1,252 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BatchResult is the outcome of one withdrawal in a BatchProve or BatchFinalize call
+type BatchResult struct {
+	TxHash   string // L2 withdrawal transaction hash
+	L1TxHash string // Submitted L1 transaction; empty if nothing was sent
+	L1TxURL  string // Explorer link for L1TxHash; empty without an L1 explorer
+	Skipped  bool   // Already proven/finalized, nothing to do
+	External bool   // Our call reverted because someone else already proved/finalized it
+	Err      error
+
+	Provenance *ProofProvenance // BatchProve only: the output the withdrawal was proven against; nil unless proven by us
+	ProofSize  *ProofSize       // BatchProve only: the submitted proof; nil unless one was generated
+	Fee        *TxFee           // What our transaction paid; nil unless it was mined successfully
+}
+
+// BatchOptions are the SubmitOptions of a BatchProve or BatchFinalize call
+type BatchOptions struct {
+	From        common.Address                            // Wallet to sign every transaction with; zero means the default signer
+	OnSubmitted func(txHash string, l1TxHash common.Hash) // Called with each withdrawal's transaction once the batch is sent, before waiting, so callers can persist it
+}
+
+// batchCall is one prepared OptimismPortal call waiting to be submitted
+type batchCall struct {
+	message    Message
+	result     *BatchResult
+	calldata   []byte
+	send       func(opts *bind.TransactOpts) (*types.Transaction, error)
+	provenance *ProofProvenance // Set for prove calls; completed and reported once mined
+}
+
+// setErr records a per-withdrawal error, counting external completion as success
+func (r *BatchResult) setErr(err error) {
+	if IsExternallyCompleted(err) {
+		r.External = true
+		return
+	}
+	r.Err = err
+}
+
+// setErr records the error of the call's withdrawal
+func (c *batchCall) setErr(err error) {
+	c.result.setErr(err)
+}
+
+// BatchProve proves several withdrawals at once: every proof is generated up front, the
+// transactions are submitted back-to-back with consecutive nonces, and receipts are awaited
+// concurrently, all signed by the wallet opts.From. The returned slice has one result per hash, in input order; the error is only set when
+// nothing could be submitted at all (e.g. no signer).
+func (m *CrossChainMessenger) BatchProve(ctx context.Context, txHashes []string, opts BatchOptions) (_ []BatchResult, err error) {
+	ctx, span := startSpan(ctx, "BatchProve", attrBatchSize.Int(len(txHashes)))
+	defer func() { endSpan(span, err) }()
+	return m.runBatch(ctx, "prove", StatusProven, txHashes, opts, func(ctx context.Context, portal *cross_abi.OptimismPortal, message Message, result *BatchResult) (*batchCall, error) {
+		if message.Status >= StatusProven {
+			result.Skipped = true
+			return nil, nil
+		}
+		call, err := m.buildProveCall(ctx, message)
+		if err != nil {
+			return nil, err
+		}
+		outputIndex := new(big.Int).SetUint64(call.outputIndex)
+		calldata, err := packOptimismPortalCall("proveWithdrawalTransaction",
+			call.withdrawalTx, outputIndex, call.outputRootProof, call.withdrawalProof)
+		if err != nil {
+			return nil, err
+		}
+		size := MeasureProof(call.withdrawalProof)
+		result.ProofSize = &size
+		return &batchCall{
+			result:   result,
+			calldata: calldata,
+			send: func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return portal.ProveWithdrawalTransaction(opts, call.withdrawalTx, outputIndex, call.outputRootProof, call.withdrawalProof)
+			},
+			provenance: &ProofProvenance{OutputIndex: call.outputIndex, OutputRoot: call.outputRoot},
+		}, nil
+	})
+}
+
+// BatchFinalize finalizes several proven withdrawals at once, the same way as BatchProve
+func (m *CrossChainMessenger) BatchFinalize(ctx context.Context, txHashes []string, opts BatchOptions) (_ []BatchResult, err error) {
+	ctx, span := startSpan(ctx, "BatchFinalize", attrBatchSize.Int(len(txHashes)))
+	defer func() { endSpan(span, err) }()
+	return m.runBatch(ctx, "finalize", StatusFinalized, txHashes, opts, func(ctx context.Context, portal *cross_abi.OptimismPortal, message Message, result *BatchResult) (*batchCall, error) {
+		if message.Status >= StatusFinalized {
+			result.Skipped = true
+			return nil, nil
+		}
+		if message.Status < StatusProven {
+			return nil, ErrNotProven
+		}
+		if _, err := m.CheckFinalizeCost(ctx, message, opts.From); err != nil {
+			return nil, err
+		}
+		withdrawalTx, err := withdrawalTransaction(message)
+		if err != nil {
+			return nil, err
+		}
+		calldata, err := packOptimismPortalCall("finalizeWithdrawalTransaction", withdrawalTx)
+		if err != nil {
+			return nil, err
+		}
+		return &batchCall{
+			result:   result,
+			calldata: calldata,
+			send: func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return portal.FinalizeWithdrawalTransaction(opts, withdrawalTx)
+			},
+		}, nil
+	})
+}
+
+// runBatch prepares a call per hash with prepare, submits the prepared calls under a
+// single hold of sendMu and then waits for all receipts in parallel. Each withdrawal
+// still short of target is locked with lockWithdrawal before it is prepared, and the
+// locks are released once every receipt is in.
+func (m *CrossChainMessenger) runBatch(ctx context.Context, operation string, target int, txHashes []string, batchOpts BatchOptions,
+	prepare func(ctx context.Context, portal *cross_abi.OptimismPortal, message Message, result *BatchResult) (*batchCall, error)) ([]BatchResult, error) {
+	if !m.HasSigner() {
+		return nil, ErrNoSigner
+	}
+	if err := m.checkNotPaused(ctx); err != nil {
+		return nil, err
+	}
+
+	portalAddr := common.HexToAddress(m.Contracts.L1.OptimismPortal)
+	portal, err := m.optimismPortal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OptimismPortal contract: %w", err)
+	}
+	baseOpts, err := m.getTransactOpts(ctx, batchOpts.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction options: %w", err)
+	}
+
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+
+	results := make([]BatchResult, len(txHashes))
+	var calls []*batchCall
+	for i, txHash := range txHashes {
+		results[i].TxHash = txHash
+		message, err := m.getMessages(ctx, txHash)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to get messages: %w", err)
+			continue
+		}
+		if message.Status < target {
+			release, err := m.lockWithdrawal(ctx, operation, &message)
+			if err != nil {
+				results[i].setErr(err)
+				continue
+			}
+			releases = append(releases, release)
+		}
+		call, err := prepare(ctx, portal, message, &results[i])
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if call != nil {
+			call.message = message
+			calls = append(calls, call)
+		}
+	}
+
+	m.logger().Infof("🚀 Submitting %d %s transaction(s) (%d skipped or failed during preparation)",
+		len(calls), operation, len(txHashes)-len(calls))
+
+	// Submit back-to-back so the nonces are consecutive
+	sent := make(map[*batchCall]*types.Transaction)
+	m.sendMu.Lock()
+	for _, call := range calls {
+		opts := *baseOpts
+		opts.Context = ctx
+		if err := m.applyGasSettings(ctx, &opts, portalAddr, call.calldata); err != nil {
+			call.setErr(m.resolveExternalCompletion(ctx, &call.message, target, err))
+			continue
+		}
+		tx, err := m.sendLocked(ctx, &opts, call.send)
+		if err != nil {
+			call.setErr(m.resolveExternalCompletion(ctx, &call.message, target,
+				fmt.Errorf("failed to send %s transaction: %w", operation, wrapContractError(ContractOptimismPortal, err))))
+			continue
+		}
+		call.result.L1TxHash = tx.Hash().Hex()
+		call.result.L1TxURL = m.Explorer.L1Tx(call.result.L1TxHash)
+		sent[call] = tx
+		m.logger().Infof("✅ %s transaction for %s submitted: %s", operation, call.result.TxHash, tx.Hash().Hex())
+	}
+	m.sendMu.Unlock()
+	if batchOpts.OnSubmitted != nil {
+		for _, call := range calls {
+			if tx, ok := sent[call]; ok {
+				batchOpts.OnSubmitted(call.result.TxHash, tx.Hash())
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for call, tx := range sent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receipt, err := m.waitSuccessful(ctx, tx, baseOpts)
+			if err == nil {
+				fee := m.receiptFee(ctx, receipt, baseOpts.From)
+				call.result.Fee = &fee
+			}
+			if err == nil && call.provenance != nil {
+				call.provenance.ProveTxHash = tx.Hash()
+				call.provenance.ProvenAt = m.provenTimestamp(ctx, call.message.WithdrawalHash)
+				call.result.Provenance = call.provenance
+			}
+			call.setErr(m.resolveExternalCompletion(ctx, &call.message, target, err))
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// waitSuccessful waits for tx to be mined and fails if it reverted
+func (m *CrossChainMessenger) waitSuccessful(ctx context.Context, tx *types.Transaction, opts *bind.TransactOpts) (*types.Receipt, error) {
+	receipt, err := m.waitMined(ctx, tx, opts)
+	if err != nil {
+		m.resetNonce()
+		return nil, fmt.Errorf("failed to wait for transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status == 0 {
+		return nil, fmt.Errorf("transaction %s: %w", tx.Hash().Hex(), m.revertedTxError(ctx, ContractOptimismPortal, tx, opts.From, receipt))
+	}
+	m.logger().Infof("✅ Transaction %s mined in block %d (gas used: %d)", tx.Hash().Hex(), receipt.BlockNumber.Uint64(), receipt.GasUsed)
+	return receipt, nil
+}
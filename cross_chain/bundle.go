@@ -0,0 +1,278 @@
+package crosschain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BundleVersion is the ClaimBundle layout ExportBundle writes and LoadBundle accepts
+const BundleVersion = 1
+
+// Bundle steps that can fail without failing the export; keys of ClaimBundle.Errors
+const (
+	BundleStepMessage = "message"
+	BundleStepOutput  = "output"
+	BundleStepProof   = "proof"
+)
+
+// ClaimBundle is everything needed to reproduce a withdrawal's claim on another machine,
+// for support escalations: the L2 receipt, the parsed message, the covering output, the
+// proof and the proveWithdrawalTransaction calldata. Only chain IDs and contract
+// addresses of the configuration are recorded; keys, RPC URLs and other secrets never
+// are. A step that fails is recorded in Errors instead of failing the export, since a
+// failing claim is what a bundle is for.
+type ClaimBundle struct {
+	Version    int                 `json:"version"`
+	TxHash     string              `json:"txHash"`
+	L1ChainID  uint64              `json:"l1ChainId"`
+	L2ChainID  uint64              `json:"l2ChainId"`
+	Contracts  BundleContracts     `json:"contracts"`
+	Receipt    *types.Receipt      `json:"receipt"`
+	Message    *BundleMessage      `json:"message,omitempty"`
+	Output     *OutputProposalInfo `json:"output,omitempty"`     // First output covering the withdrawal
+	Proof      *ProofFile          `json:"proof,omitempty"`      // Against Output; what prove --bundle submits
+	Calldata   hexutil.Bytes       `json:"calldata,omitempty"`   // proveWithdrawalTransaction for Proof, as it would be sent
+	ProofNodes []string            `json:"proofNodes,omitempty"` // Per-node detail of Proof; only with DebugProof (--debug-proof)
+	Errors     map[string]string   `json:"errors,omitempty"`     // Bundle step to why it failed
+	ExportedAt time.Time           `json:"exportedAt"`
+}
+
+// BundleContracts are the L1 contracts the bundle was exported against
+type BundleContracts struct {
+	OptimismPortal         string `json:"optimismPortal"`
+	L2OutputOracle         string `json:"l2OutputOracle"`
+	L1CrossDomainMessenger string `json:"l1CrossDomainMessenger"`
+}
+
+// BundleMessage is the withdrawal parsed from the receipt
+type BundleMessage struct {
+	BlockNumber    uint64               `json:"blockNumber"`
+	LogIndex       uint64               `json:"logIndex"`
+	Status         int                  `json:"status"`
+	StatusName     string               `json:"statusName"`
+	WithdrawalHash common.Hash          `json:"withdrawalHash"`
+	Nonce          *big.Int             `json:"nonce"` // Versioned nonce, as hashed
+	MessageVersion uint16               `json:"messageVersion"`
+	Sender         common.Address       `json:"sender"`
+	Target         common.Address       `json:"target"`
+	MntValue       *big.Int             `json:"mntValue"`
+	EthValue       *big.Int             `json:"ethValue"`
+	GasLimit       *big.Int             `json:"gasLimit"`
+	Data           hexutil.Bytes        `json:"data"`
+	Token          *TokenWithdrawalInfo `json:"token,omitempty"`
+	Provenance     *ProofProvenance     `json:"provenance,omitempty"`
+}
+
+// ExportBundle gathers the ClaimBundle of the withdrawal in txHash through the same
+// code paths prove uses. Only a missing receipt fails it; the message, output and proof
+// that can't be read or generated are recorded in Errors. A finalized withdrawal gets no
+// output or proof. Nothing is signed or sent.
+func (m *CrossChainMessenger) ExportBundle(ctx context.Context, txHash string, messageIndex int) (_ *ClaimBundle, err error) {
+	ctx, span := startSpan(ctx, "ExportBundle", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return nil, err
+	}
+	receipt, err := m.getTransactionReceipt(ctx, txHash, "L2")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L2 receipt: %w", err)
+	}
+	b := &ClaimBundle{
+		Version:   BundleVersion,
+		TxHash:    txHash,
+		L1ChainID: m.L1ChainID,
+		L2ChainID: m.L2ChainID,
+		Contracts: BundleContracts{
+			OptimismPortal:         m.Contracts.L1.OptimismPortal,
+			L2OutputOracle:         m.Contracts.L1.L2OutputOracle,
+			L1CrossDomainMessenger: m.Contracts.L1.L1CrossDomainMessenger,
+		},
+		Receipt:    receipt,
+		Errors:     map[string]string{},
+		ExportedAt: time.Now().UTC(),
+	}
+	fail := func(step string, err error) {
+		m.logger().Warnf("⚠️  Bundle %s: %v", step, err)
+		b.Errors[step] = err.Error()
+	}
+
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		fail(BundleStepMessage, err)
+		return b, nil
+	}
+	b.Message = newBundleMessage(message)
+	if message.Status >= StatusFinalized {
+		return b, nil
+	}
+
+	oracle := m.Contracts.L1.L2OutputOracle
+	if index, err := m.getL2OutputIndex(ctx, oracle, message.BlockNumber); err != nil {
+		fail(BundleStepOutput, err)
+	} else if output, err := m.getL2OutputData(ctx, oracle, index); err != nil {
+		fail(BundleStepOutput, err)
+	} else {
+		b.Output = &OutputProposalInfo{
+			OutputIndex:   index,
+			L2BlockNumber: output.L2BlockNumber.Uint64(),
+			OutputRoot:    common.Hash(output.OutputRoot),
+			L1Timestamp:   time.Unix(output.Timestamp.Int64(), 0).UTC(),
+		}
+	}
+
+	call, err := m.buildProveCall(ctx, message)
+	if err != nil {
+		fail(BundleStepProof, err)
+		return b, nil
+	}
+	b.Proof = newProofFile(message, call)
+	if m.DebugProof {
+		b.ProofNodes = proofNodeDetail(call.withdrawalProof)
+	}
+	calldata, err := packOptimismPortalCall("proveWithdrawalTransaction",
+		call.withdrawalTx, new(big.Int).SetUint64(call.outputIndex), call.outputRootProof, call.withdrawalProof)
+	if err != nil {
+		fail(BundleStepProof, err)
+		return b, nil
+	}
+	b.Calldata = calldata
+	return b, nil
+}
+
+// newBundleMessage records message for a bundle
+func newBundleMessage(message Message) *BundleMessage {
+	bm := &BundleMessage{
+		BlockNumber:    message.BlockNumber,
+		LogIndex:       message.LogIndex,
+		Status:         message.Status,
+		StatusName:     StatusDescription(message.Status),
+		WithdrawalHash: common.HexToHash(message.WithdrawalHash),
+		Nonce:          message.MsgNonceRaw,
+		MessageVersion: message.MessageVersion,
+		MntValue:       message.MntValue,
+		EthValue:       message.EthValue,
+		Token:          message.TokenWithdrawal,
+		Provenance:     message.Provenance,
+	}
+	if event := message.MessagePassedEvent; event != nil {
+		bm.Sender = event.Sender
+		bm.Target = event.Target
+		bm.GasLimit = event.GasLimit
+		bm.Data = event.Data
+	}
+	return bm
+}
+
+// ProveMessageWithBundle proves the withdrawal in txHash with the proof in a bundle
+// written by SaveBundle, checked like ProveMessageWithProof checks a proof file
+func (m *CrossChainMessenger) ProveMessageWithBundle(ctx context.Context, txHash string, messageIndex int, bundlePath string, opts SubmitOptions) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "ProveMessageWithBundle", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("\n=== PROVE MESSAGE (BUNDLE) ===")
+	m.logger().Infof("Transaction hash (on L2): %s", txHash)
+	m.logger().Infof("Bundle: %s", bundlePath)
+	return m.proveWithLoadedProof(ctx, txHash, "bundle "+bundlePath, func() (*ProofFile, error) {
+		b, err := LoadBundle(bundlePath)
+		if err != nil {
+			return nil, err
+		}
+		if b.Proof == nil {
+			return nil, fmt.Errorf("bundle %s has no proof: %s", bundlePath, b.Errors[BundleStepProof])
+		}
+		if b.L1ChainID != 0 && m.L1ChainID != 0 && b.L1ChainID != m.L1ChainID {
+			return nil, fmt.Errorf("bundle %s was exported for %s, this configuration is %s", bundlePath, chainName(b.L1ChainID), chainName(m.L1ChainID))
+		}
+		if !strings.EqualFold(b.Contracts.OptimismPortal, m.Contracts.L1.OptimismPortal) {
+			return nil, fmt.Errorf("bundle %s was exported against OptimismPortal %s, this configuration uses %s",
+				bundlePath, b.Contracts.OptimismPortal, m.Contracts.L1.OptimismPortal)
+		}
+		return b.Proof, nil
+	}, opts)
+}
+
+// SaveBundle writes b to path as indented JSON, or to stdout when path is "-"
+func SaveBundle(path string, b *ClaimBundle) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode bundle: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// LoadBundle reads a bundle written by SaveBundle and checks it: the version, that the
+// receipt is the transaction's, that the proof is internally consistent and for the
+// same transaction, and that the calldata encodes that proof
+func LoadBundle(path string) (*ClaimBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	var b ClaimBundle
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle %s: %w", path, err)
+	}
+	if b.Version != BundleVersion {
+		return nil, fmt.Errorf("bundle %s has version %d, this build reads version %d", path, b.Version, BundleVersion)
+	}
+	if !common.IsHexAddress(b.Contracts.OptimismPortal) {
+		return nil, fmt.Errorf("bundle %s has no OptimismPortal address", path)
+	}
+	if b.Receipt == nil {
+		return nil, fmt.Errorf("bundle %s has no receipt", path)
+	}
+	if b.Receipt.TxHash != common.HexToHash(b.TxHash) {
+		return nil, fmt.Errorf("bundle %s receipt is for transaction %s, bundle says %s", path, b.Receipt.TxHash.Hex(), b.TxHash)
+	}
+	if b.Proof == nil {
+		if len(b.Calldata) > 0 {
+			return nil, fmt.Errorf("bundle %s has calldata but no proof", path)
+		}
+		return &b, nil
+	}
+
+	if err := b.Proof.validate("bundle " + path + " proof"); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(b.Proof.TxHash, b.TxHash) {
+		return nil, fmt.Errorf("bundle %s proof is for transaction %s, bundle says %s", path, b.Proof.TxHash, b.TxHash)
+	}
+	if b.Message != nil && b.Message.WithdrawalHash != b.Proof.WithdrawalHash {
+		return nil, fmt.Errorf("%w: bundle %s message is withdrawal %s, its proof is for %s",
+			ErrWithdrawalHashMismatch, path, b.Message.WithdrawalHash.Hex(), b.Proof.WithdrawalHash.Hex())
+	}
+	if len(b.Calldata) > 0 {
+		call := b.Proof.proveCall()
+		calldata, err := packOptimismPortalCall("proveWithdrawalTransaction",
+			call.withdrawalTx, new(big.Int).SetUint64(call.outputIndex), call.outputRootProof, call.withdrawalProof)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(calldata, b.Calldata) {
+			return nil, fmt.Errorf("bundle %s calldata doesn't encode its proof", path)
+		}
+	}
+	return &b, nil
+}
@@ -0,0 +1,146 @@
+package crosschain
+
+import (
+	"fmt"
+	"sync"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// messengerCache holds contract bindings and L2 output lookups that don't change between
+// calls. Proposed outputs are immutable unless the challenger deletes them, so output
+// proposals and the block -> output index mapping are only ever cached once they exist.
+type messengerCache struct {
+	mu sync.Mutex
+
+	portal     *cross_abi.OptimismPortal
+	portalAddr common.Address
+	oracle     *cross_abi.L2OutputOracle
+	oracleAddr common.Address
+
+	l1Messenger     *cross_abi.L1CrossDomainMessenger
+	l1MessengerAddr common.Address
+
+	outputs       map[uint64]cross_abi.TypesOutputProposal // Output index -> proposal
+	outputIndexes map[uint64]uint64                        // L2 block -> covering output index
+
+	finalization *FinalizationParams // Oracle finalization period and mode; nil until read
+}
+
+// ClearCache drops cached contract bindings and L2 output lookups, e.g. after changing
+// Contracts or clients, or if the challenger deleted outputs
+func (m *CrossChainMessenger) ClearCache() {
+	c := &m.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.portal = nil
+	c.oracle = nil
+	c.l1Messenger = nil
+	c.outputs = nil
+	c.outputIndexes = nil
+	c.finalization = nil
+}
+
+// optimismPortal returns the OptimismPortal binding for the configured address
+func (m *CrossChainMessenger) optimismPortal() (*cross_abi.OptimismPortal, error) {
+	addr := common.HexToAddress(m.Contracts.L1.OptimismPortal)
+	c := &m.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.portal != nil && c.portalAddr == addr {
+		return c.portal, nil
+	}
+	portal, err := cross_abi.NewOptimismPortal(addr, m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OptimismPortal contract: %w", err)
+	}
+	c.portal, c.portalAddr = portal, addr
+	return portal, nil
+}
+
+// l2OutputOracle returns the L2OutputOracle binding at address
+func (m *CrossChainMessenger) l2OutputOracle(address string) (*cross_abi.L2OutputOracle, error) {
+	addr := common.HexToAddress(address)
+	c := &m.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.oracle != nil && c.oracleAddr == addr {
+		return c.oracle, nil
+	}
+	oracle, err := cross_abi.NewL2OutputOracle(addr, m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create L2OutputOracle instance: %w", err)
+	}
+	// Cached outputs belong to the previous oracle
+	c.oracle, c.oracleAddr = oracle, addr
+	c.outputs = nil
+	c.outputIndexes = nil
+	c.finalization = nil
+	return oracle, nil
+}
+
+// l1CrossDomainMessenger returns the L1CrossDomainMessenger binding for the configured address
+func (m *CrossChainMessenger) l1CrossDomainMessenger() (*cross_abi.L1CrossDomainMessenger, error) {
+	addr := common.HexToAddress(m.Contracts.L1.L1CrossDomainMessenger)
+	c := &m.cache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.l1Messenger != nil && c.l1MessengerAddr == addr {
+		return c.l1Messenger, nil
+	}
+	messenger, err := cross_abi.NewL1CrossDomainMessenger(addr, m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create L1CrossDomainMessenger contract: %w", err)
+	}
+	c.l1Messenger, c.l1MessengerAddr = messenger, addr
+	return messenger, nil
+}
+
+func (c *messengerCache) outputIndex(blockNumber uint64) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	index, ok := c.outputIndexes[blockNumber]
+	return index, ok
+}
+
+func (c *messengerCache) setOutputIndex(blockNumber, index uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.outputIndexes == nil {
+		c.outputIndexes = make(map[uint64]uint64)
+	}
+	c.outputIndexes[blockNumber] = index
+}
+
+func (c *messengerCache) output(index uint64) (cross_abi.TypesOutputProposal, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output, ok := c.outputs[index]
+	return output, ok
+}
+
+func (c *messengerCache) setOutput(index uint64, output cross_abi.TypesOutputProposal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.outputs == nil {
+		c.outputs = make(map[uint64]cross_abi.TypesOutputProposal)
+	}
+	c.outputs[index] = output
+}
+
+func (c *messengerCache) finalizationParams() (FinalizationParams, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.finalization == nil {
+		return FinalizationParams{}, false
+	}
+	return *c.finalization, true
+}
+
+func (c *messengerCache) setFinalizationParams(params FinalizationParams) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.finalization = &params
+}
@@ -0,0 +1,132 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ClaimSchedule is when a withdrawal can be proven and finalized: actual times for the
+// steps already taken, estimates for the rest
+type ClaimSchedule struct {
+	TxHash                 string              `json:"txHash"`
+	WithdrawalHash         string              `json:"withdrawalHash"`
+	Status                 int                 `json:"status"`
+	StatusName             string              `json:"statusName"`
+	ProvableAt             time.Time           `json:"provableAt"`             // When a covering output was, or is expected to be, proposed
+	ProvableEstimated      bool                `json:"provableEstimated"`      // No covering output yet; ProvableAt is from the oracle's cadence
+	OutputWait             *OutputWaitEstimate `json:"outputWait,omitempty"`   // What a not yet provable withdrawal waits for
+	ProvenAt               *time.Time          `json:"provenAt,omitempty"`     // When the prove was mined; nil until proven
+	ChallengePeriodSeconds int64               `json:"challengePeriodSeconds"` // finalizationPeriodSeconds as of now
+	Optimistic             bool                `json:"optimistic,omitempty"`   // The oracle is in optimistic mode
+	FinalizeAt             time.Time           `json:"finalizeAt"`             // Earliest finalize: the prove plus the challenge period
+	FinalizeEstimated      bool                `json:"finalizeEstimated"`      // Not proven yet; assumes a prove as soon as it is provable
+	Finalized              bool                `json:"finalized,omitempty"`    // Nothing left to wait for
+	Lower                  bool                `json:"lowerBound,omitempty"`   // The output wait hit MaxOutputWaitEstimate, so times are lower bounds
+}
+
+// String renders the schedule for the CLI, one step per line
+func (s ClaimSchedule) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Status: %s\n", s.StatusName)
+	switch {
+	case s.ProvableEstimated && s.OutputWait != nil:
+		fmt.Fprintf(&b, "Provable: %s %s; %s\n", approx(s.Lower), FormatETA(s.ProvableAt), s.OutputWait.Describe())
+	case !s.ProvableAt.IsZero():
+		fmt.Fprintf(&b, "Provable: since %s\n", FormatETA(s.ProvableAt))
+	}
+	if s.ProvenAt != nil {
+		fmt.Fprintf(&b, "Proven: %s\n", FormatETA(*s.ProvenAt))
+	}
+	period := time.Duration(s.ChallengePeriodSeconds) * time.Second
+	mode := ""
+	if s.Optimistic {
+		mode = ", optimistic mode"
+	}
+	fmt.Fprintf(&b, "Challenge period: %s%s\n", period, mode)
+	switch {
+	case s.Finalized:
+		b.WriteString("Finalized: yes\n")
+	case s.FinalizeEstimated:
+		fmt.Fprintf(&b, "Earliest finalize: %s %s, if proven once provable\n", approx(s.Lower), FormatETA(s.FinalizeAt))
+	default:
+		fmt.Fprintf(&b, "Earliest finalize: %s (in %s)\n", FormatETA(s.FinalizeAt), max(time.Until(s.FinalizeAt), 0).Round(time.Minute))
+	}
+	return b.String()
+}
+
+func approx(lower bool) string {
+	if lower {
+		return "after"
+	}
+	return "≈"
+}
+
+// FormatETA formats an estimated or actual time for messages, e.g. "2026-10-16 14:32 UTC"
+func FormatETA(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 UTC")
+}
+
+// EstimateClaimSchedule estimates when the withdrawal in txHash can be proven and
+// finalized. Before a covering output is proposed, ProvableAt comes from the oracle's
+// submission interval, L2 block time and latest proposal (see EstimateOutputWait), and
+// FinalizeAt assumes a prove right then; once proven, FinalizeAt is the actual prove
+// time plus the challenge period.
+func (m *CrossChainMessenger) EstimateClaimSchedule(ctx context.Context, txHash string) (_ ClaimSchedule, err error) {
+	ctx, span := startSpan(ctx, "EstimateClaimSchedule", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return ClaimSchedule{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+	params := m.FinalizationParams(ctx)
+	s := ClaimSchedule{
+		TxHash:                 txHash,
+		WithdrawalHash:         "0x" + message.WithdrawalHash,
+		Status:                 message.Status,
+		StatusName:             StatusDescription(message.Status),
+		ChallengePeriodSeconds: int64(params.Period.Seconds()),
+		Optimistic:             params.Optimistic,
+		Finalized:              FinalizedAtPortal(message.Status),
+	}
+
+	if message.Status >= StatusProven {
+		if p := message.Provenance; p != nil && !p.ProvenAt.IsZero() {
+			s.ProvenAt = &p.ProvenAt
+		} else if proven, timestamp, err := m.checkProvenStatus(ctx, message.WithdrawalHash); err != nil {
+			return s, fmt.Errorf("failed to check proven status: %w", err)
+		} else if proven && timestamp != nil && timestamp.Sign() > 0 {
+			provenAt := time.Unix(timestamp.Int64(), 0).UTC()
+			s.ProvenAt = &provenAt
+		}
+		if s.ProvenAt != nil {
+			s.FinalizeAt = s.ProvenAt.Add(params.Period)
+		}
+	}
+	if ev, ok, err := m.provableEvent(ctx, message.BlockNumber); err != nil {
+		m.logger().Debugf("⚠️  Failed to read the covering output: %v", err)
+	} else if ok {
+		s.ProvableAt = ev.At.UTC()
+	}
+	if s.ProvenAt != nil || s.Finalized {
+		return s, nil
+	}
+
+	// Not proven: the earliest finalize follows a prove as soon as one is possible
+	s.FinalizeEstimated = true
+	if !s.ProvableAt.IsZero() {
+		s.FinalizeAt = time.Now().UTC().Add(params.Period)
+		return s, nil
+	}
+	estimate, err := m.EstimateOutputWait(ctx, message.BlockNumber)
+	if err != nil {
+		return s, fmt.Errorf("failed to estimate the output proposal: %w", err)
+	}
+	s.ProvableEstimated = true
+	s.OutputWait = &estimate
+	s.Lower = estimate.Capped
+	s.ProvableAt = time.Now().UTC().Add(estimate.Wait)
+	s.FinalizeAt = s.ProvableAt.Add(params.Period)
+	return s, nil
+}
@@ -0,0 +1,54 @@
+package crosschain
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ChainClient is the chain access a CrossChainMessenger needs for ClientL1 and ClientL2.
+// Assign a fake to those fields to run the messenger without a node; EthClient is the
+// same interface under its older name.
+type ChainClient = EthClient
+
+// MessageReader reads withdrawals and waits for them to change status
+type MessageReader interface {
+	GetMessages(ctx context.Context, txHash string) (Message, error)
+	GetMessageStatus(ctx context.Context, txHash string) (int, error)
+	WaitForStatus(ctx context.Context, txHash string, messageIndex int, target int, poll time.Duration) error
+}
+
+// Prover submits proveWithdrawalTransaction and returns the L1 transaction hash
+type Prover interface {
+	ProveMessage(ctx context.Context, txHash string, messageIndex int, opts SubmitOptions) (common.Hash, error)
+}
+
+// Finalizer submits finalizeWithdrawalTransaction and returns the L1 transaction hash
+type Finalizer interface {
+	FinalizeMessage(ctx context.Context, txHash string, messageIndex int, opts SubmitOptions) (common.Hash, error)
+}
+
+// Client is everything a service needs to drive a withdrawal to completion. Depend on it
+// (or the narrower interfaces) instead of *CrossChainMessenger so tests can use
+// crosschaintest.FakeMessenger.
+type Client interface {
+	MessageReader
+	Prover
+	Finalizer
+}
+
+var _ Client = (*CrossChainMessenger)(nil)
+
+// GetMessageStatus returns the status of the withdrawal in txHash: StatusReadyToProve,
+// StatusProven or StatusFinalized
+func (m *CrossChainMessenger) GetMessageStatus(ctx context.Context, txHash string) (_ int, err error) {
+	ctx, span := startSpan(ctx, "GetMessageStatus", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
+	message, err := m.GetMessages(ctx, txHash)
+	if err != nil {
+		return 0, err
+	}
+	m.warnIfPaused(ctx, message)
+	return message.Status, nil
+}
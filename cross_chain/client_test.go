@@ -0,0 +1,123 @@
+package crosschain_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/cross_chain/crosschaintest"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var _ crosschain.Client = (*crosschain.CrossChainMessenger)(nil)
+
+// checkLifecycle drives the withdrawal in txHash through prove and finalize on client,
+// checking the statuses and errors every crosschain.Client must give. advance moves the
+// client's clock forward.
+func checkLifecycle(t *testing.T, client crosschain.Client, txHash string, period time.Duration, advance func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+	status := func(want int) {
+		t.Helper()
+		if got, err := client.GetMessageStatus(ctx, txHash); err != nil || got != want {
+			t.Fatalf("GetMessageStatus() = %d, %v, want %d", got, err, want)
+		}
+	}
+	wait := func(target int) error {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return client.WaitForStatus(ctx, txHash, 0, target, 10*time.Millisecond)
+	}
+
+	status(crosschain.StatusReadyToProve)
+	if message, err := client.GetMessages(ctx, txHash); err != nil || message.Status != crosschain.StatusReadyToProve {
+		t.Fatalf("GetMessages() = status %d, %v", message.Status, err)
+	}
+	if _, err := client.FinalizeMessage(ctx, txHash, 0, crosschain.SubmitOptions{}); !errors.Is(err, crosschain.ErrNotProven) {
+		t.Fatalf("FinalizeMessage() before proving = %v, want ErrNotProven", err)
+	}
+	if err := wait(crosschain.StatusProven); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForStatus(proven) before proving = %v, want the deadline", err)
+	}
+
+	var submitted common.Hash
+	proveTx, err := client.ProveMessage(ctx, txHash, 0, crosschain.SubmitOptions{OnSubmitted: func(h common.Hash) { submitted = h }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proveTx == (common.Hash{}) || submitted != proveTx {
+		t.Fatalf("ProveMessage() = %s, OnSubmitted got %s", proveTx.Hex(), submitted.Hex())
+	}
+	status(crosschain.StatusProven)
+	if err := wait(crosschain.StatusProven); err != nil {
+		t.Fatalf("WaitForStatus(proven) = %v", err)
+	}
+	if _, err := client.FinalizeMessage(ctx, txHash, 0, crosschain.SubmitOptions{}); !errors.Is(err, crosschain.ErrChallengePeriodActive) {
+		t.Fatalf("FinalizeMessage() in the challenge period = %v, want ErrChallengePeriodActive", err)
+	}
+	if err := wait(crosschain.WaitReadyToFinalize); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForStatus(ready to finalize) in the challenge period = %v, want the deadline", err)
+	}
+
+	advance(period)
+	if err := wait(crosschain.WaitReadyToFinalize); err != nil {
+		t.Fatalf("WaitForStatus(ready to finalize) = %v", err)
+	}
+	if _, err := client.FinalizeMessage(ctx, txHash, 0, crosschain.SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	status(crosschain.StatusFinalized)
+	if _, err := client.ProveMessage(ctx, txHash, 0, crosschain.SubmitOptions{}); !errors.Is(err, crosschain.ErrAlreadyFinalized) {
+		t.Fatalf("ProveMessage() when finalized = %v, want ErrAlreadyFinalized", err)
+	}
+	if _, err := client.FinalizeMessage(ctx, txHash, 0, crosschain.SubmitOptions{}); !errors.Is(err, crosschain.ErrAlreadyFinalized) {
+		t.Fatalf("FinalizeMessage() when finalized = %v, want ErrAlreadyFinalized", err)
+	}
+}
+
+func TestMessengerLifecycle(t *testing.T) {
+	m, txHash, period, advance := crosschain.NewFakeChainMessenger(t)
+	checkLifecycle(t, m, txHash, period, advance)
+}
+
+// The fake must give the same answers as the messenger it stands in for
+func TestFakeMessengerLifecycle(t *testing.T) {
+	now := time.Now()
+	fake := crosschaintest.NewFakeMessenger()
+	fake.Now = func() time.Time { return now }
+	txHash := common.HexToHash("0xaa").Hex()
+	fake.AddMessage(txHash, crosschain.Message{Status: crosschain.StatusReadyToProve}, time.Time{})
+
+	checkLifecycle(t, fake, txHash, fake.ChallengePeriod, func(d time.Duration) { now = now.Add(d) })
+
+	var methods []string
+	for _, call := range fake.Calls() {
+		if call.TxHash != txHash {
+			t.Fatalf("call %+v is for another transaction", call)
+		}
+		methods = append(methods, call.Method)
+	}
+	if len(methods) == 0 || methods[0] != "GetMessageStatus" {
+		t.Fatalf("Calls() = %v, want them recorded in order", methods)
+	}
+}
+
+func TestFakeMessengerErrors(t *testing.T) {
+	fake := crosschaintest.NewFakeMessenger()
+	txHash := common.HexToHash("0xaa").Hex()
+	if _, err := fake.GetMessages(context.Background(), txHash); !errors.Is(err, crosschain.ErrNoWithdrawalFound) {
+		t.Fatalf("GetMessages() of an unknown transaction = %v, want ErrNoWithdrawalFound", err)
+	}
+
+	fake.AddMessage(txHash, crosschain.Message{Status: crosschain.StatusReadyToProve}, time.Time{})
+	fake.Errors["ProveMessage"] = crosschain.ErrRPC
+	if _, err := fake.ProveMessage(context.Background(), txHash, 0, crosschain.SubmitOptions{}); !errors.Is(err, crosschain.ErrRPC) {
+		t.Fatalf("ProveMessage() = %v, want the configured error", err)
+	}
+	if status, _ := fake.GetMessageStatus(context.Background(), txHash); status != crosschain.StatusReadyToProve {
+		t.Fatalf("a failed prove changed the status to %d", status)
+	}
+}
@@ -0,0 +1,380 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Defaults for Timeouts, used by MessengerConfigFromEnv
+const (
+	DefaultDialTimeout      = 30 * time.Second // Initial connection to each RPC endpoint (fixed)
+	DefaultRPCReadTimeout   = 30 * time.Second // RPC_READ_TIMEOUT
+	DefaultProofTimeout     = 2 * time.Minute  // PROOF_TIMEOUT
+	DefaultWaitMinedTimeout = 15 * time.Minute // WAIT_MINED_TIMEOUT
+)
+
+// SignerConfig selects how transactions are signed; leave all empty for read-only use.
+// When several are set the KMS key is used, then the remote signer.
+type SignerConfig struct {
+	KMSKeyID      string // AWS KMS key ID (takes precedence)
+	PrivateKey    string // Hex private key
+	RemoteURL     string // JSON-RPC signer exposing eth_signTransaction (web3signer, clef)
+	RemoteAddress string // Account to use at RemoteURL; empty if the signer holds only one
+}
+
+// Timeouts bounds blocking operations; zero means no limit. Running out of one fails
+// the operation with a TimeoutError.
+type Timeouts struct {
+	Dial            time.Duration // Connecting to the RPC endpoints
+	RPCRead         time.Duration // One read-only RPC call, including its retries
+	ProofGeneration time.Duration // Fetching and checking the withdrawal proof for a prove
+	WaitMined       time.Duration // Waiting for a submitted transaction to be mined
+}
+
+// MessengerConfig carries everything needed to build a CrossChainMessenger
+type MessengerConfig struct {
+	L1RpcUrl          string
+	L2RpcUrl          string
+	L1RpcFallbacks    []string       // Tried in order when L1RpcUrl fails with a connection error or timeout
+	L2RpcFallbacks    []string       // Tried in order when L2RpcUrl fails with a connection error or timeout
+	L1ChainID         uint64         // Expected L1 chain ID; 0 skips the check
+	L2ChainID         uint64         // Expected L2 chain ID; 0 skips the check
+	L1Client          ChainClient    // Used instead of dialing L1RpcUrl when set, e.g. a simulated backend
+	L2Client          ChainClient    // Used instead of dialing L2RpcUrl when set
+	L2ArchiveRpcUrls  []string       // Archive endpoints used only for eth_getProof (L2_ARCHIVE_RPC); empty means the L2 ones
+	L2ArchiveClient   ChainClient    // Used instead of dialing L2ArchiveRpcUrls when set
+	SkipStartupChecks bool           // Don't verify chain IDs and contract code on construction
+	L2Confirmations   uint64         // L2 blocks a withdrawal needs, counting its own, before it is trusted; 0 trusts it at once
+	L1Confirmations   uint64         // L1 blocks on top of a prove or finalize before it counts as done; 0 trusts the first receipt
+	SimulationFrom    common.Address // Sender for offline gas estimates (SIMULATION_FROM); zero means the wallet, else the withdrawal's L2 sender
+	Contracts         CrossChainContracts
+	Explorer          Explorer       // Block explorer links in logs and results; empty URLs mean none
+	Signer            SignerConfig   // Default signer
+	Signers           []SignerConfig // Additional signers, picked by wallet address (SubmitOptions.From)
+	KMS               KMSSettings    // AWS settings for KMS signers
+	Gas               GasSettings
+	Cost              CostSettings // Finalize cost guard
+	Timeouts          Timeouts
+	Retry             RetryPolicy
+	RateLimit         RateLimit // Requests per second to each dialed RPC endpoint; zero means unlimited
+	Logger            Logger
+	Progress          ProgressFunc // Called at each stage of ProveMessage, FinalizeMessage and WaitForStatus; nil reports nothing
+	LockDir           string       // Directory of per-withdrawal locks against double submission (LOCK_DIR); empty disables
+
+	// Stuck transaction replacement
+	ReplacementTimeout time.Duration // Re-submit with bumped fees after this long unmined; 0 disables
+	FeeBumpPercent     int           // Fee increase per replacement (minimum 10)
+	MaxFeeGwei         float64       // Ceiling for bumped max fee per gas; 0 means Gas.MaxFee (MAX_FEE_GWEI), if set
+}
+
+// DefaultContracts returns the Mantle mainnet contract addresses
+func DefaultContracts() CrossChainContracts {
+	return CrossChainContracts{
+		L1: L1Contracts{
+			StateCommitmentChain:      "0x0000000000000000000000000000000000000000",
+			CanonicalTransactionChain: "0x0000000000000000000000000000000000000000",
+			BondManager:               "0x0000000000000000000000000000000000000000",
+			AddressManager:            "0x6968f3F16C3e64003F02E121cf0D5CCBf5625a42",
+			L1CrossDomainMessenger:    "0x676A795fe6E43C17c668de16730c3F690FEB7120",
+			L1StandardBridge:          "0x95fC37A27a2f68e3A647CDc081F0A89bb47c3012",
+			OptimismPortal:            "0xc54cb22944F2bE476E02dECfCD7e3E7d3e15A8Fb",
+			L2OutputOracle:            "0x31d543e7BE1dA6eFDc2206Ef7822879045B9f481",
+		},
+		Bridges: BridgeContracts{
+			L1Bridge:               "0x95fC37A27a2f68e3A647CDc081F0A89bb47c3012",
+			L2Bridge:               "0x4200000000000000000000000000000000000010",
+			L2CrossDomainMessenger: "0x4200000000000000000000000000000000000007",
+			L2ToL1MessagePasser:    "0x4200000000000000000000000000000000000016",
+		},
+	}
+}
+
+// ContractsFromEnv returns the default contracts with any environment overrides applied
+func ContractsFromEnv() CrossChainContracts {
+	return contractsFromEnv(DefaultContracts())
+}
+
+// contractsFromEnv returns c with any environment overrides applied
+func contractsFromEnv(c CrossChainContracts) CrossChainContracts {
+	c.L1.StateCommitmentChain = getEnvOrDefault("L1_STATE_COMMITMENT_CHAIN", c.L1.StateCommitmentChain)
+	c.L1.CanonicalTransactionChain = getEnvOrDefault("L1_CANONICAL_TRANSACTION_CHAIN", c.L1.CanonicalTransactionChain)
+	c.L1.BondManager = getEnvOrDefault("L1_BOND_MANAGER", c.L1.BondManager)
+	c.L1.AddressManager = getEnvOrDefault("L1_ADDRESS_MANAGER", c.L1.AddressManager)
+	c.L1.L1CrossDomainMessenger = getEnvOrDefault("L1_CROSS_DOMAIN_MESSENGER", c.L1.L1CrossDomainMessenger)
+	c.L1.L1StandardBridge = getEnvOrDefault("L1_STANDARD_BRIDGE", c.L1.L1StandardBridge)
+	c.L1.OptimismPortal = getEnvOrDefault("L1_OPTIMISM_PORTAL", c.L1.OptimismPortal)
+	c.L1.L2OutputOracle = getEnvOrDefault("L2_OUTPUT_ORACLE", c.L1.L2OutputOracle)
+	c.Bridges.L1Bridge = getEnvOrDefault("L1_BRIDGE", c.Bridges.L1Bridge)
+	c.Bridges.L2Bridge = getEnvOrDefault("L2_BRIDGE", c.Bridges.L2Bridge)
+	c.Bridges.L2CrossDomainMessenger = getEnvOrDefault("L2_CROSS_DOMAIN_MESSENGER", c.Bridges.L2CrossDomainMessenger)
+	c.Bridges.L2ToL1MessagePasser = getEnvOrDefault("L2_TO_L1_MESSAGE_PASSER", c.Bridges.L2ToL1MessagePasser)
+	return c
+}
+
+// MessengerConfigFromEnv builds the config used by CreateCrossChainMessenger from
+// environment variables. Each RPC URL may be a comma-separated list; entries after the
+// first, followed by L1_RPC_FALLBACKS / L2_RPC_FALLBACKS, become fallbacks. NETWORK
+// names the preset (default DefaultNetwork).
+func MessengerConfigFromEnv(l1RpcUrl, l2RpcUrl string) (MessengerConfig, error) {
+	name := os.Getenv("NETWORK")
+	if name == "" {
+		name = DefaultNetwork
+	}
+	network, err := LookupNetwork(name)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	return NetworkMessengerConfigFromEnv(network, l1RpcUrl, l2RpcUrl)
+}
+
+// NetworkMessengerConfigFromEnv is MessengerConfigFromEnv with network's chain IDs and
+// contracts as the defaults that L1_CHAINID, L2_CHAINID and the contract variables override
+func NetworkMessengerConfigFromEnv(network Network, l1RpcUrl, l2RpcUrl string) (MessengerConfig, error) {
+	l1Urls := append(splitRPCURLs(l1RpcUrl), splitRPCURLs(os.Getenv("L1_RPC_FALLBACKS"))...)
+	l2Urls := append(splitRPCURLs(l2RpcUrl), splitRPCURLs(os.Getenv("L2_RPC_FALLBACKS"))...)
+	if len(l1Urls) == 0 {
+		return MessengerConfig{}, fmt.Errorf("L1 RPC URL is not set")
+	}
+	if len(l2Urls) == 0 {
+		return MessengerConfig{}, fmt.Errorf("L2 RPC URL is not set")
+	}
+
+	gasSettings, err := gasSettingsFromEnv()
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	costSettings, err := costSettingsFromEnv()
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+
+	rpcRead, err := durationFromEnv("RPC_READ_TIMEOUT", DefaultRPCReadTimeout)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	proofTimeout, err := durationFromEnv("PROOF_TIMEOUT", DefaultProofTimeout)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	waitMined, err := durationFromEnv("WAIT_MINED_TIMEOUT", DefaultWaitMinedTimeout)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+
+	l1ChainID, err := uint64FromEnv("L1_CHAINID", network.L1ChainID)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	l2ChainID, err := uint64FromEnv("L2_CHAINID", network.L2ChainID)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+
+	l2Confirmations, err := uint64FromEnv("L2_CONFIRMATIONS", DefaultL2Confirmations)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	l1Confirmations, err := uint64FromEnv("L1_CONFIRMATIONS", DefaultL1Confirmations)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+
+	simulationFrom, err := simulationFromEnv()
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+
+	explorer := network.Explorer
+	if l1ChainID != network.L1ChainID || l2ChainID != network.L2ChainID {
+		explorer = ExplorerForChains(l1ChainID, l2ChainID)
+	}
+
+	retry, err := retryPolicyFromEnv()
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	rateLimit, err := rateLimitFromEnv()
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+
+	replacementTimeout, err := durationFromEnv("REPLACEMENT_TIMEOUT", DefaultReplacementTimeout)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	feeBump, err := uint64FromEnv("FEE_BUMP_PERCENT", DefaultFeeBumpPercent)
+	if err != nil {
+		return MessengerConfig{}, err
+	}
+	var maxFeeGwei float64
+	if v := os.Getenv("REPLACEMENT_MAX_FEE_GWEI"); v != "" {
+		maxFeeGwei, err = strconv.ParseFloat(v, 64)
+		if err != nil || maxFeeGwei < 0 {
+			return MessengerConfig{}, fmt.Errorf("invalid REPLACEMENT_MAX_FEE_GWEI %q: must be a non-negative number", v)
+		}
+	}
+
+	signer, signers := signersFromEnv()
+
+	lockDir := os.Getenv("LOCK_DIR")
+	switch lockDir {
+	case "":
+		lockDir = DefaultLockDir()
+	case "off":
+		lockDir = ""
+	}
+
+	return MessengerConfig{
+		L1RpcUrl:         l1Urls[0],
+		L2RpcUrl:         l2Urls[0],
+		L1RpcFallbacks:   l1Urls[1:],
+		L2RpcFallbacks:   l2Urls[1:],
+		L2ArchiveRpcUrls: splitRPCURLs(os.Getenv("L2_ARCHIVE_RPC")),
+		L1ChainID:        l1ChainID,
+		L2ChainID:        l2ChainID,
+		L2Confirmations:  l2Confirmations,
+		L1Confirmations:  l1Confirmations,
+		SimulationFrom:   simulationFrom,
+		Contracts:        contractsFromEnv(network.Contracts),
+		Explorer:         explorerFromEnv(explorer),
+		Signer:           signer,
+		Signers:          signers,
+		KMS: KMSSettings{
+			Region:        os.Getenv("KMS_REGION"),
+			AssumeRoleARN: os.Getenv("KMS_ASSUME_ROLE_ARN"),
+		},
+		Gas:  gasSettings,
+		Cost: costSettings,
+		Timeouts: Timeouts{
+			Dial:            DefaultDialTimeout,
+			RPCRead:         rpcRead,
+			ProofGeneration: proofTimeout,
+			WaitMined:       waitMined,
+		},
+		Retry:              retry,
+		RateLimit:          rateLimit,
+		Logger:             NewLoggerFromEnv(),
+		ReplacementTimeout: replacementTimeout,
+		FeeBumpPercent:     int(feeBump),
+		MaxFeeGwei:         maxFeeGwei,
+		LockDir:            lockDir,
+	}, nil
+}
+
+// signersFromEnv reads KMS_KEY_ID and PRIV_KEY, each a comma-separated list, and
+// SIGNER_RPC_URL with its optional SIGNER_ADDRESS. The first KMS key, else the first
+// private key, else the remote signer is the default signer; the rest are additional
+// signers.
+func signersFromEnv() (SignerConfig, []SignerConfig) {
+	var all []SignerConfig
+	for _, id := range splitEnvList("KMS_KEY_ID") {
+		all = append(all, SignerConfig{KMSKeyID: id})
+	}
+	for _, key := range splitEnvList("PRIV_KEY") {
+		all = append(all, SignerConfig{PrivateKey: key})
+	}
+	if url := os.Getenv("SIGNER_RPC_URL"); url != "" {
+		all = append(all, SignerConfig{RemoteURL: url, RemoteAddress: os.Getenv("SIGNER_ADDRESS")})
+	}
+	if len(all) == 0 {
+		return SignerConfig{}, nil
+	}
+	return all[0], all[1:]
+}
+
+// splitEnvList parses a comma-separated environment variable, dropping empty entries
+func splitEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// retryPolicyFromEnv reads RPC_MAX_ATTEMPTS and RPC_CALL_TIMEOUT on top of DefaultRetryPolicy
+func retryPolicyFromEnv() (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+
+	attempts, err := uint64FromEnv("RPC_MAX_ATTEMPTS", uint64(policy.MaxAttempts))
+	if err != nil {
+		return policy, err
+	}
+	if attempts < 1 {
+		return policy, fmt.Errorf("invalid RPC_MAX_ATTEMPTS %d: must be at least 1", attempts)
+	}
+	policy.MaxAttempts = int(attempts)
+
+	policy.CallTimeout, err = durationFromEnv("RPC_CALL_TIMEOUT", policy.CallTimeout)
+	if err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
+
+// durationFromEnv parses a Go duration string (e.g. "10m") from the environment
+func durationFromEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a duration like 30s or 10m", key, v)
+	}
+	return d, nil
+}
+
+// uint64FromEnv parses an unsigned integer from the environment
+func uint64FromEnv(key string, defaultValue uint64) (uint64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: expected a non-negative integer", key, v)
+	}
+	return n, nil
+}
+
+// replacementPolicy converts the config's replacement settings for the messenger. Without
+// a ceiling of their own, replacements stay under Gas.MaxFee like the first send.
+func (cfg MessengerConfig) replacementPolicy() (ReplacementPolicy, error) {
+	if cfg.FeeBumpPercent != 0 && cfg.FeeBumpPercent < minFeeBumpPercent {
+		return ReplacementPolicy{}, fmt.Errorf("fee bump of %d%% is too small: nodes require at least %d%%", cfg.FeeBumpPercent, minFeeBumpPercent)
+	}
+	policy := ReplacementPolicy{
+		Timeout:        cfg.ReplacementTimeout,
+		FeeBumpPercent: cfg.FeeBumpPercent,
+	}
+	if policy.FeeBumpPercent == 0 {
+		policy.FeeBumpPercent = DefaultFeeBumpPercent
+	}
+	if cfg.MaxFeeGwei > 0 {
+		maxFee, err := parseGwei(strconv.FormatFloat(cfg.MaxFeeGwei, 'f', -1, 64))
+		if err != nil {
+			return ReplacementPolicy{}, err
+		}
+		policy.MaxFee = maxFee
+	} else if cfg.Gas.MaxFee != nil {
+		policy.MaxFee = new(big.Int).Set(cfg.Gas.MaxFee)
+	}
+	return policy, nil
+}
+
+// withOptionalTimeout derives a context with a timeout, or a plain cancelable context when timeout is zero
+func withOptionalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,46 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultL2Confirmations is how many L2 blocks, counting its own, a withdrawal needs
+// before its receipt is trusted (L2_CONFIRMATIONS)
+const DefaultL2Confirmations uint64 = 50
+
+// DefaultL1Confirmations is how many L1 blocks must be built on top of a prove or
+// finalize before it counts as done (L1_CONFIRMATIONS)
+const DefaultL1Confirmations uint64 = 3
+
+// checkConfirmations returns a ConfirmationsError while message's L2 block is fewer
+// than m.L2Confirmations blocks deep, so a withdrawal that a sequencer reorg could
+// still drop isn't proven against a block that gets replaced
+func (m *CrossChainMessenger) checkConfirmations(ctx context.Context, message Message) error {
+	if m.L2Confirmations == 0 {
+		return nil
+	}
+	head, err := m.GetLatestL2Block(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the L2 head: %w", err)
+	}
+	var confirmations uint64
+	if head >= message.BlockNumber {
+		confirmations = head - message.BlockNumber + 1
+	}
+	if confirmations >= m.L2Confirmations {
+		return nil
+	}
+	return &ConfirmationsError{
+		TxHash:        message.TxHash,
+		Block:         message.BlockNumber,
+		Confirmations: confirmations,
+		Required:      m.L2Confirmations,
+	}
+}
+
+// UnconfirmedState is the decision table state of a withdrawal GetMessages rejected with
+// err: not provable until ConfirmationsNeeded more L2 blocks are built
+func UnconfirmedState(err *ConfirmationsError) WithdrawalState {
+	return WithdrawalState{Status: StatusReadyToProve, ConfirmationsNeeded: err.Remaining()}
+}
@@ -0,0 +1,48 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// headL2 is an L2 node at block head
+type headL2 struct {
+	EthClient
+	head uint64
+}
+
+func (f *headL2) BlockNumber(ctx context.Context) (uint64, error) { return f.head, nil }
+
+func TestCheckConfirmations(t *testing.T) {
+	tests := []struct {
+		name     string
+		required uint64
+		block    uint64
+		head     uint64
+		passes   bool
+		want     uint64 // Confirmations in the error
+	}{
+		{"disabled", 0, 1000, 990, true, 0},
+		{"exactly deep enough", 50, 1000, 1049, true, 0},
+		{"deeper", 50, 1000, 2000, true, 0},
+		{"one short", 50, 1000, 1048, false, 49},
+		{"in the head block", 50, 1000, 1000, false, 1},
+		{"head behind the receipt", 50, 1000, 990, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &CrossChainMessenger{ClientL2: &headL2{head: tt.head}, L2Confirmations: tt.required}
+			err := m.checkConfirmations(context.Background(), Message{TxHash: "0xabc", BlockNumber: tt.block})
+			var confErr *ConfirmationsError
+			switch {
+			case tt.passes && err != nil:
+				t.Fatalf("checkConfirmations() = %v, want nil", err)
+			case !tt.passes && !errors.As(err, &confErr):
+				t.Fatalf("checkConfirmations() = %v, want a ConfirmationsError", err)
+			case !tt.passes && (confErr.Confirmations != tt.want || confErr.Required != tt.required || confErr.Remaining() != tt.required-tt.want):
+				t.Fatalf("ConfirmationsError = %+v (remaining %d), want %d of %d", confErr, confErr.Remaining(), tt.want, tt.required)
+			}
+		})
+	}
+}
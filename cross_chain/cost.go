@@ -0,0 +1,290 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultETHUSDPriceFeed is the Chainlink ETH/USD aggregator on Ethereum mainnet
+const DefaultETHUSDPriceFeed = "0x5f4eC3Df9cbd43714FE2740f5E3616155c5b8419"
+
+// priceFeedMaxAge rejects a Chainlink answer older than this; the ETH/USD feed updates
+// at least hourly
+const priceFeedMaxAge = 2 * time.Hour
+
+// chainlinkAggregatorABI covers the AggregatorV3Interface calls used to read a price
+const chainlinkAggregatorABI = `[
+	{"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"stateMutability":"view","type":"function"},
+	{"inputs":[],"name":"latestRoundData","outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}
+]`
+
+// CostSettings guards finalization against transactions that cost more than the
+// withdrawal is worth. Both limits are off by default.
+type CostSettings struct {
+	MaxFinalizeCostUSD float64        // MAX_FINALIZE_COST_USD; 0 means no limit
+	MinValueRatio      float64        // MIN_VALUE_RATIO; withdrawal value must be at least this multiple of the cost; 0 means no limit
+	ETHPriceUSD        float64        // ETH_PRICE_USD; static price, used instead of PriceFeed when set
+	PriceFeed          common.Address // ETH_USD_PRICE_FEED; Chainlink ETH/USD aggregator read through ClientL1
+}
+
+// Enabled reports whether a limit is configured
+func (c CostSettings) Enabled() bool {
+	return c.MaxFinalizeCostUSD > 0 || c.MinValueRatio > 0
+}
+
+// costSettingsFromEnv reads MAX_FINALIZE_COST_USD, MIN_VALUE_RATIO, ETH_PRICE_USD and
+// ETH_USD_PRICE_FEED
+func costSettingsFromEnv() (CostSettings, error) {
+	settings := CostSettings{PriceFeed: common.HexToAddress(DefaultETHUSDPriceFeed)}
+
+	for _, f := range []struct {
+		key   string
+		value *float64
+	}{
+		{"MAX_FINALIZE_COST_USD", &settings.MaxFinalizeCostUSD},
+		{"MIN_VALUE_RATIO", &settings.MinValueRatio},
+		{"ETH_PRICE_USD", &settings.ETHPriceUSD},
+	} {
+		v := os.Getenv(f.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || n < 0 {
+			return settings, fmt.Errorf("invalid %s %q: must be a non-negative number", f.key, v)
+		}
+		*f.value = n
+	}
+	if v := strings.TrimSpace(os.Getenv("ETH_USD_PRICE_FEED")); v != "" {
+		if !common.IsHexAddress(v) {
+			return settings, fmt.Errorf("invalid ETH_USD_PRICE_FEED %q: must be an address", v)
+		}
+		settings.PriceFeed = common.HexToAddress(v)
+	}
+	return settings, nil
+}
+
+// FinalizeCost is the estimated L1 cost of finalizing a withdrawal
+type FinalizeCost struct {
+	GasLimit    uint64
+	FeePerGas   *big.Int // Current base fee plus priority fee (or the legacy gas price), in wei
+	CostWei     *big.Int
+	ETHPriceUSD float64
+	CostUSD     float64
+	ValueUSD    float64 // Withdrawal value; only set when ValueKnown
+	ValueKnown  bool    // Only ETH withdrawals can be priced
+}
+
+// ethPriceUSD returns ETH_PRICE_USD, or else the latest answer of the Chainlink feed
+func (m *CrossChainMessenger) ethPriceUSD(ctx context.Context) (float64, error) {
+	if m.Cost.ETHPriceUSD > 0 {
+		return m.Cost.ETHPriceUSD, nil
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(chainlinkAggregatorABI))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse price feed ABI: %w", err)
+	}
+	feed := bind.NewBoundContract(m.Cost.PriceFeed, parsed, m.ClientL1, nil, nil)
+
+	decimals, err := withRetry(ctx, m, "price feed decimals", func(ctx context.Context) ([]interface{}, error) {
+		var out []interface{}
+		err := feed.Call(&bind.CallOpts{Context: ctx}, &out, "decimals")
+		return out, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read decimals of price feed %s: %w", m.Cost.PriceFeed.Hex(), err)
+	}
+	round, err := withRetry(ctx, m, "price feed latestRoundData", func(ctx context.Context) ([]interface{}, error) {
+		var out []interface{}
+		err := feed.Call(&bind.CallOpts{Context: ctx}, &out, "latestRoundData")
+		return out, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read price feed %s: %w", m.Cost.PriceFeed.Hex(), err)
+	}
+
+	answer := round[1].(*big.Int)
+	updatedAt := time.Unix(round[3].(*big.Int).Int64(), 0)
+	if answer.Sign() <= 0 {
+		return 0, fmt.Errorf("price feed %s returned %s", m.Cost.PriceFeed.Hex(), answer)
+	}
+	if age := time.Since(updatedAt); age > priceFeedMaxAge {
+		return 0, fmt.Errorf("price feed %s was last updated %s ago", m.Cost.PriceFeed.Hex(), age.Round(time.Minute))
+	}
+	price, _ := new(big.Float).Quo(new(big.Float).SetInt(answer),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals[0].(uint8))), nil))).Float64()
+	return price, nil
+}
+
+// withdrawalValueETH returns the ETH a withdrawal releases on L1, or nil when it carries
+// a token this package can't price
+func withdrawalValueETH(message Message) *big.Int {
+	if t := message.TokenWithdrawal; t != nil {
+		if t.Kind == TokenKindETH {
+			return t.Amount
+		}
+		return nil
+	}
+	if message.EthValue != nil && message.EthValue.Sign() > 0 {
+		return message.EthValue
+	}
+	return nil
+}
+
+// EstimateFinalizeCost estimates the L1 cost of finalizing message from the wallet from
+// (zero estimates from the zero address) at the current base fee plus priority fee, and
+// prices it and the withdrawal in USD
+func (m *CrossChainMessenger) EstimateFinalizeCost(ctx context.Context, message Message, from common.Address) (_ *FinalizeCost, err error) {
+	ctx, span := startSpan(ctx, "EstimateFinalizeCost", attrTxHash.String(message.TxHash), attrWithdrawalHash.String(message.WithdrawalHash))
+	defer func() { endSpan(span, err) }()
+	withdrawalTx, err := withdrawalTransaction(message)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := packOptimismPortalCall("finalizeWithdrawalTransaction", withdrawalTx)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit := m.Gas.GasLimit
+	if gasLimit == 0 {
+		portal := common.HexToAddress(m.Contracts.L1.OptimismPortal)
+		gasLimit, err = m.ClientL1.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &portal, Data: calldata})
+		if err != nil {
+			if isRevert(err) {
+				return nil, fmt.Errorf("gas estimation failed: %w", wrapContractError(ContractOptimismPortal, err))
+			}
+			return nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+	}
+
+	var feePerGas *big.Int
+	if m.Gas.Legacy {
+		if feePerGas, err = m.ClientL1.SuggestGasPrice(ctx); err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %w", err)
+		}
+	} else {
+		tip, err := m.priorityFee(ctx)
+		if err != nil {
+			return nil, err
+		}
+		head, err := m.ClientL1.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest L1 header: %w", err)
+		}
+		feePerGas = new(big.Int).Set(tip)
+		if head.BaseFee != nil {
+			feePerGas.Add(feePerGas, head.BaseFee)
+		}
+	}
+	if m.Gas.MaxFee != nil && feePerGas.Cmp(m.Gas.MaxFee) > 0 {
+		feePerGas.Set(m.Gas.MaxFee)
+	}
+
+	price, err := m.ethPriceUSD(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cost := &FinalizeCost{
+		GasLimit:    gasLimit,
+		FeePerGas:   feePerGas,
+		CostWei:     new(big.Int).Mul(feePerGas, new(big.Int).SetUint64(gasLimit)),
+		ETHPriceUSD: price,
+	}
+	cost.CostUSD = weiToUSD(cost.CostWei, price)
+	if value := withdrawalValueETH(message); value != nil {
+		cost.ValueUSD = weiToUSD(value, price)
+		cost.ValueKnown = true
+	}
+	return cost, nil
+}
+
+// weiToUSD converts a wei amount to USD at price per ETH
+func weiToUSD(wei *big.Int, price float64) float64 {
+	eth, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return eth * price
+}
+
+// CheckFinalizeCost estimates the cost of finalizing message and fails with a
+// CostTooHighError when it breaks MaxFinalizeCostUSD or MinValueRatio. Without limits
+// configured it does nothing and returns a nil cost.
+func (m *CrossChainMessenger) CheckFinalizeCost(ctx context.Context, message Message, from common.Address) (*FinalizeCost, error) {
+	if !m.Cost.Enabled() {
+		return nil, nil
+	}
+	cost, err := m.EstimateFinalizeCost(ctx, message, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate finalize cost: %w", err)
+	}
+
+	value := "unknown (not an ETH withdrawal)"
+	if cost.ValueKnown {
+		value = fmt.Sprintf("~$%.2f", cost.ValueUSD)
+	}
+	m.logger().Infof("💵 Finalize cost: ~$%.2f (%s ETH at $%.2f/ETH), withdrawal value: %s",
+		cost.CostUSD, FormatEther(cost.CostWei), cost.ETHPriceUSD, value)
+
+	switch {
+	case m.Cost.MaxFinalizeCostUSD > 0 && cost.CostUSD > m.Cost.MaxFinalizeCostUSD:
+		return cost, &CostTooHighError{Cost: cost, Limit: fmt.Sprintf("MAX_FINALIZE_COST_USD $%.2f", m.Cost.MaxFinalizeCostUSD)}
+	case m.Cost.MinValueRatio > 0 && cost.ValueKnown && cost.ValueUSD < cost.CostUSD*m.Cost.MinValueRatio:
+		return cost, &CostTooHighError{Cost: cost, Limit: fmt.Sprintf("MIN_VALUE_RATIO %g for a ~$%.2f withdrawal", m.Cost.MinValueRatio, cost.ValueUSD)}
+	}
+	return cost, nil
+}
+
+// TxFee is what a mined prove or finalize transaction actually paid on L1
+type TxFee struct {
+	L1TxHash          common.Hash    `json:"l1TxHash"`
+	L1Block           uint64         `json:"l1Block"` // Block the transaction was mined in
+	From              common.Address `json:"from"`
+	GasUsed           uint64         `json:"gasUsed"`
+	EffectiveGasPrice *big.Int       `json:"effectiveGasPrice"`     // Wei per gas, from the receipt
+	Wei               *big.Int       `json:"feeWei"`                // GasUsed × EffectiveGasPrice
+	URL               string         `json:"explorerUrl,omitempty"` // Explorer link for L1TxHash
+}
+
+// ETH formats the fee in ETH
+func (f TxFee) ETH() string {
+	return FormatEther(f.Wei)
+}
+
+// receiptFee reads the fee of a mined transaction sent by from. When from is zero (a
+// transaction resumed from an earlier run with the default signer), the sender is
+// recovered from the transaction itself.
+func (m *CrossChainMessenger) receiptFee(ctx context.Context, receipt *types.Receipt, from common.Address) TxFee {
+	price := receipt.EffectiveGasPrice
+	if price == nil {
+		m.logger().Warnf("⚠️  Receipt of %s has no effectiveGasPrice; reporting a zero fee", receipt.TxHash.Hex())
+		price = new(big.Int)
+	}
+	if from == (common.Address{}) {
+		if tx, _, err := m.ClientL1.TransactionByHash(ctx, receipt.TxHash); err == nil {
+			from, _ = types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		} else {
+			m.logger().Warnf("⚠️  Failed to look up the sender of %s: %v", receipt.TxHash.Hex(), err)
+		}
+	}
+	return TxFee{
+		L1TxHash:          receipt.TxHash,
+		L1Block:           receipt.BlockNumber.Uint64(),
+		From:              from,
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: price,
+		Wei:               new(big.Int).Mul(price, new(big.Int).SetUint64(receipt.GasUsed)),
+		URL:               m.Explorer.L1Tx(receipt.TxHash.Hex()),
+	}
+}
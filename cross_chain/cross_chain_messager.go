@@ -1,163 +1,317 @@
 package crosschain
 
 import (
+	"bytes"
 	"context"
-	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	cross_abi "mantle-claim-crossing/abi"
 	"mantle-claim-crossing/helper"
 	"math/big"
-	"os"
 	"strings"
+	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rlp"
-	kmssigner "github.com/welthee/go-ethereum-aws-kms-tx-signer/v2"
-	"golang.org/x/crypto/sha3"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// CreateCrossChainMessenger creates a new CrossChainMessenger with KMS or private key support
+// CreateCrossChainMessenger creates a new CrossChainMessenger with KMS or private key support,
+// reading contract addresses and signer settings from the environment
 func CreateCrossChainMessenger(l1RpcUrl, l2RpcUrl string) (*CrossChainMessenger, error) {
+	cfg, err := MessengerConfigFromEnv(l1RpcUrl, l2RpcUrl)
+	if err != nil {
+		return nil, err
+	}
+	return NewCrossChainMessenger(cfg)
+}
+
+// NewCrossChainMessenger creates a CrossChainMessenger from an explicit config, so several
+// messengers with different networks or signers can live in one process
+func NewCrossChainMessenger(cfg MessengerConfig) (*CrossChainMessenger, error) {
+	if cfg.L1RpcUrl == "" && cfg.L1Client == nil {
+		return nil, fmt.Errorf("L1 RPC URL is not set")
+	}
+	if cfg.L2RpcUrl == "" && cfg.L2Client == nil {
+		return nil, fmt.Errorf("L2 RPC URL is not set")
+	}
+	if err := checkEventTopics(); err != nil {
+		return nil, err
+	}
+
+	replacement, err := cfg.replacementPolicy()
+	if err != nil {
+		return nil, err
+	}
+
 	messenger := &CrossChainMessenger{
-		L1RpcUrl: l1RpcUrl,
-		L2RpcUrl: l2RpcUrl,
-	}
-	contracts := CrossChainContracts{
-		L1: L1Contracts{
-			StateCommitmentChain:   getEnvOrDefault("L1_STATE_COMMITMENT_CHAIN", "0x0000000000000000000000000000000000000000"),
-			CanonicalTransactionChain: getEnvOrDefault("L1_CANONICAL_TRANSACTION_CHAIN", "0x0000000000000000000000000000000000000000"),
-			BondManager:            getEnvOrDefault("L1_BOND_MANAGER", "0x0000000000000000000000000000000000000000"),
-			AddressManager:         getEnvOrDefault("L1_ADDRESS_MANAGER", "0x6968f3F16C3e64003F02E121cf0D5CCBf5625a42"),
-			L1CrossDomainMessenger: getEnvOrDefault("L1_CROSS_DOMAIN_MESSENGER", "0x676A795fe6E43C17c668de16730c3F690FEB7120"),
-			L1StandardBridge:       getEnvOrDefault("L1_STANDARD_BRIDGE", "0x95fC37A27a2f68e3A647CDc081F0A89bb47c3012"),
-			OptimismPortal:         getEnvOrDefault("L1_OPTIMISM_PORTAL", "0xc54cb22944F2bE476E02dECfCD7e3E7d3e15A8Fb"),
-			L2OutputOracle:         getEnvOrDefault("L2_OUTPUT_ORACLE", "0x31d543e7BE1dA6eFDc2206Ef7822879045B9f481"),
-		},
-		Bridges: BridgeContracts{
-			L1Bridge: getEnvOrDefault("L1_BRIDGE", "0x95fC37A27a2f68e3A647CDc081F0A89bb47c3012"),
-			L2Bridge: getEnvOrDefault("L2_BRIDGE", "0x4200000000000000000000000000000000000010"),
-			L2CrossDomainMessenger:  getEnvOrDefault("L2_CROSS_DOMAIN_MESSENGER", "0x4200000000000000000000000000000000000007"),
-			L2ToL1MessagePasser: getEnvOrDefault("L2_TO_L1_MESSAGE_PASSER", "0x4200000000000000000000000000000000000016"),
-		},
-	}
-	messenger.Contracts = contracts
-	l1Client, err := ethclient.Dial(messenger.L1RpcUrl)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to L1 RPC: %w", err)
-	}
-	messenger.ClientL1 = l1Client
-	l2Client, err := ethclient.Dial(messenger.L2RpcUrl)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to L2 RPC: %w", err)
-	}
-	messenger.ClientL2 = l2Client
-
-	// Check for KMS key ID first
-	kmsKeyID := os.Getenv("KMS_KEY_ID")
-	privateKey := os.Getenv("PRIV_KEY")
-
-	if kmsKeyID != "" {
-		fmt.Println("🔐 Using AWS KMS for signing")
-		
-		// Load AWS config
-		cfg, err := config.LoadDefaultConfig(context.TODO())
+		L1RpcUrl:        cfg.L1RpcUrl,
+		L2RpcUrl:        cfg.L2RpcUrl,
+		Contracts:       cfg.Contracts,
+		Explorer:        cfg.Explorer,
+		L2Confirmations: cfg.L2Confirmations,
+		L1Confirmations: cfg.L1Confirmations,
+		SimulationFrom:  cfg.SimulationFrom,
+		L1ChainID:       cfg.L1ChainID,
+		L2ChainID:       cfg.L2ChainID,
+		Gas:             cfg.Gas,
+		Cost:            cfg.Cost,
+		Timeouts:        cfg.Timeouts,
+		Retry:           cfg.Retry,
+		Replacement:     replacement,
+		Logger:          cfg.Logger,
+		Progress:        cfg.Progress,
+		LockDir:         cfg.LockDir,
+		KMSKeyID:        cfg.Signer.KMSKeyID,
+		PrivateKey:      cfg.Signer.PrivateKey,
+		Signers:         cfg.Signers,
+		KMS:             cfg.KMS,
+	}
+	if cfg.Signer.KMSKeyID == "" && cfg.Signer.PrivateKey == "" && cfg.Signer.RemoteURL != "" {
+		// The messenger keeps only KMS and key signers as its default; a remote one leads Signers
+		messenger.Signers = append([]SignerConfig{cfg.Signer}, cfg.Signers...)
+	}
+
+	dialCtx, cancel := withOptionalTimeout(context.Background(), cfg.Timeouts.Dial)
+	defer cancel()
+	// Dialed endpoints share one limiter per URL; clients passed in are the caller's to limit
+	messenger.rateLimiters = newRateLimiters(cfg.RateLimit, cfg.Logger)
+	messenger.ClientL1 = cfg.L1Client
+	if messenger.ClientL1 == nil {
+		l1Client, err := DialFailover(dialCtx, "L1", append([]string{cfg.L1RpcUrl}, cfg.L1RpcFallbacks...), cfg.Logger)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+			return nil, err
 		}
-
-		// Create KMS client
-		messenger.KMSClient = kms.NewFromConfig(cfg)
-		messenger.KMSKeyID = kmsKeyID
-
-		// Get wallet address from KMS using the library
-		transactor, err := kmssigner.NewAwsKmsTransactorWithChainID(messenger.KMSClient, kmsKeyID, big.NewInt(1))
+		l1Client.limitWith(messenger.rateLimiters)
+		messenger.ClientL1 = l1Client
+	}
+	messenger.ClientL2 = cfg.L2Client
+	if messenger.ClientL2 == nil {
+		l2Client, err := DialFailover(dialCtx, "L2", append([]string{cfg.L2RpcUrl}, cfg.L2RpcFallbacks...), cfg.Logger)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create KMS transactor: %w", err)
+			if cfg.L1Client == nil {
+				messenger.ClientL1.Close()
+			}
+			return nil, err
 		}
-		
-		messenger.WalletAddress = transactor.From.Hex()
-		fmt.Printf("💼 Wallet address: %s\n", messenger.WalletAddress)
-	} else if privateKey != "" {
-		fmt.Println("🔑 Using private key for signing")
-		messenger.PrivateKey = privateKey
-		
-		// Get wallet address from private key
-		address, err := messenger.getWalletAddressFromPrivateKey()
+		l2Client.limitWith(messenger.rateLimiters)
+		messenger.ClientL2 = l2Client
+	}
+	messenger.ClientL2Archive = cfg.L2ArchiveClient
+	if messenger.ClientL2Archive == nil && len(cfg.L2ArchiveRpcUrls) > 0 {
+		archiveClient, err := DialFailover(dialCtx, "L2 archive", cfg.L2ArchiveRpcUrls, cfg.Logger)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get wallet address from private key: %w", err)
+			if cfg.L1Client == nil {
+				messenger.ClientL1.Close()
+			}
+			if cfg.L2Client == nil {
+				messenger.ClientL2.Close()
+			}
+			return nil, err
 		}
-		messenger.WalletAddress = address
-		fmt.Printf("💼 Wallet address: %s\n", address)
-	} else {
-		return nil, fmt.Errorf("either KMS_KEY_ID or PRIV_KEY environment variable must be set")
+		archiveClient.limitWith(messenger.rateLimiters)
+		messenger.ClientL2Archive = archiveClient
+	}
+
+	// Catch swapped or wrong-network endpoints now rather than as cryptic call failures later
+	if !cfg.SkipStartupChecks {
+		if err := messenger.verifyChainIDs(dialCtx, cfg.L1ChainID, cfg.L2ChainID); err != nil {
+			return nil, err
+		}
+		if err := messenger.verifyContractCode(dialCtx); err != nil {
+			return nil, err
+		}
+		if err := messenger.verifyKMSKeys(dialCtx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Signing credentials are only recorded here; the KMS client and keys are set up
+	// lazily by getTransactOpts so read-only commands work without credentials
+	switch signers := messenger.signerConfigs(); {
+	case len(signers) > 1:
+		messenger.logger().Infof("🔐 Using %d signers; the first is the default", len(signers))
+	case messenger.KMSKeyID != "":
+		messenger.logger().Infof("🔐 Using AWS KMS for signing")
+	case messenger.PrivateKey != "":
+		messenger.logger().Infof("🔑 Using private key for signing")
+	case len(signers) == 1:
+		messenger.logger().Infof("🔏 Using remote signer at %s", redactURL(signers[0].RemoteURL))
+	default:
+		messenger.logger().Infof("👀 No signing credentials configured (read-only mode)")
 	}
 
 	return messenger, nil
 }
 
 // CheckMessageStatus checks the status of a cross-chain message
-func (m *CrossChainMessenger) CheckMessageStatus(ctx context.Context, txHash string, messageIndex int) error {
-	fmt.Println("\n=== CHECK MESSAGE STATUS ===")
-	fmt.Printf("🔍 Checking transaction: %s\n", txHash)
-	fmt.Printf("📍 Message index: %d\n", messageIndex)
+func (m *CrossChainMessenger) CheckMessageStatus(ctx context.Context, txHash string, messageIndex int) (err error) {
+	ctx, span := startSpan(ctx, "CheckMessageStatus", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return err
+	}
+	m.logger().Infof("\n=== CHECK MESSAGE STATUS ===")
+	m.logger().Infof("🔍 Checking transaction: %s", txHash)
+	m.logger().Infof("📍 Message index: %d", messageIndex)
 
 	message, err := m.getMessages(ctx, txHash)
-	if err != nil {
+	var confErr *ConfirmationsError
+	if err != nil && !errors.As(err, &confErr) {
 		return fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	fmt.Printf("\n📋 Message Details:\n")
-	fmt.Printf("  Transaction Hash: %s\n", message.TxHash)
-	fmt.Printf("  Block Number: %d\n", message.BlockNumber)
-	fmt.Printf("  Log Index: %d\n", message.LogIndex)
-	fmt.Printf("  Direction: %s\n", message.Direction)
-	
+	m.logger().Infof("\n📋 Message Details:")
+	m.logger().Infof("  Transaction Hash: %s", message.TxHash)
+	if link := m.Explorer.L2Tx(message.TxHash); link != "" {
+		m.logger().Infof("  Explorer: %s", link)
+	}
+	m.logger().Infof("  Block Number: %d", message.BlockNumber)
+	m.logger().Infof("  Log Index: %d", message.LogIndex)
+	m.logger().Infof("  Direction: %s", message.Direction)
+	m.logger().Infof("  Message Nonce: %s (version %d)", message.MsgNonceDecoded, message.MessageVersion)
+	values := m.WithdrawalValues(ctx, message)
+	m.logger().Infof("  Value: %s", values)
+	if t := message.TokenWithdrawal; t != nil {
+		m.logger().Infof("  Bridge Method: %s", t.Method)
+		if t.Kind == TokenKindERC20 {
+			m.logger().Infof("  L1 Token: %s", t.L1Token.Hex())
+			m.logger().Infof("  L2 Token: %s", t.L2Token.Hex())
+		}
+		m.logger().Infof("  Amount: %s", values.Token)
+		m.logger().Infof("  From: %s", t.From.Hex())
+		m.logger().Infof("  To: %s", t.To.Hex())
+	}
+	if confErr != nil {
+		m.logger().Infof("  Confirmations: %d/%d (waiting for %d more L2 blocks before the receipt is trusted)",
+			confErr.Confirmations, confErr.Required, confErr.Remaining())
+		return nil
+	}
 
-	fmt.Printf("  Status: %d (%s)\n", message.Status, getStatusDescription(message.Status))
+	m.logger().Infof("  Status: %d (%s)", message.Status, StatusDescription(message.Status))
+	if message.Status == StatusRelayFailed {
+		m.logger().Infof("  Relayed Message: failed on L1; replay it through L1CrossDomainMessenger %s", m.Contracts.L1.L1CrossDomainMessenger)
+	}
+	if !FinalizedAtPortal(message.Status) {
+		m.logger().Infof("  Finalization Period: %s", m.FinalizationParams(ctx))
+	}
+	m.warnIfPaused(ctx, message)
+	if p := message.Provenance; p != nil && message.Status == StatusProven {
+		m.logger().Infof("  Proven Against: output #%d (root %s)", p.OutputIndex, p.OutputRoot.Hex())
+	}
+
+	if message.Status == StatusReadyToProve {
+		estimate, err := m.EstimateOutputWait(ctx, message.BlockNumber)
+		if err != nil {
+			m.logger().Warnf("⚠️  Failed to estimate output proposal: %v", err)
+		} else if !estimate.Covered() {
+			m.logger().Infof("  Output: %s", estimate.Describe())
+			m.logger().Infof("  Waiting for output: %d more L2 blocks (latest proposed: %d)",
+				estimate.RemainingBlocks, estimate.LatestProposedBlock)
+			m.logger().Infof("  Provable in: %s", estimate)
+		}
+	}
 
 	return nil
 }
 
 // GetMessages retrieves cross-chain messages from a transaction (exported for external use)
-func (m *CrossChainMessenger) GetMessages(ctx context.Context, txHash string) (Message, error) {
+func (m *CrossChainMessenger) GetMessages(ctx context.Context, txHash string) (_ Message, err error) {
+	ctx, span := startSpan(ctx, "GetMessages", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
 	return m.getMessages(ctx, txHash)
 }
 
+// GetMessageLocal parses the withdrawal in txHash from its L2 receipt alone. It makes no
+// L1 calls, so Status stays StatusReadyToProve and Provenance nil; use it when only the
+// withdrawal's contents and hash are needed, e.g. while the L1 RPC is down.
+func (m *CrossChainMessenger) GetMessageLocal(ctx context.Context, txHash string) (_ Message, err error) {
+	ctx, span := startSpan(ctx, "GetMessageLocal", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
+	return m.getMessageLocal(ctx, txHash)
+}
+
 // getMessages retrieves cross-chain messages from a transaction
 func (m *CrossChainMessenger) getMessages(ctx context.Context, txHash string) (Message, error) {
-	fmt.Printf("🔍 Getting transaction receipt for: %s\n", txHash)
+	message, err := m.getMessageLocal(ctx, txHash)
+	if err != nil {
+		return message, err
+	}
+	// The parsed message comes back with the error so callers can still show it
+	if err := m.checkConfirmations(ctx, message); err != nil {
+		return message, err
+	}
+
+	status, err := m.getMessageStatus(ctx, &message)
+	if err != nil {
+		return message, err
+	}
+	message.Status = status
+
+	return message, nil
+}
+
+// getMessageLocal parses and verifies the withdrawal from the L2 receipt, without its status
+func (m *CrossChainMessenger) getMessageLocal(ctx context.Context, txHash string) (Message, error) {
+	if err := ValidateTxHash(txHash); err != nil {
+		return Message{}, err
+	}
+	m.logger().Debugf("🔍 Getting transaction receipt for: %s", txHash)
 
 	// Get transaction receipt from L2
 	receipt, err := m.getTransactionReceipt(ctx, txHash, "L2")
-	
 	if err != nil {
 		return Message{}, fmt.Errorf("failed to get transaction receipt: %w", err)
 	}
 
 	// Parse logs to find cross-chain messages using enhanced parsing
-	message, err := m.parseSentMessageLogsEnhanced(receipt)
+	_, parseSpan := startSpan(ctx, "parseLogs", attrTxHash.String(txHash), attrLogs.Int(len(receipt.Logs)))
+	logs := indexLogs(receipt)
+	message, err := m.parseSentMessageLogsEnhanced(receipt, logs)
 	if err != nil {
+		endSpan(parseSpan, err)
 		return message, fmt.Errorf("failed to parse logs: %w", err)
 	}
-	
-	messagePassed, err := m.parseMessagePassedLogsEnhanced(receipt)
-	message.MessagePassedEvent = messagePassed
+
+	messagePassed, err := m.parseMessagePassedLogsEnhanced(logs)
+	endSpan(parseSpan, err)
 	if err != nil {
 		return message, fmt.Errorf("failed to parse parseMessagePassedLogsEnhanced: %w", err)
 	}
-	message.MsgNonce = messagePassed.Nonce
+	// Every withdrawal goes through the message passer, with or without SentMessage
+	if messagePassed == nil && message.TxHash != "" {
+		return Message{}, fmt.Errorf("%w: %s has a SentMessage event but no MessagePassed event from L2ToL1MessagePasser %s",
+			ErrMissingMessagePassed, txHash, m.Contracts.Bridges.L2ToL1MessagePasser)
+	}
+	if messagePassed == nil {
+		return Message{}, &NoWithdrawalFoundError{TxHash: txHash, Checked: []string{
+			"L2CrossDomainMessenger " + m.Contracts.Bridges.L2CrossDomainMessenger,
+			"L2ToL1MessagePasser " + m.Contracts.Bridges.L2ToL1MessagePasser,
+		}}
+	}
+	if message.TxHash == "" {
+		// Sent straight to the message passer, without the cross-domain messenger
+		message.TxHash = receipt.TxHash.Hex()
+		message.BlockNumber = receipt.BlockNumber.Uint64()
+		message.Direction = "L2_TO_L1"
+	}
+	message.MessagePassedEvent = messagePassed
+	message.TokenWithdrawal = m.decodeTokenWithdrawal(message.SentMessageEvent)
+	message.MsgNonceRaw = messagePassed.Nonce
+	message.MsgNonceDecoded, message.MessageVersion = DecodeVersionedNonce(messagePassed.Nonce)
 	message.WithdrawalHash = hex.EncodeToString(messagePassed.WithdrawalHash[:])
-	message.SentMessageExtension1Event, err = m.parseSentMessageExtension1LogsEnhanced(receipt)
+	annotateSpan(ctx, attrWithdrawalHash.String(message.WithdrawalHash))
+	message.SentMessageExtension1Event, err = m.parseSentMessageExtension1LogsEnhanced(logs)
+	if err != nil {
+		return message, fmt.Errorf("failed to parse SentMessageExtension1 logs: %w", err)
+	}
 
 	if message.SentMessageExtension1Event != nil {
-		if message.SentMessageExtension1Event.MntValue == nil {	
+		if message.SentMessageExtension1Event.MntValue == nil {
 			message.MntValue = big.NewInt(0)
 		}
 		if message.SentMessageExtension1Event.EthValue == nil {
@@ -170,113 +324,140 @@ func (m *CrossChainMessenger) getMessages(ctx context.Context, txHash string) (M
 			message.EthValue = message.SentMessageExtension1Event.EthValue
 		}
 	} else {
-		// If no SentMessageExtension1 event, default to 0
-		message.MntValue = big.NewInt(0)
-		message.EthValue = big.NewInt(0)
+		// Without SentMessageExtension1, e.g. sent straight to the message passer, the
+		// values are the ones MessagePassed hashed
+		message.MntValue = orZero(messagePassed.MntValue)
+		message.EthValue = orZero(messagePassed.EthValue)
 	}
 
-	
-	status, err := m.getMessageStatus(ctx, &message)
-	if err != nil {
-		fmt.Printf("⚠️  Warning: Failed to get status for message : %v\n", err)
-		
+	if err := verifyWithdrawalHash(message); err != nil {
+		return message, err
 	}
-	message.Status = status
-
 	return message, nil
 }
 
 // getTransactionReceipt fetches transaction receipt from L2
-func (m *CrossChainMessenger) getTransactionReceipt(ctx context.Context, txHash string, network string) (*types.Receipt, error) {
-	var receipt *types.Receipt
-	var err error
+func (m *CrossChainMessenger) getTransactionReceipt(ctx context.Context, txHash string, network string) (receipt *types.Receipt, err error) {
+	ctx, span := startSpan(ctx, "eth_getTransactionReceipt", attrTxHash.String(txHash), attrLayer.String(network))
+	defer func() { endSpan(span, err) }()
 	if network == "L2" {
-		receipt, err = m.ClientL2.TransactionReceipt(ctx, common.HexToHash(txHash))
+		receipt, err = withRetry(ctx, m, "L2 eth_getTransactionReceipt", func(ctx context.Context) (*types.Receipt, error) {
+			return m.ClientL2.TransactionReceipt(ctx, common.HexToHash(txHash))
+		})
 	} else {
-		receipt, err = m.ClientL1.TransactionReceipt(ctx, common.HexToHash(txHash))
+		receipt, err = withRetry(ctx, m, "L1 eth_getTransactionReceipt", func(ctx context.Context) (*types.Receipt, error) {
+			return m.ClientL1.TransactionReceipt(ctx, common.HexToHash(txHash))
+		})
 	}
-	
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction receipt: %w", err)
 	}
-	
+
 	return receipt, nil
 }
 
-
 // getMessageStatus determines the status of a cross-chain message
 func (m *CrossChainMessenger) getMessageStatus(ctx context.Context, message *Message) (int, error) {
-	fmt.Printf("🔍 Getting message status for tx: %s, log: %d\n", message.TxHash, message.LogIndex)
-	
-	fmt.Printf("\n🔍 Trying withdrawal hash method %d: %s\n", 1, message.WithdrawalHash)
-	
-	// Check if message is finalized
-	isFinalized, err := m.checkFinalizationStatus(ctx, message.WithdrawalHash)
-	if err != nil {
-		fmt.Printf("❌ Failed to check finalization status: %v\n", err)
-	} else {
-		fmt.Printf("🏁 Finalization status: %t\n", isFinalized)
-		if isFinalized {
-			fmt.Printf("✅ Found correct withdrawal hash (method %d): %s\n", 1, message.WithdrawalHash)
-			return 2, nil // RELAYED/FINALIZED
-		}
-	}
+	m.logger().Debugf("🔍 Getting message status for tx: %s, log: %d, withdrawal hash: %s",
+		message.TxHash, message.LogIndex, message.WithdrawalHash)
 
-	// Check if message is proven
-	isProven, timeStamp, err := m.checkProvenStatus(ctx, message.WithdrawalHash)
-	
+	// Read finalized and proven state in one round trip
+	// A failed read is not READY_TO_PROVE: to the scheduler that would look like a proof
+	// dropped by a reorg
+	portal, err := m.readPortalWithdrawal(ctx, message.WithdrawalHash)
 	if err != nil {
-		fmt.Printf("❌ Failed to check proven status: %v\n", err)
-	} else {
-		fmt.Printf("✅ Proven status: %t\n", isProven)
-		// proven time + 12 hours can finalize
-		currentTimeStamp := *big.NewInt(getCurrentTimestamp())
-		provenTimePlus12Hours := new(big.Int).Add(timeStamp, big.NewInt(43200))
-		if currentTimeStamp.Cmp(provenTimePlus12Hours) >= 0 && timeStamp.Cmp(big.NewInt(0)) > 0 {
-			fmt.Println("✅ Message can be finalized now.")
-		} else if timeStamp.Cmp(big.NewInt(0)) == 0 {
-			fmt.Println("⏳ Message is not yet proven.")
-		} else {
-			fmt.Println("⏳ Message cannot be finalized yet. Please wait for the challenge period to pass.")
+		return 0, fmt.Errorf("failed to check withdrawal status: %w", err)
+	}
+
+	m.logger().Debugf("🏁 Finalization status: %t", portal.finalized)
+	if portal.finalized {
+		// The portal only records the withdrawal; the message it relayed may still have reverted
+		if msg, ok := RelayedMessage(*message); ok {
+			relay, err := m.checkRelayResult(ctx, msg)
+			if err != nil {
+				m.logger().Warnf("⚠️  Failed to check whether the relayed message succeeded: %v", err)
+			} else if relay == RelayReverted {
+				m.logger().Warnf("❌ Withdrawal %s is finalized but its relayed message failed on L1", message.TxHash)
+				return StatusRelayFailed, nil
+			}
 		}
-		if isProven {
-			return 1, nil // PROVEN
+		return 2, nil // RELAYED/FINALIZED
+	}
+
+	isProven, timeStamp := portal.proven, portal.provenTimestamp
+	if isProven {
+		message.Provenance = &ProofProvenance{
+			OutputIndex: portal.provenOutputIndex,
+			OutputRoot:  portal.provenOutputRoot,
+			ProvenAt:    time.Unix(timeStamp.Int64(), 0),
 		}
 	}
+	m.logger().Debugf("✅ Proven status: %t", isProven)
+	// proven time + the oracle's finalization period can finalize
+	period := m.FinalizationParams(ctx).Period
+	currentTimeStamp := *big.NewInt(getCurrentTimestamp())
+	provenTimePlusPeriod := new(big.Int).Add(timeStamp, big.NewInt(int64(period/time.Second)))
+	if currentTimeStamp.Cmp(provenTimePlusPeriod) >= 0 && timeStamp.Cmp(big.NewInt(0)) > 0 {
+		m.logger().Infof("✅ Message can be finalized now.")
+	} else if timeStamp.Cmp(big.NewInt(0)) == 0 {
+		m.logger().Infof("⏳ Message is not yet proven.")
+	} else {
+		m.logger().Infof("⏳ Message cannot be finalized yet. Please wait for the challenge period to pass.")
+	}
+	if isProven {
+		return 1, nil // PROVEN
+	}
 
 	return 0, nil // READY_TO_PROVE
 }
 
 // checkFinalizationStatus checks if a message is finalized on L1
 func (m *CrossChainMessenger) checkFinalizationStatus(ctx context.Context, withdrawalHash string) (bool, error) {
-
-	op, err := cross_abi.NewOptimismPortal(common.HexToAddress(m.Contracts.L1.OptimismPortal), m.ClientL1)
+	op, err := m.optimismPortal()
 	if err != nil {
 		return false, err
 	}
-	result, err := op.FinalizedWithdrawals(nil, common.HexToHash(withdrawalHash))
+	result, err := withRetry(ctx, m, "finalizedWithdrawals", func(ctx context.Context) (bool, error) {
+		return op.FinalizedWithdrawals(&bind.CallOpts{Context: ctx}, common.HexToHash(withdrawalHash))
+	})
 	if err != nil {
-		return false, err
+		return false, wrapContractError(ContractOptimismPortal, err)
 	}
-	fmt.Printf("📤 checkFinalizationStatus result: %t\n", result)	
+	m.logger().Debugf("📤 checkFinalizationStatus result: %t", result)
 	return result, nil
 }
 
 // checkProvenStatus checks if a message is proven on L1
 func (m *CrossChainMessenger) checkProvenStatus(ctx context.Context, withdrawalHash string) (bool, *big.Int, error) {
-	op, _ := cross_abi.NewOptimismPortal(common.HexToAddress(m.Contracts.L1.OptimismPortal), m.ClientL1)
-	result, err := op.ProvenWithdrawals(nil, common.HexToHash(withdrawalHash))
+	op, err := m.optimismPortal()
 	if err != nil {
 		return false, nil, err
 	}
-	
-	fmt.Printf("📤 checkProvenStatus result: %s\n", result)
+	result, err := withRetry(ctx, m, "provenWithdrawals", func(ctx context.Context) (struct {
+		OutputRoot    [32]byte
+		Timestamp     *big.Int
+		L2OutputIndex *big.Int
+	}, error) {
+		return op.ProvenWithdrawals(&bind.CallOpts{Context: ctx}, common.HexToHash(withdrawalHash))
+	})
+	if err != nil {
+		return false, nil, wrapContractError(ContractOptimismPortal, err)
+	}
+
+	m.logger().Debugf("📤 checkProvenStatus result: %s", result)
 	// If result is all zeros, withdrawal is not proven
 	return common.Bytes2Hex(result.OutputRoot[:]) != "0000000000000000000000000000000000000000000000000000000000000000", result.Timestamp, nil
 }
 
-// CheckProvenStatus is the exported version of checkProvenStatus
-func (m *CrossChainMessenger) CheckProvenStatus(ctx context.Context, withdrawalHash string) (bool, *big.Int, error) {
+// CheckProvenStatus is the exported version of checkProvenStatus. Unlike Message.WithdrawalHash,
+// which the internal callers pass, withdrawalHash must be 0x-prefixed.
+func (m *CrossChainMessenger) CheckProvenStatus(ctx context.Context, withdrawalHash string) (_ bool, _ *big.Int, err error) {
+	ctx, span := startSpan(ctx, "CheckProvenStatus", attrWithdrawalHash.String(withdrawalHash))
+	defer func() { endSpan(span, err) }()
+	if err := ValidateWithdrawalHash(withdrawalHash); err != nil {
+		return false, nil, err
+	}
 	return m.checkProvenStatus(ctx, withdrawalHash)
 }
 
@@ -285,67 +466,218 @@ func (m *CrossChainMessenger) GetWithdrawalHash(message Message) string {
 	return message.WithdrawalHash
 }
 
+// ProveMessage proves a cross-chain message and returns the L1 transaction hash. If
+// opts.PreviousTx was sent by an earlier run, it is awaited instead of proving again.
+func (m *CrossChainMessenger) ProveMessage(ctx context.Context, txHash string, messageIndex int, opts SubmitOptions) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "ProveMessage", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("\n=== PROVE MESSAGE ===")
+	m.logger().Infof("Transaction hash (on L2): %s", txHash)
+	m.logger().Infof("Message index: %d", messageIndex)
 
-// ProveMessage proves a cross-chain message
-func (m *CrossChainMessenger) ProveMessage(ctx context.Context, txHash string, messageIndex int) error {
-	fmt.Println("\n=== PROVE MESSAGE ===")
-	fmt.Printf("Transaction hash (on L2): %s\n", txHash)
-	fmt.Printf("Message index: %d\n", messageIndex)
+	// Fail before generating the proof if we could never submit it
+	if !m.HasSigner() {
+		return common.Hash{}, ErrNoSigner
+	}
+
+	// A prove sent before a restart may already be mined
+	if receipt, err := m.resumeSubmitted(ctx, opts); err != nil || receipt != nil {
+		if err != nil {
+			return opts.PreviousTx, err
+		}
+		opts.mined(m.receiptFee(ctx, receipt, opts.From))
+		m.logger().Infof("✅ Message proved successfully!")
+		return opts.PreviousTx, nil
+	}
 
+	m.progress(ctx, ProgressLoadingMessage, map[string]any{"txHash": txHash})
 	message, err := m.getMessages(ctx, txHash)
 	if err != nil {
-		return fmt.Errorf("failed to get messages: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	fmt.Printf("Message direction: %s\n", message.Direction)
-	fmt.Printf("Message status: %d\n", message.Status)
+	m.logger().Infof("Message direction: %s", message.Direction)
+	m.logger().Infof("Message status: %d", message.Status)
 
-	// Check if already proven
-	if message.Status >= 2 { // TODO 1
-		fmt.Println("✅ Message already proven or finalized")
-		return nil
+	// Check if already finalized
+	if message.Status >= StatusFinalized {
+		m.logger().Infof("✅ Message already finalized")
+		return common.Hash{}, ErrAlreadyFinalized
+	}
+	// No point generating a proof the paused portal would reject
+	if err := m.checkNotPaused(ctx); err != nil {
+		return common.Hash{}, err
+	}
+	release, err := m.lockWithdrawal(ctx, "prove", &message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer release()
+
+	m.logger().Infof("🔄 Starting prove message...")
+
+	m.progress(ctx, ProgressGeneratingProof, map[string]any{"txHash": txHash, "withdrawalHash": "0x" + message.WithdrawalHash})
+	call, err := m.buildProveCallAt(ctx, message, 0, opts.L2BlockHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	m.progress(ctx, ProgressProofReady, map[string]any{
+		"outputIndex": call.outputIndex, "l2BlockNumber": call.l2BlockNumber, "proofNodes": len(call.withdrawalProof)})
+	return m.submitProveCall(ctx, message, call, opts)
+}
+
+// submitProveCall sends call for message and records where it was proven in opts
+func (m *CrossChainMessenger) submitProveCall(ctx context.Context, message Message, call *proveCall, opts SubmitOptions) (common.Hash, error) {
+	// Call proveWithdrawalTransaction
+	m.logger().Infof("\n📤 Calling proveWithdrawalTransaction...")
+	l1TxHash, err := m.callProveWithdrawalTransaction(ctx, message, call.withdrawalTx, call.outputIndex, call.outputRootProof, call.withdrawalProof, opts)
+	if err != nil {
+		// A revert may just mean another relayer proved it first
+		if resolved := m.resolveExternalCompletion(ctx, &message, StatusProven, err); IsExternallyCompleted(resolved) {
+			return l1TxHash, resolved
+		}
+		return l1TxHash, fmt.Errorf("failed to prove withdrawal transaction: %w", err)
+	}
+
+	m.logger().Infof("✅ Message proved successfully!")
+	m.logger().Infof("📌 Proven against output #%d (root %s) in %s", call.outputIndex, call.outputRoot.Hex(), l1TxHash.Hex())
+	opts.proven(ProofProvenance{
+		OutputIndex: call.outputIndex,
+		OutputRoot:  call.outputRoot,
+		ProveTxHash: l1TxHash,
+		ProvenAt:    m.provenTimestamp(ctx, message.WithdrawalHash),
+	})
+	return l1TxHash, nil
+}
+
+// withdrawalTransaction builds the OptimismPortal withdrawal struct from a message's
+// MessagePassed event
+func withdrawalTransaction(message Message) (cross_abi.TypesWithdrawalTransaction, error) {
+	eventData := message.MessagePassedEvent
+	if eventData == nil {
+		return cross_abi.TypesWithdrawalTransaction{}, fmt.Errorf("event data is nil")
 	}
+	return cross_abi.TypesWithdrawalTransaction{
+		Nonce:    message.MsgNonceRaw,
+		Sender:   eventData.Sender,
+		Target:   eventData.Target,
+		MntValue: message.MntValue,
+		EthValue: message.EthValue,
+		GasLimit: eventData.GasLimit,
+		Data:     eventData.Data,
+	}, nil
+}
 
-	fmt.Println("🔄 Starting prove message...")
+// proveCall holds the arguments of an OptimismPortal proveWithdrawalTransaction call
+type proveCall struct {
+	withdrawalTx    cross_abi.TypesWithdrawalTransaction
+	outputIndex     uint64
+	outputRoot      common.Hash // Root posted for outputIndex, which the proof reproduces
+	l2BlockNumber   uint64      // L2 block of the output; the proof is against its state
+	outputRootProof cross_abi.TypesOutputRootProof
+	withdrawalProof [][]byte
+	proof           *WithdrawalProof // What withdrawalProof and outputRootProof were built from
+}
+
+// buildProveCall finds the L2 output covering message, generates the withdrawal proof
+// against it and checks the result reproduces the posted output root
+func (m *CrossChainMessenger) buildProveCall(ctx context.Context, message Message) (*proveCall, error) {
+	return m.buildProveCallAt(ctx, message, 0, common.Hash{})
+}
+
+// buildProveCallAt is buildProveCall against the output proposed for exactly
+// l2BlockNumber; 0 picks the first output covering message. A non-zero blockHash
+// replaces the L2 RPC's block hash in the output root proof.
+func (m *CrossChainMessenger) buildProveCallAt(ctx context.Context, message Message, l2BlockNumber uint64, blockHash common.Hash) (*proveCall, error) {
+	target := message.BlockNumber
+	if l2BlockNumber != 0 {
+		if l2BlockNumber < message.BlockNumber {
+			return nil, fmt.Errorf("L2 block %d is before the withdrawal's block %d", l2BlockNumber, message.BlockNumber)
+		}
+		target = l2BlockNumber
+	}
 
 	// Get L2 output index
 	l2OutputOracleAddress := m.Contracts.L1.L2OutputOracle
-	outputIndex, err := m.getL2OutputIndex(ctx, l2OutputOracleAddress, message.BlockNumber)
+	outputIndex, err := m.getL2OutputIndex(ctx, l2OutputOracleAddress, target)
 	if err != nil {
-		return fmt.Errorf("failed to get L2 output index: %w", err)
+		if revertReasonMatches(err, outputNotProposedReasons) {
+			return nil, fmt.Errorf("%w: %w", ErrOutputNotProposed, err)
+		}
+		return nil, fmt.Errorf("failed to get L2 output index: %w", err)
 	}
-	fmt.Printf("📊 L2 Output Index: %d\n", outputIndex)
+	m.logger().Infof("📊 L2 Output Index: %d", outputIndex)
 
 	// Get L2 output data (output root proof)
 	outputData, err := m.getL2OutputData(ctx, l2OutputOracleAddress, outputIndex)
 	if err != nil {
-		return fmt.Errorf("failed to get L2 output data: %w", err)
+		return nil, fmt.Errorf("failed to get L2 output data: %w", err)
+	}
+	m.logger().Infof("📊 Output Root: %s", common.Bytes2Hex(outputData.OutputRoot[:]))
+	m.logger().Infof("📊 L2 Block Number: %d", outputData.L2BlockNumber)
+	if l2BlockNumber != 0 && outputData.L2BlockNumber.Uint64() != l2BlockNumber {
+		return nil, fmt.Errorf("no L2 output was proposed for block %d; the next one, #%d, is for block %d",
+			l2BlockNumber, outputIndex, outputData.L2BlockNumber.Uint64())
 	}
-	fmt.Printf("📊 Output Root: %s\n", common.Bytes2Hex(outputData.OutputRoot[:]))
-	fmt.Printf("📊 L2 Block Number: %d\n", outputData.L2BlockNumber)
 
+	call, err := m.proveAgainstOutput(ctx, message, outputIndex, outputData, blockHash)
+	if errors.Is(err, ErrOutputRootMismatch) && l2BlockNumber == 0 && blockHash == (common.Hash{}) {
+		return m.proveAgainstOtherOutputs(ctx, message, outputIndex, err)
+	}
+	return call, err
+}
+
+// proveAgainstOutput generates message's withdrawal proof against the state of the
+// output at outputIndex and checks it reproduces the posted root, failing with
+// ErrOutputRootMismatch when it doesn't
+func (m *CrossChainMessenger) proveAgainstOutput(ctx context.Context, message Message, outputIndex uint64, outputData cross_abi.TypesOutputProposal, blockHash common.Hash) (*proveCall, error) {
 	// Parse withdrawal transaction parameters
 	eventData := message.MessagePassedEvent
 	if eventData == nil {
-		return fmt.Errorf("event data is nil")
+		return nil, fmt.Errorf("event data is nil")
 	}
 
 	// Generate withdrawal proof
 	// CRITICAL: The withdrawal must have been included in or before the L2 Output block
 	// We generate the proof using the L2 Output block's state, not the transaction block
-	fmt.Println("\n🔍 Generating withdrawal proof...")
-	fmt.Printf("📍 Transaction block: %d, L2 Output block: %d\n", 
+	m.logger().Infof("\n🔍 Generating withdrawal proof...")
+	m.logger().Debugf("📍 Transaction block: %d, L2 Output block: %d",
 		message.BlockNumber, outputData.L2BlockNumber.Uint64())
-	
+
 	if message.BlockNumber > outputData.L2BlockNumber.Uint64() {
-		return fmt.Errorf("transaction block %d is after L2 output block %d, need to wait for a newer output",
-			message.BlockNumber, outputData.L2BlockNumber.Uint64())
+		wait := "unknown"
+		if estimate, err := m.EstimateOutputWait(ctx, message.BlockNumber); err == nil {
+			wait = estimate.String()
+		}
+		return nil, fmt.Errorf("%w: transaction block %d is after L2 output block %d, need to wait for a newer output (expected in %s)",
+			ErrOutputNotProposed, message.BlockNumber, outputData.L2BlockNumber.Uint64(), wait)
+	}
+
+	proofCtx, cancel := withOptionalTimeout(ctx, m.Timeouts.ProofGeneration)
+	defer cancel()
+	withdrawalProof, err := m.generateWithdrawalProofForBlock(proofCtx, message, outputData.L2BlockNumber.Uint64())
+	if err = asTimeout(ctx, proofCtx, "withdrawal proof generation", m.Timeouts.ProofGeneration, err); err != nil {
+		return nil, fmt.Errorf("failed to generate withdrawal proof: %w", err)
+	}
+
+	// Check the proof the way OptimismPortal will, so a bad proof fails here instead of
+	// in a reverted L1 transaction
+	slot := m.calculateSentMessagesSlot(message.WithdrawalHash)
+	_, verifySpan := startSpan(ctx, "verifyProof", attrWithdrawalHash.String(message.WithdrawalHash))
+	value, err := helper.VerifyStorageProof(withdrawalProof.MessagePasserStorageRoot, slot, withdrawalProof.WithdrawalProof)
+	if err == nil && !bytes.Equal(value, []byte{0x01}) {
+		err = fmt.Errorf("sentMessages value is 0x%x, want 0x01", value)
 	}
-	
-	withdrawalProof, err := m.generateWithdrawalProofForBlock(ctx, message, outputData.L2BlockNumber.Uint64())
+	endSpan(verifySpan, err)
 	if err != nil {
-		return fmt.Errorf("failed to generate withdrawal proof: %w", err)
+		return nil, fmt.Errorf("withdrawal proof failed local verification: %w", err)
 	}
+	m.logger().Infof("✅ Withdrawal proof verified locally")
+	m.logger().Infof("📏 Proof size: %s", MeasureProof(withdrawalProof.WithdrawalProof))
+	m.blockHashOverride(withdrawalProof, blockHash)
 
 	// Build output root proof
 	outputRootProof := cross_abi.TypesOutputRootProof{
@@ -354,29 +686,33 @@ func (m *CrossChainMessenger) ProveMessage(ctx context.Context, txHash string, m
 		MessagePasserStorageRoot: withdrawalProof.MessagePasserStorageRoot,
 		LatestBlockhash:          withdrawalProof.LatestBlockhash,
 	}
-	
-	fmt.Printf("\n📊 Output Root Proof:\n")
-	fmt.Printf("  Version: %x\n", outputRootProof.Version)
-	fmt.Printf("  State Root: %x\n", outputRootProof.StateRoot)
-	fmt.Printf("  Message Passer Storage Root: %x\n", outputRootProof.MessagePasserStorageRoot)
-	fmt.Printf("  Latest Block Hash: %x\n", outputRootProof.LatestBlockhash)
-	
+
+	m.logger().Infof("\n📊 Output Root Proof:")
+	m.logger().Debugf("  Version: %x", outputRootProof.Version)
+	m.logger().Debugf("  State Root: %x", outputRootProof.StateRoot)
+	m.logger().Debugf("  Message Passer Storage Root: %x", outputRootProof.MessagePasserStorageRoot)
+	m.logger().Debugf("  Latest Block Hash: %x", outputRootProof.LatestBlockhash)
+
 	// Calculate and verify the output root
 	// OutputRoot = keccak256(abi.encode(version, stateRoot, messagePasserStorageRoot, latestBlockhash))
 	calculatedOutputRoot := m.calculateOutputRoot(outputRootProof)
-	fmt.Printf("\n🔍 Calculated Output Root: %s\n", common.Bytes2Hex(calculatedOutputRoot[:]))
-	fmt.Printf("🔍 Expected Output Root:   %s\n", common.Bytes2Hex(outputData.OutputRoot[:]))
-	
+	m.logger().Debugf("\n🔍 Calculated Output Root: %s", common.Bytes2Hex(calculatedOutputRoot[:]))
+	m.logger().Debugf("🔍 Expected Output Root:   %s", common.Bytes2Hex(outputData.OutputRoot[:]))
+
 	if calculatedOutputRoot != outputData.OutputRoot {
-		return fmt.Errorf("output root mismatch: calculated %s, expected %s", 
-			common.Bytes2Hex(calculatedOutputRoot[:]), 
-			common.Bytes2Hex(outputData.OutputRoot[:]))
+		diagnosis := fmt.Sprintf("block hash %s from the override is not the one the proposer committed", blockHash.Hex())
+		if blockHash == (common.Hash{}) {
+			diagnosis = m.diagnoseOutputRoot(ctx, outputData.L2BlockNumber.Uint64(), withdrawalProof, outputData.OutputRoot)
+		}
+		return nil, fmt.Errorf("%w: calculated %s, expected %s: %s", ErrOutputRootMismatch,
+			common.Bytes2Hex(calculatedOutputRoot[:]),
+			common.Bytes2Hex(outputData.OutputRoot[:]), diagnosis)
 	}
-	fmt.Println("✅ Output root verification passed!")
+	m.logger().Infof("✅ Output root verification passed!")
 
 	// Build withdrawal transaction
 	withdrawalTx := cross_abi.TypesWithdrawalTransaction{
-		Nonce:    message.MsgNonce,
+		Nonce:    message.MsgNonceRaw,
 		Sender:   eventData.Sender,
 		Target:   eventData.Target,
 		MntValue: message.MntValue,
@@ -385,185 +721,191 @@ func (m *CrossChainMessenger) ProveMessage(ctx context.Context, txHash string, m
 		Data:     eventData.Data,
 	}
 
-	fmt.Printf("\n📋 Withdrawal Transaction:\n")
-	fmt.Printf("  Nonce: %s\n", withdrawalTx.Nonce.String())
-	fmt.Printf("  Sender: %s\n", withdrawalTx.Sender.Hex())
-	fmt.Printf("  Target: %s\n", withdrawalTx.Target.Hex())
-	fmt.Printf("  MNT Value: %s\n", withdrawalTx.MntValue.String())
-	fmt.Printf("  ETH Value: %s\n", withdrawalTx.EthValue.String())
-	fmt.Printf("  Gas Limit: %s\n", withdrawalTx.GasLimit.String())
-	fmt.Printf("  Data Length: %d bytes\n", len(withdrawalTx.Data))
-	fmt.Printf("  Data: %x\n", withdrawalTx.Data)
-	fmt.Println("outputIndex ", outputIndex)
-	// Call proveWithdrawalTransaction
-	fmt.Println("\n📤 Calling proveWithdrawalTransaction...")
-	err = m.callProveWithdrawalTransaction(ctx, withdrawalTx, outputIndex, outputRootProof, withdrawalProof.WithdrawalProof)
-	if err != nil {
-		return fmt.Errorf("failed to prove withdrawal transaction: %w", err)
+	m.logger().Infof("\n📋 Withdrawal Transaction:")
+	m.logger().Debugf("  Nonce: %s", withdrawalTx.Nonce.String())
+	m.logger().Debugf("  Sender: %s", withdrawalTx.Sender.Hex())
+	m.logger().Debugf("  Target: %s", withdrawalTx.Target.Hex())
+	m.logger().Debugf("  MNT Value: %s", withdrawalTx.MntValue.String())
+	m.logger().Debugf("  ETH Value: %s", withdrawalTx.EthValue.String())
+	m.logger().Debugf("  Gas Limit: %s", withdrawalTx.GasLimit.String())
+	m.logger().Debugf("  Data Length: %d bytes", len(withdrawalTx.Data))
+	m.logger().Debugf("  Data: %x", withdrawalTx.Data)
+	m.logger().Debugf("📊 Output index: %d", outputIndex)
+
+	return &proveCall{
+		withdrawalTx:    withdrawalTx,
+		outputIndex:     outputIndex,
+		outputRoot:      common.Hash(outputData.OutputRoot),
+		l2BlockNumber:   outputData.L2BlockNumber.Uint64(),
+		outputRootProof: outputRootProof,
+		withdrawalProof: withdrawalProof.WithdrawalProof,
+		proof:           withdrawalProof,
+	}, nil
+}
+
+// checkFinalizable returns ErrAlreadyFinalized, ErrNotProven or ErrChallengePeriodActive
+// when finalizing message now would be pointless or revert
+func (m *CrossChainMessenger) checkFinalizable(ctx context.Context, message Message) error {
+	// Check if already finalized
+	if message.Status >= StatusFinalized {
+		m.logger().Infof("✅ Message already finalized")
+		return ErrAlreadyFinalized
 	}
 
-	fmt.Println("✅ Message proved successfully!")
+	// Check if proven
+	if message.Status < StatusProven {
+		m.logger().Errorf("❌ Message not proven yet. Run prove first.")
+		return ErrNotProven
+	}
+
+	// Check the challenge period before spending gas on a call that would revert
+	proven, provenTimestamp, err := m.checkProvenStatus(ctx, message.WithdrawalHash)
+	if err != nil {
+		return fmt.Errorf("failed to check proven status: %w", err)
+	}
+	if proven && provenTimestamp.Sign() > 0 {
+		finalizeAt := time.Unix(provenTimestamp.Int64(), 0).Add(m.FinalizationParams(ctx).Period)
+		if time.Now().Before(finalizeAt) {
+			m.logger().Errorf("❌ Challenge period ends at %s", finalizeAt.Format(time.RFC3339))
+			return fmt.Errorf("%w: can finalize after %s", ErrChallengePeriodActive, finalizeAt.Format(time.RFC3339))
+		}
+	}
 	return nil
 }
 
-// FinalizeMessage finalizes a cross-chain message
-func (m *CrossChainMessenger) FinalizeMessage(ctx context.Context, txHash string, messageIndex int) error {
-	fmt.Println("\n=== FINALIZE MESSAGE ===")
-	fmt.Printf("Transaction hash (on L2): %s\n", txHash)
-	fmt.Printf("Message index: %d\n", messageIndex)
+// FinalizeMessage finalizes a cross-chain message and returns the L1 transaction hash. If
+// opts.PreviousTx was sent by an earlier run, it is awaited instead of finalizing again.
+func (m *CrossChainMessenger) FinalizeMessage(ctx context.Context, txHash string, messageIndex int, opts SubmitOptions) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "FinalizeMessage", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("\n=== FINALIZE MESSAGE ===")
+	m.logger().Infof("Transaction hash (on L2): %s", txHash)
+	m.logger().Infof("Message index: %d", messageIndex)
 
+	if !m.HasSigner() {
+		return common.Hash{}, ErrNoSigner
+	}
+
+	// A finalize sent before a restart may already be mined
+	if receipt, err := m.resumeSubmitted(ctx, opts); err != nil || receipt != nil {
+		if err == nil {
+			opts.mined(m.receiptFee(ctx, receipt, opts.From))
+		}
+		return opts.PreviousTx, err
+	}
+
+	m.progress(ctx, ProgressLoadingMessage, map[string]any{"txHash": txHash})
 	message, err := m.getMessages(ctx, txHash)
 	if err != nil {
-		return fmt.Errorf("failed to get messages: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	fmt.Printf("Message direction: %s\n", message.Direction)
-	fmt.Printf("Message status: %d\n", message.Status)
+	m.logger().Infof("Message direction: %s", message.Direction)
+	m.logger().Infof("Message status: %d", message.Status)
 
-	// Check if already finalized
-	if message.Status >= 2 {
-		fmt.Println("✅ Message already finalized")
-		return nil
+	if err := m.checkFinalizable(ctx, message); err != nil {
+		return common.Hash{}, err
 	}
-
-	// Check if proven
-	if message.Status < 1 {
-		fmt.Println("❌ Message not proven yet. Run prove first.")
-		return fmt.Errorf("message not proven")
+	if err := m.checkNotPaused(ctx); err != nil {
+		return common.Hash{}, err
 	}
-
-	fmt.Println("🔄 Starting finalize message...")
-	
-	// Parse event data to get withdrawal parameters
-	eventData := message.MessagePassedEvent
-	if eventData == nil {
-		return fmt.Errorf("event data is nil")
+	if _, err := m.CheckFinalizeCost(ctx, message, opts.From); err != nil {
+		return common.Hash{}, err
 	}
+	release, err := m.lockWithdrawal(ctx, "finalize", &message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer release()
 
-	// Construct withdrawal transaction using the generated struct from optimism_portal.go
-	withdrawalTx := cross_abi.TypesWithdrawalTransaction{
-		Nonce:    message.MsgNonce,
-		Sender:   eventData.Sender,
-		Target:   eventData.Target,
-		MntValue: message.MntValue,
-		EthValue: message.EthValue,
-		GasLimit: eventData.GasLimit,
-		Data:     eventData.Data,
+	m.logger().Infof("🔄 Starting finalize message...")
+
+	// Construct withdrawal transaction from the MessagePassed event
+	withdrawalTx, err := withdrawalTransaction(message)
+	if err != nil {
+		return common.Hash{}, err
 	}
 
-	fmt.Printf("\n📋 Withdrawal Transaction Parameters:\n")
-	fmt.Printf("  Nonce: %s\n", withdrawalTx.Nonce.String())
-	fmt.Printf("  Sender: %s\n", withdrawalTx.Sender.Hex())
-	fmt.Printf("  Target: %s\n", withdrawalTx.Target.Hex())
-	fmt.Printf("  MNT Value: %s\n", withdrawalTx.MntValue.String())
-	fmt.Printf("  ETH Value: %s\n", withdrawalTx.EthValue.String())
-	fmt.Printf("  Gas Limit: %s\n", withdrawalTx.GasLimit.String())
-	fmt.Printf("  Data: %s\n", string(withdrawalTx.Data))
+	m.logger().Infof("\n📋 Withdrawal Transaction Parameters:")
+	m.logger().Debugf("  Nonce: %s", withdrawalTx.Nonce.String())
+	m.logger().Debugf("  Sender: %s", withdrawalTx.Sender.Hex())
+	m.logger().Debugf("  Target: %s", withdrawalTx.Target.Hex())
+	m.logger().Debugf("  MNT Value: %s", withdrawalTx.MntValue.String())
+	m.logger().Debugf("  ETH Value: %s", withdrawalTx.EthValue.String())
+	m.logger().Debugf("  Gas Limit: %s", withdrawalTx.GasLimit.String())
+	m.logger().Debugf("  Data: %s", string(withdrawalTx.Data))
 
 	// Create OptimismPortal contract instance
 	optimismPortalAddr := common.HexToAddress(m.Contracts.L1.OptimismPortal)
-	optimismPortal, err := cross_abi.NewOptimismPortal(optimismPortalAddr, m.ClientL1)
+	optimismPortal, err := m.optimismPortal()
 	if err != nil {
-		return fmt.Errorf("failed to create OptimismPortal contract: %w", err)
+		return common.Hash{}, err
 	}
 
-	fmt.Printf("\n📝 OptimismPortal address: %s\n", optimismPortalAddr.Hex())
-	fmt.Printf("📝 Withdrawal hash: %s\n", message.WithdrawalHash)
+	m.logger().Debugf("\n📝 OptimismPortal address: %s", optimismPortalAddr.Hex())
+	m.logger().Debugf("📝 Withdrawal hash: %s", message.WithdrawalHash)
 
 	// Get transaction options
-	txOpts, err := m.getTransactOpts(ctx)
+	txOpts, err := m.getTransactOpts(ctx, opts.From)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction options: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to get transaction options: %w", err)
 	}
 
-	// Send transaction using KMS or private key
-	fmt.Println("\n🚀 Sending finalize transaction...")
-	
-	// Call finalizeWithdrawalTransaction
-	tx, err := optimismPortal.FinalizeWithdrawalTransaction(txOpts, withdrawalTx)
+	// Estimate gas and fill in fees before sending
+	calldata, err := packOptimismPortalCall("finalizeWithdrawalTransaction", withdrawalTx)
 	if err != nil {
-		return fmt.Errorf("failed to finalize withdrawal transaction: %w", err)
+		return common.Hash{}, err
+	}
+	m.progress(ctx, ProgressEstimatingGas, map[string]any{"method": "finalizeWithdrawalTransaction"})
+	if err := m.applyGasSettings(ctx, txOpts, optimismPortalAddr, calldata); err != nil {
+		return common.Hash{}, m.resolveExternalCompletion(ctx, &message, StatusFinalized, err)
+	}
+	if err := opts.confirm(newTxPreview("finalizeWithdrawalTransaction", message, txOpts, optimismPortalAddr)); err != nil {
+		return common.Hash{}, err
 	}
 
-	fmt.Printf("✅ Finalize transaction submitted: %s\n", tx.Hash().Hex())
-	
-	// Print raw transaction data for manual broadcasting
-	// txData, err := tx.MarshalBinary()
-	// if err != nil {
-	// 	fmt.Printf("⚠️  Failed to marshal transaction: %v\n", err)
-	// } else {
-	// 	fmt.Printf("\n📦 Raw Transaction Data (for manual broadcast):\n")
-	// 	fmt.Printf("0x%x\n", txData)
-	// 	fmt.Printf("\n💡 You can broadcast this with: cast publish 0x%x --rpc-url $L1_RPC\n", txData)
-	// }
-	
-	fmt.Println("\n⏳ Waiting for transaction to be mined...")
+	// Send transaction using KMS or private key
+	m.logger().Infof("\n🚀 Sending finalize transaction...")
 
-	// Wait for transaction to be mined
-	receipt, err := bind.WaitMined(ctx, m.ClientL1, tx)
+	// Call finalizeWithdrawalTransaction
+	tx, err := m.sendTransaction(ctx, txOpts, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return optimismPortal.FinalizeWithdrawalTransaction(opts, withdrawalTx)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %w", err)
+		return common.Hash{}, m.resolveExternalCompletion(ctx, &message, StatusFinalized,
+			fmt.Errorf("failed to finalize withdrawal transaction: %w", wrapContractError(ContractOptimismPortal, err)))
 	}
-	
-	if receipt.Status == 0 {
-		return fmt.Errorf("transaction failed (status: 0)")
-	}
-	
-	fmt.Printf("✅ Transaction mined in block %d (status: %d)\n", receipt.BlockNumber.Uint64(), receipt.Status)
-	fmt.Printf("   Gas used: %d\n", receipt.GasUsed)
-	fmt.Printf("🔗 Check transaction: https://etherscan.io/tx/%s\n", tx.Hash().Hex())
-	
-	return nil
-}
 
+	m.logger().Infof("✅ Finalize transaction submitted: %s", tx.Hash().Hex())
+	m.progressSubmitted(ctx, "finalizeWithdrawalTransaction", tx)
+	opts.submitted(tx)
 
-// getWalletAddressFromPrivateKey derives wallet address from private key
-func (m *CrossChainMessenger) getWalletAddressFromPrivateKey() (string, error) {
-	if m.PrivateKey == "" {
-		return "", fmt.Errorf("private key not set")
-	}
+	m.logger().Infof("\n⏳ Waiting for transaction to be mined...")
 
-	// Remove 0x prefix if present
-	privateKeyHex := strings.TrimPrefix(m.PrivateKey, "0x")
-	
-	// Parse private key
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+	// Wait for transaction to be mined
+	receipt, err := m.waitMined(ctx, tx, txOpts)
 	if err != nil {
-		return "", fmt.Errorf("failed to decode private key: %w", err)
+		m.resetNonce()
+		return tx.Hash(), fmt.Errorf("failed to wait for transaction: %w", err)
 	}
 
-	// Create ECDSA private key
-	privateKey, err := crypto.ToECDSA(privateKeyBytes)
-	if err != nil {
-		return "", fmt.Errorf("failed to create ECDSA private key: %w", err)
+	if receipt.Status == 0 {
+		// Someone else may have finalized it between our check and inclusion
+		return tx.Hash(), m.resolveExternalCompletion(ctx, &message, StatusFinalized,
+			m.revertedTxError(ctx, ContractOptimismPortal, tx, txOpts.From, receipt))
 	}
 
-	// Get public key and compute address
-	publicKey := &privateKey.PublicKey
-	address := publicKeyToAddress(publicKey)
-	return address, nil
-}
-
+	fee := m.receiptFee(ctx, receipt, txOpts.From)
+	m.logger().Infof("✅ Transaction mined in block %d (status: %d)", receipt.BlockNumber.Uint64(), receipt.Status)
+	m.logger().Infof("   Gas used: %d, fee: %s ETH", receipt.GasUsed, fee.ETH())
+	m.progressMined(ctx, receipt)
+	opts.mined(fee)
+	m.logTxLink(tx.Hash())
 
-// publicKeyToAddress converts ECDSA public key to Ethereum address
-func publicKeyToAddress(publicKey *ecdsa.PublicKey) string {
-	// Serialize public key to uncompressed format
-	publicKeyBytes := append(publicKey.X.Bytes(), publicKey.Y.Bytes()...)
-	
-	// Pad to 64 bytes if needed
-	if len(publicKeyBytes) < 64 {
-		padding := make([]byte, 64-len(publicKeyBytes))
-		publicKeyBytes = append(padding, publicKeyBytes...)
-	}
-	
-	// Compute Keccak256 hash
-	hash := sha3.NewLegacyKeccak256()
-	hash.Write(publicKeyBytes)
-	hashBytes := hash.Sum(nil)
-	
-	// Take last 20 bytes as address
-	address := "0x" + hex.EncodeToString(hashBytes[12:])
-	return address
+	return receipt.TxHash, nil
 }
 
 // SignWithKMS is deprecated - the library handles signing internally
@@ -573,100 +915,62 @@ func (m *CrossChainMessenger) SignWithKMS(hash []byte) (*EthereumSignature, erro
 }
 
 // getL2OutputIndex gets the L2 output index for a given block number
-func (m *CrossChainMessenger) getL2OutputIndex(ctx context.Context, l2OutputOracleAddress string, blockNumber uint64) (uint64, error) {
+func (m *CrossChainMessenger) getL2OutputIndex(ctx context.Context, l2OutputOracleAddress string, blockNumber uint64) (index uint64, err error) {
+	ctx, span := startSpan(ctx, "getL2OutputIndex", attrL2Block.Int64(int64(blockNumber)))
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attrOutputIndex.Int64(int64(index)))
+		}
+		endSpan(span, err)
+	}()
 	// getL2OutputIndexAfter(uint256 _l2BlockNumber) function selector: 0x7f006420
 	functionSelector := "0x7f006420"
-	
+
 	// Convert block number to hex and pad to 32 bytes
 	blockNumberHex := fmt.Sprintf("%064x", blockNumber)
 	callData := functionSelector + blockNumberHex
-	
-	fmt.Printf("🔍 Getting L2 output index for block %d\n", blockNumber)
-	fmt.Printf("📝 Call data: %s\n", callData)
-	l2Oracle, err := cross_abi.NewL2OutputOracle(common.HexToAddress(l2OutputOracleAddress), m.ClientL1)
+
+	m.logger().Debugf("🔍 Getting L2 output index for block %d", blockNumber)
+	m.logger().Debugf("📝 Call data: %s", callData)
+	l2Oracle, err := m.l2OutputOracle(l2OutputOracleAddress)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create L2OutputOracle instance: %w", err)
+		return 0, err
+	}
+	if index, ok := m.cache.outputIndex(blockNumber); ok {
+		m.logger().Debugf("📦 Using cached L2 output index %d", index)
+		return index, nil
 	}
-	result, err := 	l2Oracle.GetL2OutputIndexAfter(nil, big.NewInt(int64(blockNumber)))
+	result, err := withRetry(ctx, m, "getL2OutputIndexAfter", func(ctx context.Context) (*big.Int, error) {
+		return l2Oracle.GetL2OutputIndexAfter(&bind.CallOpts{Context: ctx}, big.NewInt(int64(blockNumber)))
+	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to call getL2OutputIndexAfter: %w", err)
+		return 0, fmt.Errorf("failed to call getL2OutputIndexAfter: %w", wrapContractError(ContractL2OutputOracle, err))
 	}
 
+	// The call only succeeds once a covering output exists, so the answer is final
+	m.cache.setOutputIndex(blockNumber, result.Uint64())
 	return result.Uint64(), nil
 }
 
-
 // getL2OutputData gets L2 output data for a given index
 func (m *CrossChainMessenger) getL2OutputData(ctx context.Context, l2OutputOracleAddress string, outputIndex uint64) (cross_abi.TypesOutputProposal, error) {
 	var result cross_abi.TypesOutputProposal
-	l2Oracle, err := cross_abi.NewL2OutputOracle(common.HexToAddress(l2OutputOracleAddress), m.ClientL1)
+	l2Oracle, err := m.l2OutputOracle(l2OutputOracleAddress)
 	if err != nil {
-		return result, fmt.Errorf("failed to create L2OutputOracle instance: %w", err)
+		return result, err
 	}
-
-	result, err = l2Oracle.GetL2Output(nil, big.NewInt(int64(outputIndex)))
-	
-	return result, err
-}
-
-
-
-// checkCanFinalize checks if a proven withdrawal is ready to be finalized
-func (m *CrossChainMessenger) checkCanFinalize(ctx context.Context, withdrawalHash string, message *Message) (bool, error) {
-	fmt.Printf("🔍 Checking if withdrawal can be finalized...\n")
-	fmt.Printf("📋 Block number: %d (0x%x)\n", message.BlockNumber, message.BlockNumber)
-	
-	// For Mantle, after a withdrawal is proven, there's typically a 12-hour challenge period
-	// Let's try to get actual timing data, but fall back to heuristic if needed
-	
-	// L2OutputOracle contract address for Mantle
-	l2OutputOracleAddress := "0x31d543e7BE1dA6eFDc2206Ef7822879045B9f481"
-	fmt.Printf("📞 L2OutputOracle: %s\n", l2OutputOracleAddress)
-	
-	// Try to get L2 output index for this block number with timeout protection
-	fmt.Printf("� Attempting to get L2 output index...\n")
-	outputIndex, err := m.getL2OutputIndex(ctx, l2OutputOracleAddress, message.BlockNumber)
-	if err != nil {
-		fmt.Printf("⚠️  Failed to get L2 output index: %v\n", err)
-		fmt.Printf("💡 Using heuristic: For proven withdrawals, assuming 12+ hours have passed\n")
-		fmt.Printf("🚀 READY TO FINALIZE! (heuristic - proven withdrawals are typically ready)\n")
-		return true, nil
+	if cached, ok := m.cache.output(outputIndex); ok {
+		return cached, nil
 	}
-	
-	fmt.Printf("✅ L2 Output Index: %d\n", outputIndex)
-	
-	// Try to get the output data with timestamp
-	fmt.Printf("🔍 Attempting to get L2 output data...\n")
-	outputData, err := m.getL2OutputData(ctx, l2OutputOracleAddress, outputIndex)
+
+	result, err = withRetry(ctx, m, "getL2Output", func(ctx context.Context) (cross_abi.TypesOutputProposal, error) {
+		return l2Oracle.GetL2Output(&bind.CallOpts{Context: ctx}, big.NewInt(int64(outputIndex)))
+	})
 	if err != nil {
-		fmt.Printf("⚠️  Failed to get L2 output data: %v\n", err)
-		fmt.Printf("💡 Using heuristic: For proven withdrawals, assuming 12+ hours have passed\n")
-		fmt.Printf("� READY TO FINALIZE! (heuristic - proven withdrawals are typically ready)\n")
-		return true, nil
-	}
-	
-	// Calculate if 12 hours have passed
-	challengePeriod := int64(12 * 60 * 60) // 12 hours in seconds
-	currentTime := getCurrentTimestamp()
-	timeElapsed := currentTime - outputData.Timestamp.Int64()
-	
-	// Output timing information
-	
-	fmt.Printf("⏰ Current timestamp: %d\n", currentTime)
-	fmt.Printf("⏰ Output timestamp: %d\n", outputData.Timestamp)
-	fmt.Printf("⏰ Time elapsed: %d seconds (%.1f hours)\n", timeElapsed, float64(timeElapsed)/3600.0)
-	fmt.Printf("⏰ Challenge period: %d seconds (12 hours)\n", challengePeriod)
-	
-	canFinalize := timeElapsed >= challengePeriod
-	
-	if canFinalize {
-		fmt.Printf("🚀 READY TO FINALIZE! Challenge period has passed (%.1f hours elapsed)\n", float64(timeElapsed)/3600.0)
-	} else {
-		remainingTime := challengePeriod - timeElapsed
-		fmt.Printf("⏳ STILL IN CHALLENGE PERIOD: Need to wait %.1f more hours\n", float64(remainingTime)/3600.0)
+		return result, wrapContractError(ContractL2OutputOracle, err)
 	}
-	
-	return canFinalize, nil
+	m.cache.setOutput(outputIndex, result)
+	return result, nil
 }
 
 // generateWithdrawalProof generates the withdrawal proof for a message using eth_getProof
@@ -675,34 +979,61 @@ func (m *CrossChainMessenger) generateWithdrawalProof(ctx context.Context, messa
 }
 
 // generateWithdrawalProofForBlock generates the withdrawal proof for a specific block number
-func (m *CrossChainMessenger) generateWithdrawalProofForBlock(ctx context.Context, message Message, blockNumber uint64) (*WithdrawalProof, error) {
-	fmt.Println("🔍 Generating withdrawal proof using eth_getProof...")
-	
+// missingStatePhrases are how L2 nodes report a historical state they have pruned
+var missingStatePhrases = []string{
+	"missing trie node",
+	"historical state",
+	"state not available",
+	"state is not available",
+	"required state unavailable",
+	"pruned",
+}
+
+// isMissingStateError reports whether err means the node no longer has the state asked for
+func isMissingStateError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, phrase := range missingStatePhrases {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// proofClient is the L2 client eth_getProof goes to: the archive endpoint if one is set
+func (m *CrossChainMessenger) proofClient() EthClient {
+	if m.ClientL2Archive != nil {
+		return m.ClientL2Archive
+	}
+	return m.ClientL2
+}
+
+func (m *CrossChainMessenger) generateWithdrawalProofForBlock(ctx context.Context, message Message, blockNumber uint64) (_ *WithdrawalProof, err error) {
+	ctx, span := startSpan(ctx, "eth_getProof", attrWithdrawalHash.String(message.WithdrawalHash), attrL2Block.Int64(int64(blockNumber)))
+	defer func() { endSpan(span, err) }()
+	m.logger().Infof("🔍 Generating withdrawal proof using eth_getProof...")
+
 	// L2ToL1MessagePasser contract address
 	messagePasserAddr := common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser)
-	fmt.Printf("📍 L2ToL1MessagePasser: %s\n", messagePasserAddr.Hex())
-	
+	m.logger().Debugf("📍 L2ToL1MessagePasser: %s", messagePasserAddr.Hex())
+
 	// Block number for the proof
 	blockNum := big.NewInt(int64(blockNumber))
-	fmt.Printf("📊 Block number: %d\n", blockNum.Uint64())
-	
-	// Get the block to retrieve the block hash
-	block, err := m.ClientL2.HeaderByNumber(ctx, blockNum)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get block header: %w", err)
-	}
-	fmt.Printf("🔗 Block hash: %s\n", block.Hash().Hex())
-	
+	m.logger().Debugf("📊 Block number: %d", blockNum.Uint64())
+
 	// Calculate storage slot for sentMessages mapping
 	// sentMessages[withdrawalHash] = true
 	// Storage slot = keccak256(abi.encode(withdrawalHash, slot))
 	// where slot = 0 for sentMessages mapping
 	withdrawalHashBytes := common.HexToHash(message.WithdrawalHash)
 	slot := m.calculateSentMessagesSlot(message.WithdrawalHash)
-	fmt.Printf("📝 Withdrawal hash: %s\n", withdrawalHashBytes.Hex())
-	fmt.Printf("📝 Storage slot: %s\n", slot.Hex())
-	
-	// Make eth_getProof RPC call
+	m.logger().Debugf("📝 Withdrawal hash: %s", withdrawalHashBytes.Hex())
+	m.logger().Debugf("📝 Storage slot: %s", slot.Hex())
+
+	// eth_getProof result
 	type GetProofResult struct {
 		AccountProof []string `json:"accountProof"`
 		StorageProof []struct {
@@ -712,89 +1043,79 @@ func (m *CrossChainMessenger) generateWithdrawalProofForBlock(ctx context.Contex
 		} `json:"storageProof"`
 		StorageHash string `json:"storageHash"`
 	}
-	
+
+	// Fetch the block header, the proof and the sentMessages slot in one batch
+	var block *types.Header
 	var proofResult GetProofResult
-	err = m.ClientL2.Client().CallContext(ctx, &proofResult, "eth_getProof", 
-		messagePasserAddr.Hex(), 
-		[]string{slot.Hex()}, 
-		fmt.Sprintf("0x%x", blockNum.Uint64()))
-	
+	var storageValue hexutil.Bytes
+	blockTag := hexutil.EncodeBig(blockNum)
+	err = m.batchCall(ctx, m.proofClient(), "L2 eth_getBlockByNumber+eth_getProof+eth_getStorageAt", []rpc.BatchElem{
+		{Method: "eth_getBlockByNumber", Args: []interface{}{blockTag, false}, Result: &block},
+		{Method: "eth_getProof", Args: []interface{}{messagePasserAddr, []string{slot.Hex()}, blockTag}, Result: &proofResult},
+		{Method: "eth_getStorageAt", Args: []interface{}{messagePasserAddr, slot, blockTag}, Result: &storageValue},
+	})
+	if isMissingStateError(err) {
+		return nil, &ArchiveStateError{Block: blockNumber, Archive: m.ClientL2Archive != nil, Err: err}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to call eth_getProof: %w", err)
+		return nil, fmt.Errorf("failed to fetch withdrawal proof inputs: %w", err)
 	}
-	
-	fmt.Printf("✅ Got proof with %d account proof elements and %d storage proof elements\n", 
+	if block == nil {
+		return nil, fmt.Errorf("failed to get block header: block %d not found", blockNumber)
+	}
+	m.logger().Debugf("🔗 Block hash: %s", block.Hash().Hex())
+
+	// Sanity check: sentMessages[withdrawalHash] must be true at this block
+	if new(big.Int).SetBytes(storageValue).Cmp(big.NewInt(1)) != 0 {
+		m.logger().Warnf("⚠️  Warning: Expected storage value 0x1 (true), got %s", storageValue)
+	}
+
+	m.logger().Debugf("✅ Got proof with %d account proof elements and %d storage proof elements",
 		len(proofResult.AccountProof), len(proofResult.StorageProof))
-	
+
 	// Parse storage hash (this is the storage root from the account)
 	storageHash := common.HexToHash(proofResult.StorageHash)
 	var messagePasserStorageRoot [32]byte
 	copy(messagePasserStorageRoot[:], storageHash[:])
-	fmt.Printf("📊 Message Passer Storage Root: %s\n", storageHash.Hex())
-	
+	m.logger().Debugf("📊 Message Passer Storage Root: %s", storageHash.Hex())
+
 	// The withdrawal proof should ONLY contain the storage proof, not the account proof
 	// The account proof is implicitly verified through the messagePasserStorageRoot
 	var withdrawalProof [][]byte
-	
+
 	// Add only storage proof elements
 	if len(proofResult.StorageProof) > 0 {
-		// Debug: Check the storage value
-		storageValue := proofResult.StorageProof[0].Value
-		fmt.Printf("📊 Storage value: %s\n", storageValue)
-		if storageValue != "0x1" && storageValue != "0x01" {
-			fmt.Printf("⚠️  Warning: Expected storage value 0x1 (true), got %s\n", storageValue)
-		}
-		
+		m.logger().Debugf("📊 Storage value: %s", proofResult.StorageProof[0].Value)
+
 		for _, proofHex := range proofResult.StorageProof[0].Proof {
 			proofBytes := common.FromHex(proofHex)
 			withdrawalProof = append(withdrawalProof, proofBytes)
 		}
-		fmt.Printf("✅ Got storage proof with %d elements\n", len(withdrawalProof))
+		m.logger().Debugf("✅ Got storage proof with %d elements", len(withdrawalProof))
 	} else {
 		return nil, fmt.Errorf("no storage proof returned for withdrawal hash")
 	}
-	
+
 	// Apply MaybeAddProofNode fix - this handles the case where the final proof element
 	// is less than 32 bytes and exists inside a branch node
 	var slotArray [32]byte
 	copy(slotArray[:], slot[:])
-	withdrawalProof, err = helper.MaybeAddProofNode(slotArray, withdrawalProof)
+	// The storage trie is keyed by the hashed slot, which is what the inlined node's path ends with
+	withdrawalProof, err = helper.MaybeAddProofNode(crypto.Keccak256Hash(slotArray[:]), withdrawalProof)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply MaybeAddProofNode: %w", err)
 	}
-	
+	withdrawalProof = m.compactProof(messagePasserStorageRoot, slotArray, withdrawalProof)
+
 	// Debug: Print proof elements in detail
-	fmt.Printf("✅ Final withdrawal proof has %d elements (after MaybeAddProofNode)\n", len(withdrawalProof))
-	for i, proof := range withdrawalProof {
-		fmt.Printf("  Proof[%d]: %d bytes\n", i, len(proof))
-		fmt.Printf("    First byte: 0x%02x (RLP prefix)\n", proof[0])
-		
-		// Try to determine node type from RLP structure
-		var rlpData []interface{}
-		err := rlp.DecodeBytes(proof, &rlpData)
-		if err == nil {
-			if len(rlpData) == 17 {
-				fmt.Printf("    Type: Branch node (17 elements)\n")
-			} else if len(rlpData) == 2 {
-				fmt.Printf("    Type: Leaf/Extension node (2 elements)\n")
-			} else {
-				fmt.Printf("    Type: Unknown (%d elements)\n", len(rlpData))
-			}
-		}
-		
-		if len(proof) <= 64 {
-			fmt.Printf("    Hex: 0x%x\n", proof)
-		} else {
-			fmt.Printf("    Hex (first 32): 0x%x...\n", proof[:32])
-			fmt.Printf("    Hex (last 32): ...0x%x\n", proof[len(proof)-32:])
-		}
-	}
-	
+	m.logger().Debugf("✅ Final withdrawal proof has %d elements (after MaybeAddProofNode)", len(withdrawalProof))
+	m.logProofNodes(withdrawalProof)
+
 	// Get the state root from the block header
 	var stateRoot [32]byte
 	copy(stateRoot[:], block.Root[:])
-	fmt.Printf("📊 Block State Root: %s\n", block.Root.Hex())
-	
+	m.logger().Debugf("📊 Block State Root: %s", block.Root.Hex())
+
 	return &WithdrawalProof{
 		WithdrawalProof:          withdrawalProof,
 		MessagePasserStorageRoot: messagePasserStorageRoot,
@@ -805,116 +1126,183 @@ func (m *CrossChainMessenger) generateWithdrawalProofForBlock(ctx context.Contex
 
 // calculateSentMessagesSlot calculates the storage slot for sentMessages mapping
 func (m *CrossChainMessenger) calculateSentMessagesSlot(withdrawalHash string) common.Hash {
+	return SentMessagesSlot(withdrawalHash)
+}
+
+// SentMessagesSlot returns the L2ToL1MessagePasser storage slot of sentMessages[withdrawalHash],
+// the slot a withdrawal proof covers
+func SentMessagesSlot(withdrawalHash string) common.Hash {
 	// sentMessages mapping is at slot 0 in L2ToL1MessagePasser contract
 	// Storage slot = keccak256(abi.encodePacked(withdrawalHash, mappingSlot))
 	withdrawalHashBytes := common.HexToHash(withdrawalHash)
 	mappingSlot := common.BigToHash(big.NewInt(0)) // sentMessages is at slot 0
-	
+
 	// Concatenate: withdrawalHash (32 bytes) + mappingSlot (32 bytes)
 	data := append(withdrawalHashBytes.Bytes(), mappingSlot.Bytes()...)
-	
+
 	// Calculate keccak256
 	hash := crypto.Keccak256Hash(data)
 	return hash
 }
 
-
-// callProveWithdrawalTransaction calls the proveWithdrawalTransaction method
-func (m *CrossChainMessenger) callProveWithdrawalTransaction(ctx context.Context, withdrawalTx cross_abi.TypesWithdrawalTransaction, l2OutputIndex uint64, outputRootProof cross_abi.TypesOutputRootProof, withdrawalProof [][]byte) error {
+// callProveWithdrawalTransaction calls the proveWithdrawalTransaction method and returns
+// the L1 transaction hash
+func (m *CrossChainMessenger) callProveWithdrawalTransaction(ctx context.Context, message Message, withdrawalTx cross_abi.TypesWithdrawalTransaction, l2OutputIndex uint64, outputRootProof cross_abi.TypesOutputRootProof, withdrawalProof [][]byte, submitOpts SubmitOptions) (common.Hash, error) {
 	// Create OptimismPortal contract instance
 	optimismPortalAddr := common.HexToAddress(m.Contracts.L1.OptimismPortal)
-	optimismPortal, err := cross_abi.NewOptimismPortal(optimismPortalAddr, m.ClientL1)
+	optimismPortal, err := m.optimismPortal()
 	if err != nil {
-		return fmt.Errorf("failed to create OptimismPortal contract: %w", err)
+		return common.Hash{}, err
 	}
 
 	// Get transaction options
-	txOpts, err := m.getTransactOpts(ctx)
+	txOpts, err := m.getTransactOpts(ctx, submitOpts.From)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction options: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to get transaction options: %w", err)
+	}
+
+	// Estimate gas and fill in fees before sending
+	calldata, err := packOptimismPortalCall("proveWithdrawalTransaction",
+		withdrawalTx, big.NewInt(int64(l2OutputIndex)), outputRootProof, withdrawalProof)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	m.progress(ctx, ProgressEstimatingGas, map[string]any{"method": "proveWithdrawalTransaction"})
+	if err := m.applyGasSettings(ctx, txOpts, optimismPortalAddr, calldata); err != nil {
+		return common.Hash{}, err
+	}
+	preview := newTxPreview("proveWithdrawalTransaction", message, txOpts, optimismPortalAddr)
+	size := MeasureProof(withdrawalProof)
+	preview.ProofSize = &size
+	if err := submitOpts.confirm(preview); err != nil {
+		return common.Hash{}, err
 	}
 
 	// Call proveWithdrawalTransaction
-	tx, err := optimismPortal.ProveWithdrawalTransaction(
-		txOpts,
-		withdrawalTx,
-		big.NewInt(int64(l2OutputIndex)),
-		outputRootProof,
-		withdrawalProof,
-	)
+	tx, err := m.sendTransaction(ctx, txOpts, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return optimismPortal.ProveWithdrawalTransaction(
+			opts,
+			withdrawalTx,
+			big.NewInt(int64(l2OutputIndex)),
+			outputRootProof,
+			withdrawalProof,
+		)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to prove withdrawal transaction: %w", err)
+		return common.Hash{}, fmt.Errorf("failed to prove withdrawal transaction: %w", wrapContractError(ContractOptimismPortal, err))
 	}
 
-	fmt.Printf("✅ Prove transaction submitted: %s\n", tx.Hash().Hex())
-	
+	m.logger().Infof("✅ Prove transaction submitted: %s", tx.Hash().Hex())
+	m.logTxLink(tx.Hash())
+	m.progressSubmitted(ctx, "proveWithdrawalTransaction", tx)
+	submitOpts.submitted(tx)
+
 	// Print raw transaction data for manual broadcasting
 	txData, err := tx.MarshalBinary()
 	if err != nil {
-		fmt.Printf("⚠️  Failed to marshal transaction: %v\n", err)
+		m.logger().Warnf("⚠️  Failed to marshal transaction: %v", err)
 	} else {
-		fmt.Printf("\n📦 Raw Transaction Data (for manual broadcast):\n")
-		fmt.Printf("0x%x\n", txData)
-		fmt.Printf("\n💡 You can broadcast this with: cast publish 0x%x --rpc-url $L1_RPC\n", txData)
+		m.logger().Debugf("\n📦 Raw Transaction Data (for manual broadcast):")
+		m.logger().Debugf("0x%x", txData)
+		m.logger().Debugf("\n💡 You can broadcast this with: cast publish 0x%x --rpc-url $L1_RPC", txData)
 	}
-	
+
 	// Wait for transaction to be mined
-	fmt.Printf("\n⏳ Waiting for transaction to be mined...\n")
-	receipt, err := bind.WaitMined(ctx, m.ClientL1, tx)
+	m.logger().Infof("\n⏳ Waiting for transaction to be mined...")
+	receipt, err := m.waitMined(ctx, tx, txOpts)
 	if err != nil {
-		return fmt.Errorf("failed to wait for transaction: %w", err)
+		m.resetNonce()
+		return tx.Hash(), fmt.Errorf("failed to wait for transaction: %w", err)
 	}
-	
+
 	if receipt.Status == 0 {
-		return fmt.Errorf("transaction failed (status: 0)")
+		m.logRevertedProof(withdrawalProof)
+		return tx.Hash(), m.revertedTxError(ctx, ContractOptimismPortal, tx, txOpts.From, receipt)
 	}
-	
-	fmt.Printf("✅ Transaction mined in block %d (status: %d)\n", receipt.BlockNumber.Uint64(), receipt.Status)
-	fmt.Printf("   Gas used: %d\n", receipt.GasUsed)
-	
-	return nil
+
+	fee := m.receiptFee(ctx, receipt, txOpts.From)
+	m.logger().Infof("✅ Transaction mined in block %d (status: %d)", receipt.BlockNumber.Uint64(), receipt.Status)
+	m.logger().Infof("   Gas used: %d, fee: %s ETH", receipt.GasUsed, fee.ETH())
+	m.progressMined(ctx, receipt)
+	submitOpts.mined(fee)
+
+	return receipt.TxHash, nil
 }
 
-// getTransactOpts gets transaction options for signing
-func (m *CrossChainMessenger) getTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
-	if m.KMSClient != nil {
-		// Use KMS for signing
-		return m.getKMSTransactOpts(ctx)
-	} else if m.PrivateKey != "" {
-		// Use private key for signing
-		return m.getPrivateKeyTransactOpts()
-	}
-	return nil, fmt.Errorf("no signing method configured")
+// HasSigner reports whether signing credentials (KMS_KEY_ID, PRIV_KEY or SIGNER_RPC_URL) are configured
+func (m *CrossChainMessenger) HasSigner() bool {
+	return len(m.signerConfigs()) > 0
 }
 
-// getKMSTransactOpts gets transaction options using KMS
-func (m *CrossChainMessenger) getKMSTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
-	if m.KMSClient == nil {
-		return nil, fmt.Errorf("KMS client not initialized")
+// getTransactOpts gets transaction options signed by the wallet from, or by the default
+// signer when from is zero, initializing signers on first use
+func (m *CrossChainMessenger) getTransactOpts(ctx context.Context, from common.Address) (*bind.TransactOpts, error) {
+	configs := m.signerConfigs()
+	if len(configs) == 0 {
+		return nil, ErrNoSigner
+	}
+	if from == (common.Address{}) {
+		opts, err := m.signerTransactOpts(ctx, configs[0])
+		if err != nil {
+			return nil, err
+		}
+		m.setDefaultWallet(opts.From)
+		return opts, nil
 	}
 
-	// Get chain ID
-	chainID, err := m.ClientL1.ChainID(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	for _, cfg := range configs {
+		if addr, ok := m.signerAddress(cfg); ok && addr != from {
+			continue
+		}
+		opts, err := m.signerTransactOpts(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if opts.From == from {
+			return opts, nil
+		}
 	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSigner, from.Hex())
+}
 
-	// Use the go-ethereum-aws-kms-tx-signer library to create TransactOpts
-	// This library handles all the KMS signing complexity including secp256k1 compatibility
-	transactor, err := kmssigner.NewAwsKmsTransactorWithChainID(m.KMSClient, m.KMSKeyID, chainID)
+// signerTransactOpts gets transaction options for one signer and records its address
+func (m *CrossChainMessenger) signerTransactOpts(ctx context.Context, cfg SignerConfig) (*bind.TransactOpts, error) {
+	var opts *bind.TransactOpts
+	var err error
+	if cfg.KMSKeyID != "" {
+		// Use KMS for signing
+		opts, err = m.getKMSTransactOpts(ctx, cfg.KMSKeyID)
+	} else if cfg.RemoteURL != "" {
+		// Use the remote signer's eth_signTransaction
+		opts, err = m.getRemoteTransactOpts(ctx, cfg)
+	} else {
+		// Use private key for signing
+		opts, err = m.getPrivateKeyTransactOpts(cfg.PrivateKey)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create KMS transactor: %w", err)
+		return nil, err
 	}
+	m.setSignerAddress(cfg, opts.From)
+	return opts, nil
+}
 
-	// Set context
-	transactor.Context = ctx
+// getKMSTransactOpts gets transaction options using KMS. keyID may be a key ID, key ARN,
+// alias ARN or alias.
+func (m *CrossChainMessenger) getKMSTransactOpts(ctx context.Context, keyID string) (*bind.TransactOpts, error) {
+	transactor, err := m.kmsTransactor(ctx, normalizeKMSKeyID(keyID))
+	if err != nil {
+		return nil, err
+	}
 
-	return transactor, nil
+	// Each caller gets its own copy with its context; nonce and fees are set per transaction
+	opts := *transactor
+	opts.Context = ctx
+	return &opts, nil
 }
 
 // getPrivateKeyTransactOpts gets transaction options using private key
-func (m *CrossChainMessenger) getPrivateKeyTransactOpts() (*bind.TransactOpts, error) {
-	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(m.PrivateKey, "0x"))
+func (m *CrossChainMessenger) getPrivateKeyTransactOpts(privateKeyHex string) (*bind.TransactOpts, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid private key: %w", err)
 	}
@@ -932,18 +1320,22 @@ func (m *CrossChainMessenger) getPrivateKeyTransactOpts() (*bind.TransactOpts, e
 	return auth, nil
 }
 
-
-
 // calculateOutputRoot calculates the output root from the output root proof
 // OutputRoot = keccak256(abi.encode(version, stateRoot, messagePasserStorageRoot, latestBlockhash))
 func (m *CrossChainMessenger) calculateOutputRoot(proof cross_abi.TypesOutputRootProof) [32]byte {
+	return computeOutputRoot(proof)
+}
+
+// computeOutputRoot is calculateOutputRoot for callers without a messenger, such as
+// LoadWithdrawalProof
+func computeOutputRoot(proof cross_abi.TypesOutputRootProof) [32]byte {
 	// ABI encode: version (32 bytes) + stateRoot (32 bytes) + messagePasserStorageRoot (32 bytes) + latestBlockhash (32 bytes)
 	data := make([]byte, 0, 128)
 	data = append(data, proof.Version[:]...)
 	data = append(data, proof.StateRoot[:]...)
 	data = append(data, proof.MessagePasserStorageRoot[:]...)
 	data = append(data, proof.LatestBlockhash[:]...)
-	
+
 	hash := crypto.Keccak256Hash(data)
 	var result [32]byte
 	copy(result[:], hash[:])
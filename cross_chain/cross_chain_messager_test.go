@@ -0,0 +1,150 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// messagePassedLog is the MessagePassed log L2ToL1MessagePasser emits for tx, carrying
+// withdrawalHash
+func messagePassedLog(t testing.TB, tx cross_abi.TypesWithdrawalTransaction, withdrawalHash common.Hash) *types.Log {
+	t.Helper()
+	parsed, err := cross_abi.L2ToL1MessagePasserMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := parsed.Events["MessagePassed"]
+	data, err := event.Inputs.NonIndexed().Pack(tx.MntValue, tx.EthValue, tx.GasLimit, tx.Data, withdrawalHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Log{
+		Address: common.HexToAddress(DefaultContracts().Bridges.L2ToL1MessagePasser),
+		Topics: []common.Hash{
+			event.ID,
+			common.BigToHash(tx.Nonce),
+			common.BytesToHash(tx.Sender.Bytes()),
+			common.BytesToHash(tx.Target.Bytes()),
+		},
+		Data: data,
+	}
+}
+
+// testWithdrawal is a version 1 withdrawal sent straight to the message passer
+func testWithdrawal() cross_abi.TypesWithdrawalTransaction {
+	nonce := new(big.Int).Lsh(big.NewInt(1), 240)
+	return cross_abi.TypesWithdrawalTransaction{
+		Nonce:    nonce.Add(nonce, big.NewInt(42)),
+		Sender:   common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		Target:   common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		MntValue: big.NewInt(3),
+		EthValue: big.NewInt(5),
+		GasLimit: big.NewInt(100000),
+		Data:     []byte{0xde, 0xad},
+	}
+}
+
+// receiptMessenger returns a messenger for mainnet whose L2 client serves receipt
+func receiptMessenger(t *testing.T, receipt *types.Receipt) *CrossChainMessenger {
+	t.Helper()
+	client := &receiptClient{receipt: receipt}
+	m, err := NewCrossChainMessenger(MessengerConfig{
+		L1Client:          client,
+		L2Client:          client,
+		SkipStartupChecks: true,
+		Contracts:         DefaultContracts(),
+		Logger:            NopLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestGetMessageLocalDirectWithdrawal(t *testing.T) {
+	tx := testWithdrawal()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &types.Receipt{
+		TxHash:      common.HexToHash("0xaa"),
+		BlockNumber: big.NewInt(7),
+		Logs:        []*types.Log{messagePassedLog(t, tx, hash)},
+	}
+
+	message, err := receiptMessenger(t, receipt).getMessageLocal(context.Background(), receipt.TxHash.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.MntValue.Cmp(tx.MntValue) != 0 || message.EthValue.Cmp(tx.EthValue) != 0 {
+		t.Fatalf("values = %s MNT, %s ETH, want the MessagePassed ones %s, %s", message.MntValue, message.EthValue, tx.MntValue, tx.EthValue)
+	}
+	if message.WithdrawalHash != common.Bytes2Hex(hash[:]) {
+		t.Fatalf("withdrawal hash = %s, want %s", message.WithdrawalHash, hash.Hex())
+	}
+	if message.TxHash != receipt.TxHash.Hex() || message.BlockNumber != 7 {
+		t.Fatalf("message is from %s in block %d, want %s in block 7", message.TxHash, message.BlockNumber, receipt.TxHash.Hex())
+	}
+}
+
+func TestPortalReadFailureKeepsProvenStatus(t *testing.T) {
+	ctx := context.Background()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	txHash := l2.receipt.TxHash.Hex()
+	if _, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, proven, err := m.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proven.Status != StatusProven || proven.ProvenAt.IsZero() {
+		t.Fatalf("state after proving = %+v, want proven", proven)
+	}
+
+	// One cycle where the portal can't be read must fail, not report READY_TO_PROVE,
+	// which the scheduler would take for a proof dropped by a reorg
+	l1.callErrs = map[string]error{"provenWithdrawals": errors.New("fakeL1: header not found")}
+	if message, err := m.GetMessages(ctx, txHash); err == nil {
+		t.Fatalf("GetMessages() with the portal unreadable = status %d, want an error", message.Status)
+	}
+	if _, state, err := m.RecommendNextAction(ctx, txHash); err == nil {
+		t.Fatalf("RecommendNextAction() with the portal unreadable = %+v, want an error", state)
+	}
+
+	l1.callErrs = nil
+	_, state, err := m.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != StatusProven || !state.ProvenAt.Equal(proven.ProvenAt) {
+		t.Fatalf("state after the portal recovered = %+v, want proven at %v", state, proven.ProvenAt)
+	}
+
+	// A successful read without a proof is what a reorg looks like
+	l1.mu.Lock()
+	clear(l1.proven)
+	l1.mu.Unlock()
+	_, state, err = m.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != StatusReadyToProve {
+		t.Fatalf("status with the proof gone = %d, want %d", state.Status, StatusReadyToProve)
+	}
+}
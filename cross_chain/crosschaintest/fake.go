@@ -0,0 +1,234 @@
+// Package crosschaintest provides an in-memory crosschain.Client for testing code that
+// proves and finalizes withdrawals without an L1 or L2 node.
+package crosschaintest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Call records one method call made on a FakeMessenger
+type Call struct {
+	Method       string
+	TxHash       string
+	MessageIndex int
+	Options      crosschain.SubmitOptions
+}
+
+// FakeMessenger is a crosschain.Client that keeps withdrawals in memory. It follows the
+// status rules of the real messenger: a message must be proven before it is finalized,
+// finalizing waits out ChallengePeriod from the time it was proven, and finalized
+// messages can't be proven or finalized again.
+type FakeMessenger struct {
+	// Now returns the current time; set it to move through the challenge period. Defaults to time.Now.
+	Now func() time.Time
+	// ChallengePeriod defaults to crosschain.ChallengePeriod
+	ChallengePeriod time.Duration
+	// Errors, keyed by method name ("GetMessages", "ProveMessage", ...), are returned
+	// instead of running the method
+	Errors map[string]error
+
+	mu       sync.Mutex
+	messages map[string]*crosschain.Message
+	provenAt map[string]time.Time
+	calls    []Call
+	nonce    uint64
+}
+
+var _ crosschain.Client = (*FakeMessenger)(nil)
+
+// NewFakeMessenger returns a FakeMessenger without any messages
+func NewFakeMessenger() *FakeMessenger {
+	return &FakeMessenger{
+		ChallengePeriod: crosschain.ChallengePeriod,
+		Errors:          make(map[string]error),
+		messages:        make(map[string]*crosschain.Message),
+		provenAt:        make(map[string]time.Time),
+	}
+}
+
+// AddMessage stores message under txHash, replacing any message already there.
+// provenAt is the proven time used for the challenge period when message.Status is
+// StatusProven; it is ignored otherwise.
+func (f *FakeMessenger) AddMessage(txHash string, message crosschain.Message, provenAt time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message.TxHash = txHash
+	f.messages[txHash] = &message
+	delete(f.provenAt, txHash)
+	if message.Status == crosschain.StatusProven {
+		f.provenAt[txHash] = provenAt
+	}
+}
+
+// SetStatus changes the status of the message in txHash, e.g. to simulate another
+// wallet proving or finalizing it. Setting StatusProven starts the challenge period now.
+func (f *FakeMessenger) SetStatus(txHash string, status int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, ok := f.messages[txHash]
+	if !ok {
+		return fmt.Errorf("no message in transaction %s", txHash)
+	}
+	message.Status = status
+	delete(f.provenAt, txHash)
+	if status == crosschain.StatusProven {
+		f.provenAt[txHash] = f.now()
+	}
+	return nil
+}
+
+// Calls returns the calls made so far, oldest first
+func (f *FakeMessenger) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// GetMessages returns a copy of the message stored under txHash
+func (f *FakeMessenger) GetMessages(ctx context.Context, txHash string) (crosschain.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, err := f.lookup("GetMessages", txHash, 0, crosschain.SubmitOptions{})
+	if err != nil {
+		return crosschain.Message{}, err
+	}
+	return *message, nil
+}
+
+// GetMessageStatus returns the status of the message stored under txHash
+func (f *FakeMessenger) GetMessageStatus(ctx context.Context, txHash string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, err := f.lookup("GetMessageStatus", txHash, 0, crosschain.SubmitOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return message.Status, nil
+}
+
+// ProveMessage marks the message proven and starts its challenge period. Proving again
+// before finalizing restarts the period, as a re-prove does on chain.
+func (f *FakeMessenger) ProveMessage(ctx context.Context, txHash string, messageIndex int, opts crosschain.SubmitOptions) (common.Hash, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, err := f.lookup("ProveMessage", txHash, messageIndex, opts)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if message.Status == crosschain.StatusFinalized {
+		return common.Hash{}, crosschain.ErrAlreadyFinalized
+	}
+	message.Status = crosschain.StatusProven
+	f.provenAt[txHash] = f.now()
+	return f.submit(opts), nil
+}
+
+// FinalizeMessage marks the message finalized once it is proven and its challenge period
+// is over
+func (f *FakeMessenger) FinalizeMessage(ctx context.Context, txHash string, messageIndex int, opts crosschain.SubmitOptions) (common.Hash, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, err := f.lookup("FinalizeMessage", txHash, messageIndex, opts)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	switch message.Status {
+	case crosschain.StatusFinalized:
+		return common.Hash{}, crosschain.ErrAlreadyFinalized
+	case crosschain.StatusReadyToProve:
+		return common.Hash{}, crosschain.ErrNotProven
+	}
+	if finalizeAt := f.provenAt[txHash].Add(f.ChallengePeriod); f.now().Before(finalizeAt) {
+		return common.Hash{}, fmt.Errorf("%w: can finalize after %s", crosschain.ErrChallengePeriodActive, finalizeAt.Format(time.RFC3339))
+	}
+	message.Status = crosschain.StatusFinalized
+	delete(f.provenAt, txHash)
+	return f.submit(opts), nil
+}
+
+// WaitForStatus polls the stored message every poll until it reaches target
+// (StatusProven, StatusFinalized or crosschain.WaitReadyToFinalize) or ctx is done
+func (f *FakeMessenger) WaitForStatus(ctx context.Context, txHash string, messageIndex int, target int, poll time.Duration) error {
+	if target != crosschain.StatusProven && target != crosschain.StatusFinalized && target != crosschain.WaitReadyToFinalize {
+		return fmt.Errorf("unsupported wait target %d", target)
+	}
+	if poll <= 0 {
+		poll = crosschain.DefaultWaitPollInterval
+	}
+	for {
+		reached, err := f.reached(txHash, messageIndex, target)
+		if err != nil {
+			return err
+		}
+		if reached {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for status %d: %w", target, ctx.Err())
+		case <-time.After(poll):
+		}
+	}
+}
+
+// reached reports whether the message in txHash is at target
+func (f *FakeMessenger) reached(txHash string, messageIndex int, target int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	message, err := f.lookup("WaitForStatus", txHash, messageIndex, crosschain.SubmitOptions{})
+	if err != nil {
+		return false, err
+	}
+	switch target {
+	case crosschain.WaitReadyToFinalize:
+		if message.Status == crosschain.StatusProven {
+			return !f.now().Before(f.provenAt[txHash].Add(f.ChallengePeriod)), nil
+		}
+		return message.Status == crosschain.StatusFinalized, nil
+	case crosschain.StatusProven:
+		return message.Status >= crosschain.StatusProven, nil
+	default:
+		return message.Status == crosschain.StatusFinalized, nil
+	}
+}
+
+// lookup records the call and returns the configured error for method or the stored
+// message; a hash without a message is not a withdrawal. f.mu must be held.
+func (f *FakeMessenger) lookup(method, txHash string, messageIndex int, opts crosschain.SubmitOptions) (*crosschain.Message, error) {
+	f.calls = append(f.calls, Call{Method: method, TxHash: txHash, MessageIndex: messageIndex, Options: opts})
+	if err := f.Errors[method]; err != nil {
+		return nil, err
+	}
+	message, ok := f.messages[txHash]
+	if !ok {
+		return nil, &crosschain.NoWithdrawalFoundError{TxHash: txHash}
+	}
+	return message, nil
+}
+
+// submit returns a fake L1 transaction hash and reports it to opts.OnSubmitted. f.mu
+// must be held.
+func (f *FakeMessenger) submit(opts crosschain.SubmitOptions) common.Hash {
+	f.nonce++
+	hash := crypto.Keccak256Hash([]byte(fmt.Sprintf("crosschaintest-%d", f.nonce)))
+	if opts.OnSubmitted != nil {
+		opts.OnSubmitted(hash)
+	}
+	return hash
+}
+
+// now returns f.Now(), or the wall clock when Now is unset
+func (f *FakeMessenger) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
+	}
+	return time.Now()
+}
@@ -4,22 +4,57 @@ import (
 	"encoding/json"
 	cross_abi "mantle-claim-crossing/abi"
 	"math/big"
+	"sync"
 
 	"github.com/aws/aws-sdk-go-v2/service/kms"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // CrossChainMessenger handles cross-chain operations
 type CrossChainMessenger struct {
-	L1RpcUrl      string
-	L2RpcUrl      string
-	KMSKeyID      string      // AWS KMS key ID for signing (if using KMS)
-	KMSClient     *kms.Client // AWS KMS Client
-	PrivateKey    string      // Private key hex for signing (if not using KMS)
-	WalletAddress string
-	ClientL1      *ethclient.Client
-	ClientL2      *ethclient.Client
-	Contracts     CrossChainContracts
+	L1RpcUrl        string
+	L2RpcUrl        string
+	KMSKeyID        string         // AWS KMS key ID for signing (if using KMS)
+	KMSClient       *kms.Client    // AWS KMS Client; nil means one per region from KMS
+	KMS             KMSSettings    // Region and role for the KMS clients created on demand
+	PrivateKey      string         // Private key hex for signing (if not using KMS)
+	Signers         []SignerConfig // Additional signers, chosen per operation by wallet address
+	WalletAddress   string         // Default signer's wallet, set once it is resolved; guarded by signerMu
+	ClientL1        EthClient      // FailoverClient over L1RpcUrl and its fallbacks
+	ClientL2        EthClient      // FailoverClient over L2RpcUrl and its fallbacks
+	ClientL2Archive EthClient      // Archive L2 endpoint for proof generation only; nil means ClientL2
+	Contracts       CrossChainContracts
+	Explorer        Explorer          // Block explorer base URLs for links
+	Logger          Logger            // Leveled output; nil discards everything
+	Gas             GasSettings       // Gas limit and fee overrides for L1 transactions
+	Cost            CostSettings      // Finalize cost guard (MAX_FINALIZE_COST_USD, MIN_VALUE_RATIO)
+	Timeouts        Timeouts          // Bounds for blocking operations
+	Retry           RetryPolicy       // Retry/timeout policy for read-only RPC calls
+	Replacement     ReplacementPolicy // Fee bumping for transactions that aren't mined in time
+	L2Confirmations uint64            // L2 blocks a withdrawal needs before GetMessages trusts it; 0 disables the check
+	L1Confirmations uint64            // L1 blocks on top of a mined transaction before waitMined returns it; 0 returns it at once
+	SimulationFrom  common.Address    // Sender for offline gas estimates; zero means the wallet, else the withdrawal's L2 sender
+	L1ChainID       uint64            // Expected L1 chain ID; 0 accepts any
+	L2ChainID       uint64            // Expected L2 chain ID; 0 accepts any
+	CompactProofs   bool              // Experimental: drop withdrawal proof nodes off the path to the slot (--compact-proof)
+	DebugProof      bool              // Log every withdrawal proof node at info level and add them to bundles (--debug-proof)
+	Progress        ProgressFunc      // Stages of long operations; see WithProgress for per-call reporting
+	LockDir         string            // Per-withdrawal lock files held while proving or finalizing; empty disables
+
+	sendMu    sync.Mutex                // Serializes nonce assignment across concurrent submissions
+	nextNonce map[common.Address]uint64 // Nonce after our last sent transaction per wallet; missing means ask the node
+	tokens    tokenMetadataCache        // Decimals and symbols of ERC20 tokens, for WithdrawalValues
+
+	cache        messengerCache // Contract bindings and L2 output lookups; see ClearCache
+	rateLimiters *rateLimiters  // Per-endpoint request limits of the dialed clients
+
+	signerMu       sync.Mutex
+	signerAddrs    map[SignerConfig]common.Address // Wallet address of each signer resolved so far
+	remoteSigners  map[string]*rpc.Client          // Remote signer connections by URL
+	kmsClients     map[string]*kms.Client          // KMS clients by region ("" for the AWS default)
+	kmsTransactors map[string]*bind.TransactOpts   // KMS transactors by normalized key ID
 }
 
 type CrossChainContracts struct {
@@ -55,13 +90,17 @@ type Message struct {
 	LogIndex    uint64
 	Direction   string
 	Status      int
-	MsgNonce *big.Int
+	MsgNonceRaw     *big.Int // Versioned nonce from MessagePassed; what the withdrawal hash and portal calls use
+	MsgNonceDecoded *big.Int // MsgNonceRaw without the version bytes
+	MessageVersion  uint16   // Version from the top two bytes of MsgNonceRaw
 	WithdrawalHash string
 	MntValue *big.Int
 	EthValue *big.Int
 	SentMessageEvent   *cross_abi.L2CrossDomainMessengerSentMessage
 	SentMessageExtension1Event *cross_abi.L2CrossDomainMessengerSentMessageExtension1
 	MessagePassedEvent *cross_abi.L2ToL1MessagePasserMessagePassed
+	TokenWithdrawal *TokenWithdrawalInfo // Decoded L1StandardBridge call; nil for other messages
+	Provenance *ProofProvenance // Output the withdrawal was proven against; nil unless proven
 }
 
 // RPCRequest represents a JSON-RPC request
@@ -0,0 +1,112 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// discoveryChunkSize caps the block range of a single eth_getLogs query; most public
+// endpoints reject wider ranges
+const discoveryChunkSize = 10_000
+
+// DiscoveredWithdrawal is a MessagePassed event found by FindWithdrawals
+type DiscoveredWithdrawal struct {
+	TxHash         string
+	BlockNumber    uint64
+	Sender         common.Address
+	WithdrawalHash string
+	Finalized      bool
+}
+
+// GetLatestL2Block returns the current L2 head block number
+func (m *CrossChainMessenger) GetLatestL2Block(ctx context.Context) (uint64, error) {
+	return withRetry(ctx, m, "L2 eth_blockNumber", func(ctx context.Context) (uint64, error) {
+		return m.ClientL2.BlockNumber(ctx)
+	})
+}
+
+// FindWithdrawals scans L2ToL1MessagePasser MessagePassed events in [fromBlock, toBlock]
+// whose sender is one of senders, and reports whether each is already finalized on L1
+func (m *CrossChainMessenger) FindWithdrawals(ctx context.Context, senders []common.Address, fromBlock, toBlock uint64) (_ []DiscoveredWithdrawal, err error) {
+	ctx, span := startSpan(ctx, "FindWithdrawals", attrFromBlock.Int64(int64(fromBlock)), attrToBlock.Int64(int64(toBlock)))
+	defer func() { endSpan(span, err) }()
+	if len(senders) == 0 || fromBlock > toBlock {
+		return nil, nil
+	}
+
+	passer, err := cross_abi.NewL2ToL1MessagePasserFilterer(common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser), m.ClientL2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create L2ToL1MessagePasser filterer: %w", err)
+	}
+
+	var found []DiscoveredWithdrawal
+	for start := fromBlock; start <= toBlock; start += discoveryChunkSize {
+		end := min(start+discoveryChunkSize-1, toBlock)
+		m.logger().Debugf("🔎 Scanning MessagePassed events in L2 blocks %d-%d", start, end)
+
+		iter, err := withRetry(ctx, m, "L2 eth_getLogs", func(ctx context.Context) (*cross_abi.L2ToL1MessagePasserMessagePassedIterator, error) {
+			return passer.FilterMessagePassed(&bind.FilterOpts{Start: start, End: &end, Context: ctx}, nil, senders, nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter MessagePassed events in blocks %d-%d: %w", start, end, err)
+		}
+		for iter.Next() {
+			event := iter.Event
+			computed, err := ComputeWithdrawalHash(cross_abi.TypesWithdrawalTransaction{
+				Nonce:    event.Nonce,
+				Sender:   event.Sender,
+				Target:   event.Target,
+				MntValue: event.MntValue,
+				EthValue: event.EthValue,
+				GasLimit: event.GasLimit,
+				Data:     event.Data,
+			})
+			if err != nil {
+				m.logger().Warnf("⚠️  Skipping MessagePassed in %s: %v", event.Raw.TxHash.Hex(), err)
+				continue
+			}
+			if computed != common.Hash(event.WithdrawalHash) {
+				m.logger().Warnf("⚠️  Skipping MessagePassed in %s: %v (event %s, computed %s)", event.Raw.TxHash.Hex(),
+					ErrWithdrawalHashMismatch, common.Hash(event.WithdrawalHash).Hex(), computed.Hex())
+				continue
+			}
+			found = append(found, DiscoveredWithdrawal{
+				TxHash:         event.Raw.TxHash.Hex(),
+				BlockNumber:    event.Raw.BlockNumber,
+				Sender:         event.Sender,
+				WithdrawalHash: common.Hash(event.WithdrawalHash).Hex(),
+			})
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MessagePassed events in blocks %d-%d: %w", start, end, err)
+		}
+	}
+
+	for i := range found {
+		finalized, err := m.checkFinalizationStatus(ctx, found[i].WithdrawalHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check finalization of %s: %w", found[i].TxHash, err)
+		}
+		found[i].Finalized = finalized
+	}
+	return found, nil
+}
+
+// DiscoveryWindow returns the block range to scan: from lastScanned+1 when a previous
+// scan exists, otherwise lookback blocks back from head
+func DiscoveryWindow(head, lastScanned, lookback uint64) (uint64, uint64) {
+	if lastScanned > 0 {
+		return lastScanned + 1, head
+	}
+	if lookback >= head {
+		return 0, head
+	}
+	return head - lookback, head
+}
@@ -0,0 +1,273 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Check outcomes
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn" // Works, but is likely to cause trouble later
+	CheckFail = "fail"
+	CheckSkip = "skip" // Not applicable to this configuration, e.g. KMS without KMS keys
+)
+
+const (
+	// checkTimeout bounds each check, so one unreachable endpoint doesn't stall the rest
+	checkTimeout = 30 * time.Second
+
+	// lowBalanceWei is the wallet balance below which the balance check warns: about
+	// one prove at elevated L1 fees
+	lowBalanceWei = 10_000_000_000_000_000 // 0.01 ETH
+)
+
+// CheckResult is the outcome of one configuration check
+type CheckResult struct {
+	Name     string `json:"name"`
+	Critical bool   `json:"critical"` // Part of ReadinessChecks
+	Status   string `json:"status"`   // CheckPass, CheckWarn, CheckFail or CheckSkip
+	Detail   string `json:"detail,omitempty"`
+	Hint     string `json:"hint,omitempty"` // How to fix a failure or warning
+}
+
+// Failed reports whether the check failed
+func (r CheckResult) Failed() bool {
+	return r.Status == CheckFail
+}
+
+// Check is one independent configuration check. Run fills in Status, Detail and Hint;
+// RunChecks adds Name and Critical.
+type Check struct {
+	Name     string
+	Critical bool // Without it nothing works, so readiness probes run it too
+	Run      func(ctx context.Context) CheckResult
+}
+
+// DoctorChecks returns every check of the messenger's configuration, critical ones first:
+// RPC endpoints and chain IDs, contract code, signers, then the oracle version,
+// eth_getProof support on L2, the portal's paused flag, KMS keys and wallet balances
+func (m *CrossChainMessenger) DoctorChecks() []Check {
+	return append(m.ReadinessChecks(true),
+		Check{Name: "L2OutputOracle version", Run: m.checkOracleVersion},
+		Check{Name: "OptimismPortal paused", Run: m.checkPortalPaused},
+		Check{Name: "L2 eth_getProof", Run: m.checkGetProof},
+		Check{Name: "KMS keys", Run: m.checkKMSKeys},
+		Check{Name: "Wallet balances", Run: m.checkBalances},
+	)
+}
+
+// ReadinessChecks returns the critical checks, cheap enough for a readiness probe:
+// the RPC endpoints answer with the expected chain IDs and the L1 contracts exist.
+// signing adds resolving the signers' addresses.
+func (m *CrossChainMessenger) ReadinessChecks(signing bool) []Check {
+	checks := []Check{
+		{Name: "L1 RPC", Critical: true, Run: m.rpcCheck("L1_RPC", m.ClientL1, m.L1ChainID)},
+		{Name: "L2 RPC", Critical: true, Run: m.rpcCheck("L2_RPC", m.ClientL2, m.L2ChainID)},
+	}
+	if m.ClientL2Archive != nil {
+		checks = append(checks, Check{Name: "L2 archive RPC", Critical: true, Run: m.rpcCheck("L2_ARCHIVE_RPC", m.ClientL2Archive, m.L2ChainID)})
+	}
+	checks = append(checks,
+		Check{Name: "OptimismPortal code", Critical: true, Run: m.codeCheck("L1_OPTIMISM_PORTAL", m.Contracts.L1.OptimismPortal)},
+		Check{Name: "L2OutputOracle code", Critical: true, Run: m.codeCheck("L2_OUTPUT_ORACLE", m.Contracts.L1.L2OutputOracle)},
+		Check{Name: "L1CrossDomainMessenger code", Critical: true, Run: m.codeCheck("L1_CROSS_DOMAIN_MESSENGER", m.Contracts.L1.L1CrossDomainMessenger)},
+	)
+	if signing {
+		checks = append(checks, Check{Name: "Signers", Critical: true, Run: m.checkSigners})
+	}
+	return checks
+}
+
+// RunChecks runs checks one after another, each bounded by its own timeout
+func (m *CrossChainMessenger) RunChecks(ctx context.Context, checks []Check) []CheckResult {
+	results := make([]CheckResult, 0, len(checks))
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		result := check.Run(checkCtx)
+		cancel()
+		result.Name = check.Name
+		result.Critical = check.Critical
+		results = append(results, result)
+	}
+	return results
+}
+
+// FirstFailure returns the first failed result as an error, or nil when none failed
+func FirstFailure(results []CheckResult) error {
+	for _, r := range results {
+		if r.Failed() {
+			return fmt.Errorf("%s: %s", r.Name, r.Detail)
+		}
+	}
+	return nil
+}
+
+func checkPass(format string, args ...any) CheckResult {
+	return CheckResult{Status: CheckPass, Detail: fmt.Sprintf(format, args...)}
+}
+
+func checkSkip(detail string) CheckResult {
+	return CheckResult{Status: CheckSkip, Detail: detail}
+}
+
+func checkFail(hint string, format string, args ...any) CheckResult {
+	return CheckResult{Status: CheckFail, Detail: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+// rpcCheck checks that client answers and serves the chain expected (0 accepts any)
+func (m *CrossChainMessenger) rpcCheck(envVar string, client EthClient, expected uint64) func(context.Context) CheckResult {
+	return func(ctx context.Context) CheckResult {
+		id, err := client.ChainID(ctx)
+		if err != nil {
+			return checkFail(fmt.Sprintf("Check that %s is reachable from this host and the URL and any API key are right", envVar),
+				"eth_chainId failed: %v", err)
+		}
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return checkFail(fmt.Sprintf("The endpoint in %s answers eth_chainId but not eth_blockNumber; try another provider", envVar),
+				"eth_blockNumber failed: %v", err)
+		}
+		actual := id.Uint64()
+		if expected != 0 && actual != expected {
+			return checkFail(fmt.Sprintf("Point %s at %s, or pick the matching NETWORK", envVar, chainName(expected)),
+				"serves %s (%d), expected %s (%d)", chainName(actual), actual, chainName(expected), expected)
+		}
+		return checkPass("%s (%d), block %d", chainName(actual), actual, head)
+	}
+}
+
+// codeCheck checks that a contract is deployed at address on L1
+func (m *CrossChainMessenger) codeCheck(envVar, address string) func(context.Context) CheckResult {
+	return func(ctx context.Context) CheckResult {
+		if !common.IsHexAddress(address) {
+			return checkFail(fmt.Sprintf("Set %s to the contract's 0x address", envVar), "%q is not an address", address)
+		}
+		code, err := m.ClientL1.CodeAt(ctx, common.HexToAddress(address), nil)
+		if err != nil {
+			return checkFail("Check the L1 RPC", "eth_getCode failed: %v", err)
+		}
+		if len(code) == 0 {
+			return checkFail(fmt.Sprintf("Wrong network or %s misconfigured; unset it to use the NETWORK preset", envVar),
+				"no contract code at %s", address)
+		}
+		return checkPass("%d bytes of code at %s", len(code), address)
+	}
+}
+
+// checkSigners resolves every signer's address, which reaches KMS or the remote signer
+func (m *CrossChainMessenger) checkSigners(ctx context.Context) CheckResult {
+	if !m.HasSigner() {
+		return checkSkip("no signer configured (read-only)")
+	}
+	addresses, err := m.WalletAddresses(ctx)
+	if err != nil {
+		return checkFail("Check KMS_KEY_ID and the AWS credentials and region, PRIV_KEY, or SIGNER_RPC_URL and SIGNER_ADDRESS",
+			"%v", err)
+	}
+	hexes := make([]string, len(addresses))
+	for i, addr := range addresses {
+		hexes[i] = addr.Hex()
+	}
+	return checkPass("%s", strings.Join(hexes, ", "))
+}
+
+// checkOracleVersion calls version() on the L2OutputOracle, which also shows the
+// address holds an oracle rather than some other contract
+func (m *CrossChainMessenger) checkOracleVersion(ctx context.Context) CheckResult {
+	oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return checkFail("Set L2_OUTPUT_ORACLE to the oracle's address", "%v", err)
+	}
+	version, err := oracle.Version(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return checkFail("L2_OUTPUT_ORACLE may point at a contract that isn't the L2OutputOracle (or its proxy)",
+			"version() failed: %v", err)
+	}
+	return checkPass("version %s", version)
+}
+
+// checkGetProof asks the proof endpoint for a proof at the latest output's L2 block, the
+// kind of block a prove needs state for. Full nodes prune that state within hours.
+func (m *CrossChainMessenger) checkGetProof(ctx context.Context) CheckResult {
+	output, err := m.latestOutputFromOracle(ctx)
+	if err != nil {
+		return checkFail("Check the L1 RPC and L2_OUTPUT_ORACLE", "failed to read the latest output: %v", err)
+	}
+	var result struct {
+		StorageHash common.Hash `json:"storageHash"`
+	}
+	err = m.proofClient().CallContext(ctx, &result, "eth_getProof",
+		common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser), []string{common.Hash{}.Hex()}, fmt.Sprintf("0x%x", output.L2BlockNumber))
+	endpoint := "L2_RPC"
+	if m.ClientL2Archive != nil {
+		endpoint = "L2_ARCHIVE_RPC"
+	}
+	switch {
+	case isMissingStateError(err):
+		return checkFail(fmt.Sprintf("Point %s at an archive node; proves need state from hours back", endpoint),
+			"no state for L2 block %d (output #%d): %v", output.L2BlockNumber, output.OutputIndex, err)
+	case err != nil:
+		return checkFail(fmt.Sprintf("%s must support eth_getProof; some providers disable it", endpoint),
+			"eth_getProof at L2 block %d failed: %v", output.L2BlockNumber, err)
+	}
+	return checkPass("proof at L2 block %d (output #%d) from %s", output.L2BlockNumber, output.OutputIndex, endpoint)
+}
+
+// checkKMSKeys checks that every KMS key exists, is enabled and has the secp256k1 spec
+func (m *CrossChainMessenger) checkKMSKeys(ctx context.Context) CheckResult {
+	var keys []string
+	for _, cfg := range m.signerConfigs() {
+		if cfg.KMSKeyID != "" {
+			keys = append(keys, normalizeKMSKeyID(cfg.KMSKeyID))
+		}
+	}
+	if len(keys) == 0 {
+		return checkSkip("no KMS keys configured")
+	}
+	if err := m.verifyKMSKeys(ctx); err != nil {
+		return checkFail("Check the AWS credentials, AWS_REGION/KMS_REGION and kms:DescribeKey on the key; "+
+			"signing keys must be ECC_SECG_P256K1 with SIGN_VERIFY usage", "%v", err)
+	}
+	return checkPass("%d key(s) enabled, ECC_SECG_P256K1, SIGN_VERIFY", len(keys))
+}
+
+// checkBalances reads every wallet's L1 balance, failing on an empty one and warning
+// below lowBalanceWei
+func (m *CrossChainMessenger) checkBalances(ctx context.Context) CheckResult {
+	if !m.HasSigner() {
+		return checkSkip("no signer configured (read-only)")
+	}
+	addresses, err := m.WalletAddresses(ctx)
+	if err != nil {
+		return checkSkip("signers could not be resolved")
+	}
+	var empty, low, balances []string
+	for _, addr := range addresses {
+		balance, err := m.ClientL1.BalanceAt(ctx, addr, nil)
+		if err != nil {
+			return checkFail("Check the L1 RPC", "eth_getBalance of %s failed: %v", addr.Hex(), err)
+		}
+		balances = append(balances, fmt.Sprintf("%s: %s ETH", addr.Hex(), FormatEther(balance)))
+		switch {
+		case balance.Sign() == 0:
+			empty = append(empty, addr.Hex())
+		case balance.Cmp(big.NewInt(lowBalanceWei)) < 0:
+			low = append(low, addr.Hex())
+		}
+	}
+	detail := strings.Join(balances, ", ")
+	switch {
+	case len(empty) > 0:
+		return checkFail("Fund "+strings.Join(empty, ", ")+" on L1; every prove and finalize pays L1 gas", "%s", detail)
+	case len(low) > 0:
+		return CheckResult{Status: CheckWarn, Detail: detail, Hint: "Top up " + strings.Join(low, ", ") + "; under 0.01 ETH may not cover a prove when L1 fees rise"}
+	}
+	return checkPass("%s", detail)
+}
@@ -1,19 +1,96 @@
 package crosschain
 
 import (
+	"errors"
 	"fmt"
 	cross_abi "mantle-claim-crossing/abi"
+	"maps"
 	"math/big"
-	"strings"
+	"slices"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
+// Event IDs (first topic) of the withdrawal events, computed from the bound ABIs so the
+// parser follows regenerated bindings instead of matching stale literals
+var (
+	SentMessageTopic           = eventTopic(cross_abi.L2CrossDomainMessengerMetaData, "SentMessage")
+	SentMessageExtension1Topic = eventTopic(cross_abi.L2CrossDomainMessengerMetaData, "SentMessageExtension1")
+	MessagePassedTopic         = eventTopic(cross_abi.L2ToL1MessagePasserMetaData, "MessagePassed")
+)
+
+// knownEventTopics are the event IDs Mantle mainnet emits. A binding regenerated with a
+// different event signature would otherwise match no logs and leave every withdrawal
+// looking like a plain transaction, so checkEventTopics makes NewCrossChainMessenger
+// fail instead.
+var knownEventTopics = map[string]struct {
+	computed common.Hash
+	mainnet  string
+}{
+	"SentMessage":           {SentMessageTopic, "0xcb0f7ffd78f9aee47a248fae8db181db6eee833039123e026dcbff529522e52a"},
+	"SentMessageExtension1": {SentMessageExtension1Topic, "0xcf00802ba1f8c659140235227979ca08afaba336a9f9fdc4a5107ed9e8013d08"},
+	"MessagePassed":         {MessagePassedTopic, "0x5da382596b838a63b4248e533d8e399b3b0f13ba6c6679f670489d44716cb173"},
+}
+
+// checkEventTopics returns an error naming the first event whose ID in the bound ABI
+// isn't the one mainnet emits
+func checkEventTopics() error {
+	for _, name := range slices.Sorted(maps.Keys(knownEventTopics)) {
+		topic := knownEventTopics[name]
+		if topic.computed == (common.Hash{}) {
+			return fmt.Errorf("event %s is missing from the bound ABI", name)
+		}
+		if topic.computed != common.HexToHash(topic.mainnet) {
+			return fmt.Errorf("%s topic from the bound ABI is %s, but mainnet emits %s", name, topic.computed.Hex(), topic.mainnet)
+		}
+	}
+	return nil
+}
+
+// eventTopic returns the ID of the event name in a bound contract's ABI, or the zero
+// hash when the ABI doesn't load or lacks the event; checkEventTopics reports that
+func eventTopic(meta *bind.MetaData, name string) common.Hash {
+	parsed, err := meta.GetAbi()
+	if err != nil {
+		return common.Hash{}
+	}
+	return parsed.Events[name].ID
+}
+
+// receiptLogs are a receipt's logs grouped by their first topic, in log order, so each
+// event is looked up once instead of every parser walking all logs
+type receiptLogs map[common.Hash][]*types.Log
+
+func indexLogs(receipt *types.Receipt) receiptLogs {
+	logs := make(receiptLogs)
+	for _, log := range receipt.Logs {
+		if len(log.Topics) > 0 {
+			logs[log.Topics[0]] = append(logs[log.Topics[0]], log)
+		}
+	}
+	return logs
+}
+
+// NonceMask selects the nonce itself from a versioned nonce; the top two bytes hold the
+// message version
 var NonceMask, _ = new(big.Int).SetString("0000ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 16)
 
+// DecodeVersionedNonce splits a versioned MessagePassed nonce into the nonce and the
+// message version encoded in its top two bytes. The withdrawal hash and the portal calls
+// use the versioned value as-is; this is for display and comparison only.
+func DecodeVersionedNonce(versioned *big.Int) (nonce *big.Int, version uint16) {
+	if versioned == nil {
+		return new(big.Int), 0
+	}
+	nonce = new(big.Int).And(versioned, NonceMask)
+	version = uint16(new(big.Int).Rsh(versioned, 240).Uint64())
+	return nonce, version
+}
+
 // parseSentMessageWithABI uses the generated ABI code to parse SentMessage events
-func parseSentMessageWithABI(log *types.Log) (*cross_abi.L2CrossDomainMessengerSentMessage, error) {
+func (m *CrossChainMessenger) parseSentMessageWithABI(log *types.Log) (*cross_abi.L2CrossDomainMessengerSentMessage, error) {
 	// Convert our Log structure to ethereum types.Log
 	ethLog := types.Log{
 		Address: log.Address,
@@ -33,71 +110,89 @@ func parseSentMessageWithABI(log *types.Log) (*cross_abi.L2CrossDomainMessengerS
 		return nil, fmt.Errorf("failed to parse SentMessage with ABI: %w", err)
 	}
 	
-	fmt.Printf("  📋 Parsed SentMessage (ABI): Target=%s, Sender=%s, Nonce=%s, GasLimit=%s\n", 
+	m.logger().Debugf("  📋 Parsed SentMessage (ABI): Target=%s, Sender=%s, Nonce=%s, GasLimit=%s",
 		sentMsg.Target.Hex(), sentMsg.Sender.Hex(), fmt.Sprintf("0x%x", sentMsg.MessageNonce), fmt.Sprintf("0x%x", sentMsg.GasLimit))
 	
 	return sentMsg, nil
 }
 
 
-// Enhanced parsing method that uses improved ABI-like parsing
-func (m *CrossChainMessenger) parseSentMessageLogsEnhanced(receipt *types.Receipt) (Message, error) {
+// Enhanced parsing method that uses improved ABI-like parsing. Logs that match the event
+// but fail to decode are reported together, each with its log index.
+func (m *CrossChainMessenger) parseSentMessageLogsEnhanced(receipt *types.Receipt, logs receiptLogs) (Message, error) {
 	var message Message
+	var errs []error
 
-	// SentMessage event signature: SentMessage(address,address,bytes,uint256,uint256)
-	// This is the keccak256 hash of the event signature
-	sentMessageTopic := "0xcb0f7ffd78f9aee47a248fae8db181db6eee833039123e026dcbff529522e52a"
-
-	for _, log := range receipt.Logs {
+	for _, log := range logs[SentMessageTopic] {
 		if log.Address != common.HexToAddress(m.Contracts.Bridges.L2CrossDomainMessenger) {
 			continue
 		}
-		// fmt.Printf("📄 Log %d: address=%s, topics=%v\n", i, log.Address, log.Topics)
-		// fmt.Printf("  📝 Raw log data: %s\n", hex.EncodeToString(log.Data))
+		// m.logger().Debugf("📄 Log %d: address=%s, topics=%v\n", i, log.Address, log.Topics)
+		// m.logger().Debugf("  📝 Raw log data: %s", hex.EncodeToString(log.Data))
 		
 		// Parse block number and log index
 		blockNumber := receipt.BlockNumber.Uint64()
 		logIndex := uint64(log.Index)
 		// Try to parse using the generated ABI code first (BEST METHOD)
-		if len(log.Topics) > 0 && strings.EqualFold(log.Topics[0].String(), sentMessageTopic) {
-			eventData, _ := parseSentMessageWithABI(log)
-
-			message = Message{
-				TxHash:      receipt.TxHash.Hex(),
-				BlockNumber: blockNumber,
-				LogIndex:    logIndex,
-				Direction:   "L2_TO_L1",
-				Status:      0, // Will be updated later
-				SentMessageEvent:   eventData,
-			}
+		eventData, err := m.parseSentMessageWithABI(log)
+		if err != nil {
+			errs = append(errs, logParseError(log, err))
+			continue
+		}
+
+		message = Message{
+			TxHash:      receipt.TxHash.Hex(),
+			BlockNumber: blockNumber,
+			LogIndex:    logIndex,
+			Direction:   "L2_TO_L1",
+			Status:      0, // Will be updated later
+			SentMessageEvent:   eventData,
 		}
 	}
 
-	return message, nil
+	return message, errors.Join(errs...)
 }
 
-func (m *CrossChainMessenger) parseSentMessageExtension1LogsEnhanced(receipt *types.Receipt) (*cross_abi.L2CrossDomainMessengerSentMessageExtension1, error) {
+// parseSentMessageExtension1LogsEnhanced reads the MNT and ETH values of the withdrawal
+// from SentMessageExtension1, which the L2CrossDomainMessenger emits next to SentMessage.
+// When no log comes from the configured messenger, any log with the event's topic is
+// used instead, with a warning: missing values would default to 0 and break the
+// withdrawal hash, which verifyWithdrawalHash then checks either way.
+func (m *CrossChainMessenger) parseSentMessageExtension1LogsEnhanced(logs receiptLogs) (*cross_abi.L2CrossDomainMessengerSentMessageExtension1, error) {
 	var messagePassed *cross_abi.L2CrossDomainMessengerSentMessageExtension1
+	var errs []error
 
-	sentMessageExtension1Topic := "0xcf00802ba1f8c659140235227979ca08afaba336a9f9fdc4a5107ed9e8013d08"
-
-	for _, log := range receipt.Logs {
-		if log.Address != common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser) {
-			continue
+	messenger := common.HexToAddress(m.Contracts.Bridges.L2CrossDomainMessenger)
+	candidates := logs[SentMessageExtension1Topic]
+	var fromMessenger []*types.Log
+	for _, log := range candidates {
+		if log.Address == messenger {
+			fromMessenger = append(fromMessenger, log)
 		}
-		// fmt.Printf("📄 Log %d: address=%s, topics=%v\n", i, log.Address, log.Topics)
-		// fmt.Printf("  📝 Raw log data: %s\n", hex.EncodeToString(log.Data))
+	}
+	if len(fromMessenger) == 0 && len(candidates) > 0 {
+		m.logger().Warnf("⚠️  SentMessageExtension1 was emitted by %s, not L2CrossDomainMessenger %s; using its values anyway",
+			candidates[0].Address.Hex(), messenger.Hex())
+		fromMessenger = candidates
+	}
+
+	for _, log := range fromMessenger {
+		// m.logger().Debugf("📄 Log %d: address=%s, topics=%v\n", i, log.Address, log.Topics)
+		// m.logger().Debugf("  📝 Raw log data: %s", hex.EncodeToString(log.Data))
 		
 		// Try to parse using the generated ABI code first (BEST METHOD)
-		if len(log.Topics) > 0 && strings.EqualFold(log.Topics[0].String(), sentMessageExtension1Topic) {
-			messagePassed, _ = parseSentMessageExtension1WithABI(log)
+		event, err := m.parseSentMessageExtension1WithABI(log)
+		if err != nil {
+			errs = append(errs, logParseError(log, err))
+			continue
 		}
+		messagePassed = event
 	}
 
-	return messagePassed, nil
+	return messagePassed, errors.Join(errs...)
 }
 
-func parseSentMessageExtension1WithABI(log *types.Log) (*cross_abi.L2CrossDomainMessengerSentMessageExtension1, error) {
+func (m *CrossChainMessenger) parseSentMessageExtension1WithABI(log *types.Log) (*cross_abi.L2CrossDomainMessengerSentMessageExtension1, error) {
 	// Convert our Log structure to ethereum types.Log
 	ethLog := types.Log{
 		Address: log.Address,
@@ -117,34 +212,36 @@ func parseSentMessageExtension1WithABI(log *types.Log) (*cross_abi.L2CrossDomain
 		return nil, fmt.Errorf("failed to parse SentMessage with ABI: %w", err)
 	}
 	
-	fmt.Printf("  📋 Parsed SentMessage (ABI): Sender=%s, MntValue=%s, EthValue=%s\n", 
+	m.logger().Debugf("  📋 Parsed SentMessageExtension1 (ABI): Sender=%s, MntValue=%s, EthValue=%s",
 		sentMsg.Sender.Hex(), sentMsg.MntValue.String(), sentMsg.EthValue.String())
 	
 	return sentMsg, nil
 }
 
-func (m *CrossChainMessenger) parseMessagePassedLogsEnhanced(receipt *types.Receipt) (*cross_abi.L2ToL1MessagePasserMessagePassed, error) {
+func (m *CrossChainMessenger) parseMessagePassedLogsEnhanced(logs receiptLogs) (*cross_abi.L2ToL1MessagePasserMessagePassed, error) {
 	var messagePassed *cross_abi.L2ToL1MessagePasserMessagePassed
+	var errs []error
 
-	messagePassedTopic := "0x5da382596b838a63b4248e533d8e399b3b0f13ba6c6679f670489d44716cb173"
-
-	for _, log := range receipt.Logs {
+	for _, log := range logs[MessagePassedTopic] {
 		if log.Address != common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser) {
 			continue
 		}
-		// fmt.Printf("📄 Log %d: address=%s, topics=%v\n", i, log.Address, log.Topics)
-		// fmt.Printf("  📝 Raw log data: %s\n", hex.EncodeToString(log.Data))
+		// m.logger().Debugf("📄 Log %d: address=%s, topics=%v\n", i, log.Address, log.Topics)
+		// m.logger().Debugf("  📝 Raw log data: %s", hex.EncodeToString(log.Data))
 		
 		// Try to parse using the generated ABI code first (BEST METHOD)
-		if len(log.Topics) > 0 && strings.EqualFold(log.Topics[0].String(), messagePassedTopic) {
-			messagePassed, _ = parseMessagePassedWithABI(log)
+		event, err := m.parseMessagePassedWithABI(log)
+		if err != nil {
+			errs = append(errs, logParseError(log, err))
+			continue
 		}
+		messagePassed = event
 	}
 
-	return messagePassed, nil
+	return messagePassed, errors.Join(errs...)
 }
 
-func parseMessagePassedWithABI(log *types.Log) (*cross_abi.L2ToL1MessagePasserMessagePassed, error) {
+func (m *CrossChainMessenger) parseMessagePassedWithABI(log *types.Log) (*cross_abi.L2ToL1MessagePasserMessagePassed, error) {
 	// Convert our Log structure to ethereum types.Log
 	ethLog := types.Log{
 		Address: log.Address,
@@ -163,9 +260,13 @@ func parseMessagePassedWithABI(log *types.Log) (*cross_abi.L2ToL1MessagePasserMe
 		return nil, fmt.Errorf("failed to parse MessagePassed with ABI: %w", err)
 	}
 	
-	// fmt.Printf("  📋 Parsed MessagePassed (ABI): Target=%s, Sender=%s, Data=%s, Nonce=%s, GasLimit=%s, WithdrawHash=%s\n", 
+	// m.logger().Debugf("  📋 Parsed MessagePassed (ABI): Target=%s, Sender=%s, Data=%s, Nonce=%s, GasLimit=%s, WithdrawHash=%s\n", 
 	// 	messagePassed.Target.Hex(), messagePassed.Sender.Hex(), hex.EncodeToString(messagePassed.Data[:]), messagePassed.Nonce.String(), messagePassed.GasLimit.String(), hex.EncodeToString(messagePassed.WithdrawalHash[:]))
 	
 	return messagePassed, nil
 }
 
+// logParseError names the log that failed to decode
+func logParseError(log *types.Log, err error) error {
+	return fmt.Errorf("log %d (address %s, topic %s): %w", log.Index, log.Address.Hex(), log.Topics[0].Hex(), err)
+}
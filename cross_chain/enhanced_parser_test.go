@@ -0,0 +1,261 @@
+package crosschain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"testing"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestEventTopicsMatchMainnet(t *testing.T) {
+	if err := checkEventTopics(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventTopicMissingEvent(t *testing.T) {
+	if topic := eventTopic(cross_abi.L2ToL1MessagePasserMetaData, "NoSuchEvent"); topic != (common.Hash{}) {
+		t.Fatalf("eventTopic of a missing event = %s, want the zero hash", topic.Hex())
+	}
+}
+
+// sentMessageLog is the SentMessage log L2CrossDomainMessenger emits next to the
+// MessagePassed log of tx
+func sentMessageLog(t *testing.T, tx cross_abi.TypesWithdrawalTransaction) *types.Log {
+	t.Helper()
+	parsed, err := cross_abi.L2CrossDomainMessengerMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := parsed.Events["SentMessage"]
+	data, err := event.Inputs.NonIndexed().Pack(tx.Sender, tx.Data, tx.Nonce, tx.GasLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Log{
+		Address: common.HexToAddress(DefaultContracts().Bridges.L2CrossDomainMessenger),
+		Topics:  []common.Hash{event.ID, common.BytesToHash(tx.Target.Bytes())},
+		Data:    data,
+	}
+}
+
+// corrupt returns a copy of log at index whose data is cut short of the event's fields
+func corrupt(log *types.Log, index uint) *types.Log {
+	cut := *log
+	cut.Data = log.Data[:len(log.Data)/2]
+	cut.Index = index
+	return &cut
+}
+
+func TestParseCorruptedLogs(t *testing.T) {
+	tx := testWithdrawal()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messagePassed := messagePassedLog(t, tx, hash)
+	sentMessage := sentMessageLog(t, tx)
+
+	tests := []struct {
+		name string
+		logs []*types.Log
+		want []string
+	}{
+		{"MessagePassed", []*types.Log{corrupt(messagePassed, 3)}, []string{"log 3 ", MessagePassedTopic.Hex()}},
+		{"SentMessage", []*types.Log{corrupt(sentMessage, 1), messagePassed}, []string{"log 1 ", SentMessageTopic.Hex()}},
+		{"several", []*types.Log{corrupt(messagePassed, 3), corrupt(messagePassed, 5)}, []string{"log 3 ", "log 5 "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receipt := &types.Receipt{TxHash: common.HexToHash("0xaa"), BlockNumber: big.NewInt(7), Logs: tt.logs}
+			_, err := receiptMessenger(t, receipt).getMessageLocal(context.Background(), receipt.TxHash.Hex())
+			if err == nil {
+				t.Fatal("getMessageLocal() of corrupted logs succeeded")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Fatalf("getMessageLocal() error = %v, want it to name %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSentMessageWithoutMessagePassed(t *testing.T) {
+	receipt := &types.Receipt{
+		TxHash:      common.HexToHash("0xaa"),
+		BlockNumber: big.NewInt(7),
+		Logs:        []*types.Log{sentMessageLog(t, testWithdrawal())},
+	}
+	_, err := receiptMessenger(t, receipt).getMessageLocal(context.Background(), receipt.TxHash.Hex())
+	if !errors.Is(err, ErrMissingMessagePassed) {
+		t.Fatalf("getMessageLocal() = %v, want ErrMissingMessagePassed", err)
+	}
+	if errors.Is(err, ErrNoWithdrawalFound) {
+		t.Fatal("a SentMessage without MessagePassed was reported as no withdrawal")
+	}
+}
+
+func TestDecodeVersionedNonce(t *testing.T) {
+	versioned := func(version uint16, nonce int64) *big.Int {
+		v := new(big.Int).Lsh(big.NewInt(int64(version)), 240)
+		return v.Or(v, big.NewInt(nonce))
+	}
+	tests := []struct {
+		name      string
+		versioned *big.Int
+		nonce     *big.Int
+		version   uint16
+	}{
+		{"nil", nil, new(big.Int), 0},
+		{"version 0", big.NewInt(42), big.NewInt(42), 0},
+		{"version 1", versioned(1, 42), big.NewInt(42), 1},
+		{"version 1, nonce 0", versioned(1, 0), new(big.Int), 1},
+		{"largest", new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1), NonceMask, 0xffff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nonce, version := DecodeVersionedNonce(tt.versioned)
+			if nonce.Cmp(tt.nonce) != 0 || version != tt.version {
+				t.Fatalf("DecodeVersionedNonce(%v) = %s, %d, want %s, %d", tt.versioned, nonce, version, tt.nonce, tt.version)
+			}
+		})
+	}
+}
+
+// The decoded nonce is for display only: the withdrawal proven and finalized on L1
+// must carry the versioned nonce it was hashed with
+func TestVersionedNonceDestinations(t *testing.T) {
+	ctx := context.Background()
+	tx := testWithdrawal()
+	l2 := newFakeL2(t, tx)
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	txHash := l2.receipt.TxHash.Hex()
+
+	message, err := m.GetMessages(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.MsgNonceRaw.Cmp(tx.Nonce) != 0 || message.MsgNonceDecoded.Cmp(big.NewInt(42)) != 0 || message.MessageVersion != 1 {
+		t.Fatalf("message nonce = raw %s, decoded %s, version %d, want %s, 42, 1",
+			message.MsgNonceRaw, message.MsgNonceDecoded, message.MessageVersion, tx.Nonce)
+	}
+	if bm := newBundleMessage(message); bm.Nonce.Cmp(tx.Nonce) != 0 {
+		t.Fatalf("bundle nonce = %s, want the versioned %s", bm.Nonce, tx.Nonce)
+	}
+
+	checkNonce := func(method string) {
+		t.Helper()
+		name, args := l1.lastCall(t)
+		got := *abi.ConvertType(args[0], new(cross_abi.TypesWithdrawalTransaction)).(*cross_abi.TypesWithdrawalTransaction)
+		if name != method || got.Nonce.Cmp(tx.Nonce) != 0 {
+			t.Fatalf("%s sent nonce %s, want the versioned %s", name, got.Nonce, tx.Nonce)
+		}
+	}
+	if _, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	checkNonce("proveWithdrawalTransaction")
+	l1.advance(testPeriod)
+	if _, err := m.FinalizeMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	checkNonce("finalizeWithdrawalTransaction")
+}
+
+// sentMessageExtension1Log is the SentMessageExtension1 log emitted next to SentMessage,
+// from address, carrying the values of tx
+func sentMessageExtension1Log(t *testing.T, tx cross_abi.TypesWithdrawalTransaction, address common.Address) *types.Log {
+	t.Helper()
+	parsed, err := cross_abi.L2CrossDomainMessengerMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := parsed.Events["SentMessageExtension1"]
+	data, err := event.Inputs.NonIndexed().Pack(tx.MntValue, tx.EthValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &types.Log{
+		Address: address,
+		Topics:  []common.Hash{event.ID, common.BytesToHash(tx.Sender.Bytes())},
+		Data:    data,
+	}
+}
+
+// bridgeWithdrawal is an MNT withdrawal through the L2CrossDomainMessenger, which
+// passes it on to the message passer with its values
+func bridgeWithdrawal() cross_abi.TypesWithdrawalTransaction {
+	tx := testWithdrawal()
+	contracts := DefaultContracts()
+	tx.Sender = common.HexToAddress(contracts.Bridges.L2CrossDomainMessenger)
+	tx.Target = common.HexToAddress(contracts.L1.L1CrossDomainMessenger)
+	tx.MntValue = new(big.Int).Mul(big.NewInt(25), big.NewInt(1e18))
+	tx.EthValue = new(big.Int)
+	return tx
+}
+
+func TestSentMessageExtension1Values(t *testing.T) {
+	tx := bridgeWithdrawal()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messenger := common.HexToAddress(DefaultContracts().Bridges.L2CrossDomainMessenger)
+	passer := common.HexToAddress(DefaultContracts().Bridges.L2ToL1MessagePasser)
+	other := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	decoy := tx
+	decoy.MntValue = big.NewInt(1)
+
+	tests := []struct {
+		name      string
+		extension []*types.Log
+		from      common.Address
+		warn      bool
+	}{
+		{"from the messenger", []*types.Log{sentMessageExtension1Log(t, tx, messenger)}, messenger, false},
+		{"from another address", []*types.Log{sentMessageExtension1Log(t, tx, other)}, other, true},
+		// Matching by topic alone would have taken the decoy from the message passer's address
+		{"messenger preferred", []*types.Log{sentMessageExtension1Log(t, tx, messenger), sentMessageExtension1Log(t, decoy, passer)}, messenger, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := []*types.Log{sentMessageLog(t, tx)}
+			logs = append(logs, tt.extension...)
+			logs = append(logs, messagePassedLog(t, tx, hash))
+			receipt := &types.Receipt{TxHash: common.HexToHash("0xaa"), BlockNumber: big.NewInt(7), Logs: logs}
+
+			var out bytes.Buffer
+			m := receiptMessenger(t, receipt)
+			m.Logger = NewStdLogger(log.New(&out, "", 0), LogLevelWarn)
+			message, err := m.getMessageLocal(context.Background(), receipt.TxHash.Hex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if message.SentMessageExtension1Event == nil || message.SentMessageExtension1Event.Raw.Address != tt.from {
+				t.Fatalf("SentMessageExtension1 = %+v, want the one from %s", message.SentMessageExtension1Event, tt.from.Hex())
+			}
+			if message.MntValue.Cmp(tx.MntValue) != 0 || message.EthValue.Sign() != 0 {
+				t.Fatalf("values = %s MNT, %s ETH, want %s MNT", message.MntValue, message.EthValue, tx.MntValue)
+			}
+			if warned := strings.Contains(out.String(), "SentMessageExtension1 was emitted by "+tt.from.Hex()); warned != tt.warn {
+				t.Fatalf("warned = %t, want %t; log: %s", warned, tt.warn, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Error kinds returned by the public methods, so callers can tell why an operation
+// failed with errors.Is instead of matching messages
+var (
+	ErrNotProven             = errors.New("withdrawal is not proven yet")
+	ErrChallengePeriodActive = errors.New("challenge period has not passed yet")
+	ErrAlreadyFinalized      = errors.New("withdrawal is already finalized")
+	ErrOutputNotProposed     = errors.New("no L2 output covering the withdrawal has been proposed yet")
+	ErrRPC                   = errors.New("RPC request failed")
+	ErrReverted              = errors.New("contract call reverted")
+	ErrInsufficientFunds     = errors.New("wallet balance too low for the transaction")
+	ErrCostTooHigh           = errors.New("finalize cost exceeds the configured limit")
+	ErrProvingOutputDeleted  = errors.New("the output the withdrawal was proven against was deleted; prove it again")
+	ErrTimeout               = errors.New("operation timed out")
+
+	// ErrNoWithdrawalFound means the L2 transaction emitted no withdrawal events, e.g. a
+	// plain transfer was passed instead of a withdrawal
+	ErrNoWithdrawalFound = errors.New("transaction is not a withdrawal")
+
+	// ErrMissingMessagePassed means the L2CrossDomainMessenger emitted SentMessage but the
+	// message passer emitted nothing, which no known bridge path produces
+	ErrMissingMessagePassed = errors.New("SentMessage without MessagePassed")
+
+	// ErrWithdrawalHashMismatch means the parsed withdrawal doesn't hash to the
+	// MessagePassed event's withdrawalHash, so the wrong log was probably picked
+	ErrWithdrawalHashMismatch = errors.New("withdrawal hash mismatch")
+
+	// ErrStaleProof means a proof file was generated against an L2 output the oracle no
+	// longer holds, so proving with it would revert; generate a new one
+	ErrStaleProof = errors.New("proof file is stale")
+
+	// ErrNoArchiveState means the L2 endpoint used for eth_getProof has pruned the
+	// state of the block the proof is for
+	ErrNoArchiveState = errors.New("L2 RPC does not have archive state")
+
+	// ErrInsufficientConfirmations means the withdrawal's L2 block is too close to the
+	// head to trust yet (L2_CONFIRMATIONS); it isn't an error, just not ready
+	ErrInsufficientConfirmations = errors.New("L2 transaction does not have enough confirmations yet")
+
+	// ErrInvalidInput means an argument was malformed, e.g. a truncated transaction hash,
+	// and nothing was sent to either chain
+	ErrInvalidInput = errors.New("invalid input")
+
+	// ErrDuplicateTxHash means a list of transaction hashes, e.g. a batch, names one twice
+	ErrDuplicateTxHash = errors.New("duplicate transaction hash")
+
+	// ErrNotFinalized means a replay was asked for a withdrawal the OptimismPortal hasn't
+	// finalized, so its message was never relayed on L1
+	ErrNotFinalized = errors.New("withdrawal is not finalized yet")
+
+	// ErrNothingToReplay means the L1CrossDomainMessenger doesn't hold the withdrawal's
+	// message as failed: it was relayed successfully or never went through the messenger
+	ErrNothingToReplay = errors.New("relayed message has not failed; nothing to replay")
+
+	// ErrReplayFailed means a replay was mined but the relayed call reverted again; the
+	// message stays in failedMessages and can be replayed later
+	ErrReplayFailed = errors.New("replayed message failed again")
+
+	// ErrNothingPending means cancel-pending found no unmined transactions from the
+	// wallet: its pending nonce equals its latest one
+	ErrNothingPending = errors.New("wallet has no pending transactions")
+
+	// ErrNonceReplaced means another transaction was mined at the nonce of one we were
+	// waiting for, e.g. a cancellation, so ours will never be mined
+	ErrNonceReplaced = errors.New("transaction nonce was used by another transaction")
+
+	// ErrWithdrawalTxNotFound means FindWithdrawalTx saw no MessagePassed event with the
+	// withdrawal hash in the blocks it scanned; a wider range may still find it
+	ErrWithdrawalTxNotFound = errors.New("no L2 transaction with this withdrawal hash in the scanned blocks")
+
+	// ErrWithdrawalLocked means another process, or another goroutine of this one, holds
+	// the lock on the withdrawal while proving or finalizing it; nothing was sent
+	ErrWithdrawalLocked = errors.New("another process is operating on this withdrawal")
+
+	// ErrOutputRootMismatch means the output root rebuilt from the L2 RPC's state is not
+	// the one posted to the L2OutputOracle, for every covering output tried
+	ErrOutputRootMismatch = errors.New("output root mismatch")
+
+	// ErrPortalPaused means the guardian has paused OptimismPortal, so a prove or finalize
+	// would revert; nothing was sent
+	ErrPortalPaused = errors.New("OptimismPortal is paused")
+)
+
+// L2OutputOracle revert reasons that mean no output covers the requested block yet
+var outputNotProposedReasons = []string{"has not been proposed"}
+
+// Is makes every RevertError match ErrReverted
+func (e *RevertError) Is(target error) bool {
+	return target == ErrReverted
+}
+
+// statusRevertedError is the type of ErrTransactionReverted, which also matches ErrReverted
+type statusRevertedError struct{}
+
+func (statusRevertedError) Error() string {
+	return "transaction failed (status: 0)"
+}
+
+func (statusRevertedError) Is(target error) bool {
+	return target == ErrReverted
+}
+
+// InsufficientFundsError is returned before sending when the wallet can't cover the
+// transaction's worst-case cost; it matches ErrInsufficientFunds
+type InsufficientFundsError struct {
+	Wallet   common.Address
+	Balance  *big.Int // Wei
+	Required *big.Int // Max gas cost plus value, with the balance margin applied, in wei
+}
+
+// Shortfall is how much ETH (in wei) the wallet needs on top of its balance
+func (e *InsufficientFundsError) Shortfall() *big.Int {
+	return new(big.Int).Sub(e.Required, e.Balance)
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("wallet %s has %s ETH but needs ~%s ETH (short %s ETH)",
+		e.Wallet.Hex(), FormatEther(e.Balance), FormatEther(e.Required), FormatEther(e.Shortfall()))
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// NoWithdrawalFoundError is returned when an L2 transaction's receipt has no
+// MessagePassed event from the configured L2ToL1MessagePasser; it matches ErrNoWithdrawalFound
+type NoWithdrawalFoundError struct {
+	TxHash  string
+	Checked []string // Contracts whose events were looked for, e.g. "L2ToL1MessagePasser 0x4200…0016"
+}
+
+func (e *NoWithdrawalFoundError) Error() string {
+	if len(e.Checked) == 0 {
+		return fmt.Sprintf("%s is not a withdrawal", e.TxHash)
+	}
+	return fmt.Sprintf("%s is not a withdrawal: no SentMessage or MessagePassed events from %s",
+		e.TxHash, strings.Join(e.Checked, " or "))
+}
+
+func (e *NoWithdrawalFoundError) Is(target error) bool {
+	return target == ErrNoWithdrawalFound
+}
+
+// CostTooHighError is returned before finalizing when the estimated L1 cost breaks
+// MAX_FINALIZE_COST_USD or MIN_VALUE_RATIO; it matches ErrCostTooHigh
+type CostTooHighError struct {
+	Cost  *FinalizeCost
+	Limit string // The limit that was exceeded, e.g. "MAX_FINALIZE_COST_USD $5.00"
+}
+
+func (e *CostTooHighError) Error() string {
+	return fmt.Sprintf("finalizing costs ~$%.2f (%s ETH), over %s",
+		e.Cost.CostUSD, FormatEther(e.Cost.CostWei), e.Limit)
+}
+
+func (e *CostTooHighError) Is(target error) bool {
+	return target == ErrCostTooHigh
+}
+
+// ArchiveStateError is returned when the L2 endpoint used for proof generation has
+// pruned the state of the block the proof is for; it matches ErrNoArchiveState
+type ArchiveStateError struct {
+	Block   uint64 // L2 block the proof was requested at
+	Archive bool   // The endpoint was L2_ARCHIVE_RPC rather than L2_RPC
+	Err     error
+}
+
+func (e *ArchiveStateError) Error() string {
+	if e.Archive {
+		return fmt.Sprintf("L2_ARCHIVE_RPC does not have archive state for block %d — point it at an archive node: %v", e.Block, e.Err)
+	}
+	return fmt.Sprintf("L2 RPC does not have archive state for block %d — configure an archive endpoint or set L2_ARCHIVE_RPC: %v", e.Block, e.Err)
+}
+
+func (e *ArchiveStateError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ArchiveStateError) Is(target error) bool {
+	return target == ErrNoArchiveState
+}
+
+// ConfirmationsError is returned while a withdrawal's L2 block has fewer than
+// L2_CONFIRMATIONS blocks on top of it; it matches ErrInsufficientConfirmations
+type ConfirmationsError struct {
+	TxHash        string
+	Block         uint64 // L2 block of the withdrawal
+	Confirmations uint64 // Blocks from Block to the L2 head, counting Block itself
+	Required      uint64
+}
+
+// Remaining is how many more L2 blocks are needed
+func (e *ConfirmationsError) Remaining() uint64 {
+	return e.Required - e.Confirmations
+}
+
+func (e *ConfirmationsError) Error() string {
+	return fmt.Sprintf("%s has %d of %d L2 confirmations; waiting for %d more blocks",
+		e.TxHash, e.Confirmations, e.Required, e.Remaining())
+}
+
+func (e *ConfirmationsError) Is(target error) bool {
+	return target == ErrInsufficientConfirmations
+}
+
+// InputError is returned when an argument can't be used as given, before any RPC call;
+// it matches ErrInvalidInput
+type InputError struct {
+	Name     string // What the argument is, e.g. "transaction hash"
+	Value    string // The offending input, as passed
+	Expected string // The accepted format, e.g. "0x followed by 64 hex digits"
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("invalid %s %q: expected %s", e.Name, e.Value, e.Expected)
+}
+
+func (e *InputError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
+
+// TimeoutError is returned when one of the Timeouts bounds runs out before an operation
+// finishes; it matches ErrTimeout and context.DeadlineExceeded, never ErrReverted
+type TimeoutError struct {
+	Operation string        // What was cut off, e.g. "getL2Output" or "waiting for 0x… to be mined"
+	Timeout   time.Duration // The limit that ran out
+	Err       error         // Last error seen before giving up, usually context.DeadlineExceeded
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s: %v", e.Operation, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout || target == context.DeadlineExceeded
+}
+
+// asTimeout turns err into a TimeoutError when bounded, derived from parent with the
+// limit timeout, has expired while parent hasn't; otherwise err is returned unchanged
+func asTimeout(parent, bounded context.Context, operation string, timeout time.Duration, err error) error {
+	if err == nil || parent.Err() != nil || !errors.Is(bounded.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return &TimeoutError{Operation: operation, Timeout: timeout, Err: err}
+}
+
+// rpcError marks a failed RPC call as ErrRPC without changing its message
+type rpcError struct {
+	err error
+}
+
+func (e *rpcError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rpcError) Unwrap() error {
+	return e.err
+}
+
+func (e *rpcError) Is(target error) bool {
+	return target == ErrRPC
+}
+
+// markRPCError wraps err as ErrRPC unless it is a revert, missing data or the caller
+// giving up, which say nothing about the endpoint
+func markRPCError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil || isRevert(err) || errors.Is(err, ethereum.NotFound) {
+		return err
+	}
+	return &rpcError{err: err}
+}
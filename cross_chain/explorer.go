@@ -0,0 +1,66 @@
+package crosschain
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Explorer holds the block explorer base URLs for a network, e.g.
+// "https://etherscan.io"; an empty URL means no links for that side
+type Explorer struct {
+	L1 string // Ethereum explorer (L1_EXPLORER_URL)
+	L2 string // Mantle explorer (MANTLE_EXPLORER_URL)
+}
+
+// knownExplorers are the default explorers by chain ID, so L1_CHAINID/L2_CHAINID
+// pointing at a testnet link to the matching explorer
+var knownExplorers = map[uint64]string{
+	1:        "https://etherscan.io",
+	17000:    "https://holesky.etherscan.io",
+	11155111: "https://sepolia.etherscan.io",
+	5000:     "https://mantlescan.xyz",
+	5003:     "https://sepolia.mantlescan.xyz",
+}
+
+// ExplorerForChains returns the known explorers for an L1 and L2 chain ID; unknown
+// chains get no links
+func ExplorerForChains(l1ChainID, l2ChainID uint64) Explorer {
+	return Explorer{L1: knownExplorers[l1ChainID], L2: knownExplorers[l2ChainID]}
+}
+
+// explorerFromEnv applies L1_EXPLORER_URL and MANTLE_EXPLORER_URL over base
+func explorerFromEnv(base Explorer) Explorer {
+	if v := strings.TrimSpace(os.Getenv("L1_EXPLORER_URL")); v != "" {
+		base.L1 = v
+	}
+	if v := strings.TrimSpace(os.Getenv("MANTLE_EXPLORER_URL")); v != "" {
+		base.L2 = v
+	}
+	return base
+}
+
+// L1Tx links an L1 transaction, e.g. a prove or finalize; "" without an L1 explorer
+func (e Explorer) L1Tx(hash string) string {
+	return explorerLink(e.L1, "tx", hash)
+}
+
+// L2Tx links an L2 transaction, e.g. the withdrawal; "" without an L2 explorer
+func (e Explorer) L2Tx(hash string) string {
+	return explorerLink(e.L2, "tx", hash)
+}
+
+func explorerLink(base, kind, id string) string {
+	if base == "" || id == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/" + kind + "/" + id
+}
+
+// logTxLink logs the explorer link of an L1 transaction we sent, when there is one
+func (m *CrossChainMessenger) logTxLink(hash common.Hash) {
+	if link := m.Explorer.L1Tx(hash.Hex()); link != "" {
+		m.logger().Infof("🔗 Check transaction: %s", link)
+	}
+}
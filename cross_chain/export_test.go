@@ -0,0 +1,22 @@
+package crosschain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// NewFakeChainMessenger gives the crosschain_test package a messenger over the fake
+// chains of prove_finalize_test.go. It returns the withdrawal they hold, the
+// finalization period and a function that moves every proof that far into the past.
+func NewFakeChainMessenger(t *testing.T) (m *CrossChainMessenger, txHash string, period time.Duration, advance func(time.Duration)) {
+	t.Helper()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proveFinalizeMessenger(t, l1, l2, key), l2.receipt.TxHash.Hex(), testPeriod, l1.advance
+}
@@ -0,0 +1,82 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Returned by prove/finalize when the call reverted because another relayer already moved
+// the withdrawal on. They are not failures: the withdrawal is in the state we wanted.
+var (
+	ErrProvenExternally    = errors.New("withdrawal was already proven by someone else")
+	ErrFinalizedExternally = errors.New("withdrawal was already finalized by someone else")
+)
+
+// OptimismPortal revert reasons that mean the withdrawal is already past the requested step
+var (
+	alreadyProvenReasons    = []string{"already been proven"}
+	alreadyFinalizedReasons = []string{"already been finalized"}
+)
+
+// IsExternallyCompleted reports whether err means someone else already did the work
+func IsExternallyCompleted(err error) bool {
+	return errors.Is(err, ErrProvenExternally) || errors.Is(err, ErrFinalizedExternally)
+}
+
+// resolveExternalCompletion re-checks the portal after a failed prove (target ==
+// StatusProven) or finalize (target == StatusFinalized). If the withdrawal has already
+// reached target, message.Status is updated and the matching Err*Externally is returned;
+// otherwise cause is returned unchanged.
+func (m *CrossChainMessenger) resolveExternalCompletion(ctx context.Context, message *Message, target int, cause error) error {
+	// Only a rejection by the portal can mean the state moved under us
+	if cause == nil || !isRevert(cause) {
+		return cause
+	}
+
+	finalized, err := m.checkFinalizationStatus(ctx, message.WithdrawalHash)
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to re-check finalization after revert: %v", err)
+		return cause
+	}
+	if finalized {
+		m.logger().Infof("🤝 Withdrawal %s was finalized by someone else", message.WithdrawalHash)
+		message.Status = StatusFinalized
+		return ErrFinalizedExternally
+	}
+	if target == StatusFinalized {
+		return cause
+	}
+
+	// A fresh prove that reverts while the portal now has a proof means we lost the race.
+	// When re-proving, a proof already existed, so only the revert reason tells us the
+	// same output root was proven again.
+	proven, _, err := m.checkProvenStatus(ctx, message.WithdrawalHash)
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to re-check proven status after revert: %v", err)
+		return cause
+	}
+	if proven && (message.Status < StatusProven || revertReasonMatches(cause, alreadyProvenReasons)) {
+		m.logger().Infof("🤝 Withdrawal %s was proven by someone else", message.WithdrawalHash)
+		message.Status = StatusProven
+		return ErrProvenExternally
+	}
+	return cause
+}
+
+// revertReasonMatches reports whether err carries one of the given revert reasons
+func revertReasonMatches(err error, reasons []string) bool {
+	msg := err.Error()
+	var revertErr *RevertError
+	if errors.As(err, &revertErr) {
+		msg = revertErr.Reason
+	} else if reason, ok := decodeRevertReason(err); ok {
+		msg = reason
+	}
+	for _, r := range reasons {
+		if strings.Contains(msg, r) {
+			return true
+		}
+	}
+	return false
+}
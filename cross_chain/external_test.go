@@ -0,0 +1,127 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// racingRelayer returns a messenger over fake chains where another relayer's transaction
+// for the withdrawal lands just before each of ours
+func racingRelayer(t *testing.T) (*CrossChainMessenger, *fakeL1, string) {
+	t.Helper()
+	tx := testWithdrawal()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2 := newFakeL2(t, tx)
+	l1 := newFakeL1(t, l2)
+	l1.onSend = func() {
+		l1.mu.Lock()
+		defer l1.mu.Unlock()
+		if _, ok := l1.proven[hash]; !ok {
+			l1.proven[hash] = provenWithdrawal{outputRoot: l1.outputs[0].OutputRoot, timestamp: time.Now(), outputIndex: new(big.Int)}
+		} else {
+			l1.finalized[hash] = true
+		}
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proveFinalizeMessenger(t, l1, l2, key), l1, l2.receipt.TxHash.Hex()
+}
+
+func TestProveLosesRace(t *testing.T) {
+	m, l1, txHash := racingRelayer(t)
+	_, err := m.ProveMessage(context.Background(), txHash, 0, SubmitOptions{})
+	if !errors.Is(err, ErrProvenExternally) {
+		t.Fatalf("ProveMessage() = %v, want ErrProvenExternally", err)
+	}
+	if !IsExternallyCompleted(err) {
+		t.Fatal("IsExternallyCompleted(ErrProvenExternally) = false")
+	}
+	if receipt, _ := l1.TransactionReceipt(context.Background(), l1.sent[0].Hash()); receipt.Status != 0 {
+		t.Fatal("our prove should have reverted")
+	}
+}
+
+func TestFinalizeLosesRace(t *testing.T) {
+	m, l1, txHash := racingRelayer(t)
+	onSend := l1.onSend
+	l1.onSend = nil
+	if _, err := m.ProveMessage(context.Background(), txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l1.advance(testPeriod)
+	l1.onSend = onSend
+
+	_, err := m.FinalizeMessage(context.Background(), txHash, 0, SubmitOptions{})
+	if !errors.Is(err, ErrFinalizedExternally) {
+		t.Fatalf("FinalizeMessage() = %v, want ErrFinalizedExternally", err)
+	}
+}
+
+func TestResolveExternalCompletion(t *testing.T) {
+	tx := testWithdrawal()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l2 := newFakeL2(t, tx)
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	withdrawalHash := common.Bytes2Hex(hash[:])
+
+	reverted := errors.New("execution reverted: OptimismPortal: invalid output root proof")
+	alreadyProven := errors.New("execution reverted: OptimismPortal: withdrawal hash has already been proven")
+	transport := errors.New("502 bad gateway")
+
+	resolve := func(status, target int, cause error) (int, error) {
+		message := &Message{WithdrawalHash: withdrawalHash, Status: status}
+		err := m.resolveExternalCompletion(context.Background(), message, target, cause)
+		return message.Status, err
+	}
+
+	// Nothing happened on L1: the revert is ours
+	if _, err := resolve(StatusReadyToProve, StatusProven, reverted); err != reverted {
+		t.Fatalf("unproven withdrawal: got %v, want the revert", err)
+	}
+
+	l1.proven[hash] = provenWithdrawal{outputRoot: l1.outputs[0].OutputRoot, timestamp: time.Now(), outputIndex: new(big.Int)}
+	if status, err := resolve(StatusReadyToProve, StatusProven, reverted); !errors.Is(err, ErrProvenExternally) || status != StatusProven {
+		t.Fatalf("proven by someone else: got %v, status %d", err, status)
+	}
+	// Only a revert can mean we lost the race
+	if _, err := resolve(StatusReadyToProve, StatusProven, transport); err != transport {
+		t.Fatalf("transport error: got %v, want it unchanged", err)
+	}
+	// A re-prove reverting for another reason is a failure of its own
+	if _, err := resolve(StatusProven, StatusProven, reverted); err != reverted {
+		t.Fatalf("failed re-prove: got %v, want the revert", err)
+	}
+	if _, err := resolve(StatusProven, StatusProven, alreadyProven); !errors.Is(err, ErrProvenExternally) {
+		t.Fatalf("re-proven by someone else: got %v, want ErrProvenExternally", err)
+	}
+	// Proven isn't enough for a finalize
+	if _, err := resolve(StatusProven, StatusFinalized, reverted); err != reverted {
+		t.Fatalf("finalize of a proven withdrawal: got %v, want the revert", err)
+	}
+
+	l1.finalized[hash] = true
+	for _, target := range []int{StatusProven, StatusFinalized} {
+		if status, err := resolve(StatusProven, target, reverted); !errors.Is(err, ErrFinalizedExternally) || status != StatusFinalized {
+			t.Fatalf("finalized by someone else, target %d: got %v, status %d", target, err, status)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// EthClient is the subset of ethclient.Client the messenger uses. It covers the
+// generated contract bindings and bind.WaitMined, plus raw calls like eth_getProof.
+type EthClient interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	Close()
+}
+
+// rpcEndpoint is one dialed URL of a FailoverClient
+type rpcEndpoint struct {
+	url     string
+	client  *ethclient.Client
+	limiter *rateLimiter // nil when the client wasn't given limiters
+}
+
+// FailoverClient spreads calls over several RPC endpoints for the same chain. Calls go to
+// the current endpoint and rotate to the next one on connection errors or timeouts; the
+// endpoint that last succeeded stays current.
+type FailoverClient struct {
+	name      string // "L1" or "L2", for logs
+	endpoints []rpcEndpoint
+	logger    Logger
+
+	mu      sync.Mutex
+	current int
+}
+
+var _ EthClient = (*FailoverClient)(nil)
+
+// DialFailover connects to every URL up front so a bad fallback is reported at startup
+func DialFailover(ctx context.Context, name string, urls []string, logger Logger) (*FailoverClient, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no %s RPC URL configured", name)
+	}
+	if logger == nil {
+		logger = NopLogger()
+	}
+
+	fc := &FailoverClient{name: name, logger: logger}
+	for _, u := range urls {
+		client, err := ethclient.DialContext(ctx, u)
+		if err != nil {
+			fc.Close()
+			return nil, fmt.Errorf("failed to connect to %s RPC %s: %w", name, redactURL(u), err)
+		}
+		fc.endpoints = append(fc.endpoints, rpcEndpoint{url: u, client: client})
+	}
+	return fc, nil
+}
+
+// limitWith puts every endpoint behind its limiter from limiters
+func (fc *FailoverClient) limitWith(limiters *rateLimiters) {
+	for i := range fc.endpoints {
+		fc.endpoints[i].limiter = limiters.forURL(fc.name, fc.endpoints[i].url)
+	}
+}
+
+// splitRPCURLs parses a comma-separated list of RPC URLs, dropping empty entries
+func splitRPCURLs(list string) []string {
+	var urls []string
+	for _, u := range strings.Split(list, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// redactURL strips the path and query from an RPC URL, which often carry API keys
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return "<invalid url>"
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+// do runs call against each endpoint in turn, starting from the current one, until one
+// succeeds or fails with an error that switching endpoints won't fix
+func (fc *FailoverClient) do(ctx context.Context, method string, call func(c *ethclient.Client) error) error {
+	return fc.doN(ctx, method, 1, call)
+}
+
+// doN is do for a call that costs n requests of each endpoint's rate limit, e.g. a batch
+func (fc *FailoverClient) doN(ctx context.Context, method string, n int, call func(c *ethclient.Client) error) error {
+	fc.mu.Lock()
+	start := fc.current
+	fc.mu.Unlock()
+
+	var err error
+	for i := 0; i < len(fc.endpoints); i++ {
+		idx := (start + i) % len(fc.endpoints)
+		endpoint := fc.endpoints[idx]
+
+		if endpoint.limiter != nil {
+			if err := endpoint.limiter.wait(ctx, method, n); err != nil {
+				return err
+			}
+		}
+		err = call(endpoint.client)
+		if err == nil {
+			fc.mu.Lock()
+			fc.current = idx
+			fc.mu.Unlock()
+			fc.logger.Debugf("🌐 %s %s served by %s", fc.name, method, redactURL(endpoint.url))
+			return nil
+		}
+		if ctx.Err() != nil || !isRetryable(err) || len(fc.endpoints) == 1 {
+			return err
+		}
+		fc.logger.Warnf("🌐 %s %s failed on %s: %v; trying next endpoint", fc.name, method, redactURL(endpoint.url), err)
+	}
+	return err
+}
+
+// Close closes every endpoint
+func (fc *FailoverClient) Close() {
+	for _, e := range fc.endpoints {
+		e.client.Close()
+	}
+}
+
+func (fc *FailoverClient) ChainID(ctx context.Context) (id *big.Int, err error) {
+	err = fc.do(ctx, "eth_chainId", func(c *ethclient.Client) error {
+		id, err = c.ChainID(ctx)
+		return err
+	})
+	return id, err
+}
+
+func (fc *FailoverClient) BlockNumber(ctx context.Context) (n uint64, err error) {
+	err = fc.do(ctx, "eth_blockNumber", func(c *ethclient.Client) error {
+		n, err = c.BlockNumber(ctx)
+		return err
+	})
+	return n, err
+}
+
+func (fc *FailoverClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return fc.do(ctx, method, func(c *ethclient.Client) error {
+		return c.Client().CallContext(ctx, result, method, args...)
+	})
+}
+
+// BatchCallContext sends b as one JSON-RPC batch. Only a failure of the whole batch
+// fails over; per-element errors are left in b for the caller.
+func (fc *FailoverClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return fc.doN(ctx, "batch", max(len(b), 1), func(c *ethclient.Client) error {
+		return c.Client().BatchCallContext(ctx, b)
+	})
+}
+
+func (fc *FailoverClient) HeaderByNumber(ctx context.Context, number *big.Int) (h *types.Header, err error) {
+	err = fc.do(ctx, "eth_getBlockByNumber", func(c *ethclient.Client) error {
+		h, err = c.HeaderByNumber(ctx, number)
+		return err
+	})
+	return h, err
+}
+
+func (fc *FailoverClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (r *types.Receipt, err error) {
+	err = fc.do(ctx, "eth_getTransactionReceipt", func(c *ethclient.Client) error {
+		r, err = c.TransactionReceipt(ctx, txHash)
+		return err
+	})
+	return r, err
+}
+
+func (fc *FailoverClient) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = fc.do(ctx, "eth_getTransactionByHash", func(c *ethclient.Client) error {
+		tx, isPending, err = c.TransactionByHash(ctx, hash)
+		return err
+	})
+	return tx, isPending, err
+}
+
+func (fc *FailoverClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = fc.do(ctx, "eth_getBalance", func(c *ethclient.Client) error {
+		balance, err = c.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (fc *FailoverClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = fc.do(ctx, "eth_getTransactionCount", func(c *ethclient.Client) error {
+		nonce, err = c.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
+
+func (fc *FailoverClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = fc.do(ctx, "eth_getCode", func(c *ethclient.Client) error {
+		code, err = c.CodeAt(ctx, account, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (fc *FailoverClient) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (out []byte, err error) {
+	err = fc.do(ctx, "eth_call", func(c *ethclient.Client) error {
+		out, err = c.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	return out, err
+}
+
+func (fc *FailoverClient) PendingCodeAt(ctx context.Context, account common.Address) (code []byte, err error) {
+	err = fc.do(ctx, "eth_getCode", func(c *ethclient.Client) error {
+		code, err = c.PendingCodeAt(ctx, account)
+		return err
+	})
+	return code, err
+}
+
+func (fc *FailoverClient) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = fc.do(ctx, "eth_getTransactionCount", func(c *ethclient.Client) error {
+		nonce, err = c.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (fc *FailoverClient) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = fc.do(ctx, "eth_gasPrice", func(c *ethclient.Client) error {
+		price, err = c.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+func (fc *FailoverClient) SuggestGasTipCap(ctx context.Context) (tip *big.Int, err error) {
+	err = fc.do(ctx, "eth_maxPriorityFeePerGas", func(c *ethclient.Client) error {
+		tip, err = c.SuggestGasTipCap(ctx)
+		return err
+	})
+	return tip, err
+}
+
+func (fc *FailoverClient) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (gas uint64, err error) {
+	err = fc.do(ctx, "eth_estimateGas", func(c *ethclient.Client) error {
+		gas, err = c.EstimateGas(ctx, msg)
+		return err
+	})
+	return gas, err
+}
+
+// SendTransaction rotates like every other call; rebroadcasting the same signed
+// transaction to another endpoint is harmless
+func (fc *FailoverClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return fc.do(ctx, "eth_sendRawTransaction", func(c *ethclient.Client) error {
+		return c.SendTransaction(ctx, tx)
+	})
+}
+
+func (fc *FailoverClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = fc.do(ctx, "eth_getLogs", func(c *ethclient.Client) error {
+		logs, err = c.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+// SubscribeFilterLogs fails over only while subscribing; an established subscription
+// stays on its endpoint
+func (fc *FailoverClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (sub ethereum.Subscription, err error) {
+	err = fc.do(ctx, "eth_subscribe", func(c *ethclient.Client) error {
+		sub, err = c.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
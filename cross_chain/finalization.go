@@ -0,0 +1,152 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FinalizationParams is how the L2OutputOracle currently gates finalization. Toggling
+// optimistic mode sets a new finalizationPeriodSeconds, so Period always belongs to the
+// active mode.
+type FinalizationParams struct {
+	Period     time.Duration // finalizationPeriodSeconds: how long a proof must age before finalizing
+	Optimistic bool          // optimisticMode: outputs are accepted without validity proofs
+}
+
+// String formats the params for logs, e.g. "12h0m0s (optimistic mode)"
+func (p FinalizationParams) String() string {
+	if p.Optimistic {
+		return p.Period.String() + " (optimistic mode)"
+	}
+	return p.Period.String()
+}
+
+// OptimisticModeChange is an OptimisticModeToggled event
+type OptimisticModeChange struct {
+	FinalizationParams
+	L1BlockNumber uint64
+	L1TxHash      common.Hash
+}
+
+// FinalizationParams returns the oracle's finalization period and optimistic mode,
+// cached until ClearCache or RefreshFinalizationParams. If finalizationPeriodSeconds
+// can't be read, ChallengePeriod is assumed.
+func (m *CrossChainMessenger) FinalizationParams(ctx context.Context) FinalizationParams {
+	if params, ok := m.cache.finalizationParams(); ok {
+		return params
+	}
+	params, err := m.RefreshFinalizationParams(ctx)
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to read the finalization period, assuming %s: %v", ChallengePeriod, err)
+	}
+	return params
+}
+
+// RefreshFinalizationParams reads finalizationPeriodSeconds and optimisticMode from the
+// oracle, bypassing and then updating the cache. On error the returned params fall back
+// to ChallengePeriod and nothing is cached.
+func (m *CrossChainMessenger) RefreshFinalizationParams(ctx context.Context) (FinalizationParams, error) {
+	params := FinalizationParams{Period: ChallengePeriod}
+
+	oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return params, err
+	}
+	period, err := withRetry(ctx, m, "finalizationPeriodSeconds", func(ctx context.Context) (*big.Int, error) {
+		return oracle.FinalizationPeriodSeconds(&bind.CallOpts{Context: ctx})
+	})
+	if err != nil {
+		return params, fmt.Errorf("failed to call finalizationPeriodSeconds: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	params.Period = time.Duration(period.Int64()) * time.Second
+
+	// Older oracles don't have optimistic mode; treat a failed call as off
+	optimistic, err := withRetry(ctx, m, "optimisticMode", func(ctx context.Context) (bool, error) {
+		return oracle.OptimisticMode(&bind.CallOpts{Context: ctx})
+	})
+	if err == nil {
+		params.Optimistic = optimistic
+	} else {
+		m.logger().Debugf("⚠️  optimisticMode() unavailable: %v", err)
+	}
+
+	m.cache.setFinalizationParams(params)
+	return params, nil
+}
+
+// WatchOptimisticModeToggled calls handle for every OptimisticModeToggled event until ctx
+// is done, updating the cached finalization params first. Like WatchOutputProposals it
+// needs a subscription-capable L1 endpoint, returns the first subscribe error right away
+// and resubscribes with backoff after that. Toggles are rare, so missed events aren't
+// replayed; callers that poll RefreshFinalizationParams still see the new mode.
+func (m *CrossChainMessenger) WatchOptimisticModeToggled(ctx context.Context, handle func(OptimisticModeChange)) error {
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return fmt.Errorf("failed to create L2OutputOracle filterer: %w", err)
+	}
+
+	subscribed := false
+	failures := 0
+	for {
+		events := make(chan *cross_abi.L2OutputOracleOptimisticModeToggled)
+		sub, err := oracle.WatchOptimisticModeToggled(&bind.WatchOpts{Context: ctx}, events, nil)
+		if err != nil && !subscribed {
+			return fmt.Errorf("failed to subscribe to OptimisticModeToggled events: %w", err)
+		}
+		if err == nil {
+			subscribed = true
+			failures = 0
+			err = m.readOptimisticModeToggles(ctx, sub.Err(), events, handle)
+			sub.Unsubscribe()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		failures++
+		delay := m.Retry.backoff(failures)
+		m.logger().Warnf("⚠️  OptimisticModeToggled subscription lost: %v; resubscribing in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// readOptimisticModeToggles passes subscription events to handle until the subscription
+// fails or ctx is done
+func (m *CrossChainMessenger) readOptimisticModeToggles(ctx context.Context, subErr <-chan error, events <-chan *cross_abi.L2OutputOracleOptimisticModeToggled, handle func(OptimisticModeChange)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-subErr:
+			if err == nil {
+				err = fmt.Errorf("subscription closed")
+			}
+			return err
+		case ev := <-events:
+			if ev.Raw.Removed {
+				continue
+			}
+			change := OptimisticModeChange{
+				FinalizationParams: FinalizationParams{
+					Period:     time.Duration(ev.FinalizationPeriodSeconds.Int64()) * time.Second,
+					Optimistic: ev.Enabled,
+				},
+				L1BlockNumber: ev.Raw.BlockNumber,
+				L1TxHash:      ev.Raw.TxHash,
+			}
+			m.cache.setFinalizationParams(change.FinalizationParams)
+			handle(change)
+		}
+	}
+}
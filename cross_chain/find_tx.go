@@ -0,0 +1,120 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultFindTxLookback is how far back FindWithdrawalTx searches when no block range is
+// given: a bit more than a prove plus challenge period
+const DefaultFindTxLookback = 7 * 24 * time.Hour
+
+// WithdrawalTx is the L2 transaction that sent a withdrawal, as found by FindWithdrawalTx
+type WithdrawalTx struct {
+	TxHash         string         `json:"txHash"`
+	BlockNumber    uint64         `json:"blockNumber"`
+	Sender         common.Address `json:"sender"`
+	Target         common.Address `json:"target"`
+	Nonce          *big.Int       `json:"nonce"`
+	WithdrawalHash string         `json:"withdrawalHash"`
+}
+
+// L2BlockTime returns the L2 block time the L2OutputOracle was deployed with (L2_BLOCK_TIME)
+func (m *CrossChainMessenger) L2BlockTime(ctx context.Context) (time.Duration, error) {
+	oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return 0, err
+	}
+	blockTime, err := withRetry(ctx, m, "L2_BLOCK_TIME", func(ctx context.Context) (*big.Int, error) {
+		return oracle.L2BLOCKTIME(&bind.CallOpts{Context: ctx})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to call L2_BLOCK_TIME: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	if blockTime.Sign() <= 0 {
+		return 0, fmt.Errorf("oracle reports L2 block time %s", blockTime)
+	}
+	return time.Duration(blockTime.Int64()) * time.Second, nil
+}
+
+// L2BlocksSince returns the first L2 block of the last period before head, counting
+// blocks at the oracle's L2_BLOCK_TIME
+func (m *CrossChainMessenger) L2BlocksSince(ctx context.Context, head uint64, period time.Duration) (uint64, error) {
+	blockTime, err := m.L2BlockTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if blocks := uint64(period / blockTime); blocks < head {
+		return head - blocks, nil
+	}
+	return 0, nil
+}
+
+// FindWithdrawalTx scans L2ToL1MessagePasser MessagePassed events in [fromBlock, toBlock]
+// for the one with withdrawalHash, newest blocks first, and returns the transaction that
+// emitted it. withdrawalHash is not an indexed topic of MessagePassed, so every event in
+// the range is read and compared; senders, when known, narrow the query through the
+// indexed sender topic. It returns ErrWithdrawalTxNotFound when no event matches.
+func (m *CrossChainMessenger) FindWithdrawalTx(ctx context.Context, withdrawalHash common.Hash, senders []common.Address, fromBlock, toBlock uint64) (_ WithdrawalTx, err error) {
+	ctx, span := startSpan(ctx, "FindWithdrawalTx", attrWithdrawalHash.String(withdrawalHash.Hex()),
+		attrFromBlock.Int64(int64(fromBlock)), attrToBlock.Int64(int64(toBlock)))
+	defer func() { endSpan(span, err) }()
+	if fromBlock > toBlock {
+		return WithdrawalTx{}, fmt.Errorf("from block %d is after to block %d", fromBlock, toBlock)
+	}
+
+	passer, err := cross_abi.NewL2ToL1MessagePasserFilterer(common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser), m.ClientL2)
+	if err != nil {
+		return WithdrawalTx{}, fmt.Errorf("failed to create L2ToL1MessagePasser filterer: %w", err)
+	}
+
+	m.logger().Infof("🔎 Searching L2 blocks %d-%d for withdrawal %s", fromBlock, toBlock, withdrawalHash.Hex())
+	for end := toBlock; ; end -= discoveryChunkSize {
+		start := fromBlock
+		if end-fromBlock >= discoveryChunkSize {
+			start = end - discoveryChunkSize + 1
+		}
+		m.logger().Debugf("🔎 Scanning MessagePassed events in L2 blocks %d-%d", start, end)
+
+		iter, err := withRetry(ctx, m, "L2 eth_getLogs", func(ctx context.Context) (*cross_abi.L2ToL1MessagePasserMessagePassedIterator, error) {
+			return passer.FilterMessagePassed(&bind.FilterOpts{Start: start, End: &end, Context: ctx}, nil, senders, nil)
+		})
+		if err != nil {
+			return WithdrawalTx{}, fmt.Errorf("failed to filter MessagePassed events in blocks %d-%d: %w", start, end, err)
+		}
+		var found *WithdrawalTx
+		for found == nil && iter.Next() {
+			event := iter.Event
+			if common.Hash(event.WithdrawalHash) != withdrawalHash {
+				continue
+			}
+			found = &WithdrawalTx{
+				TxHash:         event.Raw.TxHash.Hex(),
+				BlockNumber:    event.Raw.BlockNumber,
+				Sender:         event.Sender,
+				Target:         event.Target,
+				Nonce:          event.Nonce,
+				WithdrawalHash: withdrawalHash.Hex(),
+			}
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return WithdrawalTx{}, fmt.Errorf("failed to read MessagePassed events in blocks %d-%d: %w", start, end, err)
+		}
+		if found != nil {
+			m.logger().Infof("✅ Found in L2 block %d: %s", found.BlockNumber, found.TxHash)
+			return *found, nil
+		}
+		if start == fromBlock {
+			return WithdrawalTx{}, fmt.Errorf("%w (L2 blocks %d-%d)", ErrWithdrawalTxNotFound, fromBlock, toBlock)
+		}
+	}
+}
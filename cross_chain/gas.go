@@ -0,0 +1,261 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	cross_abi "mantle-claim-crossing/abi"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultGasLimitMultiplier is applied to the estimated gas limit when no multiplier is configured
+const DefaultGasLimitMultiplier = 1.2
+
+// DefaultBalanceMargin requires exactly the worst-case transaction cost, which is what the
+// node checks when accepting the transaction
+const DefaultBalanceMargin = 1.0
+
+// GasSettings controls gas limit and fee selection for prove/finalize transactions
+type GasSettings struct {
+	GasLimit           uint64   // Fixed gas limit; 0 means estimate
+	GasLimitMultiplier float64  // Applied to the estimate (default 1.2)
+	MaxFee             *big.Int // Ceiling for the max fee per gas (or the legacy gas price) in wei; nil means no ceiling
+	MaxPriorityFee     *big.Int // Ceiling for the node's suggested priority fee per gas in wei; nil means no ceiling
+	BalanceMargin      float64  // Wallet balance required as a multiple of the max cost (default 1.0)
+	Legacy             bool     // Send type-0 transactions priced with eth_gasPrice, for RPCs without EIP-1559
+}
+
+// gasSettingsFromEnv reads GAS_LIMIT_MULTIPLIER, MAX_FEE_GWEI, MAX_PRIORITY_FEE_GWEI,
+// BALANCE_MARGIN and LEGACY_GAS
+func gasSettingsFromEnv() (GasSettings, error) {
+	settings := GasSettings{GasLimitMultiplier: DefaultGasLimitMultiplier, BalanceMargin: DefaultBalanceMargin}
+
+	if v := os.Getenv("GAS_LIMIT_MULTIPLIER"); v != "" {
+		multiplier, err := strconv.ParseFloat(v, 64)
+		if err != nil || multiplier < 1 {
+			return settings, fmt.Errorf("invalid GAS_LIMIT_MULTIPLIER %q: must be a number >= 1", v)
+		}
+		settings.GasLimitMultiplier = multiplier
+	}
+	if v := os.Getenv("MAX_FEE_GWEI"); v != "" {
+		fee, err := parseGwei(v)
+		if err != nil {
+			return settings, fmt.Errorf("invalid MAX_FEE_GWEI: %w", err)
+		}
+		settings.MaxFee = fee
+	}
+	if v := os.Getenv("MAX_PRIORITY_FEE_GWEI"); v != "" {
+		fee, err := parseGwei(v)
+		if err != nil {
+			return settings, fmt.Errorf("invalid MAX_PRIORITY_FEE_GWEI: %w", err)
+		}
+		settings.MaxPriorityFee = fee
+	}
+	if v := os.Getenv("BALANCE_MARGIN"); v != "" {
+		margin, err := strconv.ParseFloat(v, 64)
+		if err != nil || margin < 1 {
+			return settings, fmt.Errorf("invalid BALANCE_MARGIN %q: must be a number >= 1", v)
+		}
+		settings.BalanceMargin = margin
+	}
+	if v := os.Getenv("LEGACY_GAS"); v != "" {
+		legacy, err := strconv.ParseBool(v)
+		if err != nil {
+			return settings, fmt.Errorf("invalid LEGACY_GAS %q: must be true or false", v)
+		}
+		settings.Legacy = legacy
+	}
+	return settings, nil
+}
+
+// parseGwei converts a decimal gwei string (e.g. "1.5") to wei, dropping fractions of a
+// wei. It parses exactly; a float turns "0.001" into 999999 wei.
+func parseGwei(s string) (*big.Int, error) {
+	value, ok := new(big.Rat).SetString(s)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("%q is not a valid gwei amount", s)
+	}
+	wei := value.Mul(value, big.NewRat(1e9, 1))
+	return new(big.Int).Quo(wei.Num(), wei.Denom()), nil
+}
+
+// formatGwei renders a wei amount in gwei
+func formatGwei(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Text('f', 3)
+}
+
+// FormatEther renders a wei amount in ETH with 6 decimals
+func FormatEther(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Text('f', 6)
+}
+
+// gasLimit returns the manual gas limit, or the estimate for msg with the multiplier applied
+func (m *CrossChainMessenger) gasLimit(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if m.Gas.GasLimit > 0 {
+		m.logger().Infof("⛽ Using manual gas limit: %d", m.Gas.GasLimit)
+		return m.Gas.GasLimit, nil
+	}
+
+	estimated, err := m.ClientL1.EstimateGas(ctx, msg)
+	if err != nil {
+		if isRevert(err) {
+			return 0, fmt.Errorf("gas estimation failed: %w", wrapContractError(m.contractAt(msg.To), err))
+		}
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	multiplier := m.Gas.GasLimitMultiplier
+	if multiplier < 1 {
+		multiplier = DefaultGasLimitMultiplier
+	}
+	gasLimit := uint64(float64(estimated) * multiplier)
+	m.logger().Infof("⛽ Estimated gas: %d, limit with %.2fx multiplier: %d", estimated, multiplier, gasLimit)
+	return gasLimit, nil
+}
+
+// applyGasSettings estimates the gas limit for calldata sent to `to` and fills in the
+// fees on opts, printing the values before the transaction is sent. Setting the fees
+// explicitly keeps bind from picking them, which on some RPCs means legacy pricing.
+func (m *CrossChainMessenger) applyGasSettings(ctx context.Context, opts *bind.TransactOpts, to common.Address, calldata []byte) error {
+	gasLimit, err := m.gasLimit(ctx, ethereum.CallMsg{
+		From:  opts.From,
+		To:    &to,
+		Value: opts.Value,
+		Data:  calldata,
+	})
+	if err != nil {
+		return err
+	}
+	return m.applyFees(ctx, opts, gasLimit)
+}
+
+// applyFees sets gasLimit and the fees on opts and checks the wallet can pay for them,
+// printing the values before the transaction is sent
+func (m *CrossChainMessenger) applyFees(ctx context.Context, opts *bind.TransactOpts, gasLimit uint64) error {
+	opts.GasLimit = gasLimit
+
+	var feePerGas *big.Int
+	var err error
+	if m.Gas.Legacy {
+		feePerGas, err = m.applyLegacyFees(ctx, opts)
+	} else {
+		feePerGas, err = m.applyDynamicFees(ctx, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	maxCost := new(big.Int).Mul(feePerGas, new(big.Int).SetUint64(gasLimit))
+	m.logger().Infof("⛽ Gas limit: %d, max cost: %s ETH", gasLimit, FormatEther(maxCost))
+	return m.checkBalance(ctx, opts.From, maxCost, opts.Value)
+}
+
+// applyDynamicFees sets EIP-1559 fees on opts: the tip is priorityFee, the max fee is 2 ×
+// the latest base fee plus the tip, capped at MaxFee. It returns the max fee per gas.
+func (m *CrossChainMessenger) applyDynamicFees(ctx context.Context, opts *bind.TransactOpts) (*big.Int, error) {
+	head, err := m.ClientL1.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest L1 header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, fmt.Errorf("L1 head has no base fee; use --legacy-gas or LEGACY_GAS=true on chains without EIP-1559")
+	}
+
+	tip, err := m.priorityFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	maxFee := new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip)
+	if m.Gas.MaxFee != nil && maxFee.Cmp(m.Gas.MaxFee) > 0 {
+		maxFee = new(big.Int).Set(m.Gas.MaxFee)
+	}
+	if tip.Cmp(maxFee) > 0 {
+		tip = new(big.Int).Set(maxFee)
+	}
+	opts.GasPrice = nil
+	opts.GasFeeCap = maxFee
+	opts.GasTipCap = tip
+
+	m.logger().Infof("⛽ Base fee: %s gwei, max fee: %s gwei, priority fee: %s gwei",
+		formatGwei(head.BaseFee), formatGwei(maxFee), formatGwei(tip))
+	return maxFee, nil
+}
+
+// priorityFee returns the node's eth_maxPriorityFeePerGas, capped at MaxPriorityFee
+func (m *CrossChainMessenger) priorityFee(ctx context.Context) (*big.Int, error) {
+	tip, err := m.ClientL1.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest priority fee: %w", err)
+	}
+	if m.Gas.MaxPriorityFee != nil && tip.Cmp(m.Gas.MaxPriorityFee) > 0 {
+		tip = new(big.Int).Set(m.Gas.MaxPriorityFee)
+	}
+	return tip, nil
+}
+
+// applyLegacyFees sets a legacy gas price on opts: the node's eth_gasPrice, capped at
+// MaxFee. bind then builds a type-0 transaction. It returns the gas price.
+func (m *CrossChainMessenger) applyLegacyFees(ctx context.Context, opts *bind.TransactOpts) (*big.Int, error) {
+	price, err := m.ClientL1.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest gas price: %w", err)
+	}
+	if m.Gas.MaxFee != nil && price.Cmp(m.Gas.MaxFee) > 0 {
+		price = new(big.Int).Set(m.Gas.MaxFee)
+	}
+	opts.GasPrice = price
+	opts.GasFeeCap = nil
+	opts.GasTipCap = nil
+
+	m.logger().Infof("⛽ Legacy gas price: %s gwei", formatGwei(price))
+	return price, nil
+}
+
+// checkBalance fails with an InsufficientFundsError when wallet can't pay maxCost plus
+// value with the configured margin, so a doomed transaction isn't signed and sent
+func (m *CrossChainMessenger) checkBalance(ctx context.Context, wallet common.Address, maxCost, value *big.Int) error {
+	required := new(big.Int).Set(maxCost)
+	if value != nil {
+		required.Add(required, value)
+	}
+	if margin := m.Gas.BalanceMargin; margin > 1 {
+		required, _ = new(big.Float).Mul(new(big.Float).SetInt(required), big.NewFloat(margin)).Int(nil)
+	}
+
+	balance, err := withRetry(ctx, m, "L1 eth_getBalance", func(ctx context.Context) (*big.Int, error) {
+		return m.ClientL1.BalanceAt(ctx, wallet, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get balance of %s: %w", wallet.Hex(), err)
+	}
+	if balance.Cmp(required) < 0 {
+		return &InsufficientFundsError{Wallet: wallet, Balance: balance, Required: required}
+	}
+	m.logger().Debugf("💰 Wallet balance %s ETH covers %s ETH", FormatEther(balance), FormatEther(required))
+	return nil
+}
+
+// packOptimismPortalCall ABI-encodes a call to the OptimismPortal contract
+func packOptimismPortalCall(method string, args ...interface{}) ([]byte, error) {
+	parsed, err := cross_abi.OptimismPortalMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OptimismPortal ABI: %w", err)
+	}
+	calldata, err := parsed.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s calldata: %w", method, err)
+	}
+	return calldata, nil
+}
@@ -0,0 +1,90 @@
+package crosschain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestParseGwei(t *testing.T) {
+	for s, want := range map[string]int64{
+		"0":     0,
+		"1":     1e9,
+		"1.5":   1.5e9,
+		"0.001": 1e6,
+		"30":    30e9,
+	} {
+		got, err := parseGwei(s)
+		if err != nil {
+			t.Fatalf("parseGwei(%q): %v", s, err)
+		}
+		if got.Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("parseGwei(%q) = %s, want %d", s, got, want)
+		}
+	}
+	for _, s := range []string{"", "-1", "one"} {
+		if _, err := parseGwei(s); err == nil {
+			t.Fatalf("parseGwei(%q) succeeded", s)
+		}
+	}
+}
+
+// TestSignedTxFees proves a withdrawal with each fee setting and checks the fields of the
+// transaction that reached the node. The fake L1 has a 1 gwei base fee, suggests a 1
+// gwei tip and a 3 gwei legacy gas price, and estimates 200000 gas.
+func TestSignedTxFees(t *testing.T) {
+	gwei := func(n float64) *big.Int {
+		wei, _ := new(big.Float).Mul(big.NewFloat(n), big.NewFloat(1e9)).Int(nil)
+		return wei
+	}
+	tests := []struct {
+		name     string
+		gas      GasSettings
+		txType   uint8
+		feeCap   *big.Int
+		tipCap   *big.Int
+		gasLimit uint64
+	}{
+		{"dynamic", GasSettings{}, types.DynamicFeeTxType, gwei(3), gwei(1), 240000},
+		{"priority fee capped", GasSettings{MaxPriorityFee: gwei(0.5)}, types.DynamicFeeTxType, gwei(2.5), gwei(0.5), 240000},
+		{"priority fee under the cap", GasSettings{MaxPriorityFee: gwei(2)}, types.DynamicFeeTxType, gwei(3), gwei(1), 240000},
+		{"max fee capped", GasSettings{MaxFee: gwei(2)}, types.DynamicFeeTxType, gwei(2), gwei(1), 240000},
+		{"max fee under the tip", GasSettings{MaxFee: gwei(0.5)}, types.DynamicFeeTxType, gwei(0.5), gwei(0.5), 240000},
+		{"fixed gas limit", GasSettings{GasLimit: 500000}, types.DynamicFeeTxType, gwei(3), gwei(1), 500000},
+		{"multiplier", GasSettings{GasLimitMultiplier: 1.5}, types.DynamicFeeTxType, gwei(3), gwei(1), 300000},
+		{"legacy", GasSettings{Legacy: true}, types.LegacyTxType, gwei(3), gwei(3), 240000},
+		{"legacy capped", GasSettings{Legacy: true, MaxFee: gwei(2)}, types.LegacyTxType, gwei(2), gwei(2), 240000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l2 := newFakeL2(t, testWithdrawal())
+			l1 := newFakeL1(t, l2)
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := proveFinalizeMessenger(t, l1, l2, key)
+			m.Gas = tt.gas
+			if _, err := m.ProveMessage(context.Background(), l2.receipt.TxHash.Hex(), 0, SubmitOptions{}); err != nil {
+				t.Fatal(err)
+			}
+
+			tx := l1.sent[len(l1.sent)-1]
+			if tx.Type() != tt.txType {
+				t.Fatalf("transaction type = %d, want %d", tx.Type(), tt.txType)
+			}
+			if tx.GasFeeCap().Cmp(tt.feeCap) != 0 || tx.GasTipCap().Cmp(tt.tipCap) != 0 {
+				t.Fatalf("fee cap %s, tip cap %s, want %s, %s", tx.GasFeeCap(), tx.GasTipCap(), tt.feeCap, tt.tipCap)
+			}
+			if tx.Gas() != tt.gasLimit {
+				t.Fatalf("gas limit = %d, want %d", tx.Gas(), tt.gasLimit)
+			}
+			if sender, err := types.Sender(types.LatestSignerForChainID(l1.chainID), tx); err != nil || sender != crypto.PubkeyToAddress(key.PublicKey) {
+				t.Fatalf("sender = %s, %v, want the signer", sender.Hex(), err)
+			}
+		})
+	}
+}
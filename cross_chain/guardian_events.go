@@ -0,0 +1,284 @@
+package crosschain
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// GuardianEventKind names the portal or oracle event a GuardianEvent was built from
+type GuardianEventKind string
+
+const (
+	GuardianPortalPaused              GuardianEventKind = "portal_paused"               // OptimismPortal Paused
+	GuardianPortalUnpaused            GuardianEventKind = "portal_unpaused"             // OptimismPortal Unpaused
+	GuardianFinalizationPeriodUpdated GuardianEventKind = "finalization_period_updated" // L2OutputOracle FinalizationPeriodSecondsUpdated
+	GuardianOutputsDeleted            GuardianEventKind = "outputs_deleted"             // L2OutputOracle OutputsDeleted
+)
+
+// GuardianEvent is an OptimismPortal or L2OutputOracle event emitted by the guardian,
+// challenger or owner that changes what pending withdrawals can do: a pause stops
+// proves and finalizes, a new finalization period moves every finalize time and
+// deleted outputs invalidate the proofs made against them.
+type GuardianEvent struct {
+	Kind                  GuardianEventKind
+	Account               common.Address  // Who paused or unpaused the portal
+	OldFinalizationPeriod time.Duration   // Set for GuardianFinalizationPeriodUpdated
+	NewFinalizationPeriod time.Duration   // Set for GuardianFinalizationPeriodUpdated
+	Deletion              *OutputDeletion // Set for GuardianOutputsDeleted
+	L1BlockNumber         uint64
+	L1TxHash              common.Hash
+	LogIndex              uint
+}
+
+// newGuardianEvent fills in the L1 position of an event from its log
+func newGuardianEvent(kind GuardianEventKind, raw types.Log) GuardianEvent {
+	return GuardianEvent{Kind: kind, L1BlockNumber: raw.BlockNumber, L1TxHash: raw.TxHash, LogIndex: raw.Index}
+}
+
+// pauseEvent converts a Paused or Unpaused event
+func pauseEvent(kind GuardianEventKind, account common.Address, raw types.Log) GuardianEvent {
+	ev := newGuardianEvent(kind, raw)
+	ev.Account = account
+	return ev
+}
+
+// finalizationPeriodEvent converts a FinalizationPeriodSecondsUpdated event
+func finalizationPeriodEvent(e *cross_abi.L2OutputOracleFinalizationPeriodSecondsUpdated) GuardianEvent {
+	ev := newGuardianEvent(GuardianFinalizationPeriodUpdated, e.Raw)
+	ev.OldFinalizationPeriod = time.Duration(e.OldFinalizationPeriodSeconds.Int64()) * time.Second
+	ev.NewFinalizationPeriod = time.Duration(e.NewFinalizationPeriodSeconds.Int64()) * time.Second
+	return ev
+}
+
+// outputsDeletedEvent converts an OutputsDeleted event
+func outputsDeletedEvent(e *cross_abi.L2OutputOracleOutputsDeleted) GuardianEvent {
+	ev := newGuardianEvent(GuardianOutputsDeleted, e.Raw)
+	deletion := newOutputDeletion(e)
+	ev.Deletion = &deletion
+	return ev
+}
+
+// eventIterator is the part of the abigen log iterators filterEvents needs
+type eventIterator interface {
+	Next() bool
+	Error() error
+	Close() error
+}
+
+// filterEvents runs filter over L1 blocks [fromBlock, toBlock] in discoveryChunkSize
+// windows and calls read for every event the iterators return
+func filterEvents[I eventIterator](ctx context.Context, m *CrossChainMessenger, name string, fromBlock, toBlock uint64, filter func(*bind.FilterOpts) (I, error), read func(I)) error {
+	for start := fromBlock; start <= toBlock; start += discoveryChunkSize {
+		end := min(start+discoveryChunkSize-1, toBlock)
+		m.logger().Debugf("🔎 Scanning %s events in L1 blocks %d-%d", name, start, end)
+
+		iter, err := withRetry(ctx, m, "L1 eth_getLogs", func(ctx context.Context) (I, error) {
+			return filter(&bind.FilterOpts{Start: start, End: &end, Context: ctx})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to filter %s events in blocks %d-%d: %w", name, start, end, err)
+		}
+		for iter.Next() {
+			read(iter)
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read %s events in blocks %d-%d: %w", name, start, end, err)
+		}
+	}
+	return nil
+}
+
+// ListGuardianEvents returns the OptimismPortal Paused and Unpaused and L2OutputOracle
+// FinalizationPeriodSecondsUpdated and OutputsDeleted events in L1 blocks [fromBlock,
+// toBlock], oldest first. It works with any L1 endpoint, so it is the fallback for
+// WatchGuardianEvents. Like ListOutputDeletions it drops cached outputs when outputs
+// were deleted.
+func (m *CrossChainMessenger) ListGuardianEvents(ctx context.Context, fromBlock, toBlock uint64) ([]GuardianEvent, error) {
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+	portal, err := cross_abi.NewOptimismPortalFilterer(common.HexToAddress(m.Contracts.L1.OptimismPortal), m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OptimismPortal filterer: %w", err)
+	}
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create L2OutputOracle filterer: %w", err)
+	}
+
+	var events []GuardianEvent
+	err = filterEvents(ctx, m, "Paused", fromBlock, toBlock, portal.FilterPaused, func(iter *cross_abi.OptimismPortalPausedIterator) {
+		events = append(events, pauseEvent(GuardianPortalPaused, iter.Event.Account, iter.Event.Raw))
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = filterEvents(ctx, m, "Unpaused", fromBlock, toBlock, portal.FilterUnpaused, func(iter *cross_abi.OptimismPortalUnpausedIterator) {
+		events = append(events, pauseEvent(GuardianPortalUnpaused, iter.Event.Account, iter.Event.Raw))
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = filterEvents(ctx, m, "FinalizationPeriodSecondsUpdated", fromBlock, toBlock, oracle.FilterFinalizationPeriodSecondsUpdated, func(iter *cross_abi.L2OutputOracleFinalizationPeriodSecondsUpdatedIterator) {
+		events = append(events, finalizationPeriodEvent(iter.Event))
+	})
+	if err != nil {
+		return nil, err
+	}
+	deletions, err := m.ListOutputDeletions(ctx, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deletions {
+		events = append(events, GuardianEvent{Kind: GuardianOutputsDeleted, Deletion: &d,
+			L1BlockNumber: d.L1BlockNumber, L1TxHash: d.L1TxHash, LogIndex: d.LogIndex})
+	}
+
+	slices.SortFunc(events, func(a, b GuardianEvent) int {
+		return cmp.Or(cmp.Compare(a.L1BlockNumber, b.L1BlockNumber), cmp.Compare(a.LogIndex, b.LogIndex))
+	})
+	return events, nil
+}
+
+// WatchGuardianEvents calls handle for every new event ListGuardianEvents would return
+// until ctx is done. Like WatchOptimisticModeToggled it needs a subscription-capable L1
+// endpoint, returns the first subscribe error right away and resubscribes with backoff
+// after that. Events emitted while disconnected aren't replayed; callers that scan with
+// ListGuardianEvents see them, so handle may get an event twice.
+func (m *CrossChainMessenger) WatchGuardianEvents(ctx context.Context, handle func(GuardianEvent)) error {
+	portal, err := cross_abi.NewOptimismPortalFilterer(common.HexToAddress(m.Contracts.L1.OptimismPortal), m.ClientL1)
+	if err != nil {
+		return fmt.Errorf("failed to create OptimismPortal filterer: %w", err)
+	}
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return fmt.Errorf("failed to create L2OutputOracle filterer: %w", err)
+	}
+
+	subscribed := false
+	failures := 0
+	for {
+		sub, err := subscribeGuardianEvents(ctx, portal, oracle)
+		if err != nil && !subscribed {
+			return fmt.Errorf("failed to subscribe to guardian events: %w", err)
+		}
+		if err == nil {
+			if failures > 0 {
+				m.logger().Infof("🔌 Resubscribed to guardian events after %d attempt(s)", failures)
+			}
+			subscribed = true
+			failures = 0
+			err = m.readGuardianEvents(ctx, sub, handle)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		failures++
+		delay := m.Retry.backoff(failures)
+		m.logger().Warnf("⚠️  Guardian event subscription lost: %v; resubscribing in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// guardianSubscription holds the four subscriptions behind WatchGuardianEvents
+type guardianSubscription struct {
+	subs     []event.Subscription
+	paused   chan *cross_abi.OptimismPortalPaused
+	unpaused chan *cross_abi.OptimismPortalUnpaused
+	period   chan *cross_abi.L2OutputOracleFinalizationPeriodSecondsUpdated
+	deleted  chan *cross_abi.L2OutputOracleOutputsDeleted
+}
+
+// subscribeGuardianEvents subscribes to all four events, or to none if one fails
+func subscribeGuardianEvents(ctx context.Context, portal *cross_abi.OptimismPortalFilterer, oracle *cross_abi.L2OutputOracleFilterer) (*guardianSubscription, error) {
+	s := &guardianSubscription{
+		paused:   make(chan *cross_abi.OptimismPortalPaused),
+		unpaused: make(chan *cross_abi.OptimismPortalUnpaused),
+		period:   make(chan *cross_abi.L2OutputOracleFinalizationPeriodSecondsUpdated),
+		deleted:  make(chan *cross_abi.L2OutputOracleOutputsDeleted),
+	}
+	opts := &bind.WatchOpts{Context: ctx}
+	watches := []func() (event.Subscription, error){
+		func() (event.Subscription, error) { return portal.WatchPaused(opts, s.paused) },
+		func() (event.Subscription, error) { return portal.WatchUnpaused(opts, s.unpaused) },
+		func() (event.Subscription, error) {
+			return oracle.WatchFinalizationPeriodSecondsUpdated(opts, s.period)
+		},
+		func() (event.Subscription, error) { return oracle.WatchOutputsDeleted(opts, s.deleted, nil, nil) },
+	}
+	for _, watch := range watches {
+		sub, err := watch()
+		if err != nil {
+			s.unsubscribe()
+			return nil, err
+		}
+		s.subs = append(s.subs, sub)
+	}
+	return s, nil
+}
+
+// unsubscribe ends every subscription made so far
+func (s *guardianSubscription) unsubscribe() {
+	for _, sub := range s.subs {
+		sub.Unsubscribe()
+	}
+}
+
+// readGuardianEvents passes subscription events to handle until one subscription fails
+// or ctx is done
+func (m *CrossChainMessenger) readGuardianEvents(ctx context.Context, sub *guardianSubscription, handle func(GuardianEvent)) error {
+	defer sub.unsubscribe()
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err = <-sub.subs[0].Err():
+		case err = <-sub.subs[1].Err():
+		case err = <-sub.subs[2].Err():
+		case err = <-sub.subs[3].Err():
+		case ev := <-sub.paused:
+			if !ev.Raw.Removed {
+				handle(pauseEvent(GuardianPortalPaused, ev.Account, ev.Raw))
+			}
+			continue
+		case ev := <-sub.unpaused:
+			if !ev.Raw.Removed {
+				handle(pauseEvent(GuardianPortalUnpaused, ev.Account, ev.Raw))
+			}
+			continue
+		case ev := <-sub.period:
+			if !ev.Raw.Removed {
+				handle(finalizationPeriodEvent(ev))
+			}
+			continue
+		case ev := <-sub.deleted:
+			if !ev.Raw.Removed {
+				m.ClearCache()
+				handle(outputsDeletedEvent(ev))
+			}
+			continue
+		}
+		if err == nil {
+			err = fmt.Errorf("subscription closed")
+		}
+		return err
+	}
+}
@@ -2,6 +2,7 @@ package crosschain
 
 import (
 	"encoding/asn1"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -9,6 +10,9 @@ import (
 	"time"
 )
 
+// ErrNoSigner is returned by operations that need to sign when no credentials are configured
+var ErrNoSigner = errors.New("no signing method configured — set KMS_KEY_ID, PRIV_KEY or SIGNER_RPC_URL")
+
 // Helper functions
 
 // parseHexToUint64 converts hex string to uint64
@@ -17,15 +21,18 @@ func parseHexToUint64(hexStr string) (uint64, error) {
 	return strconv.ParseUint(hexStr, 16, 64)
 }
 
-// getStatusDescription returns human-readable status description
-func getStatusDescription(status int) string {
+// StatusDescription names a withdrawal status (StatusReadyToProve, StatusProven or
+// StatusFinalized) as logs, notifications and the status store show it
+func StatusDescription(status int) string {
 	switch status {
-	case 0:
+	case StatusReadyToProve:
 		return "READY_TO_PROVE"
-	case 1:
+	case StatusProven:
 		return "PROVEN"
-	case 2:
-		return "RELAYED/FINALIZED"
+	case StatusFinalized:
+		return "FINALIZED"
+	case StatusRelayFailed:
+		return "RELAYED_FAILED"
 	default:
 		return "UNKNOWN"
 	}
@@ -0,0 +1,161 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	kmssigner "github.com/welthee/go-ethereum-aws-kms-tx-signer/v2"
+)
+
+// DefaultKMSRegion is used when neither KMS_REGION, the key ARN nor the AWS environment
+// names a region
+const DefaultKMSRegion = "ap-northeast-1"
+
+// KMSSettings configures the AWS client used by KMS signers
+type KMSSettings struct {
+	Region        string // KMS_REGION; empty means the key ARN's region, then AWS_REGION, then DefaultKMSRegion
+	AssumeRoleARN string // KMS_ASSUME_ROLE_ARN; role assumed via STS before calling KMS
+}
+
+// kmsKeyIDPattern matches the forms KMS accepts as-is: key IDs, multi-Region key IDs,
+// key and alias ARNs, and alias/ names
+var kmsKeyIDPattern = regexp.MustCompile(`^([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|mrk-[0-9a-fA-F]{32}|arn:aws[a-z-]*:kms:.+|alias/.+)$`)
+
+// normalizeKMSKeyID accepts a key ID, key ARN, alias ARN or alias, and adds the alias/
+// prefix to a bare alias name
+func normalizeKMSKeyID(keyID string) string {
+	keyID = strings.TrimSpace(keyID)
+	if kmsKeyIDPattern.MatchString(keyID) {
+		return keyID
+	}
+	return "alias/" + keyID
+}
+
+// kmsKeyRegion returns the region of a key or alias ARN, or "" for other key forms
+func kmsKeyRegion(keyID string) string {
+	// arn:partition:kms:region:account:key/id
+	parts := strings.SplitN(keyID, ":", 6)
+	if len(parts) == 6 && parts[0] == "arn" && parts[2] == "kms" {
+		return parts[3]
+	}
+	return ""
+}
+
+// kmsClient returns the KMS client for keyID, creating one per region on first use. A
+// KMSClient set by the embedder is used for every key.
+func (m *CrossChainMessenger) kmsClient(ctx context.Context, keyID string) (*kms.Client, error) {
+	if m.KMSClient != nil {
+		return m.KMSClient, nil
+	}
+
+	region := m.KMS.Region
+	if region == "" {
+		region = kmsKeyRegion(keyID)
+	}
+
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	if client, ok := m.kmsClients[region]; ok {
+		return client, nil
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultKMSRegion
+	}
+	if m.KMS.AssumeRoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), m.KMS.AssumeRoleARN)
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	client := kms.NewFromConfig(cfg)
+	if m.kmsClients == nil {
+		m.kmsClients = make(map[string]*kms.Client)
+	}
+	m.kmsClients[region] = client
+	m.logger().Debugf("🔐 KMS client for region %s created", cfg.Region)
+	return client, nil
+}
+
+// kmsTransactor returns the transactor for keyID, creating it once. Creating one fetches
+// the public key from KMS, so it isn't repeated for every transaction.
+func (m *CrossChainMessenger) kmsTransactor(ctx context.Context, keyID string) (*bind.TransactOpts, error) {
+	m.signerMu.Lock()
+	cached := m.kmsTransactors[keyID]
+	m.signerMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	client, err := m.kmsClient(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := m.ClientL1.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	// Use the go-ethereum-aws-kms-tx-signer library to create TransactOpts
+	// This library handles all the KMS signing complexity including secp256k1 compatibility.
+	// The transactor outlives ctx, so its signing calls use their own context.
+	transactor, err := kmssigner.NewAwsKmsTransactorWithChainID(client, keyID, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KMS transactor for key %s: %w", keyID, err)
+	}
+
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	if m.kmsTransactors == nil {
+		m.kmsTransactors = make(map[string]*bind.TransactOpts)
+	}
+	m.kmsTransactors[keyID] = transactor
+	return transactor, nil
+}
+
+// verifyKMSKeys checks that every KMS signer's key can sign Ethereum transactions:
+// enabled, SIGN_VERIFY and ECC_SECG_P256K1
+func (m *CrossChainMessenger) verifyKMSKeys(ctx context.Context) error {
+	for _, cfg := range m.signerConfigs() {
+		if cfg.KMSKeyID == "" {
+			continue
+		}
+		keyID := normalizeKMSKeyID(cfg.KMSKeyID)
+		client, err := m.kmsClient(ctx, keyID)
+		if err != nil {
+			return err
+		}
+		out, err := client.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(keyID)})
+		if err != nil {
+			return fmt.Errorf("failed to describe KMS key %s: %w", keyID, err)
+		}
+		meta := out.KeyMetadata
+		if meta.KeySpec != kmstypes.KeySpecEccSecgP256k1 {
+			return fmt.Errorf("KMS key %s has key spec %s; Ethereum signing needs %s", keyID, meta.KeySpec, kmstypes.KeySpecEccSecgP256k1)
+		}
+		if meta.KeyUsage != kmstypes.KeyUsageTypeSignVerify {
+			return fmt.Errorf("KMS key %s has key usage %s; it must be %s", keyID, meta.KeyUsage, kmstypes.KeyUsageTypeSignVerify)
+		}
+		if meta.KeyState != kmstypes.KeyStateEnabled {
+			return fmt.Errorf("KMS key %s is %s, not Enabled", keyID, meta.KeyState)
+		}
+		m.logger().Debugf("🔐 KMS key %s verified (%s)", keyID, aws.ToString(meta.Arn))
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package crosschain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// LockStaleAfter is how long a withdrawal lock survives without its holder
+	// refreshing it, e.g. after the holder crashed, before another process takes it over
+	LockStaleAfter = 5 * time.Minute
+
+	// lockHeartbeat is how often the holder refreshes its lock
+	lockHeartbeat = LockStaleAfter / 5
+)
+
+// DefaultLockDir returns where withdrawal locks are kept unless LOCK_DIR says otherwise
+func DefaultLockDir() string {
+	return filepath.Join(os.TempDir(), "bridge-status-locks")
+}
+
+// lockHolder is the content of a lock file, shown to whoever finds the lock taken
+type lockHolder struct {
+	Operation  string    `json:"operation"`
+	TxHash     string    `json:"txHash"`
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	Token      string    `json:"token"` // Tells our lock from one that replaced it after expiry
+}
+
+func (h lockHolder) String() string {
+	return fmt.Sprintf("%s of %s by pid %d on %s since %s", h.Operation, h.TxHash, h.PID, h.Host,
+		h.AcquiredAt.UTC().Format(time.RFC3339))
+}
+
+// lockWithdrawal takes the advisory lock on message's withdrawal for operation ("prove"
+// or "finalize") before anything is sent, so two processes sharing LockDir, or two
+// goroutines sharing a messenger, don't both submit it. A lock that is already held fails
+// with ErrWithdrawalLocked. Since message was read before the lock was taken, its status
+// is read again: when the previous holder moved the withdrawal on meanwhile, the lock is
+// released and ErrProvenExternally or ErrFinalizedExternally returned. The returned
+// release must be called once the operation is done. Without LockDir nothing is locked.
+func (m *CrossChainMessenger) lockWithdrawal(ctx context.Context, operation string, message *Message) (func(), error) {
+	if m.LockDir == "" {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(m.LockDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	hash := common.HexToHash(message.WithdrawalHash).Hex()
+	path := filepath.Join(m.LockDir, strings.ToLower(hash)+".lock")
+	holder := lockHolder{Operation: operation, TxHash: message.TxHash, PID: os.Getpid(), AcquiredAt: time.Now(), Token: newLockToken()}
+	holder.Host, _ = os.Hostname()
+
+	if err := m.acquireLockFile(path, holder); err != nil {
+		return nil, err
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(lockHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				if err := os.Chtimes(path, now, now); err != nil {
+					m.logger().Warnf("⚠️  Failed to refresh lock %s: %v", path, err)
+				}
+			}
+		}
+	}()
+	release := func() {
+		close(stop)
+		<-done
+		// Only remove the file while it is still ours
+		if current, err := readLockFile(path); err == nil && current.Token == holder.Token {
+			if err := os.Remove(path); err != nil {
+				m.logger().Warnf("⚠️  Failed to remove lock %s: %v", path, err)
+			}
+		}
+	}
+	m.logger().Debugf("🔒 Locked withdrawal %s for %s (%s)", hash, operation, path)
+
+	status, err := m.getMessageStatus(ctx, message)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	switch {
+	case status >= StatusFinalized && message.Status < StatusFinalized:
+		release()
+		m.logger().Infof("🤝 Withdrawal %s was finalized before the lock was taken", hash)
+		return nil, ErrFinalizedExternally
+	case operation == "prove" && status >= StatusProven && message.Status < StatusProven:
+		release()
+		m.logger().Infof("🤝 Withdrawal %s was proven before the lock was taken", hash)
+		return nil, ErrProvenExternally
+	}
+	return release, nil
+}
+
+// acquireLockFile creates the lock file at path for holder, taking over a stale one
+func (m *CrossChainMessenger) acquireLockFile(path string, holder lockHolder) error {
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return err
+	}
+	// One takeover of a stale lock; if that races with another process, it wins
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return fmt.Errorf("failed to write lock %s: %w", path, err)
+			}
+			return nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create lock %s: %w", path, err)
+		}
+
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // Released meanwhile
+		}
+		if err != nil {
+			return fmt.Errorf("failed to inspect lock %s: %w", path, err)
+		}
+		current, readErr := readLockFile(path)
+		if age := time.Since(info.ModTime()); age < LockStaleAfter {
+			if readErr != nil {
+				return fmt.Errorf("%w (%s)", ErrWithdrawalLocked, path)
+			}
+			return fmt.Errorf("%w: %s", ErrWithdrawalLocked, current)
+		}
+		m.logger().Warnf("⚠️  Taking over lock %s, not refreshed for %s", path, time.Since(info.ModTime()).Round(time.Second))
+		if err := takeOverLockFile(path, info); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w (%s)", ErrWithdrawalLocked, path)
+}
+
+// takeOverLockFile removes the stale lock at path last seen as stale. It is moved aside
+// first, which only one process can do; should the moved file turn out to be a fresh
+// lock that replaced the stale one, it is put back.
+func takeOverLockFile(path string, stale os.FileInfo) error {
+	aside := path + "." + newLockToken()
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to take over lock %s: %w", path, err)
+	}
+	// Inode numbers are reused as soon as a file is removed, so the modification time
+	// tells a fresh lock apart too: the stale one's is older than LockStaleAfter
+	if info, err := os.Stat(aside); err == nil && (!os.SameFile(info, stale) || !info.ModTime().Equal(stale.ModTime())) {
+		// Link doesn't overwrite, so a lock created since is kept
+		_ = os.Link(aside, path)
+	}
+	return os.Remove(aside)
+}
+
+// readLockFile returns the holder recorded in the lock file at path
+func readLockFile(path string) (lockHolder, error) {
+	var holder lockHolder
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return holder, err
+	}
+	err = json.Unmarshal(data, &holder)
+	return holder, err
+}
+
+func newLockToken() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
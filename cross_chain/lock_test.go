@@ -0,0 +1,224 @@
+package crosschain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// lockedMessenger returns a messenger over l1 and l2 keeping its locks in lockDir
+func lockedMessenger(t *testing.T, l1 *fakeL1, l2 *fakeL2, lockDir string) *CrossChainMessenger {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	m.LockDir = lockDir
+	return m
+}
+
+// lockPath is where lockWithdrawal keeps the lock of the withdrawal in txHash
+func lockPath(t *testing.T, m *CrossChainMessenger, txHash string) string {
+	t.Helper()
+	message, err := m.GetMessages(context.Background(), txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := common.HexToHash(message.WithdrawalHash).Hex()
+	return filepath.Join(m.LockDir, strings.ToLower(hash)+".lock")
+}
+
+func TestLockWithdrawalAcrossMessengers(t *testing.T) {
+	ctx := context.Background()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	lockDir := t.TempDir()
+	first := lockedMessenger(t, l1, l2, lockDir)
+	second := lockedMessenger(t, l1, l2, lockDir)
+	txHash := l2.receipt.TxHash.Hex()
+
+	// Hold the first prove inside SendTransaction, with its lock taken, while the
+	// second messenger tries the same withdrawal
+	sending := make(chan struct{})
+	proceed := make(chan struct{})
+	var once sync.Once
+	l1.onSend = func() {
+		once.Do(func() {
+			close(sending)
+			<-proceed
+		})
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := first.ProveMessage(ctx, txHash, 0, SubmitOptions{})
+		done <- err
+	}()
+	<-sending
+
+	_, err := second.ProveMessage(ctx, txHash, 0, SubmitOptions{})
+	if !errors.Is(err, ErrWithdrawalLocked) {
+		t.Fatalf("second ProveMessage() while the first holds the lock = %v, want ErrWithdrawalLocked", err)
+	}
+	if !strings.Contains(err.Error(), "prove of "+txHash) {
+		t.Fatalf("lock error %q doesn't name the holder", err)
+	}
+	close(proceed)
+	if err := <-done; err != nil {
+		t.Fatalf("first ProveMessage() = %v", err)
+	}
+	if len(l1.sent) != 1 {
+		t.Fatalf("%d transactions sent, want 1", len(l1.sent))
+	}
+	if _, err := os.Stat(lockPath(t, first, txHash)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock left behind after the prove: %v", err)
+	}
+}
+
+func TestLockWithdrawalConcurrentSubmitters(t *testing.T) {
+	ctx := context.Background()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	lockDir := t.TempDir()
+	txHash := l2.receipt.TxHash.Hex()
+
+	// Goroutines sharing a messenger and messengers sharing a lock directory
+	shared := lockedMessenger(t, l1, l2, lockDir)
+	messengers := []*CrossChainMessenger{shared, shared, shared, shared}
+	for i := 0; i < 4; i++ {
+		messengers = append(messengers, lockedMessenger(t, l1, l2, lockDir))
+	}
+
+	start := make(chan struct{})
+	errs := make(chan error, len(messengers))
+	var wg sync.WaitGroup
+	for _, m := range messengers {
+		wg.Add(1)
+		go func(m *CrossChainMessenger) {
+			defer wg.Done()
+			<-start
+			_, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{})
+			errs <- err
+		}(m)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 || len(l1.sent) != 1 {
+		t.Fatalf("%d provers succeeded and %d transactions were sent, want exactly one of each", succeeded, len(l1.sent))
+	}
+}
+
+func TestLockWithdrawalTakesOverStaleLock(t *testing.T) {
+	ctx := context.Background()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	m := lockedMessenger(t, l1, l2, t.TempDir())
+	txHash := l2.receipt.TxHash.Hex()
+	path := lockPath(t, m, txHash)
+
+	// A holder that crashed: its heartbeat stopped longer ago than LockStaleAfter
+	data, err := json.Marshal(lockHolder{Operation: "prove", TxHash: txHash, PID: 1, Host: "crashed", Token: "stale"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	heartbeat := time.Now().Add(-LockStaleAfter / 2)
+	if err := os.Chtimes(path, heartbeat, heartbeat); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{}); !errors.Is(err, ErrWithdrawalLocked) {
+		t.Fatalf("ProveMessage() with a live lock = %v, want ErrWithdrawalLocked", err)
+	}
+
+	heartbeat = time.Now().Add(-LockStaleAfter - time.Minute)
+	if err := os.Chtimes(path, heartbeat, heartbeat); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatalf("ProveMessage() with a stale lock = %v, want it taken over", err)
+	}
+	if len(l1.sent) != 1 {
+		t.Fatalf("%d transactions sent, want 1", len(l1.sent))
+	}
+	entries, err := os.ReadDir(m.LockDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("lock directory not empty after the prove: %v", entries)
+	}
+}
+
+func TestTakeOverLockFileKeepsFreshLock(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "withdrawal.lock")
+	if err := os.WriteFile(path, []byte(`{"token":"stale"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	heartbeat := time.Now().Add(-LockStaleAfter - time.Minute)
+	if err := os.Chtimes(path, heartbeat, heartbeat); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process took the stale lock over and created its own before our rename;
+	// the new file may well get the same inode
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"token":"fresh"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := takeOverLockFile(path, stale); err != nil {
+		t.Fatal(err)
+	}
+	holder, err := readLockFile(path)
+	if err != nil {
+		t.Fatalf("fresh lock was removed: %v", err)
+	}
+	if holder.Token != "fresh" {
+		t.Fatalf("lock token = %q, want the fresh lock", holder.Token)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("lock directory holds %d files, want only the lock", len(entries))
+	}
+
+	// With the stale lock still in place, the takeover removes it
+	if err := takeOverLockFile(path, mustStat(t, path)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("stale lock still there: %v", err)
+	}
+}
+
+func mustStat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
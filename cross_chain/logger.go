@@ -0,0 +1,145 @@
+package crosschain
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// LogLevel controls which messages a Logger emits
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelSilent
+)
+
+// String returns the name used for the level in LOG_LEVEL
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	case LogLevelSilent:
+		return "silent"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLogLevel parses a LOG_LEVEL value (debug, info, warn, error, silent)
+func ParseLogLevel(s string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "", "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	case "silent", "off", "none":
+		return LogLevelSilent, nil
+	default:
+		return LogLevelInfo, fmt.Errorf("unknown log level %q (expected debug, info, warn, error or silent)", s)
+	}
+}
+
+// Logger is the leveled logging interface used by CrossChainMessenger
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// StdLogger writes leveled messages through a standard library *log.Logger
+type StdLogger struct {
+	out   *log.Logger
+	level LogLevel
+}
+
+// NewStdLogger creates a StdLogger that drops messages below level
+func NewStdLogger(out *log.Logger, level LogLevel) *StdLogger {
+	return &StdLogger{out: out, level: level}
+}
+
+// NewLoggerFromEnv creates the default CLI logger: plain lines on stdout,
+// filtered by the LOG_LEVEL environment variable (default: info)
+func NewLoggerFromEnv() *StdLogger {
+	level, err := ParseLogLevel(os.Getenv("LOG_LEVEL"))
+	logger := NewStdLogger(log.New(os.Stdout, "", 0), level)
+	if err != nil {
+		logger.Warnf("⚠️  %v, using info", err)
+	}
+	return logger
+}
+
+// Level returns the minimum level that is emitted
+func (l *StdLogger) Level() LogLevel {
+	return l.level
+}
+
+// Enabled reports whether messages at the given level are emitted
+func (l *StdLogger) Enabled(level LogLevel) bool {
+	return level >= l.level && l.level != LogLevelSilent
+}
+
+func (l *StdLogger) logf(level LogLevel, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	// calldepth 3 makes Lshortfile report the caller of Debugf/Infof/...
+	l.out.Output(3, fmt.Sprintf(format, args...))
+}
+
+func (l *StdLogger) Debugf(format string, args ...interface{}) {
+	l.logf(LogLevelDebug, format, args...)
+}
+func (l *StdLogger) Infof(format string, args ...interface{}) { l.logf(LogLevelInfo, format, args...) }
+func (l *StdLogger) Warnf(format string, args ...interface{}) { l.logf(LogLevelWarn, format, args...) }
+func (l *StdLogger) Errorf(format string, args ...interface{}) {
+	l.logf(LogLevelError, format, args...)
+}
+
+// nopLogger discards everything
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Infof(format string, args ...interface{})  {}
+func (nopLogger) Warnf(format string, args ...interface{})  {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+// NopLogger returns a Logger that discards all output, for embedding the
+// messenger in services that do their own logging
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+// debugEnabled reports whether a logger would emit debug messages. Loggers that
+// don't expose their level are assumed to want everything.
+func debugEnabled(l Logger) bool {
+	if leveled, ok := l.(interface{ Enabled(LogLevel) bool }); ok {
+		return leveled.Enabled(LogLevelDebug)
+	}
+	_, nop := l.(nopLogger)
+	return !nop
+}
+
+// logger returns the messenger's Logger, falling back to a no-op logger so a
+// zero-value messenger stays quiet
+func (m *CrossChainMessenger) logger() Logger {
+	if m.Logger == nil {
+		return nopLogger{}
+	}
+	return m.Logger
+}
@@ -0,0 +1,106 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// relayMessageSelector prefixes the message in Encoding.encodeCrossDomainMessageV1
+var relayMessageSelector = crypto.Keccak256([]byte("relayMessage(uint256,address,address,uint256,uint256,uint256,bytes)"))[:4]
+
+// CrossDomainMessage is what the L2CrossDomainMessenger relays to L1, as recorded by its
+// SentMessage and SentMessageExtension1 events
+type CrossDomainMessage struct {
+	Nonce    *big.Int // Versioned message nonce
+	Sender   common.Address
+	Target   common.Address
+	MntValue *big.Int
+	EthValue *big.Int
+	GasLimit *big.Int // Minimum gas limit for the call on L1
+	Data     []byte
+}
+
+// ComputeCrossDomainMessageHash hashes msg the way the L1CrossDomainMessenger's
+// relayMessage does to key successfulMessages and failedMessages:
+// keccak256 of relayMessage(nonce, sender, target, mntValue, ethValue, gasLimit, data)
+// calldata (Hashing.hashCrossDomainMessageV1). relayMessage uses this hash for version 0
+// nonces too, so only versions above 1 are rejected. Nil amounts are encoded as zero.
+func ComputeCrossDomainMessageHash(msg CrossDomainMessage) (common.Hash, error) {
+	if _, version := DecodeVersionedNonce(msg.Nonce); version > 1 {
+		return common.Hash{}, fmt.Errorf("unsupported cross domain message version %d", version)
+	}
+	encoded, err := withdrawalHashArgs.Pack(
+		orZero(msg.Nonce), msg.Sender, msg.Target, orZero(msg.MntValue), orZero(msg.EthValue), orZero(msg.GasLimit), msg.Data)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode cross domain message: %w", err)
+	}
+	return crypto.Keccak256Hash(append(append([]byte{}, relayMessageSelector...), encoded...)), nil
+}
+
+// RelayedMessage returns the message the withdrawal relays through the
+// L1CrossDomainMessenger; false for withdrawals sent straight to the message passer
+func RelayedMessage(message Message) (CrossDomainMessage, bool) {
+	sent := message.SentMessageEvent
+	if sent == nil {
+		return CrossDomainMessage{}, false
+	}
+	return CrossDomainMessage{
+		Nonce:    sent.MessageNonce,
+		Sender:   sent.Sender,
+		Target:   sent.Target,
+		MntValue: message.MntValue,
+		EthValue: message.EthValue,
+		GasLimit: sent.GasLimit,
+		Data:     sent.Message,
+	}, true
+}
+
+// RelayResult is what the L1CrossDomainMessenger recorded for a relayed message
+type RelayResult int
+
+const (
+	RelayUnknown   RelayResult = iota // Neither successful nor failed, e.g. not relayed through the messenger
+	RelaySucceeded                    // successfulMessages is set
+	RelayReverted                     // failedMessages is set; the message can be replayed
+)
+
+// checkRelayResult reads successfulMessages and failedMessages for the message a
+// finalized withdrawal relayed. A withdrawal can be finalized at the portal while the
+// call it relayed reverted, leaving the funds in the messenger until it is replayed.
+func (m *CrossChainMessenger) checkRelayResult(ctx context.Context, msg CrossDomainMessage) (_ RelayResult, err error) {
+	ctx, span := startSpan(ctx, "checkRelayResult", attrNonce.String(orZero(msg.Nonce).String()))
+	defer func() { endSpan(span, err) }()
+	hash, err := ComputeCrossDomainMessageHash(msg)
+	if err != nil {
+		return RelayUnknown, err
+	}
+	messenger, err := m.l1CrossDomainMessenger()
+	if err != nil {
+		return RelayUnknown, err
+	}
+	successful, err := withRetry(ctx, m, "successfulMessages", func(ctx context.Context) (bool, error) {
+		return messenger.SuccessfulMessages(&bind.CallOpts{Context: ctx}, hash)
+	})
+	if err != nil {
+		return RelayUnknown, fmt.Errorf("failed to call successfulMessages: %w", wrapContractError(ContractL1CrossDomainMessenger, err))
+	}
+	if successful {
+		return RelaySucceeded, nil
+	}
+	failed, err := withRetry(ctx, m, "failedMessages", func(ctx context.Context) (bool, error) {
+		return messenger.FailedMessages(&bind.CallOpts{Context: ctx}, hash)
+	})
+	if err != nil {
+		return RelayUnknown, fmt.Errorf("failed to call failedMessages: %w", wrapContractError(ContractL1CrossDomainMessenger, err))
+	}
+	if failed {
+		m.logger().Debugf("❌ Message %s is in failedMessages", hash.Hex())
+		return RelayReverted, nil
+	}
+	return RelayUnknown, nil
+}
@@ -0,0 +1,168 @@
+package crosschain
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testRelayedMessage is the message testWithdrawal would carry if it were sent
+// through the L2CrossDomainMessenger
+func testRelayedMessage() CrossDomainMessage {
+	tx := testWithdrawal()
+	return CrossDomainMessage{
+		Nonce:    tx.Nonce,
+		Sender:   common.HexToAddress("0x3333333333333333333333333333333333333333"),
+		Target:   common.HexToAddress("0x4444444444444444444444444444444444444444"),
+		MntValue: tx.MntValue,
+		EthValue: tx.EthValue,
+		GasLimit: big.NewInt(200000),
+		Data:     []byte{0xca, 0xfe, 0xba, 0xbe},
+	}
+}
+
+func TestRelayMessageSelector(t *testing.T) {
+	parsed, err := cross_abi.L1CrossDomainMessengerMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := parsed.Methods["relayMessage"].ID; !bytes.Equal(relayMessageSelector, id) {
+		t.Fatalf("relayMessageSelector = %x, the bound relayMessage is %x", relayMessageSelector, id)
+	}
+}
+
+func TestComputeCrossDomainMessageHash(t *testing.T) {
+	// relayMessage calldata has the withdrawal hash's argument layout after the selector
+	encode := func(msg CrossDomainMessage) []byte {
+		return append(append([]byte{}, relayMessageSelector...), encodeWithdrawal(cross_abi.TypesWithdrawalTransaction{
+			Nonce: msg.Nonce, Sender: msg.Sender, Target: msg.Target,
+			MntValue: msg.MntValue, EthValue: msg.EthValue, GasLimit: msg.GasLimit, Data: msg.Data,
+		})...)
+	}
+
+	version0 := testRelayedMessage()
+	version0.Nonce = big.NewInt(42)
+	long := testRelayedMessage()
+	long.Data = bytes.Repeat([]byte{0x5a}, 70)
+	for name, msg := range map[string]CrossDomainMessage{
+		"version 1": testRelayedMessage(),
+		"version 0": version0,
+		"long data": long,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ComputeCrossDomainMessageHash(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := crypto.Keccak256Hash(encode(msg)); got != want {
+				t.Fatalf("ComputeCrossDomainMessageHash() = %s, want %s", got.Hex(), want.Hex())
+			}
+		})
+	}
+
+	nilAmounts := testRelayedMessage()
+	nilAmounts.MntValue, nilAmounts.EthValue = nil, nil
+	zeroAmounts := testRelayedMessage()
+	zeroAmounts.MntValue, zeroAmounts.EthValue = new(big.Int), new(big.Int)
+	got, err := ComputeCrossDomainMessageHash(nilAmounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := ComputeCrossDomainMessageHash(zeroAmounts); got != want {
+		t.Fatalf("nil amounts hash to %s, zero amounts to %s", got.Hex(), want.Hex())
+	}
+
+	version2 := testRelayedMessage()
+	version2.Nonce = new(big.Int).Lsh(big.NewInt(2), 240)
+	if _, err := ComputeCrossDomainMessageHash(version2); err == nil {
+		t.Fatal("ComputeCrossDomainMessageHash() of a version 2 message succeeded")
+	}
+}
+
+func TestRelayedMessage(t *testing.T) {
+	want := testRelayedMessage()
+	message := Message{
+		MntValue: want.MntValue,
+		EthValue: want.EthValue,
+		SentMessageEvent: &cross_abi.L2CrossDomainMessengerSentMessage{
+			Target:       want.Target,
+			Sender:       want.Sender,
+			Message:      want.Data,
+			MessageNonce: want.Nonce,
+			GasLimit:     want.GasLimit,
+		},
+	}
+	got, ok := RelayedMessage(message)
+	if !ok {
+		t.Fatal("RelayedMessage() of a SentMessage withdrawal = false")
+	}
+	gotHash, _ := ComputeCrossDomainMessageHash(got)
+	wantHash, _ := ComputeCrossDomainMessageHash(want)
+	if gotHash != wantHash {
+		t.Fatalf("RelayedMessage() = %+v, want %+v", got, want)
+	}
+
+	message.SentMessageEvent = nil
+	if _, ok := RelayedMessage(message); ok {
+		t.Fatal("RelayedMessage() of a withdrawal sent straight to the message passer = true")
+	}
+}
+
+func TestRelayFailedStatus(t *testing.T) {
+	msg := testRelayedMessage()
+	messageHash, err := ComputeCrossDomainMessageHash(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		finalized bool
+		relay     RelayResult
+		direct    bool // Sent straight to the message passer; nothing was relayed
+		want      int
+	}{
+		{"relay succeeded", true, RelaySucceeded, false, StatusFinalized},
+		{"relay reverted", true, RelayReverted, false, StatusRelayFailed},
+		{"relay unknown", true, RelayUnknown, false, StatusFinalized},
+		{"direct withdrawal", true, RelayReverted, true, StatusFinalized},
+		{"not finalized", false, RelayReverted, false, StatusReadyToProve},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l2 := newFakeL2(t, testWithdrawal())
+			l1 := newFakeL1(t, l2)
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := proveFinalizeMessenger(t, l1, l2, key)
+
+			withdrawalHash := crypto.Keccak256Hash([]byte(tt.name))
+			l1.finalized[withdrawalHash] = tt.finalized
+			l1.relayed[messageHash] = tt.relay
+			message := Message{
+				WithdrawalHash: common.Bytes2Hex(withdrawalHash[:]),
+				MntValue:       msg.MntValue,
+				EthValue:       msg.EthValue,
+			}
+			if !tt.direct {
+				message.SentMessageEvent = &cross_abi.L2CrossDomainMessengerSentMessage{
+					Target: msg.Target, Sender: msg.Sender, Message: msg.Data, MessageNonce: msg.Nonce, GasLimit: msg.GasLimit,
+				}
+			}
+			status, err := m.getMessageStatus(context.Background(), &message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.want {
+				t.Fatalf("getMessageStatus() = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Default chain IDs the messenger expects when none are configured
+const (
+	DefaultL1ChainID uint64 = 1    // Ethereum mainnet
+	DefaultL2ChainID uint64 = 5000 // Mantle mainnet
+)
+
+// DefaultNetwork is the preset used when no network is named
+const DefaultNetwork = "mainnet"
+
+// Network is a named deployment: the chain IDs the RPC endpoints must serve and the
+// contracts to call on them
+type Network struct {
+	Name      string
+	L1ChainID uint64
+	L2ChainID uint64
+	Contracts CrossChainContracts
+	Explorer  Explorer // Block explorers for links in output
+}
+
+// networks are the presets LookupNetwork knows
+var networks = map[string]func() Network{
+	"mainnet": func() Network {
+		return Network{
+			Name:      "mainnet",
+			L1ChainID: DefaultL1ChainID,
+			L2ChainID: DefaultL2ChainID,
+			Contracts: DefaultContracts(),
+			Explorer:  ExplorerForChains(DefaultL1ChainID, DefaultL2ChainID),
+		}
+	},
+}
+
+// LookupNetwork returns the preset called name (e.g. "mainnet")
+func LookupNetwork(name string) (Network, error) {
+	preset, ok := networks[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		known := make([]string, 0, len(networks))
+		for n := range networks {
+			known = append(known, n)
+		}
+		sort.Strings(known)
+		return Network{}, fmt.Errorf("unknown network %q: must be one of %s", name, strings.Join(known, ", "))
+	}
+	return preset(), nil
+}
+
+// knownChains names the networks this tool is commonly pointed at, for error messages
+var knownChains = map[uint64]string{
+	1:        "Ethereum mainnet",
+	17000:    "Ethereum Holesky",
+	11155111: "Ethereum Sepolia",
+	5000:     "Mantle",
+	5003:     "Mantle Sepolia",
+}
+
+// chainName returns a readable name for a chain ID
+func chainName(chainID uint64) string {
+	if name, ok := knownChains[chainID]; ok {
+		return name
+	}
+	return fmt.Sprintf("chain %d", chainID)
+}
+
+// verifyChainIDs checks that the RPC endpoints serve the expected networks.
+// An expected ID of 0 skips the check for that side.
+func (m *CrossChainMessenger) verifyChainIDs(ctx context.Context, expectedL1, expectedL2 uint64) error {
+	type chainCheck struct {
+		name     string
+		expected uint64
+		client   EthClient
+	}
+	checks := []chainCheck{
+		{"L1_RPC", expectedL1, m.ClientL1},
+		{"L2_RPC", expectedL2, m.ClientL2},
+	}
+	if m.ClientL2Archive != nil {
+		checks = append(checks, chainCheck{"L2_ARCHIVE_RPC", expectedL2, m.ClientL2Archive})
+	}
+
+	for _, check := range checks {
+		if check.expected == 0 {
+			continue
+		}
+		id, err := check.client.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get %s chain id: %w", check.name, err)
+		}
+		actual := id.Uint64()
+		if actual == check.expected {
+			continue
+		}
+		if name, known := knownChains[actual]; known {
+			return fmt.Errorf("%s chain id %d looks like %s; expected %s (%d)",
+				check.name, actual, name, chainName(check.expected), check.expected)
+		}
+		return fmt.Errorf("%s chain id %d does not match expected %s (%d)",
+			check.name, actual, chainName(check.expected), check.expected)
+	}
+	return nil
+}
+
+// verifyContractCode checks that the L1 contracts the messenger calls are actually deployed
+func (m *CrossChainMessenger) verifyContractCode(ctx context.Context) error {
+	contracts := []struct {
+		name    string
+		envVar  string
+		address string
+	}{
+		{"OptimismPortal", "L1_OPTIMISM_PORTAL", m.Contracts.L1.OptimismPortal},
+		{"L2OutputOracle", "L2_OUTPUT_ORACLE", m.Contracts.L1.L2OutputOracle},
+	}
+
+	for _, c := range contracts {
+		if !common.IsHexAddress(c.address) {
+			return fmt.Errorf("%s address %q is not a valid address (check %s)", c.name, c.address, c.envVar)
+		}
+		code, err := m.ClientL1.CodeAt(ctx, common.HexToAddress(c.address), nil)
+		if err != nil {
+			return fmt.Errorf("failed to read code for %s at %s: %w", c.name, c.address, err)
+		}
+		if len(code) == 0 {
+			return fmt.Errorf("no contract code for %s at %s on L1 — wrong network or %s misconfigured", c.name, c.address, c.envVar)
+		}
+	}
+	return nil
+}
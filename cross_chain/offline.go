@@ -0,0 +1,161 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// UnsignedTx is an OptimismPortal or L1CrossDomainMessenger call built for signing
+// elsewhere, e.g. on an air-gapped signer. Nonce and fees are left to the signer.
+type UnsignedTx struct {
+	Method   string         `json:"method"` // "proveWithdrawalTransaction", "finalizeWithdrawalTransaction" or "relayMessage"
+	ChainID  *big.Int       `json:"chainId"`
+	To       common.Address `json:"to"`
+	Value    *big.Int       `json:"value"`
+	Data     hexutil.Bytes  `json:"data"`
+	GasLimit uint64         `json:"gasLimit"` // Suggested; estimated from EstimatedFrom
+
+	EstimatedFrom       common.Address `json:"estimatedFrom"`       // Sender GasLimit was estimated from
+	EstimatedFromSource string         `json:"estimatedFromSource"` // FromSourceOverride, FromSourceSigner, FromSourceL2Sender or FromSourceRelay
+
+	ProofSize *ProofSize `json:"proofSize,omitempty"` // proveWithdrawalTransaction only
+}
+
+// BuildProveCalldata generates the proof for the withdrawal in txHash and returns the
+// proveWithdrawalTransaction call without signing or sending it. No signer is needed;
+// gas is estimated from the sender estimationFrom picks.
+func (m *CrossChainMessenger) BuildProveCalldata(ctx context.Context, txHash string, messageIndex int) (_ *UnsignedTx, err error) {
+	ctx, span := startSpan(ctx, "BuildProveCalldata", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return nil, err
+	}
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	if message.Status >= StatusFinalized {
+		return nil, ErrAlreadyFinalized
+	}
+
+	call, err := m.buildProveCall(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := packOptimismPortalCall("proveWithdrawalTransaction",
+		call.withdrawalTx, new(big.Int).SetUint64(call.outputIndex), call.outputRootProof, call.withdrawalProof)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := m.unsignedPortalTx(ctx, "proveWithdrawalTransaction", message, calldata)
+	if err != nil {
+		return nil, err
+	}
+	size := MeasureProof(call.withdrawalProof)
+	tx.ProofSize = &size
+	return tx, nil
+}
+
+// BuildFinalizeCalldata returns the finalizeWithdrawalTransaction call for the
+// withdrawal in txHash without signing or sending it. No signer is needed.
+func (m *CrossChainMessenger) BuildFinalizeCalldata(ctx context.Context, txHash string, messageIndex int) (_ *UnsignedTx, err error) {
+	ctx, span := startSpan(ctx, "BuildFinalizeCalldata", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return nil, err
+	}
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	if err := m.checkFinalizable(ctx, message); err != nil {
+		return nil, err
+	}
+
+	withdrawalTx, err := withdrawalTransaction(message)
+	if err != nil {
+		return nil, err
+	}
+	calldata, err := packOptimismPortalCall("finalizeWithdrawalTransaction", withdrawalTx)
+	if err != nil {
+		return nil, err
+	}
+	return m.unsignedPortalTx(ctx, "finalizeWithdrawalTransaction", message, calldata)
+}
+
+// BuildReplayCalldata returns the relayMessage call that replays the failed message of
+// the withdrawal in txHash, without signing or sending it. Like ReplayMessage it checks
+// the message is in failedMessages first. No signer is needed.
+func (m *CrossChainMessenger) BuildReplayCalldata(ctx context.Context, txHash string, messageIndex int) (_ *UnsignedTx, err error) {
+	ctx, span := startSpan(ctx, "BuildReplayCalldata", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return nil, err
+	}
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	msg, err := m.checkReplayable(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	calldata, err := packReplayCall(msg)
+	if err != nil {
+		return nil, err
+	}
+	gasLimit, err := m.replayGasLimit(ctx, calldata)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := m.unsignedTx(ctx, "relayMessage", common.HexToAddress(m.Contracts.L1.L1CrossDomainMessenger), calldata, gasLimit)
+	if err != nil {
+		return nil, err
+	}
+	tx.EstimatedFrom, tx.EstimatedFromSource = relayEstimationAddress, FromSourceRelay
+	return tx, nil
+}
+
+// unsignedPortalTx wraps calldata for the OptimismPortal with the L1 chain ID and a
+// gas limit estimated from the sender estimationFrom picks for message. Neither call
+// depends on msg.sender, but the estimate can still differ between senders, so the one
+// used is logged and returned.
+func (m *CrossChainMessenger) unsignedPortalTx(ctx context.Context, method string, message Message, calldata []byte) (*UnsignedTx, error) {
+	portal := common.HexToAddress(m.Contracts.L1.OptimismPortal)
+
+	from, source := m.estimationFrom(ctx, message)
+	m.logger().Infof("⛽ Estimating %s gas from %s (%s)", method, from.Hex(), source)
+	gasLimit, err := m.gasLimit(ctx, ethereum.CallMsg{From: from, To: &portal, Data: calldata})
+	if err != nil {
+		return nil, err
+	}
+	tx, err := m.unsignedTx(ctx, method, portal, calldata, gasLimit)
+	if err != nil {
+		return nil, err
+	}
+	tx.EstimatedFrom, tx.EstimatedFromSource = from, source
+	return tx, nil
+}
+
+// unsignedTx wraps calldata for to with the L1 chain ID and the suggested gas limit
+func (m *CrossChainMessenger) unsignedTx(ctx context.Context, method string, to common.Address, calldata []byte, gasLimit uint64) (*UnsignedTx, error) {
+	chainID, err := m.ClientL1.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 chain ID: %w", err)
+	}
+
+	return &UnsignedTx{
+		Method:   method,
+		ChainID:  chainID,
+		To:       to,
+		Value:    new(big.Int),
+		Data:     calldata,
+		GasLimit: gasLimit,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// blockhashWindow is how many recent L1 blocks the EVM's blockhash() can see; proposals
+// anchored to older L1 blocks need the hash checkpointed first, or they revert with
+// L1BlockHashNotCheckpointed
+const blockhashWindow = 256
+
+// NextOutput is the output the L2OutputOracle expects next and when it should arrive
+type NextOutput struct {
+	OutputIndex        uint64    `json:"outputIndex"`        // nextOutputIndex()
+	L2BlockNumber      uint64    `json:"l2BlockNumber"`      // nextBlockNumber(): the L2 block it must be for
+	LatestBlockNumber  uint64    `json:"latestBlockNumber"`  // L2 block of the latest output
+	LatestProposedAt   time.Time `json:"latestProposedAt"`   // When the latest output was proposed; zero without outputs
+	SubmissionInterval uint64    `json:"submissionInterval"` // L2 blocks between outputs
+	L2BlockTime        uint64    `json:"l2BlockTime"`        // Seconds per L2 block
+	ProposableAt       time.Time `json:"proposableAt"`       // When L2BlockNumber is produced, the earliest it can be proposed
+	ExpectedAt         time.Time `json:"expectedAt"`         // Latest proposal plus one interval, or ProposableAt if later
+	Overdue            bool      `json:"overdue"`            // ExpectedAt has passed without the output
+	Optimistic         bool      `json:"optimistic"`         // Oracle is in optimistic mode, cadence may differ
+}
+
+// Wait is how long until the next output is expected; 0 once it is overdue
+func (n NextOutput) Wait() time.Duration {
+	return max(time.Until(n.ExpectedAt), 0)
+}
+
+// NextOutput reads which output the oracle expects next, from nextOutputIndex,
+// nextBlockNumber, SUBMISSION_INTERVAL and L2_BLOCK_TIME, and estimates when it will be
+// proposed from the proposer's cadence
+func (m *CrossChainMessenger) NextOutput(ctx context.Context) (_ *NextOutput, err error) {
+	ctx, span := startSpan(ctx, "NextOutput")
+	defer func() { endSpan(span, err) }()
+	l2Oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return nil, err
+	}
+	read := func(name string, call func(opts *bind.CallOpts) (*big.Int, error)) (uint64, error) {
+		v, err := withRetry(ctx, m, name, func(ctx context.Context) (*big.Int, error) {
+			return call(&bind.CallOpts{Context: ctx})
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to call %s: %w", name, wrapContractError(ContractL2OutputOracle, err))
+		}
+		return v.Uint64(), nil
+	}
+
+	next := &NextOutput{}
+	if next.OutputIndex, err = read("nextOutputIndex", l2Oracle.NextOutputIndex); err != nil {
+		return nil, err
+	}
+	if next.L2BlockNumber, err = read("nextBlockNumber", l2Oracle.NextBlockNumber); err != nil {
+		return nil, err
+	}
+	if next.LatestBlockNumber, err = read("latestBlockNumber", l2Oracle.LatestBlockNumber); err != nil {
+		return nil, err
+	}
+	if next.SubmissionInterval, err = read("SUBMISSION_INTERVAL", l2Oracle.SUBMISSIONINTERVAL); err != nil {
+		return nil, err
+	}
+	if next.L2BlockTime, err = read("L2_BLOCK_TIME", l2Oracle.L2BLOCKTIME); err != nil {
+		return nil, err
+	}
+	proposable, err := read("computeL2Timestamp", func(opts *bind.CallOpts) (*big.Int, error) {
+		return l2Oracle.ComputeL2Timestamp(opts, new(big.Int).SetUint64(next.L2BlockNumber))
+	})
+	if err != nil {
+		return nil, err
+	}
+	next.ProposableAt = time.Unix(int64(proposable), 0).UTC()
+	next.ExpectedAt = next.ProposableAt
+
+	if next.OutputIndex > 0 {
+		latest, err := m.getL2OutputData(ctx, m.Contracts.L1.L2OutputOracle, next.OutputIndex-1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call getL2Output: %w", err)
+		}
+		next.LatestProposedAt = time.Unix(latest.Timestamp.Int64(), 0).UTC()
+		cadence := time.Duration(next.SubmissionInterval*next.L2BlockTime) * time.Second
+		if expected := next.LatestProposedAt.Add(cadence); expected.After(next.ExpectedAt) {
+			next.ExpectedAt = expected
+		}
+	}
+	next.Overdue = time.Now().After(next.ExpectedAt)
+
+	// Older oracles don't have optimistic mode; treat a failed call as off
+	if optimistic, err := l2Oracle.OptimisticMode(&bind.CallOpts{Context: ctx}); err == nil {
+		next.Optimistic = optimistic
+	} else {
+		m.logger().Debugf("⚠️  optimisticMode() unavailable: %v", err)
+	}
+	return next, nil
+}
+
+// BlockHashCheckpoint is what the oracle's historicBlockHashes holds for an L1 block.
+// Proposals name the L1 block they were computed against; the oracle checks its hash
+// with blockhash() or, for blocks out of its window, against this checkpoint.
+type BlockHashCheckpoint struct {
+	L1BlockNumber     uint64      `json:"l1BlockNumber"`
+	Hash              common.Hash `json:"hash"`                // historicBlockHashes(l1Block); zero if never checkpointed
+	Checkpointed      bool        `json:"checkpointed"`        // Hash is set
+	ChainHash         common.Hash `json:"chainHash,omitempty"` // The block's hash on L1; zero if it doesn't exist yet
+	InBlockhashWindow bool        `json:"inBlockhashWindow"`   // Recent enough for blockhash(), no checkpoint needed
+}
+
+// Usable reports whether a proposal anchored to the block would pass the oracle's L1
+// block hash check right now
+func (c BlockHashCheckpoint) Usable() bool {
+	return c.InBlockhashWindow || (c.Checkpointed && c.Hash == c.ChainHash)
+}
+
+// BlockHashCheckpoint reads historicBlockHashes(l1Block) and compares it with the L1
+// block's actual hash, to tell whether a proposal for it would revert with
+// L1BlockHashNotCheckpointed
+func (m *CrossChainMessenger) BlockHashCheckpoint(ctx context.Context, l1Block uint64) (_ *BlockHashCheckpoint, err error) {
+	ctx, span := startSpan(ctx, "BlockHashCheckpoint", attrBlock.Int64(int64(l1Block)))
+	defer func() { endSpan(span, err) }()
+	l2Oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := withRetry(ctx, m, "historicBlockHashes", func(ctx context.Context) ([32]byte, error) {
+		return l2Oracle.HistoricBlockHashes(&bind.CallOpts{Context: ctx}, new(big.Int).SetUint64(l1Block))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call historicBlockHashes: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	checkpoint := &BlockHashCheckpoint{
+		L1BlockNumber: l1Block,
+		Hash:          common.Hash(hash),
+		Checkpointed:  hash != [32]byte{},
+	}
+
+	head, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+	if l1Block > head {
+		return checkpoint, nil
+	}
+	// blockhash() covers the 256 blocks before the one executing the proposal
+	checkpoint.InBlockhashWindow = head-l1Block < blockhashWindow
+	header, err := withRetry(ctx, m, "L1 eth_getBlockByNumber", func(ctx context.Context) (*types.Header, error) {
+		return m.ClientL1.HeaderByNumber(ctx, new(big.Int).SetUint64(l1Block))
+	})
+	switch {
+	case errors.Is(err, ethereum.NotFound):
+	case err != nil:
+		return nil, fmt.Errorf("failed to get L1 block %d: %w", l1Block, err)
+	default:
+		checkpoint.ChainHash = header.Hash()
+	}
+	return checkpoint, nil
+}
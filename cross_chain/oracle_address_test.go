@@ -0,0 +1,69 @@
+package crosschain
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// callRecorder is an L1 client that answers every eth_call with the uint256 42 and
+// records which contract was called. Anything else panics on the nil EthClient.
+type callRecorder struct {
+	EthClient
+
+	mu     sync.Mutex
+	called []common.Address
+}
+
+func (c *callRecorder) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.called = append(c.called, *msg.To)
+	return common.LeftPadBytes(big.NewInt(42).Bytes(), 32), nil
+}
+
+func TestL2OutputOracleOverride(t *testing.T) {
+	oracle := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	t.Setenv("L2_OUTPUT_ORACLE", oracle.Hex())
+
+	client := &callRecorder{}
+	m, err := NewCrossChainMessenger(MessengerConfig{
+		L1Client:          client,
+		L2Client:          client,
+		SkipStartupChecks: true,
+		Contracts:         ContractsFromEnv(),
+		Logger:            NopLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := m.GetLatestProposedL2Block(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != 42 {
+		t.Fatalf("GetLatestProposedL2Block() = %d, want 42", latest)
+	}
+	params, err := m.RefreshFinalizationParams(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Period != 42*time.Second {
+		t.Fatalf("finalization period = %s, want 42s", params.Period)
+	}
+
+	if len(client.called) == 0 {
+		t.Fatal("no contract was called")
+	}
+	for _, addr := range client.called {
+		if addr != oracle {
+			t.Fatalf("called %s, want only the L2_OUTPUT_ORACLE override %s", addr.Hex(), oracle.Hex())
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cross_abi "mantle-claim-crossing/abi"
+	"mantle-claim-crossing/helper"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcBlockHeader is the part of an eth_getBlockByNumber result the diagnosis compares;
+// Hash is what the node reports, not recomputed from the fields
+type rpcBlockHeader struct {
+	Hash      common.Hash `json:"hash"`
+	StateRoot common.Hash `json:"stateRoot"`
+}
+
+// diagnoseOutputRoot explains why proof doesn't reproduce expected, the root the oracle
+// holds for blockNumber. The oracle only stores the hash, so each component is checked
+// against the others and against a second source: the block hash the node reports
+// against the header's rehash, the storage root against the state root through the
+// account proof, and the header against L2_RPC when proofs come from L2_ARCHIVE_RPC.
+func (m *CrossChainMessenger) diagnoseOutputRoot(ctx context.Context, blockNumber uint64, proof *WithdrawalProof, expected common.Hash) string {
+	blockTag := hexutil.EncodeUint64(blockNumber)
+	passer := common.HexToAddress(m.Contracts.Bridges.L2ToL1MessagePasser)
+	var header *rpcBlockHeader
+	var account struct {
+		AccountProof []hexutil.Bytes `json:"accountProof"`
+	}
+	err := m.batchCall(ctx, m.proofClient(), "L2 eth_getBlockByNumber+eth_getProof", []rpc.BatchElem{
+		{Method: "eth_getBlockByNumber", Args: []interface{}{blockTag, false}, Result: &header},
+		{Method: "eth_getProof", Args: []interface{}{passer, []string{}, blockTag}, Result: &account},
+	})
+	if err != nil || header == nil {
+		return fmt.Sprintf("could not re-read block %d to diagnose: %v", blockNumber, err)
+	}
+
+	var findings []string
+	if header.StateRoot != proof.StateRoot {
+		findings = append(findings, fmt.Sprintf("the node now reports state root %s for block %d, the proof used %s; the RPC's view of the block changed while proving",
+			header.StateRoot.Hex(), blockNumber, common.Hash(proof.StateRoot).Hex()))
+	}
+
+	nodes := make([][]byte, len(account.AccountProof))
+	for i, node := range account.AccountProof {
+		nodes[i] = node
+	}
+	storageRoot, err := helper.VerifyAccountProof(proof.StateRoot, passer, nodes)
+	switch {
+	case err != nil:
+		findings = append(findings, fmt.Sprintf("the message passer's account proof doesn't verify under state root %s (%v)",
+			common.Hash(proof.StateRoot).Hex(), err))
+	case storageRoot != proof.MessagePasserStorageRoot:
+		findings = append(findings, fmt.Sprintf("storage root %s is not the message passer's under state root %s (%s is); eth_getProof and the header come from different states",
+			common.Hash(proof.MessagePasserStorageRoot).Hex(), common.Hash(proof.StateRoot).Hex(), common.Hash(storageRoot).Hex()))
+	}
+
+	if header.Hash != proof.LatestBlockhash {
+		withReported := proof.outputRootProof()
+		withReported.LatestBlockhash = header.Hash
+		if common.Hash(computeOutputRoot(withReported)) == expected {
+			return fmt.Sprintf("state root and storage root match but block hash differs: the node reports %s, its header rehashes to %s; the header has fields this client doesn't hash. Prove with --l2-block-hash %s",
+				header.Hash.Hex(), common.Hash(proof.LatestBlockhash).Hex(), header.Hash.Hex())
+		}
+		findings = append(findings, fmt.Sprintf("the node reports block hash %s but its header rehashes to %s",
+			header.Hash.Hex(), common.Hash(proof.LatestBlockhash).Hex()))
+	}
+
+	if m.ClientL2Archive != nil {
+		var regular *rpcBlockHeader
+		if err := m.ClientL2.CallContext(ctx, &regular, "eth_getBlockByNumber", blockTag, false); err == nil && regular != nil && regular.Hash != header.Hash {
+			findings = append(findings, fmt.Sprintf("L2_RPC and L2_ARCHIVE_RPC disagree on block %d (%s vs %s); one of them is on a non-canonical fork",
+				blockNumber, regular.Hash.Hex(), header.Hash.Hex()))
+		}
+	}
+	var byHash *rpcBlockHeader
+	if err := m.proofClient().CallContext(ctx, &byHash, "eth_getBlockByHash", header.Hash, false); err == nil && byHash == nil {
+		findings = append(findings, fmt.Sprintf("the node doesn't find block %s by its own hash; RPC may be returning a non-canonical header", header.Hash.Hex()))
+	}
+
+	if len(findings) == 0 {
+		return fmt.Sprintf("state root, storage root and block hash agree with each other, so the node's block %d is not the one the proposer committed; the RPC may be on a different fork. If the block hash is known, prove with --l2-block-hash",
+			blockNumber)
+	}
+	return strings.Join(findings, "; ")
+}
+
+// outputRootProof is the output root preimage proof claims
+func (p *WithdrawalProof) outputRootProof() cross_abi.TypesOutputRootProof {
+	return cross_abi.TypesOutputRootProof{
+		StateRoot:                p.StateRoot,
+		MessagePasserStorageRoot: p.MessagePasserStorageRoot,
+		LatestBlockhash:          p.LatestBlockhash,
+	}
+}
+
+// blockHashOverride replaces the proof's LatestBlockhash with hash when it isn't zero
+func (m *CrossChainMessenger) blockHashOverride(proof *WithdrawalProof, hash common.Hash) {
+	if hash == (common.Hash{}) || hash == proof.LatestBlockhash {
+		return
+	}
+	m.logger().Warnf("⚠️  Using L2 block hash %s instead of the RPC header's %s", hash.Hex(), common.Hash(proof.LatestBlockhash).Hex())
+	proof.LatestBlockhash = hash
+}
@@ -0,0 +1,183 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// MaxOutputWaitEstimate caps EstimateOutputWait. Proposers don't always post on the exact
+// submission interval, so longer estimates would only be false precision.
+const MaxOutputWaitEstimate = 24 * time.Hour
+
+// OutputWaitEstimate is how long until an L2 output covering a withdrawal block is
+// expected to be proposed. It is derived from the oracle's configured cadence, so it is
+// always approximate.
+type OutputWaitEstimate struct {
+	LatestProposedBlock uint64        `json:"latestProposedBlock"`  // Latest L2 block covered by an output
+	CoveringBlock       uint64        `json:"coveringBlock"`        // Output block at or after the withdrawal block: proposed already, or expected next
+	RemainingBlocks     uint64        `json:"remainingBlocks"`      // Withdrawal block minus LatestProposedBlock; 0 once covered
+	ExpectedAt          time.Time     `json:"expectedAt"`           // When CoveringBlock is produced on L2, the earliest it can be proposed; zero once covered
+	Wait                time.Duration `json:"-"`                    // Estimated time until CoveringBlock is proposed
+	Capped              bool          `json:"capped,omitempty"`     // Wait was cut down to MaxOutputWaitEstimate
+	Overdue             bool          `json:"overdue,omitempty"`    // CoveringBlock is already past; the proposal is late
+	Optimistic          bool          `json:"optimistic,omitempty"` // Oracle is in optimistic mode, cadence may differ
+	Irregular           bool          `json:"irregular,omitempty"`  // Outputs are off the startingBlockNumber grid; CoveringBlock counts from the latest one
+}
+
+// Covered reports whether an output covering the withdrawal block was proposed already
+func (e OutputWaitEstimate) Covered() bool {
+	return e.RemainingBlocks == 0
+}
+
+// String formats the estimate for logs and notifications, e.g. "~35m (approximate)"
+func (e OutputWaitEstimate) String() string {
+	var s string
+	switch {
+	case e.Overdue:
+		s = "any time now, the next proposal is overdue"
+	case e.Capped:
+		s = fmt.Sprintf("more than %s", MaxOutputWaitEstimate)
+	default:
+		s = "~" + e.Wait.Round(time.Minute).String()
+	}
+	s += " (approximate"
+	if e.Optimistic {
+		s += ", optimistic mode"
+	}
+	return s + ")"
+}
+
+// Describe formats what a withdrawal that isn't provable yet waits for, e.g. "waiting
+// for output covering block 68,123,400 (next expected proposal ≈ 14:32 UTC)"
+func (e OutputWaitEstimate) Describe() string {
+	if e.Covered() {
+		return fmt.Sprintf("covered by the output at block %s", formatBlockNumber(e.CoveringBlock))
+	}
+	at := e.ExpectedAt.UTC()
+	layout := "15:04 UTC"
+	if at.Sub(time.Now().UTC()) > 20*time.Hour {
+		layout = "Jan 2 15:04 UTC"
+	}
+	when := "next expected proposal ≈ " + at.Format(layout)
+	if e.Overdue {
+		when = "next proposal overdue since " + at.Format(layout)
+	}
+	switch {
+	case e.Irregular:
+		when += ", irregular proposals"
+	case e.Optimistic:
+		when += ", optimistic mode"
+	}
+	return fmt.Sprintf("waiting for output covering block %s (%s)", formatBlockNumber(e.CoveringBlock), when)
+}
+
+// formatBlockNumber groups a block number's digits by thousands, e.g. 68,123,400
+func formatBlockNumber(n uint64) string {
+	s := strconv.FormatUint(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// EstimateOutputWait estimates when an L2 output covering l2Block will be proposed, using
+// the oracle's latestBlockNumber, startingBlockNumber, SUBMISSION_INTERVAL and
+// L2_BLOCK_TIME. Outputs are expected at startingBlockNumber plus a multiple of the
+// interval; when the latest one isn't (the proposer skipped ahead or the oracle is
+// optimistic), the covering block is counted from latestBlockNumber instead and the
+// estimate marked Irregular. Once covered, CoveringBlock is the proposed output's block.
+// All reads together are bounded by Timeouts.RPCRead.
+func (m *CrossChainMessenger) EstimateOutputWait(parent context.Context, l2Block uint64) (_ OutputWaitEstimate, err error) {
+	parent, span := startSpan(parent, "EstimateOutputWait", attrL2Block.Int64(int64(l2Block)))
+	defer func() { endSpan(span, err) }()
+	ctx, cancel := withOptionalTimeout(parent, m.Timeouts.RPCRead)
+	defer cancel()
+
+	estimate, err := m.estimateOutputWait(ctx, l2Block)
+	return estimate, asTimeout(parent, ctx, "output wait estimate", m.Timeouts.RPCRead, err)
+}
+
+func (m *CrossChainMessenger) estimateOutputWait(ctx context.Context, l2Block uint64) (OutputWaitEstimate, error) {
+	var estimate OutputWaitEstimate
+
+	l2Oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return estimate, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+
+	latest, err := l2Oracle.LatestBlockNumber(opts)
+	if err != nil {
+		return estimate, fmt.Errorf("failed to call latestBlockNumber: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	estimate.LatestProposedBlock = latest.Uint64()
+	if estimate.LatestProposedBlock >= l2Block {
+		estimate.CoveringBlock = estimate.LatestProposedBlock
+		if output, err := l2Oracle.GetL2OutputAfter(opts, new(big.Int).SetUint64(l2Block)); err == nil {
+			estimate.CoveringBlock = output.L2BlockNumber.Uint64()
+		} else {
+			m.logger().Debugf("⚠️  getL2OutputAfter(%d) failed: %v", l2Block, err)
+		}
+		return estimate, nil
+	}
+	estimate.RemainingBlocks = l2Block - estimate.LatestProposedBlock
+
+	interval, err := l2Oracle.SUBMISSIONINTERVAL(opts)
+	if err != nil {
+		return estimate, fmt.Errorf("failed to call SUBMISSION_INTERVAL: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	blockTime, err := l2Oracle.L2BLOCKTIME(opts)
+	if err != nil {
+		return estimate, fmt.Errorf("failed to call L2_BLOCK_TIME: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	if interval.Sign() <= 0 || blockTime.Sign() <= 0 {
+		return estimate, fmt.Errorf("oracle reports submission interval %s and block time %s", interval, blockTime)
+	}
+
+	// Older oracles don't have optimistic mode; treat a failed call as off
+	if optimistic, err := l2Oracle.OptimisticMode(opts); err == nil {
+		estimate.Optimistic = optimistic
+	} else {
+		m.logger().Debugf("⚠️  optimisticMode() unavailable: %v", err)
+	}
+
+	// Outputs are proposed every `interval` blocks from startingBlockNumber. A proposer
+	// that skipped ahead breaks the grid, so then count from the latest output instead.
+	step := interval.Uint64()
+	start, err := l2Oracle.StartingBlockNumber(opts)
+	if err == nil && estimate.LatestProposedBlock >= start.Uint64() && (estimate.LatestProposedBlock-start.Uint64())%step == 0 {
+		first := start.Uint64()
+		estimate.CoveringBlock = first + (l2Block-first+step-1)/step*step
+	} else {
+		if err != nil {
+			m.logger().Debugf("⚠️  startingBlockNumber() unavailable: %v", err)
+		}
+		estimate.Irregular = true
+		intervals := (estimate.RemainingBlocks + step - 1) / step
+		estimate.CoveringBlock = estimate.LatestProposedBlock + intervals*step
+	}
+
+	coveringTime, err := l2Oracle.ComputeL2Timestamp(opts, new(big.Int).SetUint64(estimate.CoveringBlock))
+	if err != nil {
+		return estimate, fmt.Errorf("failed to call computeL2Timestamp: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+
+	// The output can only be proposed once its L2 block exists
+	estimate.ExpectedAt = time.Unix(coveringTime.Int64(), 0).UTC()
+	wait := time.Until(estimate.ExpectedAt)
+	if wait <= 0 {
+		estimate.Overdue = true
+		wait = 0
+	}
+	if wait > MaxOutputWaitEstimate {
+		estimate.Capped = true
+		wait = MaxOutputWaitEstimate
+	}
+	estimate.Wait = wait
+	return estimate, nil
+}
@@ -0,0 +1,62 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxOutputCandidates caps how many other covering outputs proveAgainstOtherOutputs
+// tries; each costs an eth_getProof and a root check
+const maxOutputCandidates = 5
+
+// proveAgainstOtherOutputs retries a prove whose output root didn't match (mismatch) on
+// output tried against the other outputs covering message: newest first, down to the
+// first covering one, at most maxOutputCandidates of them. An L2 RPC that lags behind
+// the proposer may not have settled the state of one output's block while another one
+// proves fine. The first candidate whose root matches is used; when none does, mismatch
+// is returned with its diagnosis.
+func (m *CrossChainMessenger) proveAgainstOtherOutputs(ctx context.Context, message Message, tried uint64, mismatch error) (*proveCall, error) {
+	oracle := m.Contracts.L1.L2OutputOracle
+	first, err := m.getL2OutputIndex(ctx, oracle, message.BlockNumber)
+	if err != nil {
+		return nil, mismatch
+	}
+	latest, err := m.latestOutputFromOracle(ctx)
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to list other covering outputs: %v", err)
+		return nil, mismatch
+	}
+
+	candidates := 0
+	for next := latest.OutputIndex + 1; next > first && candidates < maxOutputCandidates; next-- {
+		index := next - 1
+		if index == tried {
+			continue
+		}
+		candidates++
+		output, err := m.getL2OutputData(ctx, oracle, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get L2 output data: %w", err)
+		}
+		m.logger().Warnf("🔁 Output #%d's root didn't match; trying output #%d (L2 block %d)",
+			tried, index, output.L2BlockNumber.Uint64())
+		call, err := m.proveAgainstOutput(ctx, message, index, output, common.Hash{})
+		if err == nil {
+			m.logger().Infof("✅ Proving against output #%d instead of #%d", index, tried)
+			return call, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if !errors.Is(err, ErrOutputRootMismatch) {
+			m.logger().Warnf("⚠️  Output #%d unusable: %v", index, err)
+		}
+	}
+	if candidates == 0 {
+		return nil, mismatch
+	}
+	return nil, fmt.Errorf("%w; %d other covering output(s) did not match either", mismatch, candidates)
+}
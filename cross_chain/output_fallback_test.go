@@ -0,0 +1,89 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// laggingChains returns fakes whose oracle holds outputs for blocks, each with the root
+// the proposer saw, while the L2 node serves the blocks in lagging with other state.
+// The withdrawal is in block 7.
+func laggingChains(t *testing.T, blocks []uint64, lagging ...uint64) (*CrossChainMessenger, *fakeL1, *fakeL2) {
+	t.Helper()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	l1.outputs = nil
+	for _, block := range blocks {
+		l1.outputs = append(l1.outputs, cross_abi.TypesOutputProposal{
+			OutputRoot:    l2.outputRootAt(block),
+			Timestamp:     big.NewInt(time.Now().Unix()),
+			L2BlockNumber: new(big.Int).SetUint64(block),
+		})
+	}
+	for _, block := range lagging {
+		l2.lagging[block] = true
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proveFinalizeMessenger(t, l1, l2, key), l1, l2
+}
+
+func TestProveWithLaggingL2(t *testing.T) {
+	// Output #1 is the first covering the withdrawal; #3 is the newest
+	m, l1, l2 := laggingChains(t, []uint64{5, 10, 12, 14}, 10, 14)
+	if _, err := m.ProveMessage(context.Background(), l2.receipt.TxHash.Hex(), 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, args := l1.lastCall(t)
+	if index := args[1].(*big.Int); index.Int64() != 2 {
+		t.Fatalf("proven against output #%s, want #2, the only covering one the node has settled", index)
+	}
+	if l2.proofs != 3 {
+		t.Fatalf("generated %d proofs, want 3: outputs #1, #3 and #2", l2.proofs)
+	}
+}
+
+func TestProveWithLaggingL2NoMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocks  []uint64
+		lagging []uint64
+		proofs  int
+		others  string
+	}{
+		{"only output", []uint64{10}, []uint64{10}, 1, ""},
+		// Outputs #0 to #7 all cover the withdrawal; #0 and five others are tried
+		{"capped", []uint64{10, 11, 12, 13, 14, 15, 16, 17}, []uint64{10, 11, 12, 13, 14, 15, 16, 17}, 6, "5 other covering output(s)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, l1, l2 := laggingChains(t, tt.blocks, tt.lagging...)
+			_, err := m.ProveMessage(context.Background(), l2.receipt.TxHash.Hex(), 0, SubmitOptions{})
+			if !errors.Is(err, ErrOutputRootMismatch) {
+				t.Fatalf("ProveMessage() = %v, want ErrOutputRootMismatch", err)
+			}
+			if tt.others != "" && !strings.Contains(err.Error(), tt.others) {
+				t.Fatalf("ProveMessage() error = %v, want it to count %q", err, tt.others)
+			}
+			if tt.others == "" && strings.Contains(err.Error(), "other covering") {
+				t.Fatalf("ProveMessage() error = %v, but there was no other output to try", err)
+			}
+			if l2.proofs != tt.proofs {
+				t.Fatalf("generated %d proofs, want %d", l2.proofs, tt.proofs)
+			}
+			if len(l1.sent) != 0 {
+				t.Fatal("a mismatched proof was sent")
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// L1BlockTime is the Ethereum slot time, used to turn a duration into an L1 block range
+const L1BlockTime = 12 * time.Second
+
+// OutputProposalInfo is an OutputProposed event found by ListOutputProposals
+type OutputProposalInfo struct {
+	OutputIndex   uint64      `json:"outputIndex"`
+	L2BlockNumber uint64      `json:"l2BlockNumber"`
+	OutputRoot    common.Hash `json:"outputRoot"`
+	L1Timestamp   time.Time   `json:"l1Timestamp"`
+	L1BlockNumber uint64      `json:"l1BlockNumber"`
+	L1TxHash      common.Hash `json:"l1TxHash"`
+}
+
+// GetLatestL1Block returns the current L1 head block number
+func (m *CrossChainMessenger) GetLatestL1Block(ctx context.Context) (uint64, error) {
+	return withRetry(ctx, m, "L1 eth_blockNumber", func(ctx context.Context) (uint64, error) {
+		return m.ClientL1.BlockNumber(ctx)
+	})
+}
+
+// ListOutputProposals returns the L2OutputOracle OutputProposed events in L1 blocks
+// [fromBlock, toBlock], oldest first
+func (m *CrossChainMessenger) ListOutputProposals(ctx context.Context, fromBlock, toBlock uint64) ([]OutputProposalInfo, error) {
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create L2OutputOracle filterer: %w", err)
+	}
+
+	var proposals []OutputProposalInfo
+	for start := fromBlock; start <= toBlock; start += discoveryChunkSize {
+		end := min(start+discoveryChunkSize-1, toBlock)
+		m.logger().Debugf("🔎 Scanning OutputProposed events in L1 blocks %d-%d", start, end)
+
+		iter, err := withRetry(ctx, m, "L1 eth_getLogs", func(ctx context.Context) (*cross_abi.L2OutputOracleOutputProposedIterator, error) {
+			return oracle.FilterOutputProposed(&bind.FilterOpts{Start: start, End: &end, Context: ctx}, nil, nil, nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter OutputProposed events in blocks %d-%d: %w", start, end, err)
+		}
+		for iter.Next() {
+			event := iter.Event
+			proposals = append(proposals, OutputProposalInfo{
+				OutputIndex:   event.L2OutputIndex.Uint64(),
+				L2BlockNumber: event.L2BlockNumber.Uint64(),
+				OutputRoot:    common.Hash(event.OutputRoot),
+				L1Timestamp:   time.Unix(event.L1Timestamp.Int64(), 0).UTC(),
+				L1BlockNumber: event.Raw.BlockNumber,
+				L1TxHash:      event.Raw.TxHash,
+			})
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OutputProposed events in blocks %d-%d: %w", start, end, err)
+		}
+	}
+	return proposals, nil
+}
+
+// outputScanWindows are the L1 lookbacks LatestOutputProposal scans, in order, when the
+// oracle can't be read. A paused proposer or a long submission interval leaves the
+// narrow ones empty.
+var outputScanWindows = []uint64{1_000, 10_000, 100_000}
+
+// LatestOutputProposal returns the oracle's latest output from
+// getL2Output(latestOutputIndex()). Only if the oracle can't be read does it look for the
+// newest OutputProposed event, widening the lookback until one is found. L1BlockNumber
+// and L1TxHash are only set by the event fallback.
+func (m *CrossChainMessenger) LatestOutputProposal(ctx context.Context) (*OutputProposalInfo, error) {
+	proposal, err := m.latestOutputFromOracle(ctx)
+	if err == nil {
+		return proposal, nil
+	}
+	m.logger().Warnf("⚠️  Failed to read the latest output from the L2OutputOracle, scanning OutputProposed events: %v", err)
+
+	head, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+	for _, window := range outputScanWindows {
+		fromBlock := uint64(0)
+		if head > window {
+			fromBlock = head - window
+		}
+		proposals, err := m.ListOutputProposals(ctx, fromBlock, head)
+		if err != nil {
+			return nil, err
+		}
+		if len(proposals) > 0 {
+			return &proposals[len(proposals)-1], nil
+		}
+		if fromBlock == 0 {
+			break
+		}
+	}
+	return nil, fmt.Errorf("no OutputProposed events in the last %d L1 blocks", outputScanWindows[len(outputScanWindows)-1])
+}
+
+// latestOutputFromOracle reads getL2Output(latestOutputIndex())
+func (m *CrossChainMessenger) latestOutputFromOracle(ctx context.Context) (*OutputProposalInfo, error) {
+	l2Oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return nil, err
+	}
+	index, err := withRetry(ctx, m, "latestOutputIndex", func(ctx context.Context) (*big.Int, error) {
+		return l2Oracle.LatestOutputIndex(&bind.CallOpts{Context: ctx})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call latestOutputIndex: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	output, err := m.getL2OutputData(ctx, m.Contracts.L1.L2OutputOracle, index.Uint64())
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getL2Output: %w", err)
+	}
+	return &OutputProposalInfo{
+		OutputIndex:   index.Uint64(),
+		L2BlockNumber: output.L2BlockNumber.Uint64(),
+		OutputRoot:    common.Hash(output.OutputRoot),
+		L1Timestamp:   time.Unix(output.Timestamp.Int64(), 0).UTC(),
+	}, nil
+}
+
+// WatchOutputProposals calls handle for every new OutputProposed event until ctx is done.
+// The L1 endpoint must support subscriptions (websocket or IPC); if the first subscribe
+// fails, e.g. with rpc.ErrNotificationsUnsupported on HTTP, the error is returned right
+// away. When the subscription drops it resubscribes with backoff and replays the events
+// of the blocks it missed, so handle may see an event twice but never misses one.
+func (m *CrossChainMessenger) WatchOutputProposals(ctx context.Context, handle func(OutputProposalInfo)) error {
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return fmt.Errorf("failed to create L2OutputOracle filterer: %w", err)
+	}
+	lastBlock, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+
+	subscribed := false
+	failures := 0
+	for {
+		events := make(chan *cross_abi.L2OutputOracleOutputProposed)
+		sub, err := oracle.WatchOutputProposed(&bind.WatchOpts{Context: ctx}, events, nil, nil, nil)
+		if err != nil && !subscribed {
+			return fmt.Errorf("failed to subscribe to OutputProposed events: %w", err)
+		}
+		if err == nil && subscribed {
+			// Catch up on what was emitted while disconnected before trusting the new subscription
+			if lastBlock, err = m.replayOutputProposals(ctx, lastBlock, handle); err != nil {
+				sub.Unsubscribe()
+			}
+		}
+		if err == nil {
+			if failures > 0 {
+				m.logger().Infof("🔌 Resubscribed to OutputProposed events after %d attempt(s)", failures)
+			}
+			subscribed = true
+			failures = 0
+			err = m.readOutputProposals(ctx, sub, events, &lastBlock, handle)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		failures++
+		delay := m.Retry.backoff(failures)
+		m.logger().Warnf("⚠️  OutputProposed subscription lost: %v; resubscribing in %s", err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// readOutputProposals passes subscription events to handle until the subscription fails
+// or ctx is done, keeping lastBlock at the newest L1 block seen
+func (m *CrossChainMessenger) readOutputProposals(ctx context.Context, sub event.Subscription, events <-chan *cross_abi.L2OutputOracleOutputProposed, lastBlock *uint64, handle func(OutputProposalInfo)) error {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-sub.Err():
+			if err == nil {
+				err = fmt.Errorf("subscription closed")
+			}
+			return err
+		case ev := <-events:
+			if ev.Raw.Removed {
+				continue
+			}
+			*lastBlock = max(*lastBlock, ev.Raw.BlockNumber)
+			handle(OutputProposalInfo{
+				OutputIndex:   ev.L2OutputIndex.Uint64(),
+				L2BlockNumber: ev.L2BlockNumber.Uint64(),
+				OutputRoot:    common.Hash(ev.OutputRoot),
+				L1Timestamp:   time.Unix(ev.L1Timestamp.Int64(), 0).UTC(),
+				L1BlockNumber: ev.Raw.BlockNumber,
+				L1TxHash:      ev.Raw.TxHash,
+			})
+		}
+	}
+}
+
+// replayOutputProposals hands the events after lastBlock up to the current head to handle
+// and returns the new last block
+func (m *CrossChainMessenger) replayOutputProposals(ctx context.Context, lastBlock uint64, handle func(OutputProposalInfo)) (uint64, error) {
+	head, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return lastBlock, fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+	if head <= lastBlock {
+		return lastBlock, nil
+	}
+	missed, err := m.ListOutputProposals(ctx, lastBlock+1, head)
+	if err != nil {
+		return lastBlock, fmt.Errorf("failed to replay OutputProposed events after L1 block %d: %w", lastBlock, err)
+	}
+	m.logger().Infof("🔁 Replayed %d OutputProposed event(s) from L1 blocks %d-%d", len(missed), lastBlock+1, head)
+	for _, p := range missed {
+		handle(p)
+	}
+	return head, nil
+}
+
+// OutputDeletion is an OutputsDeleted event: the challenger removed the outputs with
+// index NewNextOutputIndex up to, but not including, PrevNextOutputIndex
+type OutputDeletion struct {
+	PrevNextOutputIndex uint64      `json:"prevNextOutputIndex"`
+	NewNextOutputIndex  uint64      `json:"newNextOutputIndex"`
+	L1BlockNumber       uint64      `json:"l1BlockNumber"`
+	L1TxHash            common.Hash `json:"l1TxHash"`
+	LogIndex            uint        `json:"logIndex"`
+}
+
+// newOutputDeletion converts an OutputsDeleted event
+func newOutputDeletion(e *cross_abi.L2OutputOracleOutputsDeleted) OutputDeletion {
+	return OutputDeletion{
+		PrevNextOutputIndex: e.PrevNextOutputIndex.Uint64(),
+		NewNextOutputIndex:  e.NewNextOutputIndex.Uint64(),
+		L1BlockNumber:       e.Raw.BlockNumber,
+		L1TxHash:            e.Raw.TxHash,
+		LogIndex:            e.Raw.Index,
+	}
+}
+
+// Covers reports whether the output at index was among the deleted ones
+func (d OutputDeletion) Covers(index uint64) bool {
+	return index >= d.NewNextOutputIndex && index < d.PrevNextOutputIndex
+}
+
+// ListOutputDeletions returns the L2OutputOracle OutputsDeleted events in L1 blocks
+// [fromBlock, toBlock], oldest first. Cached outputs are dropped when any are found,
+// since they may include the deleted ones.
+func (m *CrossChainMessenger) ListOutputDeletions(ctx context.Context, fromBlock, toBlock uint64) ([]OutputDeletion, error) {
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create L2OutputOracle filterer: %w", err)
+	}
+
+	var deletions []OutputDeletion
+	for start := fromBlock; start <= toBlock; start += discoveryChunkSize {
+		end := min(start+discoveryChunkSize-1, toBlock)
+		m.logger().Debugf("🔎 Scanning OutputsDeleted events in L1 blocks %d-%d", start, end)
+
+		iter, err := withRetry(ctx, m, "L1 eth_getLogs", func(ctx context.Context) (*cross_abi.L2OutputOracleOutputsDeletedIterator, error) {
+			return oracle.FilterOutputsDeleted(&bind.FilterOpts{Start: start, End: &end, Context: ctx}, nil, nil)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter OutputsDeleted events in blocks %d-%d: %w", start, end, err)
+		}
+		for iter.Next() {
+			deletions = append(deletions, newOutputDeletion(iter.Event))
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OutputsDeleted events in blocks %d-%d: %w", start, end, err)
+		}
+	}
+	if len(deletions) > 0 {
+		m.ClearCache()
+	}
+	return deletions, nil
+}
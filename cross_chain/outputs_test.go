@@ -0,0 +1,190 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// scanningL1 is a fakeL1 at head whose eth_getLogs finds the OutputProposed events in
+// proposed, keyed by L1 block. It records the block ranges asked for.
+type scanningL1 struct {
+	*fakeL1
+	head     uint64
+	proposed map[uint64]OutputProposalInfo
+
+	mu     sync.Mutex
+	ranges [][2]uint64
+}
+
+func (f *scanningL1) BlockNumber(ctx context.Context) (uint64, error) { return f.head, nil }
+
+func (f *scanningL1) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	f.mu.Lock()
+	f.ranges = append(f.ranges, [2]uint64{from, to})
+	f.mu.Unlock()
+
+	event := f.oracleABI.Events["OutputProposed"]
+	var logs []types.Log
+	for block := from; block <= to; block++ {
+		p, ok := f.proposed[block]
+		if !ok {
+			continue
+		}
+		data, err := event.Inputs.NonIndexed().Pack(big.NewInt(p.L1Timestamp.Unix()))
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, types.Log{
+			Address: f.oracle,
+			Topics: []common.Hash{event.ID, p.OutputRoot,
+				common.BigToHash(new(big.Int).SetUint64(p.OutputIndex)),
+				common.BigToHash(new(big.Int).SetUint64(p.L2BlockNumber))},
+			Data:        data,
+			BlockNumber: block,
+			TxHash:      p.L1TxHash,
+		})
+	}
+	return logs, nil
+}
+
+// oldestScanned returns the lower end of every widening of the scan, in order
+func (f *scanningL1) oldestScanned() []uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var starts []uint64
+	for _, r := range f.ranges {
+		if len(starts) == 0 || r[0] < starts[len(starts)-1] {
+			starts = append(starts, r[0])
+		}
+	}
+	return starts
+}
+
+// scanningMessenger returns a messenger over a scanningL1 at head holding proposed;
+// with oracleDown, latestOutputIndex fails so LatestOutputProposal has to scan
+func scanningMessenger(t *testing.T, head uint64, oracleDown bool, proposed map[uint64]OutputProposalInfo) (*CrossChainMessenger, *scanningL1) {
+	t.Helper()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := &scanningL1{fakeL1: newFakeL1(t, l2), head: head, proposed: proposed}
+	if oracleDown {
+		l1.callErrs = map[string]error{"latestOutputIndex": errors.New("execution reverted")}
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proveFinalizeMessenger(t, l1, l2, key), l1
+}
+
+func TestLatestOutputProposalWidensScan(t *testing.T) {
+	const head = 500_000
+	// The proposer last submitted 50,000 blocks ago: the 1,000 and 10,000 block windows are empty
+	want := OutputProposalInfo{
+		OutputIndex:   41,
+		L2BlockNumber: 64_000,
+		OutputRoot:    common.HexToHash("0x0a"),
+		L1Timestamp:   time.Unix(1700000000, 0).UTC(),
+		L1BlockNumber: head - 50_000,
+		L1TxHash:      common.HexToHash("0x0b"),
+	}
+	older := want
+	older.OutputIndex, older.L1BlockNumber = 40, head-80_000
+	m, l1 := scanningMessenger(t, head, true, map[uint64]OutputProposalInfo{
+		older.L1BlockNumber: older,
+		want.L1BlockNumber:  want,
+	})
+
+	got, err := m.LatestOutputProposal(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != want {
+		t.Fatalf("LatestOutputProposal() = %+v, want the newest event %+v", *got, want)
+	}
+	if starts, wantStarts := l1.oldestScanned(), []uint64{head - 1_000, head - 10_000, head - 100_000}; !reflect.DeepEqual(starts, wantStarts) {
+		t.Fatalf("scanned back to %v, want %v", starts, wantStarts)
+	}
+	for _, r := range l1.ranges {
+		if r[1] > head || r[1]-r[0] >= discoveryChunkSize {
+			t.Fatalf("scanned blocks %d-%d, want chunks of at most %d up to the head", r[0], r[1], discoveryChunkSize)
+		}
+	}
+}
+
+func TestLatestOutputProposalReadsOracleFirst(t *testing.T) {
+	m, l1 := scanningMessenger(t, 500_000, false, map[uint64]OutputProposalInfo{
+		499_990: {OutputIndex: 9, L2BlockNumber: 99, L1Timestamp: time.Unix(1700000000, 0).UTC()},
+	})
+
+	got, err := m.LatestOutputProposal(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := l1.outputs[len(l1.outputs)-1]
+	if got.OutputIndex != uint64(len(l1.outputs)-1) || got.L2BlockNumber != latest.L2BlockNumber.Uint64() || got.OutputRoot != common.Hash(latest.OutputRoot) {
+		t.Fatalf("LatestOutputProposal() = %+v, want the oracle's latest output", *got)
+	}
+	if got.L1BlockNumber != 0 {
+		t.Fatalf("L1BlockNumber = %d, only the event scan sets it", got.L1BlockNumber)
+	}
+	if len(l1.ranges) != 0 {
+		t.Fatalf("scanned %v although the oracle could be read", l1.ranges)
+	}
+}
+
+func TestLatestOutputProposalNoEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		head   uint64
+		starts []uint64
+	}{
+		{"all windows", 500_000, []uint64{499_000, 490_000, 400_000}},
+		// A chain younger than a window is scanned from genesis once
+		{"short chain", 5_000, []uint64{4_000, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, l1 := scanningMessenger(t, tt.head, true, nil)
+			_, err := m.LatestOutputProposal(context.Background())
+			if err == nil || !strings.Contains(err.Error(), "no OutputProposed events in the last 100000 L1 blocks") {
+				t.Fatalf("LatestOutputProposal() = %v, want no events found", err)
+			}
+			if starts := l1.oldestScanned(); !reflect.DeepEqual(starts, tt.starts) {
+				t.Fatalf("scanned back to %v, want %v", starts, tt.starts)
+			}
+		})
+	}
+}
+
+func TestGetLatestProposedL2BlockFallsBackToOutputs(t *testing.T) {
+	m, l1 := scanningMessenger(t, 500_000, false, nil)
+	latest, err := m.GetLatestProposedL2Block(context.Background())
+	if err != nil || latest != l1.outputs[0].L2BlockNumber.Uint64() {
+		t.Fatalf("GetLatestProposedL2Block() = %d, %v; want %s", latest, err, l1.outputs[0].L2BlockNumber)
+	}
+
+	// A node that can't run latestBlockNumber: the caller falls back to LatestOutputProposal
+	l1.callErrs = map[string]error{"latestBlockNumber": errors.New("execution reverted")}
+	if _, err := m.GetLatestProposedL2Block(context.Background()); err == nil {
+		t.Fatal("GetLatestProposedL2Block() succeeded without latestBlockNumber")
+	}
+	proposal, err := m.LatestOutputProposal(context.Background())
+	if err != nil || proposal.L2BlockNumber != latest {
+		t.Fatalf("LatestOutputProposal() = %+v, %v; want L2 block %d from the oracle", proposal, err, latest)
+	}
+	if len(l1.ranges) != 0 {
+		t.Fatalf("scanned %v although the oracle could be read", l1.ranges)
+	}
+}
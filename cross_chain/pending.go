@@ -0,0 +1,164 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// cancelGasLimit is the gas of a plain transfer, which is all a cancellation is
+	cancelGasLimit = 21000
+
+	// maxCancelAttempts bounds how often CancelPending raises its fees after the node
+	// turns the cancellation down as underpriced
+	maxCancelAttempts = 5
+)
+
+// PendingQueue is a wallet's L1 transaction queue as the node sees it. Transactions
+// from one wallet are mined in nonce order, so everything in the queue waits on the
+// one at LatestNonce.
+type PendingQueue struct {
+	Wallet       common.Address `json:"wallet"`
+	LatestNonce  uint64         `json:"latestNonce"`  // Next nonce at the latest block; everything below it is mined
+	PendingNonce uint64         `json:"pendingNonce"` // Next nonce counting the node's mempool
+}
+
+// Pending returns how many transactions were sent but not mined yet
+func (q PendingQueue) Pending() uint64 {
+	if q.PendingNonce <= q.LatestNonce {
+		return 0
+	}
+	return q.PendingNonce - q.LatestNonce
+}
+
+// CheckPendingQueue compares the latest and pending nonces of wallet on L1. A gap means
+// transactions are queued behind the one at LatestNonce, e.g. a prove priced too low,
+// and later prove and finalize transactions from the wallet will queue behind it too.
+func (m *CrossChainMessenger) CheckPendingQueue(ctx context.Context, wallet common.Address) (_ PendingQueue, err error) {
+	ctx, span := startSpan(ctx, "CheckPendingQueue", attrFrom.String(wallet.Hex()))
+	defer func() { endSpan(span, err) }()
+	latest, err := withRetry(ctx, m, "L1 eth_getTransactionCount", func(ctx context.Context) (uint64, error) {
+		return m.ClientL1.NonceAt(ctx, wallet, nil)
+	})
+	if err != nil {
+		return PendingQueue{}, fmt.Errorf("failed to get latest nonce of %s: %w", wallet.Hex(), err)
+	}
+	pending, err := withRetry(ctx, m, "L1 eth_getTransactionCount", func(ctx context.Context) (uint64, error) {
+		return m.ClientL1.PendingNonceAt(ctx, wallet)
+	})
+	if err != nil {
+		return PendingQueue{}, fmt.Errorf("failed to get pending nonce of %s: %w", wallet.Hex(), err)
+	}
+	return PendingQueue{Wallet: wallet, LatestNonce: latest, PendingNonce: pending}, nil
+}
+
+// CancelPending unblocks the queue of the wallet opts.From (zero means the default
+// signer) by replacing the transaction at its lowest unmined nonce with a 0-value
+// transfer to itself. The cancellation starts at the current fees and is bumped by
+// Replacement.FeeBumpPercent, up to Replacement.MaxFee, whenever the node rejects it as
+// underpriced; once sent, waitMined keeps bumping it like any stuck transaction. It
+// returns ErrNothingPending when the wallet has no unmined transactions and an error
+// matching ErrNonceReplaced when the stuck transaction was mined first.
+func (m *CrossChainMessenger) CancelPending(ctx context.Context, opts SubmitOptions) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "CancelPending", attrFrom.String(opts.From.Hex()))
+	defer func() { endSpan(span, err) }()
+	if !m.HasSigner() {
+		return common.Hash{}, ErrNoSigner
+	}
+
+	txOpts, err := m.getTransactOpts(ctx, opts.From)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get transaction options: %w", err)
+	}
+	queue, err := m.CheckPendingQueue(ctx, txOpts.From)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("\n=== CANCEL PENDING ===")
+	m.logger().Infof("Wallet: %s", txOpts.From.Hex())
+	if queue.Pending() == 0 {
+		m.logger().Infof("✅ No pending transactions (nonce %d)", queue.LatestNonce)
+		return common.Hash{}, ErrNothingPending
+	}
+	nonce := queue.LatestNonce
+	span.SetAttributes(attrNonce.Int64(int64(nonce)))
+	m.logger().Infof("⏳ %d pending transaction(s), nonces %d-%d", queue.Pending(), nonce, queue.PendingNonce-1)
+
+	if err := m.applyFees(ctx, txOpts, cancelGasLimit); err != nil {
+		return common.Hash{}, err
+	}
+	preview := newTxPreview("cancel", Message{}, txOpts, txOpts.From)
+	preview.Nonce = nonce
+	if err := opts.confirm(preview); err != nil {
+		return common.Hash{}, err
+	}
+
+	m.logger().Infof("\n🚀 Sending 0-value self-transfer at nonce %d...", nonce)
+	tx, err := m.sendCancel(ctx, txOpts, nonce)
+	// Whatever happened, the locally tracked nonce no longer matches the queue
+	m.resetNonce()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("✅ Cancellation submitted: %s", tx.Hash().Hex())
+	opts.submitted(tx)
+
+	m.logger().Infof("\n⏳ Waiting for transaction to be mined...")
+	receipt, err := m.waitMined(ctx, tx, txOpts)
+	if err != nil {
+		return tx.Hash(), fmt.Errorf("failed to wait for cancellation: %w", err)
+	}
+	fee := m.receiptFee(ctx, receipt, txOpts.From)
+	m.logger().Infof("✅ Nonce %d cancelled in block %d, fee: %s ETH", nonce, receipt.BlockNumber.Uint64(), fee.ETH())
+	m.logTxLink(receipt.TxHash)
+	opts.mined(fee)
+	return receipt.TxHash, nil
+}
+
+// sendCancel signs and sends the 0-value transfer to opts.From at nonce with the fees
+// on opts, bumping them while the node rejects it as underpriced
+func (m *CrossChainMessenger) sendCancel(ctx context.Context, opts *bind.TransactOpts, nonce uint64) (*types.Transaction, error) {
+	chainID, err := m.ClientL1.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get L1 chain ID: %w", err)
+	}
+	to := opts.From
+	var tx *types.Transaction
+	if opts.GasPrice != nil {
+		tx = types.NewTx(&types.LegacyTx{Nonce: nonce, GasPrice: opts.GasPrice, Gas: cancelGasLimit, To: &to, Value: new(big.Int)})
+	} else {
+		tx = types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: nonce, GasTipCap: opts.GasTipCap, GasFeeCap: opts.GasFeeCap,
+			Gas: cancelGasLimit, To: &to, Value: new(big.Int)})
+	}
+
+	for attempt := 1; ; attempt++ {
+		signed, err := opts.Signer(opts.From, tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign cancellation: %w", err)
+		}
+		err = m.ClientL1.SendTransaction(ctx, signed)
+		if err == nil {
+			return signed, nil
+		}
+		if !isUnderpriced(err) || attempt == maxCancelAttempts {
+			return nil, fmt.Errorf("failed to send cancellation: %w", err)
+		}
+		if tx = m.bumpedTx(tx); tx == nil {
+			return nil, fmt.Errorf("failed to send cancellation: %w; the fee ceiling leaves no room to bump further", err)
+		}
+		m.logger().Warnf("⛽ Cancellation underpriced; retrying at max fee %s gwei, priority fee %s gwei",
+			formatGwei(tx.GasFeeCap()), formatGwei(tx.GasTipCap()))
+	}
+}
+
+// isUnderpriced reports whether the node rejected a transaction for paying too little,
+// e.g. "replacement transaction underpriced" when a pending one has the same nonce
+func isUnderpriced(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "underpriced")
+}
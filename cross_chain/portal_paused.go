@@ -0,0 +1,68 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// PortalPaused reads OptimismPortal's paused flag. While the guardian keeps the portal
+// paused, proveWithdrawalTransaction and finalizeWithdrawalTransaction revert.
+func (m *CrossChainMessenger) PortalPaused(ctx context.Context) (bool, error) {
+	portal, err := m.optimismPortal()
+	if err != nil {
+		return false, err
+	}
+	paused, err := withRetry(ctx, m, "paused", func(ctx context.Context) (bool, error) {
+		return portal.Paused(&bind.CallOpts{Context: ctx})
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check paused state: %w", err)
+	}
+	return paused, nil
+}
+
+// checkNotPaused returns ErrPortalPaused while the portal is paused, so a prove or
+// finalize is skipped instead of sent only to revert
+func (m *CrossChainMessenger) checkNotPaused(ctx context.Context) error {
+	paused, err := m.PortalPaused(ctx)
+	if err != nil {
+		return err
+	}
+	if paused {
+		m.logger().Errorf("⏸️  OptimismPortal %s is paused by the guardian", m.Contracts.L1.OptimismPortal)
+		return ErrPortalPaused
+	}
+	return nil
+}
+
+// warnIfPaused logs a warning when the portal is paused and message still has to go
+// through it. Failing to read the flag is only logged at debug level.
+func (m *CrossChainMessenger) warnIfPaused(ctx context.Context, message Message) {
+	if FinalizedAtPortal(message.Status) {
+		return
+	}
+	paused, err := m.PortalPaused(ctx)
+	if err != nil {
+		m.logger().Debugf("⚠️  %v", err)
+		return
+	}
+	if paused {
+		m.logger().Warnf("⏸️  OptimismPortal is paused by the guardian: prove and finalize revert until it is unpaused")
+	}
+}
+
+// checkPortalPaused warns while the portal is paused; nothing in this configuration can
+// fix it
+func (m *CrossChainMessenger) checkPortalPaused(ctx context.Context) CheckResult {
+	paused, err := m.PortalPaused(ctx)
+	if err != nil {
+		return checkFail("Check that L1_OPTIMISM_PORTAL is the OptimismPortal of this network", "%v", err)
+	}
+	if paused {
+		return CheckResult{Status: CheckWarn, Detail: "paused by the guardian; prove and finalize revert until it is unpaused",
+			Hint: "Nothing to fix here: wait for the guardian to unpause it. The scheduler resumes on its own"}
+	}
+	return checkPass("not paused")
+}
@@ -0,0 +1,69 @@
+package crosschain
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxPreview describes a prove or finalize after gas estimation and right before it is
+// signed, for callers that show it and ask first; see SubmitOptions.Confirm
+type TxPreview struct {
+	Method          string // "proveWithdrawalTransaction", "finalizeWithdrawalTransaction", "relayMessage" or "cancel"
+	TxHash          string // L2 withdrawal transaction
+	WithdrawalHash  string
+	Sender          common.Address // L2 sender of the withdrawal
+	Target          common.Address // L1 address the withdrawal calls
+	MntValue        *big.Int
+	EthValue        *big.Int
+	TokenWithdrawal *TokenWithdrawalInfo // Decoded L1StandardBridge call; nil for other messages
+	From            common.Address       // Wallet that will sign
+	To              common.Address       // OptimismPortal, or L1CrossDomainMessenger for relayMessage
+	GasLimit        uint64
+	MaxFeePerGas    *big.Int   // Max fee per gas, or the gas price with legacy gas
+	MaxCostWei      *big.Int   // GasLimit × MaxFeePerGas; the most the transaction can cost
+	ProofSize       *ProofSize // proveWithdrawalTransaction only
+	Nonce           uint64     // cancel only: the stuck nonce the self-transfer replaces
+}
+
+// newTxPreview describes the method call for message as txOpts, already through
+// applyGasSettings, would send it to the contract at to
+func newTxPreview(method string, message Message, txOpts *bind.TransactOpts, to common.Address) TxPreview {
+	feePerGas := txOpts.GasFeeCap
+	if feePerGas == nil {
+		feePerGas = txOpts.GasPrice
+	}
+	if feePerGas == nil {
+		feePerGas = new(big.Int)
+	}
+	preview := TxPreview{
+		Method:          method,
+		TxHash:          message.TxHash,
+		MntValue:        message.MntValue,
+		EthValue:        message.EthValue,
+		TokenWithdrawal: message.TokenWithdrawal,
+		From:            txOpts.From,
+		To:              to,
+		GasLimit:        txOpts.GasLimit,
+		MaxFeePerGas:    feePerGas,
+		MaxCostWei:      new(big.Int).Mul(feePerGas, new(big.Int).SetUint64(txOpts.GasLimit)),
+	}
+	if message.WithdrawalHash != "" {
+		preview.WithdrawalHash = "0x" + strings.TrimPrefix(message.WithdrawalHash, "0x")
+	}
+	if passed := message.MessagePassedEvent; passed != nil {
+		preview.Sender = passed.Sender
+		preview.Target = passed.Target
+	}
+	return preview
+}
+
+// confirm asks o.Confirm, if set, whether to send the transaction preview describes
+func (o SubmitOptions) confirm(preview TxPreview) error {
+	if o.Confirm == nil {
+		return nil
+	}
+	return o.Confirm(preview)
+}
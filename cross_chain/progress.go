@@ -0,0 +1,87 @@
+package crosschain
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Progress stages, in the order a successful ProveMessage, FinalizeMessage or
+// WaitForStatus reaches them. Each comes with the detail keys noted.
+const (
+	ProgressResuming        = "resuming"               // l1TxHash: a transaction from an earlier run is awaited instead
+	ProgressLoadingMessage  = "loading-message"        // txHash
+	ProgressGeneratingProof = "generating-proof"       // txHash, withdrawalHash (ProveMessage)
+	ProgressProofReady      = "proof-ready"            // outputIndex, l2BlockNumber, proofNodes (ProveMessage)
+	ProgressEstimatingGas   = "estimating-gas"         // method
+	ProgressSubmitted       = "submitted"              // method, l1TxHash, nonce
+	ProgressWaitingReceipt  = "waiting-for-receipt"    // l1TxHash, elapsed, timeout ("" without one); repeated every minute
+	ProgressReplaced        = "replaced"               // l1TxHash, replaces: a stuck transaction was re-sent with higher fees
+	ProgressConfirming      = "awaiting-confirmations" // l1TxHash, blockNumber, confirmations, required: mined, not yet L1_CONFIRMATIONS deep
+	ProgressMined           = "mined"                  // l1TxHash, blockNumber, gasUsed, feeWei (decimal string)
+	ProgressStatus          = "status"                 // status: WaitForStatus saw the withdrawal change status
+	ProgressCheckFailed     = "check-failed"           // error, retryIn: a WaitForStatus check failed and is retried
+	ProgressReached         = "reached"                // status: WaitForStatus reached its target
+)
+
+// progressWaitInterval is how often waitMined repeats ProgressWaitingReceipt
+const progressWaitInterval = time.Minute
+
+// ProgressFunc receives the stages of long operations as they happen, e.g. to show them
+// in a UI or stream them to an API client. detail holds the stage's values as strings
+// and numbers, ready for JSON. It is called on the operation's goroutine, so it should
+// return quickly.
+type ProgressFunc func(stage string, detail map[string]any)
+
+// ProgressEvent is one ProgressFunc call, as recorded by the HTTP API's jobs
+type ProgressEvent struct {
+	Stage  string         `json:"stage"`
+	Detail map[string]any `json:"detail,omitempty"`
+	Time   time.Time      `json:"time"`
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context whose operations also report their progress to fn, in
+// addition to the messenger's Progress. It scopes reporting to one call, e.g. one API
+// job, when the messenger is shared.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progress reports stage to the messenger's Progress and to the one on ctx, if any
+func (m *CrossChainMessenger) progress(ctx context.Context, stage string, detail map[string]any) {
+	if m.Progress != nil {
+		m.Progress(stage, detail)
+	}
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(stage, detail)
+	}
+}
+
+// progressSubmitted reports that tx was sent for method
+func (m *CrossChainMessenger) progressSubmitted(ctx context.Context, method string, tx *types.Transaction) {
+	m.progress(ctx, ProgressSubmitted, map[string]any{"method": method, "l1TxHash": tx.Hash().Hex(), "nonce": tx.Nonce()})
+}
+
+// progressMined reports the receipt of a mined transaction
+func (m *CrossChainMessenger) progressMined(ctx context.Context, receipt *types.Receipt) {
+	detail := map[string]any{"l1TxHash": receipt.TxHash.Hex(), "blockNumber": receipt.BlockNumber.Uint64(), "gasUsed": receipt.GasUsed}
+	if receipt.EffectiveGasPrice != nil {
+		fee := new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		detail["feeWei"] = fee.String()
+	}
+	m.progress(ctx, ProgressMined, detail)
+}
+
+// progressWaiting reports that tx has been waited on for elapsed
+func (m *CrossChainMessenger) progressWaiting(ctx context.Context, tx *types.Transaction, elapsed time.Duration) {
+	timeout := ""
+	if m.Timeouts.WaitMined > 0 {
+		timeout = m.Timeouts.WaitMined.String()
+	}
+	m.progress(ctx, ProgressWaitingReceipt, map[string]any{
+		"l1TxHash": tx.Hash().Hex(), "elapsed": elapsed.Round(time.Second).String(), "timeout": timeout})
+}
@@ -0,0 +1,134 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// progressRecorder keeps the stages a ProgressFunc is called with
+type progressRecorder struct {
+	events []ProgressEvent
+}
+
+func (r *progressRecorder) record(stage string, detail map[string]any) {
+	r.events = append(r.events, ProgressEvent{Stage: stage, Detail: detail})
+}
+
+func (r *progressRecorder) stages() []string {
+	stages := make([]string, len(r.events))
+	for i, event := range r.events {
+		stages[i] = event.Stage
+	}
+	return stages
+}
+
+// detail returns the detail of the first event of stage
+func (r *progressRecorder) detail(t *testing.T, stage string) map[string]any {
+	t.Helper()
+	for _, event := range r.events {
+		if event.Stage == stage {
+			return event.Detail
+		}
+	}
+	t.Fatalf("no %s stage in %v", stage, r.stages())
+	return nil
+}
+
+func progressMessenger(t *testing.T) (*CrossChainMessenger, *fakeL1, string, *progressRecorder) {
+	t.Helper()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	r := &progressRecorder{}
+	m.Progress = r.record
+	return m, l1, l2.receipt.TxHash.Hex(), r
+}
+
+func TestProveProgress(t *testing.T) {
+	m, _, txHash, r := progressMessenger(t)
+	scoped := &progressRecorder{}
+	l1TxHash, err := m.ProveMessage(WithProgress(context.Background(), scoped.record), txHash, 0, SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		ProgressLoadingMessage,
+		ProgressGeneratingProof,
+		ProgressProofReady,
+		ProgressEstimatingGas,
+		ProgressSubmitted,
+		ProgressWaitingReceipt,
+		ProgressMined,
+	}
+	if got := r.stages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stages = %v, want %v", got, want)
+	}
+	if got := scoped.stages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stages reported to the context's ProgressFunc = %v, want %v", got, want)
+	}
+
+	for stage, want := range map[string]map[string]string{
+		ProgressLoadingMessage: {"txHash": txHash},
+		ProgressProofReady:     {"outputIndex": "0", "l2BlockNumber": "10", "proofNodes": "1"},
+		ProgressEstimatingGas:  {"method": "proveWithdrawalTransaction"},
+		ProgressSubmitted:      {"method": "proveWithdrawalTransaction", "l1TxHash": l1TxHash.Hex(), "nonce": "0"},
+		ProgressMined:          {"l1TxHash": l1TxHash.Hex(), "blockNumber": "100", "gasUsed": "150000", "feeWei": "300000000000000"},
+	} {
+		detail := r.detail(t, stage)
+		for key, value := range want {
+			if got := fmt.Sprint(detail[key]); got != value {
+				t.Fatalf("%s %s = %s, want %s", stage, key, got, value)
+			}
+		}
+	}
+}
+
+func TestFinalizeProgress(t *testing.T) {
+	m, l1, txHash, r := progressMessenger(t)
+	if _, err := m.ProveMessage(context.Background(), txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l1.advance(testPeriod)
+	r.events = nil
+
+	if _, err := m.FinalizeMessage(context.Background(), txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ProgressLoadingMessage, ProgressEstimatingGas, ProgressSubmitted, ProgressWaitingReceipt, ProgressMined}
+	if got := r.stages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stages = %v, want %v", got, want)
+	}
+	if method := r.detail(t, ProgressSubmitted)["method"]; method != "finalizeWithdrawalTransaction" {
+		t.Fatalf("submitted method = %v", method)
+	}
+}
+
+func TestWaitForStatusProgress(t *testing.T) {
+	m, l1, txHash, r := progressMessenger(t)
+	if _, err := m.ProveMessage(context.Background(), txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	l1.advance(testPeriod)
+	r.events = nil
+
+	if err := m.WaitForStatus(context.Background(), txHash, 0, WaitReadyToFinalize, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ProgressStatus, ProgressReached}
+	if got := r.stages(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stages = %v, want %v", got, want)
+	}
+	if status := r.detail(t, ProgressReached)["status"]; status != waitTargetDescription(WaitReadyToFinalize) {
+		t.Fatalf("reached status = %v, want %s", status, waitTargetDescription(WaitReadyToFinalize))
+	}
+}
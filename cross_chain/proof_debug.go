@@ -0,0 +1,76 @@
+package crosschain
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// proofNodeDetail describes every node of a withdrawal proof: its size, RLP prefix, node
+// type and hex (shortened past 64 bytes). It is too long for normal runs, so it is only
+// logged with --debug-proof or at debug level, after a prove reverts, and in bundles
+// exported with --debug-proof.
+func proofNodeDetail(withdrawalProof [][]byte) []string {
+	var lines []string
+	for i, proof := range withdrawalProof {
+		lines = append(lines, fmt.Sprintf("Proof[%d]: %d bytes", i, len(proof)))
+		if len(proof) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  First byte: 0x%02x (RLP prefix)", proof[0]))
+
+		// Determine the node type from its RLP structure
+		var rlpData []interface{}
+		if err := rlp.DecodeBytes(proof, &rlpData); err == nil {
+			switch len(rlpData) {
+			case 17:
+				lines = append(lines, "  Type: Branch node (17 elements)")
+			case 2:
+				lines = append(lines, "  Type: Leaf/Extension node (2 elements)")
+			default:
+				lines = append(lines, fmt.Sprintf("  Type: Unknown (%d elements)", len(rlpData)))
+			}
+		}
+
+		if len(proof) <= 64 {
+			lines = append(lines, fmt.Sprintf("  Hex: 0x%x", proof))
+		} else {
+			lines = append(lines, fmt.Sprintf("  Hex (first 32): 0x%x...", proof[:32]))
+			lines = append(lines, fmt.Sprintf("  Hex (last 32): ...0x%x", proof[len(proof)-32:]))
+		}
+	}
+	return lines
+}
+
+// proofDetailLogged reports whether logProofNodes already dumped the proof, so a revert
+// doesn't log it twice
+func (m *CrossChainMessenger) proofDetailLogged() bool {
+	return m.DebugProof || debugEnabled(m.logger())
+}
+
+// logProofNodes dumps every proof node: at info level with DebugProof, else at debug
+// level
+func (m *CrossChainMessenger) logProofNodes(withdrawalProof [][]byte) {
+	if !m.proofDetailLogged() {
+		return
+	}
+	logf := m.logger().Debugf
+	if m.DebugProof {
+		logf = m.logger().Infof
+	}
+	for _, line := range proofNodeDetail(withdrawalProof) {
+		logf("  %s", line)
+	}
+}
+
+// logRevertedProof dumps the proof of a reverted prove once at warning level, so the
+// failure can be diagnosed from the logs without re-running with --debug-proof
+func (m *CrossChainMessenger) logRevertedProof(withdrawalProof [][]byte) {
+	if m.proofDetailLogged() {
+		return
+	}
+	m.logger().Warnf("🔬 Proof of the reverted prove (%d nodes):", len(withdrawalProof))
+	for _, line := range proofNodeDetail(withdrawalProof) {
+		m.logger().Warnf("  %s", line)
+	}
+}
@@ -0,0 +1,325 @@
+package crosschain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+	"mantle-claim-crossing/helper"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ProofFileVersion is the ProofFile layout SaveWithdrawalProof writes and
+// LoadWithdrawalProof accepts
+const ProofFileVersion = 1
+
+// ProofFile is a generated withdrawal proof with every proveWithdrawalTransaction
+// argument, so a machine without archive node access can submit it later
+type ProofFile struct {
+	Version         int             `json:"version"`
+	TxHash          string          `json:"txHash"`
+	WithdrawalHash  common.Hash     `json:"withdrawalHash"`
+	OutputIndex     uint64          `json:"outputIndex"`
+	OutputRoot      common.Hash     `json:"outputRoot"`    // Root the oracle held for OutputIndex when the proof was generated
+	L2BlockNumber   uint64          `json:"l2BlockNumber"` // L2 block of that output; the proof is against its state
+	OutputRootProof ProofOutputRoot `json:"outputRootProof"`
+	WithdrawalProof []hexutil.Bytes `json:"withdrawalProof"` // sentMessages storage proof nodes
+	Withdrawal      ProofWithdrawal `json:"withdrawal"`
+	Size            ProofSize       `json:"size"` // Of WithdrawalProof; informational, not read back
+	GeneratedAt     time.Time       `json:"generatedAt"`
+}
+
+// ProofOutputRoot is the preimage of an output root
+type ProofOutputRoot struct {
+	Version                  common.Hash `json:"version"`
+	StateRoot                common.Hash `json:"stateRoot"`
+	MessagePasserStorageRoot common.Hash `json:"messagePasserStorageRoot"`
+	LatestBlockhash          common.Hash `json:"latestBlockhash"`
+}
+
+// ProofWithdrawal is the withdrawal transaction the proof is for
+type ProofWithdrawal struct {
+	Nonce    *big.Int       `json:"nonce"` // Versioned nonce, as hashed
+	Sender   common.Address `json:"sender"`
+	Target   common.Address `json:"target"`
+	MntValue *big.Int       `json:"mntValue"`
+	EthValue *big.Int       `json:"ethValue"`
+	GasLimit *big.Int       `json:"gasLimit"`
+	Data     hexutil.Bytes  `json:"data"`
+}
+
+// GenerateWithdrawalProof generates the proof for the withdrawal in txHash against the
+// L2 output proposed for l2BlockNumber, or the first output covering the withdrawal
+// when l2BlockNumber is 0. The output root the proof implies is checked against the
+// oracle's. Nothing is signed or sent.
+func (m *CrossChainMessenger) GenerateWithdrawalProof(ctx context.Context, txHash string, messageIndex int, l2BlockNumber uint64) (_ *WithdrawalProof, _ *cross_abi.TypesOutputRootProof, err error) {
+	ctx, span := startSpan(ctx, "GenerateWithdrawalProof", append(messageAttrs(txHash, messageIndex), attrL2Block.Int64(int64(l2BlockNumber)))...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return nil, nil, err
+	}
+	_, call, err := m.generateProveCall(ctx, txHash, l2BlockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	return call.proof, &call.outputRootProof, nil
+}
+
+// ExportWithdrawalProof is GenerateWithdrawalProof returning everything a later
+// ProveMessageWithProof needs, ready for SaveWithdrawalProof
+func (m *CrossChainMessenger) ExportWithdrawalProof(ctx context.Context, txHash string, messageIndex int, l2BlockNumber uint64) (_ *ProofFile, err error) {
+	ctx, span := startSpan(ctx, "ExportWithdrawalProof", append(messageAttrs(txHash, messageIndex), attrL2Block.Int64(int64(l2BlockNumber)))...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return nil, err
+	}
+	message, call, err := m.generateProveCall(ctx, txHash, l2BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return newProofFile(message, call), nil
+}
+
+// generateProveCall reads the withdrawal in txHash and builds its prove call
+func (m *CrossChainMessenger) generateProveCall(ctx context.Context, txHash string, l2BlockNumber uint64) (Message, *proveCall, error) {
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return message, nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	if message.Status >= StatusFinalized {
+		return message, nil, ErrAlreadyFinalized
+	}
+	call, err := m.buildProveCallAt(ctx, message, l2BlockNumber, common.Hash{})
+	if err != nil {
+		return message, nil, err
+	}
+	return message, call, nil
+}
+
+// newProofFile records call, built for message, as a ProofFile
+func newProofFile(message Message, call *proveCall) *ProofFile {
+	nodes := make([]hexutil.Bytes, len(call.withdrawalProof))
+	for i, node := range call.withdrawalProof {
+		nodes[i] = node
+	}
+	tx := call.withdrawalTx
+	return &ProofFile{
+		Version:        ProofFileVersion,
+		TxHash:         message.TxHash,
+		WithdrawalHash: common.HexToHash(message.WithdrawalHash),
+		OutputIndex:    call.outputIndex,
+		OutputRoot:     call.outputRoot,
+		L2BlockNumber:  call.l2BlockNumber,
+		OutputRootProof: ProofOutputRoot{
+			Version:                  call.outputRootProof.Version,
+			StateRoot:                call.outputRootProof.StateRoot,
+			MessagePasserStorageRoot: call.outputRootProof.MessagePasserStorageRoot,
+			LatestBlockhash:          call.outputRootProof.LatestBlockhash,
+		},
+		WithdrawalProof: nodes,
+		Size:            MeasureProof(call.withdrawalProof),
+		Withdrawal: ProofWithdrawal{
+			Nonce:    tx.Nonce,
+			Sender:   tx.Sender,
+			Target:   tx.Target,
+			MntValue: tx.MntValue,
+			EthValue: tx.EthValue,
+			GasLimit: tx.GasLimit,
+			Data:     tx.Data,
+		},
+		GeneratedAt: time.Now().UTC(),
+	}
+}
+
+// proveCall turns the file back into proveWithdrawalTransaction arguments
+func (p *ProofFile) proveCall() *proveCall {
+	nodes := make([][]byte, len(p.WithdrawalProof))
+	for i, node := range p.WithdrawalProof {
+		nodes[i] = node
+	}
+	return &proveCall{
+		withdrawalTx: cross_abi.TypesWithdrawalTransaction{
+			Nonce:    p.Withdrawal.Nonce,
+			Sender:   p.Withdrawal.Sender,
+			Target:   p.Withdrawal.Target,
+			MntValue: p.Withdrawal.MntValue,
+			EthValue: p.Withdrawal.EthValue,
+			GasLimit: p.Withdrawal.GasLimit,
+			Data:     p.Withdrawal.Data,
+		},
+		outputIndex:   p.OutputIndex,
+		outputRoot:    p.OutputRoot,
+		l2BlockNumber: p.L2BlockNumber,
+		outputRootProof: cross_abi.TypesOutputRootProof{
+			Version:                  p.OutputRootProof.Version,
+			StateRoot:                p.OutputRootProof.StateRoot,
+			MessagePasserStorageRoot: p.OutputRootProof.MessagePasserStorageRoot,
+			LatestBlockhash:          p.OutputRootProof.LatestBlockhash,
+		},
+		withdrawalProof: nodes,
+	}
+}
+
+// ProveMessageWithProof proves the withdrawal in txHash with a proof file written by
+// SaveWithdrawalProof, e.g. on a machine with archive node access, instead of
+// generating the proof here. Before sending it checks the file belongs to txHash and
+// that the oracle still holds the output it was generated against; ErrStaleProof means
+// it doesn't and a new proof must be generated. Like ProveMessage, a prove sent by an
+// earlier run (opts.PreviousTx) is awaited instead.
+func (m *CrossChainMessenger) ProveMessageWithProof(ctx context.Context, txHash string, messageIndex int, proofPath string, opts SubmitOptions) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "ProveMessageWithProof", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("\n=== PROVE MESSAGE (PROOF FILE) ===")
+	m.logger().Infof("Transaction hash (on L2): %s", txHash)
+	m.logger().Infof("Proof file: %s", proofPath)
+	return m.proveWithLoadedProof(ctx, txHash, "proof file "+proofPath, func() (*ProofFile, error) {
+		return LoadWithdrawalProof(proofPath)
+	}, opts)
+}
+
+// proveWithLoadedProof submits the proof load returns for txHash once it checks out
+// against the chain; source names where it came from in errors. load only runs when no
+// earlier prove is awaited instead.
+func (m *CrossChainMessenger) proveWithLoadedProof(ctx context.Context, txHash, source string, load func() (*ProofFile, error), opts SubmitOptions) (common.Hash, error) {
+	if !m.HasSigner() {
+		return common.Hash{}, ErrNoSigner
+	}
+	if receipt, err := m.resumeSubmitted(ctx, opts); err != nil || receipt != nil {
+		if err != nil {
+			return opts.PreviousTx, err
+		}
+		opts.mined(m.receiptFee(ctx, receipt, opts.From))
+		m.logger().Infof("✅ Message proved successfully!")
+		return opts.PreviousTx, nil
+	}
+
+	p, err := load()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if !strings.EqualFold(p.TxHash, txHash) {
+		return common.Hash{}, fmt.Errorf("%s is for transaction %s, not %s", source, p.TxHash, txHash)
+	}
+
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+	if message.Status >= StatusFinalized {
+		m.logger().Infof("✅ Message already finalized")
+		return common.Hash{}, ErrAlreadyFinalized
+	}
+	if hash := common.HexToHash(message.WithdrawalHash); hash != p.WithdrawalHash {
+		return common.Hash{}, fmt.Errorf("%w: %s is for withdrawal %s, the transaction's is %s",
+			ErrWithdrawalHashMismatch, source, p.WithdrawalHash.Hex(), hash.Hex())
+	}
+	if err := m.checkProofFileOutput(ctx, p); err != nil {
+		return common.Hash{}, err
+	}
+	if err := m.checkNotPaused(ctx); err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("✅ Proof file matches output #%d (root %s)", p.OutputIndex, p.OutputRoot.Hex())
+	release, err := m.lockWithdrawal(ctx, "prove", &message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer release()
+
+	return m.submitProveCall(ctx, message, p.proveCall(), opts)
+}
+
+// checkProofFileOutput returns ErrStaleProof unless the oracle still holds p.OutputRoot
+// at p.OutputIndex
+func (m *CrossChainMessenger) checkProofFileOutput(ctx context.Context, p *ProofFile) error {
+	current, deleted, err := m.checkProvenance(ctx, ProofProvenance{OutputIndex: p.OutputIndex, OutputRoot: p.OutputRoot})
+	if err != nil {
+		return fmt.Errorf("failed to check the proof's output: %w", err)
+	}
+	if !deleted {
+		return nil
+	}
+	reason := "was deleted"
+	if current != (common.Hash{}) {
+		reason = fmt.Sprintf("now has root %s", current.Hex())
+	}
+	return fmt.Errorf("%w: it was generated %s against output #%d (root %s), which %s; generate a new one with `proof %s`",
+		ErrStaleProof, p.GeneratedAt.Format(time.RFC3339), p.OutputIndex, p.OutputRoot.Hex(), reason, p.TxHash)
+}
+
+// SaveWithdrawalProof writes p to path as indented JSON
+func SaveWithdrawalProof(path string, p *ProofFile) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode proof: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write proof file: %w", err)
+	}
+	return nil
+}
+
+// LoadWithdrawalProof reads a file written by SaveWithdrawalProof and checks it is
+// internally consistent: the withdrawal hashes to WithdrawalHash, the storage proof
+// shows it sent under the message passer storage root, and the output root proof
+// hashes to OutputRoot. Whether the oracle still holds that output is up to the caller.
+func LoadWithdrawalProof(path string) (*ProofFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file: %w", err)
+	}
+	var p ProofFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to parse proof file %s: %w", path, err)
+	}
+	if err := p.validate("proof file " + path); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// validate checks a decoded proof is internally consistent; source names it in errors
+func (p *ProofFile) validate(source string) error {
+	if p.Version != ProofFileVersion {
+		return fmt.Errorf("%s has version %d, this build reads version %d", source, p.Version, ProofFileVersion)
+	}
+	w := p.Withdrawal
+	if w.Nonce == nil || w.MntValue == nil || w.EthValue == nil || w.GasLimit == nil {
+		return fmt.Errorf("%s is missing withdrawal fields", source)
+	}
+
+	call := p.proveCall()
+	hash, err := ComputeWithdrawalHash(call.withdrawalTx)
+	if err != nil {
+		return fmt.Errorf("%s has an invalid withdrawal: %w", source, err)
+	}
+	if hash != p.WithdrawalHash {
+		return fmt.Errorf("%w: %s withdrawal hashes to %s, file says %s",
+			ErrWithdrawalHashMismatch, source, hash.Hex(), p.WithdrawalHash.Hex())
+	}
+	slot := SentMessagesSlot(p.WithdrawalHash.Hex())
+	value, err := helper.VerifyStorageProof(call.outputRootProof.MessagePasserStorageRoot, slot, call.withdrawalProof)
+	if err != nil {
+		return fmt.Errorf("%s has an invalid withdrawal proof: %w", source, err)
+	}
+	if !bytes.Equal(value, []byte{0x01}) {
+		return fmt.Errorf("%s has an invalid withdrawal proof: sentMessages value is 0x%x, want 0x01", source, value)
+	}
+	if root := common.Hash(computeOutputRoot(call.outputRootProof)); root != p.OutputRoot {
+		return fmt.Errorf("%s output root proof hashes to %s, file says %s", source, root.Hex(), p.OutputRoot.Hex())
+	}
+	return nil
+}
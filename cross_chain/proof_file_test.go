@@ -0,0 +1,143 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// exportProof writes the proof of the withdrawal l2 holds to a file, as the proof
+// command does, and returns its path
+func exportProof(t *testing.T, m *CrossChainMessenger, l2 *fakeL2) string {
+	t.Helper()
+	p, err := m.ExportWithdrawalProof(context.Background(), l2.receipt.TxHash.Hex(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "proof.json")
+	if err := SaveWithdrawalProof(path, p); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProofFileRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	tx := testWithdrawal()
+	l2 := newFakeL2(t, tx)
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	txHash := l2.receipt.TxHash.Hex()
+	path := exportProof(t, m, l2)
+
+	p, err := LoadWithdrawalProof(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TxHash != txHash || p.OutputRoot != l1.outputs[0].OutputRoot || p.L2BlockNumber != l2.header.Number.Uint64() {
+		t.Fatalf("loaded proof is for %s against root %s at block %d", p.TxHash, p.OutputRoot.Hex(), p.L2BlockNumber)
+	}
+
+	// Submitted by a messenger that never generates a proof itself
+	submitter := proveFinalizeMessenger(t, l1, &fakeL2{receipt: l2.receipt}, key)
+	if _, err := submitter.ProveMessageWithProof(ctx, txHash, 0, path, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	name, args := l1.lastCall(t)
+	if name != "proveWithdrawalTransaction" {
+		t.Fatalf("sent %s, want proveWithdrawalTransaction", name)
+	}
+	assertWithdrawal(t, args[0], tx)
+	if status, err := m.GetMessageStatus(ctx, txHash); err != nil || status != StatusProven {
+		t.Fatalf("status after proving with the file = %d, %v, want %d", status, err, StatusProven)
+	}
+}
+
+func TestProofFileStale(t *testing.T) {
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	path := exportProof(t, m, l2)
+
+	// The output was deleted and proposed again with another root
+	replaced := crypto.Keccak256Hash([]byte("replaced"))
+	l1.outputs[0].OutputRoot = replaced
+	_, err = m.ProveMessageWithProof(context.Background(), l2.receipt.TxHash.Hex(), 0, path, SubmitOptions{})
+	if !errors.Is(err, ErrStaleProof) {
+		t.Fatalf("ProveMessageWithProof() = %v, want ErrStaleProof", err)
+	}
+	if !strings.Contains(err.Error(), replaced.Hex()) || !strings.Contains(err.Error(), "generate a new one") {
+		t.Fatalf("ProveMessageWithProof() error = %v, want it to name the new root and what to do", err)
+	}
+	if len(l1.sent) != 0 {
+		t.Fatal("a stale proof was sent")
+	}
+}
+
+func TestProofFileRejected(t *testing.T) {
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	path := exportProof(t, m, l2)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edit := func(old, new string) string {
+		t.Helper()
+		if !strings.Contains(string(data), old) {
+			t.Fatalf("proof file has no %q", old)
+		}
+		edited := filepath.Join(t.TempDir(), "proof.json")
+		if err := os.WriteFile(edited, []byte(strings.Replace(string(data), old, new, 1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return edited
+	}
+	stateRoot := l2.header.Root.Hex()
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"version", edit(`"version": 1`, `"version": 2`), "version 2"},
+		{"unknown field", edit(`"version": 1`, `"version": 1, "extra": true`), "unknown field"},
+		{"withdrawal", edit(`"gasLimit": 100000`, `"gasLimit": 100001`), "withdrawal hashes to"},
+		{"output root proof", edit(stateRoot, common.HexToHash("0x01").Hex()), "output root proof hashes to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.ProveMessageWithProof(context.Background(), l2.receipt.TxHash.Hex(), 0, tt.path, SubmitOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ProveMessageWithProof() = %v, want an error naming %q", err, tt.want)
+			}
+		})
+	}
+
+	other := common.HexToHash("0xbb").Hex()
+	if _, err := m.ProveMessageWithProof(context.Background(), other, 0, path, SubmitOptions{}); err == nil || !strings.Contains(err.Error(), "not "+other) {
+		t.Fatalf("ProveMessageWithProof() of another transaction = %v", err)
+	}
+	if len(l1.sent) != 0 {
+		t.Fatal("a rejected proof was sent")
+	}
+}
@@ -0,0 +1,60 @@
+package crosschain
+
+import (
+	"fmt"
+
+	"mantle-claim-crossing/helper"
+)
+
+// Calldata gas per byte (EIP-2028)
+const (
+	calldataZeroByteGas    = 4
+	calldataNonZeroByteGas = 16
+)
+
+// ProofSize is what a withdrawal proof adds to the proveWithdrawalTransaction calldata
+type ProofSize struct {
+	Nodes       int    `json:"nodes"`
+	Bytes       int    `json:"bytes"`       // Sum of the node lengths, without ABI encoding overhead
+	CalldataGas uint64 `json:"calldataGas"` // 16 gas per non-zero byte, 4 per zero byte
+}
+
+// MeasureProof returns the size and calldata gas of the proof nodes
+func MeasureProof(proof [][]byte) ProofSize {
+	size := ProofSize{Nodes: len(proof)}
+	for _, node := range proof {
+		size.Bytes += len(node)
+		for _, b := range node {
+			if b == 0 {
+				size.CalldataGas += calldataZeroByteGas
+			} else {
+				size.CalldataGas += calldataNonZeroByteGas
+			}
+		}
+	}
+	return size
+}
+
+func (s ProofSize) String() string {
+	return fmt.Sprintf("%d nodes, %d bytes, ~%d calldata gas", s.Nodes, s.Bytes, s.CalldataGas)
+}
+
+// compactProof drops the nodes of proof that are off the path to slot when CompactProofs
+// is set (--compact-proof). It is an experiment, so proof is kept whenever the trimmed
+// one doesn't verify.
+func (m *CrossChainMessenger) compactProof(root [32]byte, slot [32]byte, proof [][]byte) [][]byte {
+	if !m.CompactProofs {
+		return proof
+	}
+	compacted, err := helper.CompactStorageProof(root, slot, proof)
+	if err != nil {
+		m.logger().Warnf("⚠️  Keeping the full proof: %v", err)
+		return proof
+	}
+	if dropped := len(proof) - len(compacted); dropped > 0 {
+		before, after := MeasureProof(proof), MeasureProof(compacted)
+		m.logger().Infof("✂️  Compacted proof: dropped %d redundant node(s), %d bytes, ~%d calldata gas",
+			dropped, before.Bytes-after.Bytes, before.CalldataGas-after.CalldataGas)
+	}
+	return compacted
+}
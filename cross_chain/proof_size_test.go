@@ -0,0 +1,206 @@
+package crosschain
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mantle-claim-crossing/helper"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+var synthesizeProofs = flag.Bool("synthesize-proofs", false, "rewrite the synthetic-*.json proof fixtures")
+
+// proofFixtureDir holds eth_getProof responses for withdrawals, recorded by
+// cmd/proof-fixture or, in synthetic-*.json, built from a local trie
+const proofFixtureDir = "testdata/proofs"
+
+// proofFixture is one eth_getProof response for the sentMessages slot of a withdrawal
+type proofFixture struct {
+	Synthetic      string `json:"synthetic,omitempty"` // How the fixture was built; empty when recorded from a node
+	Network        string `json:"network"`
+	Block          uint64 `json:"block"`
+	WithdrawalHash string `json:"withdrawalHash"`
+	Proof          struct {
+		StorageHash  common.Hash `json:"storageHash"`
+		StorageProof []struct {
+			Key   string          `json:"key"`
+			Value string          `json:"value"`
+			Proof []hexutil.Bytes `json:"proof"`
+		} `json:"storageProof"`
+	} `json:"proof"`
+}
+
+// withdrawalProof returns the storage root, the slot and the proof as getWithdrawalProof
+// builds it from the response, before compacting
+func (f proofFixture) withdrawalProof(tb testing.TB) ([32]byte, [32]byte, [][]byte) {
+	tb.Helper()
+	if len(f.Proof.StorageProof) != 1 {
+		tb.Fatalf("fixture has %d storage proofs, want 1", len(f.Proof.StorageProof))
+	}
+	slot := SentMessagesSlot(f.WithdrawalHash)
+	if got := common.HexToHash(f.Proof.StorageProof[0].Key); got != slot {
+		tb.Fatalf("fixture proves slot %s, the withdrawal is in %s", got.Hex(), slot.Hex())
+	}
+	var proof [][]byte
+	for _, node := range f.Proof.StorageProof[0].Proof {
+		proof = append(proof, node)
+	}
+	proof, err := helper.MaybeAddProofNode(crypto.Keccak256Hash(slot[:]), proof)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return f.Proof.StorageHash, slot, proof
+}
+
+// loadProofFixtures returns the proof fixtures by file name
+func loadProofFixtures(tb testing.TB) map[string]proofFixture {
+	tb.Helper()
+	paths, err := filepath.Glob(filepath.Join(proofFixtureDir, "*.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	fixtures := make(map[string]proofFixture)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		var fixture proofFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			tb.Fatalf("%s: %v", path, err)
+		}
+		fixtures[strings.TrimSuffix(filepath.Base(path), ".json")] = fixture
+	}
+	if len(fixtures) == 0 {
+		tb.Fatalf("no proof fixtures in %s", proofFixtureDir)
+	}
+	return fixtures
+}
+
+// syntheticProofSizes are the sentMessages entries in the tries the synthetic fixtures
+// are built from, standing in for a young chain and for a busy one
+var syntheticProofSizes = []int{1_000, 100_000, 1_000_000}
+
+// writeSyntheticProofFixtures builds a storage trie holding true in the sentMessages slot
+// of n made-up withdrawals for each size and writes the proof of the last one
+func writeSyntheticProofFixtures(t *testing.T) {
+	for _, n := range syntheticProofSizes {
+		tr := trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+		var withdrawalHash common.Hash
+		for i := 0; i < n; i++ {
+			withdrawalHash = crypto.Keccak256Hash([]byte(fmt.Sprintf("synthetic withdrawal %d", i)))
+			slot := SentMessagesSlot(withdrawalHash.Hex())
+			tr.MustUpdate(crypto.Keccak256(slot[:]), []byte{0x01})
+		}
+		slot := SentMessagesSlot(withdrawalHash.Hex())
+		var nodes trienode.ProofList
+		if err := tr.Prove(crypto.Keccak256(slot[:]), &nodes); err != nil {
+			t.Fatal(err)
+		}
+
+		var fixture proofFixture
+		fixture.Synthetic = fmt.Sprintf("built by TestProofFixtures -synthesize-proofs from a local trie of %d made-up withdrawals, not recorded from a node", n)
+		fixture.Network = DefaultNetwork
+		fixture.WithdrawalHash = withdrawalHash.Hex()
+		fixture.Proof.StorageHash = tr.Hash()
+		fixture.Proof.StorageProof = make([]struct {
+			Key   string          `json:"key"`
+			Value string          `json:"value"`
+			Proof []hexutil.Bytes `json:"proof"`
+		}, 1)
+		fixture.Proof.StorageProof[0].Key = slot.Hex()
+		fixture.Proof.StorageProof[0].Value = "0x1"
+		for _, node := range nodes {
+			fixture.Proof.StorageProof[0].Proof = append(fixture.Proof.StorageProof[0].Proof, hexutil.Bytes(node))
+		}
+
+		data, err := json.MarshalIndent(fixture, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(proofFixtureDir, fmt.Sprintf("synthetic-%d.json", n))
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMeasureProof(t *testing.T) {
+	tests := []struct {
+		name  string
+		proof [][]byte
+		want  ProofSize
+	}{
+		{"empty", nil, ProofSize{}},
+		{"zero bytes", [][]byte{{0, 0, 0}}, ProofSize{Nodes: 1, Bytes: 3, CalldataGas: 12}},
+		{"mixed", [][]byte{{0xf8, 0x00}, {0x01}}, ProofSize{Nodes: 2, Bytes: 3, CalldataGas: 36}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MeasureProof(tt.proof); got != tt.want {
+				t.Fatalf("MeasureProof() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProofFixtures(t *testing.T) {
+	if *synthesizeProofs {
+		writeSyntheticProofFixtures(t)
+	}
+	for name, fixture := range loadProofFixtures(t) {
+		t.Run(name, func(t *testing.T) {
+			root, slot, proof := fixture.withdrawalProof(t)
+			if value, err := helper.VerifyStorageProof(root, slot, proof); err != nil || len(value) != 1 || value[0] != 0x01 {
+				t.Fatalf("proof of %s doesn't show it sent: %x, %v", fixture.WithdrawalHash, value, err)
+			}
+			compacted, err := helper.CompactStorageProof(root, slot, proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			full, compact := MeasureProof(proof), MeasureProof(compacted)
+			if compact.Nodes > full.Nodes || compact.CalldataGas > full.CalldataGas {
+				t.Fatalf("compacted proof (%s) is bigger than the full one (%s)", compact, full)
+			}
+			t.Logf("%s: full %s; compacted %s", name, full, compact)
+		})
+	}
+}
+
+// BenchmarkWithdrawalProof times turning each fixture's eth_getProof response into the
+// proof sent on L1, as generateWithdrawalProof does, with and without --compact-proof, and
+// reports the size of the result
+func BenchmarkWithdrawalProof(b *testing.B) {
+	for name, fixture := range loadProofFixtures(b) {
+		root, slot, _ := fixture.withdrawalProof(b)
+		for _, compact := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/compact=%t", name, compact), func(b *testing.B) {
+				m := &CrossChainMessenger{CompactProofs: compact, Logger: NopLogger()}
+				var size ProofSize
+				for i := 0; i < b.N; i++ {
+					_, _, proof := fixture.withdrawalProof(b)
+					proof = m.compactProof(root, slot, proof)
+					if _, err := helper.VerifyStorageProof(root, slot, proof); err != nil {
+						b.Fatal(err)
+					}
+					size = MeasureProof(proof)
+				}
+				b.ReportMetric(float64(size.Nodes), "nodes/proof")
+				b.ReportMetric(float64(size.Bytes), "bytes/proof")
+				b.ReportMetric(float64(size.CalldataGas), "calldata-gas/proof")
+			})
+		}
+	}
+}
@@ -0,0 +1,700 @@
+package crosschain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+	"mantle-claim-crossing/helper"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testPeriod is the finalization period the fake L2OutputOracle reports
+const testPeriod = time.Hour
+
+// fakeL2 is an L2 node holding one withdrawal: its receipt and the L2ToL1MessagePasser
+// storage proving it at header, the block the output is proposed for. Later blocks
+// have the same state.
+type fakeL2 struct {
+	EthClient
+
+	receipt     *types.Receipt
+	header      *types.Header
+	storageRoot common.Hash
+	proofNode   []byte
+
+	// lagging blocks are served with a state root other than the one the proposer saw,
+	// like a node that hasn't settled them yet
+	lagging map[uint64]bool
+	proofs  int // eth_getProof calls for the withdrawal
+}
+
+// newFakeL2 serves tx as sent in block 7, with an output covering it at block 10
+func newFakeL2(t testing.TB, tx cross_abi.TypesWithdrawalTransaction) *fakeL2 {
+	t.Helper()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A storage trie with sentMessages[hash] = true as its only leaf: the leaf's path is
+	// the whole hashed slot, behind the hex-prefix flag of an even-length leaf
+	slot := SentMessagesSlot(hash.Hex())
+	path := append([]byte{0x20}, crypto.Keccak256(slot[:])...)
+	node, err := rlp.EncodeToBytes([][]byte{path, {0x01}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeL2{
+		receipt: &types.Receipt{
+			TxHash:      common.HexToHash("0xaa"),
+			BlockNumber: big.NewInt(7),
+			Logs:        []*types.Log{messagePassedLog(t, tx, hash)},
+		},
+		header: &types.Header{
+			Number:     big.NewInt(10),
+			Root:       common.HexToHash("0x5757"),
+			Difficulty: big.NewInt(0),
+		},
+		storageRoot: crypto.Keccak256Hash(node),
+		proofNode:   node,
+		lagging:     make(map[uint64]bool),
+	}
+}
+
+// headerAt is the header of block number as the proposer saw it
+func (l *fakeL2) headerAt(number uint64) *types.Header {
+	header := types.CopyHeader(l.header)
+	header.Number = new(big.Int).SetUint64(number)
+	return header
+}
+
+// outputRoot is the root the proposer posts for l.header
+func (l *fakeL2) outputRoot() common.Hash {
+	return l.outputRootAt(l.header.Number.Uint64())
+}
+
+// outputRootAt is the root the proposer posts for block number
+func (l *fakeL2) outputRootAt(number uint64) common.Hash {
+	header := l.headerAt(number)
+	return computeOutputRoot(cross_abi.TypesOutputRootProof{
+		StateRoot:                header.Root,
+		MessagePasserStorageRoot: l.storageRoot,
+		LatestBlockhash:          header.Hash(),
+	})
+}
+
+// servedHeader is the header the node serves for the block tag, which is l.header for
+// anything but a block number
+func (l *fakeL2) servedHeader(tag interface{}) *types.Header {
+	s, _ := tag.(string)
+	number, err := hexutil.DecodeUint64(s)
+	if err != nil {
+		return l.header
+	}
+	header := l.headerAt(number)
+	if l.lagging[number] {
+		header.Root = crypto.Keccak256Hash(header.Root[:])
+	}
+	return header
+}
+
+func (l *fakeL2) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if hash != l.receipt.TxHash {
+		return nil, ethereum.NotFound
+	}
+	return l.receipt, nil
+}
+
+func (l *fakeL2) BlockNumber(ctx context.Context) (uint64, error) {
+	return l.header.Number.Uint64(), nil
+}
+
+func (l *fakeL2) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	for i := range elems {
+		var result any
+		switch elems[i].Method {
+		case "eth_getBlockByNumber":
+			result = l.servedHeader(elems[i].Args[0])
+		case "eth_getBlockByHash":
+			result = l.header
+		case "eth_getProof":
+			if slots := elems[i].Args[1].([]string); len(slots) == 0 {
+				// The account proof, which the fake doesn't have
+				result = map[string]any{"accountProof": []string{}}
+				break
+			}
+			l.proofs++
+			result = map[string]any{
+				"accountProof": []string{},
+				"storageHash":  l.storageRoot,
+				"storageProof": []map[string]any{{
+					"key":   elems[i].Args[1].([]string)[0],
+					"value": "0x1",
+					"proof": []string{hexutil.Encode(l.proofNode)},
+				}},
+			}
+		case "eth_getStorageAt":
+			result = hexutil.Bytes{0x01}
+		default:
+			elems[i].Error = fmt.Errorf("fakeL2: unexpected %s", elems[i].Method)
+			continue
+		}
+		elems[i].Error = setResult(elems[i].Result, result)
+	}
+	return nil
+}
+
+func (l *fakeL2) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	elem := rpc.BatchElem{Method: method, Args: args, Result: result}
+	if err := l.BatchCallContext(ctx, []rpc.BatchElem{elem}); err != nil {
+		return err
+	}
+	return elem.Error
+}
+
+// setResult stores result in target the way the RPC client would, through JSON
+func setResult(target, result any) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// provenWithdrawal is the fake portal's provenWithdrawals entry
+type provenWithdrawal struct {
+	outputRoot  common.Hash
+	timestamp   time.Time
+	outputIndex *big.Int
+}
+
+// fakeL1 is an L1 node running an OptimismPortal and L2OutputOracle at the mainnet
+// addresses, with one output proposed unless a test adds more. Sent transactions are
+// mined at once.
+type fakeL1 struct {
+	EthClient
+
+	t       testing.TB
+	chainID *big.Int
+	portal  common.Address
+	oracle  common.Address
+	outputs []cross_abi.TypesOutputProposal // By output index
+
+	messenger common.Address
+
+	portalABI    *abi.ABI
+	oracleABI    *abi.ABI
+	messengerABI *abi.ABI
+
+	// onSend runs before each sent transaction is mined, e.g. another relayer's
+	// transaction landing first
+	onSend func()
+
+	mu        sync.Mutex
+	callErrs  map[string]error // eth_call failures by method name, e.g. a node that can't serve the portal
+	proven    map[common.Hash]provenWithdrawal
+	finalized map[common.Hash]bool
+	relayed   map[common.Hash]RelayResult // By cross domain message hash
+	sent      []*types.Transaction
+	receipts  map[common.Hash]*types.Receipt
+}
+
+func newFakeL1(t testing.TB, l2 *fakeL2) *fakeL1 {
+	t.Helper()
+	portalABI, err := cross_abi.OptimismPortalMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oracleABI, err := cross_abi.L2OutputOracleMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	messengerABI, err := cross_abi.L1CrossDomainMessengerMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts := DefaultContracts()
+	return &fakeL1{
+		t:         t,
+		chainID:   big.NewInt(1),
+		portal:    common.HexToAddress(contracts.L1.OptimismPortal),
+		oracle:    common.HexToAddress(contracts.L1.L2OutputOracle),
+		messenger: common.HexToAddress(contracts.L1.L1CrossDomainMessenger),
+		outputs: []cross_abi.TypesOutputProposal{{
+			OutputRoot:    l2.outputRoot(),
+			Timestamp:     big.NewInt(time.Now().Unix()),
+			L2BlockNumber: l2.header.Number,
+		}},
+		portalABI:    portalABI,
+		oracleABI:    oracleABI,
+		messengerABI: messengerABI,
+		proven:       make(map[common.Hash]provenWithdrawal),
+		finalized:    make(map[common.Hash]bool),
+		relayed:      make(map[common.Hash]RelayResult),
+		receipts:     make(map[common.Hash]*types.Receipt),
+	}
+}
+
+// advance moves every proof d into the past, as if d had passed since it was proven
+func (f *fakeL1) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for hash, p := range f.proven {
+		p.timestamp = p.timestamp.Add(-d)
+		f.proven[hash] = p
+	}
+}
+
+// call answers an eth_call to the portal, the oracle or the L1 messenger
+func (f *fakeL1) call(to common.Address, data []byte) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var contract *abi.ABI
+	switch to {
+	case f.portal:
+		contract = f.portalABI
+	case f.oracle:
+		contract = f.oracleABI
+	case f.messenger:
+		contract = f.messengerABI
+	default:
+		return nil, fmt.Errorf("fakeL1: no contract at %s", to.Hex())
+	}
+	method, err := contract.MethodById(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.callErrs[method.Name]; err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	switch method.Name {
+	case "proveWithdrawalTransaction", "finalizeWithdrawalTransaction":
+		if err := f.execute(method, data, false); err != nil {
+			return nil, fmt.Errorf("execution reverted: %w", err)
+		}
+		return nil, nil
+	case "paused", "optimisticMode":
+		return method.Outputs.Pack(false)
+	case "finalizedWithdrawals":
+		return method.Outputs.Pack(f.finalized[args[0].([32]byte)])
+	case "provenWithdrawals":
+		p, ok := f.proven[args[0].([32]byte)]
+		if !ok {
+			return method.Outputs.Pack([32]byte{}, new(big.Int), new(big.Int))
+		}
+		return method.Outputs.Pack(p.outputRoot, big.NewInt(p.timestamp.Unix()), p.outputIndex)
+	case "getL2OutputIndexAfter":
+		for i, output := range f.outputs {
+			if output.L2BlockNumber.Cmp(args[0].(*big.Int)) >= 0 {
+				return method.Outputs.Pack(big.NewInt(int64(i)))
+			}
+		}
+		return nil, errors.New("execution reverted: L2OutputOracle: cannot get output for a block that has not been proposed")
+	case "getL2Output":
+		index := args[0].(*big.Int)
+		if !index.IsUint64() || index.Uint64() >= uint64(len(f.outputs)) {
+			return nil, errors.New("execution reverted")
+		}
+		return method.Outputs.Pack(f.outputs[index.Uint64()])
+	case "latestBlockNumber":
+		return method.Outputs.Pack(f.outputs[len(f.outputs)-1].L2BlockNumber)
+	case "latestOutputIndex":
+		return method.Outputs.Pack(big.NewInt(int64(len(f.outputs) - 1)))
+	case "nextOutputIndex":
+		return method.Outputs.Pack(big.NewInt(int64(len(f.outputs))))
+	case "finalizationPeriodSeconds":
+		return method.Outputs.Pack(big.NewInt(int64(testPeriod / time.Second)))
+	case "successfulMessages":
+		return method.Outputs.Pack(f.relayed[args[0].([32]byte)] == RelaySucceeded)
+	case "failedMessages":
+		return method.Outputs.Pack(f.relayed[args[0].([32]byte)] == RelayReverted)
+	}
+	return nil, fmt.Errorf("fakeL1: unexpected call to %s", method.Name)
+}
+
+// execute applies a prove or finalize the way OptimismPortal would, returning the
+// revert reason where it would revert. With commit false, nothing is applied, as in an
+// eth_call. The caller holds f.mu.
+func (f *fakeL1) execute(method *abi.Method, data []byte, commit bool) error {
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return err
+	}
+	withdrawal := *abi.ConvertType(args[0], new(cross_abi.TypesWithdrawalTransaction)).(*cross_abi.TypesWithdrawalTransaction)
+	hash, err := ComputeWithdrawalHash(withdrawal)
+	if err != nil {
+		return err
+	}
+
+	switch method.Name {
+	case "proveWithdrawalTransaction":
+		index := args[1].(*big.Int)
+		if !index.IsUint64() || index.Uint64() >= uint64(len(f.outputs)) {
+			return errors.New("L2OutputOracle: output index out of range")
+		}
+		output := f.outputs[index.Uint64()]
+		proof := *abi.ConvertType(args[2], new(cross_abi.TypesOutputRootProof)).(*cross_abi.TypesOutputRootProof)
+		if computeOutputRoot(proof) != output.OutputRoot {
+			return errors.New("OptimismPortal: invalid output root proof")
+		}
+		if p, ok := f.proven[hash]; ok && p.outputRoot == output.OutputRoot {
+			return errors.New("OptimismPortal: withdrawal hash has already been proven")
+		}
+		value, err := helper.VerifyStorageProof(proof.MessagePasserStorageRoot, SentMessagesSlot(hash.Hex()), args[3].([][]byte))
+		if err != nil || len(value) != 1 || value[0] != 0x01 {
+			return errors.New("OptimismPortal: invalid withdrawal inclusion proof")
+		}
+		if commit {
+			f.proven[hash] = provenWithdrawal{outputRoot: output.OutputRoot, timestamp: time.Now(), outputIndex: index}
+		}
+	case "finalizeWithdrawalTransaction":
+		if f.finalized[hash] {
+			return errors.New("OptimismPortal: withdrawal has already been finalized")
+		}
+		p, ok := f.proven[hash]
+		if !ok {
+			return errors.New("OptimismPortal: withdrawal has not been proven yet")
+		}
+		if time.Since(p.timestamp) < testPeriod {
+			return errors.New("OptimismPortal: proven withdrawal finalization period has not elapsed")
+		}
+		if commit {
+			f.finalized[hash] = true
+		}
+	default:
+		return fmt.Errorf("unexpected %s", method.Name)
+	}
+	return nil
+}
+
+func (f *fakeL1) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	return f.call(*msg.To, msg.Data)
+}
+
+func (f *fakeL1) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	for i := range elems {
+		if elems[i].Method != "eth_call" {
+			elems[i].Error = fmt.Errorf("fakeL1: unexpected %s", elems[i].Method)
+			continue
+		}
+		msg := elems[i].Args[0].(map[string]interface{})
+		result, err := f.call(msg["to"].(common.Address), msg["data"].(hexutil.Bytes))
+		if err != nil {
+			elems[i].Error = err
+			continue
+		}
+		*elems[i].Result.(*hexutil.Bytes) = result
+	}
+	return nil
+}
+
+// EstimateGas fails like a node does when the call would revert
+func (f *fakeL1) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if _, err := f.call(*msg.To, msg.Data); err != nil {
+		return 0, err
+	}
+	return 200000, nil
+}
+
+func (f *fakeL1) ChainID(ctx context.Context) (*big.Int, error) { return f.chainID, nil }
+
+func (f *fakeL1) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1e9), nil
+}
+
+func (f *fakeL1) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(3e9), nil
+}
+
+func (f *fakeL1) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), BaseFee: big.NewInt(1e9)}, nil
+}
+
+func (f *fakeL1) BlockNumber(ctx context.Context) (uint64, error) { return 100, nil }
+
+func (f *fakeL1) BalanceAt(ctx context.Context, account common.Address, block *big.Int) (*big.Int, error) {
+	return big.NewInt(1e18), nil
+}
+
+func (f *fakeL1) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return uint64(len(f.sent)), nil
+}
+
+func (f *fakeL1) NonceAt(ctx context.Context, account common.Address, block *big.Int) (uint64, error) {
+	return f.PendingNonceAt(ctx, account)
+}
+
+// SendTransaction mines tx at once, after onSend, with a failed receipt where the portal
+// would revert
+func (f *fakeL1) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if f.onSend != nil {
+		f.onSend()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tx.To() == nil || *tx.To() != f.portal {
+		return fmt.Errorf("fakeL1: %s is not sent to the portal", tx.Hash().Hex())
+	}
+	method, err := f.portalABI.MethodById(tx.Data())
+	if err != nil {
+		return err
+	}
+	status := types.ReceiptStatusSuccessful
+	if err := f.execute(method, tx.Data(), true); err != nil {
+		f.t.Logf("fakeL1: %s reverted: %v", tx.Hash().Hex(), err)
+		status = types.ReceiptStatusFailed
+	}
+	f.sent = append(f.sent, tx)
+	f.receipts[tx.Hash()] = &types.Receipt{
+		Status:            status,
+		TxHash:            tx.Hash(),
+		BlockNumber:       big.NewInt(100),
+		GasUsed:           150000,
+		EffectiveGasPrice: big.NewInt(2e9),
+	}
+	return nil
+}
+
+func (f *fakeL1) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if receipt, ok := f.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeL1) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, tx := range f.sent {
+		if tx.Hash() == hash {
+			return tx, false, nil
+		}
+	}
+	return nil, false, ethereum.NotFound
+}
+
+// lastCall decodes the calldata of the last transaction sent to the portal
+func (f *fakeL1) lastCall(t *testing.T) (string, []interface{}) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.sent) == 0 {
+		t.Fatal("no transaction was sent")
+	}
+	data := f.sent[len(f.sent)-1].Data()
+	method, err := f.portalABI.MethodById(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return method.Name, args
+}
+
+// proveFinalizeMessenger returns a messenger over l1 and l2 signing with key
+func proveFinalizeMessenger(t testing.TB, l1 EthClient, l2 *fakeL2, key *ecdsa.PrivateKey) *CrossChainMessenger {
+	t.Helper()
+	m, err := NewCrossChainMessenger(MessengerConfig{
+		L1Client:          l1,
+		L2Client:          l2,
+		SkipStartupChecks: true,
+		Contracts:         DefaultContracts(),
+		Signer:            SignerConfig{PrivateKey: hexutil.Encode(crypto.FromECDSA(key))},
+		Logger:            NopLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestProveAndFinalize(t *testing.T) {
+	ctx := context.Background()
+	tx := testWithdrawal()
+	l2 := newFakeL2(t, tx)
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	txHash := l2.receipt.TxHash.Hex()
+
+	message, err := m.GetMessages(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != StatusReadyToProve {
+		t.Fatalf("status before proving = %d, want %d", message.Status, StatusReadyToProve)
+	}
+	if _, err := m.FinalizeMessage(ctx, txHash, 0, SubmitOptions{}); !errors.Is(err, ErrNotProven) {
+		t.Fatalf("FinalizeMessage() before proving = %v, want ErrNotProven", err)
+	}
+
+	proveTx, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proveTx != l1.sent[len(l1.sent)-1].Hash() {
+		t.Fatalf("ProveMessage() = %s, want the sent transaction", proveTx.Hex())
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(l1.chainID), l1.sent[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := crypto.PubkeyToAddress(key.PublicKey); sender != want {
+		t.Fatalf("prove sent by %s, want the signer %s", sender.Hex(), want.Hex())
+	}
+	name, args := l1.lastCall(t)
+	if name != "proveWithdrawalTransaction" {
+		t.Fatalf("prove called %s", name)
+	}
+	assertWithdrawal(t, args[0], tx)
+	if index := args[1].(*big.Int); index.Sign() != 0 {
+		t.Fatalf("proven against output %s, want 0", index)
+	}
+	rootProof := *abi.ConvertType(args[2], new(cross_abi.TypesOutputRootProof)).(*cross_abi.TypesOutputRootProof)
+	if rootProof.StateRoot != l2.header.Root || rootProof.MessagePasserStorageRoot != l2.storageRoot ||
+		rootProof.LatestBlockhash != l2.header.Hash() {
+		t.Fatalf("output root proof = %+v, not of L2 block %d", rootProof, l2.header.Number)
+	}
+
+	message, err = m.GetMessages(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != StatusProven {
+		t.Fatalf("status after proving = %d, want %d", message.Status, StatusProven)
+	}
+	if message.Provenance == nil || message.Provenance.OutputRoot != l1.outputs[0].OutputRoot {
+		t.Fatalf("provenance = %+v, want output root %s", message.Provenance, common.Hash(l1.outputs[0].OutputRoot).Hex())
+	}
+
+	sent := len(l1.sent)
+	if _, err := m.FinalizeMessage(ctx, txHash, 0, SubmitOptions{}); !errors.Is(err, ErrChallengePeriodActive) {
+		t.Fatalf("FinalizeMessage() in the challenge period = %v, want ErrChallengePeriodActive", err)
+	}
+	if len(l1.sent) != sent {
+		t.Fatal("FinalizeMessage() sent a transaction during the challenge period")
+	}
+
+	l1.advance(testPeriod)
+	if _, err := m.FinalizeMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	name, args = l1.lastCall(t)
+	if name != "finalizeWithdrawalTransaction" {
+		t.Fatalf("finalize called %s", name)
+	}
+	assertWithdrawal(t, args[0], tx)
+
+	message, err = m.GetMessages(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Status != StatusFinalized {
+		t.Fatalf("status after finalizing = %d, want %d", message.Status, StatusFinalized)
+	}
+	if _, err := m.FinalizeMessage(ctx, txHash, 0, SubmitOptions{}); !errors.Is(err, ErrAlreadyFinalized) {
+		t.Fatalf("FinalizeMessage() when finalized = %v, want ErrAlreadyFinalized", err)
+	}
+}
+
+// assertWithdrawal checks the withdrawal struct unpacked from portal calldata is want
+func assertWithdrawal(t *testing.T, arg interface{}, want cross_abi.TypesWithdrawalTransaction) {
+	t.Helper()
+	got := *abi.ConvertType(arg, new(cross_abi.TypesWithdrawalTransaction)).(*cross_abi.TypesWithdrawalTransaction)
+	gotHash, err := ComputeWithdrawalHash(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHash, err := ComputeWithdrawalHash(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotHash != wantHash {
+		t.Fatalf("withdrawal in calldata = %+v, want %+v", got, want)
+	}
+}
+
+func TestBatchProveAndFinalize(t *testing.T) {
+	ctx := context.Background()
+	l2 := newFakeL2(t, testWithdrawal())
+	l1 := newFakeL1(t, l2)
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := proveFinalizeMessenger(t, l1, l2, key)
+	txHashes := []string{l2.receipt.TxHash.Hex()}
+
+	var submitted []common.Hash
+	opts := BatchOptions{OnSubmitted: func(txHash string, l1TxHash common.Hash) {
+		if txHash != txHashes[0] {
+			t.Errorf("OnSubmitted(%s), want %s", txHash, txHashes[0])
+		}
+		submitted = append(submitted, l1TxHash)
+	}}
+	results, err := m.BatchProve(ctx, txHashes, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Err != nil || r.Skipped || r.Provenance == nil || r.Fee == nil {
+		t.Fatalf("BatchProve() = %+v, want proven by us", r)
+	}
+	if len(submitted) != 1 || submitted[0].Hex() != results[0].L1TxHash {
+		t.Fatalf("OnSubmitted got %v, want once with %s", submitted, results[0].L1TxHash)
+	}
+	if name, _ := l1.lastCall(t); name != "proveWithdrawalTransaction" {
+		t.Fatalf("BatchProve() called %s", name)
+	}
+
+	results, err = m.BatchProve(ctx, txHashes, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Skipped {
+		t.Fatalf("BatchProve() of a proven withdrawal = %+v, want skipped", results[0])
+	}
+
+	l1.advance(testPeriod)
+	results, err = m.BatchFinalize(ctx, txHashes, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.Err != nil || r.Skipped || r.L1TxHash == "" {
+		t.Fatalf("BatchFinalize() = %+v, want finalized by us", r)
+	}
+	if name, _ := l1.lastCall(t); name != "finalizeWithdrawalTransaction" {
+		t.Fatalf("BatchFinalize() called %s", name)
+	}
+	if status, err := m.GetMessageStatus(ctx, txHashes[0]); err != nil || status != StatusFinalized {
+		t.Fatalf("GetMessageStatus() after BatchFinalize = %d, %v, want %d", status, err, StatusFinalized)
+	}
+}
@@ -0,0 +1,192 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// proveTxSearchBlocks is how many L1 blocks either side of the block estimated from the
+// proven timestamp FindProveTx scans for the WithdrawalProven event
+const proveTxSearchBlocks = 300
+
+// ProofProvenance records which L2 output a withdrawal was proven against. If the
+// challenger deletes that output, the proof can no longer be finalized and the
+// withdrawal must be proven again.
+type ProofProvenance struct {
+	OutputIndex uint64      `json:"outputIndex"`
+	OutputRoot  common.Hash `json:"outputRoot"`
+	ProveTxHash common.Hash `json:"proveTxHash,omitempty"` // L1 proveWithdrawalTransaction; zero when not known
+	ProvenAt    time.Time   `json:"provenAt"`
+}
+
+// ProofCheck is the result of VerifyProof
+type ProofCheck struct {
+	TxHash         string
+	WithdrawalHash string
+	Provenance     ProofProvenance
+	CurrentRoot    common.Hash // Root the oracle now holds at Provenance.OutputIndex; zero if the output is gone
+	OutputDeleted  bool        // The proving output was deleted or replaced; the withdrawal must be re-proven
+}
+
+// VerifyProof re-reads provenWithdrawals for the withdrawal in txHash, fetches the output it
+// was proven against and reports whether the oracle still holds the same output root.
+// It returns ErrNotProven for a withdrawal that isn't proven and ErrAlreadyFinalized for
+// one whose proof no longer matters.
+func (m *CrossChainMessenger) VerifyProof(ctx context.Context, txHash string) (_ *ProofCheck, err error) {
+	ctx, span := startSpan(ctx, "VerifyProof", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	switch {
+	case message.Status >= StatusFinalized:
+		return nil, ErrAlreadyFinalized
+	case message.Provenance == nil:
+		return nil, ErrNotProven
+	}
+
+	check := &ProofCheck{
+		TxHash:         txHash,
+		WithdrawalHash: message.WithdrawalHash,
+		Provenance:     *message.Provenance,
+	}
+	check.CurrentRoot, check.OutputDeleted, err = m.checkProvenance(ctx, check.Provenance)
+	if err != nil {
+		return nil, err
+	}
+
+	proveTx, err := m.FindProveTx(ctx, message.WithdrawalHash, check.Provenance.ProvenAt)
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to find the prove transaction: %v", err)
+	}
+	check.Provenance.ProveTxHash = proveTx
+	return check, nil
+}
+
+// ProvenAt returns the timestamp OptimismPortal recorded for the withdrawal's proof,
+// which starts its challenge period; zero when the withdrawal isn't proven
+func (m *CrossChainMessenger) ProvenAt(ctx context.Context, withdrawalHash string) (_ time.Time, err error) {
+	ctx, span := startSpan(ctx, "ProvenAt", attrWithdrawalHash.String(withdrawalHash))
+	defer func() { endSpan(span, err) }()
+	isProven, timestamp, err := m.checkProvenStatus(ctx, withdrawalHash)
+	if err != nil || !isProven || timestamp == nil || timestamp.Sign() <= 0 {
+		return time.Time{}, err
+	}
+	return time.Unix(timestamp.Int64(), 0), nil
+}
+
+// provenTimestamp reads back the proven timestamp of a prove we just mined, falling back
+// to the local time when the portal can't be read or doesn't show the proof yet
+func (m *CrossChainMessenger) provenTimestamp(ctx context.Context, withdrawalHash string) time.Time {
+	provenAt, err := m.ProvenAt(ctx, withdrawalHash)
+	switch {
+	case err != nil:
+		m.logger().Warnf("⚠️  Failed to read back the proven timestamp: %v; counting the challenge period from now", err)
+	case provenAt.IsZero():
+		m.logger().Warnf("⚠️  OptimismPortal doesn't show the proof yet; counting the challenge period from now")
+	default:
+		return provenAt
+	}
+	return time.Now()
+}
+
+// checkProvenance reads the output at p.OutputIndex straight from the oracle, bypassing the
+// cache, and reports whether it was deleted or replaced since the withdrawal was proven
+func (m *CrossChainMessenger) checkProvenance(ctx context.Context, p ProofProvenance) (common.Hash, bool, error) {
+	oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+
+	// Deleting outputs shrinks the list, so an index past its end is gone
+	next, err := withRetry(ctx, m, "nextOutputIndex", func(ctx context.Context) (*big.Int, error) {
+		return oracle.NextOutputIndex(&bind.CallOpts{Context: ctx})
+	})
+	if err != nil {
+		return common.Hash{}, false, fmt.Errorf("failed to call nextOutputIndex: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	if p.OutputIndex >= next.Uint64() {
+		m.logger().Warnf("⚠️  Proving output #%d was deleted (next output index is %d)", p.OutputIndex, next.Uint64())
+		return common.Hash{}, true, nil
+	}
+
+	output, err := withRetry(ctx, m, "getL2Output", func(ctx context.Context) (cross_abi.TypesOutputProposal, error) {
+		return oracle.GetL2Output(&bind.CallOpts{Context: ctx}, new(big.Int).SetUint64(p.OutputIndex))
+	})
+	if err != nil {
+		return common.Hash{}, false, fmt.Errorf("failed to call getL2Output: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	current := common.Hash(output.OutputRoot)
+	if current != p.OutputRoot {
+		// Deleted and proposed again with a different root
+		m.logger().Warnf("⚠️  Proving output #%d was replaced: proven against %s, oracle now has %s",
+			p.OutputIndex, p.OutputRoot.Hex(), current.Hex())
+		return current, true, nil
+	}
+	return current, false, nil
+}
+
+// FindProveTx looks for the WithdrawalProven event of withdrawalHash in the L1 blocks
+// around provenAt and returns the transaction that emitted it. Re-proving emits the event
+// again, so the latest match is returned. It returns a zero hash if none is found.
+func (m *CrossChainMessenger) FindProveTx(ctx context.Context, withdrawalHash string, provenAt time.Time) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "FindProveTx", attrWithdrawalHash.String(withdrawalHash))
+	defer func() { endSpan(span, err) }()
+	proveTx, _, err := m.findProveEvent(ctx, withdrawalHash, provenAt)
+	return proveTx, err
+}
+
+// findProveEvent is FindProveTx also returning the L1 block of the transaction
+func (m *CrossChainMessenger) findProveEvent(ctx context.Context, withdrawalHash string, provenAt time.Time) (common.Hash, uint64, error) {
+	head, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return common.Hash{}, 0, fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+	estimate := l1BlockAt(head, provenAt)
+	fromBlock := uint64(0)
+	if estimate > proveTxSearchBlocks {
+		fromBlock = estimate - proveTxSearchBlocks
+	}
+	toBlock := min(estimate+proveTxSearchBlocks, head)
+
+	portal, err := cross_abi.NewOptimismPortalFilterer(common.HexToAddress(m.Contracts.L1.OptimismPortal), m.ClientL1)
+	if err != nil {
+		return common.Hash{}, 0, fmt.Errorf("failed to create OptimismPortal filterer: %w", err)
+	}
+	iter, err := withRetry(ctx, m, "L1 eth_getLogs", func(ctx context.Context) (*cross_abi.OptimismPortalWithdrawalProvenIterator, error) {
+		return portal.FilterWithdrawalProven(&bind.FilterOpts{Start: fromBlock, End: &toBlock, Context: ctx},
+			[][32]byte{common.HexToHash(withdrawalHash)}, nil, nil)
+	})
+	if err != nil {
+		return common.Hash{}, 0, fmt.Errorf("failed to filter WithdrawalProven events in blocks %d-%d: %w", fromBlock, toBlock, err)
+	}
+	defer iter.Close()
+
+	var proveTx common.Hash
+	var block uint64
+	for iter.Next() {
+		proveTx, block = iter.Event.Raw.TxHash, iter.Event.Raw.BlockNumber
+	}
+	if err := iter.Error(); err != nil {
+		return common.Hash{}, 0, fmt.Errorf("failed to read WithdrawalProven events in blocks %d-%d: %w", fromBlock, toBlock, err)
+	}
+	return proveTx, block, nil
+}
+
+// l1BlockAt estimates which L1 block was mined at t from the current head, assuming
+// every slot since has a block
+func l1BlockAt(head uint64, t time.Time) uint64 {
+	age := uint64(max(time.Since(t), 0) / L1BlockTime)
+	if age >= head {
+		return 0
+	}
+	return head - age
+}
@@ -0,0 +1,92 @@
+package crosschain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDeletedProvingOutputNeedsReprove(t *testing.T) {
+	ctx := context.Background()
+	// Output #1 is the first covering the withdrawal in block 7 and the one it is proven against
+	m, l1, l2 := laggingChains(t, []uint64{5, 10})
+	txHash := l2.receipt.TxHash.Hex()
+	if _, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	message, err := m.GetMessages(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Provenance == nil || message.Provenance.OutputIndex != 1 {
+		t.Fatalf("provenance = %+v, want output #1", message.Provenance)
+	}
+	provenRoot := message.Provenance.OutputRoot
+
+	// The challenger deletes output #1: the proven index is now past latestOutputIndex
+	l1.mu.Lock()
+	l1.outputs = l1.outputs[:1]
+	l1.mu.Unlock()
+
+	current, deleted, err := m.checkProvenance(ctx, *message.Provenance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted || current != (common.Hash{}) {
+		t.Fatalf("checkProvenance() = %s, %t; want the output reported deleted", current.Hex(), deleted)
+	}
+	rec, state, err := m.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != StatusProven || !state.NeedsReprove || state.OutputProposed {
+		t.Fatalf("state = %+v, want a proven withdrawal that must be re-proven once an output covers it", state)
+	}
+	if rec.Action != ActionWaitForOutput {
+		t.Fatalf("recommended %s, want %s until a new output covers the withdrawal", rec.Action, ActionWaitForOutput)
+	}
+
+	// A new output #1 is proposed with another root: the proof still points at the old one
+	l1.mu.Lock()
+	l1.outputs = append(l1.outputs, cross_abi.TypesOutputProposal{
+		OutputRoot:    l2.outputRootAt(12),
+		Timestamp:     big.NewInt(time.Now().Unix()),
+		L2BlockNumber: big.NewInt(12),
+	})
+	l1.mu.Unlock()
+
+	current, deleted, err = m.checkProvenance(ctx, *message.Provenance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted || current != l2.outputRootAt(12) {
+		t.Fatalf("checkProvenance() = %s, %t; want the output reported replaced by %s", current.Hex(), deleted, l2.outputRootAt(12).Hex())
+	}
+	rec, state, err = m.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.NeedsReprove || rec.Action != ActionReprove {
+		t.Fatalf("recommended %s (needs re-prove %t), want %s", rec.Action, state.NeedsReprove, ActionReprove)
+	}
+
+	// Proving again against the new output clears it
+	if _, err := m.ProveMessage(ctx, txHash, 0, SubmitOptions{}); err != nil {
+		t.Fatalf("re-prove: %v", err)
+	}
+	rec, state, err = m.RecommendNextAction(ctx, txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.NeedsReprove || rec.Action != ActionWaitChallenge {
+		t.Fatalf("after re-proving recommended %s (needs re-prove %t), want %s", rec.Action, state.NeedsReprove, ActionWaitChallenge)
+	}
+	if state.Provenance == nil || state.Provenance.OutputRoot == provenRoot || state.Provenance.OutputRoot != l2.outputRootAt(12) {
+		t.Fatalf("provenance after re-proving = %+v, want the new output's root", state.Provenance)
+	}
+}
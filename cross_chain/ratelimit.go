@@ -0,0 +1,214 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitQueueWarn is how long a request may wait for its turn before it is logged
+	rateLimitQueueWarn = time.Second
+
+	// rateLimitWarnEvery keeps a saturated endpoint from logging every request
+	rateLimitWarnEvery = time.Minute
+
+	// rateLimitWindow is the span Utilization is measured over
+	rateLimitWindow = 10 * time.Second
+)
+
+// RateLimit caps the requests sent to each RPC endpoint, so a burst of batch status
+// checks doesn't trip a provider's quota and fail everything at once
+type RateLimit struct {
+	RequestsPerSecond float64 // Sustained rate per endpoint (RPC_RATE_LIMIT); 0 means unlimited
+	Burst             int     // Requests allowed at once after an idle spell (RPC_RATE_BURST); 0 means the rate rounded up
+}
+
+// RateLimitStats is one RPC endpoint's limiter state, as exposed on /metrics
+type RateLimitStats struct {
+	Client            string  `json:"client"`   // "L1", "L2" or "L2 archive"
+	Endpoint          string  `json:"endpoint"` // Scheme and host only; paths often carry API keys
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+	Requests          uint64  `json:"requests"`    // Sent since startup; a batch counts each element
+	Delayed           uint64  `json:"delayed"`     // Requests that had to wait for their turn
+	Queued            int     `json:"queued"`      // Requests waiting right now
+	Utilization       float64 `json:"utilization"` // Share of RequestsPerSecond used over the last 10s; 0 when unlimited
+}
+
+// rateLimitFromEnv reads RPC_RATE_LIMIT and RPC_RATE_BURST
+func rateLimitFromEnv() (RateLimit, error) {
+	var limit RateLimit
+	if v := os.Getenv("RPC_RATE_LIMIT"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil || rate < 0 || math.IsInf(rate, 0) {
+			return limit, fmt.Errorf("invalid RPC_RATE_LIMIT %q: must be a non-negative number of requests per second", v)
+		}
+		limit.RequestsPerSecond = rate
+	}
+	burst, err := uint64FromEnv("RPC_RATE_BURST", 0)
+	if err != nil {
+		return limit, err
+	}
+	limit.Burst = int(burst)
+	return limit, nil
+}
+
+// rateLimiter is a token bucket for one endpoint. Requests reserve their slot under the
+// lock in arrival order and then sleep until it comes, so under contention they go out
+// first come, first served rather than in whatever order a retry loop wins.
+type rateLimiter struct {
+	client   string
+	endpoint string
+	limit    RateLimit
+	logger   Logger
+
+	mu         sync.Mutex
+	tokens     float64   // May go negative: requests already promised future slots
+	last       time.Time // When tokens was last topped up
+	requests   uint64
+	delayed    uint64
+	queued     int
+	window     []time.Time // Request times within rateLimitWindow, for Utilization
+	lastWarned time.Time
+}
+
+func newRateLimiter(client, endpoint string, limit RateLimit, logger Logger) *rateLimiter {
+	if limit.RequestsPerSecond > 0 && limit.Burst <= 0 {
+		limit.Burst = int(math.Ceil(limit.RequestsPerSecond))
+	}
+	return &rateLimiter{client: client, endpoint: endpoint, limit: limit, logger: logger, tokens: float64(limit.Burst), last: time.Now()}
+}
+
+// wait blocks until n requests for method may be sent, or ctx is done. An unlimited
+// limiter only counts them.
+func (l *rateLimiter) wait(ctx context.Context, method string, n int) error {
+	now := time.Now()
+	l.mu.Lock()
+	l.requests += uint64(n)
+	if l.limit.RequestsPerSecond <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	for i := 0; i < n; i++ {
+		l.window = append(l.window, now)
+	}
+	l.trimWindow(now)
+
+	l.tokens = min(float64(l.limit.Burst), l.tokens+now.Sub(l.last).Seconds()*l.limit.RequestsPerSecond)
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-l.tokens / l.limit.RequestsPerSecond * float64(time.Second))
+	l.delayed += uint64(n)
+	l.queued++
+	warn := delay > rateLimitQueueWarn && now.Sub(l.lastWarned) > rateLimitWarnEvery
+	if warn {
+		l.lastWarned = now
+	}
+	l.mu.Unlock()
+
+	if warn {
+		l.logger.Warnf("🐢 %s %s queued for %s by the %s rate limit (%g req/s); raise RPC_RATE_LIMIT if the provider allows more",
+			l.client, method, delay.Round(100*time.Millisecond), l.endpoint, l.limit.RequestsPerSecond)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		// The slot isn't handed back: later requests were promised theirs after it,
+		// and a newcomer taking it would jump the queue
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// trimWindow drops request times older than rateLimitWindow; mu must be held
+func (l *rateLimiter) trimWindow(now time.Time) {
+	i := sort.Search(len(l.window), func(i int) bool { return now.Sub(l.window[i]) < rateLimitWindow })
+	l.window = l.window[i:]
+}
+
+func (l *rateLimiter) stats() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := RateLimitStats{
+		Client:            l.client,
+		Endpoint:          l.endpoint,
+		RequestsPerSecond: l.limit.RequestsPerSecond,
+		Burst:             l.limit.Burst,
+		Requests:          l.requests,
+		Delayed:           l.delayed,
+		Queued:            l.queued,
+	}
+	if l.limit.RequestsPerSecond > 0 {
+		l.trimWindow(time.Now())
+		s.Utilization = float64(len(l.window)) / (rateLimitWindow.Seconds() * l.limit.RequestsPerSecond)
+	}
+	return s
+}
+
+// rateLimiters hands out one limiter per endpoint URL, so clients sharing an endpoint,
+// e.g. L2_RPC repeated in L2_ARCHIVE_RPC, share its quota
+type rateLimiters struct {
+	limit  RateLimit
+	logger Logger
+
+	mu       sync.Mutex
+	byURL    map[string]*rateLimiter
+	limiters []*rateLimiter // In creation order, for stable stats
+}
+
+func newRateLimiters(limit RateLimit, logger Logger) *rateLimiters {
+	if logger == nil {
+		logger = NopLogger()
+	}
+	return &rateLimiters{limit: limit, logger: logger, byURL: map[string]*rateLimiter{}}
+}
+
+func (r *rateLimiters) forURL(client, url string) *rateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.byURL[url]; ok {
+		return l
+	}
+	l := newRateLimiter(client, redactURL(url), r.limit, r.logger)
+	r.byURL[url] = l
+	r.limiters = append(r.limiters, l)
+	return l
+}
+
+func (r *rateLimiters) stats() []RateLimitStats {
+	r.mu.Lock()
+	limiters := append([]*rateLimiter(nil), r.limiters...)
+	r.mu.Unlock()
+	stats := make([]RateLimitStats, len(limiters))
+	for i, l := range limiters {
+		stats[i] = l.stats()
+	}
+	return stats
+}
+
+// RateLimitStats returns the limiter state of every RPC endpoint the messenger dialed;
+// clients passed in through MessengerConfig aren't limited and don't show up
+func (m *CrossChainMessenger) RateLimitStats() []RateLimitStats {
+	if m.rateLimiters == nil {
+		return nil
+	}
+	return m.rateLimiters.stats()
+}
@@ -0,0 +1,158 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// queue starts a wait on l in a goroutine and returns once it has taken its place in
+// line, so the waits queued by successive calls arrive in call order
+func queue(t *testing.T, l *rateLimiter, ctx context.Context, done func(error)) {
+	t.Helper()
+	queued := l.stats().Queued
+	go func() { done(l.wait(ctx, "eth_call", 1)) }()
+	deadline := time.Now().Add(time.Second)
+	for l.stats().Queued == queued {
+		if time.Now().After(deadline) {
+			t.Fatal("request never queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRateLimiterFairness(t *testing.T) {
+	l := newRateLimiter("L1", "https://rpc.example", RateLimit{RequestsPerSecond: 50, Burst: 1}, NopLogger())
+	if err := l.wait(context.Background(), "eth_call", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	const waiters = 8
+	finished := make(chan int, waiters)
+	for i := 0; i < waiters; i++ {
+		i := i
+		queue(t, l, context.Background(), func(err error) {
+			if err != nil {
+				t.Error(err)
+			}
+			finished <- i
+		})
+	}
+	var order []int
+	for range waiters {
+		order = append(order, <-finished)
+	}
+	want := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("requests went out in order %v, want the order they arrived in", order)
+	}
+
+	stats := l.stats()
+	if stats.Requests != waiters+1 || stats.Delayed != waiters || stats.Queued != 0 {
+		t.Fatalf("stats = %+v, want %d requests, %d delayed, none queued", stats, waiters+1, waiters)
+	}
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := newRateLimiter("L2", "https://rpc.example", RateLimit{RequestsPerSecond: 10, Burst: 3}, NopLogger())
+	start := time.Now()
+	if err := l.wait(context.Background(), "eth_getProof", 3); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("a burst of 3 waited %s", elapsed)
+	}
+	if err := l.wait(context.Background(), "eth_call", 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("the request after the burst went out after %s, want about 100ms", elapsed)
+	}
+	stats := l.stats()
+	if stats.Requests != 4 || stats.Delayed != 1 {
+		t.Fatalf("stats = %+v, want 4 requests, 1 delayed", stats)
+	}
+	if stats.Utilization < 0.039 || stats.Utilization > 0.041 {
+		t.Fatalf("utilization = %g, want 4 requests of 100 in the window", stats.Utilization)
+	}
+}
+
+func TestRateLimiterDefaultBurst(t *testing.T) {
+	if l := newRateLimiter("L1", "", RateLimit{RequestsPerSecond: 2.5}, NopLogger()); l.limit.Burst != 3 {
+		t.Fatalf("burst = %d, want the rate rounded up", l.limit.Burst)
+	}
+	unlimited := newRateLimiter("L1", "", RateLimit{}, NopLogger())
+	for range 100 {
+		if err := unlimited.wait(context.Background(), "eth_call", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stats := unlimited.stats(); stats.Requests != 100 || stats.Delayed != 0 || stats.Utilization != 0 {
+		t.Fatalf("unlimited stats = %+v, want 100 requests counted and nothing else", stats)
+	}
+}
+
+// A canceled request keeps its slot, so the requests behind it keep their place
+func TestRateLimiterCanceledWait(t *testing.T) {
+	l := newRateLimiter("L1", "https://rpc.example", RateLimit{RequestsPerSecond: 20, Burst: 1}, NopLogger())
+	if err := l.wait(context.Background(), "eth_call", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	queue(t, l, ctx, func(err error) { canceled <- err })
+	finished := make(chan time.Duration, 1)
+	start := time.Now()
+	queue(t, l, context.Background(), func(err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		finished <- time.Since(start)
+	})
+
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled wait = %v, want context.Canceled", err)
+	}
+	if elapsed := <-finished; elapsed < 80*time.Millisecond {
+		t.Fatalf("the request behind a canceled one went out after %s, want its own slot 100ms out", elapsed)
+	}
+}
+
+func TestRateLimitersShareEndpoint(t *testing.T) {
+	r := newRateLimiters(RateLimit{RequestsPerSecond: 25}, nil)
+	l2 := r.forURL("L2", "https://rpc.example/v1/secret-key")
+	archive := r.forURL("L2 archive", "https://rpc.example/v1/secret-key")
+	l1 := r.forURL("L1", "https://l1.example")
+	if l2 != archive || l1 == l2 {
+		t.Fatal("limiters aren't shared by URL")
+	}
+	stats := r.stats()
+	if len(stats) != 2 || stats[0].Client != "L2" || stats[1].Client != "L1" {
+		t.Fatalf("stats = %+v, want L2 then L1", stats)
+	}
+	if stats[0].Endpoint != "https://rpc.example" {
+		t.Fatalf("endpoint = %s, want the API key redacted", stats[0].Endpoint)
+	}
+}
+
+func TestRateLimitFromEnv(t *testing.T) {
+	t.Setenv("RPC_RATE_LIMIT", "12.5")
+	t.Setenv("RPC_RATE_BURST", "20")
+	limit, err := rateLimitFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit != (RateLimit{RequestsPerSecond: 12.5, Burst: 20}) {
+		t.Fatalf("rateLimitFromEnv() = %+v", limit)
+	}
+	for _, v := range []string{"-1", "fast", "+Inf"} {
+		t.Setenv("RPC_RATE_LIMIT", v)
+		if _, err := rateLimitFromEnv(); err == nil {
+			t.Fatalf("RPC_RATE_LIMIT=%s was accepted", v)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ChallengePeriod is the usual time a proven withdrawal must wait before it can be
+// finalized. The oracle's finalizationPeriodSeconds (see FinalizationParams) takes
+// precedence; this is only assumed when it can't be read.
+const ChallengePeriod = 12 * time.Hour
+
+// Message status values as returned by getMessageStatus
+const (
+	StatusReadyToProve = 0
+	StatusProven       = 1
+	StatusFinalized    = 2
+	StatusRelayFailed  = 3 // Finalized at the portal, but the message it relayed reverted on L1
+
+	// StatusUnknown is reported by GetStatusByWithdrawalHash when the portal has no
+	// record of the withdrawal: not proven yet, or not a withdrawal hash at all
+	StatusUnknown = -1
+)
+
+// FinalizedAtPortal reports whether OptimismPortal has finalized a withdrawal with
+// status, whether or not the message it relayed succeeded
+func FinalizedAtPortal(status int) bool {
+	return status == StatusFinalized || status == StatusRelayFailed
+}
+
+// Action is the next step recommended for a withdrawal
+type Action string
+
+const (
+	ActionNone                 Action = "none"
+	ActionWaitForConfirmations Action = "wait-for-confirmations"
+	ActionWaitForOutput        Action = "wait-for-output"
+	ActionProve                Action = "prove"
+	ActionReprove              Action = "re-prove"
+	ActionWaitChallenge        Action = "wait-for-challenge-period"
+	ActionFinalize             Action = "finalize"
+	ActionReplay               Action = "replay"
+	ActionWaitForUnpause       Action = "wait-for-unpause"
+	ActionFundWallet           Action = "fund-wallet"
+	ActionReviewCost           Action = "review-cost"
+)
+
+// WithdrawalState is everything the decision table needs to know about a withdrawal
+type WithdrawalState struct {
+	Status              int                // StatusReadyToProve, StatusProven, StatusFinalized or StatusRelayFailed
+	OutputProposed      bool               // An L2 output covering the withdrawal block exists
+	ChallengePassed     bool               // Proven and the challenge period has elapsed
+	PortalPaused        bool               // OptimismPortal is paused by the guardian
+	RelayFailed         bool               // Finalized at the portal but the relayed message failed
+	NeedsReprove        bool               // The output used for proving was deleted
+	InsufficientBalance bool               // Signing wallet can't pay for the next transaction
+	OverBudget          bool               // Finalizing costs more than MAX_FINALIZE_COST_USD or MIN_VALUE_RATIO allow
+	LatestProposedBlock uint64             // Latest L2 block covered by an output
+	ProvenAt            time.Time          // Zero unless proven
+	FinalizeAt          time.Time          // Zero unless proven
+	Finalization        FinalizationParams // Oracle finalization period and mode FinalizeAt was computed with
+	Provenance          *ProofProvenance   // Output the withdrawal was proven against; nil unless proven
+	ConfirmationsNeeded uint64             // More L2 blocks needed before the withdrawal is trusted (L2_CONFIRMATIONS); 0 once it is
+}
+
+// Recommendation is the result of running a WithdrawalState through the decision table
+type Recommendation struct {
+	Action    Action
+	Reason    string
+	Command   string // Exact command line to run, empty when nothing is to be done
+	Automatic bool   // The scheduler performs this action on its own
+}
+
+// recommendationRule is one row of the decision table; the first matching row wins
+type recommendationRule struct {
+	match     func(s WithdrawalState) bool
+	action    Action
+	reason    string
+	command   string // Format string taking the tx hash
+	automatic bool
+}
+
+// canSubmit reports whether nothing blocks sending a transaction
+func canSubmit(s WithdrawalState) bool {
+	return !s.PortalPaused && !s.InsufficientBalance
+}
+
+// needsSubmission reports whether the next step is a prove or finalize transaction
+func needsSubmission(s WithdrawalState) bool {
+	switch s.Status {
+	case StatusReadyToProve:
+		return s.OutputProposed
+	case StatusProven:
+		if s.NeedsReprove {
+			return s.OutputProposed
+		}
+		return s.ChallengePassed
+	}
+	return false
+}
+
+var recommendationTable = []recommendationRule{
+	{
+		match:  func(s WithdrawalState) bool { return FinalizedAtPortal(s.Status) && s.RelayFailed },
+		action: ActionReplay,
+		reason: "finalized at the portal but the relayed message reverted on L1, so the funds are held by " +
+			"L1CrossDomainMessenger; replay it once the target can accept it",
+		command: "bridge-status replay %s",
+	},
+	{
+		match:  func(s WithdrawalState) bool { return s.Status == StatusFinalized },
+		action: ActionNone,
+		reason: "withdrawal is finalized, nothing left to do",
+	},
+	{
+		match:     func(s WithdrawalState) bool { return s.Status == StatusReadyToProve && s.ConfirmationsNeeded > 0 },
+		action:    ActionWaitForConfirmations,
+		reason:    "the withdrawal's L2 block doesn't have enough confirmations yet",
+		command:   "bridge-status check %s",
+		automatic: true,
+	},
+	{
+		match:     func(s WithdrawalState) bool { return s.Status == StatusReadyToProve && !s.OutputProposed },
+		action:    ActionWaitForOutput,
+		reason:    "no L2 output covering the withdrawal block has been proposed yet",
+		command:   "bridge-status check %s",
+		automatic: true,
+	},
+	{
+		match:     func(s WithdrawalState) bool { return s.Status == StatusProven && s.NeedsReprove && !s.OutputProposed },
+		action:    ActionWaitForOutput,
+		reason:    "the output used to prove this withdrawal was deleted and no new output covers it yet",
+		command:   "bridge-status check %s",
+		automatic: true,
+	},
+	{
+		match:     func(s WithdrawalState) bool { return s.Status == StatusProven && !s.NeedsReprove && !s.ChallengePassed },
+		action:    ActionWaitChallenge,
+		reason:    "proven; waiting for the challenge period to pass",
+		command:   "bridge-status check %s",
+		automatic: true,
+	},
+	{
+		match:     func(s WithdrawalState) bool { return needsSubmission(s) && s.PortalPaused },
+		action:    ActionWaitForUnpause,
+		reason:    "OptimismPortal is paused by the guardian; submissions would revert",
+		command:   "bridge-status check %s",
+		automatic: true,
+	},
+	{
+		match:   func(s WithdrawalState) bool { return needsSubmission(s) && s.InsufficientBalance },
+		action:  ActionFundWallet,
+		reason:  "signing wallet does not have enough L1 ETH for the next transaction; top it up",
+		command: "bridge-status check %s",
+	},
+	{
+		match: func(s WithdrawalState) bool {
+			return s.Status == StatusProven && !s.NeedsReprove && s.ChallengePassed && s.OverBudget
+		},
+		action: ActionReviewCost,
+		reason: "estimated L1 cost of finalizing exceeds MAX_FINALIZE_COST_USD or MIN_VALUE_RATIO; " +
+			"the command below prints the estimate, add --force if it is worth it",
+		command: "bridge-status finalize %s",
+	},
+	{
+		match:     func(s WithdrawalState) bool { return s.Status == StatusProven && s.NeedsReprove && canSubmit(s) },
+		action:    ActionReprove,
+		reason:    "the output used to prove this withdrawal was deleted; prove it again",
+		command:   "bridge-status prove %s",
+		automatic: true,
+	},
+	{
+		match: func(s WithdrawalState) bool {
+			return s.Status == StatusReadyToProve && s.OutputProposed && canSubmit(s)
+		},
+		action:    ActionProve,
+		reason:    "an L2 output covering the withdrawal exists; ready to prove",
+		command:   "bridge-status prove %s",
+		automatic: true,
+	},
+	{
+		match: func(s WithdrawalState) bool {
+			return s.Status == StatusProven && s.ChallengePassed && canSubmit(s) && !s.OverBudget
+		},
+		action:    ActionFinalize,
+		reason:    "challenge period has passed; ready to finalize",
+		command:   "bridge-status finalize %s",
+		automatic: true,
+	},
+}
+
+// Recommend maps a withdrawal state to the next action. The scheduler drives its
+// automation from the same table, so the CLI recommendation never contradicts it.
+func Recommend(state WithdrawalState, txHash string) Recommendation {
+	for _, rule := range recommendationTable {
+		if !rule.match(state) {
+			continue
+		}
+		rec := Recommendation{Action: rule.action, Reason: rule.reason, Automatic: rule.automatic}
+		if rule.command != "" {
+			rec.Command = fmt.Sprintf(rule.command, txHash)
+		}
+		return rec
+	}
+	return Recommendation{
+		Action: ActionNone,
+		Reason: fmt.Sprintf("unknown status %d (%s)", state.Status, StatusDescription(state.Status)),
+	}
+}
+
+// GetLatestProposedL2Block returns the latest L2 block covered by an output on the L2OutputOracle
+func (m *CrossChainMessenger) GetLatestProposedL2Block(ctx context.Context) (uint64, error) {
+	l2Oracle, err := m.l2OutputOracle(m.Contracts.L1.L2OutputOracle)
+	if err != nil {
+		return 0, err
+	}
+	latest, err := withRetry(ctx, m, "latestBlockNumber", func(ctx context.Context) (*big.Int, error) {
+		return l2Oracle.LatestBlockNumber(&bind.CallOpts{Context: ctx})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to call latestBlockNumber: %w", wrapContractError(ContractL2OutputOracle, err))
+	}
+	return latest.Uint64(), nil
+}
+
+// BuildWithdrawalState fills in the decision table inputs that can be read from chain
+// for a message. Conditions that depend on the signer or local policy (balance, budget)
+// are left for the caller to set.
+func (m *CrossChainMessenger) BuildWithdrawalState(ctx context.Context, message Message, latestProposedBlock uint64) (_ WithdrawalState, err error) {
+	ctx, span := startSpan(ctx, "BuildWithdrawalState", attrTxHash.String(message.TxHash), attrWithdrawalHash.String(message.WithdrawalHash))
+	defer func() { endSpan(span, err) }()
+	state := WithdrawalState{
+		Status:              message.Status,
+		RelayFailed:         message.Status == StatusRelayFailed,
+		OutputProposed:      latestProposedBlock >= message.BlockNumber,
+		LatestProposedBlock: latestProposedBlock,
+		Finalization:        m.FinalizationParams(ctx),
+	}
+
+	if message.Status == StatusProven {
+		isProven, provenTimestamp, err := m.checkProvenStatus(ctx, message.WithdrawalHash)
+		if err != nil {
+			return state, fmt.Errorf("failed to check proven status: %w", err)
+		}
+		if isProven && provenTimestamp != nil && provenTimestamp.Cmp(big.NewInt(0)) > 0 {
+			state.ProvenAt = time.Unix(provenTimestamp.Int64(), 0)
+			state.FinalizeAt = state.ProvenAt.Add(state.Finalization.Period)
+			state.ChallengePassed = !time.Now().Before(state.FinalizeAt)
+		}
+
+		// A proof against a deleted output can never be finalized
+		if message.Provenance != nil {
+			state.Provenance = message.Provenance
+			_, deleted, err := m.checkProvenance(ctx, *message.Provenance)
+			if err != nil {
+				return state, fmt.Errorf("failed to check the proving output: %w", err)
+			}
+			if deleted {
+				state.NeedsReprove = true
+				// Cached lookups may still point at the deleted output
+				m.ClearCache()
+			}
+		}
+	}
+
+	if !FinalizedAtPortal(message.Status) {
+		paused, err := m.PortalPaused(ctx)
+		if err != nil {
+			return state, err
+		}
+		state.PortalPaused = paused
+	}
+
+	return state, nil
+}
+
+// RecommendNextAction loads a withdrawal's state from chain and runs it through the decision table
+func (m *CrossChainMessenger) RecommendNextAction(ctx context.Context, txHash string) (_ Recommendation, _ WithdrawalState, err error) {
+	ctx, span := startSpan(ctx, "RecommendNextAction", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
+	message, err := m.getMessages(ctx, txHash)
+	var confErr *ConfirmationsError
+	if errors.As(err, &confErr) {
+		state := UnconfirmedState(confErr)
+		state.Finalization = m.FinalizationParams(ctx)
+		return Recommend(state, txHash), state, nil
+	}
+	if err != nil {
+		return Recommendation{}, WithdrawalState{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+	latest, err := m.GetLatestProposedL2Block(ctx)
+	if err != nil {
+		return Recommendation{}, WithdrawalState{}, err
+	}
+	state, err := m.BuildWithdrawalState(ctx, message, latest)
+	if err != nil {
+		return Recommendation{}, state, err
+	}
+	if state.Status == StatusProven && state.ChallengePassed {
+		if _, err := m.CheckFinalizeCost(ctx, message, common.Address{}); errors.Is(err, ErrCostTooHigh) {
+			state.OverBudget = true
+		} else if err != nil {
+			m.logger().Warnf("⚠️  %v", err)
+		}
+	}
+	if needsSubmission(state) && canSubmit(state) && m.HasSigner() {
+		if err := m.checkSubmissionFunds(ctx, message, state); errors.Is(err, ErrInsufficientFunds) {
+			m.logger().Warnf("💸 %v", err)
+			state.InsufficientBalance = true
+		} else if err != nil {
+			m.logger().Warnf("⚠️  %v", err)
+		}
+	}
+	return Recommend(state, txHash), state, nil
+}
+
+// checkSubmissionFunds prices the prove or finalize transaction state calls for the way
+// it would be sent, and fails with an InsufficientFundsError when the default signer
+// can't pay for it. A prove is priced with a freshly generated proof.
+func (m *CrossChainMessenger) checkSubmissionFunds(ctx context.Context, message Message, state WithdrawalState) error {
+	var calldata []byte
+	if state.Status == StatusReadyToProve || state.NeedsReprove {
+		call, err := m.buildProveCall(ctx, message)
+		if err != nil {
+			return err
+		}
+		calldata, err = packOptimismPortalCall("proveWithdrawalTransaction", call.withdrawalTx,
+			new(big.Int).SetUint64(call.outputIndex), call.outputRootProof, call.withdrawalProof)
+		if err != nil {
+			return err
+		}
+	} else {
+		withdrawalTx, err := withdrawalTransaction(message)
+		if err != nil {
+			return err
+		}
+		if calldata, err = packOptimismPortalCall("finalizeWithdrawalTransaction", withdrawalTx); err != nil {
+			return err
+		}
+	}
+	opts, err := m.getTransactOpts(ctx, common.Address{})
+	if err != nil {
+		return fmt.Errorf("failed to get transaction options: %w", err)
+	}
+	return m.applyGasSettings(ctx, opts, common.HexToAddress(m.Contracts.L1.OptimismPortal), calldata)
+}
@@ -0,0 +1,66 @@
+package crosschain
+
+import "testing"
+
+func TestRecommend(t *testing.T) {
+	const txHash = "0xabc"
+	readyToProve := WithdrawalState{Status: StatusReadyToProve, OutputProposed: true}
+	readyToFinalize := WithdrawalState{Status: StatusProven, OutputProposed: true, ChallengePassed: true}
+	needsReprove := WithdrawalState{Status: StatusProven, OutputProposed: true, NeedsReprove: true}
+
+	with := func(s WithdrawalState, set func(*WithdrawalState)) WithdrawalState {
+		set(&s)
+		return s
+	}
+
+	tests := []struct {
+		name      string
+		state     WithdrawalState
+		action    Action
+		command   string
+		automatic bool
+	}{
+		{"finalized", WithdrawalState{Status: StatusFinalized}, ActionNone, "", false},
+		{"finalized while paused", WithdrawalState{Status: StatusFinalized, PortalPaused: true}, ActionNone, "", false},
+		{"relay failed", WithdrawalState{Status: StatusRelayFailed, RelayFailed: true}, ActionReplay, "bridge-status replay 0xabc", false},
+		{"unconfirmed", WithdrawalState{Status: StatusReadyToProve, ConfirmationsNeeded: 3}, ActionWaitForConfirmations, "bridge-status check 0xabc", true},
+		{"no output", WithdrawalState{Status: StatusReadyToProve}, ActionWaitForOutput, "bridge-status check 0xabc", true},
+		{"ready to prove", readyToProve, ActionProve, "bridge-status prove 0xabc", true},
+		{"prove while paused", with(readyToProve, func(s *WithdrawalState) { s.PortalPaused = true }), ActionWaitForUnpause, "bridge-status check 0xabc", true},
+		{"prove without funds", with(readyToProve, func(s *WithdrawalState) { s.InsufficientBalance = true }), ActionFundWallet, "bridge-status check 0xabc", false},
+		{"prove paused and without funds", with(readyToProve, func(s *WithdrawalState) { s.PortalPaused = true; s.InsufficientBalance = true }), ActionWaitForUnpause, "bridge-status check 0xabc", true},
+		{"challenge period", WithdrawalState{Status: StatusProven, OutputProposed: true}, ActionWaitChallenge, "bridge-status check 0xabc", true},
+		{"challenge period while paused", WithdrawalState{Status: StatusProven, PortalPaused: true}, ActionWaitChallenge, "bridge-status check 0xabc", true},
+		{"ready to finalize", readyToFinalize, ActionFinalize, "bridge-status finalize 0xabc", true},
+		{"finalize while paused", with(readyToFinalize, func(s *WithdrawalState) { s.PortalPaused = true }), ActionWaitForUnpause, "bridge-status check 0xabc", true},
+		{"finalize without funds", with(readyToFinalize, func(s *WithdrawalState) { s.InsufficientBalance = true }), ActionFundWallet, "bridge-status check 0xabc", false},
+		{"finalize over budget", with(readyToFinalize, func(s *WithdrawalState) { s.OverBudget = true }), ActionReviewCost, "bridge-status finalize 0xabc", false},
+		{"finalize without funds and over budget", with(readyToFinalize, func(s *WithdrawalState) { s.InsufficientBalance = true; s.OverBudget = true }), ActionFundWallet, "bridge-status check 0xabc", false},
+		{"re-prove without output", with(needsReprove, func(s *WithdrawalState) { s.OutputProposed = false }), ActionWaitForOutput, "bridge-status check 0xabc", true},
+		{"re-prove", needsReprove, ActionReprove, "bridge-status prove 0xabc", true},
+		{"re-prove after the challenge period", with(needsReprove, func(s *WithdrawalState) { s.ChallengePassed = true }), ActionReprove, "bridge-status prove 0xabc", true},
+		{"re-prove while paused", with(needsReprove, func(s *WithdrawalState) { s.PortalPaused = true }), ActionWaitForUnpause, "bridge-status check 0xabc", true},
+		{"re-prove without funds", with(needsReprove, func(s *WithdrawalState) { s.InsufficientBalance = true }), ActionFundWallet, "bridge-status check 0xabc", false},
+		{"unknown status", WithdrawalState{Status: 7}, ActionNone, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Recommend(tt.state, txHash)
+			if rec.Action != tt.action || rec.Command != tt.command || rec.Automatic != tt.automatic {
+				t.Fatalf("Recommend() = %s %q automatic=%t, want %s %q automatic=%t",
+					rec.Action, rec.Command, rec.Automatic, tt.action, tt.command, tt.automatic)
+			}
+			if rec.Reason == "" {
+				t.Fatal("Recommend() gave no reason")
+			}
+		})
+	}
+}
+
+// OverBudget only concerns finalizing, so it must not stop a prove
+func TestRecommendOverBudgetProve(t *testing.T) {
+	state := WithdrawalState{Status: StatusReadyToProve, OutputProposed: true, OverBudget: true}
+	if rec := Recommend(state, "0xabc"); rec.Action != ActionProve {
+		t.Fatalf("Recommend() of an over-budget prove = %s, want %s", rec.Action, ActionProve)
+	}
+}
@@ -0,0 +1,151 @@
+package crosschain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// remoteSignRequest is the transaction object eth_signTransaction takes
+type remoteSignRequest struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`             // Legacy transactions only
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`         // EIP-1559 transactions only
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"` // EIP-1559 transactions only
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+	Type                 hexutil.Uint64  `json:"type"`
+}
+
+// remoteSigner returns the JSON-RPC client for the signer at url, dialing it on first use
+func (m *CrossChainMessenger) remoteSigner(ctx context.Context, url string) (*rpc.Client, error) {
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	if client, ok := m.remoteSigners[url]; ok {
+		return client, nil
+	}
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote signer %s: %w", redactURL(url), err)
+	}
+	if m.remoteSigners == nil {
+		m.remoteSigners = make(map[string]*rpc.Client)
+	}
+	m.remoteSigners[url] = client
+	return client, nil
+}
+
+// getRemoteTransactOpts gets transaction options that sign through a remote signer's
+// eth_signTransaction (web3signer, clef). Without cfg.RemoteAddress the signer must hold
+// exactly one account.
+func (m *CrossChainMessenger) getRemoteTransactOpts(ctx context.Context, cfg SignerConfig) (*bind.TransactOpts, error) {
+	client, err := m.remoteSigner(ctx, cfg.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var from common.Address
+	if cfg.RemoteAddress != "" {
+		from = common.HexToAddress(cfg.RemoteAddress)
+	} else {
+		var accounts []common.Address
+		if err := client.CallContext(ctx, &accounts, "eth_accounts"); err != nil {
+			return nil, fmt.Errorf("failed to list remote signer accounts: %w", err)
+		}
+		if len(accounts) != 1 {
+			return nil, fmt.Errorf("remote signer %s holds %d accounts; set SIGNER_ADDRESS to pick one", redactURL(cfg.RemoteURL), len(accounts))
+		}
+		from = accounts[0]
+	}
+
+	chainID, err := m.ClientL1.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+
+	return &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signRemote(ctx, client, from, chainID, tx)
+		},
+	}, nil
+}
+
+// signRemote has the remote signer sign tx and checks that what came back is tx, signed
+// by from. The caller broadcasts the result through ClientL1 as with any other signer.
+func signRemote(ctx context.Context, client *rpc.Client, from common.Address, chainID *big.Int, tx *types.Transaction) (*types.Transaction, error) {
+	req := remoteSignRequest{
+		From:    from,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+		Type:    hexutil.Uint64(tx.Type()),
+	}
+	if tx.Type() == types.LegacyTxType {
+		req.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		req.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		req.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	}
+	var result json.RawMessage
+	if err := client.CallContext(ctx, &result, "eth_signTransaction", req); err != nil {
+		return nil, fmt.Errorf("remote signer rejected the transaction: %w", err)
+	}
+	raw, err := decodeSignResult(result)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %w", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid signature: %w", err)
+	}
+	if sender != from {
+		return nil, fmt.Errorf("remote signer signed as %s instead of %s", sender.Hex(), from.Hex())
+	}
+	if signed.Nonce() != tx.Nonce() || signed.Gas() != tx.Gas() || signed.To() == nil || tx.To() == nil ||
+		*signed.To() != *tx.To() || signed.Value().Cmp(tx.Value()) != 0 || !bytes.Equal(signed.Data(), tx.Data()) ||
+		signed.Type() != tx.Type() || signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 || signed.GasTipCap().Cmp(tx.GasTipCap()) != 0 {
+		return nil, fmt.Errorf("remote signer changed the transaction it was asked to sign")
+	}
+	return signed, nil
+}
+
+// decodeSignResult extracts the raw transaction from an eth_signTransaction result:
+// web3signer returns the hex string, clef an object with a "raw" field
+func decodeSignResult(result json.RawMessage) ([]byte, error) {
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err == nil {
+		return raw, nil
+	}
+	var obj struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := json.Unmarshal(result, &obj); err != nil || len(obj.Raw) == 0 {
+		return nil, fmt.Errorf("unexpected eth_signTransaction result: %s", result)
+	}
+	return obj.Raw, nil
+}
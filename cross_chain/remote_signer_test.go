@@ -0,0 +1,202 @@
+package crosschain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stubSigner is a web3signer-style JSON-RPC endpoint holding account, signing with key
+type stubSigner struct {
+	t       *testing.T
+	account common.Address
+	key     *ecdsa.PrivateKey         // Signs eth_signTransaction; another key than account's fakes a wrong signer
+	clef    bool                      // Answer in clef's {"raw": ...} form
+	status  int                       // Non-zero: fail eth_signTransaction with this HTTP status
+	hang    chan struct{}             // Non-nil: hold eth_signTransaction until closed
+	tamper  func(*types.DynamicFeeTx) // Changes the transaction before signing
+}
+
+func (s *stubSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.t.Errorf("stub signer: %v", err)
+		return
+	}
+	var result any
+	switch req.Method {
+	case "eth_accounts":
+		result = []common.Address{s.account}
+	case "eth_signTransaction":
+		if s.status != 0 {
+			http.Error(w, "signer unavailable", s.status)
+			return
+		}
+		if s.hang != nil {
+			select {
+			case <-s.hang:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		var sign remoteSignRequest
+		if err := json.Unmarshal(req.Params[0], &sign); err != nil {
+			s.t.Errorf("stub signer: %v", err)
+			return
+		}
+		if sign.From != s.account {
+			s.t.Errorf("stub signer asked to sign as %s, holds %s", sign.From.Hex(), s.account.Hex())
+		}
+		inner := &types.DynamicFeeTx{
+			ChainID:   sign.ChainID.ToInt(),
+			Nonce:     uint64(sign.Nonce),
+			GasTipCap: sign.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: sign.MaxFeePerGas.ToInt(),
+			Gas:       uint64(sign.Gas),
+			To:        sign.To,
+			Value:     sign.Value.ToInt(),
+			Data:      sign.Data,
+		}
+		if s.tamper != nil {
+			s.tamper(inner)
+		}
+		signed, err := types.SignNewTx(s.key, types.LatestSignerForChainID(inner.ChainID), inner)
+		if err != nil {
+			s.t.Errorf("stub signer: %v", err)
+			return
+		}
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			s.t.Errorf("stub signer: %v", err)
+			return
+		}
+		if s.clef {
+			result = map[string]any{"raw": hexutil.Bytes(raw), "tx": signed}
+		} else {
+			result = hexutil.Bytes(raw)
+		}
+	default:
+		s.t.Errorf("stub signer: unexpected %s", req.Method)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+// newStubSigner starts a stub signer holding a fresh account
+func newStubSigner(t *testing.T) (*stubSigner, *httptest.Server) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := &stubSigner{t: t, account: crypto.PubkeyToAddress(key.PublicKey), key: key}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+// remoteSignTx signs a portal call through the signer at url, discovering its account
+func remoteSignTx(ctx context.Context, t *testing.T, url string) (*types.Transaction, *types.Transaction, error) {
+	t.Helper()
+	m := &CrossChainMessenger{ClientL1: chainIDClient{}}
+	opts, err := m.getRemoteTransactOpts(ctx, SignerConfig{RemoteURL: url})
+	if err != nil {
+		return nil, nil, err
+	}
+	to := common.HexToAddress(DefaultContracts().L1.OptimismPortal)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     4,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(3e9),
+		Gas:       250000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x8c, 0x3c, 0x9a, 0x3b},
+	})
+	signed, err := opts.Signer(opts.From, tx)
+	return tx, signed, err
+}
+
+func TestRemoteSigner(t *testing.T) {
+	for _, clef := range []bool{false, true} {
+		stub, server := newStubSigner(t)
+		stub.clef = clef
+		tx, signed, err := remoteSignTx(context.Background(), t, server.URL)
+		if err != nil {
+			t.Fatalf("clef=%t: %v", clef, err)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+		if err != nil || sender != stub.account {
+			t.Fatalf("clef=%t: signed by %s (%v), want %s", clef, sender.Hex(), err, stub.account.Hex())
+		}
+		if signed.Nonce() != tx.Nonce() || signed.Gas() != tx.Gas() || signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 {
+			t.Fatalf("clef=%t: signed %+v, want the transaction asked for", clef, signed)
+		}
+	}
+}
+
+func TestRemoteSignerWrongAddress(t *testing.T) {
+	stub, server := newStubSigner(t)
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub.key = other
+	_, _, err = remoteSignTx(context.Background(), t, server.URL)
+	if err == nil || !strings.Contains(err.Error(), "signed as "+crypto.PubkeyToAddress(other.PublicKey).Hex()) {
+		t.Fatalf("signature from another account = %v, want it rejected", err)
+	}
+}
+
+func TestRemoteSignerChangedTransaction(t *testing.T) {
+	stub, server := newStubSigner(t)
+	stub.tamper = func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(1e18) }
+	_, _, err := remoteSignTx(context.Background(), t, server.URL)
+	if err == nil || !strings.Contains(err.Error(), "changed the transaction") {
+		t.Fatalf("signature over a changed transaction = %v, want it rejected", err)
+	}
+}
+
+func TestRemoteSignerHTTPError(t *testing.T) {
+	stub, server := newStubSigner(t)
+	stub.status = http.StatusBadGateway
+	_, _, err := remoteSignTx(context.Background(), t, server.URL)
+	if err == nil || !strings.Contains(err.Error(), "remote signer rejected the transaction: 502") {
+		t.Fatalf("signer answering 502 = %v, want the HTTP error", err)
+	}
+}
+
+func TestRemoteSignerTimeout(t *testing.T) {
+	stub, server := newStubSigner(t)
+	stub.hang = make(chan struct{})
+	defer close(stub.hang)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := remoteSignTx(ctx, t, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("signer never answering = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("gave up after %s, want about the 200ms deadline", elapsed)
+	}
+}
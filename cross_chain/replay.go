@@ -0,0 +1,198 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// relayEstimationAddress is the tx.origin for which relayMessage reverts when the relayed
+// call fails. From anywhere else a failing call is recorded in failedMessages and the
+// transaction succeeds, so estimating from our wallet could settle on a gas limit too
+// low for the call to go through.
+var relayEstimationAddress = common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+// checkReplayable returns the message a finalized withdrawal relayed, once the
+// L1CrossDomainMessenger confirms it failed and hasn't been relayed since. It returns
+// ErrNotFinalized before the portal finalized the withdrawal and ErrNothingToReplay when
+// there is no failed message.
+func (m *CrossChainMessenger) checkReplayable(ctx context.Context, message Message) (CrossDomainMessage, error) {
+	if !FinalizedAtPortal(message.Status) {
+		m.logger().Errorf("❌ Withdrawal is not finalized yet (status %s); its message was never relayed", StatusDescription(message.Status))
+		return CrossDomainMessage{}, ErrNotFinalized
+	}
+	msg, ok := RelayedMessage(message)
+	if !ok {
+		return CrossDomainMessage{}, fmt.Errorf("%w: the withdrawal was not sent through the L2CrossDomainMessenger", ErrNothingToReplay)
+	}
+	relay, err := m.checkRelayResult(ctx, msg)
+	if err != nil {
+		return CrossDomainMessage{}, err
+	}
+	switch relay {
+	case RelaySucceeded:
+		m.logger().Infof("✅ Message was already relayed successfully")
+		return CrossDomainMessage{}, fmt.Errorf("%w: it was already relayed successfully", ErrNothingToReplay)
+	case RelayReverted:
+		return msg, nil
+	default:
+		return CrossDomainMessage{}, fmt.Errorf("%w: it is not in failedMessages", ErrNothingToReplay)
+	}
+}
+
+// packReplayCall ABI-encodes the relayMessage call that replays msg
+func packReplayCall(msg CrossDomainMessage) ([]byte, error) {
+	parsed, err := cross_abi.L1CrossDomainMessengerMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load L1CrossDomainMessenger ABI: %w", err)
+	}
+	calldata, err := parsed.Pack("relayMessage",
+		orZero(msg.Nonce), msg.Sender, msg.Target, orZero(msg.MntValue), orZero(msg.EthValue), orZero(msg.GasLimit), msg.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack relayMessage calldata: %w", err)
+	}
+	return calldata, nil
+}
+
+// replayGasLimit estimates the gas to replay calldata from relayEstimationAddress, so the
+// estimate covers the relayed call succeeding
+func (m *CrossChainMessenger) replayGasLimit(ctx context.Context, calldata []byte) (uint64, error) {
+	messengerAddr := common.HexToAddress(m.Contracts.L1.L1CrossDomainMessenger)
+	return m.gasLimit(ctx, ethereum.CallMsg{From: relayEstimationAddress, To: &messengerAddr, Data: calldata})
+}
+
+// ReplayMessage replays the message of a withdrawal that was finalized at the portal but
+// whose relayed call reverted, by calling relayMessage on the L1CrossDomainMessenger
+// with the original SentMessage fields. It checks on chain that the message is in
+// failedMessages and not in successfulMessages first, and returns the L1 transaction
+// hash. A replay that is mined while the call reverts again returns ErrReplayFailed. If
+// opts.PreviousTx was sent by an earlier run, it is awaited instead of replaying again.
+func (m *CrossChainMessenger) ReplayMessage(ctx context.Context, txHash string, messageIndex int, opts SubmitOptions) (_ common.Hash, err error) {
+	ctx, span := startSpan(ctx, "ReplayMessage", messageAttrs(txHash, messageIndex)...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return common.Hash{}, err
+	}
+	m.logger().Infof("\n=== REPLAY MESSAGE ===")
+	m.logger().Infof("Transaction hash (on L2): %s", txHash)
+	m.logger().Infof("Message index: %d", messageIndex)
+
+	if !m.HasSigner() {
+		return common.Hash{}, ErrNoSigner
+	}
+
+	// A replay sent before a restart may already be mined
+	if receipt, err := m.resumeSubmitted(ctx, opts); err != nil || receipt != nil {
+		if err == nil {
+			if err = m.checkReplayReceipt(receipt); err == nil {
+				opts.mined(m.receiptFee(ctx, receipt, opts.From))
+			}
+		}
+		return opts.PreviousTx, err
+	}
+
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get messages: %w", err)
+	}
+	msg, err := m.checkReplayable(ctx, message)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	m.logger().Infof("🔄 Replaying failed message...")
+	m.logger().Debugf("  Nonce: %s", msg.Nonce.String())
+	m.logger().Debugf("  Sender: %s", msg.Sender.Hex())
+	m.logger().Debugf("  Target: %s", msg.Target.Hex())
+	m.logger().Debugf("  MNT Value: %s", orZero(msg.MntValue).String())
+	m.logger().Debugf("  ETH Value: %s", orZero(msg.EthValue).String())
+	m.logger().Debugf("  Min Gas Limit: %s", orZero(msg.GasLimit).String())
+	m.logger().Debugf("  Data: %x", msg.Data)
+
+	messengerAddr := common.HexToAddress(m.Contracts.L1.L1CrossDomainMessenger)
+	messenger, err := m.l1CrossDomainMessenger()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	txOpts, err := m.getTransactOpts(ctx, opts.From)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get transaction options: %w", err)
+	}
+
+	// The messenger already holds the funds; replays must not send value
+	calldata, err := packReplayCall(msg)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gasLimit, err := m.replayGasLimit(ctx, calldata)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := m.applyFees(ctx, txOpts, gasLimit); err != nil {
+		return common.Hash{}, err
+	}
+	// The preview shows the relayed call, not the portal's call into the messenger
+	preview := newTxPreview("relayMessage", message, txOpts, messengerAddr)
+	preview.Sender, preview.Target = msg.Sender, msg.Target
+	if err := opts.confirm(preview); err != nil {
+		return common.Hash{}, err
+	}
+
+	m.logger().Infof("\n🚀 Sending replay transaction...")
+	tx, err := m.sendTransaction(ctx, txOpts, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return messenger.RelayMessage(opts, orZero(msg.Nonce), msg.Sender, msg.Target,
+			orZero(msg.MntValue), orZero(msg.EthValue), orZero(msg.GasLimit), msg.Data)
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to replay message: %w", wrapContractError(ContractL1CrossDomainMessenger, err))
+	}
+	m.logger().Infof("✅ Replay transaction submitted: %s", tx.Hash().Hex())
+	opts.submitted(tx)
+
+	m.logger().Infof("\n⏳ Waiting for transaction to be mined...")
+	receipt, err := m.waitMined(ctx, tx, txOpts)
+	if err != nil {
+		m.resetNonce()
+		return tx.Hash(), fmt.Errorf("failed to wait for transaction: %w", err)
+	}
+	if receipt.Status == 0 {
+		return tx.Hash(), m.revertedTxError(ctx, ContractL1CrossDomainMessenger, tx, txOpts.From, receipt)
+	}
+
+	fee := m.receiptFee(ctx, receipt, txOpts.From)
+	m.logger().Infof("✅ Transaction mined in block %d (status: %d)", receipt.BlockNumber.Uint64(), receipt.Status)
+	m.logger().Infof("   Gas used: %d, fee: %s ETH", receipt.GasUsed, fee.ETH())
+	m.logTxLink(tx.Hash())
+	if err := m.checkReplayReceipt(receipt); err != nil {
+		return tx.Hash(), err
+	}
+	opts.mined(fee)
+	return receipt.TxHash, nil
+}
+
+// checkReplayReceipt returns ErrReplayFailed when a mined replay emitted
+// FailedRelayedMessage: relayMessage doesn't revert when the relayed call fails again, it
+// records the failure and returns. A replay relays a single message, so any such event
+// from the messenger is ours.
+func (m *CrossChainMessenger) checkReplayReceipt(receipt *types.Receipt) error {
+	parsed, err := cross_abi.L1CrossDomainMessengerMetaData.GetAbi()
+	if err != nil {
+		return fmt.Errorf("failed to load L1CrossDomainMessenger ABI: %w", err)
+	}
+	failedTopic := parsed.Events["FailedRelayedMessage"].ID
+	messengerAddr := common.HexToAddress(m.Contracts.L1.L1CrossDomainMessenger)
+	for _, log := range receipt.Logs {
+		if log.Address == messengerAddr && len(log.Topics) > 1 && log.Topics[0] == failedTopic {
+			m.logger().Errorf("❌ Replayed message %s failed again", log.Topics[1].Hex())
+			return fmt.Errorf("%w: %s emitted FailedRelayedMessage", ErrReplayFailed, receipt.TxHash.Hex())
+		}
+	}
+	m.logger().Infof("✅ Message relayed successfully")
+	return nil
+}
@@ -0,0 +1,95 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SubmitOptions lets ProveMessage and FinalizeMessage pick up a transaction sent by an
+// earlier run that died before it was mined, instead of submitting a duplicate, and
+// choose which configured wallet signs
+type SubmitOptions struct {
+	PreviousTx  common.Hash                // L1 transaction sent by an earlier run; zero if none
+	OnSubmitted func(l1TxHash common.Hash) // Called right after sending, before waiting, so callers can persist the hash
+	From        common.Address             // Wallet to sign with; zero means the default signer
+	OnProven    func(p ProofProvenance)    // ProveMessage only: called once the prove is mined, with the output it used
+	L2BlockHash common.Hash                // ProveMessage only: block hash to prove with instead of the L2 RPC header's; for manual recovery
+	OnMined     func(fee TxFee)            // Called once our transaction is mined successfully, with the fee it paid
+	Confirm     func(p TxPreview) error    // Called after gas estimation, before signing; an error aborts without sending and is returned
+}
+
+// submitted reports a freshly sent transaction to OnSubmitted
+func (o SubmitOptions) submitted(tx *types.Transaction) {
+	if o.OnSubmitted != nil {
+		o.OnSubmitted(tx.Hash())
+	}
+}
+
+// proven reports the output a mined prove used to OnProven
+func (o SubmitOptions) proven(p ProofProvenance) {
+	if o.OnProven != nil {
+		o.OnProven(p)
+	}
+}
+
+// mined reports the fee of a successfully mined transaction to OnMined
+func (o SubmitOptions) mined(fee TxFee) {
+	if o.OnMined != nil {
+		o.OnMined(fee)
+	}
+}
+
+// resumeSubmitted checks opts.PreviousTx. It waits for the transaction if it is still
+// pending and returns its receipt once mined successfully. It returns nil when there is
+// nothing to resume: no previous transaction, or one that reverted or was dropped, in
+// which case the caller should submit again.
+func (m *CrossChainMessenger) resumeSubmitted(ctx context.Context, opts SubmitOptions) (*types.Receipt, error) {
+	hash := opts.PreviousTx
+	if hash == (common.Hash{}) {
+		return nil, nil
+	}
+	m.logger().Infof("🔎 Checking previously submitted transaction %s", hash.Hex())
+	m.progress(ctx, ProgressResuming, map[string]any{"l1TxHash": hash.Hex()})
+
+	receipt, err := m.ClientL1.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		tx, _, err := m.ClientL1.TransactionByHash(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			m.logger().Warnf("⚠️  %s was dropped or replaced; submitting again", hash.Hex())
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up previously submitted transaction: %w", err)
+		}
+
+		m.logger().Infof("⏳ %s is still pending, resuming wait...", hash.Hex())
+		receipt, err = m.waitMined(ctx, tx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for transaction: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up previously submitted transaction: %w", err)
+	} else {
+		receipt, err = m.awaitL1Confirmations(ctx, receipt, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for confirmations: %w", err)
+		}
+		if receipt == nil {
+			// Reorged out; it is pending again or gone
+			return m.resumeSubmitted(ctx, opts)
+		}
+	}
+
+	if receipt.Status == 0 {
+		m.logger().Warnf("⚠️  %s reverted in block %d; submitting again", hash.Hex(), receipt.BlockNumber.Uint64())
+		return nil, nil
+	}
+	m.logger().Infof("✅ %s was already mined in block %d", hash.Hex(), receipt.BlockNumber.Uint64())
+	m.progressMined(ctx, receipt)
+	return receipt, nil
+}
@@ -0,0 +1,150 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Defaults used by MessengerConfigFromEnv
+const (
+	DefaultRPCMaxAttempts    = 4
+	DefaultRPCCallTimeout    = 20 * time.Second
+	DefaultRPCInitialBackoff = 500 * time.Millisecond
+	DefaultRPCMaxBackoff     = 8 * time.Second
+)
+
+// RetryPolicy controls how read-only RPC calls are retried on transport failures.
+// The zero value makes a single attempt with no per-call timeout.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts per call, including the first
+	CallTimeout    time.Duration // Bound on each attempt; 0 means none
+	InitialBackoff time.Duration // Delay before the first retry, doubled after each attempt
+	MaxBackoff     time.Duration // Upper bound on the delay between attempts
+}
+
+// DefaultRetryPolicy returns the policy used when nothing is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    DefaultRPCMaxAttempts,
+		CallTimeout:    DefaultRPCCallTimeout,
+		InitialBackoff: DefaultRPCInitialBackoff,
+		MaxBackoff:     DefaultRPCMaxBackoff,
+	}
+}
+
+// backoff returns the delay before retry number attempt (1-based)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay > 0; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// retryablePhrases are transport-level failures public endpoints report in the error text
+var retryablePhrases = []string{
+	"rate limit",
+	"too many requests",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"bad gateway",
+	"service unavailable",
+	"gateway timeout",
+	"timeout",
+	"header not found",
+}
+
+// isRetryable reports whether err is a transient transport failure. Contract reverts
+// and missing data are deterministic and never retried.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ethereum.NotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if _, reverted := decodeRevertReason(err); reverted {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == 429 || httpErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, phrase := range retryablePhrases {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry runs call under the messenger's retry policy, giving each attempt its own
+// timeout and backing off between retryable failures. All attempts together are bounded
+// by Timeouts.RPCRead; running out of it returns a TimeoutError.
+func withRetry[T any](parent context.Context, m *CrossChainMessenger, name string, call func(ctx context.Context) (T, error)) (T, error) {
+	ctx, cancel := withOptionalTimeout(parent, m.Timeouts.RPCRead)
+	defer cancel()
+
+	result, err := retryCall(ctx, m, name, call)
+	return result, asTimeout(parent, ctx, name, m.Timeouts.RPCRead, err)
+}
+
+// retryCall is withRetry without the overall bound
+func retryCall[T any](ctx context.Context, m *CrossChainMessenger, name string, call func(ctx context.Context) (T, error)) (T, error) {
+	policy := m.Retry
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var zero T
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		callCtx, cancel := withOptionalTimeout(ctx, policy.CallTimeout)
+		result, err := call(callCtx)
+		cancel()
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+
+		// A canceled or expired parent context means the caller gave up, not the endpoint
+		if ctx.Err() != nil || !isRetryable(err) || attempt == attempts {
+			break
+		}
+
+		delay := policy.backoff(attempt)
+		m.logger().Warnf("🔁 %s failed (attempt %d/%d): %v; retrying in %s", name, attempt, attempts, err, delay)
+		select {
+		case <-ctx.Done():
+			return zero, fmt.Errorf("%s: %w", name, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+	return zero, markRPCError(ctx, lastErr)
+}
@@ -0,0 +1,151 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limited", errors.New("429 Too Many Requests"), true},
+		{"rate limit text", errors.New("daily request rate limit exceeded"), true},
+		{"HTTP 429", rpc.HTTPError{StatusCode: 429}, true},
+		{"HTTP 502", rpc.HTTPError{StatusCode: 502}, true},
+		{"HTTP 400", rpc.HTTPError{StatusCode: 400}, false},
+		{"network error", &net.OpError{Op: "dial", Err: errors.New("no route to host")}, true},
+		{"EOF", fmt.Errorf("reading response: %w", io.EOF), true},
+		{"attempt timed out", context.DeadlineExceeded, true},
+		{"header not found", errors.New("header not found"), true},
+		{"caller canceled", context.Canceled, false},
+		{"not found", ethereum.NotFound, false},
+		{"revert", errors.New("execution reverted: L2OutputOracle: cannot get output for a block that has not been proposed"), false},
+		// A revert reason mentioning a timeout is still a revert
+		{"revert mentioning a timeout", errors.New("execution reverted: timeout not reached"), false},
+		{"other", errors.New("invalid argument 0: hex string without 0x prefix"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Fatalf("isRetryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		4: 800 * time.Millisecond,
+		5: time.Second,
+		9: time.Second,
+	} {
+		if got := p.backoff(attempt); got != want {
+			t.Fatalf("backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+// flakyClient fails the first failures receipt lookups with err, then succeeds
+type flakyClient struct {
+	EthClient
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *flakyClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return nil, c.err
+	}
+	return &types.Receipt{TxHash: hash, BlockNumber: big.NewInt(1)}, nil
+}
+
+func flakyMessenger(t *testing.T, client *flakyClient, policy RetryPolicy) *CrossChainMessenger {
+	t.Helper()
+	m, err := NewCrossChainMessenger(MessengerConfig{
+		L1Client:          client,
+		L2Client:          client,
+		SkipStartupChecks: true,
+		Contracts:         DefaultContracts(),
+		Retry:             policy,
+		Logger:            NopLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	txHash := common.HexToHash("0xaa").Hex()
+
+	client := &flakyClient{failures: 2, err: rpc.HTTPError{StatusCode: 502}}
+	if _, err := flakyMessenger(t, client, policy).getTransactionReceipt(context.Background(), txHash, "L2"); err != nil {
+		t.Fatalf("two transient failures with 3 attempts: %v", err)
+	}
+	if client.calls != 3 {
+		t.Fatalf("made %d calls, want 3", client.calls)
+	}
+
+	client = &flakyClient{failures: 3, err: rpc.HTTPError{StatusCode: 502}}
+	_, err := flakyMessenger(t, client, policy).getTransactionReceipt(context.Background(), txHash, "L2")
+	if !errors.Is(err, ErrRPC) {
+		t.Fatalf("three transient failures with 3 attempts = %v, want ErrRPC", err)
+	}
+	if client.calls != 3 {
+		t.Fatalf("made %d calls, want 3", client.calls)
+	}
+}
+
+func TestRetrySkipsDeterministicFailures(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	for _, failure := range []error{
+		errors.New("execution reverted: OptimismPortal: paused"),
+		ethereum.NotFound,
+	} {
+		client := &flakyClient{failures: 1, err: failure}
+		_, err := flakyMessenger(t, client, policy).getTransactionReceipt(context.Background(), common.HexToHash("0xaa").Hex(), "L2")
+		if err == nil || errors.Is(err, ErrRPC) {
+			t.Fatalf("%v: got %v, want it returned unmarked", failure, err)
+		}
+		if client.calls != 1 {
+			t.Fatalf("%v: made %d calls, want 1", failure, client.calls)
+		}
+	}
+}
+
+func TestRetryCallTimeout(t *testing.T) {
+	m := flakyMessenger(t, &flakyClient{}, RetryPolicy{MaxAttempts: 2, CallTimeout: 10 * time.Millisecond, InitialBackoff: time.Millisecond})
+	calls := 0
+	_, err := withRetry(context.Background(), m, "slow", func(ctx context.Context) (struct{}, error) {
+		calls++
+		<-ctx.Done()
+		return struct{}{}, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("withRetry() = %v, want the attempt timeout", err)
+	}
+	if calls != 2 {
+		t.Fatalf("made %d calls, want 2: a timed-out attempt is retried", calls)
+	}
+}
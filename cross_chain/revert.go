@@ -0,0 +1,206 @@
+package crosschain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrTransactionReverted is returned when a submitted transaction is mined with status 0.
+// It matches ErrReverted.
+var ErrTransactionReverted error = statusRevertedError{}
+
+// Contract names used in decoded revert messages
+const (
+	ContractOptimismPortal         = "OptimismPortal"
+	ContractL2OutputOracle         = "L2OutputOracle"
+	ContractL1CrossDomainMessenger = "L1CrossDomainMessenger"
+)
+
+// RevertError is a contract call, gas estimation or transaction that reverted, with the
+// reason decoded from the revert data
+type RevertError struct {
+	Contract string // Contract that reverted, empty if unknown
+	Reason   string // Decoded reason, e.g. "withdrawal timestamp less than finalization period"
+	Err      error  // Underlying RPC error, if any
+}
+
+func (e *RevertError) Error() string {
+	if e.Contract == "" {
+		return "execution reverted: " + e.Reason
+	}
+	return fmt.Sprintf("%s reverted: %s", e.Contract, e.Reason)
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// isRevert reports whether err means a contract rejected the call, as opposed to an
+// RPC or signing failure
+func isRevert(err error) bool {
+	var revertErr *RevertError
+	if errors.As(err, &revertErr) || errors.Is(err, ErrTransactionReverted) {
+		return true
+	}
+	_, ok := decodeRevertReason(err)
+	return ok
+}
+
+// RevertReason returns the decoded revert from anywhere in err's chain, formatted as
+// "OptimismPortal reverted: <reason>", so callers can surface it without the wrapping
+func RevertReason(err error) (string, bool) {
+	var revertErr *RevertError
+	if !errors.As(err, &revertErr) {
+		return "", false
+	}
+	return revertErr.Error(), true
+}
+
+// contractAt names the configured contract at addr for decoding its reverts. Calls we
+// send go to the OptimismPortal unless they are replays to the L1CrossDomainMessenger.
+func (m *CrossChainMessenger) contractAt(addr *common.Address) string {
+	if addr != nil && *addr == common.HexToAddress(m.Contracts.L1.L1CrossDomainMessenger) {
+		return ContractL1CrossDomainMessenger
+	}
+	return ContractOptimismPortal
+}
+
+// contractABI returns the parsed ABI of a contract we decode custom errors for
+func contractABI(contract string) *abi.ABI {
+	var metaData *bind.MetaData
+	switch contract {
+	case ContractOptimismPortal:
+		metaData = cross_abi.OptimismPortalMetaData
+	case ContractL2OutputOracle:
+		metaData = cross_abi.L2OutputOracleMetaData
+	case ContractL1CrossDomainMessenger:
+		metaData = cross_abi.L1CrossDomainMessengerMetaData
+	default:
+		return nil
+	}
+	parsed, err := metaData.GetAbi()
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// wrapContractError turns err into a *RevertError naming contract when it is a revert;
+// any other error is returned unchanged
+func wrapContractError(contract string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var revertErr *RevertError
+	if errors.As(err, &revertErr) {
+		return err
+	}
+
+	if data, ok := revertData(err); ok {
+		return &RevertError{Contract: contract, Reason: decodeRevertData(contract, data), Err: err}
+	}
+	if reason, ok := decodeRevertReason(err); ok {
+		return &RevertError{Contract: contract, Reason: trimContractPrefix(contract, reason), Err: err}
+	}
+	return err
+}
+
+// revertData extracts the raw revert payload carried by an RPC error
+func revertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok || hexData == "" {
+		return nil, false
+	}
+	return common.FromHex(hexData), true
+}
+
+// decodeRevertData decodes Error(string), Panic(uint256) and the contract's custom errors
+func decodeRevertData(contract string, data []byte) string {
+	if len(data) == 0 {
+		return "no reason given"
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return trimContractPrefix(contract, reason)
+	}
+	if parsed := contractABI(contract); parsed != nil && len(data) >= 4 {
+		for name, customErr := range parsed.Errors {
+			if !bytes.HasPrefix(data, customErr.ID[:4]) {
+				continue
+			}
+			args, err := customErr.Unpack(data)
+			if err != nil {
+				return name
+			}
+			if values, ok := args.([]interface{}); ok && len(values) > 0 {
+				return fmt.Sprintf("%s%v", name, values)
+			}
+			return name
+		}
+	}
+	return fmt.Sprintf("unknown error 0x%x", data)
+}
+
+// decodeRevertReason extracts a human-readable revert reason from an RPC error.
+// It understands the standard Error(string) encoding carried in the error data.
+func decodeRevertReason(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	if data, ok := revertData(err); ok {
+		if reason, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+			return reason, true
+		}
+	}
+
+	// Some nodes put the reason directly in the message
+	if msg := err.Error(); strings.Contains(msg, "execution reverted") {
+		if _, reason, found := strings.Cut(msg, "execution reverted: "); found {
+			return reason, true
+		}
+		return msg, true
+	}
+	return "", false
+}
+
+// trimContractPrefix drops the "OptimismPortal: " prefix the contracts put on their reasons
+func trimContractPrefix(contract, reason string) string {
+	return strings.TrimPrefix(reason, contract+": ")
+}
+
+// revertedTxError explains a transaction mined with status 0 by replaying it as a call
+// against the state before its block, so the revert reason can be decoded
+func (m *CrossChainMessenger) revertedTxError(ctx context.Context, contract string, tx *types.Transaction, from common.Address, receipt *types.Receipt) error {
+	block := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	_, err := m.ClientL1.CallContract(ctx, ethereum.CallMsg{
+		From:      from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}, block)
+	if wrapped := wrapContractError(contract, err); isRevert(wrapped) {
+		return fmt.Errorf("%w: %w", ErrTransactionReverted, wrapped)
+	}
+	m.logger().Debugf("⚠️  Could not reproduce revert of %s: %v", tx.Hash().Hex(), err)
+	return ErrTransactionReverted
+}
@@ -0,0 +1,106 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// batchCall sends elems to client as one JSON-RPC batch under the retry policy. The
+// whole batch is resent on retryable failures, including a retryable error in a single
+// element; otherwise the first element error is returned.
+func (m *CrossChainMessenger) batchCall(ctx context.Context, client EthClient, name string, elems []rpc.BatchElem) error {
+	_, err := withRetry(ctx, m, name, func(ctx context.Context) (struct{}, error) {
+		for i := range elems {
+			elems[i].Error = nil
+		}
+		if err := client.BatchCallContext(ctx, elems); err != nil {
+			return struct{}{}, err
+		}
+		for _, elem := range elems {
+			if elem.Error != nil {
+				return struct{}{}, fmt.Errorf("%s: %w", elem.Method, elem.Error)
+			}
+		}
+		return struct{}{}, nil
+	})
+	return err
+}
+
+// ethCallElem builds a batched eth_call of data against to at the latest block
+func ethCallElem(to common.Address, data []byte, result *hexutil.Bytes) rpc.BatchElem {
+	return rpc.BatchElem{
+		Method: "eth_call",
+		Args: []interface{}{
+			map[string]interface{}{"to": to, "data": hexutil.Bytes(data)},
+			"latest",
+		},
+		Result: result,
+	}
+}
+
+// portalWithdrawal is the OptimismPortal's record of one withdrawal
+type portalWithdrawal struct {
+	finalized         bool
+	proven            bool
+	provenTimestamp   *big.Int
+	provenOutputRoot  common.Hash // Output the withdrawal was proven against; zero unless proven
+	provenOutputIndex uint64
+}
+
+// readPortalWithdrawal reads finalizedWithdrawals and provenWithdrawals for
+// withdrawalHash in a single batch
+func (m *CrossChainMessenger) readPortalWithdrawal(ctx context.Context, withdrawalHash string) (portalWithdrawal, error) {
+	var result portalWithdrawal
+
+	parsed, err := cross_abi.OptimismPortalMetaData.GetAbi()
+	if err != nil {
+		return result, fmt.Errorf("failed to load OptimismPortal ABI: %w", err)
+	}
+	hash := common.HexToHash(withdrawalHash)
+	finalizedData, err := parsed.Pack("finalizedWithdrawals", hash)
+	if err != nil {
+		return result, fmt.Errorf("failed to pack finalizedWithdrawals calldata: %w", err)
+	}
+	provenData, err := parsed.Pack("provenWithdrawals", hash)
+	if err != nil {
+		return result, fmt.Errorf("failed to pack provenWithdrawals calldata: %w", err)
+	}
+
+	portal := common.HexToAddress(m.Contracts.L1.OptimismPortal)
+	var finalizedOut, provenOut hexutil.Bytes
+	elems := []rpc.BatchElem{
+		ethCallElem(portal, finalizedData, &finalizedOut),
+		ethCallElem(portal, provenData, &provenOut),
+	}
+	if err := m.batchCall(ctx, m.ClientL1, "L1 finalizedWithdrawals+provenWithdrawals", elems); err != nil {
+		return result, wrapContractError(ContractOptimismPortal, err)
+	}
+
+	finalized, err := parsed.Unpack("finalizedWithdrawals", finalizedOut)
+	if err != nil {
+		return result, fmt.Errorf("failed to decode finalizedWithdrawals: %w", err)
+	}
+	result.finalized = *abi.ConvertType(finalized[0], new(bool)).(*bool)
+
+	proven, err := parsed.Unpack("provenWithdrawals", provenOut)
+	if err != nil {
+		return result, fmt.Errorf("failed to decode provenWithdrawals: %w", err)
+	}
+	outputRoot := *abi.ConvertType(proven[0], new([32]byte)).(*[32]byte)
+	result.proven = outputRoot != [32]byte{}
+	result.provenTimestamp = *abi.ConvertType(proven[1], new(*big.Int)).(**big.Int)
+	result.provenOutputRoot = outputRoot
+	result.provenOutputIndex = (*abi.ConvertType(proven[2], new(*big.Int)).(**big.Int)).Uint64()
+
+	m.logger().Debugf("📤 Portal withdrawal state: finalized=%t proven=%t timestamp=%s",
+		result.finalized, result.proven, result.provenTimestamp)
+	return result, nil
+}
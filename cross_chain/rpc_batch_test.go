@@ -0,0 +1,102 @@
+package crosschain
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// countingL1 is a fakeL1 behind a transport that counts round trips and takes latency
+// for each
+type countingL1 struct {
+	*fakeL1
+	latency time.Duration
+
+	roundTrips atomic.Int64 // Batches and single calls
+	batched    atomic.Int64 // Calls that went out inside a batch
+}
+
+func (c *countingL1) BatchCallContext(ctx context.Context, elems []rpc.BatchElem) error {
+	c.roundTrips.Add(1)
+	c.batched.Add(int64(len(elems)))
+	time.Sleep(c.latency)
+	return c.fakeL1.BatchCallContext(ctx, elems)
+}
+
+func (c *countingL1) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	c.roundTrips.Add(1)
+	time.Sleep(c.latency)
+	return c.fakeL1.CallContract(ctx, msg, block)
+}
+
+// countingMessenger returns a messenger over a counting L1 with a proven withdrawal
+func countingMessenger(tb testing.TB, latency time.Duration) (*CrossChainMessenger, *countingL1, string) {
+	tb.Helper()
+	l2 := newFakeL2(tb, testWithdrawal())
+	l1 := &countingL1{fakeL1: newFakeL1(tb, l2), latency: latency}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	m := proveFinalizeMessenger(tb, l1, l2, key)
+	hash, err := ComputeWithdrawalHash(testWithdrawal())
+	if err != nil {
+		tb.Fatal(err)
+	}
+	l1.proven[hash] = provenWithdrawal{outputRoot: l1.outputs[0].OutputRoot, timestamp: time.Now(), outputIndex: big.NewInt(0)}
+	return m, l1, common.Bytes2Hex(hash[:])
+}
+
+func TestReadPortalWithdrawalBatches(t *testing.T) {
+	m, l1, withdrawalHash := countingMessenger(t, 0)
+	portal, err := m.readPortalWithdrawal(context.Background(), withdrawalHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !portal.proven || portal.finalized || portal.provenOutputRoot != l1.outputs[0].OutputRoot {
+		t.Fatalf("portal state = %+v, want proven against output 0", portal)
+	}
+	if got := l1.roundTrips.Load(); got != 1 {
+		t.Fatalf("readPortalWithdrawal() made %d round trips, want 1", got)
+	}
+	if got := l1.batched.Load(); got != 2 {
+		t.Fatalf("readPortalWithdrawal() batched %d calls, want finalizedWithdrawals and provenWithdrawals", got)
+	}
+}
+
+// BenchmarkPortalStatus compares reading a withdrawal's portal state in one batch with
+// the separate finalizedWithdrawals and provenWithdrawals calls, over a transport with
+// 200µs of latency per round trip
+func BenchmarkPortalStatus(b *testing.B) {
+	ctx := context.Background()
+	b.Run("batched", func(b *testing.B) {
+		m, l1, withdrawalHash := countingMessenger(b, 200*time.Microsecond)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := m.readPortalWithdrawal(ctx, withdrawalHash); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(l1.roundTrips.Load())/float64(b.N), "round-trips/op")
+	})
+	b.Run("separate", func(b *testing.B) {
+		m, l1, withdrawalHash := countingMessenger(b, 200*time.Microsecond)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := m.checkFinalizationStatus(ctx, withdrawalHash); err != nil {
+				b.Fatal(err)
+			}
+			if _, _, err := m.checkProvenStatus(ctx, withdrawalHash); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(l1.roundTrips.Load())/float64(b.N), "round-trips/op")
+	})
+}
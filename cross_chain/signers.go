@@ -0,0 +1,93 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrUnknownSigner is returned when a transaction is requested from a wallet none of
+// the configured signers controls
+var ErrUnknownSigner = errors.New("no configured signer for wallet")
+
+// signerConfigs returns the configured signers, the default one (KMSKeyID/PrivateKey, or
+// else the first of Signers) first. Each entry signs with its KMS key if set, then its
+// remote signer, then its private key.
+func (m *CrossChainMessenger) signerConfigs() []SignerConfig {
+	var configs []SignerConfig
+	switch {
+	case m.KMSKeyID != "":
+		configs = append(configs, SignerConfig{KMSKeyID: m.KMSKeyID})
+	case m.PrivateKey != "":
+		configs = append(configs, SignerConfig{PrivateKey: m.PrivateKey})
+	}
+	for _, cfg := range m.Signers {
+		if cfg.KMSKeyID != "" || cfg.RemoteURL != "" || cfg.PrivateKey != "" {
+			configs = append(configs, cfg)
+		}
+	}
+	return configs
+}
+
+// signerAddress returns the wallet address of cfg if it has been resolved before
+func (m *CrossChainMessenger) signerAddress(cfg SignerConfig) (common.Address, bool) {
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	addr, ok := m.signerAddrs[cfg]
+	return addr, ok
+}
+
+func (m *CrossChainMessenger) setSignerAddress(cfg SignerConfig, addr common.Address) {
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	if _, ok := m.signerAddrs[cfg]; ok {
+		return
+	}
+	if m.signerAddrs == nil {
+		m.signerAddrs = make(map[SignerConfig]common.Address)
+	}
+	m.signerAddrs[cfg] = addr
+	m.logger().Infof("💼 Wallet address: %s", addr.Hex())
+}
+
+// walletAddress returns WalletAddress under signerMu, since submissions for different
+// withdrawals and wallets resolve signers concurrently
+func (m *CrossChainMessenger) walletAddress() string {
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	return m.WalletAddress
+}
+
+// setDefaultWallet sets WalletAddress to addr, the default signer's wallet, unless it is
+// already set
+func (m *CrossChainMessenger) setDefaultWallet(addr common.Address) {
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+	if m.WalletAddress == "" {
+		m.WalletAddress = addr.Hex()
+	}
+}
+
+// WalletAddresses resolves every configured signer and returns their addresses, the
+// default signer first. KMS signers are looked up on first use, so this may call KMS.
+func (m *CrossChainMessenger) WalletAddresses(ctx context.Context) ([]common.Address, error) {
+	configs := m.signerConfigs()
+	addresses := make([]common.Address, 0, len(configs))
+	for i, cfg := range configs {
+		addr, ok := m.signerAddress(cfg)
+		if !ok {
+			opts, err := m.signerTransactOpts(ctx, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("signer %d: %w", i+1, err)
+			}
+			addr = opts.From
+		}
+		addresses = append(addresses, addr)
+	}
+	if len(addresses) > 0 {
+		m.setDefaultWallet(addresses[0])
+	}
+	return addresses, nil
+}
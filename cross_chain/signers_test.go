@@ -0,0 +1,77 @@
+package crosschain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// chainIDClient is an L1 client that only knows its chain ID
+type chainIDClient struct {
+	EthClient
+}
+
+func (chainIDClient) ChainID(ctx context.Context) (*big.Int, error) { return big.NewInt(1), nil }
+
+// leadingZeroKey's public key has a full 32-byte X and a Y coordinate with a leading
+// zero byte. The old helper padded X‖Y as a whole, at the front, so X moved into Y's
+// zero byte and the address came out wrong.
+const leadingZeroKey = "0x5a00000000000000000000000000000000000000000000000000000000000019"
+
+func TestSignerAddressLeadingZero(t *testing.T) {
+	key, err := crypto.HexToECDSA(leadingZeroKey[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key.PublicKey.X.Bytes()) != 32 || len(key.PublicKey.Y.Bytes()) == 32 {
+		t.Fatal("leadingZeroKey no longer has a short Y coordinate")
+	}
+	// keccak256 of the 64-byte X‖Y, each coordinate padded to 32 bytes
+	want := common.BytesToAddress(crypto.Keccak256(crypto.FromECDSAPub(&key.PublicKey)[1:])[12:])
+
+	// What the old helper hashed
+	old := append(key.PublicKey.X.Bytes(), key.PublicKey.Y.Bytes()...)
+	old = append(make([]byte, 64-len(old)), old...)
+	if common.BytesToAddress(crypto.Keccak256(old)[12:]) == want {
+		t.Fatal("leadingZeroKey doesn't trigger the old padding bug")
+	}
+
+	m, err := NewCrossChainMessenger(MessengerConfig{
+		L1Client:          chainIDClient{},
+		L2Client:          chainIDClient{},
+		SkipStartupChecks: true,
+		Contracts:         DefaultContracts(),
+		Signer:            SignerConfig{PrivateKey: leadingZeroKey},
+		Logger:            NopLogger(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallets, err := m.WalletAddresses(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wallets) != 1 || wallets[0] != want {
+		t.Fatalf("WalletAddresses() = %v, want [%s]", wallets, want.Hex())
+	}
+	if m.walletAddress() != want.Hex() {
+		t.Fatalf("WalletAddress = %s, want %s", m.walletAddress(), want.Hex())
+	}
+
+	// Transactions are signed by the same account the balance checks look at
+	opts, err := m.getTransactOpts(context.Background(), want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), Gas: 21000}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed); err != nil || sender != want {
+		t.Fatalf("signed by %s (%v), want %s", sender.Hex(), err, want.Hex())
+	}
+}
@@ -0,0 +1,78 @@
+package crosschain
+
+import (
+	"context"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Where the sender of an offline gas estimate came from; UnsignedTx.EstimatedFromSource
+const (
+	FromSourceOverride = "override"          // WithSimulationFrom, --from or SIMULATION_FROM
+	FromSourceSigner   = "signer"            // The configured wallet
+	FromSourceL2Sender = "withdrawal sender" // The withdrawal's original L2 sender
+	FromSourceRelay    = "relay estimation"  // relayMessage's estimation address; see relayEstimationAddress
+)
+
+type simulationFromKey struct{}
+
+// WithSimulationFrom returns a context whose offline calldata builds estimate gas from
+// from instead of the messenger's SimulationFrom or wallet. It scopes the sender to one
+// call, e.g. one API request, when the messenger is shared.
+func WithSimulationFrom(ctx context.Context, from common.Address) context.Context {
+	return context.WithValue(ctx, simulationFromKey{}, from)
+}
+
+// ParseSimulationFrom parses the address name (e.g. "--from") is set to. The zero address
+// is rejected: it isn't a sender anyone can sign for, and estimates from it can differ
+// from a real one's.
+func ParseSimulationFrom(name, value string) (common.Address, error) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, &InputError{Name: name, Value: value, Expected: "a 20-byte hex address"}
+	}
+	from := common.HexToAddress(value)
+	if from == (common.Address{}) {
+		return common.Address{}, &InputError{Name: name, Value: value, Expected: "an address other than the zero address"}
+	}
+	return from, nil
+}
+
+// simulationFromEnv reads SIMULATION_FROM; zero when unset
+func simulationFromEnv() (common.Address, error) {
+	v := os.Getenv("SIMULATION_FROM")
+	if v == "" {
+		return common.Address{}, nil
+	}
+	return ParseSimulationFrom("SIMULATION_FROM", v)
+}
+
+// estimationFrom picks the sender to estimate message's offline calls from: the one on
+// ctx, else SimulationFrom, else the wallet, else the withdrawal's original L2 sender as
+// a reasonable proxy for a read-only user. It returns the address and where it came from.
+func (m *CrossChainMessenger) estimationFrom(ctx context.Context, message Message) (common.Address, string) {
+	if from, ok := ctx.Value(simulationFromKey{}).(common.Address); ok && from != (common.Address{}) {
+		return from, FromSourceOverride
+	}
+	if m.SimulationFrom != (common.Address{}) {
+		return m.SimulationFrom, FromSourceOverride
+	}
+	if wallet := m.walletAddress(); wallet != "" {
+		return common.HexToAddress(wallet), FromSourceSigner
+	}
+	return withdrawalSender(message), FromSourceL2Sender
+}
+
+// withdrawalSender is who started message on L2: the from of a decoded bridge
+// withdrawal, else the sender of SentMessage, else that of MessagePassed
+func withdrawalSender(message Message) common.Address {
+	switch {
+	case message.TokenWithdrawal != nil:
+		return message.TokenWithdrawal.From
+	case message.SentMessageEvent != nil:
+		return message.SentMessageEvent.Sender
+	case message.MessagePassedEvent != nil:
+		return message.MessagePassedEvent.Sender
+	}
+	return common.Address{}
+}
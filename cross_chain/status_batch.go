@@ -0,0 +1,113 @@
+package crosschain
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultStatusConcurrency is how many withdrawals GetMessageStatusBatch reads at once
+// when no concurrency is given
+const DefaultStatusConcurrency = 8
+
+// MessageStatusReport is one withdrawal's status as read by GetMessageStatusBatch or
+// GetStatusByWithdrawalHash. The latter only knows the withdrawal hash, so it leaves the
+// fields it can't fill empty and names them in Missing.
+type MessageStatusReport struct {
+	TxHash         string              `json:"txHash,omitempty"`
+	WithdrawalHash string              `json:"withdrawalHash"`
+	L2BlockNumber  uint64              `json:"l2BlockNumber,omitempty"`
+	Status         int                 `json:"status"`
+	StatusName     string              `json:"statusName"`           // StatusDescription(Status)
+	Provenance     *ProofProvenance    `json:"provenance,omitempty"` // Output it was proven against; nil unless proven
+	FinalizeAt     *time.Time          `json:"finalizeAt,omitempty"` // When the challenge period ends; only set by GetStatusByWithdrawalHash
+	OutputWait     *OutputWaitEstimate `json:"outputWait,omitempty"` // Output a withdrawal not yet covered by one waits for; only set by GetMessageStatusBatch
+	Values         *WithdrawalValues   `json:"values,omitempty"`     // Amounts the withdrawal moves, raw and formatted; only set by GetMessageStatusBatch
+	Missing        []string            `json:"missing,omitempty"`    // Fields that could not be read, e.g. "txHash"
+}
+
+// GetMessageStatusBatch reads the status of many withdrawals with up to concurrency
+// (default DefaultStatusConcurrency) in flight at once. All of them share the
+// messenger's cached oracle and portal bindings and its output cache. Every distinct
+// hash ends up in exactly one of the maps: its report, or the error reading it, so a
+// few bad hashes don't hide the rest. Hashes not started when ctx is done get ctx's error.
+// Withdrawals waiting for an output get OutputWait; failing to estimate it only logs.
+func (m *CrossChainMessenger) GetMessageStatusBatch(ctx context.Context, txHashes []string, concurrency int) (map[string]MessageStatusReport, map[string]error) {
+	ctx, span := startSpan(ctx, "GetMessageStatusBatch", attrBatchSize.Int(len(txHashes)))
+	defer span.End()
+	if concurrency <= 0 {
+		concurrency = DefaultStatusConcurrency
+	}
+
+	var unique []string
+	seen := make(map[string]bool, len(txHashes))
+	for _, txHash := range txHashes {
+		if !seen[txHash] {
+			seen[txHash] = true
+			unique = append(unique, txHash)
+		}
+	}
+
+	reports := make(map[string]MessageStatusReport, len(unique))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, len(unique)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for txHash := range jobs {
+				message, err := m.GetMessages(ctx, txHash)
+				var outputWait *OutputWaitEstimate
+				var values WithdrawalValues
+				if err == nil {
+					values = m.WithdrawalValues(ctx, message)
+				}
+				if err == nil && message.Status == StatusReadyToProve {
+					if estimate, estErr := m.EstimateOutputWait(ctx, message.BlockNumber); estErr != nil {
+						m.logger().Warnf("⚠️  Failed to estimate output proposal for %s: %v", txHash, estErr)
+					} else if !estimate.Covered() {
+						outputWait = &estimate
+					}
+				}
+				mu.Lock()
+				if err != nil {
+					errs[txHash] = err
+				} else {
+					reports[txHash] = MessageStatusReport{
+						TxHash:         txHash,
+						WithdrawalHash: "0x" + message.WithdrawalHash,
+						L2BlockNumber:  message.BlockNumber,
+						Status:         message.Status,
+						StatusName:     StatusDescription(message.Status),
+						Provenance:     message.Provenance,
+						OutputWait:     outputWait,
+						Values:         &values,
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for i, txHash := range unique {
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			for _, skipped := range unique[i:] {
+				errs[skipped] = ctx.Err()
+			}
+			mu.Unlock()
+			break dispatch
+		case jobs <- txHash:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	span.SetAttributes(attrFailed.Int(len(errs)))
+	m.logger().Debugf("📋 Read the status of %d withdrawal(s), %d failed", len(reports), len(errs))
+	return reports, errs
+}
@@ -0,0 +1,50 @@
+package crosschain
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GetStatusByWithdrawalHash reads a withdrawal's status from OptimismPortal's
+// provenWithdrawals and finalizedWithdrawals alone, for when only the withdrawal hash is
+// known, e.g. from an explorer. Without the L2 transaction there is no block number,
+// values or relayed message, so the report leaves TxHash and L2BlockNumber empty and
+// lists them in Missing. The status is StatusProven or StatusFinalized as the portal
+// records it, and StatusUnknown otherwise: an unproven withdrawal looks the same as a
+// hash the portal never saw. A finalized withdrawal whose relayed message failed is
+// still StatusFinalized, with "relayResult" in Missing.
+func (m *CrossChainMessenger) GetStatusByWithdrawalHash(ctx context.Context, hash common.Hash) (_ MessageStatusReport, err error) {
+	ctx, span := startSpan(ctx, "GetStatusByWithdrawalHash", attrWithdrawalHash.String(hash.Hex()))
+	defer func() { endSpan(span, err) }()
+
+	report := MessageStatusReport{
+		WithdrawalHash: hash.Hex(),
+		Status:         StatusUnknown,
+		Missing:        []string{"txHash", "l2BlockNumber", "values"},
+	}
+	portal, err := m.readPortalWithdrawal(ctx, hash.Hex())
+	if err != nil {
+		return MessageStatusReport{}, err
+	}
+
+	if portal.proven {
+		provenAt := time.Unix(portal.provenTimestamp.Int64(), 0)
+		report.Status = StatusProven
+		report.Provenance = &ProofProvenance{
+			OutputIndex: portal.provenOutputIndex,
+			OutputRoot:  portal.provenOutputRoot,
+			ProvenAt:    provenAt,
+		}
+		finalizeAt := provenAt.Add(m.FinalizationParams(ctx).Period)
+		report.FinalizeAt = &finalizeAt
+	}
+	if portal.finalized {
+		report.Status = StatusFinalized
+		report.Missing = append(report.Missing, "relayResult")
+	}
+	report.StatusName = StatusDescription(report.Status)
+	span.SetAttributes(attrStatus.Int(report.Status))
+	return report, nil
+}
@@ -0,0 +1,282 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TimelineStage is a step in the life of a withdrawal
+type TimelineStage string
+
+// Timeline stages, in the order a withdrawal goes through them
+const (
+	StageFirstSeen         TimelineStage = "first_seen"         // Picked up by the scheduler; the L2 withdrawal transaction
+	StageProvable          TimelineStage = "provable"           // The first L2 output covering it was proposed; the L1 proposal
+	StageProveSubmitted    TimelineStage = "prove_submitted"    // Our prove was sent; the L1 transaction, not mined yet
+	StageProveMined        TimelineStage = "prove_mined"        // The prove was mined; its L1 transaction
+	StageFinalizeEligible  TimelineStage = "finalize_eligible"  // The challenge period ended
+	StageFinalizeSubmitted TimelineStage = "finalize_submitted" // Our finalize was sent; the L1 transaction, not mined yet
+	StageFinalizeMined     TimelineStage = "finalize_mined"     // The finalize was mined; its L1 transaction
+)
+
+// timelineStages orders a Timeline
+var timelineStages = []TimelineStage{
+	StageFirstSeen, StageProvable, StageProveSubmitted, StageProveMined,
+	StageFinalizeEligible, StageFinalizeSubmitted, StageFinalizeMined,
+}
+
+// timelineSearchBlocks caps how many L1 blocks past the end of the challenge period
+// WithdrawalTimeline scans for the finalize transaction, about a week
+const timelineSearchBlocks = 50_000
+
+// TimelineEvent is when a withdrawal reached a stage, with the transaction behind it
+type TimelineEvent struct {
+	Stage    TimelineStage `json:"stage"`
+	At       time.Time     `json:"at"`
+	Chain    string        `json:"chain,omitempty"`    // "L1" or "L2": where TxHash and Block are
+	TxHash   string        `json:"txHash,omitempty"`   // Empty when not known or there is none, e.g. finalize_eligible
+	Block    uint64        `json:"block,omitempty"`    // Block of TxHash; 0 while not mined or not known
+	Inferred bool          `json:"inferred,omitempty"` // Reconstructed from chain data afterwards rather than seen as it happened
+}
+
+// Timeline is the stages a withdrawal has reached, in stage order
+type Timeline []TimelineEvent
+
+// Event returns the event of stage, if the withdrawal reached it
+func (t Timeline) Event(stage TimelineStage) (TimelineEvent, bool) {
+	for _, ev := range t {
+		if ev.Stage == stage {
+			return ev, true
+		}
+	}
+	return TimelineEvent{}, false
+}
+
+// With returns a copy of t with ev recorded. An event of the same stage is replaced when
+// ev names a different transaction (e.g. a re-prove) or the old one was inferred;
+// otherwise the old one is kept and only its missing details are taken from ev.
+func (t Timeline) With(ev TimelineEvent) Timeline {
+	out := slices.Clone(t)
+	for i, old := range out {
+		if old.Stage != ev.Stage {
+			continue
+		}
+		switch {
+		case old.TxHash != "" && ev.TxHash != "" && !strings.EqualFold(old.TxHash, ev.TxHash):
+			out[i] = ev
+		case old.Inferred:
+			out[i] = fillEvent(ev, old)
+		default:
+			out[i] = fillEvent(old, ev)
+		}
+		return out
+	}
+	out = append(out, ev)
+	slices.SortStableFunc(out, func(a, b TimelineEvent) int {
+		return slices.Index(timelineStages, a.Stage) - slices.Index(timelineStages, b.Stage)
+	})
+	return out
+}
+
+// Merge returns a copy of t with every event of other recorded as by With
+func (t Timeline) Merge(other Timeline) Timeline {
+	out := slices.Clone(t)
+	for _, ev := range other {
+		out = out.With(ev)
+	}
+	return out
+}
+
+// fillEvent completes ev with whatever it lacks from fallback about the same transaction
+func fillEvent(ev, fallback TimelineEvent) TimelineEvent {
+	if ev.At.IsZero() {
+		ev.At = fallback.At
+	}
+	if ev.TxHash == "" {
+		ev.TxHash, ev.Chain, ev.Block = fallback.TxHash, fallback.Chain, fallback.Block
+	}
+	if ev.Block == 0 && strings.EqualFold(ev.TxHash, fallback.TxHash) {
+		ev.Block = fallback.Block
+	}
+	return ev
+}
+
+// WithdrawalTimeline reconstructs what it can of txHash's timeline from chain data alone,
+// every event marked Inferred: first_seen is the L2 block time, provable the proposal of
+// the first covering output, prove_mined the proven timestamp OptimismPortal recorded and
+// finalize_mined the WithdrawalFinalized event. Submission times are only known to
+// whoever sent the transactions, so they are missing. Lookups that fail are logged and
+// leave their stage out; only failing to read the withdrawal itself is an error.
+func (m *CrossChainMessenger) WithdrawalTimeline(ctx context.Context, txHash string) (_ Timeline, err error) {
+	ctx, span := startSpan(ctx, "WithdrawalTimeline", attrTxHash.String(txHash))
+	defer func() { endSpan(span, err) }()
+	message, err := m.getMessages(ctx, txHash)
+	var confErr *ConfirmationsError
+	if err != nil && !errors.As(err, &confErr) {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	var timeline Timeline
+	if at, err := m.blockTime(ctx, "L2", message.BlockNumber); err != nil {
+		m.logger().Warnf("⚠️  %v", err)
+	} else {
+		timeline = timeline.With(TimelineEvent{Stage: StageFirstSeen, At: at, Chain: "L2",
+			TxHash: txHash, Block: message.BlockNumber, Inferred: true})
+	}
+	if confErr != nil {
+		return timeline, nil
+	}
+
+	if ev, ok, err := m.provableEvent(ctx, message.BlockNumber); err != nil {
+		m.logger().Warnf("⚠️  Failed to find the output covering L2 block %d: %v", message.BlockNumber, err)
+	} else if ok {
+		timeline = timeline.With(ev)
+	}
+	if message.Status < StatusProven {
+		return timeline, nil
+	}
+
+	provenAt, err := m.ProvenAt(ctx, message.WithdrawalHash)
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to read the proven timestamp: %v", err)
+		return timeline, nil
+	}
+	if provenAt.IsZero() {
+		return timeline, nil
+	}
+	proved := TimelineEvent{Stage: StageProveMined, At: provenAt, Chain: "L1", Inferred: true}
+	if proveTx, block, err := m.findProveEvent(ctx, message.WithdrawalHash, provenAt); err != nil {
+		m.logger().Warnf("⚠️  Failed to find the prove transaction: %v", err)
+	} else if proveTx != (common.Hash{}) {
+		proved.TxHash, proved.Block = proveTx.Hex(), block
+	}
+	timeline = timeline.With(proved)
+
+	finalizeAt := provenAt.Add(m.FinalizationParams(ctx).Period)
+	if FinalizedAtPortal(message.Status) || !time.Now().Before(finalizeAt) {
+		timeline = timeline.With(TimelineEvent{Stage: StageFinalizeEligible, At: finalizeAt, Inferred: true})
+	}
+	if FinalizedAtPortal(message.Status) {
+		if ev, ok, err := m.finalizedEvent(ctx, message.WithdrawalHash, finalizeAt); err != nil {
+			m.logger().Warnf("⚠️  Failed to find the finalize transaction: %v", err)
+		} else if ok {
+			timeline = timeline.With(ev)
+		}
+	}
+	return timeline, nil
+}
+
+// provableEvent returns when the first output covering l2Block was proposed; ok is false
+// while none is
+func (m *CrossChainMessenger) provableEvent(ctx context.Context, l2Block uint64) (TimelineEvent, bool, error) {
+	latest, err := m.GetLatestProposedL2Block(ctx)
+	if err != nil || latest < l2Block {
+		return TimelineEvent{}, false, err
+	}
+	index, err := m.getL2OutputIndex(ctx, m.Contracts.L1.L2OutputOracle, l2Block)
+	if err != nil {
+		return TimelineEvent{}, false, err
+	}
+	output, err := m.getL2OutputData(ctx, m.Contracts.L1.L2OutputOracle, index)
+	if err != nil {
+		return TimelineEvent{}, false, fmt.Errorf("failed to call getL2Output: %w", err)
+	}
+	ev := TimelineEvent{Stage: StageProvable, At: time.Unix(output.Timestamp.Int64(), 0), Chain: "L1", Inferred: true}
+
+	// The proposal transaction is a nicety; the oracle's timestamp is what matters
+	head, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return ev, true, nil
+	}
+	estimate := l1BlockAt(head, ev.At)
+	fromBlock := estimate - min(estimate, proveTxSearchBlocks)
+	toBlock := min(estimate+proveTxSearchBlocks, head)
+	oracle, err := cross_abi.NewL2OutputOracleFilterer(common.HexToAddress(m.Contracts.L1.L2OutputOracle), m.ClientL1)
+	if err != nil {
+		return ev, true, nil
+	}
+	iter, err := withRetry(ctx, m, "L1 eth_getLogs", func(ctx context.Context) (*cross_abi.L2OutputOracleOutputProposedIterator, error) {
+		return oracle.FilterOutputProposed(&bind.FilterOpts{Start: fromBlock, End: &toBlock, Context: ctx},
+			nil, []*big.Int{new(big.Int).SetUint64(index)}, nil)
+	})
+	if err != nil {
+		m.logger().Debugf("Failed to find the proposal of output #%d: %v", index, err)
+		return ev, true, nil
+	}
+	defer iter.Close()
+	for iter.Next() {
+		ev.TxHash, ev.Block = iter.Event.Raw.TxHash.Hex(), iter.Event.Raw.BlockNumber
+	}
+	return ev, true, nil
+}
+
+// finalizedEvent looks for the WithdrawalFinalized event of withdrawalHash from the end
+// of the challenge period on, in timelineSearchBlocks at most; ok is false if none is found
+func (m *CrossChainMessenger) finalizedEvent(ctx context.Context, withdrawalHash string, finalizeAt time.Time) (TimelineEvent, bool, error) {
+	head, err := m.GetLatestL1Block(ctx)
+	if err != nil {
+		return TimelineEvent{}, false, fmt.Errorf("failed to get latest L1 block: %w", err)
+	}
+	estimate := l1BlockAt(head, finalizeAt)
+	fromBlock := estimate - min(estimate, proveTxSearchBlocks)
+	lastBlock := min(estimate+timelineSearchBlocks, head)
+
+	portal, err := cross_abi.NewOptimismPortalFilterer(common.HexToAddress(m.Contracts.L1.OptimismPortal), m.ClientL1)
+	if err != nil {
+		return TimelineEvent{}, false, fmt.Errorf("failed to create OptimismPortal filterer: %w", err)
+	}
+	for start := fromBlock; start <= lastBlock; start += discoveryChunkSize {
+		end := min(start+discoveryChunkSize-1, lastBlock)
+		iter, err := withRetry(ctx, m, "L1 eth_getLogs", func(ctx context.Context) (*cross_abi.OptimismPortalWithdrawalFinalizedIterator, error) {
+			return portal.FilterWithdrawalFinalized(&bind.FilterOpts{Start: start, End: &end, Context: ctx},
+				[][32]byte{common.HexToHash(withdrawalHash)})
+		})
+		if err != nil {
+			return TimelineEvent{}, false, fmt.Errorf("failed to filter WithdrawalFinalized events in blocks %d-%d: %w", start, end, err)
+		}
+		found := iter.Next()
+		raw := iter.Event
+		iterErr := iter.Error()
+		iter.Close()
+		if iterErr != nil {
+			return TimelineEvent{}, false, fmt.Errorf("failed to read WithdrawalFinalized events in blocks %d-%d: %w", start, end, iterErr)
+		}
+		if !found {
+			continue
+		}
+
+		at, err := m.blockTime(ctx, "L1", raw.Raw.BlockNumber)
+		if err != nil {
+			return TimelineEvent{}, false, err
+		}
+		return TimelineEvent{Stage: StageFinalizeMined, At: at, Chain: "L1", TxHash: raw.Raw.TxHash.Hex(),
+			Block: raw.Raw.BlockNumber, Inferred: true}, true, nil
+	}
+	return TimelineEvent{}, false, nil
+}
+
+// blockTime returns the timestamp of block on layer, "L1" or "L2"
+func (m *CrossChainMessenger) blockTime(ctx context.Context, layer string, block uint64) (time.Time, error) {
+	client := m.ClientL1
+	if layer == "L2" {
+		client = m.ClientL2
+	}
+	header, err := withRetry(ctx, m, layer+" eth_getBlockByNumber", func(ctx context.Context) (*types.Header, error) {
+		return client.HeaderByNumber(ctx, new(big.Int).SetUint64(block))
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read the time of %s block %d: %w", layer, block, err)
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
@@ -0,0 +1,115 @@
+package crosschain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Token kinds a bridge withdrawal can release on L1
+const (
+	TokenKindERC20 = "ERC20"
+	TokenKindETH   = "ETH"
+	TokenKindMNT   = "MNT"
+)
+
+// TokenWithdrawalInfo is what the L1StandardBridge will release for a withdrawal,
+// decoded from the finalize call the L2 bridge sent through the messenger
+type TokenWithdrawalInfo struct {
+	Kind    string         // TokenKindERC20, TokenKindETH or TokenKindMNT
+	Method  string         // L1StandardBridge method the message calls
+	L1Token common.Address // Zero for ETH and MNT
+	L2Token common.Address // Zero for ETH and MNT
+	From    common.Address
+	To      common.Address
+	Amount  *big.Int // In the token's smallest unit
+}
+
+// AmountString renders the amount; ERC20 amounts stay in base units since the token's
+// decimals aren't known here (see WithdrawalValues), ETH and MNT are shown exactly in
+// whole units
+func (t *TokenWithdrawalInfo) AmountString() string {
+	if t.Kind == TokenKindERC20 {
+		return t.Amount.String()
+	}
+	return NewAmount(t.Amount, nativeDecimals, t.Kind).String()
+}
+
+// String renders the withdrawal as e.g. "1000000 of ERC20 0x… (L2 0x…) from 0x… to 0x…"
+func (t *TokenWithdrawalInfo) String() string {
+	if t.Kind == TokenKindERC20 {
+		return fmt.Sprintf("%s of ERC20 %s (L2 %s) from %s to %s",
+			t.AmountString(), t.L1Token.Hex(), t.L2Token.Hex(), t.From.Hex(), t.To.Hex())
+	}
+	return fmt.Sprintf("%s from %s to %s", t.AmountString(), t.From.Hex(), t.To.Hex())
+}
+
+// l1BridgeFinalizeABI holds the L1StandardBridge finalize methods the L2 bridge targets
+const l1BridgeFinalizeABI = `[
+	{"type":"function","name":"finalizeERC20Withdrawal","inputs":[{"name":"_l1Token","type":"address"},{"name":"_l2Token","type":"address"},{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}]},
+	{"type":"function","name":"finalizeBridgeERC20","inputs":[{"name":"_localToken","type":"address"},{"name":"_remoteToken","type":"address"},{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}]},
+	{"type":"function","name":"finalizeETHWithdrawal","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}]},
+	{"type":"function","name":"finalizeBridgeETH","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}]},
+	{"type":"function","name":"finalizeMantleWithdrawal","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}]},
+	{"type":"function","name":"finalizeBridgeMNT","inputs":[{"name":"_from","type":"address"},{"name":"_to","type":"address"},{"name":"_amount","type":"uint256"},{"name":"_extraData","type":"bytes"}]}
+]`
+
+var l1BridgeFinalize = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(l1BridgeFinalizeABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid L1StandardBridge finalize ABI: %v", err))
+	}
+	return parsed
+}()
+
+// nativeTokenKinds maps the native-asset finalize methods to the asset they release
+var nativeTokenKinds = map[string]string{
+	"finalizeETHWithdrawal":    TokenKindETH,
+	"finalizeBridgeETH":        TokenKindETH,
+	"finalizeMantleWithdrawal": TokenKindMNT,
+	"finalizeBridgeMNT":        TokenKindMNT,
+}
+
+// decodeTokenWithdrawal decodes the bridge finalize call carried by a SentMessage event.
+// It returns nil when the message doesn't target the L1StandardBridge or isn't a
+// finalize call we know, e.g. for plain messenger withdrawals.
+func (m *CrossChainMessenger) decodeTokenWithdrawal(event *cross_abi.L2CrossDomainMessengerSentMessage) *TokenWithdrawalInfo {
+	if event == nil || len(event.Message) < 4 {
+		return nil
+	}
+	if event.Target != common.HexToAddress(m.Contracts.L1.L1StandardBridge) {
+		return nil
+	}
+
+	method, err := l1BridgeFinalize.MethodById(event.Message[:4])
+	if err != nil {
+		m.logger().Debugf("  📦 Bridge message selector 0x%x is not a known finalize method", event.Message[:4])
+		return nil
+	}
+	args, err := method.Inputs.Unpack(event.Message[4:])
+	if err != nil {
+		m.logger().Warnf("⚠️  Failed to decode %s arguments: %v", method.Name, err)
+		return nil
+	}
+
+	info := &TokenWithdrawalInfo{Method: method.Name}
+	if kind, ok := nativeTokenKinds[method.Name]; ok {
+		info.Kind = kind
+	} else {
+		info.Kind = TokenKindERC20
+		info.L1Token = *abi.ConvertType(args[0], new(common.Address)).(*common.Address)
+		info.L2Token = *abi.ConvertType(args[1], new(common.Address)).(*common.Address)
+		args = args[2:]
+	}
+	info.From = *abi.ConvertType(args[0], new(common.Address)).(*common.Address)
+	info.To = *abi.ConvertType(args[1], new(common.Address)).(*common.Address)
+	info.Amount = *abi.ConvertType(args[2], new(*big.Int)).(**big.Int)
+
+	m.logger().Debugf("  📦 Decoded %s: %s", method.Name, info)
+	return info
+}
@@ -0,0 +1,62 @@
+package crosschain
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer starts the messenger's spans. Until internal/tracing installs a provider
+// (OTEL_EXPORTER_OTLP_ENDPOINT is set) the global one is a no-op, so spans cost
+// next to nothing.
+var tracer = otel.Tracer("mantle-claim-crossing/cross_chain")
+
+// Span attribute keys
+const (
+	attrTxHash         = attribute.Key("tx.hash")         // The L2 withdrawal transaction
+	attrMessageIndex   = attribute.Key("message.index")   // Which of its messages
+	attrWithdrawalHash = attribute.Key("withdrawal.hash") // The message's withdrawal hash
+	attrL2Block        = attribute.Key("l2.block")
+	attrOutputIndex    = attribute.Key("output.index")
+	attrLayer          = attribute.Key("layer")   // "L1" or "L2"
+	attrSentTx         = attribute.Key("sent.tx") // An L1 transaction we sent
+	attrLogs           = attribute.Key("logs")
+	attrAttempts       = attribute.Key("attempts") // Including fee-bumped replacements
+	attrFrom           = attribute.Key("from")
+	attrNonce          = attribute.Key("nonce")
+	attrGasUsed        = attribute.Key("gas.used")
+	attrBlock          = attribute.Key("block")
+	attrStatus         = attribute.Key("status")
+	attrBatchSize      = attribute.Key("batch.size")
+	attrFailed         = attribute.Key("failed")
+	attrFromBlock      = attribute.Key("from.block")
+	attrToBlock        = attribute.Key("to.block")
+)
+
+// startSpan starts a span as a child of whatever span ctx carries
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends span, marking it failed with err when there is one
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// annotateSpan adds attrs to the span ctx carries, e.g. the withdrawal hash once a
+// public method has parsed it
+func annotateSpan(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// messageAttrs identifies the message a span works on
+func messageAttrs(txHash string, messageIndex int) []attribute.KeyValue {
+	return []attribute.KeyValue{attrTxHash.String(txHash), attrMessageIndex.Int(messageIndex)}
+}
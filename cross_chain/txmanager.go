@@ -0,0 +1,336 @@
+package crosschain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Defaults for stuck transaction replacement
+const (
+	DefaultReplacementTimeout = 10 * time.Minute
+	DefaultFeeBumpPercent     = 20
+
+	// minFeeBumpPercent is the smallest bump nodes accept for a same-nonce replacement
+	minFeeBumpPercent = 10
+)
+
+// receiptPollInterval is how often pending transactions are checked for a receipt; tests
+// shorten it
+var receiptPollInterval = 5 * time.Second
+
+// ReplacementPolicy controls re-submission of transactions that aren't mined in time
+type ReplacementPolicy struct {
+	Timeout        time.Duration // Wait this long for each attempt before bumping fees; 0 disables replacement
+	FeeBumpPercent int           // Fee increase per replacement (minimum 10)
+	MaxFee         *big.Int      // Ceiling for the bumped max fee per gas in wei; nil means no ceiling
+}
+
+// sendTransaction assigns the next nonce for opts.From and sends the transaction built by
+// send. Submissions from one messenger are serialized here so concurrent prove/finalize
+// calls from the same wallet never pick the same nonce; receipts are still awaited in parallel.
+func (m *CrossChainMessenger) sendTransaction(ctx context.Context, opts *bind.TransactOpts, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+	return m.sendLocked(ctx, opts, send)
+}
+
+// sendLocked is sendTransaction for callers already holding sendMu, so a batch can
+// submit several transactions with consecutive nonces without interleaving
+func (m *CrossChainMessenger) sendLocked(ctx context.Context, opts *bind.TransactOpts, send func(opts *bind.TransactOpts) (*types.Transaction, error)) (_ *types.Transaction, err error) {
+	ctx, span := startSpan(ctx, "sendTransaction", attrFrom.String(opts.From.Hex()))
+	defer func() { endSpan(span, err) }()
+	pending, err := m.ClientL1.PendingNonceAt(ctx, opts.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+	// The node may not have seen our previous submission yet
+	nonce := max(pending, m.nextNonce[opts.From])
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+	span.SetAttributes(attrNonce.Int64(int64(nonce)))
+
+	tx, err := send(opts)
+	if err != nil {
+		return nil, err
+	}
+	if m.nextNonce == nil {
+		m.nextNonce = make(map[common.Address]uint64)
+	}
+	m.nextNonce[opts.From] = nonce + 1
+	span.SetAttributes(attrSentTx.String(tx.Hash().Hex()))
+	m.logger().Debugf("📨 Sent %s with nonce %d", tx.Hash().Hex(), nonce)
+	return tx, nil
+}
+
+// resetNonce forgets the locally tracked nonces so the next submission asks the node again,
+// used when a sent transaction's fate is unknown (e.g. waiting for it timed out)
+func (m *CrossChainMessenger) resetNonce() {
+	m.sendMu.Lock()
+	m.nextNonce = nil
+	m.sendMu.Unlock()
+}
+
+// waitMined waits for tx to be mined on L1, bounded by Timeouts.WaitMined. If no attempt
+// is mined within Replacement.Timeout, the transaction is re-signed with opts at the same
+// nonce with bumped fees, and whichever attempt is mined first wins. When another
+// transaction takes the nonce instead, e.g. one sent by cancel-pending, it returns
+// ErrNonceReplaced rather than waiting out the timeout. Once mined, the receipt is only
+// returned after L1Confirmations blocks were built on top of it; an attempt a reorg drops
+// meanwhile is sent again and waited for like before.
+func (m *CrossChainMessenger) waitMined(ctx context.Context, tx *types.Transaction, opts *bind.TransactOpts) (_ *types.Receipt, err error) {
+	ctx, span := startSpan(ctx, "waitMined", attrSentTx.String(tx.Hash().Hex()))
+	defer func() { endSpan(span, err) }()
+	waitCtx, cancel := withOptionalTimeout(ctx, m.Timeouts.WaitMined)
+	defer cancel()
+
+	attempts := []*types.Transaction{tx}
+	deadline := m.nextReplacementDeadline()
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	started := time.Now()
+	var reported time.Time
+
+	for {
+		if time.Since(reported) >= progressWaitInterval {
+			reported = time.Now()
+			m.progressWaiting(ctx, attempts[len(attempts)-1], time.Since(started))
+		}
+		if receipt, attempt := m.minedAttempt(waitCtx, attempts); receipt != nil {
+			if attempt != tx {
+				m.logger().Infof("🔁 Replacement %s was mined instead of %s", attempt.Hash().Hex(), tx.Hash().Hex())
+			}
+			receipt, err := m.awaitL1Confirmations(waitCtx, receipt, attempt)
+			if err != nil {
+				return nil, asTimeout(ctx, waitCtx, fmt.Sprintf("waiting for %s to be confirmed", attempt.Hash().Hex()), m.Timeouts.WaitMined, err)
+			}
+			if receipt != nil {
+				span.SetAttributes(attrSentTx.String(attempt.Hash().Hex()), attrGasUsed.Int64(int64(receipt.GasUsed)),
+					attrBlock.Int64(receipt.BlockNumber.Int64()), attrAttempts.Int(len(attempts)))
+				return receipt, nil
+			}
+			continue
+		}
+		// Checked after the receipts, so an attempt mined between the two reads is
+		// found on the next poll instead of being mistaken for a foreign transaction
+		if m.nonceUsed(waitCtx, tx, opts) {
+			if receipt, _ := m.minedAttempt(waitCtx, attempts); receipt == nil {
+				m.logger().Warnf("⚠️  Nonce %d of %s was taken by another transaction", tx.Nonce(), tx.Hash().Hex())
+				return nil, fmt.Errorf("%w: %s and its replacements were not mined at nonce %d", ErrNonceReplaced, tx.Hash().Hex(), tx.Nonce())
+			}
+			continue
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) && opts != nil {
+			latest := attempts[len(attempts)-1]
+			replacement, err := m.replaceTransaction(waitCtx, latest, opts)
+			switch {
+			case err != nil:
+				m.logger().Warnf("⚠️  Could not replace stuck transaction %s: %v", latest.Hash().Hex(), err)
+			case replacement != nil:
+				attempts = append(attempts, replacement)
+				m.progress(ctx, ProgressReplaced, map[string]any{"l1TxHash": replacement.Hash().Hex(), "replaces": latest.Hash().Hex()})
+			}
+			deadline = m.nextReplacementDeadline()
+		}
+
+		select {
+		case <-waitCtx.Done():
+			return nil, asTimeout(ctx, waitCtx, fmt.Sprintf("waiting for %s to be mined", tx.Hash().Hex()), m.Timeouts.WaitMined, waitCtx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// awaitL1Confirmations waits until receipt, of attempt, has L1Confirmations blocks on
+// top, re-reading the receipt on every poll. It returns the receipt as of then, which a
+// reorg may have moved to another block, or nil when the transaction was dropped, after
+// sending attempt again (nil attempt: just reporting it) so the caller can wait anew.
+func (m *CrossChainMessenger) awaitL1Confirmations(ctx context.Context, receipt *types.Receipt, attempt *types.Transaction) (*types.Receipt, error) {
+	if m.L1Confirmations == 0 {
+		return receipt, nil
+	}
+	hash := receipt.TxHash
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+	reported := false
+
+	for {
+		current, err := m.ClientL1.TransactionReceipt(ctx, hash)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			m.logger().Warnf("⛓️  %s vanished from block %d in an L1 reorg", hash.Hex(), receipt.BlockNumber.Uint64())
+			if attempt != nil {
+				if err := m.ClientL1.SendTransaction(ctx, attempt); err != nil {
+					// Usually "already known": the node put it back in its pool itself
+					m.logger().Debugf("⚠️  Re-sending %s: %v", hash.Hex(), err)
+				} else {
+					m.logger().Infof("🔁 Re-sent %s", hash.Hex())
+				}
+			}
+			return nil, nil
+		case err != nil:
+			m.logger().Debugf("⚠️  Receipt lookup for %s failed: %v", hash.Hex(), err)
+		default:
+			if current.BlockHash != receipt.BlockHash {
+				m.logger().Warnf("⛓️  %s moved from block %d to %d in an L1 reorg", hash.Hex(),
+					receipt.BlockNumber.Uint64(), current.BlockNumber.Uint64())
+			}
+			receipt = current
+			head, err := m.ClientL1.BlockNumber(ctx)
+			if err != nil {
+				m.logger().Debugf("⚠️  L1 head lookup failed: %v", err)
+				break
+			}
+			block := receipt.BlockNumber.Uint64()
+			var confirmations uint64
+			if head > block {
+				confirmations = head - block
+			}
+			if confirmations >= m.L1Confirmations {
+				return receipt, nil
+			}
+			if !reported {
+				reported = true
+				m.logger().Infof("⛓️  Mined in block %d; waiting for %d L1 confirmations", block, m.L1Confirmations)
+				m.progress(ctx, ProgressConfirming, map[string]any{
+					"l1TxHash": hash.Hex(), "blockNumber": block, "confirmations": confirmations, "required": m.L1Confirmations})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// minedAttempt returns the receipt of whichever of attempts was mined, with that attempt,
+// or nil while none was
+func (m *CrossChainMessenger) minedAttempt(ctx context.Context, attempts []*types.Transaction) (*types.Receipt, *types.Transaction) {
+	for _, attempt := range attempts {
+		receipt, err := m.ClientL1.TransactionReceipt(ctx, attempt.Hash())
+		if err == nil {
+			return receipt, attempt
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			m.logger().Debugf("⚠️  Receipt lookup for %s failed: %v", attempt.Hash().Hex(), err)
+		}
+	}
+	return nil, nil
+}
+
+// nonceUsed reports whether a transaction at tx's nonce was mined, i.e. the sender's
+// nonce at the latest block has moved past it. Read errors count as not used.
+func (m *CrossChainMessenger) nonceUsed(ctx context.Context, tx *types.Transaction, opts *bind.TransactOpts) bool {
+	var from common.Address
+	if opts != nil {
+		from = opts.From
+	} else {
+		sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return false
+		}
+		from = sender
+	}
+	latest, err := m.ClientL1.NonceAt(ctx, from, nil)
+	if err != nil {
+		m.logger().Debugf("⚠️  Nonce lookup for %s failed: %v", from.Hex(), err)
+		return false
+	}
+	return latest > tx.Nonce()
+}
+
+// nextReplacementDeadline returns when the current attempt counts as stuck, or the zero
+// time when replacement is disabled
+func (m *CrossChainMessenger) nextReplacementDeadline() time.Time {
+	if m.Replacement.Timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(m.Replacement.Timeout)
+}
+
+// replaceTransaction re-signs tx at the same nonce with fees bumped by FeeBumpPercent.
+// It returns nil without error when the fee ceiling leaves no room to bump.
+func (m *CrossChainMessenger) replaceTransaction(ctx context.Context, tx *types.Transaction, opts *bind.TransactOpts) (*types.Transaction, error) {
+	replacement := m.bumpedTx(tx)
+	if replacement == nil {
+		m.logger().Warnf("⛽ %s is stuck at max fee %s gwei and the ceiling is %s gwei; still waiting",
+			tx.Hash().Hex(), formatGwei(tx.GasFeeCap()), formatGwei(m.Replacement.MaxFee))
+		return nil, nil
+	}
+	signed, err := opts.Signer(opts.From, replacement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign replacement: %w", err)
+	}
+	if err := m.ClientL1.SendTransaction(ctx, signed); err != nil {
+		return nil, fmt.Errorf("failed to send replacement: %w", err)
+	}
+
+	m.logger().Warnf("🔁 %s not mined after %s; replaced with %s (nonce %d, max fee %s gwei, priority fee %s gwei)",
+		tx.Hash().Hex(), m.Replacement.Timeout, signed.Hash().Hex(), signed.Nonce(), formatGwei(signed.GasFeeCap()), formatGwei(signed.GasTipCap()))
+	return signed, nil
+}
+
+// bumpedTx returns tx unsigned with its fees raised by FeeBumpPercent, the max fee capped
+// at Replacement.MaxFee, or nil when the ceiling leaves no room for the minimum bump
+// nodes accept for a same-nonce replacement
+func (m *CrossChainMessenger) bumpedTx(tx *types.Transaction) *types.Transaction {
+	percent := m.Replacement.FeeBumpPercent
+	if percent < minFeeBumpPercent {
+		percent = minFeeBumpPercent
+	}
+	feeCap := bumpFee(tx.GasFeeCap(), percent)
+	tipCap := bumpFee(tx.GasTipCap(), percent)
+
+	if ceiling := m.Replacement.MaxFee; ceiling != nil && feeCap.Cmp(ceiling) > 0 {
+		// Nodes reject replacements that don't raise both fees by the minimum bump
+		if bumpFee(tx.GasFeeCap(), minFeeBumpPercent).Cmp(ceiling) > 0 {
+			return nil
+		}
+		feeCap = new(big.Int).Set(ceiling)
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap = new(big.Int).Set(feeCap)
+	}
+	return repricedTx(tx, feeCap, tipCap)
+}
+
+// repricedTx returns tx unsigned with feeCap and tipCap; a legacy transaction's fee cap
+// and tip are both its gas price, so it gets feeCap
+func repricedTx(tx *types.Transaction, feeCap, tipCap *big.Int) *types.Transaction {
+	if tx.Type() == types.LegacyTxType {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: feeCap,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		})
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   tx.ChainId(),
+		Nonce:     tx.Nonce(),
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       tx.Gas(),
+		To:        tx.To(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	})
+}
+
+// bumpFee returns fee increased by percent, rounded up
+func bumpFee(fee *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(int64(100+percent)))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
@@ -0,0 +1,370 @@
+package crosschain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// minerL1 is an L1 node that mines a sent transaction only when mine says so, at the
+// current head
+type minerL1 struct {
+	EthClient
+
+	mine    func(tx *types.Transaction) bool // nil mines nothing
+	step    uint64                           // Blocks built per eth_blockNumber call
+	reorgAt uint64                           // Head at which every mined transaction is dropped; 0 never
+
+	mu       sync.Mutex
+	head     uint64
+	nonce    uint64 // Sender's nonce at the latest block
+	sent     []*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newMinerL1() *minerL1 {
+	return &minerL1{head: 100, receipts: make(map[common.Hash]*types.Receipt)}
+}
+
+func (f *minerL1) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, tx)
+	if f.mine != nil && f.mine(tx) {
+		f.receipts[tx.Hash()] = &types.Receipt{
+			Status:      types.ReceiptStatusSuccessful,
+			TxHash:      tx.Hash(),
+			BlockNumber: new(big.Int).SetUint64(f.head),
+			BlockHash:   common.BigToHash(new(big.Int).SetUint64(f.head)),
+		}
+		f.nonce = tx.Nonce() + 1
+	}
+	return nil
+}
+
+func (f *minerL1) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if receipt, ok := f.receipts[hash]; ok {
+		copied := *receipt
+		return &copied, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *minerL1) NonceAt(ctx context.Context, account common.Address, block *big.Int) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nonce, nil
+}
+
+func (f *minerL1) BlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head += f.step
+	if f.reorgAt != 0 && f.head == f.reorgAt {
+		clear(f.receipts)
+		f.nonce = 0
+	}
+	return f.head, nil
+}
+
+// sentTxs returns the transactions sent so far
+func (f *minerL1) sentTxs() []*types.Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*types.Transaction(nil), f.sent...)
+}
+
+// fastReceiptPolls makes waitMined poll every few milliseconds for the rest of the test
+func fastReceiptPolls(t *testing.T) {
+	t.Helper()
+	saved := receiptPollInterval
+	receiptPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { receiptPollInterval = saved })
+}
+
+// sendStuckTx signs and sends a transaction at nonce 7 paying feeCap and tipCap gwei,
+// returning it with the options waitMined re-signs replacements with
+func sendStuckTx(t *testing.T, l1 *minerL1, feeCap, tipCap int64) (*types.Transaction, *bind.TransactOpts) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := transactor(t, key)
+	to := common.HexToAddress(DefaultContracts().L1.OptimismPortal)
+	tx, err := opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     7,
+		GasTipCap: big.NewInt(tipCap * 1e9),
+		GasFeeCap: big.NewInt(feeCap * 1e9),
+		Gas:       200000,
+		To:        &to,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l1.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	return tx, opts
+}
+
+func transactor(t *testing.T, key *ecdsa.PrivateKey) *bind.TransactOpts {
+	t.Helper()
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+func TestWaitMinedReplacesStuckTransaction(t *testing.T) {
+	fastReceiptPolls(t)
+	l1 := newMinerL1()
+	tx, opts := sendStuckTx(t, l1, 10, 1)
+	// The first attempt is never mined; any replacement is
+	l1.mine = func(sent *types.Transaction) bool { return sent.Hash() != tx.Hash() }
+	m := &CrossChainMessenger{
+		ClientL1:    l1,
+		Replacement: ReplacementPolicy{Timeout: time.Millisecond, FeeBumpPercent: 20},
+		Timeouts:    Timeouts{WaitMined: 10 * time.Second},
+	}
+
+	receipt, err := m.waitMined(context.Background(), tx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := l1.sentTxs()
+	if len(sent) != 2 {
+		t.Fatalf("%d transactions sent, want the stuck one and one replacement", len(sent))
+	}
+	replacement := sent[1]
+	if receipt.TxHash != replacement.Hash() {
+		t.Fatalf("waitMined() returned the receipt of %s, want the replacement %s", receipt.TxHash.Hex(), replacement.Hash().Hex())
+	}
+	if replacement.Nonce() != tx.Nonce() {
+		t.Fatalf("replacement nonce = %d, want %d", replacement.Nonce(), tx.Nonce())
+	}
+	if want := bumpFee(tx.GasFeeCap(), 20); replacement.GasFeeCap().Cmp(want) != 0 {
+		t.Fatalf("replacement max fee = %s, want %s (+20%%)", replacement.GasFeeCap(), want)
+	}
+	if want := bumpFee(tx.GasTipCap(), 20); replacement.GasTipCap().Cmp(want) != 0 {
+		t.Fatalf("replacement priority fee = %s, want %s (+20%%)", replacement.GasTipCap(), want)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), replacement)
+	if err != nil || from != opts.From {
+		t.Fatalf("replacement signed by %s (%v), want %s", from.Hex(), err, opts.From.Hex())
+	}
+}
+
+func TestWaitMinedStopsBumpingAtCeiling(t *testing.T) {
+	fastReceiptPolls(t)
+	l1 := newMinerL1()
+	tx, opts := sendStuckTx(t, l1, 10, 1)
+	ceiling := big.NewInt(15e9)
+	m := &CrossChainMessenger{
+		ClientL1:    l1,
+		Replacement: ReplacementPolicy{Timeout: time.Millisecond, FeeBumpPercent: 20, MaxFee: ceiling},
+		Timeouts:    Timeouts{WaitMined: 300 * time.Millisecond},
+	}
+
+	_, err := m.waitMined(context.Background(), tx, opts)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("waitMined() with nothing mined = %v, want ErrTimeout", err)
+	}
+	// 10 gwei, then 12 and 14.4; another 10% would pass the 15 gwei ceiling
+	sent := l1.sentTxs()
+	if len(sent) != 3 {
+		t.Fatalf("%d transactions sent, want the stuck one and two replacements", len(sent))
+	}
+	for i := 1; i < len(sent); i++ {
+		prev, cur := sent[i-1], sent[i]
+		if cur.Nonce() != tx.Nonce() {
+			t.Fatalf("attempt %d nonce = %d, want %d", i, cur.Nonce(), tx.Nonce())
+		}
+		if floor := bumpFee(prev.GasFeeCap(), minFeeBumpPercent); cur.GasFeeCap().Cmp(floor) < 0 {
+			t.Fatalf("attempt %d max fee %s is less than the minimum bump %s", i, cur.GasFeeCap(), floor)
+		}
+		if floor := bumpFee(prev.GasTipCap(), minFeeBumpPercent); cur.GasTipCap().Cmp(floor) < 0 {
+			t.Fatalf("attempt %d priority fee %s is less than the minimum bump %s", i, cur.GasTipCap(), floor)
+		}
+		if cur.GasFeeCap().Cmp(ceiling) > 0 {
+			t.Fatalf("attempt %d max fee %s is over the ceiling %s", i, cur.GasFeeCap(), ceiling)
+		}
+	}
+}
+
+func TestWaitMinedAwaitsL1Confirmations(t *testing.T) {
+	fastReceiptPolls(t)
+	l1 := newMinerL1()
+	l1.mine = func(*types.Transaction) bool { return true }
+	l1.step = 1
+	tx, opts := sendStuckTx(t, l1, 10, 1)
+	var stages []string
+	m := &CrossChainMessenger{
+		ClientL1:        l1,
+		L1Confirmations: 3,
+		Timeouts:        Timeouts{WaitMined: 10 * time.Second},
+		Progress:        func(stage string, _ map[string]any) { stages = append(stages, stage) },
+	}
+
+	receipt, err := m.waitMined(context.Background(), tx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.TxHash != tx.Hash() || receipt.BlockNumber.Uint64() != 100 {
+		t.Fatalf("receipt of %s in block %d, want %s in block 100", receipt.TxHash.Hex(), receipt.BlockNumber, tx.Hash().Hex())
+	}
+	// Blocks 101, 102 and 103 were built on top of block 100 before it returned
+	if l1.head != 103 {
+		t.Fatalf("returned at L1 head %d, want 103 (3 confirmations)", l1.head)
+	}
+	confirming := 0
+	for _, stage := range stages {
+		if stage == ProgressConfirming {
+			confirming++
+		}
+	}
+	if confirming != 1 {
+		t.Fatalf("%s reported %d times, want once", ProgressConfirming, confirming)
+	}
+}
+
+func TestWaitMinedResendsAfterReorg(t *testing.T) {
+	fastReceiptPolls(t)
+	l1 := newMinerL1()
+	l1.mine = func(*types.Transaction) bool { return true }
+	l1.step = 1
+	// Mined in block 100, then dropped when block 102 replaces the chain it was in
+	l1.reorgAt = 102
+	tx, opts := sendStuckTx(t, l1, 10, 1)
+	m := &CrossChainMessenger{
+		ClientL1:        l1,
+		L1Confirmations: 3,
+		Timeouts:        Timeouts{WaitMined: 10 * time.Second},
+	}
+
+	receipt, err := m.waitMined(context.Background(), tx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := l1.sentTxs()
+	if len(sent) != 2 || sent[1].Hash() != tx.Hash() {
+		t.Fatalf("sent %d transactions, want %s and its re-broadcast", len(sent), tx.Hash().Hex())
+	}
+	if receipt.TxHash != tx.Hash() || receipt.BlockNumber.Uint64() != 102 {
+		t.Fatalf("receipt of %s in block %d, want %s re-mined in block 102", receipt.TxHash.Hex(), receipt.BlockNumber, tx.Hash().Hex())
+	}
+	if l1.head < 105 {
+		t.Fatalf("returned at L1 head %d, before block 102 had 3 confirmations", l1.head)
+	}
+}
+
+func TestAwaitL1ConfirmationsReportsDroppedTransaction(t *testing.T) {
+	fastReceiptPolls(t)
+	l1 := newMinerL1()
+	m := &CrossChainMessenger{ClientL1: l1, L1Confirmations: 3}
+	// Without the attempt, e.g. a transaction found on chain, nothing is re-sent
+	receipt := &types.Receipt{TxHash: common.HexToHash("0x1"), BlockNumber: big.NewInt(100)}
+	got, err := m.awaitL1Confirmations(context.Background(), receipt, nil)
+	if err != nil || got != nil {
+		t.Fatalf("awaitL1Confirmations() for a vanished receipt = %v, %v; want nil, nil", got, err)
+	}
+	if sent := l1.sentTxs(); len(sent) != 0 {
+		t.Fatalf("%d transactions sent, want none", len(sent))
+	}
+}
+
+func TestBumpedTx(t *testing.T) {
+	gwei := func(n float64) *big.Int {
+		wei, _ := new(big.Float).Mul(big.NewFloat(n), big.NewFloat(1e9)).Int(nil)
+		return wei
+	}
+	to := common.HexToAddress("0x1")
+	tests := []struct {
+		name       string
+		tx         *types.Transaction
+		percent    int
+		ceiling    *big.Int
+		wantFeeCap *big.Int // nil: no replacement
+		wantTipCap *big.Int
+	}{
+		{
+			name:       "bump",
+			tx:         types.NewTx(&types.DynamicFeeTx{Nonce: 3, GasFeeCap: gwei(10), GasTipCap: gwei(2), Gas: 21000, To: &to}),
+			percent:    20,
+			wantFeeCap: gwei(12),
+			wantTipCap: gwei(2.4),
+		},
+		{
+			name:       "below the minimum bump",
+			tx:         types.NewTx(&types.DynamicFeeTx{Nonce: 3, GasFeeCap: gwei(10), GasTipCap: gwei(2), Gas: 21000, To: &to}),
+			percent:    5,
+			wantFeeCap: gwei(11),
+			wantTipCap: gwei(2.2),
+		},
+		{
+			name:       "capped at the ceiling",
+			tx:         types.NewTx(&types.DynamicFeeTx{Nonce: 3, GasFeeCap: gwei(14), GasTipCap: gwei(2), Gas: 21000, To: &to}),
+			percent:    20,
+			ceiling:    gwei(15.5),
+			wantFeeCap: gwei(15.5),
+			wantTipCap: gwei(2.4),
+		},
+		{
+			name:    "ceiling leaves no room",
+			tx:      types.NewTx(&types.DynamicFeeTx{Nonce: 3, GasFeeCap: gwei(14.4), GasTipCap: gwei(2), Gas: 21000, To: &to}),
+			percent: 20,
+			ceiling: gwei(15),
+		},
+		{
+			name:       "tip capped at the fee cap",
+			tx:         types.NewTx(&types.DynamicFeeTx{Nonce: 3, GasFeeCap: gwei(10), GasTipCap: gwei(10), Gas: 21000, To: &to}),
+			percent:    20,
+			ceiling:    gwei(11),
+			wantFeeCap: gwei(11),
+			wantTipCap: gwei(11),
+		},
+		{
+			name:       "legacy",
+			tx:         types.NewTx(&types.LegacyTx{Nonce: 3, GasPrice: gwei(10), Gas: 21000, To: &to}),
+			percent:    20,
+			wantFeeCap: gwei(12),
+			wantTipCap: gwei(12),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &CrossChainMessenger{Replacement: ReplacementPolicy{FeeBumpPercent: tt.percent, MaxFee: tt.ceiling}}
+			got := m.bumpedTx(tt.tx)
+			if tt.wantFeeCap == nil {
+				if got != nil {
+					t.Fatalf("bumpedTx() = max fee %s, want no replacement", got.GasFeeCap())
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("bumpedTx() = nil, want a replacement")
+			}
+			if got.Nonce() != tt.tx.Nonce() || got.Type() != tt.tx.Type() || got.Gas() != tt.tx.Gas() {
+				t.Fatalf("replacement nonce %d, type %d, gas %d; want %d, %d, %d",
+					got.Nonce(), got.Type(), got.Gas(), tt.tx.Nonce(), tt.tx.Type(), tt.tx.Gas())
+			}
+			if got.GasFeeCap().Cmp(tt.wantFeeCap) != 0 || got.GasTipCap().Cmp(tt.wantTipCap) != 0 {
+				t.Fatalf("replacement fees = %s/%s, want %s/%s", got.GasFeeCap(), got.GasTipCap(), tt.wantFeeCap, tt.wantTipCap)
+			}
+		})
+	}
+}
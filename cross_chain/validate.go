@@ -0,0 +1,73 @@
+package crosschain
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// hashFormat is the accepted form of transaction and withdrawal hashes
+const hashFormat = "0x followed by 64 hex digits"
+
+// ValidateTxHash checks that txHash is a 0x-prefixed 32-byte hex hash. common.HexToHash
+// zero-pads anything shorter, so "0x123" would otherwise surface later as a confusing
+// "receipt not found".
+func ValidateTxHash(txHash string) error {
+	return validateHash("transaction hash", txHash)
+}
+
+// ValidateWithdrawalHash checks that withdrawalHash is a 0x-prefixed 32-byte hex hash
+func ValidateWithdrawalHash(withdrawalHash string) error {
+	return validateHash("withdrawal hash", withdrawalHash)
+}
+
+// ValidateTxHashes validates a list of transaction hashes, e.g. a batch, rejecting bad and
+// repeated entries by their position in the list (counting from 1)
+func ValidateTxHashes(txHashes []string) error {
+	seen := make(map[common.Hash]int, len(txHashes))
+	for i, txHash := range txHashes {
+		if err := ValidateTxHash(txHash); err != nil {
+			return fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		hash := common.HexToHash(txHash)
+		if first, ok := seen[hash]; ok {
+			return fmt.Errorf("entry %d: %w: %s is already entry %d", i+1, ErrDuplicateTxHash, txHash, first)
+		}
+		seen[hash] = i + 1
+	}
+	return nil
+}
+
+// ValidateMessageIndex checks that a message index is not negative
+func ValidateMessageIndex(messageIndex int) error {
+	if messageIndex < 0 {
+		return &InputError{Name: "message index", Value: strconv.Itoa(messageIndex), Expected: "a non-negative integer"}
+	}
+	return nil
+}
+
+// ParseMessageIndex parses a message index argument, e.g. from the command line
+func ParseMessageIndex(s string) (int, error) {
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 {
+		return 0, &InputError{Name: "message index", Value: s, Expected: "a non-negative integer"}
+	}
+	return index, nil
+}
+
+// validateMessageRef validates the (txHash, messageIndex) pair most public methods take
+func validateMessageRef(txHash string, messageIndex int) error {
+	if err := ValidateTxHash(txHash); err != nil {
+		return err
+	}
+	return ValidateMessageIndex(messageIndex)
+}
+
+func validateHash(name, hash string) error {
+	if _, err := hexutil.Decode(hash); err != nil || len(hash) != 66 {
+		return &InputError{Name: name, Value: hash, Expected: hashFormat}
+	}
+	return nil
+}
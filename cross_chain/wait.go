@@ -0,0 +1,109 @@
+package crosschain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WaitReadyToFinalize is a WaitForStatus target meaning proven and past the challenge
+// period. It is never returned as a message status.
+const WaitReadyToFinalize = 3
+
+// DefaultWaitPollInterval is how often WaitForStatus checks when no interval is given
+const DefaultWaitPollInterval = time.Minute
+
+// waitTargetDescription names a WaitForStatus target for logs and errors
+func waitTargetDescription(target int) string {
+	if target == WaitReadyToFinalize {
+		return "READY_TO_FINALIZE"
+	}
+	return StatusDescription(target)
+}
+
+// WaitForStatus polls the withdrawal in txHash every poll until it reaches target
+// (StatusProven, StatusFinalized or WaitReadyToFinalize), logging each status change.
+// Failed checks are retried with exponential backoff; it returns ctx's error once ctx
+// is done, so callers bound the wait with a deadline.
+func (m *CrossChainMessenger) WaitForStatus(ctx context.Context, txHash string, messageIndex int, target int, poll time.Duration) (err error) {
+	ctx, span := startSpan(ctx, "WaitForStatus", append(messageAttrs(txHash, messageIndex), attrStatus.Int(target))...)
+	defer func() { endSpan(span, err) }()
+	if err := validateMessageRef(txHash, messageIndex); err != nil {
+		return err
+	}
+	if target != StatusProven && target != StatusFinalized && target != WaitReadyToFinalize {
+		return fmt.Errorf("unsupported wait target %d", target)
+	}
+	if poll <= 0 {
+		poll = DefaultWaitPollInterval
+	}
+
+	m.logger().Infof("⏳ Waiting for %s (message %d) to reach %s, checking every %s",
+		txHash, messageIndex, waitTargetDescription(target), poll)
+
+	last := -1
+	failures := 0
+	for {
+		reached, status, err := m.checkWaitTarget(ctx, txHash, target)
+		delay := poll
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("stopped waiting for %s: %w", waitTargetDescription(target), ctx.Err())
+			}
+			failures++
+			// Back off on repeated failures, but never poll less often than asked
+			delay = max(poll, m.Retry.backoff(failures))
+			m.logger().Warnf("⚠️  Status check failed (%d in a row): %v; retrying in %s", failures, err, delay)
+			m.progress(ctx, ProgressCheckFailed, map[string]any{"error": err.Error(), "retryIn": delay.String()})
+		} else {
+			failures = 0
+			if status != last {
+				m.logger().Infof("📍 Status: %s", waitTargetDescription(status))
+				m.progress(ctx, ProgressStatus, map[string]any{"status": waitTargetDescription(status)})
+				last = status
+			}
+			if reached {
+				m.logger().Infof("✅ Reached %s", waitTargetDescription(target))
+				m.progress(ctx, ProgressReached, map[string]any{"status": waitTargetDescription(target)})
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("stopped waiting for %s: %w", waitTargetDescription(target), ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// checkWaitTarget loads the withdrawal's current status and reports whether target is
+// reached. The returned status is WaitReadyToFinalize once a proven withdrawal's challenge
+// period is over, so that step shows up as its own transition.
+func (m *CrossChainMessenger) checkWaitTarget(ctx context.Context, txHash string, target int) (bool, int, error) {
+	message, err := m.getMessages(ctx, txHash)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	status := message.Status
+	if status == StatusProven {
+		proven, provenTimestamp, err := m.checkProvenStatus(ctx, message.WithdrawalHash)
+		if err != nil {
+			return false, status, fmt.Errorf("failed to check proven status: %w", err)
+		}
+		period := m.FinalizationParams(ctx).Period
+		if proven && provenTimestamp.Sign() > 0 && !time.Now().Before(time.Unix(provenTimestamp.Int64(), 0).Add(period)) {
+			status = WaitReadyToFinalize
+		}
+	}
+
+	switch target {
+	case WaitReadyToFinalize:
+		return status == WaitReadyToFinalize || FinalizedAtPortal(status), status, nil
+	case StatusProven:
+		return status >= StatusProven, status, nil
+	default:
+		return FinalizedAtPortal(status), status, nil
+	}
+}
@@ -0,0 +1,66 @@
+package crosschain
+
+import (
+	"fmt"
+	"math/big"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// withdrawalHashArgs is the abi.encode layout of Hashing.hashWithdrawal
+var withdrawalHashArgs = func() abi.Arguments {
+	uint256, _ := abi.NewType("uint256", "", nil)
+	address, _ := abi.NewType("address", "", nil)
+	bytesType, _ := abi.NewType("bytes", "", nil)
+	return abi.Arguments{
+		{Type: uint256},   // nonce
+		{Type: address},   // sender
+		{Type: address},   // target
+		{Type: uint256},   // mntValue
+		{Type: uint256},   // ethValue
+		{Type: uint256},   // gasLimit
+		{Type: bytesType}, // data
+	}
+}()
+
+// ComputeWithdrawalHash hashes tx the way Mantle's Hashing.hashWithdrawal does:
+// keccak256(abi.encode(nonce, sender, target, mntValue, ethValue, gasLimit, data)).
+// Nil amounts are encoded as zero; negative or oversized ones can't be encoded.
+func ComputeWithdrawalHash(tx cross_abi.TypesWithdrawalTransaction) (common.Hash, error) {
+	encoded, err := withdrawalHashArgs.Pack(
+		orZero(tx.Nonce), tx.Sender, tx.Target, orZero(tx.MntValue), orZero(tx.EthValue), orZero(tx.GasLimit), tx.Data)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode withdrawal: %w", err)
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+func orZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
+// verifyWithdrawalHash recomputes the hash of the withdrawal we would submit for message
+// and checks it against the hash from the MessagePassed event, so a wrongly parsed log
+// can't send every later query to the wrong withdrawal
+func verifyWithdrawalHash(message Message) error {
+	tx, err := withdrawalTransaction(message)
+	if err != nil {
+		return err
+	}
+	computed, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		return err
+	}
+	if computed != common.HexToHash(message.WithdrawalHash) {
+		return fmt.Errorf("%w: event has 0x%s, transaction fields hash to %s",
+			ErrWithdrawalHashMismatch, message.WithdrawalHash, computed.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package crosschain
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	cross_abi "mantle-claim-crossing/abi"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// encodeWithdrawal lays out abi.encode(nonce, sender, target, mntValue, ethValue,
+// gasLimit, data) word by word, independently of the abi package
+func encodeWithdrawal(tx cross_abi.TypesWithdrawalTransaction) []byte {
+	word := func(v *big.Int) []byte { return common.BigToHash(v).Bytes() }
+	var out []byte
+	out = append(out, word(tx.Nonce)...)
+	out = append(out, common.BytesToHash(tx.Sender.Bytes()).Bytes()...)
+	out = append(out, common.BytesToHash(tx.Target.Bytes()).Bytes()...)
+	out = append(out, word(tx.MntValue)...)
+	out = append(out, word(tx.EthValue)...)
+	out = append(out, word(tx.GasLimit)...)
+	out = append(out, word(big.NewInt(7*32))...) // Offset of data, after the seven head words
+	out = append(out, word(big.NewInt(int64(len(tx.Data))))...)
+	out = append(out, tx.Data...)
+	if pad := len(tx.Data) % 32; pad != 0 {
+		out = append(out, make([]byte, 32-pad)...)
+	}
+	return out
+}
+
+func TestComputeWithdrawalHash(t *testing.T) {
+	long := testWithdrawal()
+	long.Data = make([]byte, 100) // Spans several words, the last one padded
+	for i := range long.Data {
+		long.Data[i] = byte(i)
+	}
+	empty := testWithdrawal()
+	empty.Data = nil
+
+	for name, tx := range map[string]cross_abi.TypesWithdrawalTransaction{
+		"short data": testWithdrawal(),
+		"long data":  long,
+		"no data":    empty,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ComputeWithdrawalHash(tx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := crypto.Keccak256Hash(encodeWithdrawal(tx)); got != want {
+				t.Fatalf("ComputeWithdrawalHash() = %s, want %s", got.Hex(), want.Hex())
+			}
+		})
+	}
+}
+
+func TestComputeWithdrawalHashAmounts(t *testing.T) {
+	tx := testWithdrawal()
+	zero := tx
+	zero.MntValue, zero.EthValue = new(big.Int), new(big.Int)
+	unset := tx
+	unset.MntValue, unset.EthValue = nil, nil
+
+	want, err := ComputeWithdrawalHash(zero)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ComputeWithdrawalHash(unset); err != nil || got != want {
+		t.Fatalf("nil amounts hash to %s (%v), want the zero ones %s", got.Hex(), err, want.Hex())
+	}
+	if other, _ := ComputeWithdrawalHash(tx); other == want {
+		t.Fatal("the MNT and ETH values don't change the hash")
+	}
+
+	negative := tx
+	negative.EthValue = big.NewInt(-1)
+	if _, err := ComputeWithdrawalHash(negative); err == nil {
+		t.Fatal("ComputeWithdrawalHash() of a negative amount succeeded")
+	}
+}
+
+func TestVerifyWithdrawalHash(t *testing.T) {
+	tx := testWithdrawal()
+	hash, err := ComputeWithdrawalHash(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := Message{
+		MsgNonceRaw:    tx.Nonce,
+		MntValue:       tx.MntValue,
+		EthValue:       tx.EthValue,
+		WithdrawalHash: common.Bytes2Hex(hash[:]),
+		MessagePassedEvent: &cross_abi.L2ToL1MessagePasserMessagePassed{
+			Nonce:    tx.Nonce,
+			Sender:   tx.Sender,
+			Target:   tx.Target,
+			MntValue: tx.MntValue,
+			EthValue: tx.EthValue,
+			GasLimit: tx.GasLimit,
+			Data:     tx.Data,
+		},
+	}
+	if err := verifyWithdrawalHash(message); err != nil {
+		t.Fatal(err)
+	}
+
+	// E.g. values taken from the wrong SentMessageExtension1 log
+	message.EthValue = big.NewInt(6)
+	if err := verifyWithdrawalHash(message); !errors.Is(err, ErrWithdrawalHashMismatch) {
+		t.Fatalf("verifyWithdrawalHash() with a wrong value = %v, want ErrWithdrawalHashMismatch", err)
+	}
+}
@@ -0,0 +1,77 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// CompactStorageProof returns the nodes of proof that lie on the path from root to the
+// value stored under slot, in order, dropping the ones the walk never visits. Those are
+// elements MaybeAddProofNode appended for an inlined child that is not on the path, or
+// that follow the node holding the value; the on-chain MerkleTrie only pays for them in
+// calldata, or rejects nodes after the value outright. The compacted proof must still
+// pass VerifyStorageProof, otherwise an error is returned and proof should be used as is.
+func CompactStorageProof(root [32]byte, slot [32]byte, proof [][]byte) ([][]byte, error) {
+	if len(proof) == 0 {
+		return nil, errors.New("proof is empty")
+	}
+
+	key := keyNibbles(crypto.Keccak256(slot[:]))
+	keyIndex := 0
+	nodeID := root[:]
+	remaining := proof
+	var path [][]byte
+
+walk:
+	for len(nodeID) > 0 {
+		// The next node on the path is the first remaining one its parent references
+		next := -1
+		for j, node := range remaining {
+			if checkNodeID(len(path), node, nodeID) == nil {
+				next = j
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		node := remaining[next]
+		remaining = remaining[next+1:]
+		path = append(path, node)
+
+		items, err := splitNode(node)
+		if err != nil {
+			break
+		}
+		switch len(items) {
+		case 17: // Branch
+			if keyIndex == len(key) {
+				break walk
+			}
+			nodeID = items[key[keyIndex]]
+			keyIndex++
+		case 2: // Extension or leaf
+			nodePath, isLeaf, err := decodeCompactPath(items[0])
+			if err != nil || isLeaf {
+				break walk
+			}
+			rest := key[keyIndex:]
+			if len(nodePath) > len(rest) || !bytes.Equal(nodePath, rest[:len(nodePath)]) {
+				break walk
+			}
+			nodeID = items[1]
+			keyIndex += len(nodePath)
+		default:
+			break walk
+		}
+	}
+
+	// Whatever stopped the walk, the result has to stand on its own
+	if _, err := VerifyStorageProof(root, slot, path); err != nil {
+		return nil, fmt.Errorf("compacted proof failed verification: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,188 @@
+package helper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// VerifyStorageProof walks a storage proof the same way the on-chain SecureMerkleTrie does
+// and returns the value stored under slot. The first node must hash to root; each later
+// node must match the reference in its parent, by hash when it is 32 bytes or longer and
+// byte-for-byte when it was inlined (the element MaybeAddProofNode appends). The returned
+// value is the RLP-encoded slot content, e.g. 0x01 for a set sentMessages entry.
+func VerifyStorageProof(root [32]byte, slot [32]byte, proof [][]byte) ([]byte, error) {
+	return verifyProof(root, crypto.Keccak256(slot[:]), proof)
+}
+
+// VerifyAccountProof walks an eth_getProof account proof from the state root and returns
+// the storage root of address, so a storage root can be checked against a state root
+func VerifyAccountProof(stateRoot [32]byte, address common.Address, proof [][]byte) ([32]byte, error) {
+	value, err := verifyProof(stateRoot, crypto.Keccak256(address[:]), proof)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	var account struct {
+		Nonce       uint64
+		Balance     *big.Int
+		StorageRoot common.Hash
+		CodeHash    []byte
+	}
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return [32]byte{}, fmt.Errorf("invalid account in proof: %w", err)
+	}
+	return account.StorageRoot, nil
+}
+
+// verifyProof walks proof from root to the value stored under the hashed key
+func verifyProof(root [32]byte, hashedKey []byte, proof [][]byte) ([]byte, error) {
+	if len(proof) == 0 {
+		return nil, errors.New("proof is empty")
+	}
+
+	key := keyNibbles(hashedKey)
+	keyIndex := 0
+	nodeID := root[:]
+
+	for i, node := range proof {
+		if err := checkNodeID(i, node, nodeID); err != nil {
+			return nil, err
+		}
+
+		items, err := splitNode(node)
+		if err != nil {
+			return nil, fmt.Errorf("proof node %d: %w", i, err)
+		}
+
+		switch len(items) {
+		case 17: // Branch
+			if keyIndex == len(key) {
+				return nodeValue(i, items[16])
+			}
+			nodeID = items[key[keyIndex]]
+			keyIndex++
+
+		case 2: // Extension or leaf
+			path, isLeaf, err := decodeCompactPath(items[0])
+			if err != nil {
+				return nil, fmt.Errorf("proof node %d: %w", i, err)
+			}
+			remaining := key[keyIndex:]
+			if isLeaf {
+				if !bytes.Equal(path, remaining) {
+					return nil, fmt.Errorf("proof node %d: leaf path %s does not match key remainder %s", i, nibblesHex(path), nibblesHex(remaining))
+				}
+				return nodeValue(i, items[1])
+			}
+			if len(path) > len(remaining) || !bytes.Equal(path, remaining[:len(path)]) {
+				return nil, fmt.Errorf("proof node %d: extension path %s diverges from key remainder %s", i, nibblesHex(path), nibblesHex(remaining))
+			}
+			nodeID = items[1]
+			keyIndex += len(path)
+
+		default:
+			return nil, fmt.Errorf("proof node %d: invalid node with %d items", i, len(items))
+		}
+
+		if len(nodeID) == 0 {
+			return nil, fmt.Errorf("proof node %d: key is not in the trie (empty child)", i)
+		}
+	}
+	return nil, fmt.Errorf("proof ended after %d nodes without reaching the value; a final inlined node may be missing (see MaybeAddProofNode)", len(proof))
+}
+
+// checkNodeID verifies node against the reference its parent holds
+func checkNodeID(i int, node, nodeID []byte) error {
+	if i == 0 || len(node) >= 32 {
+		if hash := crypto.Keccak256(node); !bytes.Equal(hash, nodeID) {
+			if i == 0 {
+				return fmt.Errorf("proof node 0: hash %s does not match storage root %s", hexutil.Encode(hash), hexutil.Encode(nodeID))
+			}
+			return fmt.Errorf("proof node %d: hash %s does not match reference %s in node %d", i, hexutil.Encode(hash), hexutil.Encode(nodeID), i-1)
+		}
+		return nil
+	}
+	if !bytes.Equal(node, nodeID) {
+		return fmt.Errorf("proof node %d: inline node %s does not match reference %s in node %d", i, hexutil.Encode(node), hexutil.Encode(nodeID), i-1)
+	}
+	return nil
+}
+
+// splitNode returns the items of an RLP list node. String items are returned as their
+// content (a child hash or value); embedded lists, which are inlined child nodes, keep
+// their full encoding so they can be compared with the next proof element.
+func splitNode(node []byte) ([][]byte, error) {
+	content, rest, err := rlp.SplitList(node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RLP: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after node")
+	}
+
+	var items [][]byte
+	for len(content) > 0 {
+		kind, itemContent, itemRest, err := rlp.Split(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RLP item: %w", err)
+		}
+		if kind == rlp.List {
+			items = append(items, content[:len(content)-len(itemRest)])
+		} else {
+			items = append(items, itemContent)
+		}
+		content = itemRest
+	}
+	return items, nil
+}
+
+// nodeValue returns the value held by proof node i
+func nodeValue(i int, value []byte) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("proof node %d: key is in the trie but has no value", i)
+	}
+	return value, nil
+}
+
+// decodeCompactPath decodes a hex-prefix encoded path into nibbles
+func decodeCompactPath(encoded []byte) ([]byte, bool, error) {
+	if len(encoded) == 0 {
+		return nil, false, errors.New("empty node path")
+	}
+	nibbles := keyNibbles(encoded)
+	prefix := nibbles[0]
+	if prefix > 3 {
+		return nil, false, fmt.Errorf("invalid node path prefix %d", prefix)
+	}
+	isLeaf := prefix >= 2
+	if prefix%2 == 1 {
+		return nibbles[1:], isLeaf, nil // Odd length: the path starts in the prefix byte
+	}
+	return nibbles[2:], isLeaf, nil
+}
+
+// keyNibbles splits b into 4-bit nibbles, high nibble first
+func keyNibbles(b []byte) []byte {
+	nibbles := make([]byte, len(b)*2)
+	for i, v := range b {
+		nibbles[i*2] = v >> 4
+		nibbles[i*2+1] = v & 0x0f
+	}
+	return nibbles
+}
+
+// nibblesHex formats nibbles as one hex digit each
+func nibblesHex(nibbles []byte) string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, len(nibbles))
+	for i, n := range nibbles {
+		b[i] = digits[n]
+	}
+	return string(b)
+}
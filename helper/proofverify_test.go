@@ -0,0 +1,138 @@
+package helper
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// newTrie returns an empty in-memory Merkle-Patricia trie
+func newTrie() *trie.Trie {
+	return trie.NewEmpty(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+}
+
+// prove returns the proof nodes of key in tr, root first, the way eth_getProof lists them
+func prove(t *testing.T, tr *trie.Trie, key []byte) [][]byte {
+	t.Helper()
+	var proof trienode.ProofList
+	if err := tr.Prove(key, &proof); err != nil {
+		t.Fatal(err)
+	}
+	nodes := make([][]byte, len(proof))
+	for i, node := range proof {
+		nodes[i] = node
+	}
+	return nodes
+}
+
+// storageTrie is a contract storage trie where each of n slots holds true, like
+// sentMessages after n withdrawals
+func storageTrie(n int) (*trie.Trie, [][32]byte) {
+	tr := newTrie()
+	slots := make([][32]byte, n)
+	for i := range slots {
+		slots[i] = crypto.Keccak256Hash(common.BigToHash(common.Big1).Bytes(), []byte{byte(i), byte(i >> 8)})
+		tr.MustUpdate(crypto.Keccak256(slots[i][:]), []byte{0x01})
+	}
+	return tr, slots
+}
+
+func TestVerifyStorageProof(t *testing.T) {
+	tr, slots := storageTrie(500)
+	root := tr.Hash()
+	for _, slot := range slots[:20] {
+		value, err := VerifyStorageProof(root, slot, prove(t, tr, crypto.Keccak256(slot[:])))
+		if err != nil {
+			t.Fatalf("slot %x: %v", slot, err)
+		}
+		if !bytes.Equal(value, []byte{0x01}) {
+			t.Fatalf("slot %x holds %x, want 01", slot, value)
+		}
+	}
+}
+
+func TestVerifyStorageProofRejects(t *testing.T) {
+	tr, slots := storageTrie(500)
+	root := tr.Hash()
+	slot := slots[0]
+	proof := prove(t, tr, crypto.Keccak256(slot[:]))
+	if len(proof) < 3 {
+		t.Fatalf("proof has %d nodes, want a deeper trie", len(proof))
+	}
+
+	tampered := append([][]byte(nil), proof...)
+	tampered[1] = append([]byte(nil), proof[1]...)
+	tampered[1][len(tampered[1])-1] ^= 0xff
+
+	var missing [32]byte
+	missing[0] = 0x42
+
+	tests := []struct {
+		name  string
+		root  [32]byte
+		slot  [32]byte
+		proof [][]byte
+		want  string
+	}{
+		{"empty", root, slot, nil, "proof is empty"},
+		{"wrong root", crypto.Keccak256Hash([]byte("other")), slot, proof, "proof node 0: hash"},
+		{"tampered node", root, slot, tampered, "proof node 1: hash"},
+		{"truncated", root, slot, proof[:len(proof)-1], "proof ended after"},
+		{"other slot", root, slots[1], proof, "proof node"},
+		{"slot not in the trie", root, missing, prove(t, tr, crypto.Keccak256(missing[:])), "proof node"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := VerifyStorageProof(tt.root, tt.slot, tt.proof)
+			if err == nil {
+				t.Fatalf("VerifyStorageProof() = %x, want an error", value)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("VerifyStorageProof() error = %v, want it to name %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// Two keys differing only in their last byte end in a branch whose leaves are under 32
+// bytes, so they are inlined in it and eth_getProof stops at the branch. The walk
+// can't reach the value until MaybeAddProofNode appends the inlined leaf. The values
+// are long enough that the branch itself is hashed rather than inlined too.
+func TestVerifyShortFinalNode(t *testing.T) {
+	prefix := bytes.Repeat([]byte{0xab}, 31)
+	key := append(append([]byte(nil), prefix...), 0x1a)
+	sibling := append(append([]byte(nil), prefix...), 0x2b)
+	stored := bytes.Repeat([]byte{0x01}, 20)
+
+	tr := newTrie()
+	tr.MustUpdate(key, stored)
+	tr.MustUpdate(sibling, stored)
+	root := tr.Hash()
+	proof := prove(t, tr, key)
+
+	if _, err := verifyProof(root, key, proof); err == nil || !strings.Contains(err.Error(), "MaybeAddProofNode") {
+		t.Fatalf("verifyProof() without the inlined leaf = %v, want it to point at MaybeAddProofNode", err)
+	}
+
+	fixed, err := MaybeAddProofNode([32]byte(key), proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixed) != len(proof)+1 {
+		t.Fatalf("MaybeAddProofNode() added %d nodes, want 1: the leaf of key only", len(fixed)-len(proof))
+	}
+	value, err := verifyProof(root, key, fixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value, stored) {
+		t.Fatalf("value = %x, want %x", value, stored)
+	}
+}
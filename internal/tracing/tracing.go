@@ -0,0 +1,59 @@
+// Package tracing exports OpenTelemetry traces of the prove/finalize pipeline over
+// OTLP/HTTP, so a slow check can be broken down into receipt fetches, proofs and
+// transactions in Jaeger or any other OTLP backend
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"mantle-claim-crossing/internal/version"
+)
+
+// Enabled reports whether an OTLP endpoint is configured; the exporter reads the
+// rest of its settings (headers, protocol, TLS) from the standard OTEL_* variables
+func Enabled() bool {
+	return strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")) != "" ||
+		strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")) != ""
+}
+
+// Setup installs the global tracer provider exporting to the configured OTLP
+// endpoint, named service unless OTEL_SERVICE_NAME says otherwise. Without an
+// endpoint it installs nothing and spans stay no-ops. The returned shutdown flushes
+// the spans still buffered and must run before the process exits.
+func Setup(ctx context.Context, service string) (shutdown func(context.Context) error, err error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			attribute.String("service.name", service),
+			attribute.String("service.version", version.BuildInfo().Version),
+		),
+		resource.WithFromEnv(), // OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
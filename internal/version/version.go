@@ -0,0 +1,104 @@
+// Package version reports which build of the tools is running, so a log line, a
+// notification or an API response can be traced back to it
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X mantle-claim-crossing/internal/version.Version=v1.4.0 \
+//	  -X mantle-claim-crossing/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X mantle-claim-crossing/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Dependencies whose versions BuildInfo reports, by short name
+var trackedDeps = map[string]string{
+	"go-ethereum": "github.com/ethereum/go-ethereum",
+	"kmssigner":   "github.com/welthee/go-ethereum-aws-kms-tx-signer/v2",
+}
+
+// Info describes the running build
+type Info struct {
+	Version   string            `json:"version"`
+	Commit    string            `json:"commit,omitempty"`
+	Date      string            `json:"date,omitempty"`
+	GoVersion string            `json:"goVersion"`
+	Deps      map[string]string `json:"deps,omitempty"` // Short name (go-ethereum, kmssigner) → module version
+}
+
+// BuildInfo returns the version injected with -ldflags; the commit and date fall back
+// to the VCS stamp Go embeds when building from a checkout. It is computed once.
+func BuildInfo() Info {
+	return buildInfo()
+}
+
+var buildInfo = sync.OnceValue(readBuildInfo)
+
+func readBuildInfo() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version(), Deps: map[string]string{}}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, dep := range bi.Deps {
+		for name, path := range trackedDeps {
+			if dep.Path == path {
+				info.Deps[name] = dep.Version
+			}
+		}
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+				if len(info.Commit) > 12 {
+					info.Commit = info.Commit[:12]
+				}
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// Short is the one-line version string, e.g. "v1.4.0 (abc1234)", used in logs,
+// notifications and the X-Build-Version header
+func (i Info) Short() string {
+	if i.Commit == "" {
+		return i.Version
+	}
+	return fmt.Sprintf("%s (%s)", i.Version, i.Commit)
+}
+
+// String is the multi-line report printed by the version commands
+func (i Info) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Version:     %s\n", i.Version)
+	fmt.Fprintf(&b, "Commit:      %s\n", orUnknown(i.Commit))
+	fmt.Fprintf(&b, "Built:       %s\n", orUnknown(i.Date))
+	fmt.Fprintf(&b, "Go:          %s\n", i.GoVersion)
+	fmt.Fprintf(&b, "go-ethereum: %s\n", orUnknown(i.Deps["go-ethereum"]))
+	fmt.Fprintf(&b, "kmssigner:   %s\n", orUnknown(i.Deps["kmssigner"]))
+	return b.String()
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
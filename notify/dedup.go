@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultFailureCooldown is how long Dedup holds back repeats of the same failure
+const DefaultFailureCooldown = time.Hour
+
+// failureKey identifies a failure: the withdrawal and the class of error it hit
+type failureKey struct {
+	txHash string
+	class  string
+}
+
+// failureRun is a failure seen one or more times in a row
+type failureRun struct {
+	attempts int
+	since    time.Time
+	lastSent time.Time
+}
+
+// Dedup keeps a failing withdrawal from flooding every destination. The first event
+// with a given (TxHash, ErrorClass) goes through; repeats within the cool-down are
+// dropped, and the first repeat after it goes out as "still failing after N attempts".
+// Events without an ErrorClass always pass. Call Recovered once the withdrawal works
+// again to announce it and reset its failures.
+type Dedup struct {
+	next     Notifier
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	failing map[failureKey]*failureRun
+}
+
+// NewDedup wraps n, holding back repeated failures for cooldown
+func NewDedup(n Notifier, cooldown time.Duration) *Dedup {
+	return &Dedup{
+		next:     n,
+		cooldown: cooldown,
+		now:      time.Now,
+		failing:  make(map[failureKey]*failureRun),
+	}
+}
+
+// Notify implements Notifier
+func (d *Dedup) Notify(ctx context.Context, event Event) error {
+	if event.ErrorClass == "" {
+		return d.next.Notify(ctx, event)
+	}
+
+	now := d.now()
+	key := failureKey{event.TxHash, event.ErrorClass}
+	d.mu.Lock()
+	run := d.failing[key]
+	if run == nil {
+		d.failing[key] = &failureRun{attempts: 1, since: now, lastSent: now}
+		d.mu.Unlock()
+		return d.next.Notify(ctx, event)
+	}
+	run.attempts++
+	if now.Sub(run.lastSent) < d.cooldown {
+		d.mu.Unlock()
+		return nil
+	}
+	run.lastSent = now
+	event.Attempts = run.attempts
+	event.Text = fmt.Sprintf("🔁 *Still failing after %d attempts* (since %s)\n\n%s",
+		run.attempts, run.since.UTC().Format(time.RFC3339), event.Text)
+	d.mu.Unlock()
+	return d.next.Notify(ctx, event)
+}
+
+// Recovered forgets every failure of txHash and, if there were any, sends an
+// EventRecovered. label is shown with the hash; it may be empty.
+func (d *Dedup) Recovered(ctx context.Context, txHash, label string) error {
+	d.mu.Lock()
+	attempts := 0
+	var since time.Time
+	for key, run := range d.failing {
+		if key.txHash != txHash {
+			continue
+		}
+		attempts += run.attempts
+		if since.IsZero() || run.since.Before(since) {
+			since = run.since
+		}
+		delete(d.failing, key)
+	}
+	d.mu.Unlock()
+	if attempts == 0 {
+		return nil
+	}
+
+	text := "✅ *Recovered*\n\n"
+	if label != "" {
+		text += "🏷️ Label: " + EscapeMarkdown(label) + "\n"
+	}
+	text += fmt.Sprintf("Transaction: `%s`\nWorking again after %d failed attempt(s) since %s.",
+		txHash, attempts, since.UTC().Format(time.RFC3339))
+	return d.next.Notify(ctx, Event{
+		Type:     EventRecovered,
+		TxHash:   txHash,
+		Label:    label,
+		Text:     text,
+		Time:     d.now(),
+		Attempts: attempts,
+	})
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recorder keeps every event it is notified of
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Notify(ctx context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// newTestDedup returns a Dedup with a one-hour cool-down over a recorder, and a function
+// moving its clock forward
+func newTestDedup() (*Dedup, *recorder, func(time.Duration)) {
+	r := &recorder{}
+	d := NewDedup(r, time.Hour)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, r, func(step time.Duration) { now = now.Add(step) }
+}
+
+func checkFailed(txHash, class string) Event {
+	return Event{Type: EventCheckFailed, TxHash: txHash, ErrorClass: class, Text: "❌ Check failed"}
+}
+
+func TestDedupFailureSequence(t *testing.T) {
+	ctx := context.Background()
+	d, r, advance := newTestDedup()
+
+	// The scheduler checks every 10 minutes while the endpoint is down
+	for i := 0; i < 6; i++ {
+		if err := d.Notify(ctx, checkFailed("0xaa", "rpc")); err != nil {
+			t.Fatal(err)
+		}
+		advance(10 * time.Minute)
+	}
+	if len(r.events) != 1 {
+		t.Fatalf("sent %d events in the first hour, want 1", len(r.events))
+	}
+	if r.events[0].Attempts != 0 || r.events[0].Text != "❌ Check failed" {
+		t.Fatalf("first failure = %+v, want it sent unchanged", r.events[0])
+	}
+
+	if err := d.Notify(ctx, checkFailed("0xaa", "rpc")); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 2 {
+		t.Fatalf("sent %d events after the cool-down, want 2", len(r.events))
+	}
+	escalated := r.events[1]
+	if escalated.Attempts != 7 || !strings.Contains(escalated.Text, "Still failing after 7 attempts") ||
+		!strings.Contains(escalated.Text, "since 2024-01-01T00:00:00Z") || !strings.HasSuffix(escalated.Text, "❌ Check failed") {
+		t.Fatalf("repeat after the cool-down = %+v, want it escalated", escalated)
+	}
+
+	// The cool-down restarts from the escalation
+	advance(59 * time.Minute)
+	if err := d.Notify(ctx, checkFailed("0xaa", "rpc")); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 2 {
+		t.Fatalf("sent %d events within the second cool-down, want 2", len(r.events))
+	}
+}
+
+func TestDedupKeys(t *testing.T) {
+	ctx := context.Background()
+	d, r, _ := newTestDedup()
+	for _, event := range []Event{
+		checkFailed("0xaa", "rpc"),
+		checkFailed("0xaa", "rpc"),
+		checkFailed("0xaa", "timeout"), // Another class of failure of the same withdrawal
+		checkFailed("0xbb", "rpc"),     // The same failure of another withdrawal
+		{Type: EventProveFailed, TxHash: "0xaa"},
+		{Type: EventProveFailed, TxHash: "0xaa"}, // Events without a class are never held back
+	} {
+		if err := d.Notify(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(r.events) != 5 {
+		t.Fatalf("sent %d events, want 5: only the repeated (0xaa, rpc) is held back", len(r.events))
+	}
+}
+
+func TestDedupRecovered(t *testing.T) {
+	ctx := context.Background()
+	d, r, advance := newTestDedup()
+
+	if err := d.Recovered(ctx, "0xaa", "savings"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 0 {
+		t.Fatalf("Recovered() of a withdrawal that never failed sent %+v", r.events)
+	}
+
+	for _, class := range []string{"rpc", "rpc", "timeout"} {
+		if err := d.Notify(ctx, checkFailed("0xaa", class)); err != nil {
+			t.Fatal(err)
+		}
+		advance(10 * time.Minute)
+	}
+	if err := d.Notify(ctx, checkFailed("0xbb", "rpc")); err != nil {
+		t.Fatal(err)
+	}
+	r.events = nil
+
+	if err := d.Recovered(ctx, "0xaa", "savings"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 1 {
+		t.Fatalf("Recovered() sent %d events, want 1", len(r.events))
+	}
+	recovered := r.events[0]
+	if recovered.Type != EventRecovered || recovered.TxHash != "0xaa" || recovered.Attempts != 3 ||
+		!strings.Contains(recovered.Text, "after 3 failed attempt(s) since 2024-01-01T00:00:00Z") || !strings.Contains(recovered.Text, "savings") {
+		t.Fatalf("Recovered() sent %+v", recovered)
+	}
+
+	// The recovery is announced once, and a new failure is reported like the first
+	if err := d.Recovered(ctx, "0xaa", "savings"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Notify(ctx, checkFailed("0xaa", "rpc")); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 2 || r.events[1].Type != EventCheckFailed || r.events[1].Attempts != 0 {
+		t.Fatalf("events after recovering = %+v, want one plain failure", r.events[1:])
+	}
+	// 0xbb is still failing
+	if err := d.Notify(ctx, checkFailed("0xbb", "rpc")); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.events) != 2 {
+		t.Fatal("recovering 0xaa reset the failures of 0xbb")
+	}
+}
@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownEscaper escapes the characters Telegram's legacy Markdown treats as markup
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// markdownUnescaper reverses markdownEscaper for destinations that don't use backslash escapes
+var markdownUnescaper = strings.NewReplacer("\\_", "_", "\\*", "*", "\\`", "`", "\\[", "[")
+
+// markdownLink matches a Markdown [text](url) link not escaped by markdownEscaper
+var markdownLink = regexp.MustCompile(`(^|[^\\])\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+
+// EscapeMarkdown escapes free text (error messages, reasons) interpolated into an event's
+// Markdown so a stray "_" or "*" can't break rendering or make Telegram reject the message
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,179 @@
+// Package notify delivers scheduler events to chat and HTTP endpoints
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventType identifies a withdrawal state transition reported by the scheduler
+type EventType string
+
+const (
+	EventWithdrawalDiscovered  EventType = "withdrawal_discovered"
+	EventWithdrawalsReloaded   EventType = "withdrawals_reloaded" // The config file's withdrawal list changed
+	EventNotAWithdrawal        EventType = "not_a_withdrawal"     // A monitored hash has no withdrawal events; it is no longer checked
+	EventWithdrawalReady       EventType = "withdrawal_ready"     // Monitor-only mode: ready, but we won't submit
+	EventActionRequired        EventType = "action_required"
+	EventProvePending          EventType = "prove_pending"
+	EventReadyToProve          EventType = "ready_to_prove"
+	EventProveSubmitted        EventType = "prove_submitted"
+	EventProveSucceeded        EventType = "prove_succeeded"
+	EventProveFailed           EventType = "prove_failed"
+	EventInsufficientFunds     EventType = "insufficient_funds" // Wallet can't pay for a prove/finalize; nothing was sent
+	EventProvenExternally      EventType = "proven_externally"
+	EventOutputsDeleted        EventType = "outputs_deleted" // The challenger deleted outputs; lists the withdrawals proven against them, which will be re-proven
+	EventProofReorged          EventType = "proof_reorged"   // A proof seen on chain is gone again, e.g. after an L1 reorg; it will be re-proven
+	EventChallengeWaiting      EventType = "challenge_waiting"
+	EventFinalizeSoon          EventType = "finalize_soon"
+	EventReadyToFinalize       EventType = "ready_to_finalize"
+	EventFinalizeSubmitted     EventType = "finalize_submitted"
+	EventFinalizeSucceeded     EventType = "finalize_succeeded"
+	EventFinalizeFailed        EventType = "finalize_failed"
+	EventFinalizedExternally   EventType = "finalized_externally"
+	EventAlreadyFinalized      EventType = "already_finalized"
+	EventRelayedMessageFailed  EventType = "relayed_message_failed" // Finalized, but the message relayed on L1 reverted; it must be replayed
+	EventReplaySucceeded       EventType = "replay_succeeded"       // A /replay relayed the failed message; the funds were delivered
+	EventReplayFailed          EventType = "replay_failed"          // A /replay couldn't be sent, reverted or the message failed again
+	EventAllCompleted          EventType = "all_completed"
+	EventOptimisticModeToggled EventType = "optimistic_mode_toggled" // The oracle's finalization period or mode changed; countdowns were recalculated
+	EventBatchSubmitted        EventType = "batch_submitted"
+	EventBatchFailed           EventType = "batch_failed"
+	EventBatchResults          EventType = "batch_results"
+	EventDailySummary          EventType = "daily_summary"        // Digest of every tracked withdrawal, on SUMMARY_SCHEDULE or on demand
+	EventCheckFailed           EventType = "check_failed"         // A withdrawal's status couldn't be read, e.g. because the RPC endpoint is down
+	EventRecovered             EventType = "recovered"            // A withdrawal that was failing works again; see Dedup
+	EventRelayCompleted        EventType = "relay_completed"      // A relay request's withdrawal is finalized; FeesWei is what it cost us
+	EventRelayFailed           EventType = "relay_failed"         // A relay request can't be completed, e.g. the hash is not a withdrawal
+	EventShuttingDown          EventType = "shutting_down"        // The scheduler got a shutdown signal and is letting in-flight work finish
+	EventWalletQueueStuck      EventType = "wallet_queue_stuck"   // A signing wallet's oldest pending L1 transaction stayed unmined for STUCK_TX_ALERT
+	EventWalletQueueCleared    EventType = "wallet_queue_cleared" // A wallet alerted as stuck has no pending transactions anymore
+	EventPortalPaused          EventType = "portal_paused"        // The guardian paused OptimismPortal; proves and finalizes are skipped until it is unpaused
+	EventPortalUnpaused        EventType = "portal_unpaused"      // OptimismPortal was unpaused; proves and finalizes resume
+)
+
+// Defaults for WithRetry
+const (
+	DefaultAttempts       = 3
+	DefaultInitialBackoff = 2 * time.Second
+)
+
+// Event is one notification. Text is the Markdown message shown by chat notifiers;
+// TxHash is empty for events that aren't about a single withdrawal.
+type Event struct {
+	Type    EventType `json:"type"`
+	TxHash  string    `json:"txHash,omitempty"`
+	TxURL   string    `json:"txUrl,omitempty"`   // TxHash on the Mantle explorer; empty without one
+	Label   string    `json:"label,omitempty"`   // Config label of the withdrawal; already shown in Text
+	Network string    `json:"network,omitempty"` // Scheduler target the event is about; already shown in Text. See WithNetwork
+	Text    string    `json:"text"`
+	Time    time.Time `json:"time"`
+	Fee     *Fee      `json:"fee,omitempty"` // Set on prove_succeeded and finalize_succeeded
+
+	// Set on events about a withdrawal submitted through the relay API: the caller's
+	// reference and metadata, and on relay_completed the total L1 fees paid for it
+	RequestID string            `json:"requestId,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	FeesWei   string            `json:"feesWei,omitempty"`
+
+	// ErrorClass marks a failure event for Dedup, e.g. "timeout" or "rpc"; Attempts counts
+	// the failures so far once a repeat or a recovery is reported
+	ErrorClass string `json:"errorClass,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+}
+
+// Fee is the L1 fee a mined prove or finalize paid
+type Fee struct {
+	L1TxHash string `json:"l1TxHash"`
+	L1TxURL  string `json:"l1TxUrl,omitempty"` // L1TxHash on the L1 explorer
+	Wallet   string `json:"wallet"`
+	GasUsed  uint64 `json:"gasUsed"`
+	Wei      string `json:"wei"` // Decimal string; may exceed a JSON number's precision
+	ETH      string `json:"eth"`
+}
+
+// Notifier delivers events to one destination
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi fans an event out to every notifier. Each gets the event even if an earlier one
+// fails; the failures are joined.
+type Multi []Notifier
+
+// Notify implements Notifier
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retrying retries a notifier with exponential backoff
+type retrying struct {
+	name           string
+	next           Notifier
+	attempts       int
+	initialBackoff time.Duration
+}
+
+// WithRetry wraps n so failed deliveries are retried up to attempts times in total,
+// doubling the delay from initialBackoff after each failure. name labels the error.
+func WithRetry(name string, n Notifier, attempts int, initialBackoff time.Duration) Notifier {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &retrying{name: name, next: n, attempts: attempts, initialBackoff: initialBackoff}
+}
+
+// Notify implements Notifier
+func (r *retrying) Notify(ctx context.Context, event Event) error {
+	delay := r.initialBackoff
+	var err error
+	for attempt := 1; attempt <= r.attempts; attempt++ {
+		if err = r.next.Notify(ctx, event); err == nil {
+			return nil
+		}
+		if attempt == r.attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w (gave up: %w)", r.name, err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return fmt.Errorf("%s: delivery failed after %d attempt(s): %w", r.name, r.attempts, err)
+}
+
+// networked names the scheduler target of every event it passes on
+type networked struct {
+	network string
+	next    Notifier
+}
+
+// WithNetwork wraps n so every event, including Dedup's recoveries, carries network in
+// Event.Network and on a line below the title of its text. The scheduler uses it when one
+// process runs several networks.
+func WithNetwork(network string, n Notifier) Notifier {
+	return &networked{network: network, next: n}
+}
+
+// Notify implements Notifier
+func (w *networked) Notify(ctx context.Context, event Event) error {
+	event.Network = w.network
+	line := "🌐 Network: " + EscapeMarkdown(w.network)
+	if title, body, ok := strings.Cut(event.Text, "\n\n"); ok {
+		event.Text = title + "\n\n" + line + "\n" + body
+	} else {
+		event.Text += "\n" + line
+	}
+	return w.next.Notify(ctx, event)
+}
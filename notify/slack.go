@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMantleExplorer is where Slack messages link withdrawal transactions
+	DefaultMantleExplorer = "https://mantlescan.xyz"
+
+	slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+	// slackMinInterval is Slack's posting limit of about one message per second per channel
+	slackMinInterval = time.Second
+)
+
+// Slack posts events with Block Kit, either through an incoming webhook or with a bot
+// token. In bot mode, follow-ups for a withdrawal are threaded under the first message
+// posted about it; incoming webhooks can't thread.
+type Slack struct {
+	webhookURL string
+	token      string
+	channel    string
+	explorer   string // Base URL for L2 transaction links
+	client     *http.Client
+
+	mu          sync.Mutex
+	nextAllowed time.Time         // Earliest time the next message may be posted
+	threads     map[string]string // Withdrawal tx hash -> thread root ts
+}
+
+// NewSlackWebhook creates a Slack notifier that posts to an incoming-webhook URL
+func NewSlackWebhook(webhookURL, explorer string) *Slack {
+	return newSlack(explorer, func(s *Slack) { s.webhookURL = webhookURL })
+}
+
+// NewSlackBot creates a Slack notifier that posts to channel with a bot token
+func NewSlackBot(token, channel, explorer string) *Slack {
+	return newSlack(explorer, func(s *Slack) {
+		s.token = token
+		s.channel = channel
+	})
+}
+
+func newSlack(explorer string, configure func(s *Slack)) *Slack {
+	if explorer == "" {
+		explorer = DefaultMantleExplorer
+	}
+	s := &Slack{
+		explorer: strings.TrimRight(explorer, "/"),
+		client:   &http.Client{Timeout: webhookTimeout},
+		threads:  make(map[string]string),
+	}
+	configure(s)
+	return s
+}
+
+// slackMessage is the chat.postMessage / incoming-webhook payload
+type slackMessage struct {
+	Channel  string       `json:"channel,omitempty"`
+	Text     string       `json:"text"` // Fallback for notifications and old clients
+	Blocks   []slackBlock `json:"blocks"`
+	ThreadTS string       `json:"thread_ts,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Notify implements Notifier
+func (s *Slack) Notify(ctx context.Context, event Event) error {
+	// Hold the lock for the whole post so messages go out in order and spaced out
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if wait := time.Until(s.nextAllowed); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	s.nextAllowed = time.Now().Add(slackMinInterval)
+
+	msg := slackMessage{
+		Channel: s.channel,
+		Text:    toSlackMarkdown(event.Text),
+		Blocks:  s.blocks(event),
+	}
+	if event.TxHash != "" {
+		msg.ThreadTS = s.threads[event.TxHash]
+	}
+
+	if s.webhookURL != "" {
+		return s.postWebhook(ctx, msg)
+	}
+	ts, err := s.postMessage(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if event.TxHash != "" && msg.ThreadTS == "" {
+		s.threads[event.TxHash] = ts
+	}
+	return nil
+}
+
+// blocks renders the event as a section plus an explorer link for the withdrawal
+func (s *Slack) blocks(event Event) []slackBlock {
+	blocks := []slackBlock{{
+		Type: "section",
+		Text: &slackText{Type: "mrkdwn", Text: toSlackMarkdown(event.Text)},
+	}}
+	if event.TxHash != "" {
+		link := event.TxURL
+		if link == "" {
+			link = s.explorer + "/tx/" + event.TxHash
+		}
+		blocks = append(blocks, slackBlock{
+			Type: "context",
+			Elements: []slackText{{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("<%s|View `%s` on Mantle explorer>", link, shortHash(event.TxHash)),
+			}},
+		})
+	}
+	return blocks
+}
+
+// postWebhook sends msg to the incoming webhook, which answers with plain "ok"
+func (s *Slack) postWebhook(ctx context.Context, msg slackMessage) error {
+	resp, err := s.post(ctx, s.webhookURL, msg)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// postMessage calls chat.postMessage and returns the posted message's ts
+func (s *Slack) postMessage(ctx context.Context, msg slackMessage) (string, error) {
+	resp, err := s.post(ctx, slackPostMessageURL, msg)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		TS    string `json:"ts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode chat.postMessage response (%s): %w", resp.Status, err)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("chat.postMessage failed: %s", result.Error)
+	}
+	return result.TS, nil
+}
+
+// post sends msg as JSON. A 429 pushes back the next allowed post by Retry-After and
+// is returned as an error, so the retry wrapper tries again once the window opens.
+func (s *Slack) post(ctx context.Context, url string, msg slackMessage) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resp.Body.Close()
+		retryAfter := slackMinInterval
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		s.nextAllowed = time.Now().Add(retryAfter)
+		return nil, fmt.Errorf("slack rate limited, retry after %s", retryAfter)
+	}
+	return resp, nil
+}
+
+// toSlackMarkdown converts the Telegram-style Markdown used in event texts to Slack
+// mrkdwn. Both use *bold* and `code`; Slack has no backslash escapes, so EscapeMarkdown
+// is undone, &, < and > are HTML-escaped as Slack requires, and [text](url) links
+// become <url|text>.
+func toSlackMarkdown(text string) string {
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+	text = markdownLink.ReplaceAllString(text, "$1<$3|$2>")
+	return markdownUnescaper.Replace(text)
+}
+
+// shortHash abbreviates a transaction hash for link labels
+func shortHash(hash string) string {
+	if len(hash) <= 14 {
+		return hash
+	}
+	return hash[:8] + "…" + hash[len(hash)-6:]
+}
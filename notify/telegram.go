@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram posts events as Markdown messages to a chat, optionally inside a forum topic
+type Telegram struct {
+	bot    *tgbotapi.BotAPI
+	chatID int64
+
+	mu      sync.Mutex
+	topicID int64 // Forum topic (message_thread_id); 0 posts to the chat itself
+}
+
+// NewTelegram connects to the bot API with token
+func NewTelegram(token string, chatID, topicID int64) (*Telegram, error) {
+	if chatID == 0 {
+		return nil, fmt.Errorf("telegram chat ID is not set")
+	}
+	bot, err := tgbotapi.NewBotAPI(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Telegram bot: %w", err)
+	}
+	return &Telegram{bot: bot, chatID: chatID, topicID: topicID}, nil
+}
+
+// UserName returns the bot's username
+func (t *Telegram) UserName() string {
+	return t.bot.Self.UserName
+}
+
+// Notify implements Notifier. If the chat rejects the topic (not a forum, or the topic
+// doesn't exist) the message is posted to the chat itself and the topic is dropped.
+func (t *Telegram) Notify(ctx context.Context, event Event) error {
+	t.mu.Lock()
+	topicID := t.topicID
+	t.mu.Unlock()
+
+	text := event.Text
+	if event.TxURL != "" {
+		text += "\n🔗 [View on Mantle explorer](" + event.TxURL + ")"
+	}
+	err := t.send(text, topicID)
+	if topicID != 0 && isTopicError(err) {
+		t.mu.Lock()
+		t.topicID = 0
+		t.mu.Unlock()
+		err = t.send(text, 0)
+	}
+	return err
+}
+
+// SelfTest posts text to the configured chat and topic, returning an actionable error
+// when the chat or topic is wrong. Unlike Notify it does not fall back to the chat.
+func (t *Telegram) SelfTest(text string) error {
+	t.mu.Lock()
+	topicID := t.topicID
+	t.mu.Unlock()
+
+	err := t.send(text, topicID)
+	switch {
+	case err == nil:
+		return nil
+	case topicID != 0 && isTopicError(err):
+		return fmt.Errorf("TELEGRAM_TOPIC_ID %d is not a forum topic in chat %d (%w); use the topic's message_thread_id, "+
+			"enable topics for the group, or unset TELEGRAM_TOPIC_ID", topicID, t.chatID, err)
+	default:
+		return fmt.Errorf("cannot post to TELEGRAM_CHAT_ID %d (%w); check that the bot is a member of the chat "+
+			"and that supergroup IDs include the -100 prefix", t.chatID, err)
+	}
+}
+
+// send posts one Markdown message. tgbotapi v5.5 predates forum topics, so the request
+// is built by hand to include message_thread_id.
+func (t *Telegram) send(text string, topicID int64) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", t.chatID)
+	params.AddNonEmpty("text", text)
+	params.AddNonEmpty("parse_mode", tgbotapi.ModeMarkdown)
+	params.AddNonZero64("message_thread_id", topicID)
+
+	if _, err := t.bot.MakeRequest("sendMessage", params); err != nil {
+		return fmt.Errorf("failed to send Telegram message: %w", err)
+	}
+	return nil
+}
+
+// TelegramCommand is a /command sent to the bot in the configured chat
+type TelegramCommand struct {
+	Name      string   // Command without the slash or @botname, e.g. "status"
+	Args      []string // Whitespace-separated arguments
+	UserID    int64
+	UserName  string
+	MessageID int
+}
+
+// commandPollTimeout is the long-polling timeout for getUpdates, in seconds
+const commandPollTimeout = 30
+
+// Commands long-polls for bot commands posted in the configured chat until ctx is done.
+// Messages from other chats are ignored.
+func (t *Telegram) Commands(ctx context.Context) <-chan TelegramCommand {
+	cfg := tgbotapi.NewUpdate(0)
+	cfg.Timeout = commandPollTimeout
+	cfg.AllowedUpdates = []string{"message"}
+	updates := t.bot.GetUpdatesChan(cfg)
+
+	commands := make(chan TelegramCommand)
+	go func() {
+		defer close(commands)
+		defer t.bot.StopReceivingUpdates()
+		for {
+			var update tgbotapi.Update
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok = <-updates:
+				if !ok {
+					return
+				}
+			}
+
+			msg := update.Message
+			if msg == nil || !msg.IsCommand() || msg.Chat == nil || msg.Chat.ID != t.chatID || msg.From == nil {
+				continue
+			}
+			cmd := TelegramCommand{
+				Name:      strings.ToLower(msg.Command()),
+				Args:      strings.Fields(msg.CommandArguments()),
+				UserID:    msg.From.ID,
+				UserName:  msg.From.UserName,
+				MessageID: msg.MessageID,
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case commands <- cmd:
+			}
+		}
+	}()
+	return commands
+}
+
+// Reply answers cmd with a Markdown message. Replying to the command message keeps the
+// answer in the topic the command was sent from.
+func (t *Telegram) Reply(cmd TelegramCommand, text string) error {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", t.chatID)
+	params.AddNonEmpty("text", text)
+	params.AddNonEmpty("parse_mode", tgbotapi.ModeMarkdown)
+	params.AddNonZero("reply_to_message_id", cmd.MessageID)
+	params.AddBool("allow_sending_without_reply", true)
+
+	if _, err := t.bot.MakeRequest("sendMessage", params); err != nil {
+		return fmt.Errorf("failed to reply to /%s: %w", cmd.Name, err)
+	}
+	return nil
+}
+
+// isTopicError reports whether Telegram rejected the message_thread_id
+func isTopicError(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	msg := strings.ToLower(apiErr.Message)
+	return strings.Contains(msg, "thread not found") || strings.Contains(msg, "topic")
+}
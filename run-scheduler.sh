@@ -10,7 +10,7 @@ fi
 
 # Build the scheduler
 echo "📦 Building scheduler..."
-go build -o mantle-scheduler scheduler.go
+go build -o bridge-status ./cmd/bridge-status
 
 if [ $? -ne 0 ]; then
 	echo "❌ Build failed"
@@ -25,6 +25,10 @@ if [ "$1" == "" ]; then
 	echo "Usage:"
 	echo "  ./run-scheduler.sh check             - Run a single check"
 	echo "  ./run-scheduler.sh start             - Start the scheduler (runs continuously)"
+	echo "  ./run-scheduler.sh validate-config   - Check the config and RPC connectivity, then exit"
+	echo "  ./run-scheduler.sh costs             - Print the L1 fees paid per wallet"
+	echo "  ./run-scheduler.sh list [STATUS...]  - Print the tracked withdrawals"
+	echo "  Add --config scheduler.yaml to any command to read settings from a file (see scheduler.example.yaml)"
 	echo ""
 	echo "Environment Variables:"
 	echo "  WITHDRAWAL_TX_HASH - Withdrawal transaction hash(es) to monitor (comma-separated for multiple)"
@@ -42,4 +46,4 @@ fi
 # Run the scheduler
 echo "🚀 Running scheduler..."
 echo ""
-./mantle-scheduler "$@"
+./bridge-status scheduler "$@"
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"net/http"
+)
+
+// Probe reports whether a process is healthy; a nil error means yes
+type Probe func(ctx context.Context) error
+
+// healthResponse is the body of /healthz and /readyz
+type healthResponse struct {
+	Status       string `json:"status"` // "ok" or "unavailable"
+	Error        string `json:"error,omitempty"`
+	PortalPaused *bool  `json:"portalPaused,omitempty"` // Omitted until the flag was read once
+}
+
+// HealthMux returns a mux answering GET /healthz with live and GET /readyz with ready:
+// 200 when the probe passes and 503 with its error when it doesn't. Neither needs a
+// token, so container probes can call them. Other handlers, e.g. metrics, can be
+// registered on the same mux to share the listener.
+//
+// Both responses include portalPaused, OptimismPortal's paused flag last seen. A paused
+// portal doesn't fail the probes: restarting the process can't unpause it.
+func HealthMux(live, ready Probe, portalPaused func() *bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", probeHandler(live, portalPaused))
+	mux.HandleFunc("GET /readyz", probeHandler(ready, portalPaused))
+	return mux
+}
+
+// probeHandler answers with the result of probe
+func probeHandler(probe Probe, portalPaused func() *bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := healthResponse{Status: "ok", PortalPaused: portalPaused()}
+		if err := probe(r.Context()); err != nil {
+			resp.Status = "unavailable"
+			resp.Error = err.Error()
+			writeJSON(w, http.StatusServiceUnavailable, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Job states reported by GET /jobs/{id}
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// jobRetention is how long finished jobs stay queryable
+const jobRetention = 24 * time.Hour
+
+// maxJobProgress caps the progress events a job keeps; a wait that outlives its timeout
+// reports every minute, so the oldest are dropped rather than growing without bound
+const maxJobProgress = 500
+
+// Job is an asynchronous prove or finalize started through the API
+type Job struct {
+	ID         string            `json:"id"`
+	Operation  string            `json:"operation"` // "prove" or "finalize"
+	TxHash     string            `json:"txHash"`
+	State      string            `json:"state"`
+	L1TxHash   string            `json:"l1TxHash,omitempty"` // Set as soon as the transaction is sent
+	Fee        *crosschain.TxFee `json:"fee,omitempty"`      // Set once our transaction is mined
+	External   bool              `json:"external,omitempty"` // Already proven/finalized, by someone else or earlier
+	Error      string            `json:"error,omitempty"`
+	Reason     string            `json:"revertReason,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	StartedAt  *time.Time        `json:"startedAt,omitempty"`
+	FinishedAt *time.Time        `json:"finishedAt,omitempty"`
+
+	// Progress lists the stages the job went through, oldest first; GET
+	// /jobs/{id}/events streams them as they happen
+	Progress      []crosschain.ProgressEvent `json:"progress,omitempty"`
+	progressCount int                        // Events ever added to Progress, including dropped ones
+}
+
+// jobStore keeps jobs in memory; they don't survive a restart
+type jobStore struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	active  map[string]*Job          // Unfinished job per operation and tx hash
+	changed map[string]chan struct{} // Closed and replaced whenever the job changes
+}
+
+func newJobStore() *jobStore {
+	return &jobStore{
+		jobs:    make(map[string]*Job),
+		active:  make(map[string]*Job),
+		changed: make(map[string]chan struct{}),
+	}
+}
+
+// start runs fn in the background as a new job, unless the same operation is already
+// pending for txHash, in which case the existing job is returned with created false.
+// fn passes opts on to ProveMessage or FinalizeMessage so the job records the L1
+// transaction as soon as it is sent and its fee once mined.
+func (s *jobStore) start(ctx context.Context, operation, txHash string, fn func(ctx context.Context, opts crosschain.SubmitOptions) error) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := operation + ":" + txHash
+	if existing, ok := s.active[key]; ok {
+		return *existing, false
+	}
+	s.prune()
+
+	job := &Job{
+		ID:        newJobID(),
+		Operation: operation,
+		TxHash:    txHash,
+		State:     JobQueued,
+		CreatedAt: time.Now(),
+	}
+	s.jobs[job.ID] = job
+	s.active[key] = job
+	s.changed[job.ID] = make(chan struct{})
+
+	ctx = crosschain.WithProgress(ctx, func(stage string, detail map[string]any) {
+		event := crosschain.ProgressEvent{Stage: stage, Detail: detail, Time: time.Now()}
+		s.update(job, func(j *Job) {
+			if len(j.Progress) >= maxJobProgress {
+				j.Progress = j.Progress[1:]
+			}
+			j.Progress = append(j.Progress, event)
+			j.progressCount++
+		})
+	})
+
+	go func() {
+		s.update(job, func(j *Job) {
+			now := time.Now()
+			j.State = JobRunning
+			j.StartedAt = &now
+		})
+		err := fn(ctx, crosschain.SubmitOptions{
+			OnSubmitted: func(l1TxHash common.Hash) {
+				s.update(job, func(j *Job) { j.L1TxHash = l1TxHash.Hex() })
+			},
+			OnMined: func(fee crosschain.TxFee) {
+				s.update(job, func(j *Job) { j.Fee = &fee })
+			},
+		})
+		s.update(job, func(j *Job) {
+			now := time.Now()
+			j.FinishedAt = &now
+			switch {
+			case err == nil:
+				j.State = JobSucceeded
+			case crosschain.IsExternallyCompleted(err), errors.Is(err, crosschain.ErrAlreadyFinalized):
+				j.State = JobSucceeded
+				j.External = true
+			default:
+				j.State = JobFailed
+				j.Error = err.Error()
+				if reason, ok := crosschain.RevertReason(err); ok {
+					j.Reason = reason
+				}
+			}
+		})
+		s.mu.Lock()
+		delete(s.active, key)
+		s.mu.Unlock()
+	}()
+	return *job, true
+}
+
+// get returns a snapshot of the job with the given ID
+func (s *jobStore) get(id string) (Job, bool) {
+	job, _, ok := s.watch(id)
+	return job, ok
+}
+
+// watch returns a snapshot of the job with the given ID and a channel that is closed
+// the next time the job changes
+func (s *jobStore) watch(id string) (Job, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, nil, false
+	}
+	snapshot := *job
+	snapshot.Progress = append([]crosschain.ProgressEvent(nil), job.Progress...)
+	return snapshot, s.changed[id], true
+}
+
+func (s *jobStore) update(job *Job, fn func(j *Job)) {
+	s.mu.Lock()
+	fn(job)
+	close(s.changed[job.ID])
+	s.changed[job.ID] = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// prune drops finished jobs older than jobRetention; callers hold mu
+func (s *jobStore) prune() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(s.jobs, id)
+			delete(s.changed, id)
+		}
+	}
+}
+
+func newJobID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+)
+
+// metric is one series family on /metrics
+type metric struct {
+	name, kind, help string
+	value            func(crosschain.RateLimitStats) float64
+}
+
+var rpcMetrics = []metric{
+	{"bridge_status_rpc_requests_total", "counter", "RPC requests sent to the endpoint; a batch counts each element",
+		func(s crosschain.RateLimitStats) float64 { return float64(s.Requests) }},
+	{"bridge_status_rpc_rate_limited_total", "counter", "RPC requests that waited for the endpoint's rate limit",
+		func(s crosschain.RateLimitStats) float64 { return float64(s.Delayed) }},
+	{"bridge_status_rpc_rate_limit_queued", "gauge", "RPC requests waiting for the endpoint's rate limit right now",
+		func(s crosschain.RateLimitStats) float64 { return float64(s.Queued) }},
+	{"bridge_status_rpc_rate_limit_utilization", "gauge", "Share of the endpoint's rate limit used over the last 10s (RPC_RATE_LIMIT); 0 when unlimited",
+		func(s crosschain.RateLimitStats) float64 { return s.Utilization }},
+	{"bridge_status_rpc_rate_limit_requests_per_second", "gauge", "The endpoint's configured rate limit; 0 when unlimited",
+		func(s crosschain.RateLimitStats) float64 { return s.RequestsPerSecond }},
+}
+
+// MetricsHandler answers GET /metrics with the RPC endpoints' request counts and rate
+// limit utilization from stats, in the Prometheus text format. Like the health probes it
+// needs no token; endpoints are shown by scheme and host only.
+func MetricsHandler(stats func() []crosschain.RateLimitStats) http.HandlerFunc {
+	return NetworkMetricsHandler(map[string]func() []crosschain.RateLimitStats{"": stats})
+}
+
+// NetworkMetricsHandler is MetricsHandler for a scheduler running several networks: stats
+// maps each network name to its messenger's stats, and every series gets a network label.
+// The empty name adds no label.
+func NetworkMetricsHandler(stats map[string]func() []crosschain.RateLimitStats) http.HandlerFunc {
+	networks := slices.Sorted(maps.Keys(stats))
+	return func(w http.ResponseWriter, r *http.Request) {
+		current := make([][]crosschain.RateLimitStats, len(networks))
+		for i, network := range networks {
+			current[i] = stats[network]()
+		}
+		var b strings.Builder
+		for _, m := range rpcMetrics {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+			for i, network := range networks {
+				label := ""
+				if network != "" {
+					label = fmt.Sprintf("network=%q,", network)
+				}
+				for _, s := range current[i] {
+					fmt.Fprintf(&b, "%s{%sclient=%q,endpoint=%q} %g\n", m.name, label, s.Client, s.Endpoint, m.value(s))
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write([]byte(b.String()))
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Errors a Relayer returns so the API can answer with the right status code
+var (
+	ErrInvalidRelayRequest = errors.New("invalid relay request")
+	ErrAlreadyTracked      = errors.New("withdrawal is already tracked")
+)
+
+// RelayRequest asks the scheduler to prove and finalize someone else's withdrawal and
+// report the outcome, with the L1 fees paid for it, to CallbackURL
+type RelayRequest struct {
+	TxHash      string            `json:"txHash"`
+	CallbackURL string            `json:"callbackUrl"`
+	RequestID   string            `json:"requestId,omitempty"` // Caller's reference, e.g. for billing; defaults to TxHash
+	Label       string            `json:"label,omitempty"`     // Shown in notifications like a config label
+	Metadata    map[string]string `json:"metadata,omitempty"`  // Passed through to notifications and the callback
+}
+
+// Validate checks the fields a Relayer can't do without
+func (r RelayRequest) Validate() error {
+	if _, err := hexutil.Decode(r.TxHash); err != nil || len(r.TxHash) != 66 {
+		return fmt.Errorf("%w: txHash %q is not a transaction hash", ErrInvalidRelayRequest, r.TxHash)
+	}
+	u, err := url.Parse(r.CallbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: callbackUrl %q must be an http(s) URL", ErrInvalidRelayRequest, r.CallbackURL)
+	}
+	return nil
+}
+
+// RelayStatus is returned by GET /relay/withdrawals/{txHash}
+type RelayStatus struct {
+	RelayRequest
+	State       string     `json:"state"`            // "queued", "finalized" or "failed"
+	Status      string     `json:"status,omitempty"` // Last known withdrawal status, e.g. PROVEN
+	FeesWei     string     `json:"feesWei"`          // L1 fees paid for this request so far
+	LastError   string     `json:"lastError,omitempty"`
+	AcceptedAt  time.Time  `json:"acceptedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// Relayer accepts relay requests; the scheduler implements it
+type Relayer interface {
+	// SubmitWithdrawal queues req; it returns ErrInvalidRelayRequest or ErrAlreadyTracked
+	// for requests it won't take
+	SubmitWithdrawal(req RelayRequest) error
+	// RelayStatus reports on a request accepted earlier; ok is false for unknown hashes
+	RelayStatus(ctx context.Context, txHash string) (status RelayStatus, ok bool, err error)
+}
+
+// RegisterRelay adds the relay API to mux, behind "Authorization: Bearer <token>":
+// POST /relay/withdrawals queues a RelayRequest and GET /relay/withdrawals/{txHash}
+// reports on it
+func RegisterRelay(mux *http.ServeMux, token string, relayer Relayer) {
+	mux.Handle("POST /relay/withdrawals", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		var req RelayRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body: " + err.Error()})
+			return
+		}
+		switch err := relayer.SubmitWithdrawal(req); {
+		case errors.Is(err, ErrInvalidRelayRequest):
+			writeError(w, http.StatusBadRequest, err)
+		case errors.Is(err, ErrAlreadyTracked):
+			writeError(w, http.StatusConflict, err)
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusAccepted, map[string]string{"txHash": req.TxHash, "state": "queued"})
+		}
+	}))
+	mux.Handle("GET /relay/withdrawals/{txHash}", requireToken(token, func(w http.ResponseWriter, r *http.Request) {
+		txHash, ok := pathTxHash(w, r)
+		if !ok {
+			return
+		}
+		status, ok, err := relayer.RelayStatus(r.Context(), txHash)
+		switch {
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		case !ok:
+			writeJSON(w, http.StatusNotFound, errorResponse{Error: "no relay request for " + txHash})
+		default:
+			writeJSON(w, http.StatusOK, status)
+		}
+	}))
+}
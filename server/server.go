@@ -0,0 +1,404 @@
+// Package server exposes CrossChainMessenger over HTTP so withdrawals can be checked,
+// proven and finalized from a dashboard instead of the CLI
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+	"mantle-claim-crossing/internal/version"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Server routes API requests to a messenger
+type Server struct {
+	messenger *crosschain.CrossChainMessenger
+	token     string
+	logger    crosschain.Logger
+	jobs      *jobStore
+	ctx       context.Context // Parent of all jobs; canceled on shutdown
+
+	// StatusConcurrency is how many withdrawals POST /withdrawals/status reads at once;
+	// 0 means crosschain.DefaultStatusConcurrency
+	StatusConcurrency int
+
+	// StoredTimeline, when set, returns the stages a status store recorded for a
+	// withdrawal; status responses merge them over the timeline read from chain
+	StoredTimeline func(ctx context.Context, txHash string) (crosschain.Timeline, error)
+}
+
+// StatusResponse is returned by GET /withdrawals/{txHash}/status
+type StatusResponse struct {
+	TxHash              string                       `json:"txHash"`
+	Status              int                          `json:"status"`
+	OutputProposed      bool                         `json:"outputProposed"`
+	LatestProposedBlock uint64                       `json:"latestProposedBlock"`
+	ChallengePassed     bool                         `json:"challengePassed"`
+	PortalPaused        bool                         `json:"portalPaused"`
+	OptimisticMode      bool                         `json:"optimisticMode"`
+	FinalizationPeriod  int64                        `json:"finalizationPeriodSeconds"`
+	ProvenAt            *time.Time                   `json:"provenAt,omitempty"`
+	FinalizeAt          *time.Time                   `json:"finalizeAt,omitempty"`
+	Provenance          *crosschain.ProofProvenance  `json:"provenance,omitempty"`
+	NeedsReprove        bool                         `json:"needsReprove,omitempty"`
+	NextAction          string                       `json:"nextAction"`
+	Reason              string                       `json:"reason"`
+	Command             string                       `json:"command,omitempty"`
+	ConfirmationsNeeded uint64                       `json:"confirmationsNeeded,omitempty"` // L2 blocks still needed (L2_CONFIRMATIONS)
+	ExplorerURL         string                       `json:"explorerUrl,omitempty"`         // L2 transaction on the Mantle explorer
+	ProveTxURL          string                       `json:"proveTxExplorerUrl,omitempty"`  // Provenance.ProveTxHash on the L1 explorer
+	Timeline            crosschain.Timeline          `json:"timeline"`                      // When the withdrawal reached each stage
+	Values              *crosschain.WithdrawalValues `json:"values,omitempty"`              // MNT, ETH and bridged token amounts, raw and formatted
+}
+
+// maxBatchStatusHashes caps the hashes one POST /withdrawals/status may ask for
+const maxBatchStatusHashes = 1000
+
+// BatchStatusResponse is returned by POST /withdrawals/status. Every distinct hash is in
+// one of the maps: its status, or why it couldn't be read.
+type BatchStatusResponse struct {
+	Statuses map[string]crosschain.MessageStatusReport `json:"statuses"`
+	Errors   map[string]string                         `json:"errors"`
+}
+
+// errorResponse is the body of every non-2xx response
+type errorResponse struct {
+	Error        string `json:"error"`
+	RevertReason string `json:"revertReason,omitempty"`
+}
+
+// New creates a Server. Every endpoint except /healthz requires "Authorization: Bearer
+// <token>". Jobs run under ctx, so canceling it aborts in-flight prove/finalize waits.
+func New(ctx context.Context, messenger *crosschain.CrossChainMessenger, token string, logger crosschain.Logger) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("API token is not set")
+	}
+	if logger == nil {
+		logger = crosschain.NopLogger()
+	}
+	return &Server{
+		messenger: messenger,
+		token:     token,
+		logger:    logger,
+		jobs:      newJobStore(),
+		ctx:       ctx,
+	}, nil
+}
+
+// Handler returns the HTTP handler with all routes registered
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /metrics", MetricsHandler(s.messenger.RateLimitStats))
+	mux.Handle("GET /withdrawals/{txHash}/status", s.authorized(s.handleStatus))
+	mux.Handle("POST /withdrawals/status", s.authorized(s.handleBatchStatus))
+	mux.Handle("POST /withdrawals/{txHash}/prove", s.authorized(s.handleSubmit("prove", s.messenger.ProveMessage)))
+	mux.Handle("POST /withdrawals/{txHash}/finalize", s.authorized(s.handleSubmit("finalize", s.messenger.FinalizeMessage)))
+	mux.Handle("GET /withdrawals/{txHash}/prove/calldata", s.authorized(s.handleCalldata(s.messenger.BuildProveCalldata)))
+	mux.Handle("GET /withdrawals/{txHash}/finalize/calldata", s.authorized(s.handleCalldata(s.messenger.BuildFinalizeCalldata)))
+	mux.Handle("GET /jobs/{id}", s.authorized(s.handleJob))
+	mux.Handle("GET /jobs/{id}/events", s.authorized(s.handleJobEvents))
+	return mux
+}
+
+// authorized rejects requests without the configured bearer token
+func (s *Server) authorized(next http.HandlerFunc) http.Handler {
+	return requireToken(s.token, next)
+}
+
+// requireToken rejects requests without "Authorization: Bearer <want>"
+func requireToken(want string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(want)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "missing or invalid bearer token"})
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "version": version.BuildInfo().Version})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	txHash, ok := pathTxHash(w, r)
+	if !ok {
+		return
+	}
+
+	rec, state, err := s.messenger.RecommendNextAction(r.Context(), txHash)
+	if errors.Is(err, crosschain.ErrNoWithdrawalFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		s.logger.Warnf("⚠️  API status for %s failed: %v", txHash, err)
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	resp := StatusResponse{
+		TxHash:              txHash,
+		Status:              state.Status,
+		OutputProposed:      state.OutputProposed,
+		LatestProposedBlock: state.LatestProposedBlock,
+		ChallengePassed:     state.ChallengePassed,
+		PortalPaused:        state.PortalPaused,
+		OptimisticMode:      state.Finalization.Optimistic,
+		FinalizationPeriod:  int64(state.Finalization.Period / time.Second),
+		Provenance:          state.Provenance,
+		NeedsReprove:        state.NeedsReprove,
+		NextAction:          string(rec.Action),
+		Reason:              rec.Reason,
+		Command:             rec.Command,
+		ConfirmationsNeeded: state.ConfirmationsNeeded,
+		ExplorerURL:         s.messenger.Explorer.L2Tx(txHash),
+	}
+	if p := state.Provenance; p != nil && p.ProveTxHash != (common.Hash{}) {
+		resp.ProveTxURL = s.messenger.Explorer.L1Tx(p.ProveTxHash.Hex())
+	}
+	if !state.ProvenAt.IsZero() {
+		resp.ProvenAt = &state.ProvenAt
+		resp.FinalizeAt = &state.FinalizeAt
+	}
+	resp.Timeline = s.timeline(r.Context(), txHash)
+	if message, err := s.messenger.GetMessageLocal(r.Context(), txHash); err != nil {
+		s.logger.Warnf("⚠️  API values for %s failed: %v", txHash, err)
+	} else {
+		values := s.messenger.WithdrawalValues(r.Context(), message)
+		resp.Values = &values
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleBatchStatus reads the status of every withdrawal in the JSON array body at once
+func (s *Server) handleBatchStatus(w http.ResponseWriter, r *http.Request) {
+	var txHashes []string
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err := decoder.Decode(&txHashes); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body, expected an array of transaction hashes: " + err.Error()})
+		return
+	}
+	if len(txHashes) == 0 || len(txHashes) > maxBatchStatusHashes {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("expected 1 to %d transaction hashes, got %d", maxBatchStatusHashes, len(txHashes))})
+		return
+	}
+	for i, txHash := range txHashes {
+		if err := crosschain.ValidateTxHash(txHash); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("entry %d: %w", i+1, err))
+			return
+		}
+	}
+
+	statuses, errs := s.messenger.GetMessageStatusBatch(r.Context(), txHashes, s.StatusConcurrency)
+	resp := BatchStatusResponse{Statuses: statuses, Errors: make(map[string]string, len(errs))}
+	for txHash, err := range errs {
+		resp.Errors[txHash] = err.Error()
+	}
+	if len(errs) > 0 {
+		s.logger.Warnf("⚠️  API batch status: %d of %d withdrawal(s) failed", len(errs), len(statuses)+len(errs))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// timeline is txHash's timeline from chain data with StoredTimeline's stages merged
+// over it; a failed lookup is logged and leaves out what it would have added
+func (s *Server) timeline(ctx context.Context, txHash string) crosschain.Timeline {
+	var recorded crosschain.Timeline
+	if s.StoredTimeline != nil {
+		var err error
+		if recorded, err = s.StoredTimeline(ctx, txHash); err != nil {
+			s.logger.Warnf("⚠️  Failed to read the recorded timeline of %s: %v", txHash, err)
+		}
+	}
+	reconstructed, err := s.messenger.WithdrawalTimeline(ctx, txHash)
+	if err != nil {
+		s.logger.Warnf("⚠️  Failed to reconstruct the timeline of %s: %v", txHash, err)
+	}
+	timeline := recorded.Merge(reconstructed)
+	if timeline == nil {
+		timeline = crosschain.Timeline{}
+	}
+	return timeline
+}
+
+// handleSubmit starts operation as a background job and answers 202 with the job
+func (s *Server) handleSubmit(operation string, run func(ctx context.Context, txHash string, messageIndex int, opts crosschain.SubmitOptions) (common.Hash, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		txHash, ok := pathTxHash(w, r)
+		if !ok {
+			return
+		}
+		if !s.messenger.HasSigner() {
+			writeError(w, http.StatusConflict, crosschain.ErrNoSigner)
+			return
+		}
+		// Optional ?from= picks one of the configured wallets
+		var from common.Address
+		if v := r.URL.Query().Get("from"); v != "" {
+			if !common.IsHexAddress(v) {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid from address"})
+				return
+			}
+			from = common.HexToAddress(v)
+		}
+
+		job, created := s.jobs.start(s.ctx, operation, txHash, func(ctx context.Context, opts crosschain.SubmitOptions) error {
+			s.logger.Infof("🌐 API %s started for %s", operation, txHash)
+			opts.From = from
+			_, err := run(ctx, txHash, 0, opts)
+			if err != nil && !crosschain.IsExternallyCompleted(err) && !errors.Is(err, crosschain.ErrAlreadyFinalized) {
+				s.logger.Errorf("❌ API %s for %s failed: %v", operation, txHash, err)
+			}
+			return err
+		})
+		if !created {
+			s.logger.Infof("🌐 API %s for %s already running as job %s", operation, txHash, job.ID)
+		}
+		w.Header().Set("Location", "/jobs/"+job.ID)
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// handleCalldata returns the unsigned prove/finalize call built by build, for signing
+// outside the server. Withdrawals that aren't ready for the call answer 409.
+func (s *Server) handleCalldata(build func(ctx context.Context, txHash string, messageIndex int) (*crosschain.UnsignedTx, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		txHash, ok := pathTxHash(w, r)
+		if !ok {
+			return
+		}
+
+		// Optional ?from= sets the sender gas is estimated from
+		ctx := r.Context()
+		if v := r.URL.Query().Get("from"); v != "" {
+			from, err := crosschain.ParseSimulationFrom("from", v)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			ctx = crosschain.WithSimulationFrom(ctx, from)
+		}
+
+		tx, err := build(ctx, txHash, 0)
+		if err != nil {
+			code := http.StatusBadGateway
+			switch {
+			case isNotReady(err):
+				code = http.StatusConflict
+			case errors.Is(err, crosschain.ErrNoWithdrawalFound):
+				code = http.StatusNotFound
+			}
+			writeError(w, code, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, tx)
+	}
+}
+
+// isNotReady reports whether err means the withdrawal is in the wrong state for the call
+func isNotReady(err error) bool {
+	return errors.Is(err, crosschain.ErrAlreadyFinalized) ||
+		errors.Is(err, crosschain.ErrNotProven) ||
+		errors.Is(err, crosschain.ErrChallengePeriodActive) ||
+		errors.Is(err, crosschain.ErrOutputNotProposed)
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.jobs.get(r.PathValue("id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "job not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleJobEvents streams the job's progress as server-sent events: a "progress" event
+// per stage, including the ones already recorded, then a "done" event carrying the
+// finished job, after which the stream ends
+func (s *Server) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	job, changed, ok := s.jobs.watch(r.PathValue("id"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "job not found"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Build-Version", version.BuildInfo().Short())
+	w.WriteHeader(http.StatusOK)
+	flusher := http.NewResponseController(w)
+
+	sent := 0 // Events recorded so far, counting ones the job has since dropped
+	for {
+		unsent := min(job.progressCount-sent, len(job.Progress))
+		for _, event := range job.Progress[len(job.Progress)-unsent:] {
+			if writeEvent(w, "progress", event) != nil {
+				return
+			}
+		}
+		sent = job.progressCount
+		if job.FinishedAt != nil {
+			_ = writeEvent(w, "done", job)
+			_ = flusher.Flush()
+			return
+		}
+		if flusher.Flush() != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+		if job, changed, ok = s.jobs.watch(job.ID); !ok {
+			return
+		}
+	}
+}
+
+// writeEvent writes body as one server-sent event named event
+func writeEvent(w http.ResponseWriter, event string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// pathTxHash reads and validates the {txHash} path segment
+func pathTxHash(w http.ResponseWriter, r *http.Request) (string, bool) {
+	txHash := r.PathValue("txHash")
+	if err := crosschain.ValidateTxHash(txHash); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return "", false
+	}
+	return txHash, true
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	resp := errorResponse{Error: err.Error()}
+	if reason, ok := crosschain.RevertReason(err); ok {
+		resp.RevertReason = reason
+	}
+	writeJSON(w, code, resp)
+}
+
+// writeJSON answers with body; every response carries the build in X-Build-Version
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Build-Version", version.BuildInfo().Short())
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // Pure-Go driver, registered as "sqlite"; no cgo needed
+)
+
+// migrations are applied in order on open; each runs once, tracked in schema_version.
+// Append new ones; never edit a released step.
+var migrations = []string{
+	`CREATE TABLE withdrawals (
+		tx_hash        TEXT PRIMARY KEY,
+		label          TEXT NOT NULL DEFAULT '',
+		status         TEXT NOT NULL COLLATE NOCASE,
+		next_action    TEXT NOT NULL DEFAULT '',
+		next_action_at INTEGER NOT NULL DEFAULT 0,
+		updated_at     INTEGER NOT NULL
+	)`,
+	`CREATE INDEX withdrawals_status ON withdrawals (status)`,
+	`ALTER TABLE withdrawals ADD COLUMN finalized_at INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE withdrawals ADD COLUMN fees_wei TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE withdrawals ADD COLUMN last_error TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE withdrawals ADD COLUMN timeline TEXT NOT NULL DEFAULT ''`,
+}
+
+// SQLite is a StatusStore backed by a SQLite database file
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens (creating if needed) the database at path and brings its schema up
+// to date
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite store %s: %w", path, err)
+	}
+	// One connection: writes are serialized anyway and a busy database fails fast
+	db.SetMaxOpenConns(1)
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate SQLite store %s: %w", path, err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+// migrate applies the migrations the database hasn't seen yet, each in its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT version FROM schema_version`).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := db.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (0)`); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE schema_version SET version = ?`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Get implements StatusStore
+func (s *SQLite) Get(ctx context.Context, txHash string) (Record, bool, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+recordColumns+` FROM withdrawals WHERE tx_hash = ?`, txHash)
+	rec, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, fmt.Errorf("failed to read %s: %w", txHash, err)
+	}
+	return rec, true, nil
+}
+
+// Upsert implements StatusStore
+func (s *SQLite) Upsert(ctx context.Context, rec Record) error {
+	var feesWei, timeline string
+	if rec.FeesWei != nil {
+		feesWei = rec.FeesWei.String()
+	}
+	if len(rec.Timeline) > 0 {
+		data, err := json.Marshal(rec.Timeline)
+		if err != nil {
+			return fmt.Errorf("failed to encode the timeline of %s: %w", rec.TxHash, err)
+		}
+		timeline = string(data)
+	}
+	_, err := s.db.ExecContext(ctx, `INSERT INTO withdrawals (`+recordColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tx_hash) DO UPDATE SET
+			label = excluded.label,
+			status = excluded.status,
+			next_action = excluded.next_action,
+			next_action_at = excluded.next_action_at,
+			finalized_at = excluded.finalized_at,
+			fees_wei = excluded.fees_wei,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at,
+			timeline = excluded.timeline`,
+		rec.TxHash, rec.Label, rec.Status, rec.NextAction, unixOrZero(rec.NextActionAt),
+		unixOrZero(rec.FinalizedAt), feesWei, rec.LastError, unixOrZero(rec.UpdatedAt), timeline)
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", rec.TxHash, err)
+	}
+	return nil
+}
+
+// ListByStatus implements StatusStore
+func (s *SQLite) ListByStatus(ctx context.Context, statuses ...string) ([]Record, error) {
+	query := `SELECT ` + recordColumns + ` FROM withdrawals`
+	args := make([]any, len(statuses))
+	if len(statuses) > 0 {
+		query += ` WHERE status IN (?` + strings.Repeat(`, ?`, len(statuses)-1) + `)`
+		for i, status := range statuses {
+			args[i] = status
+		}
+	}
+	query += ` ORDER BY tx_hash`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list withdrawals: %w", err)
+	}
+	defer rows.Close()
+	var list []Record
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read withdrawal: %w", err)
+		}
+		list = append(list, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list withdrawals: %w", err)
+	}
+	return list, nil
+}
+
+// Close implements StatusStore
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// recordColumns are the withdrawals columns in the order scanRecord reads them
+const recordColumns = `tx_hash, label, status, next_action, next_action_at, finalized_at, fees_wei, last_error, updated_at, timeline`
+
+// scanRecord reads one withdrawals row selected with recordColumns
+func scanRecord(row interface{ Scan(...any) error }) (Record, error) {
+	var rec Record
+	var nextActionAt, finalizedAt, updatedAt int64
+	var feesWei, timeline string
+	err := row.Scan(&rec.TxHash, &rec.Label, &rec.Status, &rec.NextAction, &nextActionAt,
+		&finalizedAt, &feesWei, &rec.LastError, &updatedAt, &timeline)
+	if err != nil {
+		return rec, err
+	}
+	rec.NextActionAt = timeOrZero(nextActionAt)
+	rec.FinalizedAt = timeOrZero(finalizedAt)
+	rec.UpdatedAt = timeOrZero(updatedAt)
+	if feesWei != "" {
+		fees, ok := new(big.Int).SetString(feesWei, 10)
+		if !ok {
+			return rec, fmt.Errorf("invalid fees_wei %q for %s", feesWei, rec.TxHash)
+		}
+		rec.FeesWei = fees
+	}
+	if timeline != "" {
+		if err := json.Unmarshal([]byte(timeline), &rec.Timeline); err != nil {
+			return rec, fmt.Errorf("invalid timeline for %s: %w", rec.TxHash, err)
+		}
+	}
+	return rec, nil
+}
+
+// unixOrZero stores a zero time as 0 rather than a large negative number
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeOrZero is the inverse of unixOrZero
+func timeOrZero(unix int64) time.Time {
+	if unix == 0 {
+		return time.Time{}
+	}
+	return time.Unix(unix, 0)
+}
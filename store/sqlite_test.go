@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+)
+
+func openTestStore(t *testing.T) *SQLite {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "status.db")
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func schemaVersion(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var version int
+	if err := db.QueryRow(`SELECT version FROM schema_version`).Scan(&version); err != nil {
+		t.Fatalf("read schema_version: %v", err)
+	}
+	return version
+}
+
+func TestOpenSQLiteMigratesFromVersionZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.db")
+	// A database created by a build that recorded version 0 but never got to a migration
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`CREATE TABLE schema_version (version INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(`INSERT INTO schema_version (version) VALUES (0)`); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer s.Close()
+	if got := schemaVersion(t, s.db); got != len(migrations) {
+		t.Fatalf("schema version %d, want %d", got, len(migrations))
+	}
+	rec := Record{TxHash: "0xabc", Status: "PROVEN", FeesWei: big.NewInt(7), UpdatedAt: time.Unix(1700000000, 0)}
+	if err := s.Upsert(context.Background(), rec); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+}
+
+func TestOpenSQLiteFinishesPartialMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.db")
+	// A database from a build that only knew the first three migrations, with a row in it
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := append([]string{`CREATE TABLE schema_version (version INTEGER NOT NULL)`,
+		`INSERT INTO schema_version (version) VALUES (3)`}, migrations[:3]...)
+	stmts = append(stmts, `INSERT INTO withdrawals (tx_hash, label, status, next_action, next_action_at, updated_at, finalized_at)
+		VALUES ('0xold', 'payroll', 'FINALIZED', 'none', 0, 1700000000, 1700000500)`)
+	for _, stmt := range stmts {
+		if _, err := raw.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	raw.Close()
+
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer s.Close()
+	if got := schemaVersion(t, s.db); got != len(migrations) {
+		t.Fatalf("schema version %d, want %d", got, len(migrations))
+	}
+	rec, ok, err := s.Get(context.Background(), "0xold")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	want := Record{TxHash: "0xold", Label: "payroll", Status: "FINALIZED", NextAction: "none",
+		FinalizedAt: time.Unix(1700000500, 0), UpdatedAt: time.Unix(1700000000, 0)}
+	if !reflect.DeepEqual(rec, want) {
+		t.Fatalf("migrated record\n got %+v\nwant %+v", rec, want)
+	}
+
+	// Reopening applies nothing
+	s.Close()
+	s, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+}
+
+func TestOpenSQLiteRejectsNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.db")
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.Exec(`CREATE TABLE schema_version (version INTEGER NOT NULL)`)
+	raw.Exec(`INSERT INTO schema_version (version) VALUES (?)`, len(migrations)+1)
+	raw.Close()
+
+	if s, err := OpenSQLite(path); err == nil {
+		s.Close()
+		t.Fatal("OpenSQLite accepted a schema from a newer build")
+	}
+}
+
+func TestSQLiteUpsertReplacesOnConflict(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+
+	first := Record{TxHash: "0xabc", Label: "a", Status: "READY_TO_PROVE", NextAction: "prove",
+		LastError: "rpc timeout", FeesWei: big.NewInt(100), UpdatedAt: time.Unix(1700000000, 0)}
+	if err := s.Upsert(ctx, first); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	second := Record{TxHash: "0xabc", Label: "b", Status: "PROVEN", NextAction: "wait-for-challenge-period",
+		NextActionAt: time.Unix(1700600000, 0), FeesWei: big.NewInt(250), UpdatedAt: time.Unix(1700000100, 0),
+		Timeline: crosschain.Timeline{{Stage: crosschain.StageProveMined, At: time.Unix(1700000050, 0).UTC(), Chain: "L1", TxHash: "0xdef", Block: 9}}}
+	if err := s.Upsert(ctx, second); err != nil {
+		t.Fatalf("Upsert on conflict: %v", err)
+	}
+
+	got, ok, err := s.Get(ctx, "0xabc")
+	if err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+	if !reflect.DeepEqual(got, second) {
+		t.Fatalf("after conflict\n got %+v\nwant %+v", got, second)
+	}
+	list, err := s.ListByStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("%d rows after upserting the same hash twice, want 1", len(list))
+	}
+}
+
+func TestSQLiteListByStatus(t *testing.T) {
+	s := openTestStore(t)
+	ctx := context.Background()
+	for _, rec := range []Record{
+		{TxHash: "0x03", Status: "FINALIZED"},
+		{TxHash: "0x01", Status: "READY_TO_PROVE"},
+		{TxHash: "0x02", Status: "PROVEN"},
+		{TxHash: "0x04", Status: "PROVEN"},
+	} {
+		if err := s.Upsert(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		statuses []string
+		want     []string
+	}{
+		{"no filter", nil, []string{"0x01", "0x02", "0x03", "0x04"}},
+		{"one status", []string{"PROVEN"}, []string{"0x02", "0x04"}},
+		{"several statuses", []string{"READY_TO_PROVE", "FINALIZED"}, []string{"0x01", "0x03"}},
+		{"case-insensitive", []string{"proven"}, []string{"0x02", "0x04"}},
+		{"no match", []string{"UNKNOWN"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := s.ListByStatus(ctx, tt.statuses...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, rec := range list {
+				got = append(got, rec.TxHash)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ListByStatus(%v) = %v, want %v", tt.statuses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSQLiteGetMissing(t *testing.T) {
+	s := openTestStore(t)
+	if _, ok, err := s.Get(context.Background(), "0xmissing"); ok || err != nil {
+		t.Fatalf("Get = %v, %v; want not found", ok, err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package store keeps the last known status of each tracked withdrawal
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	crosschain "mantle-claim-crossing/cross_chain"
+)
+
+// ErrUnknownBackend is returned by Open for a spec it doesn't recognize
+var ErrUnknownBackend = errors.New("unknown status store")
+
+// Record is the last known status of one withdrawal
+type Record struct {
+	TxHash       string    `json:"txHash"`                 // L2 withdrawal transaction
+	Label        string    `json:"label,omitempty"`        // Name from the config file
+	Status       string    `json:"status"`                 // READY_TO_PROVE, PROVEN or FINALIZED
+	NextAction   string    `json:"nextAction"`             // Recommended action, e.g. "prove" or "wait-for-challenge-period"
+	NextActionAt time.Time `json:"nextActionAt,omitempty"` // When the next action can run; zero when it can run now or isn't known
+	FinalizedAt  time.Time `json:"finalizedAt,omitempty"`  // When the scheduler saw it finalized, by us or someone else
+	FeesWei      *big.Int  `json:"feesWei,omitempty"`      // L1 fees our proves and finalizes paid for it; nil for none
+	LastError    string    `json:"lastError,omitempty"`    // Why the last check failed; empty after a successful one
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	Timeline crosschain.Timeline `json:"timeline,omitempty"` // When it reached each stage, as seen or reconstructed
+}
+
+// StatusStore persists withdrawal records
+type StatusStore interface {
+	// Get returns the record of txHash; ok is false when there is none
+	Get(ctx context.Context, txHash string) (rec Record, ok bool, err error)
+	// Upsert inserts rec or replaces the record with the same TxHash
+	Upsert(ctx context.Context, rec Record) error
+	// ListByStatus returns the records with one of statuses, or every record when none
+	// are given, ordered by TxHash
+	ListByStatus(ctx context.Context, statuses ...string) ([]Record, error)
+	Close() error
+}
+
+// Open returns the store named by spec: "json" (or empty) for a Memory store the
+// scheduler persists in its state file, or "sqlite:PATH" for a SQLite database
+func Open(spec string) (StatusStore, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "", "json":
+		return NewMemory(nil), nil
+	case "sqlite":
+		if path == "" {
+			return nil, fmt.Errorf("%w %q: expected sqlite:PATH", ErrUnknownBackend, spec)
+		}
+		return OpenSQLite(path)
+	default:
+		return nil, fmt.Errorf("%w %q: use json or sqlite:PATH", ErrUnknownBackend, spec)
+	}
+}
+
+// Memory is the default StatusStore: records live in memory and the caller saves
+// Records wherever it keeps its own state
+type Memory struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// NewMemory returns a Memory store holding records, e.g. as loaded from a state file
+func NewMemory(records map[string]Record) *Memory {
+	m := &Memory{records: make(map[string]Record, len(records))}
+	for hash, rec := range records {
+		m.records[hash] = rec
+	}
+	return m
+}
+
+// Get implements StatusStore
+func (m *Memory) Get(_ context.Context, txHash string) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.records[txHash]
+	return rec, ok, nil
+}
+
+// Upsert implements StatusStore
+func (m *Memory) Upsert(_ context.Context, rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[rec.TxHash] = rec
+	return nil
+}
+
+// ListByStatus implements StatusStore
+func (m *Memory) ListByStatus(_ context.Context, statuses ...string) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []Record
+	for _, rec := range m.records {
+		if matchesStatus(rec.Status, statuses) {
+			list = append(list, rec)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].TxHash < list[j].TxHash })
+	return list, nil
+}
+
+// Records returns a copy of every record, keyed by TxHash, for saving
+func (m *Memory) Records() map[string]Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := make(map[string]Record, len(m.records))
+	for hash, rec := range m.records {
+		records[hash] = rec
+	}
+	return records
+}
+
+// Close implements StatusStore; there is nothing to release
+func (m *Memory) Close() error {
+	return nil
+}
+
+// matchesStatus reports whether status is one of statuses; an empty list matches all
+func matchesStatus(status string, statuses []string) bool {
+	if len(statuses) == 0 {
+		return true
+	}
+	for _, s := range statuses {
+		if strings.EqualFold(s, status) {
+			return true
+		}
+	}
+	return false
+}